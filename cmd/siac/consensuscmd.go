@@ -28,25 +28,32 @@ func consensuscmd() {
 		die("Could not get current consensus state:", err)
 	}
 	if cg.Synced {
+		avgBlockTime := time.Duration(cg.AverageBlockTime) * time.Second
 		fmt.Printf(`Synced: %v
 Block:      %v
 Height:     %v
 Target:     %v
 Difficulty: %v
-`, yesNo(cg.Synced), cg.CurrentBlock, cg.Height, cg.Target, cg.Difficulty)
+Block Time: %v (average of the last %v blocks)
+`, yesNo(cg.Synced), cg.CurrentBlock, cg.Height, cg.Target, cg.Difficulty, avgBlockTime, cg.BlockTimeWindow)
 	} else {
-		estimatedHeight := estimatedHeightAt(time.Now())
-		estimatedProgress := float64(cg.Height) / float64(estimatedHeight) * 100
-		if estimatedProgress > 100 {
-			estimatedProgress = 100
-		}
 		fmt.Printf(`Synced: %v
 Height: %v
 Progress (estimated): %.1f%%
-`, yesNo(cg.Synced), cg.Height, estimatedProgress)
+`, yesNo(cg.Synced), cg.Height, estimatedProgress(cg.Height, time.Now()))
 	}
 }
 
+// estimatedProgress returns the estimated sync progress, as a percentage, of a
+// node at the given height at time t.
+func estimatedProgress(height types.BlockHeight, t time.Time) float64 {
+	progress := float64(height) / float64(estimatedHeightAt(t)) * 100
+	if progress > 100 {
+		progress = 100
+	}
+	return progress
+}
+
 // estimatedHeightAt returns the estimated block height for the given time.
 // Block height is estimated by calculating the minutes since a known block in
 // the past and dividing by 10 minutes (the block time).
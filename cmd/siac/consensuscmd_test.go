@@ -47,3 +47,23 @@ func TestEstimatedHeightAt(t *testing.T) {
 		}
 	}
 }
+
+// TestEstimatedProgress tests that the estimated sync progress is relative to
+// the estimated height and never exceeds 100%.
+func TestEstimatedProgress(t *testing.T) {
+	block100k := time.Date(2017, time.April, 13, 23, 29, 49, 0, time.UTC)
+	tests := []struct {
+		height   types.BlockHeight
+		expected float64
+	}{
+		{0, 0},
+		{50e3, 50},
+		{100e3, 100},
+		{150e3, 100},
+	}
+	for _, tt := range tests {
+		if p := estimatedProgress(tt.height, block100k); p != tt.expected {
+			t.Errorf("expected progress of %v at height %v, got %v", tt.expected, tt.height, p)
+		}
+	}
+}
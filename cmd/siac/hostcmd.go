@@ -5,8 +5,10 @@ import (
 	"math/big"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/node/api"
@@ -15,6 +17,10 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// recentEarningsWindow is the number of blocks over which recent host earnings
+// are reported, roughly 30 days.
+const recentEarningsWindow = 30 * 144
+
 var (
 	hostAnnounceCmd = &cobra.Command{
 		Use:   "announce",
@@ -25,7 +31,12 @@ You can revert this by running:
 	siac host config acceptingcontracts false
 You may also supply a specific address to be announced, e.g.:
 	siac host announce my-host-domain.com:9001
-Doing so will override the standard connectivity checks.`,
+Doing so will override the automatically discovered address. The port may
+differ from the one siad is listening on, e.g. when a router forwards an
+external port to the host.
+Before announcing, the host checks that it can be reached at the address,
+whether supplied or discovered. Use --force to skip this check if your router
+cannot connect to its own external address.`,
 		Run: hostannouncecmd,
 	}
 
@@ -48,22 +59,40 @@ Available settings:
      maxrevisebatchsize:   bytes
      netaddress:           string
      windowsize:           blocks
-
-     collateral:       currency
-     collateralbudget: currency
-     maxcollateral:    currency
+     proofwindowbuffer:    blocks
+     maxopenconns:         connections
+     connidletimeout:      duration
+     connreadtimeout:      duration
+     connwritetimeout:     duration
+     maxrenterconns:       connections
+     maxbandwidth:         bytes / second (0 for no limit)
+     dataretention:        none, blocks, or untilfull
+     dataretentionblocks:  blocks
+     minannounceinterval:  duration
+     maxannouncefees:      currency
+     announcefeeperiod:    duration
+
+     collateral:                 currency
+     collateralbudget:           currency
+     collateralreservetarget:    currency
+     collateralreservethreshold: currency
+     maxcollateral:              currency
 
      mincontractprice:          currency
+     mincontractvalue:          currency
      mindownloadbandwidthprice: currency / TB
      minstorageprice:           currency / TB / Month
      minuploadbandwidthprice:   currency / TB
 
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
-Durations (maxduration and windowsize) must be specified in either blocks (b),
+Durations (maxduration, windowsize, proofwindowbuffer and dataretentionblocks) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
+Connection timeouts (connidletimeout, connreadtimeout and connwritetimeout) are
+specified as durations such as 30s or 2m.
+
 For a description of each parameter, see doc/API.md.
 
 To configure the host to accept new contracts, set acceptingcontracts to true:
@@ -79,6 +108,15 @@ To configure the host to accept new contracts, set acceptingcontracts to true:
 		Run:   wrap(hostfolderaddcmd),
 	}
 
+	hostErrorsCmd = &cobra.Command{
+		Use:   "errors",
+		Short: "Show recent failed RPCs",
+		Long: `Show the most recent RPC calls to the host that failed, newest first. This
+can help diagnose why renters are unable to form contracts with, upload to, or
+download from the host.`,
+		Run: wrap(hosterrorscmd),
+	}
+
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
 		Short: "Add, remove, or resize a storage folder",
@@ -103,6 +141,25 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostDecommissionCmd = &cobra.Command{
+		Use:   "decommission [deadline]",
+		Short: "Permanently retire the host",
+		Long: `Permanently retire the host. The host will reject new contracts, renewals and
+uploads, and will tell renters that it serves downloads until the block height
+given by deadline, so that they can move their data to other hosts. Running
+the command again changes the deadline.`,
+		Run: wrap(hostdecommissioncmd),
+	}
+
+	hostMaintenanceCmd = &cobra.Command{
+		Use:   "maintenance [true|false]",
+		Short: "Enable or disable maintenance mode",
+		Long: `Enable or disable maintenance mode. While in maintenance mode the host will
+reject new contracts, renewals and uploads, but will continue to serve
+downloads and submit storage proofs for existing contracts.`,
+		Run: wrap(hostmaintenancecmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
 		Short: "Add or delete a sector (add not supported)",
@@ -132,6 +189,21 @@ func hostcmd() {
 	if err != nil {
 		die("Could not fetch storage info:", err)
 	}
+	// fetch the revenue earned over the last month
+	var cg api.ConsensusGET
+	err = getAPI("/consensus", &cg)
+	if err != nil {
+		die("Could not fetch consensus info:", err)
+	}
+	var since types.BlockHeight
+	if cg.Height > recentEarningsWindow {
+		since = cg.Height - recentEarningsWindow
+	}
+	eg := new(api.HostEarningsGET)
+	err = getAPI(fmt.Sprintf("/host/earnings?since=%v", since), eg)
+	if err != nil {
+		die("Could not fetch host earnings:", err)
+	}
 
 	es := hg.ExternalSettings
 	fm := hg.FinancialMetrics
@@ -175,10 +247,15 @@ func hostcmd() {
 		connectabilityString = "Host is not connectable (re-checks every few minutes)."
 	}
 
+	if hg.Decommission.Decommissioning {
+		fmt.Printf("Host is decommissioning and serves downloads until block %v.\n\n", hg.Decommission.Deadline)
+	}
+
 	if hostVerbose {
 		// describe net address
 		fmt.Printf(`General Info:
 	Connectability Status: %v
+	Maintenance Mode:      %v
 
 Host Internal Settings:
 	acceptingcontracts:   %v
@@ -187,12 +264,23 @@ Host Internal Settings:
 	maxrevisebatchsize:   %v
 	netaddress:           %v
 	windowsize:           %v Hours
-
-	collateral:       %v / TB / Month
-	collateralbudget: %v
-	maxcollateral:    %v Per Contract
+	proofwindowbuffer:    %v Hours
+	maxopenconns:         %v
+	connidletimeout:      %v
+	connreadtimeout:      %v
+	connwritetimeout:     %v
+	maxrenterconns:       %v
+	maxbandwidth:         %v
+	dataretention:        %v
+
+	collateral:                 %v / TB / Month
+	collateralbudget:           %v
+	collateralreservetarget:    %v
+	collateralreservethreshold: %v
+	maxcollateral:              %v Per Contract
 
 	mincontractprice:          %v
+	mincontractvalue:          %v
 	mindownloadbandwidthprice: %v / TB
 	minstorageprice:           %v / TB / Month
 	minuploadbandwidthprice:   %v / TB
@@ -206,15 +294,18 @@ Host Financials:
 	Storage Revenue:           %v
 	Potential Storage Revenue: %v
 
-	Locked Collateral: %v
-	Risked Collateral: %v
-	Lost Collateral:   %v
+	Locked Collateral:  %v
+	Risked Collateral:  %v
+	Lost Collateral:    %v
+	Collateral Reserve: %v
 
 	Download Revenue:           %v
 	Potential Download Revenue: %v
 	Upload Revenue:             %v
 	Potential Upload Revenue:   %v
 
+	Revenue (Last 30 Days): %v
+
 RPC Stats:
 	Error Calls:        %v
 	Unrecognized Calls: %v
@@ -224,18 +315,25 @@ RPC Stats:
 	Settings Calls:     %v
 	FormContract Calls: %v
 `,
-			connectabilityString,
+			connectabilityString, yesNo(hg.Maintenance),
 
 			yesNo(is.AcceptingContracts), periodUnits(is.MaxDuration),
 			filesizeUnits(int64(is.MaxDownloadBatchSize)),
 			filesizeUnits(int64(is.MaxReviseBatchSize)), netaddr,
 			is.WindowSize/6,
+			is.ProofWindowBuffer/6,
+			is.MaxOpenConns, is.ConnIdleTimeout, is.ConnReadTimeout, is.ConnWriteTimeout,
+			is.MaxRenterConns, bandwidthLimit(int64(is.MaxBandwidth)),
+			dataRetention(is),
 
 			currencyUnits(is.Collateral.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.CollateralBudget),
+			currencyUnits(is.CollateralReserveTarget),
+			currencyUnits(is.CollateralReserveThreshold),
 			currencyUnits(is.MaxCollateral),
 
 			currencyUnits(is.MinContractPrice),
+			currencyUnits(is.MinContractValue),
 			currencyUnits(is.MinDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 			currencyUnits(is.MinStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.MinUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
@@ -250,18 +348,22 @@ RPC Stats:
 			currencyUnits(fm.LockedStorageCollateral),
 			currencyUnits(fm.RiskedStorageCollateral),
 			currencyUnits(fm.LostStorageCollateral),
+			currencyUnits(fm.CollateralReserve),
 
 			currencyUnits(fm.DownloadBandwidthRevenue),
 			currencyUnits(fm.PotentialDownloadBandwidthRevenue),
 			currencyUnits(fm.UploadBandwidthRevenue),
 			currencyUnits(fm.PotentialUploadBandwidthRevenue),
 
+			currencyUnits(eg.Earnings),
+
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
 			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
 			nm.FormContractCalls)
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
+	Maintenance Mode:      %v
 
 	Storage:      %v (%v used)
 	Price:        %v / TB / Month
@@ -271,8 +373,9 @@ RPC Stats:
 	Anticipated Revenue:  %v
 	Locked Collateral:    %v
 	Revenue:              %v
+	Revenue (30 Days):    %v
 `,
-			connectabilityString,
+			connectabilityString, yesNo(hg.Maintenance),
 
 			filesizeUnits(int64(totalstorage)),
 			filesizeUnits(int64(totalstorage-storageremaining)), price,
@@ -280,7 +383,7 @@ RPC Stats:
 
 			yesNo(is.AcceptingContracts), currencyUnits(totalPotentialRevenue),
 			currencyUnits(fm.LockedStorageCollateral),
-			currencyUnits(totalRevenue))
+			currencyUnits(totalRevenue), currencyUnits(eg.Earnings))
 	}
 
 	// if wallet is locked print warning
@@ -313,13 +416,35 @@ RPC Stats:
 	w.Flush()
 }
 
+// clampPerByte converts a price per unit into a price per byte by dividing by
+// the number of bytes in the unit. A nonzero price that would round down to
+// zero is clamped to one hasting, so that a host cannot accidentally give away
+// storage or bandwidth.
+func clampPerByte(price, bytesPerUnit types.Currency) types.Currency {
+	c := price.Div(bytesPerUnit)
+	if c.IsZero() && !price.IsZero() {
+		fmt.Println("Warning: price is too small to be represented per byte, rounding up to 1 H / byte")
+		c = types.NewCurrency64(1)
+	}
+	return c
+}
+
+// dataRetention returns a human-readable description of the host's data
+// retention policy.
+func dataRetention(is modules.HostInternalSettings) string {
+	if is.DataRetention == modules.HostRetainBlocks {
+		return fmt.Sprintf("%v blocks", is.DataRetentionBlocks)
+	}
+	return string(is.DataRetention)
+}
+
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
 // Modifies host settings.
 func hostconfigcmd(param, value string) {
 	var err error
 	switch param {
 	// currency (convert to hastings)
-	case "collateralbudget", "maxcollateral", "mincontractprice":
+	case "collateralbudget", "collateralreservetarget", "collateralreservethreshold", "maxcollateral", "mincontractprice", "mincontractvalue", "maxannouncefees":
 		value, err = parseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -332,7 +457,7 @@ func hostconfigcmd(param, value string) {
 			die("Could not parse "+param+":", err)
 		}
 		i, _ := new(big.Int).SetString(hastings, 10)
-		c := types.NewCurrency(i).Div(modules.BytesPerTerabyte)
+		c := clampPerByte(types.NewCurrency(i), modules.BytesPerTerabyte)
 		value = c.String()
 
 	// currency/TB/month (convert to hastings/byte/block)
@@ -342,7 +467,7 @@ func hostconfigcmd(param, value string) {
 			die("Could not parse "+param+":", err)
 		}
 		i, _ := new(big.Int).SetString(hastings, 10)
-		c := types.NewCurrency(i).Div(modules.BlockBytesPerMonthTerabyte)
+		c := clampPerByte(types.NewCurrency(i), modules.BlockBytesPerMonthTerabyte)
 		value = c.String()
 
 	// bool (allow "yes" and "no")
@@ -355,14 +480,23 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// duration (convert to blocks)
-	case "maxduration", "windowsize":
+	case "maxduration", "windowsize", "proofwindowbuffer", "dataretentionblocks":
 		value, err = parsePeriod(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
+	// bytes per second
+	case "maxbandwidth":
+		value, err = parseFilesize(value)
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress",
+		"maxopenconns", "connidletimeout", "connreadtimeout", "connwritetimeout",
+		"maxrenterconns", "dataretention", "minannounceinterval", "announcefeeperiod":
 
 	// invalid settings
 	default:
@@ -394,9 +528,9 @@ func hostannouncecmd(cmd *cobra.Command, args []string) {
 	var err error
 	switch len(args) {
 	case 0:
-		err = post("/host/announce", "")
+		err = post("/host/announce", "force="+strconv.FormatBool(hostAnnounceForce))
 	case 1:
-		err = post("/host/announce", "netaddress="+args[0])
+		err = post("/host/announce", "netaddress="+args[0]+"&force="+strconv.FormatBool(hostAnnounceForce))
 	default:
 		cmd.UsageFunc()(cmd)
 		os.Exit(exitCodeUsage)
@@ -418,6 +552,43 @@ To revert this, run:
 `)
 }
 
+// hostmaintenancecmd is the handler for the command `siac host maintenance
+// [true|false]`. Enables or disables maintenance mode.
+func hostmaintenancecmd(enabled string) {
+	var maintenance bool
+	switch strings.ToLower(enabled) {
+	case "true", "on", "yes":
+		maintenance = true
+	case "false", "off", "no":
+		maintenance = false
+	default:
+		die("Could not parse maintenance value:", enabled)
+	}
+	err := post("/host/maintenance", fmt.Sprintf("enabled=%t", maintenance))
+	if err != nil {
+		die("Could not set maintenance mode:", err)
+	}
+	if maintenance {
+		fmt.Println("Host is now in maintenance mode.")
+	} else {
+		fmt.Println("Host has left maintenance mode.")
+	}
+}
+
+// hostdecommissioncmd is the handler for the command `siac host decommission
+// [deadline]`. Permanently retires the host.
+func hostdecommissioncmd(deadline string) {
+	var height types.BlockHeight
+	if _, err := fmt.Sscan(deadline, &height); err != nil {
+		die("Could not parse deadline:", err)
+	}
+	err := post("/host/decommission", "deadline="+fmt.Sprint(height))
+	if err != nil {
+		die("Could not decommission host:", err)
+	}
+	fmt.Printf("Host is decommissioning and will serve downloads until block %v.\n", height)
+}
+
 // hostfolderaddcmd adds a folder to the host.
 func hostfolderaddcmd(path, size string) {
 	size, err := parseFilesize(size)
@@ -475,3 +646,22 @@ func hostsectordeletecmd(root string) {
 	}
 	fmt.Println("Deleted sector", root)
 }
+
+// hosterrorscmd prints the most recent failed RPC calls to the host.
+func hosterrorscmd() {
+	var heg api.HostErrorsGET
+	err := getAPI("/host/errors", &heg)
+	if err != nil {
+		die("Could not fetch host errors:", err)
+	}
+	if len(heg.Errors) == 0 {
+		fmt.Println("No recent RPC errors.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Time\tRPC\tRenter\tType\tError")
+	for _, e := range heg.Errors {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", e.Time.Format(time.RFC3339), e.RPC, e.RenterAddress, e.Type, e.Error)
+	}
+	w.Flush()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestClampPerByte checks that per-unit prices are converted to per-byte
+// prices without rounding a nonzero price down to zero.
+func TestClampPerByte(t *testing.T) {
+	tests := []struct {
+		price, out types.Currency
+	}{
+		{types.ZeroCurrency, types.ZeroCurrency},
+		{types.NewCurrency64(1), types.NewCurrency64(1)},
+		{modules.BytesPerTerabyte.Sub(types.NewCurrency64(1)), types.NewCurrency64(1)},
+		{modules.BytesPerTerabyte.Mul64(3), types.NewCurrency64(3)},
+	}
+	for _, test := range tests {
+		if out := clampPerByte(test.price, modules.BytesPerTerabyte); !out.Equals(test.out) {
+			t.Errorf("clampPerByte(%v): expected %v, got %v", test.price, test.out, out)
+		}
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -17,12 +20,16 @@ import (
 
 var (
 	// Flags.
-	addr              string // override default API address
-	hostVerbose       bool   // display additional host info
-	initForce         bool   // destroy and reencrypt the wallet on init if it already exists
-	initPassword      bool   // supply a custom password when creating a wallet
-	renterListVerbose bool   // Show additional info about uploaded files.
-	renterShowHistory bool   // Show download history in addition to download queue.
+	addr               string        // override default API address
+	hostVerbose        bool          // display additional host info
+	hostAnnounceForce  bool          // announce without checking reachability
+	initForce          bool          // destroy and reencrypt the wallet on init if it already exists
+	initPassword       bool          // supply a custom password when creating a wallet
+	renterListVerbose  bool          // Show additional info about uploaded files.
+	renterShowHistory  bool          // Show download history in addition to download queue.
+	renterShowProgress bool          // Follow the progress of transfers until they finish.
+	walletAccount      uint64        // account used by wallet address and wallet send siacoins
+	watchInterval      time.Duration // redraw status commands at this interval
 )
 
 var (
@@ -237,6 +244,44 @@ func wrap(fn interface{}) func(*cobra.Command, []string) {
 	}
 }
 
+// clearScreen is the ANSI escape sequence that moves the cursor to the top
+// left of the terminal and clears the screen.
+const clearScreen = "\033[H\033[2J"
+
+// watchable adds a --watch flag to a status command. When the flag is set,
+// the command is re-run at the given interval, redrawing its output in place
+// until siac is interrupted.
+func watchable(cmd *cobra.Command) {
+	cmd.Flags().DurationVarP(&watchInterval, "watch", "w", 0, "Redraw the output at the given interval (e.g. 5s) until interrupted")
+	run := cmd.Run
+	cmd.Run = func(c *cobra.Command, args []string) {
+		if watchInterval == 0 {
+			run(c, args)
+			return
+		} else if watchInterval < 0 {
+			die("Watch interval must be positive")
+		}
+		title := fmt.Sprintf("Every %v: siac %v", watchInterval, strings.Join(os.Args[1:], " "))
+		watch(os.Stdout, title, watchInterval, nil, func() { run(c, args) })
+	}
+}
+
+// watch repeatedly clears the screen and calls draw, waiting interval between
+// each redraw, until stop is closed. The title and the time of the redraw are
+// printed above the output of draw.
+func watch(w io.Writer, title string, interval time.Duration, stop <-chan struct{}, draw func()) {
+	for {
+		fmt.Fprint(w, clearScreen)
+		fmt.Fprintf(w, "%v\t%v\n\n", title, time.Now().Format(time.RFC1123))
+		draw()
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
 // die prints its arguments to stderr, then exits the program with the default
 // error code.
 func die(args ...interface{}) {
@@ -262,10 +307,11 @@ func main() {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostSectorCmd)
+	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostDecommissionCmd, hostErrorsCmd, hostFolderCmd, hostMaintenanceCmd, hostSectorCmd)
 	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
 	hostCmd.Flags().BoolVarP(&hostVerbose, "verbose", "v", false, "Display detailed host info")
+	hostAnnounceCmd.Flags().BoolVarP(&hostAnnounceForce, "force", "f", false, "Announce the address without checking that the host is reachable at it")
 
 	root.AddCommand(hostdbCmd)
 	hostdbCmd.AddCommand(hostdbViewCmd)
@@ -276,14 +322,22 @@ func main() {
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
-		walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd,
-		walletBalanceCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAccountsCmd, walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
+		walletLoadCmd, walletLockCmd, walletPaymentsCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd,
+		walletBalanceCmd, walletTransactionsCmd, walletUnlockCmd, walletBuildCmd)
+	walletBuildCmd.AddCommand(walletBuildNewCmd, walletBuildViewCmd, walletBuildAddInputCmd,
+		walletBuildAddOutputCmd, walletBuildAddFeeCmd, walletBuildFundCmd, walletBuildSignCmd,
+		walletBuildSubmitCmd, walletBuildDropCmd)
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
+	walletAccountsCmd.AddCommand(walletAccountsCreateCmd)
+	walletTransactionsCmd.AddCommand(walletTransactionsNoteCmd)
+	walletAddressCmd.Flags().Uint64VarP(&walletAccount, "account", "", 0, "ID of the account that the address belongs to")
+	walletSendSiacoinsCmd.Flags().Uint64VarP(&walletAccount, "account", "", 0, "ID of the account that funds the transaction")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
-	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
+	walletPaymentsCmd.AddCommand(walletPaymentsCancelCmd, walletPaymentsScheduleCmd)
+	walletSendCmd.AddCommand(walletSendAllCmd, walletSendSiacoinsCmd, walletSendSiafundsCmd)
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
 
 	root.AddCommand(renterCmd)
@@ -291,7 +345,7 @@ func main() {
 		renterDownloadsCmd, renterAllowanceCmd, renterSetAllowanceCmd,
 		renterContractsCmd, renterFilesListCmd, renterFilesRenameCmd,
 		renterFilesUploadCmd, renterUploadsCmd, renterExportCmd,
-		renterPricesCmd)
+		renterPricesCmd, renterCostCmd)
 
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
@@ -299,6 +353,9 @@ func main() {
 	renterCmd.Flags().BoolVarP(&renterListVerbose, "verbose", "v", false, "Show additional file info such as redundancy")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
 	renterFilesListCmd.Flags().BoolVarP(&renterListVerbose, "verbose", "v", false, "Show additional file info such as redundancy")
+	renterDownloadsCmd.Flags().BoolVarP(&renterShowProgress, "progress", "p", false, "Show progress bars for the downloads in progress until they finish")
+	renterUploadsCmd.Flags().BoolVarP(&renterShowProgress, "progress", "p", false, "Show progress bars for the uploads in progress until they finish")
+	renterFilesUploadCmd.Flags().BoolVarP(&renterShowProgress, "progress", "p", false, "Show progress bars for the new uploads until they finish")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 
 	root.AddCommand(gatewayCmd)
@@ -306,6 +363,15 @@ func main() {
 
 	root.AddCommand(consensusCmd)
 
+	root.AddCommand(statusCmd)
+
+	// allow the status commands to be watched
+	watchable(consensusCmd)
+	watchable(hostCmd)
+	watchable(renterCmd)
+	watchable(renterDownloadsCmd)
+	watchable(statusCmd)
+
 	root.AddCommand(bashcomplCmd)
 	root.AddCommand(mangenCmd)
 
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWatch checks that watch redraws the output in place until it is
+// stopped.
+func TestWatch(t *testing.T) {
+	var buf bytes.Buffer
+	stop := make(chan struct{})
+	draws := 0
+	watch(&buf, "Every 1ms: siac consensus", time.Millisecond, stop, func() {
+		draws++
+		buf.WriteString("Synced: Yes\n")
+		if draws == 3 {
+			close(stop)
+		}
+	})
+	if draws != 3 {
+		t.Fatal("expected 3 draws, got", draws)
+	}
+	out := buf.String()
+	if n := strings.Count(out, clearScreen); n != 3 {
+		t.Fatal("expected the screen to be cleared 3 times, got", n)
+	}
+	if n := strings.Count(out, "Every 1ms: siac consensus\t"); n != 3 {
+		t.Fatal("expected the title to be drawn 3 times, got", n)
+	}
+	if !strings.HasSuffix(out, "Synced: Yes\n") {
+		t.Fatal("output of the last draw is missing:", out)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/NebulousLabs/Sia/node/api"
+)
+
+const (
+	// progressInterval is the interval at which the progress of transfers is
+	// polled and redrawn.
+	progressInterval = time.Second
+
+	// progressLineInterval is the interval at which the progress of transfers
+	// is printed when stdout is not a terminal.
+	progressLineInterval = 10 * time.Second
+
+	// progressBarWidth is the number of characters inside a progress bar.
+	progressBarWidth = 30
+)
+
+// transferProgress is the progress of a single upload or download.
+type transferProgress struct {
+	name   string
+	done   uint64
+	total  uint64
+	eta    time.Duration
+	etaOK  bool
+	status string // reported after the name if nonempty, e.g. "stuck"
+}
+
+// percent returns the percentage of the transfer that has completed.
+func (tp transferProgress) percent() float64 {
+	if tp.total == 0 {
+		return 100
+	}
+	return 100 * float64(tp.done) / float64(tp.total)
+}
+
+// String returns the progress bar line of the transfer.
+func (tp transferProgress) String() string {
+	eta := "ETA unknown"
+	if tp.done >= tp.total {
+		eta = "done"
+	} else if tp.etaOK {
+		eta = "ETA " + tp.eta.Round(time.Second).String()
+	}
+	line := fmt.Sprintf("%v %5.1f%% of %9v, %-14v %v", progressBar(tp.percent(), progressBarWidth), tp.percent(), filesizeUnits(int64(tp.total)), eta, tp.name)
+	if tp.status != "" {
+		line += " (" + tp.status + ")"
+	}
+	return line
+}
+
+// progressBar returns a bar with width characters between its brackets,
+// filled to pct percent.
+func progressBar(pct float64, width int) string {
+	if pct < 0 {
+		pct = 0
+	} else if pct > 100 {
+		pct = 100
+	}
+	filled := int(pct / 100 * float64(width))
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	return "[" + bar + "]"
+}
+
+// progressDisplay draws the progress of a set of transfers. On a terminal the
+// progress bars are redrawn in place; otherwise a line with the percentage of
+// each transfer is printed every progressLineInterval.
+type progressDisplay struct {
+	w         io.Writer
+	tty       bool
+	lines     int // number of lines drawn on the terminal
+	lastPrint time.Time
+}
+
+// newProgressDisplay returns a progressDisplay that draws to f.
+func newProgressDisplay(f *os.File) *progressDisplay {
+	return &progressDisplay{
+		w:   f,
+		tty: terminal.IsTerminal(int(f.Fd())),
+	}
+}
+
+// draw draws the progress of the transfers.
+func (pd *progressDisplay) draw(transfers []transferProgress) {
+	if !pd.tty {
+		if time.Since(pd.lastPrint) < progressLineInterval {
+			return
+		}
+		pd.lastPrint = time.Now()
+		for _, tp := range transfers {
+			fmt.Fprintf(pd.w, "%5.1f%% of %v: %v\n", tp.percent(), filesizeUnits(int64(tp.total)), tp.name)
+		}
+		return
+	}
+
+	// Move back to the first line that was drawn, then redraw every line,
+	// clearing lines that are no longer used.
+	if pd.lines > 0 {
+		fmt.Fprintf(pd.w, "\033[%dA", pd.lines)
+	}
+	for _, tp := range transfers {
+		fmt.Fprintf(pd.w, "\r\033[K%v\n", tp)
+	}
+	for i := len(transfers); i < pd.lines; i++ {
+		fmt.Fprint(pd.w, "\r\033[K\n")
+	}
+	if len(transfers) > pd.lines {
+		pd.lines = len(transfers)
+	}
+}
+
+// finish draws the final progress of the transfers, regardless of when the
+// progress was last printed.
+func (pd *progressDisplay) finish(transfers []transferProgress) {
+	pd.lastPrint = time.Time{}
+	pd.draw(transfers)
+}
+
+// followTransfers polls the progress of a set of transfers and draws it until
+// poll reports that all of the transfers have finished, or until stop is
+// closed. poll returns nil transfers if the progress could not be fetched. The
+// transfers that were drawn last are returned.
+func followTransfers(stop <-chan struct{}, poll func() ([]transferProgress, bool)) []transferProgress {
+	pd := newProgressDisplay(os.Stdout)
+	var last []transferProgress
+	for {
+		transfers, finished := poll()
+		if transfers != nil {
+			last = transfers
+		}
+		if finished {
+			pd.finish(last)
+			return last
+		}
+		pd.draw(last)
+		select {
+		case <-stop:
+			if transfers, _ := poll(); transfers != nil {
+				last = transfers
+			}
+			pd.finish(last)
+			return last
+		case <-time.After(progressInterval):
+		}
+	}
+}
+
+// downloadKey identifies a download in the download queue.
+type downloadKey struct {
+	siapath     string
+	destination string
+	start       time.Time
+}
+
+// pollDownloads returns a poll function for followTransfers that reports the
+// downloads chosen by choose from the download queue. If waitForStart is set,
+// the downloads may not have been queued yet, so an empty choice is reported
+// as unfinished; otherwise there is nothing to follow and the transfers have
+// finished.
+func pollDownloads(choose func([]api.DownloadInfo) []api.DownloadInfo, waitForStart bool) func() ([]transferProgress, bool) {
+	return func() ([]transferProgress, bool) {
+		var queue api.RenterDownloadQueue
+		if err := getAPI("/renter/downloads", &queue); err != nil {
+			return nil, false
+		}
+		return downloadProgress(choose(queue.Downloads), waitForStart)
+	}
+}
+
+// downloadProgress returns the progress of downloads, and whether none of
+// them is still in progress. An empty set of downloads has finished unless
+// waitForStart is set.
+func downloadProgress(downloads []api.DownloadInfo, waitForStart bool) ([]transferProgress, bool) {
+	if len(downloads) == 0 {
+		return []transferProgress{}, !waitForStart
+	}
+	transfers := make([]transferProgress, 0, len(downloads))
+	active := false
+	for _, d := range downloads {
+		tp := transferProgress{
+			name:  d.SiaPath + " -> " + d.Destination,
+			done:  d.Received,
+			total: d.Filesize,
+		}
+		tp.eta, tp.etaOK = d.ETA()
+		if d.Error != "" {
+			tp.status = "failed: " + d.Error
+		} else if d.Received < d.Filesize {
+			active = true
+			if d.Stuck {
+				tp.status = "stuck"
+			}
+		}
+		transfers = append(transfers, tp)
+	}
+	return transfers, !active
+}
+
+// chooseDownload returns a function for pollDownloads that chooses the most
+// recent download of siapath to destination.
+func chooseDownload(siapath, destination string) func([]api.DownloadInfo) []api.DownloadInfo {
+	return func(downloads []api.DownloadInfo) []api.DownloadInfo {
+		var chosen []api.DownloadInfo
+		for _, d := range downloads {
+			if d.SiaPath != siapath || d.Destination != destination {
+				continue
+			}
+			if len(chosen) == 0 || d.StartTime.After(chosen[0].StartTime) {
+				chosen = []api.DownloadInfo{d}
+			}
+		}
+		return chosen
+	}
+}
+
+// chooseActiveDownloads returns a function for pollDownloads that chooses the
+// downloads that were in progress when it was first called.
+func chooseActiveDownloads() func([]api.DownloadInfo) []api.DownloadInfo {
+	var keys map[downloadKey]struct{}
+	return func(downloads []api.DownloadInfo) []api.DownloadInfo {
+		if keys == nil {
+			keys = make(map[downloadKey]struct{})
+			for _, d := range downloads {
+				if d.Received < d.Filesize && d.Error == "" {
+					keys[downloadKey{d.SiaPath, d.Destination, d.StartTime}] = struct{}{}
+				}
+			}
+		}
+		var chosen []api.DownloadInfo
+		for _, d := range downloads {
+			if _, ok := keys[downloadKey{d.SiaPath, d.Destination, d.StartTime}]; ok {
+				chosen = append(chosen, d)
+			}
+		}
+		return chosen
+	}
+}
+
+// uploadRate estimates the rate of uploads from the progress observed by
+// siac, since the renter does not report when an upload started.
+type uploadRate struct {
+	first map[string]rateSample
+}
+
+// rateSample is the progress of an upload at a point in time.
+type rateSample struct {
+	time time.Time
+	done uint64
+}
+
+// eta returns the estimated time until the upload of siapath completes, from
+// the progress observed since the upload was first seen. ok is false if the
+// upload has not progressed since then.
+func (ur *uploadRate) eta(siapath string, done, total uint64, now time.Time) (eta time.Duration, ok bool) {
+	if done >= total {
+		return 0, true
+	}
+	if ur.first == nil {
+		ur.first = make(map[string]rateSample)
+	}
+	first, seen := ur.first[siapath]
+	if !seen {
+		ur.first[siapath] = rateSample{time: now, done: done}
+		return 0, false
+	}
+	elapsed := now.Sub(first.time)
+	if done <= first.done || elapsed <= 0 {
+		return 0, false
+	}
+	rate := float64(done-first.done) / elapsed.Seconds()
+	return time.Duration(float64(total-done) / rate * float64(time.Second)), true
+}
+
+// pollUploads returns a poll function for followTransfers that reports the
+// uploads of the given siapaths. If siapaths is nil, the files that were
+// uploading when the function was first called are reported. The transfers
+// have finished once every file is fully uploaded.
+func pollUploads(siapaths []string) func() ([]transferProgress, bool) {
+	var rate uploadRate
+	return func() ([]transferProgress, bool) {
+		var rf api.RenterFiles
+		if err := getAPI("/renter/files", &rf); err != nil {
+			return nil, false
+		}
+		if siapaths == nil {
+			siapaths = []string{}
+			for _, f := range rf.Files {
+				if f.UploadProgress < 100 {
+					siapaths = append(siapaths, f.SiaPath)
+				}
+			}
+		}
+		files := make(map[string]int)
+		for i, f := range rf.Files {
+			files[f.SiaPath] = i
+		}
+		now := time.Now()
+		transfers := make([]transferProgress, 0, len(siapaths))
+		finished := true
+		for _, siapath := range siapaths {
+			i, exists := files[siapath]
+			if !exists {
+				// The file may not have been added to the renter yet.
+				finished = false
+				continue
+			}
+			f := rf.Files[i]
+			tp := transferProgress{
+				name:  f.SiaPath,
+				done:  uint64(f.UploadProgress / 100 * float64(f.Filesize)),
+				total: f.Filesize,
+			}
+			if f.UploadProgress >= 100 {
+				tp.done = tp.total
+			} else {
+				finished = false
+			}
+			tp.eta, tp.etaOK = rate.eta(f.SiaPath, tp.done, tp.total, now)
+			transfers = append(transfers, tp)
+		}
+		return transfers, finished
+	}
+}
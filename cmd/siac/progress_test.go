@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/node/api"
+)
+
+// TestProgressBar checks that progress bars are filled in proportion to the
+// percentage and always have the same width.
+func TestProgressBar(t *testing.T) {
+	tests := []struct {
+		pct      float64
+		expected string
+	}{
+		{-5, "[>         ]"},
+		{0, "[>         ]"},
+		{45, "[====>     ]"},
+		{100, "[==========]"},
+		{150, "[==========]"},
+	}
+	for _, tt := range tests {
+		if bar := progressBar(tt.pct, 10); bar != tt.expected {
+			t.Errorf("progressBar(%v): expected %q, got %q", tt.pct, tt.expected, bar)
+		}
+	}
+}
+
+// TestProgressDisplay checks that a terminal display redraws its lines in
+// place, and that other displays print a line at most every
+// progressLineInterval.
+func TestProgressDisplay(t *testing.T) {
+	transfers := []transferProgress{
+		{name: "foo", done: 50, total: 100},
+		{name: "bar", done: 100, total: 100, status: "stuck"},
+	}
+	var buf bytes.Buffer
+	pd := &progressDisplay{w: &buf, tty: true}
+	pd.draw(transfers)
+	if strings.Contains(buf.String(), "\033[2A") {
+		t.Fatal("first draw should not move the cursor up")
+	}
+	if !strings.Contains(buf.String(), " 50.0% ") || !strings.Contains(buf.String(), "bar (stuck)") {
+		t.Fatal("unexpected progress lines:", buf.String())
+	}
+	buf.Reset()
+	pd.draw(transfers[:1])
+	if !strings.HasPrefix(buf.String(), "\033[2A") || strings.Count(buf.String(), "\n") != 2 {
+		t.Fatalf("redraw should replace both lines, got %q", buf.String())
+	}
+
+	buf.Reset()
+	pd = &progressDisplay{w: &buf}
+	pd.draw(transfers)
+	pd.draw(transfers)
+	if n := strings.Count(buf.String(), "\n"); n != 2 {
+		t.Fatal("expected a single round of progress lines, got", n)
+	}
+	pd.finish(transfers)
+	if n := strings.Count(buf.String(), "\n"); n != 4 {
+		t.Fatal("finish should print the final progress, got", n)
+	}
+}
+
+// TestUploadRate checks that upload ETAs are estimated from the progress
+// observed since an upload was first seen.
+func TestUploadRate(t *testing.T) {
+	var ur uploadRate
+	now := time.Now()
+	if _, ok := ur.eta("foo", 100, 1000, now); ok {
+		t.Fatal("ETA should be unknown for a new upload")
+	}
+	if _, ok := ur.eta("foo", 100, 1000, now.Add(time.Second)); ok {
+		t.Fatal("ETA should be unknown for an upload that has not progressed")
+	}
+	eta, ok := ur.eta("foo", 400, 1000, now.Add(3*time.Second))
+	if !ok || eta != 6*time.Second {
+		t.Fatal("expected an ETA of 6s, got", eta, ok)
+	}
+	if eta, ok := ur.eta("bar", 1000, 1000, now); !ok || eta != 0 {
+		t.Fatal("completed upload should have a zero ETA, got", eta, ok)
+	}
+}
+
+// TestChooseDownloads checks that the most recent download of a file is
+// followed, and that a batch follows only the downloads that were in
+// progress when it started.
+func TestChooseDownloads(t *testing.T) {
+	start := time.Now()
+	old := api.DownloadInfo{SiaPath: "foo", Destination: "/tmp/foo", Filesize: 10, Received: 10, StartTime: start}
+	current := api.DownloadInfo{SiaPath: "foo", Destination: "/tmp/foo", Filesize: 10, Received: 5, StartTime: start.Add(time.Minute)}
+	other := api.DownloadInfo{SiaPath: "bar", Destination: "/tmp/bar", Filesize: 10, Received: 2, StartTime: start}
+	queue := []api.DownloadInfo{old, current, other}
+
+	chosen := chooseDownload("foo", "/tmp/foo")(queue)
+	if len(chosen) != 1 || !chosen[0].StartTime.Equal(current.StartTime) {
+		t.Fatal("expected the most recent download to be chosen, got", chosen)
+	}
+
+	choose := chooseActiveDownloads()
+	if chosen := choose(queue); len(chosen) != 2 {
+		t.Fatal("expected the two active downloads, got", len(chosen))
+	}
+	later := api.DownloadInfo{SiaPath: "baz", Destination: "/tmp/baz", Filesize: 10, StartTime: start}
+	if chosen := choose(append(queue, later)); len(chosen) != 2 {
+		t.Fatal("downloads queued later should not be followed, got", len(chosen))
+	}
+}
+
+// TestDownloadProgress checks that downloads are finished once none of them
+// is in progress, and that an empty set of downloads is finished unless the
+// downloads may not have been queued yet.
+func TestDownloadProgress(t *testing.T) {
+	if transfers, finished := downloadProgress(nil, false); !finished || len(transfers) != 0 {
+		t.Fatal("an empty set of downloads should be finished")
+	}
+	if _, finished := downloadProgress(nil, true); finished {
+		t.Fatal("an empty set of downloads should not be finished while waiting for it to start")
+	}
+
+	active := api.DownloadInfo{SiaPath: "foo", Destination: "/tmp/foo", Filesize: 10, Received: 5}
+	failed := api.DownloadInfo{SiaPath: "bar", Destination: "/tmp/bar", Filesize: 10, Received: 2, Error: "no hosts"}
+	if transfers, finished := downloadProgress([]api.DownloadInfo{active, failed}, false); finished || len(transfers) != 2 {
+		t.Fatal("a set with an active download should not be finished")
+	}
+	active.Received = active.Filesize
+	if _, finished := downloadProgress([]api.DownloadInfo{active, failed}, false); !finished {
+		t.Fatal("a set with no active downloads should be finished")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"sort"
 	"strconv"
 	"text/tabwriter"
-	"time"
 
 	"github.com/spf13/cobra"
 
@@ -51,6 +50,22 @@ var (
 		Run:   wrap(rentercontractsviewcmd),
 	}
 
+	renterCostCmd = &cobra.Command{
+		Use:   "cost [filesize] [duration] [redundancy]",
+		Short: "Estimate the cost of uploading a file",
+		Long: `Estimate the cost of uploading a file and storing it for a given duration.
+
+filesize is given in bytes or with a unit suffix (KB, MB, GB, etc.)
+
+duration is given in either blocks (b), hours (h), days (d), or weeks (w).
+
+redundancy is optional and defaults to 3.
+
+The estimate is based on the current prices of the hosts that the file would
+be uploaded to.`,
+		Run: rentercostcmd,
+	}
+
 	renterDownloadsCmd = &cobra.Command{
 		Use:   "downloads",
 		Short: "View the download queue",
@@ -174,6 +189,13 @@ func rentercmd() {
 // renteruploadscmd is the handler for the command `siac renter uploads`.
 // Lists files currently uploading.
 func renteruploadscmd() {
+	if renterShowProgress {
+		if len(followTransfers(nil, pollUploads(nil))) == 0 {
+			fmt.Println("No files are uploading.")
+		}
+		return
+	}
+
 	var rf api.RenterFiles
 	err := getAPI("/renter/files", &rf)
 	if err != nil {
@@ -206,6 +228,13 @@ func renteruploadscmd() {
 // Lists files currently downloading, and optionally previously downloaded
 // files if the -H or --history flag is specified.
 func renterdownloadscmd() {
+	if renterShowProgress {
+		if len(followTransfers(nil, pollDownloads(chooseActiveDownloads(), false))) == 0 {
+			fmt.Println("No files are downloading.")
+		}
+		return
+	}
+
 	var queue api.RenterDownloadQueue
 	err := getAPI("/renter/downloads", &queue)
 	if err != nil {
@@ -223,7 +252,11 @@ func renterdownloadscmd() {
 	} else {
 		fmt.Println("Downloading", len(downloading), "files:")
 		for _, file := range downloading {
-			fmt.Printf("%s: %5.1f%% %s -> %s\n", file.StartTime.Format("Jan 02 03:04 PM"), 100*float64(file.Received)/float64(file.Filesize), file.SiaPath, file.Destination)
+			var stuck string
+			if file.Stuck {
+				stuck = " (stuck)"
+			}
+			fmt.Printf("%s: %5.1f%% %s -> %s%s\n", file.StartTime.Format("Jan 02 03:04 PM"), 100*float64(file.Received)/float64(file.Filesize), file.SiaPath, file.Destination, stuck)
 		}
 	}
 	if !renterShowHistory {
@@ -312,6 +345,37 @@ func rentersetallowancecmd(cmd *cobra.Command, args []string) {
 	fmt.Println("Allowance updated.")
 }
 
+// rentercostcmd estimates the cost of uploading a file and storing it for a
+// given duration. The redundancy parameter is optional.
+func rentercostcmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 || len(args) > 3 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	size, err := parseFilesize(args[0])
+	if err != nil {
+		die("Could not parse filesize:", err)
+	}
+	blocks, err := parsePeriod(args[1])
+	if err != nil {
+		die("Could not parse duration:", err)
+	}
+	queryString := fmt.Sprintf("size=%s&duration=%s", size, blocks)
+	if len(args) > 2 {
+		_, err = strconv.ParseFloat(args[2], 64)
+		if err != nil {
+			die("Could not parse redundancy")
+		}
+		queryString += fmt.Sprintf("&redundancy=%s", args[2])
+	}
+	var rcg api.RenterCostGET
+	err = getAPI("/renter/cost?"+queryString, &rcg)
+	if err != nil {
+		die("Could not estimate the upload cost:", err)
+	}
+	fmt.Println("Estimated cost:", currencyUnits(rcg.Cost))
+}
+
 // byValue sorts contracts by their value in siacoins, high to low. If two
 // contracts have the same value, they are sorted by their host's address.
 type byValue []api.RenterContract
@@ -418,48 +482,20 @@ func renterfilesdeletecmd(path string) {
 // Downloads a path from the Sia network to the local specified destination.
 func renterfilesdownloadcmd(path, destination string) {
 	destination = abs(destination)
+	stop := make(chan struct{})
 	done := make(chan struct{})
-	go downloadprogress(done, path)
+	go func() {
+		followTransfers(stop, pollDownloads(chooseDownload(path, destination), true))
+		close(done)
+	}()
 
 	err := get("/renter/download/" + path + "?destination=" + destination)
-	close(done)
+	close(stop)
+	<-done
 	if err != nil {
 		die("Could not download file:", err)
 	}
-	fmt.Printf("\nDownloaded '%s' to %s.\n", path, abs(destination))
-}
-
-func downloadprogress(done chan struct{}, siapath string) {
-	time.Sleep(time.Second) // give download time to initialize
-	for {
-		select {
-		case <-done:
-			return
-
-		case <-time.Tick(time.Second):
-			// get download progress of file
-			var queue api.RenterDownloadQueue
-			err := getAPI("/renter/downloads", &queue)
-			if err != nil {
-				continue // benign
-			}
-			var d api.DownloadInfo
-			for _, d = range queue.Downloads {
-				if d.SiaPath == siapath {
-					break
-				}
-			}
-			if d.Filesize == 0 {
-				continue // file hasn't appeared in queue yet
-			}
-			pct := 100 * float64(d.Received) / float64(d.Filesize)
-			elapsed := time.Since(d.StartTime)
-			elapsed -= elapsed % time.Second // round to nearest second
-			mbps := (float64(d.Received*8) / 1e6) / time.Since(d.StartTime).Seconds()
-			fmt.Printf("\rDownloading... %5.1f%% of %v, %v elapsed, %.2f Mbps    ", pct, filesizeUnits(int64(d.Filesize)), elapsed, mbps)
-		}
-	}
-
+	fmt.Printf("Downloaded '%s' to %s.\n", path, abs(destination))
 }
 
 // bySiaPath implements sort.Interface for [] modules.FileInfo based on the
@@ -551,6 +587,7 @@ func renterfilesuploadcmd(source, path string) {
 		} else if len(files) == 0 {
 			die("Nothing to upload.")
 		}
+		siapaths := make([]string, 0, len(files))
 		for _, file := range files {
 			fpath, _ := filepath.Rel(source, file)
 			fpath = filepath.Join(path, fpath)
@@ -559,8 +596,12 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Could not upload file:", err)
 			}
+			siapaths = append(siapaths, fpath)
 		}
 		fmt.Printf("Uploaded %d files into '%s'.\n", len(files), path)
+		if renterShowProgress {
+			followTransfers(nil, pollUploads(siapaths))
+		}
 	} else {
 		// single file
 		err = post("/renter/upload/"+path, "source="+abs(source))
@@ -568,6 +609,9 @@ func renterfilesuploadcmd(source, path string) {
 			die("Could not upload file:", err)
 		}
 		fmt.Printf("Uploaded '%s' as %s.\n", abs(source), path)
+		if renterShowProgress {
+			followTransfers(nil, pollUploads([]string{path}))
+		}
 	}
 }
 
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/node/api"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Print a summary of the state of all modules",
+		Long: `Print a summary of the state of all modules, including sync progress, peers,
+wallet balance, active transfers, host storage and earnings, and bandwidth
+usage. Modules that are not running are skipped.`,
+		Run: wrap(statuscmd),
+	}
+)
+
+// statuscmd is the handler for the command `siac status`. It aggregates the
+// most important figures of each module into a single view. Unlike the
+// per-module commands, a failing call does not abort the command, as siad may
+// have been started without some of the modules.
+func statuscmd() {
+	statusConsensus()
+	statusGateway()
+	statusWallet()
+	statusRenter()
+	statusHost()
+}
+
+// statusConsensus prints the sync height and progress.
+func statusConsensus() {
+	var cg api.ConsensusGET
+	err := getAPI("/consensus", &cg)
+	if err != nil {
+		fmt.Printf("Consensus:\n  Unavailable: %v\n\n", err)
+		return
+	}
+	progress := float64(100)
+	if !cg.Synced {
+		progress = estimatedProgress(cg.Height, time.Now())
+	}
+	fmt.Printf(`Consensus:
+  Synced:   %v
+  Height:   %v
+  Progress: %.1f%%
+
+`, yesNo(cg.Synced), cg.Height, progress)
+}
+
+// statusGateway prints the number of peers and the bandwidth used by the
+// gateway.
+func statusGateway() {
+	var gg api.GatewayGET
+	err := getAPI("/gateway", &gg)
+	if err != nil {
+		fmt.Printf("Gateway:\n  Unavailable: %v\n\n", err)
+		return
+	}
+	var inbound int
+	for _, peer := range gg.Peers {
+		if peer.Inbound {
+			inbound++
+		}
+	}
+	fmt.Printf(`Gateway:
+  Address: %v
+  Peers:   %v (%v inbound)
+`, gg.NetAddress, len(gg.Peers), inbound)
+
+	var bg api.GatewayBandwidthGET
+	err = getAPI("/gateway/bandwidth", &bg)
+	if err == nil {
+		fmt.Printf("  Download: %v (limit: %v)\n", filesizeUnits(int64(bg.Download)), bandwidthLimit(bg.DownloadLimit))
+		fmt.Printf("  Upload:   %v (limit: %v)\n", filesizeUnits(int64(bg.Upload)), bandwidthLimit(bg.UploadLimit))
+	}
+	fmt.Println()
+}
+
+// bandwidthLimit returns a human-readable bandwidth limit, where a limit of
+// zero or less means no limit.
+func bandwidthLimit(limit int64) string {
+	if limit <= 0 {
+		return "none"
+	}
+	return filesizeUnits(limit) + "/s"
+}
+
+// statusWallet prints the spendable and locked balance of the wallet.
+func statusWallet() {
+	var wg api.WalletGET
+	err := getAPI("/wallet", &wg)
+	if err != nil {
+		fmt.Printf("Wallet:\n  Unavailable: %v\n\n", err)
+		return
+	}
+	if !wg.Unlocked {
+		fmt.Print("Wallet:\n  Locked\n\n")
+		return
+	}
+	locked := types.ZeroCurrency
+	if wg.SpendableSiacoinBalance.Cmp(wg.ConfirmedSiacoinBalance) < 0 {
+		locked = wg.ConfirmedSiacoinBalance.Sub(wg.SpendableSiacoinBalance)
+	}
+	fmt.Printf(`Wallet:
+  Spendable: %v
+  Locked:    %v
+  Siafunds:  %v SF
+
+`, currencyUnits(wg.SpendableSiacoinBalance), currencyUnits(locked), wg.SiafundBalance)
+}
+
+// statusRenter prints the progress of the active downloads and uploads.
+func statusRenter() {
+	var queue api.RenterDownloadQueue
+	err := getAPI("/renter/downloads", &queue)
+	if err != nil {
+		fmt.Printf("Renter:\n  Unavailable: %v\n\n", err)
+		return
+	}
+	var rf api.RenterFiles
+	err = getAPI("/renter/files", &rf)
+	if err != nil {
+		fmt.Printf("Renter:\n  Unavailable: %v\n\n", err)
+		return
+	}
+
+	var downloading []api.DownloadInfo
+	for _, d := range queue.Downloads {
+		if d.Received != d.Filesize && d.Error == "" {
+			downloading = append(downloading, d)
+		}
+	}
+	var uploading []modules.FileInfo
+	for _, fi := range rf.Files {
+		if !fi.Available {
+			uploading = append(uploading, fi)
+		}
+	}
+
+	fmt.Println("Renter:")
+	fmt.Printf("  Files:       %v\n", len(rf.Files))
+	var rb api.RenterBandwidthGET
+	err = getAPI("/renter/bandwidth", &rb)
+	if err == nil {
+		fmt.Printf("  Limits:      %v download, %v upload\n", bandwidthLimit(rb.DownloadLimit), bandwidthLimit(rb.UploadLimit))
+	}
+	fmt.Printf("  Downloading: %v\n", len(downloading))
+	for _, d := range downloading {
+		fmt.Printf("    %5.1f%% %v\n", 100*float64(d.Received)/float64(d.Filesize), d.SiaPath)
+	}
+	fmt.Printf("  Uploading:   %v\n", len(uploading))
+	for _, fi := range uploading {
+		fmt.Printf("    %5.1f%% %v\n", fi.UploadProgress, fi.SiaPath)
+	}
+	fmt.Println()
+}
+
+// statusHost prints the storage used and the earnings of the host.
+func statusHost() {
+	hg := new(api.HostGET)
+	err := getAPI("/host", hg)
+	if err != nil {
+		fmt.Printf("Host:\n  Unavailable: %v\n\n", err)
+		return
+	}
+	sg := new(api.StorageGET)
+	err = getAPI("/host/storage", sg)
+	if err != nil {
+		fmt.Printf("Host:\n  Unavailable: %v\n\n", err)
+		return
+	}
+	var totalStorage, storageRemaining uint64
+	for _, folder := range sg.Folders {
+		totalStorage += folder.Capacity
+		storageRemaining += folder.CapacityRemaining
+	}
+	fm := hg.FinancialMetrics
+	earnings := fm.ContractCompensation.
+		Add(fm.StorageRevenue).
+		Add(fm.DownloadBandwidthRevenue).
+		Add(fm.UploadBandwidthRevenue)
+	potential := fm.PotentialContractCompensation.
+		Add(fm.PotentialStorageRevenue).
+		Add(fm.PotentialDownloadBandwidthRevenue).
+		Add(fm.PotentialUploadBandwidthRevenue)
+	fmt.Printf(`Host:
+  Accepting Contracts: %v
+  Storage Used:        %v / %v
+  Contracts:           %v
+  Earnings:            %v
+  Expected Earnings:   %v
+`, yesNo(hg.InternalSettings.AcceptingContracts),
+		filesizeUnits(int64(totalStorage-storageRemaining)), filesizeUnits(int64(totalStorage)),
+		fm.ContractCount, currencyUnits(earnings), currencyUnits(potential))
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NebulousLabs/Sia/node/api"
+)
+
+var (
+	walletBuildCmd = &cobra.Command{
+		Use:   "build",
+		Short: "Construct a transaction step by step",
+		Long: `Construct a transaction step by step. A transaction is built in a session
+held by the daemon, which is created with 'siac wallet build new'. The other
+build commands take the id of the session as their first argument and print
+the transaction as JSON after each step, so that it can be inspected before
+it is signed and submitted.
+
+Amounts can be specified in units, e.g. 1.23KS. Run 'siac wallet --help' for a
+list of units.`,
+		// Run field is not set, as the build command itself is not a valid
+		// command. A subcommand must be provided.
+	}
+
+	walletBuildNewCmd = &cobra.Command{
+		Use:   "new",
+		Short: "Start a new transaction",
+		Long:  "Start a new transaction, printing the id of its session.",
+		Run:   wrap(walletbuildnewcmd),
+	}
+
+	walletBuildViewCmd = &cobra.Command{
+		Use:   "view [id]",
+		Short: "View the transaction",
+		Long:  "Print the transaction under construction and its parents as JSON.",
+		Run:   wrap(walletbuildviewcmd),
+	}
+
+	walletBuildAddInputCmd = &cobra.Command{
+		Use:   "add-input [id] [parentid] [unlockconditions]",
+		Short: "Add a siacoin input",
+		Long: `Add a siacoin input spending the output 'parentid'. 'unlockconditions' are
+the JSON-encoded unlock conditions of the output. The input is not signed by
+the wallet.`,
+		Run: wrap(walletbuildaddinputcmd),
+	}
+
+	walletBuildAddOutputCmd = &cobra.Command{
+		Use:   "add-output [id] [amount] [dest]",
+		Short: "Add a siacoin output",
+		Long:  "Add a siacoin output sending 'amount' to the address 'dest'.",
+		Run:   wrap(walletbuildaddoutputcmd),
+	}
+
+	walletBuildAddFeeCmd = &cobra.Command{
+		Use:   "add-fee [id] [amount]",
+		Short: "Add a miner fee",
+		Long:  "Add a miner fee of 'amount' to the transaction.",
+		Run:   wrap(walletbuildaddfeecmd),
+	}
+
+	walletBuildFundCmd = &cobra.Command{
+		Use:   "fund [id] [amount]",
+		Short: "Fund the transaction from the wallet",
+		Long: `Add inputs from the wallet worth exactly 'amount' to the transaction. The
+inputs are signed when 'siac wallet build sign' is called.`,
+		Run: wrap(walletbuildfundcmd),
+	}
+
+	walletBuildSignCmd = &cobra.Command{
+		Use:   "sign [id]",
+		Short: "Sign the transaction",
+		Long: `Sign the inputs that were added by 'siac wallet build fund'. The signatures
+cover the whole transaction, so no more fields can be added afterwards.`,
+		Run: wrap(walletbuildsigncmd),
+	}
+
+	walletBuildSubmitCmd = &cobra.Command{
+		Use:   "submit [id]",
+		Short: "Submit the transaction",
+		Long:  "Submit the transaction and its parents to the transaction pool, closing the session.",
+		Run:   wrap(walletbuildsubmitcmd),
+	}
+
+	walletBuildDropCmd = &cobra.Command{
+		Use:   "drop [id]",
+		Short: "Discard the transaction",
+		Long:  "Discard the transaction, releasing any wallet outputs that it used and closing the session.",
+		Run:   wrap(walletbuilddropcmd),
+	}
+)
+
+// printBuilderTransaction prints the transaction of a builder session as
+// indented JSON.
+func printBuilderTransaction(wbg api.WalletBuilderGET) {
+	b, err := json.MarshalIndent(wbg, "", "  ")
+	if err != nil {
+		die("Could not encode transaction:", err)
+	}
+	fmt.Println(string(b))
+}
+
+// walletbuildstep performs a step of building the transaction in session id
+// and prints the resulting transaction.
+func walletbuildstep(id, step string, vals url.Values) {
+	var wbg api.WalletBuilderGET
+	err := postResp("/wallet/builder/"+id+"/"+step, vals.Encode(), &wbg)
+	if err != nil {
+		die("Could not "+step+" transaction:", err)
+	}
+	printBuilderTransaction(wbg)
+}
+
+// walletbuildnewcmd starts a new transaction builder session.
+func walletbuildnewcmd() {
+	var wbp api.WalletBuilderPOST
+	err := postResp("/wallet/builder", "", &wbp)
+	if err != nil {
+		die("Could not start transaction:", err)
+	}
+	fmt.Println(wbp.ID)
+}
+
+// walletbuildviewcmd prints the transaction of a builder session.
+func walletbuildviewcmd(id string) {
+	var wbg api.WalletBuilderGET
+	err := getAPI("/wallet/builder/"+id, &wbg)
+	if err != nil {
+		die("Could not view transaction:", err)
+	}
+	printBuilderTransaction(wbg)
+}
+
+// walletbuildaddinputcmd adds a siacoin input to a transaction.
+func walletbuildaddinputcmd(id, parentID, unlockConditions string) {
+	walletbuildstep(id, "input", url.Values{
+		"parentid":         {parentID},
+		"unlockconditions": {unlockConditions},
+	})
+}
+
+// walletbuildaddoutputcmd adds a siacoin output to a transaction.
+func walletbuildaddoutputcmd(id, amount, dest string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	walletbuildstep(id, "output", url.Values{
+		"amount":      {hastings},
+		"destination": {dest},
+	})
+}
+
+// walletbuildaddfeecmd adds a miner fee to a transaction.
+func walletbuildaddfeecmd(id, amount string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	walletbuildstep(id, "fee", url.Values{"amount": {hastings}})
+}
+
+// walletbuildfundcmd funds a transaction from the wallet.
+func walletbuildfundcmd(id, amount string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	walletbuildstep(id, "fund", url.Values{"amount": {hastings}})
+}
+
+// walletbuildsigncmd signs a transaction.
+func walletbuildsigncmd(id string) {
+	walletbuildstep(id, "sign", nil)
+}
+
+// walletbuildsubmitcmd submits a transaction to the transaction pool.
+func walletbuildsubmitcmd(id string) {
+	walletbuildstep(id, "submit", nil)
+	fmt.Println("Transaction submitted.")
+}
+
+// walletbuilddropcmd discards a transaction.
+func walletbuilddropcmd(id string) {
+	err := post("/wallet/builder/"+id+"/drop", "")
+	if err != nil {
+		die("Could not drop transaction:", err)
+	}
+	fmt.Println("Transaction dropped.")
+}
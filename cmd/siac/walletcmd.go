@@ -1,24 +1,47 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"math/big"
+	"net/url"
 	"os"
 	"syscall"
+	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"golang.org/x/crypto/ssh/terminal"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/node/api"
 	"github.com/NebulousLabs/Sia/types"
 )
 
 var (
+	walletAccountsCmd = &cobra.Command{
+		Use:   "accounts",
+		Short: "List wallet accounts",
+		Long: `List the accounts of the wallet and their confirmed balances. Each account
+derives its addresses from the wallet's primary seed, and only spends its own
+outputs. The default account, with ID 0, holds all other funds.`,
+		Run: wrap(walletaccountscmd),
+	}
+
+	walletAccountsCreateCmd = &cobra.Command{
+		Use:   "create [name]",
+		Short: "Create a wallet account",
+		Long:  "Create a new account with the given name. The wallet must be unlocked.",
+		Run:   wrap(walletaccountscreatecmd),
+	}
+
 	walletAddressCmd = &cobra.Command{
 		Use:   "address",
 		Short: "Get a new wallet address",
-		Long:  "Generate a new wallet address from the wallet's primary seed.",
-		Run:   wrap(walletaddresscmd),
+		Long: `Generate a new wallet address from the wallet's primary seed. Use --account
+to generate an address of another account.`,
+		Run: wrap(walletaddresscmd),
 	}
 
 	walletAddressesCmd = &cobra.Command{
@@ -127,6 +150,20 @@ By default the wallet encryption / unlock password is the same as the generated
 		// A subcommand must be provided.
 	}
 
+	walletSendAllCmd = &cobra.Command{
+		Use:   "all [dest]",
+		Short: "Send the entire siacoin balance to an address",
+		Long: `Send the entire spendable siacoin balance of the wallet, minus the
+transaction fee, to an address in a single transaction with no change output.
+'dest' must be a 76-byte hexadecimal address. This is useful when migrating to
+a new wallet.
+
+Outputs that are timelocked or were spent recently are not sent. If the wallet
+has more outputs than fit in one transaction, only the largest are sent, and
+the command can be run again to send the rest.`,
+		Run: wrap(walletsendallcmd),
+	}
+
 	walletSendSiacoinsCmd = &cobra.Command{
 		Use:   "siacoins [amount] [dest]",
 		Short: "Send siacoins to an address",
@@ -134,6 +171,8 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
+Use --account to only spend the outputs of an account.
+
 A miner fee of 10 SC is levied on all transactions.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
@@ -146,6 +185,35 @@ Run 'wallet send --help' to see a list of available units.`,
 		Run: wrap(walletsendsiafundscmd),
 	}
 
+	walletPaymentsCmd = &cobra.Command{
+		Use:   "payments",
+		Short: "List scheduled payments",
+		Long: `List the payments that the wallet is scheduled to make. Payments that could
+not be made, for example because the wallet was locked, show the reason they
+failed and are retried at every new block.`,
+		Run: wrap(walletpaymentscmd),
+	}
+
+	walletPaymentsCancelCmd = &cobra.Command{
+		Use:   "cancel [id]",
+		Short: "Cancel a scheduled payment",
+		Long:  "Cancel a scheduled payment. Payments that were already made are not affected.",
+		Run:   wrap(walletpaymentscancelcmd),
+	}
+
+	walletPaymentsScheduleCmd = &cobra.Command{
+		Use:   "schedule [amount] [dest] [interval]",
+		Short: "Schedule a recurring payment",
+		Long: `Send 'amount' siacoins to 'dest' every 'interval', until the payment is
+canceled. The first payment is made one interval from now. 'amount' can be
+specified in units, e.g. 1.23KS. 'interval' can be specified in blocks (b),
+hours (h), days (d), or weeks (w); a block is approximately 10 minutes.
+
+Payments are made while siad is running and the wallet is unlocked. Payments
+that are missed are made one per block once the wallet is unlocked again.`,
+		Run: wrap(walletpaymentsschedulecmd),
+	}
+
 	walletSweepCmd = &cobra.Command{
 		Use:   "sweep",
 		Short: "Sweep siacoins and siafunds from a seed.",
@@ -161,6 +229,15 @@ will be sent to your wallet.`,
 		Run:   wrap(wallettransactionscmd),
 	}
 
+	walletTransactionsNoteCmd = &cobra.Command{
+		Use:   "note [txid] [note]",
+		Short: "Attach a note to a transaction",
+		Long: `Attach a note, such as "rent payment", to a transaction of the wallet. The
+note is shown by 'siac wallet transactions'. An empty note removes the
+transaction's note.`,
+		Run: wrap(wallettransactionsnotecmd),
+	}
+
 	walletUnlockCmd = &cobra.Command{
 		Use:   `unlock`,
 		Short: "Unlock the wallet",
@@ -177,19 +254,72 @@ const askPasswordText = "We need to encrypt the new data using the current walle
 const currentPasswordText = "Current Password: "
 const newPasswordText = "New Password: "
 
-// passwordPrompt securely reads a password from stdin.
+// stdinReader buffers stdin when it is not a terminal, so that consecutive
+// prompts each consume a single line.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// passwordPrompt securely reads a password from stdin. If stdin is a
+// terminal, the password is read without being echoed. Otherwise a single line
+// is read, which allows scripts to pipe the password in. The buffer holding
+// the raw input is wiped before returning.
 func passwordPrompt(prompt string) (string, error) {
-	fmt.Print(prompt)
-	pw, err := terminal.ReadPassword(int(syscall.Stdin))
-	fmt.Println()
+	var pw []byte
+	var err error
+	if fd := int(syscall.Stdin); terminal.IsTerminal(fd) {
+		fmt.Print(prompt)
+		pw, err = terminal.ReadPassword(fd)
+		fmt.Println()
+	} else {
+		pw, err = readPasswordLine(stdinReader)
+	}
+	defer crypto.SecureWipe(pw)
 	return string(pw), err
 }
 
+// readPasswordLine reads a single line from r, stripping the line ending. A
+// final line without a line ending is accepted.
+func readPasswordLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadBytes('\n')
+	if err == io.EOF && len(line) > 0 {
+		err = nil
+	}
+	if err != nil {
+		crypto.SecureWipe(line)
+		return nil, err
+	}
+	return bytes.TrimRight(line, "\r\n"), nil
+}
+
+// walletaccountscmd lists the wallet's accounts.
+func walletaccountscmd() {
+	var wag api.WalletAccountsGET
+	err := getAPI("/wallet/accounts", &wag)
+	if err != nil {
+		die("Could not get wallet accounts:", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tName\tAddresses\tConfirmed Balance")
+	for _, a := range wag.Accounts {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", a.ID, a.Name, a.Addresses, currencyUnits(a.ConfirmedSiacoinBalance))
+	}
+	w.Flush()
+}
+
+// walletaccountscreatecmd creates a wallet account.
+func walletaccountscreatecmd(name string) {
+	var wap api.WalletAccountsPOST
+	err := postResp("/wallet/accounts", "name="+name, &wap)
+	if err != nil {
+		die("Could not create account:", err)
+	}
+	fmt.Printf("Created account %v with ID %v\n", name, wap.ID)
+}
+
 // walletaddresscmd fetches a new address from the wallet that will be able to
 // receive coins.
 func walletaddresscmd() {
 	addr := new(api.WalletAddressGET)
-	err := getAPI("/wallet/address", addr)
+	err := getAPI(fmt.Sprintf("/wallet/address?account=%v", walletAccount), addr)
 	if err != nil {
 		die("Could not generate new address:", err)
 	}
@@ -364,13 +494,71 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if err != nil {
 		die("Could not parse amount:", err)
 	}
-	err = post("/wallet/siacoins", fmt.Sprintf("amount=%s&destination=%s", hastings, dest))
+	err = post("/wallet/siacoins", fmt.Sprintf("amount=%s&destination=%s&account=%v", hastings, dest, walletAccount))
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
 	fmt.Printf("Sent %s hastings to %s\n", hastings, dest)
 }
 
+// walletsendallcmd sends the entire siacoin balance of the wallet to a
+// destination address.
+func walletsendallcmd(dest string) {
+	var swept api.WalletSweepAllPOST
+	err := postResp("/wallet/sweep/all", "destination="+dest, &swept)
+	if err != nil {
+		die("Could not send siacoins:", err)
+	}
+	fmt.Printf("Sent %v to %s with a fee of %v\n", currencyUnits(swept.Value), dest, currencyUnits(swept.Fee))
+	fmt.Println("Transaction ID:", swept.TransactionID)
+}
+
+// walletpaymentscmd lists the wallet's scheduled payments.
+func walletpaymentscmd() {
+	var wpg api.WalletPaymentsGET
+	err := getAPI("/wallet/payments", &wpg)
+	if err != nil {
+		die("Could not get scheduled payments:", err)
+	}
+	if len(wpg.Payments) == 0 {
+		fmt.Println("No scheduled payments.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAmount\tInterval\tNext Height\tPayments Made\tDestination\tLast Error")
+	for _, p := range wpg.Payments {
+		fmt.Fprintf(w, "%v\t%v\t%v blocks\t%v\t%v\t%v\t%v\n", p.ID, currencyUnits(p.Amount), p.Interval, p.NextHeight, p.PaymentsMade, p.Destination, p.LastError)
+	}
+	w.Flush()
+}
+
+// walletpaymentscancelcmd cancels a scheduled payment.
+func walletpaymentscancelcmd(id string) {
+	err := post("/wallet/payments/cancel/"+id, "")
+	if err != nil {
+		die("Could not cancel scheduled payment:", err)
+	}
+	fmt.Println("Canceled scheduled payment", id)
+}
+
+// walletpaymentsschedulecmd schedules a recurring payment.
+func walletpaymentsschedulecmd(amount, dest, interval string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	blocks, err := parsePeriod(interval)
+	if err != nil {
+		die("Could not parse interval:", err)
+	}
+	var wpp api.WalletPaymentsPOST
+	err = postResp("/wallet/payments", fmt.Sprintf("amount=%s&destination=%s&interval=%s", hastings, dest, blocks), &wpp)
+	if err != nil {
+		die("Could not schedule payment:", err)
+	}
+	fmt.Printf("Scheduled payment %v of %s hastings to %s every %s blocks\n", wpp.ID, hastings, dest, blocks)
+}
+
 // walletsendsiafundscmd sends siafunds to a destination address.
 func walletsendsiafundscmd(amount, dest string) {
 	err := post("/wallet/siafunds", fmt.Sprintf("amount=%s&destination=%s", amount, dest))
@@ -415,15 +603,37 @@ Unlock the wallet to view balance
 	fmt.Printf(`Wallet status:
 %s, Unlocked
 Confirmed Balance:   %v
+Spendable Balance:   %v
 Unconfirmed Delta:  %v
 Exact:               %v H
 Siafunds:            %v SF
 Siafund Claims:      %v H
 
 Estimated Fee:       %v / KB
-`, encStatus, currencyUnits(status.ConfirmedSiacoinBalance), delta,
-		status.ConfirmedSiacoinBalance, status.SiafundBalance, status.SiacoinClaimBalance,
+`, encStatus, currencyUnits(status.ConfirmedSiacoinBalance), currencyUnits(status.SpendableSiacoinBalance),
+		delta, status.ConfirmedSiacoinBalance, status.SiafundBalance, status.SiacoinClaimBalance,
 		fees.Maximum.Mul64(1e3).HumanString())
+	if status.SpendableSiacoinBalance.Cmp(status.ConfirmedSiacoinBalance) < 0 {
+		fmt.Printf(`
+%v of the confirmed balance is timelocked and cannot be spent yet.
+`, currencyUnits(status.ConfirmedSiacoinBalance.Sub(status.SpendableSiacoinBalance)))
+	}
+
+	var reused api.WalletAddressesGET
+	err = getAPI("/wallet/addresses/reused", &reused)
+	if err != nil {
+		die("Could not get reused addresses:", err)
+	}
+	if len(reused.Addresses) > 0 {
+		fmt.Printf(`
+Warning: %v address(es) have received funds more than once. Payments to the
+same address can be linked together; use 'siac wallet address' to generate a
+new address for each payment. Reused addresses:
+`, len(reused.Addresses))
+		for _, addr := range reused.Addresses {
+			fmt.Println(" ", addr)
+		}
+	}
 }
 
 // walletsweepcmd sweeps coins and funds from a seed.
@@ -450,7 +660,7 @@ func wallettransactionscmd() {
 		die("Could not fetch transaction history:", err)
 	}
 
-	fmt.Println("    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]")
+	fmt.Println("    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]   [note]")
 	txns := append(wtg.ConfirmedTransactions, wtg.UnconfirmedTransactions...)
 	for _, txn := range txns {
 		// Determine the number of outgoing siacoins and siafunds.
@@ -493,10 +703,27 @@ func wallettransactionscmd() {
 		fmt.Printf("%67v%15.2f SC", txn.TransactionID, incomingSiacoinsFloat-outgoingSiacoinsFloat)
 		// For siafunds, need to avoid having a negative types.Currency.
 		if incomingSiafunds.Cmp(outgoingSiafunds) >= 0 {
-			fmt.Printf("%14v SF\n", incomingSiafunds.Sub(outgoingSiafunds))
+			fmt.Printf("%14v SF", incomingSiafunds.Sub(outgoingSiafunds))
 		} else {
-			fmt.Printf("-%14v SF\n", outgoingSiafunds.Sub(incomingSiafunds))
+			fmt.Printf("-%14v SF", outgoingSiafunds.Sub(incomingSiafunds))
+		}
+		if txn.Note != "" {
+			fmt.Printf("   %v", txn.Note)
 		}
+		fmt.Println()
+	}
+}
+
+// wallettransactionsnotecmd attaches a note to a transaction.
+func wallettransactionsnotecmd(txid, note string) {
+	err := post("/wallet/transaction/"+txid+"/note", "note="+url.QueryEscape(note))
+	if err != nil {
+		die("Could not set transaction note:", err)
+	}
+	if note == "" {
+		fmt.Println("Removed the note of transaction", txid)
+	} else {
+		fmt.Println("Set the note of transaction", txid)
 	}
 }
 
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// TestReadPasswordLine checks that passwords piped into siac are read one line
+// at a time with the line ending removed.
+func TestReadPasswordLine(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("current pass\r\nnew pass\nlast"))
+	for _, expected := range []string{"current pass", "new pass", "last"} {
+		pw, err := readPasswordLine(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(pw) != expected {
+			t.Fatalf("expected %q, got %q", expected, pw)
+		}
+	}
+	if _, err := readPasswordLine(r); err == nil {
+		t.Fatal("expected an error once input is exhausted")
+	}
+}
@@ -49,6 +49,7 @@ type (
 		config        Config
 		moduleClosers []moduleCloser
 		api           http.Handler
+		cs            modules.ConsensusSet
 		mu            sync.Mutex
 	}
 
@@ -326,13 +327,22 @@ func (srv *Server) daemonUpdateHandlerPOST(w http.ResponseWriter, _ *http.Reques
 
 // debugConstantsHandler prints a json file containing all of the constants.
 func (srv *Server) daemonConstantsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	// Report the maturity delay of the loaded consensus set, which may differ
+	// from types.MaturityDelay on a private network.
+	maturityDelay := types.MaturityDelay
+	srv.mu.Lock()
+	if srv.cs != nil {
+		maturityDelay = srv.cs.MaturityDelay()
+	}
+	srv.mu.Unlock()
+
 	sc := SiaConstants{
 		BlockFrequency:         types.BlockFrequency,
 		BlockSizeLimit:         types.BlockSizeLimit,
 		ExtremeFutureThreshold: types.ExtremeFutureThreshold,
 		FutureThreshold:        types.FutureThreshold,
 		GenesisTimestamp:       types.GenesisTimestamp,
-		MaturityDelay:          types.MaturityDelay,
+		MaturityDelay:          maturityDelay,
 		MedianTimestampWindow:  types.MedianTimestampWindow,
 		SiafundCount:           types.SiafundCount,
 		SiafundPortion:         types.SiafundPortion,
@@ -556,6 +566,7 @@ func (srv *Server) loadModules() error {
 	// connect the API to the server
 	srv.mu.Lock()
 	srv.api = a
+	srv.cs = cs
 	srv.mu.Unlock()
 
 	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
@@ -9,3 +9,15 @@ func SecureWipe(data []byte) {
 		data[i] = 0
 	}
 }
+
+// Zeroize overwrites the secret key with zeroes. A pointer receiver is used so
+// that the canonical copy of the key is wiped rather than a temporary copy.
+func (sk *SecretKey) Zeroize() {
+	SecureWipe(sk[:])
+}
+
+// Zeroize overwrites the twofish key with zeroes. A pointer receiver is used
+// so that the canonical copy of the key is wiped rather than a temporary copy.
+func (key *TwofishKey) Zeroize() {
+	SecureWipe(key[:])
+}
@@ -21,3 +21,19 @@ func TestUnitSecureWipeEdgeCases(t *testing.T) {
 	SecureWipe(nil)
 	SecureWipe([]byte{})
 }
+
+// TestZeroize checks that Zeroize wipes the stored keys rather than a copy.
+func TestZeroize(t *testing.T) {
+	sk, _ := GenerateKeyPair()
+	keys := []SecretKey{sk}
+	keys[0].Zeroize()
+	if keys[0] != (SecretKey{}) {
+		t.Error("secret key was not zeroized")
+	}
+
+	tk := GenerateTwofishKey()
+	tk.Zeroize()
+	if tk != (TwofishKey{}) {
+		t.Error("twofish key was not zeroized")
+	}
+}
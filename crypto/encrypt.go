@@ -11,15 +11,29 @@ import (
 
 	"github.com/NebulousLabs/fastrand"
 
+	"golang.org/x/crypto/scrypt"
 	"golang.org/x/crypto/twofish"
 )
 
 const (
 	TwofishOverhead = 28 // number of bytes added by EncryptBytes
+
+	// PassphraseSaltSize is the minimum size of the salt used to derive a key
+	// from a passphrase.
+	PassphraseSaltSize = 16
+
+	// The scrypt parameters used by TwofishKeyFromPassphrase. Changing these
+	// values changes the key derived from every passphrase, making existing
+	// ciphertexts unreadable.
+	passphraseScryptN = 1 << 15
+	passphraseScryptR = 8
+	passphraseScryptP = 1
 )
 
 var (
+	ErrEmptyPassphrase = errors.New("passphrase must not be empty")
 	ErrInsufficientLen = errors.New("supplied ciphertext is not long enough to contain a nonce")
+	ErrShortSalt       = errors.New("salt must be at least PassphraseSaltSize bytes")
 )
 
 type (
@@ -34,6 +48,27 @@ func GenerateTwofishKey() (key TwofishKey) {
 	return
 }
 
+// TwofishKeyFromPassphrase derives a key from a passphrase using scrypt, a
+// memory-hard key derivation function. The same passphrase and salt always
+// produce the same key, so data encrypted with the key can be decrypted
+// without storing the key itself. The salt does not need to be kept secret,
+// but it must be stored alongside the encrypted data and should be unique
+// for each key.
+func TwofishKeyFromPassphrase(passphrase string, salt []byte) (key TwofishKey, err error) {
+	if passphrase == "" {
+		return TwofishKey{}, ErrEmptyPassphrase
+	}
+	if len(salt) < PassphraseSaltSize {
+		return TwofishKey{}, ErrShortSalt
+	}
+	derived, err := scrypt.Key([]byte(passphrase), salt, passphraseScryptN, passphraseScryptR, passphraseScryptP, len(key))
+	if err != nil {
+		return TwofishKey{}, err
+	}
+	copy(key[:], derived)
+	return key, nil
+}
+
 // NewCipher creates a new Twofish cipher from the key.
 func (key TwofishKey) NewCipher() cipher.Block {
 	// NOTE: NewCipher only returns an error if len(key) != 16, 24, or 32.
@@ -3,6 +3,7 @@ package crypto
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/hex"
 	"testing"
 
 	"github.com/NebulousLabs/fastrand"
@@ -212,3 +213,57 @@ func TestCipherNewGCMAssumption(t *testing.T) {
 		t.Errorf("cipher must have BlockSize 16, but generated cipher has BlockSize %d\n", block.BlockSize())
 	}
 }
+
+// TestTwofishKeyFromPassphrase checks the key derivation against known
+// vectors, so that keys derived from a passphrase remain stable across
+// versions.
+func TestTwofishKeyFromPassphrase(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tests := []struct {
+		passphrase string
+		salt       string
+		key        string
+	}{
+		{"password", "00000000000000000000000000000000", "821c384a3f232a4d41f4c68ed40aeb5b8a16bea836d2aa3c697400ed28a2c382"},
+		{"correct horse battery staple", "000102030405060708090a0b0c0d0e0f", "7a8e34241db898d59175c696538c417467a975ffe569068425f16188d3159c58"},
+	}
+	for _, test := range tests {
+		salt, _ := hex.DecodeString(test.salt)
+		key, err := TwofishKeyFromPassphrase(test.passphrase, salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key[:]) != test.key {
+			t.Errorf("wrong key for passphrase %q: expected %v, got %x", test.passphrase, test.key, key)
+		}
+	}
+
+	// A key derived from the same passphrase and salt should be able to
+	// decrypt data encrypted with the original key.
+	salt := fastrand.Bytes(PassphraseSaltSize)
+	key1, err := TwofishKeyFromPassphrase("foo", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := TwofishKeyFromPassphrase("foo", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := fastrand.Bytes(600)
+	decrypted, err := key2.DecryptBytes(key1.EncryptBytes(plaintext))
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(plaintext, decrypted) {
+		t.Fatal("derived keys do not match")
+	}
+
+	// Invalid passphrases and salts should be rejected.
+	if _, err := TwofishKeyFromPassphrase("", salt); err != ErrEmptyPassphrase {
+		t.Fatal("expected ErrEmptyPassphrase, got", err)
+	}
+	if _, err := TwofishKeyFromPassphrase("foo", salt[:PassphraseSaltSize-1]); err != ErrShortSalt {
+		t.Fatal("expected ErrShortSalt, got", err)
+	}
+}
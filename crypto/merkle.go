@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/NebulousLabs/Sia/encoding"
 
@@ -17,6 +18,17 @@ const (
 	SegmentSize = 64
 )
 
+var (
+	// ErrBadSegmentSize is returned if a set of segments does not split data
+	// the way that storage proofs do: every segment but the last must be
+	// exactly SegmentSize bytes, and the last must not be empty or larger.
+	ErrBadSegmentSize = errors.New("segments must be SegmentSize bytes, except for a shorter final segment")
+
+	// ErrSegmentIndexOutOfRange is returned when a proof is requested for a
+	// segment that does not exist.
+	ErrSegmentIndexOutOfRange = errors.New("segment index is out of range")
+)
+
 // MerkleTree wraps merkletree.Tree, changing some of the function definitions
 // to assume sia-specific constants and return sia-specific types.
 type MerkleTree struct {
@@ -137,6 +149,61 @@ func MerkleProof(b []byte, proofIndex uint64) (base []byte, hashSet []Hash) {
 	return base, hashSet
 }
 
+// checkSegments returns ErrBadSegmentSize if the segments are not the ones
+// that MerkleRoot would produce from their concatenation.
+func checkSegments(segments [][]byte) error {
+	for i, seg := range segments {
+		if len(seg) > SegmentSize || len(seg) == 0 || (i < len(segments)-1 && len(seg) != SegmentSize) {
+			return ErrBadSegmentSize
+		}
+	}
+	return nil
+}
+
+// MerkleRootSegments returns the Merkle root of data that has already been
+// split into segments. The result is the same as MerkleRoot of the
+// concatenated segments, and is the root that storage proofs are verified
+// against.
+func MerkleRootSegments(segments [][]byte) (Hash, error) {
+	if err := checkSegments(segments); err != nil {
+		return Hash{}, err
+	}
+	t := NewTree()
+	for _, seg := range segments {
+		t.Push(seg)
+	}
+	return t.Root(), nil
+}
+
+// MerkleProofSegments builds a Merkle proof that segments[index] is a part of
+// the Merkle root of segments. The returned hash set, together with the
+// segment itself, is what a storage proof for that segment contains, and can
+// be checked with VerifySegment.
+func MerkleProofSegments(segments [][]byte, index int) ([]Hash, error) {
+	if err := checkSegments(segments); err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(segments) {
+		return nil, ErrSegmentIndexOutOfRange
+	}
+	t := NewTree()
+	if err := t.SetIndex(uint64(index)); err != nil {
+		return nil, err
+	}
+	for _, seg := range segments {
+		t.Push(seg)
+	}
+	_, proof, _, _ := t.Prove()
+	if len(proof) == 0 {
+		return nil, ErrSegmentIndexOutOfRange
+	}
+	hashSet := make([]Hash, len(proof)-1)
+	for i, p := range proof[1:] {
+		copy(hashSet[i][:], p)
+	}
+	return hashSet, nil
+}
+
 // VerifySegment will verify that a segment, given the proof, is a part of a
 // Merkle root.
 func VerifySegment(base []byte, hashSet []Hash, numSegments, proofIndex uint64, root Hash) bool {
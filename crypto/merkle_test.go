@@ -153,3 +153,99 @@ func TestOddDataSize(t *testing.T) {
 		}
 	}
 }
+
+// TestMerkleSegmentsVectors checks MerkleRootSegments and MerkleProofSegments
+// against independently computed test vectors, and checks that the proofs are
+// accepted by VerifySegment, the function used to validate storage proofs.
+func TestMerkleSegmentsVectors(t *testing.T) {
+	tests := []struct {
+		size   int
+		root   string
+		proofs [][]string
+	}{
+		// Four segments, the last of which is 8 bytes.
+		{200, "429a6180cb60ea259ece7eb0851ee659ddf2a3464dae98a3784b988f4cb3c0a2", [][]string{
+			{"97e888b10a749016f36e98cab8ebb8306b0ca0a3a4b78221f24839d7d4e2ecd3", "d246ea69ebea75823e7c0a19794d485e038e9a3918d0820366b884d1798970c0"},
+			{"5450d0d0dc7eb22a12f09617236354bde65426d37c7221ea1dad7ff37b58ab26", "d246ea69ebea75823e7c0a19794d485e038e9a3918d0820366b884d1798970c0"},
+			{"b6dac03a1fa850623d40fd67641286e3da205c1acf3056ab1b510ccb939f2bcd", "4c4eb6762fac662b259e12798162aa15d88bf32b897a2ec195f902b2a58491ec"},
+			{"827635716684d2b086b0ae88ce463df1f6fc19ea8863aed9ce206d791ec19cf9", "4c4eb6762fac662b259e12798162aa15d88bf32b897a2ec195f902b2a58491ec"},
+		}},
+		// Five segments, so the tree is unbalanced.
+		{300, "f2e27858ce6957c9158ea6cf247331c7e887aad8294fce6037e2b19e1773d117", [][]string{
+			{"97e888b10a749016f36e98cab8ebb8306b0ca0a3a4b78221f24839d7d4e2ecd3", "af666159ccda2c4f7f60d103546a1691aa38877dac3745249a8b5a04b8c1535d", "76677db355238e700a5446ec4c9aca17acc91bfa29875b73d433b6b5191b5afb"},
+			{"5450d0d0dc7eb22a12f09617236354bde65426d37c7221ea1dad7ff37b58ab26", "af666159ccda2c4f7f60d103546a1691aa38877dac3745249a8b5a04b8c1535d", "76677db355238e700a5446ec4c9aca17acc91bfa29875b73d433b6b5191b5afb"},
+			{"4735bf95512ef442f51b7e7a93f3c7153200c643683547c0045fc52a20be8588", "4c4eb6762fac662b259e12798162aa15d88bf32b897a2ec195f902b2a58491ec", "76677db355238e700a5446ec4c9aca17acc91bfa29875b73d433b6b5191b5afb"},
+			{"827635716684d2b086b0ae88ce463df1f6fc19ea8863aed9ce206d791ec19cf9", "4c4eb6762fac662b259e12798162aa15d88bf32b897a2ec195f902b2a58491ec", "76677db355238e700a5446ec4c9aca17acc91bfa29875b73d433b6b5191b5afb"},
+			{"1b48346e3bd77d7fc1d674e22e77a1916fb9d070a866bce100a9371ee9d78048"},
+		}},
+	}
+	for _, test := range tests {
+		data := make([]byte, test.size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		var segments [][]byte
+		for i := 0; i < len(data); i += SegmentSize {
+			end := i + SegmentSize
+			if end > len(data) {
+				end = len(data)
+			}
+			segments = append(segments, data[i:end])
+		}
+
+		var expRoot Hash
+		if err := expRoot.LoadString(test.root); err != nil {
+			t.Fatal(err)
+		}
+		root, err := MerkleRootSegments(segments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != expRoot || MerkleRoot(data) != expRoot {
+			t.Fatalf("wrong root for %v bytes: got %v, expected %v", test.size, root, expRoot)
+		}
+
+		for i, expProof := range test.proofs {
+			hashSet, err := MerkleProofSegments(segments, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hashSet) != len(expProof) {
+				t.Fatalf("proof %v of %v bytes has %v hashes, expected %v", i, test.size, len(hashSet), len(expProof))
+			}
+			for j := range expProof {
+				if hashSet[j].String() != expProof[j] {
+					t.Fatalf("proof %v of %v bytes differs at hash %v", i, test.size, j)
+				}
+			}
+			if !VerifySegment(segments[i], hashSet, uint64(len(segments)), uint64(i), root) {
+				t.Fatalf("proof %v of %v bytes did not verify", i, test.size)
+			}
+		}
+	}
+}
+
+// TestMerkleSegmentsErrors checks that segments that do not match the layout
+// used by storage proofs are rejected.
+func TestMerkleSegmentsErrors(t *testing.T) {
+	full := make([]byte, SegmentSize)
+	short := make([]byte, SegmentSize/2)
+	long := make([]byte, SegmentSize+1)
+	for _, segments := range [][][]byte{
+		{short, full},
+		{full, long},
+		{full, {}},
+	} {
+		if _, err := MerkleRootSegments(segments); err != ErrBadSegmentSize {
+			t.Error("expected ErrBadSegmentSize, got", err)
+		}
+		if _, err := MerkleProofSegments(segments, 0); err != ErrBadSegmentSize {
+			t.Error("expected ErrBadSegmentSize, got", err)
+		}
+	}
+	for _, index := range []int{-1, 2} {
+		if _, err := MerkleProofSegments([][]byte{full, short}, index); err != ErrSegmentIndexOutOfRange {
+			t.Error("expected ErrSegmentIndexOutOfRange, got", err)
+		}
+	}
+}
@@ -12,6 +12,10 @@ import (
 )
 
 const (
+	// SignatureAlgorithm is the name of the signature algorithm used by
+	// PublicKey, SecretKey and Signature.
+	SignatureAlgorithm = "ed25519"
+
 	// EntropySize defines the amount of entropy necessary to do secure
 	// cryptographic operations, in bytes.
 	EntropySize = 32
@@ -42,8 +46,31 @@ type (
 	// Signature proves that data was signed by the owner of a particular
 	// public key's corresponding secret key.
 	Signature [SignatureSize]byte
+
+	// SignatureScheme describes a signature algorithm and the sizes of its
+	// keys and signatures, in bytes. It allows callers that marshal keys, such
+	// as external signers, to size their buffers without hardcoding the sizes.
+	SignatureScheme struct {
+		Algorithm     string `json:"algorithm"`
+		EntropySize   int    `json:"entropysize"`
+		PublicKeySize int    `json:"publickeysize"`
+		SecretKeySize int    `json:"secretkeysize"`
+		SignatureSize int    `json:"signaturesize"`
+	}
 )
 
+// KeyInfo returns the SignatureScheme used by PublicKey, SecretKey and
+// Signature.
+func KeyInfo() SignatureScheme {
+	return SignatureScheme{
+		Algorithm:     SignatureAlgorithm,
+		EntropySize:   EntropySize,
+		PublicKeySize: PublicKeySize,
+		SecretKeySize: SecretKeySize,
+		SignatureSize: SignatureSize,
+	}
+}
+
 // PublicKey returns the public key that corresponds to a secret key.
 func (sk SecretKey) PublicKey() (pk PublicKey) {
 	copy(pk[:], sk[SecretKeySize-PublicKeySize:])
@@ -70,6 +97,39 @@ func GenerateKeyPairDeterministic(entropy [EntropySize]byte) (sk SecretKey, pk P
 	return
 }
 
+// GenerateKeyPairs creates n public-secret keypairs. The entropy for all of
+// the keypairs is read in a single call, which is considerably cheaper than
+// calling GenerateKeyPair n times when many keys are needed at once.
+func GenerateKeyPairs(n int) ([]SecretKey, []PublicKey) {
+	// no error possible when using fastrand.Reader
+	sks, pks, _ := generateKeyPairs(fastrand.Reader, n)
+	return sks, pks
+}
+
+// generateKeyPairs reads n*EntropySize bytes of entropy from r and derives n
+// keypairs from it. If r cannot supply all of the entropy, no keys are
+// returned.
+func generateKeyPairs(r io.Reader, n int) ([]SecretKey, []PublicKey, error) {
+	if n <= 0 {
+		return nil, nil, nil
+	}
+	entropy := make([]byte, n*EntropySize)
+	defer SecureWipe(entropy)
+	if _, err := io.ReadFull(r, entropy); err != nil {
+		return nil, nil, err
+	}
+
+	sks := make([]SecretKey, n)
+	pks := make([]PublicKey, n)
+	var seed [EntropySize]byte
+	for i := range sks {
+		copy(seed[:], entropy[i*EntropySize:])
+		sks[i], pks[i] = GenerateKeyPairDeterministic(seed)
+	}
+	SecureWipe(seed[:])
+	return sks, pks, nil
+}
+
 // ReadSignedObject reads a length-prefixed object prefixed by its signature,
 // and verifies the signature.
 func ReadSignedObject(r io.Reader, obj interface{}, maxLen uint64, pk PublicKey) error {
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/encoding"
@@ -99,6 +100,74 @@ func TestUnitSigning(t *testing.T) {
 	}
 }
 
+// failingReader is an io.Reader that returns a fixed amount of data before
+// failing.
+type failingReader struct {
+	remaining int
+}
+
+// Read implements the io.Reader interface.
+func (fr *failingReader) Read(b []byte) (int, error) {
+	if fr.remaining == 0 {
+		return 0, errors.New("entropy source failed")
+	}
+	n := len(b)
+	if n > fr.remaining {
+		n = fr.remaining
+	}
+	fr.remaining -= n
+	return n, nil
+}
+
+// TestGenerateKeyPairs checks that GenerateKeyPairs produces distinct, usable
+// keys, that the keys match those derived deterministically from the same
+// entropy, and that no keys are returned if the entropy source fails.
+func TestGenerateKeyPairs(t *testing.T) {
+	message := HashBytes([]byte{'m', 's', 'g'})
+	sks, pks := GenerateKeyPairs(5)
+	if len(sks) != 5 || len(pks) != 5 {
+		t.Fatal("wrong number of keys generated:", len(sks), len(pks))
+	}
+	seen := make(map[PublicKey]struct{})
+	for i := range sks {
+		if sks[i].PublicKey() != pks[i] {
+			t.Error("secret key does not match public key", i)
+		}
+		if err := VerifyHash(message, pks[i], SignHash(message, sks[i])); err != nil {
+			t.Error(err)
+		}
+		seen[pks[i]] = struct{}{}
+	}
+	if len(seen) != len(pks) {
+		t.Error("generated keys are not distinct")
+	}
+
+	// Keys should match those derived from the same entropy one at a time.
+	entropy := fastrand.Bytes(3 * EntropySize)
+	sks, pks, err := generateKeyPairs(bytes.NewReader(entropy), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range sks {
+		var seed [EntropySize]byte
+		copy(seed[:], entropy[i*EntropySize:])
+		detSK, detPK := GenerateKeyPairDeterministic(seed)
+		if sks[i] != detSK || pks[i] != detPK {
+			t.Error("batch key does not match deterministic key", i)
+		}
+	}
+
+	// If the entropy source fails part way through, no keys should be
+	// returned.
+	sks, pks, err = generateKeyPairs(&failingReader{remaining: 2 * EntropySize}, 3)
+	if err == nil {
+		t.Fatal("expected error from failing entropy source")
+	}
+	if sks != nil || pks != nil {
+		t.Fatal("keys were returned despite entropy failure")
+	}
+}
+
 // TestIntegrationSigKeyGenerate is an integration test checking that
 // GenerateKeyPair and GenerateKeyPairDeterminisitc accurately create keys.
 func TestIntegrationSigKeyGeneration(t *testing.T) {
@@ -193,3 +262,23 @@ func TestUnitPublicKey(t *testing.T) {
 		}
 	}
 }
+
+// TestKeyInfo checks that KeyInfo reports the sizes of the key types.
+func TestKeyInfo(t *testing.T) {
+	info := KeyInfo()
+	if info.Algorithm != SignatureAlgorithm {
+		t.Error("wrong algorithm:", info.Algorithm)
+	}
+	sk, pk := GenerateKeyPair()
+	sig := SignHash(Hash{}, sk)
+	if info.PublicKeySize != len(pk) || info.SecretKeySize != len(sk) || info.SignatureSize != len(sig) {
+		t.Error("KeyInfo sizes do not match the key types:", info)
+	}
+	if info.EntropySize != EntropySize {
+		t.Error("wrong entropy size:", info.EntropySize)
+	}
+	// Marshalled keys take exactly as many bytes as reported.
+	if len(encoding.Marshal(pk)) != info.PublicKeySize || len(encoding.Marshal(sig)) != info.SignatureSize {
+		t.Error("marshalled sizes do not match KeyInfo")
+	}
+}
@@ -0,0 +1,97 @@
+package encoding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// frameHeaderSize is the size of the header preceding each frame: an 8-byte
+// sequence number followed by an 8-byte payload length.
+const frameHeaderSize = 16
+
+var (
+	// ErrFrameOutOfSequence is returned when a frame is received out of
+	// order, indicating that the two sides of the stream are out of sync.
+	ErrFrameOutOfSequence = errors.New("frame received out of sequence")
+
+	// ErrMalformedFrame is returned when the payload of a frame does not
+	// decode into exactly one object.
+	ErrMalformedFrame = errors.New("frame payload is malformed")
+
+	// ErrTruncatedFrame is returned when the stream ends partway through a
+	// frame.
+	ErrTruncatedFrame = errors.New("frame is truncated")
+)
+
+// A FramedStream reads and writes a sequence of objects over a single
+// connection. Each object is sent as a frame containing a sequence number and
+// the length of the encoded object, so that a peer which sends a malformed,
+// truncated, or unexpected message is detected as soon as the message is read
+// instead of corrupting the rest of the conversation.
+//
+// Both sides of a conversation must use a FramedStream, and each object
+// written by one side must be read by the other. A FramedStream is not safe
+// for concurrent use.
+type FramedStream struct {
+	rw       io.ReadWriter
+	readSeq  uint64
+	writeSeq uint64
+}
+
+// NewFramedStream returns a FramedStream that reads and writes frames using
+// rw.
+func NewFramedStream(rw io.ReadWriter) *FramedStream {
+	return &FramedStream{rw: rw}
+}
+
+// WriteObject encodes v and writes it to the stream as a single frame.
+func (fs *FramedStream) WriteObject(v interface{}) error {
+	payload := Marshal(v)
+	frame := make([]byte, frameHeaderSize+len(payload))
+	copy(frame, EncUint64(fs.writeSeq))
+	copy(frame[8:], EncUint64(uint64(len(payload))))
+	copy(frame[frameHeaderSize:], payload)
+	n, err := fs.rw.Write(frame)
+	if err == nil && n != len(frame) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		return err
+	}
+	fs.writeSeq++
+	return nil
+}
+
+// ReadObject reads the next frame from the stream and decodes it into obj.
+// The operation is aborted if the frame's payload exceeds maxLen bytes.
+func (fs *FramedStream) ReadObject(obj interface{}, maxLen uint64) error {
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(fs.rw, header); err == io.ErrUnexpectedEOF {
+		return ErrTruncatedFrame
+	} else if err != nil {
+		return err
+	}
+	if DecUint64(header[:8]) != fs.readSeq {
+		return ErrFrameOutOfSequence
+	}
+	payloadLen := DecUint64(header[8:])
+	if payloadLen > maxLen {
+		return fmt.Errorf("length %d exceeds maxLen of %d", payloadLen, maxLen)
+	}
+	payload := make([]byte, payloadLen)
+	if _, err := io.ReadFull(fs.rw, payload); err == io.ErrUnexpectedEOF || err == io.EOF {
+		return ErrTruncatedFrame
+	} else if err != nil {
+		return err
+	}
+	fs.readSeq++
+
+	// The payload must decode into exactly one object.
+	buf := bytes.NewBuffer(payload)
+	if err := NewDecoder(buf).Decode(obj); err != nil || buf.Len() != 0 {
+		return ErrMalformedFrame
+	}
+	return nil
+}
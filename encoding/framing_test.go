@@ -0,0 +1,71 @@
+package encoding
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestFramedStream checks that objects written to a FramedStream are read
+// back in order.
+func TestFramedStream(t *testing.T) {
+	buf := new(bytes.Buffer)
+	fs := NewFramedStream(buf)
+	if err := fs.WriteObject("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.WriteObject(uint64(7)); err != nil {
+		t.Fatal(err)
+	}
+
+	var s string
+	if err := fs.ReadObject(&s, 100); err != nil {
+		t.Fatal(err)
+	} else if s != "foo" {
+		t.Fatalf("expected foo, got %q", s)
+	}
+	var u uint64
+	if err := fs.ReadObject(&u, 100); err != nil {
+		t.Fatal(err)
+	} else if u != 7 {
+		t.Fatal("expected 7, got", u)
+	}
+
+	// Frames exceeding maxLen should be rejected.
+	fs.WriteObject("foo")
+	if err := fs.ReadObject(&s, 2); err == nil {
+		t.Fatal("expected oversized frame to be rejected")
+	}
+}
+
+// TestFramedStreamErrors checks that out of sequence, malformed, and truncated
+// frames are detected.
+func TestFramedStreamErrors(t *testing.T) {
+	// A frame read by a stream expecting a different sequence number.
+	buf := new(bytes.Buffer)
+	NewFramedStream(buf).WriteObject("foo")
+	fs := NewFramedStream(buf)
+	fs.readSeq = 1
+	var s string
+	if err := fs.ReadObject(&s, 100); err != ErrFrameOutOfSequence {
+		t.Fatal("expected ErrFrameOutOfSequence, got", err)
+	}
+
+	// A frame with trailing bytes after the object.
+	buf.Reset()
+	NewFramedStream(buf).WriteObject([]uint64{1, 2})
+	var u uint64
+	if err := NewFramedStream(buf).ReadObject(&u, 100); err != ErrMalformedFrame {
+		t.Fatal("expected ErrMalformedFrame, got", err)
+	}
+
+	// A frame that ends partway through the header or the payload.
+	buf.Reset()
+	NewFramedStream(buf).WriteObject("foo")
+	frame := buf.Bytes()
+	for _, n := range []int{frameHeaderSize / 2, len(frame) - 1} {
+		err := NewFramedStream(bytes.NewBuffer(frame[:n])).ReadObject(&s, 100)
+		if err != ErrTruncatedFrame {
+			t.Fatalf("expected ErrTruncatedFrame for %d bytes, got %v", n, err)
+		}
+	}
+}
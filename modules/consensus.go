@@ -2,6 +2,7 @@ package modules
 
 import (
 	"errors"
+	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
@@ -18,6 +19,20 @@ const (
 	// DiffRevert indicates that a diff is being reverted from the consensus
 	// set.
 	DiffRevert DiffDirection = false
+
+	// FileContractCreated indicates that a file contract was created.
+	FileContractCreated FileContractEventType = "created"
+
+	// FileContractRevised indicates that a file contract was revised.
+	FileContractRevised FileContractEventType = "revised"
+
+	// FileContractValidProof indicates that a valid storage proof was
+	// submitted for a file contract, paying out its valid proof outputs.
+	FileContractValidProof FileContractEventType = "validproof"
+
+	// FileContractMissedProof indicates that a file contract expired without
+	// a storage proof, paying out its missed proof outputs.
+	FileContractMissedProof FileContractEventType = "missedproof"
 )
 
 var (
@@ -70,6 +85,48 @@ type (
 		ProcessConsensusChange(ConsensusChange)
 	}
 
+	// A FileContractSubscriber is an object that receives the file contract
+	// events of every change in consensus.
+	FileContractSubscriber interface {
+		// ProcessFileContractEvents sends the file contract events of a
+		// consensus change to a module through a function call. It is called
+		// for every consensus change, in order, even if the change contains
+		// no events, so that the ID can be used to resume the subscription.
+		ProcessFileContractEvents(ConsensusChangeID, []FileContractEvent)
+	}
+
+	// A FileContractEventType identifies a stage in the lifecycle of a file
+	// contract.
+	FileContractEventType string
+
+	// A FileContractEvent records a change in the lifecycle of a file
+	// contract.
+	FileContractEvent struct {
+		// Direction is DiffApply for events of applied blocks, and
+		// DiffRevert for events of reverted blocks, which should be undone.
+		Direction DiffDirection
+		ID        types.FileContractID
+		Type      FileContractEventType
+		Height    types.BlockHeight
+
+		// RevisionNumber is the revision number of the contract after the
+		// event, or at the time it resolved for proof events.
+		RevisionNumber uint64
+
+		// Payouts are the outputs paid by the contract, and are only set for
+		// FileContractValidProof and FileContractMissedProof events.
+		Payouts []FileContractPayout
+	}
+
+	// A FileContractPayout is a siacoin output created when a file contract
+	// resolves. It can be spent once the block height reaches
+	// MaturityHeight.
+	FileContractPayout struct {
+		ID             types.SiacoinOutputID
+		SiacoinOutput  types.SiacoinOutput
+		MaturityHeight types.BlockHeight
+	}
+
 	// A ConsensusChange enumerates a set of changes that occurred to the consensus set.
 	ConsensusChange struct {
 		// ID is a unique id for the consensus change derived from the reverted
@@ -88,6 +145,18 @@ type (
 		// applied.
 		AppliedBlocks []types.Block
 
+		// ReorgDepth is the number of blocks that were reverted by the change.
+		// It is nonzero only if the change replaced blocks of the current
+		// path with blocks of a heavier fork.
+		ReorgDepth types.BlockHeight
+
+		// RevertedTransactions contains the IDs of the transactions of the
+		// reverted blocks that are not confirmed again by the applied blocks,
+		// in the order that they were reverted. These transactions are no
+		// longer confirmed; they may be confirmed again later or may have
+		// been invalidated by the fork.
+		RevertedTransactions []types.TransactionID
+
 		// SiacoinOutputDiffs contains the set of siacoin diffs that were applied
 		// to the consensus set in the recent change. The direction for the set of
 		// diffs is 'DiffApply'.
@@ -111,6 +180,11 @@ type (
 		// consensus set in the recent change.
 		SiafundPoolDiffs []SiafundPoolDiff
 
+		// FileContractEvents are the file contract events of the reverted and
+		// applied blocks, in the order that the blocks were reverted and
+		// applied.
+		FileContractEvents []FileContractEvent
+
 		// ChildTarget defines the target of any block that would be the child
 		// of the block most recently appended to the consensus set.
 		ChildTarget types.Target
@@ -175,6 +249,22 @@ type (
 		Adjusted  types.Currency
 	}
 
+	// A SiacoinOutputProof proves that a transaction, and therefore the
+	// siacoin outputs it creates, is part of a block. The proof consists of
+	// the header of the block and a Merkle proof that the transaction is a
+	// leaf of the block's Merkle tree, whose leaves are the block's miner
+	// payouts followed by its transactions.
+	//
+	// A proof shows that an output was created, not that it is unspent: Sia
+	// blocks do not commit to the set of unspent outputs.
+	SiacoinOutputProof struct {
+		Header      types.BlockHeader `json:"header"`
+		Transaction types.Transaction `json:"transaction"`
+		LeafIndex   uint64            `json:"leafindex"`
+		NumLeaves   uint64            `json:"numleaves"`
+		HashSet     []crypto.Hash     `json:"hashset"`
+	}
+
 	// A ConsensusSet accepts blocks and builds an understanding of network
 	// consensus.
 	ConsensusSet interface {
@@ -186,10 +276,19 @@ type (
 		// still be returned.
 		AcceptBlock(types.Block) error
 
+		// AcceptBlockWithChange behaves like AcceptBlock, but also returns the
+		// consensus change that resulted from accepting the block. The change
+		// is the same one that is sent to subscribers, and includes any blocks
+		// that were reverted if the block caused a reorg.
+		AcceptBlockWithChange(types.Block) (ConsensusChange, error)
+
 		// BlockAtHeight returns the block found at the input height, with a
 		// bool to indicate whether that block exists.
 		BlockAtHeight(types.BlockHeight) (types.Block, bool)
 
+		// BlockSizeLimit returns the maximum encoded size of a block.
+		BlockSizeLimit() uint64
+
 		// ChildTarget returns the target required to extend the current heaviest
 		// fork. This function is typically used by miners looking to extend the
 		// heaviest fork.
@@ -206,30 +305,83 @@ type (
 		// A channel can be provided to abort the subscription process.
 		ConsensusSetSubscribe(ConsensusSetSubscriber, ConsensusChangeID, <-chan struct{}) error
 
+		// ConsensusSetFileContractSubscribe behaves like
+		// ConsensusSetSubscribe, but only delivers the file contract events
+		// of each consensus change.
+		ConsensusSetFileContractSubscribe(FileContractSubscriber, ConsensusChangeID, <-chan struct{}) error
+
+		// BlockTimeStats returns the average time between the last 'window'
+		// blocks of the current path, along with the target that the next
+		// block must meet. Fewer blocks are averaged if the blockchain is
+		// shorter than the window.
+		BlockTimeStats(window int) (avgInterval time.Duration, currentTarget types.Target)
+
+		// ClearDoSBlock removes a block from the set of blocks known to be
+		// invalid, allowing it to be resubmitted. This is useful after an
+		// upgrade that changes the validation rules.
+		ClearDoSBlock(types.BlockID)
+
+		// CirculatingSupply returns the number of siacoins that have been
+		// issued at the current height, excluding siacoins that are known to
+		// have been burned.
+		CirculatingSupply() types.Currency
+
 		// CurrentBlock returns the latest block in the heaviest known
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// DoSBlocks returns the ids of all blocks that have been marked as
+		// invalid by the consensus set.
+		DoSBlocks() []types.BlockID
+
 		// Flush will cause the consensus set to finish all in-progress
 		// routines.
 		Flush() error
 
+		// ForEachSiacoinOutput calls fn on every unspent siacoin output,
+		// stopping at and returning the first error returned by fn. The
+		// outputs are read from a consistent snapshot of the consensus set.
+		ForEachSiacoinOutput(fn func(types.SiacoinOutputID, types.SiacoinOutput) error) error
+
+		// ForEachSiafundOutput calls fn on every unspent siafund output,
+		// stopping at and returning the first error returned by fn. The
+		// outputs are read from a consistent snapshot of the consensus set.
+		ForEachSiafundOutput(fn func(types.SiafundOutputID, types.SiafundOutput) error) error
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
 		// Synced returns true if the consensus set is synced with the network.
 		Synced() bool
 
+		// TotalSupply returns the number of siacoins that have been issued at
+		// the current height.
+		TotalSupply() types.Currency
+
 		// InCurrentPath returns true if the block id presented is found in the
 		// current path, false otherwise.
 		InCurrentPath(types.BlockID) bool
 
+		// MaturityDelay returns the number of blocks that miner payouts and
+		// other delayed siacoin outputs wait before they can be spent.
+		MaturityDelay() types.BlockHeight
+
+		// MinerPayoutMaturityHeight returns the height at which a delayed
+		// siacoin output, such as a miner payout, becomes spendable. false is
+		// returned if the output is not delayed.
+		MinerPayoutMaturityHeight(types.SiacoinOutputID) (types.BlockHeight, bool)
+
 		// MinimumValidChildTimestamp returns the earliest timestamp that is
 		// valid on the current longest fork according to the consensus set. This is
 		// a required piece of information for the miner, who could otherwise be at
 		// risk of mining invalid blocks.
 		MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool)
 
+		// SiafundOutput returns the unspent siafund output with the given id,
+		// along with the siacoins that the output could currently claim from
+		// the siafund pool. false is returned if the output does not exist.
+		SiafundOutput(types.SiafundOutputID) (types.SiafundOutput, types.Currency, bool)
+
 		// StorageProofSegment returns the segment to be used in the storage proof for
 		// a given file contract.
 		StorageProofSegment(types.FileContractID) (uint64, error)
@@ -244,6 +396,56 @@ type (
 		// allowing for garbage collection and rescanning. If the subscriber is
 		// not found in the subscriber database, no action is taken.
 		Unsubscribe(ConsensusSetSubscriber)
+
+		// UnsubscribeFileContracts removes a subscriber that was added with
+		// ConsensusSetFileContractSubscribe.
+		UnsubscribeFileContracts(FileContractSubscriber)
+
+		// View calls fn with a read-only view of the consensus set. All
+		// lookups made through the view during fn see the same, consistent
+		// state, and share a single database transaction. The view must not
+		// be used after fn returns. The error returned by fn is returned by
+		// View.
+		View(fn func(ConsensusView) error) error
+	}
+
+	// ConsensusView is a read-only snapshot of the consensus set, provided to
+	// the callback of ConsensusSet.View. A ConsensusView is only valid until
+	// the callback returns.
+	ConsensusView interface {
+		// BlockAtHeight returns the block at the given height in the current
+		// path, with a bool to indicate whether that block exists.
+		BlockAtHeight(types.BlockHeight) (types.Block, bool)
+
+		// BlockByID returns the block with the given id and its height,
+		// with a bool to indicate whether the block is known. The block may
+		// be on a fork other than the current path; use InCurrentPath to
+		// check.
+		BlockByID(types.BlockID) (types.Block, types.BlockHeight, bool)
+
+		// CurrentBlock returns the latest block in the current path.
+		CurrentBlock() types.Block
+
+		// FileContract returns the unresolved file contract with the given
+		// id, with a bool to indicate whether it exists.
+		FileContract(types.FileContractID) (types.FileContract, bool)
+
+		// Height returns the height of the current path.
+		Height() types.BlockHeight
+
+		// InCurrentPath returns true if the block is in the current path.
+		InCurrentPath(types.BlockID) bool
+
+		// SiacoinOutput returns the unspent siacoin output with the given id,
+		// with a bool to indicate whether it exists.
+		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
+
+		// SiafundOutput returns the unspent siafund output with the given id,
+		// with a bool to indicate whether it exists.
+		SiafundOutput(types.SiafundOutputID) (types.SiafundOutput, bool)
+
+		// SiafundPool returns the number of siacoins in the siafund pool.
+		SiafundPool() types.Currency
 	}
 )
 
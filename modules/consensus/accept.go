@@ -19,6 +19,8 @@ var (
 	errNoBlockMap      = errors.New("block map is not in database")
 	errNonLinearChain  = errors.New("block set is not a contiguous chain")
 	errOrphan          = errors.New("block has no known parent")
+
+	errUnexpectedChanges = errors.New("accepting a single block produced an unexpected number of consensus changes")
 )
 
 // managedBroadcastBlock will broadcast a block to the consensus set's peers.
@@ -138,9 +140,11 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 
 // addBlockToTree inserts a block into the blockNode tree by adding it to its
 // parent's list of children. If the new blockNode is heavier than the current
-// node, the blockchain is forked to put the new block and its parents at the
-// tip. An error will be returned if block verification fails or if the block
-// does not extend the longest fork.
+// node, or exactly as heavy and wins the tie-breaker after the
+// TieBreakHardforkBlock, the blockchain is forked to put the new block and its
+// parents at the tip. An error will be returned
+// if block verification fails or if the block does not extend the longest
+// fork.
 //
 // addBlockToTree might need to modify the database while returning an error
 // on the block. Such errors are handled outside of the transaction by the
@@ -150,11 +154,11 @@ func (cs *ConsensusSet) addBlockToTree(tx *bolt.Tx, b types.Block, parent *proce
 	// Prepare the child processed block associated with the parent block.
 	newNode := cs.newChild(tx, parent, b)
 
-	// Check whether the new node is part of a chain that is heavier than the
+	// Check whether the new node is part of a chain that should replace the
 	// current node. If not, return ErrNonExtending and don't fork the
 	// blockchain.
 	currentNode := currentProcessedBlock(tx)
-	if !newNode.heavierThan(currentNode) {
+	if !newNode.replaces(currentNode) {
 		return changeEntry{}, modules.ErrNonExtendingBlock
 	}
 
@@ -219,10 +223,31 @@ func (cs *ConsensusSet) threadedSleepOnFutureBlock(b types.Block) {
 // consecutive calls to AcceptBlock with each successive call accepting the
 // child block of the previous call.
 func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExtended bool, err error) {
-	// Grab a lock on the consensus set.
+	_, blockchainExtended, err = cs.managedAcceptBlocksWithChanges(blocks)
+	return blockchainExtended, err
+}
+
+// managedAcceptBlocksWithChanges is the implementation of managedAcceptBlocks.
+// In addition to reporting whether the blockchain was extended, it returns the
+// consensus changes that were sent to subscribers as a result of accepting the
+// blocks.
+//
+// Orphan blocks are cached until their parent arrives. Once the blocks have
+// been added, the cached orphans that they are the parents of are tried
+// again.
+func (cs *ConsensusSet) managedAcceptBlocksWithChanges(blocks []types.Block) (ccs []modules.ConsensusChange, blockchainExtended bool, err error) {
 	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	ccs, blockchainExtended, err = cs.acceptBlocks(blocks)
+	adopted := cs.adoptOrphans(blocks)
+	cs.mu.Unlock()
 
+	cs.managedAcceptOrphans(adopted)
+	return ccs, blockchainExtended, err
+}
+
+// acceptBlocks is the implementation of managedAcceptBlocksWithChanges. The
+// caller must hold the consensus set's lock.
+func (cs *ConsensusSet) acceptBlocks(blocks []types.Block) (ccs []modules.ConsensusChange, blockchainExtended bool, err error) {
 	// Make sure that blocks are consecutive. Though this isn't a strict
 	// requirement, if blocks are not consecutive then it becomes a lot harder
 	// to maintain correcetness when adding multiple blocks in a single tx.
@@ -232,7 +257,7 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	for i := 0; i < len(blocks); i++ {
 		blockIDs = append(blockIDs, blocks[i].ID())
 		if i > 0 && blocks[i].ParentID != blockIDs[i-1] {
-			return false, errNonLinearChain
+			return nil, false, errNonLinearChain
 		}
 	}
 
@@ -254,6 +279,10 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 				// Queue the block to be tried again if it is a future block.
 				go cs.threadedSleepOnFutureBlock(blocks[i])
 			}
+			if err == errOrphan {
+				// Hold the block until its parent arrives.
+				cs.orphans.add(blocks[i], blockIDs[i])
+			}
 			if err != nil {
 				return err
 			}
@@ -291,7 +320,7 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 		// Check if any blocks were valid.
 		if len(validBlocks) < 1 {
 			// Nothing more to do, the first block was invalid.
-			return false, setErr
+			return nil, false, setErr
 		}
 
 		// At least some of the blocks were valid. Add the valid blocks before
@@ -320,13 +349,13 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 		// reached. If it is, return early because both attempts to add blocks
 		// have failed.
 		if err != nil {
-			return false, err
+			return nil, false, err
 		}
 	}
 
 	// Stop here if the blocks did not extend the longest blockchain.
 	if !chainExtended {
-		return false, modules.ErrNonExtendingBlock
+		return nil, false, modules.ErrNonExtendingBlock
 	}
 
 	// Sanity check - if we get here, len(changes) should be non-zero.
@@ -337,16 +366,16 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 	// Update the subscribers with all of the consensus changes. First combine
 	// the changes into a single set.
 	for _, change := range changes {
-		cs.updateSubscribers(change)
+		ccs = append(ccs, cs.updateSubscribers(change))
 	}
 
 	// If there were valid blocks and invalid blocks in the set that was
 	// provided, then the setErr is not going to be nil. Return the set error to
 	// the caller.
 	if setErr != nil {
-		return chainExtended, setErr
+		return ccs, chainExtended, setErr
 	}
-	return chainExtended, nil
+	return ccs, chainExtended, nil
 }
 
 // AcceptBlock will try to add a block to the consensus set. If the block does
@@ -357,18 +386,35 @@ func (cs *ConsensusSet) managedAcceptBlocks(blocks []types.Block) (blockchainExt
 // without error, it will be relayed to all connected peers. This function
 // should only be called for new blocks.
 func (cs *ConsensusSet) AcceptBlock(b types.Block) error {
+	_, err := cs.AcceptBlockWithChange(b)
+	return err
+}
+
+// AcceptBlockWithChange behaves like AcceptBlock, but also returns the
+// consensus change produced by accepting the block. This allows callers that
+// submit a block to act on its effects without subscribing to the consensus
+// set. If the block does not extend the longest chain, an empty change is
+// returned alongside modules.ErrNonExtendingBlock. The block is still
+// accepted if the error is errUnexpectedChanges, which is never expected.
+func (cs *ConsensusSet) AcceptBlockWithChange(b types.Block) (modules.ConsensusChange, error) {
 	err := cs.tg.Add()
 	if err != nil {
-		return err
+		return modules.ConsensusChange{}, err
 	}
 	defer cs.tg.Done()
 
-	chainExtended, err := cs.managedAcceptBlocks([]types.Block{b})
+	ccs, chainExtended, err := cs.managedAcceptBlocksWithChanges([]types.Block{b})
 	if err != nil {
-		return err
+		return modules.ConsensusChange{}, err
 	}
 	if chainExtended {
 		cs.managedBroadcastBlock(b)
 	}
-	return nil
+	// Accepting a single extending block should produce exactly one consensus
+	// change. If it did not, no single change describes the block's effects,
+	// so none is returned.
+	if len(ccs) != 1 {
+		return modules.ConsensusChange{}, errUnexpectedChanges
+	}
+	return ccs[0], nil
 }
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -328,3 +329,79 @@ func TestBuriedBadFork(t *testing.T) {
 		t.Fatal("a bad block failed to cause an error")
 	}
 }
+
+// TestEqualWeightForks creates competing blocks of equal weight on separate
+// consensus sets, and checks that both sets settle on the same block
+// regardless of the order in which they receive the blocks once the
+// TieBreakHardforkBlock has been reached, while each set keeps the block it
+// saw first before the hardfork.
+func TestEqualWeightForks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := blankConsensusSetTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst1.Close()
+	cst2, err := blankConsensusSetTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+
+	// competingBlocks has each set mine a block on its current tip and gives
+	// each set the two blocks in the opposite order. The blocks pay different
+	// wallets, so they have different IDs but the same weight.
+	competingBlocks := func() (types.Block, types.Block) {
+		b1, _ := cst1.miner.FindBlock()
+		b2, _ := cst2.miner.FindBlock()
+		if b1.ID() == b2.ID() {
+			t.Fatal("competing blocks should differ")
+		}
+		for _, b := range []types.Block{b1, b2} {
+			err := cst1.cs.AcceptBlock(b)
+			if err != nil && err != modules.ErrNonExtendingBlock {
+				t.Fatal(err)
+			}
+		}
+		for _, b := range []types.Block{b2, b1} {
+			err := cst2.cs.AcceptBlock(b)
+			if err != nil && err != modules.ErrNonExtendingBlock {
+				t.Fatal(err)
+			}
+		}
+		return b1, b2
+	}
+
+	// Before the hardfork, each set keeps the block it saw first.
+	b1, b2 := competingBlocks()
+	if cst1.cs.CurrentBlock().ID() != b1.ID() || cst2.cs.CurrentBlock().ID() != b2.ID() {
+		t.Fatal("tie was broken before the hardfork")
+	}
+
+	// Bring both sets onto the same chain and past the hardfork.
+	for cst1.cs.Height() < types.TieBreakHardforkBlock {
+		b, err := cst1.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = cst2.cs.AcceptBlock(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b1, b2 = competingBlocks()
+	winner := b1.ID()
+	if id2 := b2.ID(); bytes.Compare(id2[:], winner[:]) < 0 {
+		winner = id2
+	}
+	if cst1.cs.CurrentBlock().ID() != winner || cst2.cs.CurrentBlock().ID() != winner {
+		t.Fatal("consensus sets did not settle on the block with the smaller id")
+	}
+	if cst1.cs.dbConsensusChecksum() != cst2.cs.dbConsensusChecksum() {
+		t.Fatal("consensus sets have different checksums after an equal-weight fork")
+	}
+}
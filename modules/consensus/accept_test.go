@@ -533,6 +533,22 @@ func TestIntegrationDoSBlockHandling(t *testing.T) {
 	if err != errDoSBlock {
 		t.Fatalf("expected %v, got %v", errDoSBlock, err)
 	}
+
+	// The block should be reported as a DoS block.
+	dosBlocks := cst.cs.DoSBlocks()
+	if len(dosBlocks) != 1 || dosBlocks[0] != dosBlock.ID() {
+		t.Fatal("expected the invalid block to be the only DoS block, got", dosBlocks)
+	}
+
+	// After clearing the block, it should be validated again when submitted.
+	cst.cs.ClearDoSBlock(dosBlock.ID())
+	if len(cst.cs.DoSBlocks()) != 0 {
+		t.Fatal("DoS block was not cleared")
+	}
+	err = cst.cs.AcceptBlock(dosBlock)
+	if err != errSiacoinInputOutputMismatch {
+		t.Fatalf("expected %v, got %v", errSiacoinInputOutputMismatch, err)
+	}
 }
 
 // TestBlockKnownHandling submits known blocks to the consensus set.
@@ -625,6 +641,60 @@ func TestOrphanHandling(t *testing.T) {
 	}
 }
 
+// TestOrphanAdoption checks that an orphan block is accepted once its parent
+// arrives.
+func TestOrphanAdoption(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := createConsensusSetTester(t.Name() + "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst1.Close()
+	cst2, err := blankConsensusSetTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+	for cst2.cs.Height() < cst1.cs.Height() {
+		id, err := cst1.cs.dbGetPath(cst2.cs.Height() + 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pb, err := cst1.cs.dbGetBlockMap(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cst2.cs.AcceptBlock(pb.Block); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mine two blocks on cst1 and give them to cst2 in the opposite order.
+	parent, err := cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cst2.cs.AcceptBlock(child); err != errOrphan {
+		t.Fatalf("expected %v, got %v", errOrphan, err)
+	}
+	if err := cst2.cs.AcceptBlock(parent); err != nil {
+		t.Fatal(err)
+	}
+	if cst2.cs.CurrentBlock().ID() != child.ID() {
+		t.Fatal("cached orphan was not accepted when its parent arrived")
+	}
+	if cst2.cs.orphans.len() != 0 {
+		t.Fatal("accepted orphan should be removed from the cache")
+	}
+}
+
 // TestMissedTarget submits a block that does not meet the required target.
 func TestMissedTarget(t *testing.T) {
 	if testing.Short() {
@@ -682,6 +752,51 @@ func TestMinerPayoutHandling(t *testing.T) {
 	}
 }
 
+// TestMinerPayoutMaturityHeight checks that the consensus set reports when a
+// miner payout matures, and stops reporting it once the payout is spendable.
+func TestMinerPayoutMaturityHeight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	block, err := cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mpid := block.MinerPayoutID(0)
+	height, exists := cst.cs.MinerPayoutMaturityHeight(mpid)
+	if !exists {
+		t.Fatal("miner payout should be delayed")
+	}
+	if expected := cst.cs.Height() + types.MaturityDelay; height != expected {
+		t.Fatalf("expected maturity height %v, got %v", expected, height)
+	}
+
+	// Unknown outputs are not reported.
+	if _, exists := cst.cs.MinerPayoutMaturityHeight(types.SiacoinOutputID{}); exists {
+		t.Fatal("unknown output should not have a maturity height")
+	}
+
+	// Once the payout has matured it is no longer delayed.
+	for i := types.BlockHeight(0); i < types.MaturityDelay; i++ {
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, exists := cst.cs.MinerPayoutMaturityHeight(mpid); exists {
+		t.Fatal("matured payout should not have a maturity height")
+	}
+	if _, err := cst.cs.dbGetSiacoinOutput(mpid); err != nil {
+		t.Fatal("matured payout should be in the siacoin output set:", err)
+	}
+}
+
 // TestEarlyTimestampHandling checks that blocks too far in the past are
 // rejected.
 func TestEarlyTimestampHandling(t *testing.T) {
@@ -861,6 +976,75 @@ func TestInconsistentCheck(t *testing.T) {
 	}
 }
 
+// TestRepeatSiacoinOutput checks that, after the RepeatOutputHardforkBlock, a
+// block creating a siacoin output that already exists is rejected by
+// AcceptBlock instead of triggering a panic when the output is applied.
+func TestRepeatSiacoinOutput(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	for cst.cs.Height() < types.RepeatOutputHardforkBlock {
+		_, err = cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A transaction with no inputs and a single zero-value output is valid,
+	// and including it twice results in two outputs with the same id.
+	txn := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.ZeroCurrency}},
+	}
+
+	// Submit a block containing the transaction twice.
+	block, target, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, txn, txn)
+	solvedBlock, _ := cst.miner.SolveBlock(block, target)
+	height := cst.cs.Height()
+	err = cst.cs.AcceptBlock(solvedBlock)
+	if err != errRepeatSiacoinOutput {
+		t.Fatalf("expected %v, got %v", errRepeatSiacoinOutput, err)
+	}
+	if cst.cs.Height() != height {
+		t.Fatal("block with repeated siacoin output was added to the consensus set")
+	}
+
+	// Submit a block containing the transaction once, followed by a block
+	// that repeats it.
+	block, target, err = cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, txn)
+	solvedBlock, _ = cst.miner.SolveBlock(block, target)
+	err = cst.cs.AcceptBlock(solvedBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, target, err = cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, txn)
+	solvedBlock, _ = cst.miner.SolveBlock(block, target)
+	err = cst.cs.AcceptBlock(solvedBlock)
+	if err != errRepeatSiacoinOutput {
+		t.Fatalf("expected %v, got %v", errRepeatSiacoinOutput, err)
+	}
+	if cst.cs.Height() != height+1 {
+		t.Fatal("block with repeated siacoin output was added to the consensus set")
+	}
+}
+
 // COMPATv0.4.0
 //
 // This test checks that the hardfork scheduled for block 21,000 rolls through
@@ -1036,6 +1220,52 @@ func TestAcceptBlockBroadcasts(t *testing.T) {
 	}
 }
 
+// TestAcceptBlockWithChange checks that AcceptBlockWithChange returns the same
+// consensus change that is delivered to subscribers.
+func TestAcceptBlockWithChange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	ms := newMockSubscriber()
+	err = cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeRecent, cst.cs.tg.StopChan())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := cst.miner.FindBlock()
+	cc, err := cst.cs.AcceptBlockWithChange(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cc.AppliedBlocks) != 1 || cc.AppliedBlocks[0].ID() != b.ID() {
+		t.Fatal("consensus change does not apply the accepted block")
+	}
+	if len(cc.RevertedBlocks) != 0 {
+		t.Fatal("consensus change unexpectedly reverts blocks")
+	}
+	if len(cc.SiacoinOutputDiffs) == 0 {
+		t.Fatal("consensus change is missing the miner payout diffs")
+	}
+	if len(ms.updates) == 0 || ms.updates[len(ms.updates)-1].ID != cc.ID {
+		t.Fatal("returned consensus change does not match the one sent to subscribers")
+	}
+
+	// Accepting the same block again should fail and return an empty change.
+	cc, err = cst.cs.AcceptBlockWithChange(b)
+	if err != modules.ErrBlockKnown {
+		t.Fatal("expected ErrBlockKnown, got", err)
+	}
+	if len(cc.AppliedBlocks) != 0 {
+		t.Fatal("expected an empty consensus change for a rejected block")
+	}
+}
+
 // blockCountingSubscriber counts the number of blocks that get submitted to the
 // subscriber, as well as the number of times that the subscriber has been given
 // changes at all.
@@ -1170,3 +1400,75 @@ func TestChainedAcceptBlock(t *testing.T) {
 		}
 	}
 }
+
+// TestSiafundOutputClaim checks that the consensus set reports the siacoins
+// that a siafund output can claim from the siafund pool.
+func TestSiafundOutputClaim(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Add siacoins to the siafund pool by creating a file contract.
+	payout := types.NewCurrency64(400e6)
+	outputSize := types.PostTax(cst.cs.dbBlockHeight(), payout)
+	fc := types.FileContract{
+		WindowStart:        cst.cs.dbBlockHeight() + 12,
+		WindowEnd:          cst.cs.dbBlockHeight() + 14,
+		Payout:             payout,
+		ValidProofOutputs:  []types.SiacoinOutput{{Value: outputSize}},
+		MissedProofOutputs: []types.SiacoinOutput{{Value: outputSize}},
+		UnlockHash:         types.UnlockConditions{}.UnlockHash(),
+	}
+	txnBuilder := cst.wallet.StartTransaction()
+	err = txnBuilder.FundSiacoins(payout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddFileContract(fc)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cst.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := cst.cs.dbGetSiafundPool()
+	if pool.IsZero() {
+		t.Fatal("siafund pool was not increased")
+	}
+
+	// An output created before the pool grew claims its share of the pool,
+	// and an output created afterwards claims nothing.
+	oldID, newID := types.SiafundOutputID{1}, types.SiafundOutputID{2}
+	cst.cs.dbAddSiafundOutput(oldID, types.SiafundOutput{Value: types.NewCurrency64(100)})
+	cst.cs.dbAddSiafundOutput(newID, types.SiafundOutput{Value: types.NewCurrency64(100), ClaimStart: pool})
+	sfo, claim, exists := cst.cs.SiafundOutput(oldID)
+	if !exists {
+		t.Fatal("siafund output was not found")
+	}
+	if !sfo.Value.Equals64(100) {
+		t.Fatal("wrong siafund output returned:", sfo.Value)
+	}
+	if expected := pool.Div(types.SiafundCount).Mul64(100); !claim.Equals(expected) {
+		t.Fatalf("expected claim %v, got %v", expected, claim)
+	}
+	if _, claim, _ := cst.cs.SiafundOutput(newID); !claim.IsZero() {
+		t.Fatal("new siafund output should not have a claim, got", claim)
+	}
+
+	// Unknown outputs are not reported.
+	if _, _, exists := cst.cs.SiafundOutput(types.SiafundOutputID{3}); exists {
+		t.Fatal("unknown siafund output was reported")
+	}
+}
@@ -131,7 +131,7 @@ func applyStorageProofs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 				Direction:      modules.DiffApply,
 				ID:             spoid,
 				SiacoinOutput:  vpo,
-				MaturityHeight: pb.Height + types.MaturityDelay,
+				MaturityHeight: pb.Height + maturityDelay(tx),
 			}
 			pb.DelayedSiacoinOutputDiffs = append(pb.DelayedSiacoinOutputDiffs, dscod)
 			commitDelayedSiacoinOutputDiff(tx, dscod, modules.DiffApply)
@@ -147,6 +147,13 @@ func applyStorageProofs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 	}
 }
 
+// siafundClaim returns the siacoins that the siafund output can claim from the
+// siafund pool: its share of everything added to the pool since the output
+// was created.
+func siafundClaim(pool types.Currency, sfo types.SiafundOutput) types.Currency {
+	return pool.Sub(sfo.ClaimStart).Div(types.SiafundCount).Mul(sfo.Value)
+}
+
 // applyTxSiafundInputs takes all of the siafund inputs in a transaction and
 // applies them to the state, updating the diffs in the processed block.
 func applySiafundInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
@@ -156,7 +163,7 @@ func applySiafundInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 		if build.DEBUG && err != nil {
 			panic(err)
 		}
-		claimPortion := getSiafundPool(tx).Sub(sfo.ClaimStart).Div(types.SiafundCount).Mul(sfo.Value)
+		claimPortion := siafundClaim(getSiafundPool(tx), sfo)
 
 		// Add the claim output to the delayed set of outputs.
 		sco := types.SiacoinOutput{
@@ -168,7 +175,7 @@ func applySiafundInputs(tx *bolt.Tx, pb *processedBlock, t types.Transaction) {
 			Direction:      modules.DiffApply,
 			ID:             sfoid,
 			SiacoinOutput:  sco,
-			MaturityHeight: pb.Height + types.MaturityDelay,
+			MaturityHeight: pb.Height + maturityDelay(tx),
 		}
 		pb.DelayedSiacoinOutputDiffs = append(pb.DelayedSiacoinOutputDiffs, dscod)
 		commitDelayedSiacoinOutputDiff(tx, dscod, modules.DiffApply)
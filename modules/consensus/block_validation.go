@@ -31,13 +31,23 @@ type stdBlockValidator struct {
 
 	// marshaler encodes and decodes between objects and byte slices.
 	marshaler marshaler
+
+	// sizeLimit is the maximum encoded size of a valid block.
+	sizeLimit uint64
 }
 
 // NewBlockValidator creates a new stdBlockValidator with default settings.
 func NewBlockValidator() stdBlockValidator {
+	return newBlockValidator(types.BlockSizeLimit)
+}
+
+// newBlockValidator creates a new stdBlockValidator that rejects blocks larger
+// than sizeLimit.
+func newBlockValidator(sizeLimit uint64) stdBlockValidator {
 	return stdBlockValidator{
 		clock:     types.StdClock{},
 		marshaler: stdMarshaler{},
+		sizeLimit: sizeLimit,
 	}
 }
 
@@ -76,7 +86,7 @@ func (bv stdBlockValidator) ValidateBlock(b types.Block, id types.BlockID, minTi
 
 	// Check that the block is below the size limit.
 	blockSize := len(bv.marshaler.Marshal(b))
-	if uint64(blockSize) > types.BlockSizeLimit {
+	if uint64(blockSize) > bv.sizeLimit {
 		return errLargeBlock
 	}
 
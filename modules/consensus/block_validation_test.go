@@ -77,6 +77,7 @@ func TestUnitValidateBlock(t *testing.T) {
 			clock: mockClock{
 				now: tt.now,
 			},
+			sizeLimit: types.BlockSizeLimit,
 		}
 		err := blockValidator.ValidateBlock(b, b.ID(), tt.minTimestamp, types.RootDepth, 0, nil)
 		if err != tt.errWant {
@@ -85,6 +86,28 @@ func TestUnitValidateBlock(t *testing.T) {
 	}
 }
 
+// TestValidateBlockSizeLimit checks that ValidateBlock enforces the size limit
+// of the validator rather than types.BlockSizeLimit.
+func TestValidateBlockSizeLimit(t *testing.T) {
+	b := types.Block{}
+	for _, limit := range []uint64{types.BlockSizeLimit / 2, types.BlockSizeLimit * 2} {
+		bv := stdBlockValidator{
+			marshaler: mockMarshaler{marshalLength: limit},
+			clock:     mockClock{},
+			sizeLimit: limit,
+		}
+		// A block at the limit passes the size check and fails later on,
+		// because it has no miner payouts.
+		if err := bv.ValidateBlock(b, b.ID(), 0, types.RootDepth, 0, nil); err != errBadMinerPayouts {
+			t.Errorf("block of size %v with limit %v: got %v, want %v", limit, limit, err, errBadMinerPayouts)
+		}
+		bv.marshaler = mockMarshaler{marshalLength: limit + 1}
+		if err := bv.ValidateBlock(b, b.ID(), 0, types.RootDepth, 0, nil); err != errLargeBlock {
+			t.Errorf("block of size %v with limit %v: got %v, want %v", limit+1, limit, err, errLargeBlock)
+		}
+	}
+}
+
 // TestCheckMinerPayouts probes the checkMinerPayouts function.
 func TestCheckMinerPayouts(t *testing.T) {
 	// All tests are done at height = 0.
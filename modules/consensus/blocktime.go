@@ -0,0 +1,56 @@
+package consensus
+
+import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// averageInterval returns the average time between intervals consecutive
+// blocks, given the timestamps of the first and last of them. Zero is
+// returned if there are no intervals, or if the timestamps are out of order,
+// which block timestamp rules allow over short spans.
+func averageInterval(first, last types.Timestamp, intervals int) time.Duration {
+	if intervals <= 0 || last < first {
+		return 0
+	}
+	return time.Duration(last-first) * time.Second / time.Duration(intervals)
+}
+
+// BlockTimeStats returns the average time between the last 'window' blocks of
+// the current path, along with the target that the next block must meet. If
+// the blockchain has fewer than window+1 blocks, the average is taken over
+// every block since the genesis block; at height 0 it is zero.
+func (cs *ConsensusSet) BlockTimeStats(window int) (avgInterval time.Duration, currentTarget types.Target) {
+	err := cs.tg.Add()
+	if err != nil {
+		return 0, types.Target{}
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		current := currentProcessedBlock(tx)
+		currentTarget = current.ChildTarget
+		if window <= 0 {
+			return nil
+		}
+		if types.BlockHeight(window) > current.Height {
+			window = int(current.Height)
+		}
+		id, err := getPath(tx, current.Height-types.BlockHeight(window))
+		if err != nil {
+			return err
+		}
+		start, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		avgInterval = averageInterval(start.Block.Timestamp, current.Block.Timestamp, window)
+		return nil
+	})
+	return avgInterval, currentTarget
+}
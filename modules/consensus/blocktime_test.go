@@ -0,0 +1,64 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestAverageInterval probes the averageInterval function.
+func TestAverageInterval(t *testing.T) {
+	tests := []struct {
+		first, last types.Timestamp
+		intervals   int
+		want        time.Duration
+	}{
+		{100, 700, 1, 600 * time.Second},
+		{100, 700, 4, 150 * time.Second},
+		{100, 100, 0, 0},
+		{700, 100, 2, 0}, // out of order timestamps
+	}
+	for _, tt := range tests {
+		if got := averageInterval(tt.first, tt.last, tt.intervals); got != tt.want {
+			t.Errorf("averageInterval(%v, %v, %v): got %v, want %v", tt.first, tt.last, tt.intervals, got, tt.want)
+		}
+	}
+}
+
+// TestBlockTimeStats checks that BlockTimeStats averages over the requested
+// window, and over the whole blockchain when it is shorter than the window.
+func TestBlockTimeStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	height := cst.cs.Height()
+	current := cst.cs.CurrentBlock()
+	target, _ := cst.cs.ChildTarget(current.ID())
+
+	// A window reaching past the genesis block is clamped to the height.
+	genesis, _ := cst.cs.BlockAtHeight(0)
+	avg, currentTarget := cst.cs.BlockTimeStats(int(height) + 100)
+	if want := averageInterval(genesis.Timestamp, current.Timestamp, int(height)); avg != want {
+		t.Errorf("expected an average of %v over the whole chain, got %v", want, avg)
+	}
+	if currentTarget != target {
+		t.Error("BlockTimeStats returned the wrong target")
+	}
+
+	// A window of one block is the time since the parent.
+	parent, _ := cst.cs.BlockAtHeight(height - 1)
+	if avg, _ := cst.cs.BlockTimeStats(1); avg != averageInterval(parent.Timestamp, current.Timestamp, 1) {
+		t.Error("wrong average for a window of one block:", avg)
+	}
+	if avg, _ := cst.cs.BlockTimeStats(0); avg != 0 {
+		t.Error("expected a zero average for an empty window, got", avg)
+	}
+}
@@ -54,6 +54,12 @@ var (
 	// inconsistencies within the database have been detected.
 	Consistency = []byte("Consistency")
 
+	// MaturityDelay is a database bucket storing the number of blocks that
+	// delayed siacoin outputs wait before they can be spent. Databases created
+	// before the delay was configurable do not have the bucket, and use
+	// types.MaturityDelay.
+	MaturityDelay = []byte("MaturityDelay")
+
 	// FileContracts is a database bucket that contains all of the open file
 	// contracts.
 	FileContracts = []byte("FileContracts")
@@ -91,6 +97,7 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 		BlockMap,
 		BlockPath,
 		Consistency,
+		MaturityDelay,
 		SiacoinOutputs,
 		FileContracts,
 		SiafundOutputs,
@@ -114,6 +121,12 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 	// Set the siafund pool to 0.
 	setSiafundPool(tx, types.NewCurrency64(0))
 
+	// Record the maturity delay of the network.
+	err = tx.Bucket(MaturityDelay).Put(MaturityDelay, encoding.Marshal(cs.maturityDelay))
+	if err != nil {
+		return err
+	}
+
 	// Update the siafund output diffs map for the genesis block on disk. This
 	// needs to happen between the database being opened/initilized and the
 	// consensus set hash being calculated
@@ -123,8 +136,8 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 
 	// Add the miner payout from the genesis block to the delayed siacoin
 	// outputs - unspendable, as the unlock hash is blank.
-	createDSCOBucket(tx, types.MaturityDelay)
-	addDSCO(tx, types.MaturityDelay, cs.blockRoot.Block.MinerPayoutID(0), types.SiacoinOutput{
+	createDSCOBucket(tx, cs.maturityDelay)
+	addDSCO(tx, cs.maturityDelay, cs.blockRoot.Block.MinerPayoutID(0), types.SiacoinOutput{
 		Value:      types.CalculateCoinbase(0),
 		UnlockHash: types.UnlockHash{},
 	})
@@ -150,6 +163,21 @@ func blockHeight(tx *bolt.Tx) types.BlockHeight {
 	return height
 }
 
+// maturityDelay returns the number of blocks that delayed siacoin outputs,
+// such as miner payouts, wait before they can be spent.
+func maturityDelay(tx *bolt.Tx) types.BlockHeight {
+	bucket := tx.Bucket(MaturityDelay)
+	if bucket == nil {
+		return types.MaturityDelay
+	}
+	var delay types.BlockHeight
+	err := encoding.Unmarshal(bucket.Get(MaturityDelay), &delay)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return delay
+}
+
 // currentBlockID returns the id of the most recent block in the consensus set.
 func currentBlockID(tx *bolt.Tx) types.BlockID {
 	id, err := getPath(tx, blockHeight(tx))
@@ -510,6 +538,21 @@ func addDSCO(tx *bolt.Tx, bh types.BlockHeight, id types.SiacoinOutputID, sco ty
 	}
 }
 
+// getDSCOMaturityHeight returns the height at which a delayed siacoin output
+// matures. Only the buckets that can still hold delayed outputs, which are
+// those between the current height and the maturity delay, are searched.
+func getDSCOMaturityHeight(tx *bolt.Tx, id types.SiacoinOutputID) (types.BlockHeight, bool) {
+	height := blockHeight(tx)
+	delay := maturityDelay(tx)
+	for bh := height + 1; bh <= height+delay; bh++ {
+		bucket := tx.Bucket(append(prefixDSCO, encoding.Marshal(bh)...))
+		if bucket != nil && bucket.Get(id[:]) != nil {
+			return bh, true
+		}
+	}
+	return 0, false
+}
+
 // removeDSCO removes a delayed siacoin output from the consensus set.
 func removeDSCO(tx *bolt.Tx, bh types.BlockHeight, id types.SiacoinOutputID) {
 	bucketID := append(prefixDSCO, encoding.Marshal(bh)...)
@@ -21,8 +21,40 @@ import (
 
 var (
 	errNilGateway = errors.New("cannot have a nil gateway as input")
+
+	// errSmallBlockSizeLimit is returned when a consensus set is created with
+	// a block size limit below minBlockSizeLimit.
+	errSmallBlockSizeLimit = errors.New("block size limit is too small")
+
+	// errWrongMaturityDelay is returned when a consensus database is loaded
+	// with a different maturity delay than it was created with.
+	errWrongMaturityDelay = errors.New("consensus database was created with a different maturity delay")
+
+	// Errors returned when a custom genesis block is not internally
+	// consistent.
+	errGenesisHasParent          = errors.New("genesis block cannot have a parent")
+	errGenesisHasMinerPayouts    = errors.New("genesis block cannot have miner payouts")
+	errGenesisTransactionCount   = errors.New("genesis block must have exactly one transaction")
+	errGenesisInvalidTransaction = errors.New("genesis transaction may only contain siafund outputs")
+	errGenesisZeroSiafundOutput  = errors.New("genesis siafund outputs must have a nonzero value")
+	errGenesisWrongSiafundCount  = errors.New("genesis siafund outputs must allocate exactly SiafundCount siafunds")
+	errGenesisSiafundClaimStart  = errors.New("genesis siafund outputs cannot have a claim start")
 )
 
+// TransactionRule is an additional validation rule that every transaction
+// must satisfy in order to be accepted into a block, on top of the standard
+// consensus rules. A TransactionRule is given the transaction and the height
+// of the block that it is being added to, and returns an error if the
+// transaction should be rejected. Transaction rules are intended for private
+// networks that need to restrict the transactions that can be made, for
+// example by only allowing whitelisted addresses.
+//
+// A TransactionRule is part of consensus: every node on the network must be
+// configured with the same rule, otherwise nodes will disagree on which
+// blocks are valid and the network will fork. For the same reason, the rule
+// must be deterministic and depend only on its inputs.
+type TransactionRule func(txn types.Transaction, height types.BlockHeight) error
+
 // marshaler marshals objects into byte slices and unmarshals byte
 // slices into objects.
 type marshaler interface {
@@ -69,6 +101,12 @@ type ConsensusSet struct {
 	// the genesis block, meaning the PoW is not very expensive.
 	dosBlocks map[types.BlockID]struct{}
 
+	// orphans are blocks whose parents are not known yet. They are held
+	// until the parent arrives, so that blocks received out of order during
+	// synchronization do not have to be downloaded again. The cache is
+	// bounded, see maxOrphanBlocks.
+	orphans *orphanCache
+
 	// checkingConsistency is a bool indicating whether or not a consistency
 	// check is in progress. The consistency check logic call itself, resulting
 	// in infinite loops. This bool prevents that while still allowing for full
@@ -86,6 +124,21 @@ type ConsensusSet struct {
 	blockRuleHelper blockRuleHelper
 	blockValidator  blockValidator
 
+	// transactionRule is an optional network-wide validation rule that is
+	// applied to every transaction. It is nil on the public network.
+	transactionRule TransactionRule
+
+	// blockSizeLimit is the maximum encoded size of a block. It is
+	// types.BlockSizeLimit unless the consensus set was created for a private
+	// network with a different limit.
+	blockSizeLimit uint64
+
+	// maturityDelay is the number of blocks that delayed siacoin outputs,
+	// such as miner payouts, wait before they can be spent. It is
+	// types.MaturityDelay unless the consensus set was created for a private
+	// network with a different delay.
+	maturityDelay types.BlockHeight
+
 	// Utilities
 	db         *persist.BoltDatabase
 	log        *persist.Logger
@@ -94,21 +147,129 @@ type ConsensusSet struct {
 	tg         sync.ThreadGroup
 }
 
+// validateGenesisBlock checks that a genesis block is internally consistent.
+// The only content a genesis block may have is a single transaction containing
+// the initial siafund allocation, which must account for every siafund.
+func validateGenesisBlock(b types.Block) error {
+	if b.ParentID != (types.BlockID{}) {
+		return errGenesisHasParent
+	}
+	if len(b.MinerPayouts) != 0 {
+		return errGenesisHasMinerPayouts
+	}
+	if len(b.Transactions) != 1 {
+		return errGenesisTransactionCount
+	}
+	txn := b.Transactions[0]
+	if len(txn.SiacoinInputs) != 0 || len(txn.SiacoinOutputs) != 0 ||
+		len(txn.FileContracts) != 0 || len(txn.FileContractRevisions) != 0 ||
+		len(txn.StorageProofs) != 0 || len(txn.SiafundInputs) != 0 ||
+		len(txn.MinerFees) != 0 || len(txn.ArbitraryData) != 0 ||
+		len(txn.TransactionSignatures) != 0 {
+		return errGenesisInvalidTransaction
+	}
+	var total types.Currency
+	for _, sfo := range txn.SiafundOutputs {
+		if sfo.Value.IsZero() {
+			return errGenesisZeroSiafundOutput
+		}
+		if !sfo.ClaimStart.IsZero() {
+			return errGenesisSiafundClaimStart
+		}
+		total = total.Add(sfo.Value)
+	}
+	if !total.Equals(types.SiafundCount) {
+		return errGenesisWrongSiafundCount
+	}
+	return nil
+}
+
+// minBlockSizeLimit is the smallest block size limit a private network may
+// use. The miner keeps 5 kB of every block free for the header and the miner
+// payouts, so a smaller limit would leave no room for transactions.
+const minBlockSizeLimit = 10e3
+
+// Options are the consensus parameters of a private network. Every node on
+// the network must use the same options, or the network will fork. Zero
+// values select the parameters of the Sia network.
+type Options struct {
+	// Genesis replaces the genesis block of the Sia network. It must
+	// contain a single transaction holding the initial siafund allocation.
+	// A persist directory containing a blockchain with a different genesis
+	// block cannot be loaded.
+	Genesis *types.Block
+
+	// TransactionRule rejects any transaction that does not satisfy it.
+	TransactionRule TransactionRule
+
+	// BlockSizeLimit replaces types.BlockSizeLimit as the maximum encoded
+	// size of a block. It may not be less than 10 kB.
+	BlockSizeLimit uint64
+
+	// MaturityDelay replaces types.MaturityDelay as the number of blocks
+	// that miner payouts and other delayed siacoin outputs wait before they
+	// can be spent. The delay is stored in the consensus database, which
+	// cannot be loaded with a different delay afterwards.
+	MaturityDelay types.BlockHeight
+}
+
 // New returns a new ConsensusSet, containing at least the genesis block. If
 // there is an existing block database present in the persist directory, it
 // will be loaded.
 func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*ConsensusSet, error) {
+	return NewCustom(gateway, bootstrap, persistDir, Options{})
+}
+
+// NewCustom returns a new ConsensusSet for a private network that uses the
+// consensus parameters in opts instead of those of the Sia network.
+func NewCustom(gateway modules.Gateway, bootstrap bool, persistDir string, opts Options) (*ConsensusSet, error) {
+	if opts.Genesis == nil {
+		opts.Genesis = &types.GenesisBlock
+	} else if err := validateGenesisBlock(*opts.Genesis); err != nil {
+		return nil, err
+	}
+	if opts.BlockSizeLimit == 0 {
+		opts.BlockSizeLimit = types.BlockSizeLimit
+	} else if opts.BlockSizeLimit < minBlockSizeLimit {
+		return nil, errSmallBlockSizeLimit
+	}
+	if opts.MaturityDelay == 0 {
+		opts.MaturityDelay = types.MaturityDelay
+	}
+	return newConsensusSet(gateway, bootstrap, persistDir, opts)
+}
+
+// NewCustomGenesis returns a new ConsensusSet that uses the provided genesis
+// block instead of the genesis block of the Sia network, allowing isolated
+// private networks to be created. It is shorthand for NewCustom with only
+// Genesis set.
+func NewCustomGenesis(gateway modules.Gateway, bootstrap bool, persistDir string, genesis types.Block) (*ConsensusSet, error) {
+	return NewCustom(gateway, bootstrap, persistDir, Options{Genesis: &genesis})
+}
+
+// NewCustomRules returns a new ConsensusSet for a private network that uses
+// the provided genesis block and rejects any transaction that does not
+// satisfy 'rule'. It is shorthand for NewCustom with only Genesis and
+// TransactionRule set.
+func NewCustomRules(gateway modules.Gateway, bootstrap bool, persistDir string, genesis types.Block, rule TransactionRule) (*ConsensusSet, error) {
+	return NewCustom(gateway, bootstrap, persistDir, Options{Genesis: &genesis, TransactionRule: rule})
+}
+
+// newConsensusSet creates a ConsensusSet rooted at the genesis block of opts.
+// Every option must be set.
+func newConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir string, opts Options) (*ConsensusSet, error) {
 	// Check for nil dependencies.
 	if gateway == nil {
 		return nil, errNilGateway
 	}
+	genesis := *opts.Genesis
 
 	// Create the ConsensusSet object.
 	cs := &ConsensusSet{
 		gateway: gateway,
 
 		blockRoot: processedBlock{
-			Block:       types.GenesisBlock,
+			Block:       genesis,
 			ChildTarget: types.RootTarget,
 			Depth:       types.RootDepth,
 
@@ -116,17 +277,21 @@ func New(gateway modules.Gateway, bootstrap bool, persistDir string) (*Consensus
 		},
 
 		dosBlocks: make(map[types.BlockID]struct{}),
+		orphans:   newOrphanCache(maxOrphanBlocks),
 
 		marshaler:       stdMarshaler{},
 		blockRuleHelper: stdBlockRuleHelper{},
-		blockValidator:  NewBlockValidator(),
+		blockValidator:  newBlockValidator(opts.BlockSizeLimit),
+		transactionRule: opts.TransactionRule,
+		blockSizeLimit:  opts.BlockSizeLimit,
+		maturityDelay:   opts.MaturityDelay,
 
 		persistDir: persistDir,
 	}
 
 	// Create the diffs for the genesis siafund outputs.
-	for i, siafundOutput := range types.GenesisBlock.Transactions[0].SiafundOutputs {
-		sfid := types.GenesisBlock.Transactions[0].SiafundOutputID(uint64(i))
+	for i, siafundOutput := range genesis.Transactions[0].SiafundOutputs {
+		sfid := genesis.Transactions[0].SiafundOutputID(uint64(i))
 		sfod := modules.SiafundOutputDiff{
 			Direction:     modules.DiffApply,
 			ID:            sfid,
@@ -223,6 +388,14 @@ func (cs *ConsensusSet) ChildTarget(id types.BlockID) (target types.Target, exis
 	return target, exists
 }
 
+// ClearDoSBlock removes a block from the set of blocks known to be invalid,
+// allowing the block to be resubmitted and validated again.
+func (cs *ConsensusSet) ClearDoSBlock(id types.BlockID) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	delete(cs.dosBlocks, id)
+}
+
 // Close safely closes the block database.
 func (cs *ConsensusSet) Close() error {
 	return cs.tg.Stop()
@@ -264,6 +437,17 @@ func (cs *ConsensusSet) CurrentBlock() (block types.Block) {
 	return block
 }
 
+// DoSBlocks returns the ids of all blocks that are known to be invalid.
+func (cs *ConsensusSet) DoSBlocks() []types.BlockID {
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+	ids := make([]types.BlockID, 0, len(cs.dosBlocks))
+	for id := range cs.dosBlocks {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // Flush will block until the consensus set has finished all in-progress
 // routines.
 func (cs *ConsensusSet) Flush() error {
@@ -342,6 +526,60 @@ func (cs *ConsensusSet) MinimumValidChildTimestamp(id types.BlockID) (timestamp
 	return timestamp, exists
 }
 
+// BlockSizeLimit returns the maximum encoded size of a block. The limit is
+// fixed when the consensus set is created, so no lock is needed.
+func (cs *ConsensusSet) BlockSizeLimit() uint64 {
+	return cs.blockSizeLimit
+}
+
+// MaturityDelay returns the number of blocks that miner payouts and other
+// delayed siacoin outputs wait before they can be spent. The delay is fixed
+// when the consensus set is created, so no lock is needed.
+func (cs *ConsensusSet) MaturityDelay() types.BlockHeight {
+	return cs.maturityDelay
+}
+
+// MinerPayoutMaturityHeight returns the height at which the delayed siacoin
+// output with the given id, such as a miner payout, becomes spendable. false is
+// returned if the output is unknown or has already matured.
+func (cs *ConsensusSet) MinerPayoutMaturityHeight(id types.SiacoinOutputID) (height types.BlockHeight, exists bool) {
+	// A call to a closed database can cause undefined behavior.
+	err := cs.tg.Add()
+	if err != nil {
+		return 0, false
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		height, exists = getDSCOMaturityHeight(tx, id)
+		return nil
+	})
+	return height, exists
+}
+
+// SiafundOutput returns the unspent siafund output with the given id, along
+// with the siacoins that spending it would currently claim from the siafund
+// pool.
+func (cs *ConsensusSet) SiafundOutput(id types.SiafundOutputID) (sfo types.SiafundOutput, claim types.Currency, exists bool) {
+	// A call to a closed database can cause undefined behavior.
+	err := cs.tg.Add()
+	if err != nil {
+		return types.SiafundOutput{}, types.Currency{}, false
+	}
+	defer cs.tg.Done()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		sfo, err = getSiafundOutput(tx, id)
+		if err != nil {
+			return err
+		}
+		claim = siafundClaim(getSiafundPool(tx), sfo)
+		exists = true
+		return nil
+	})
+	return sfo, claim, exists
+}
+
 // StorageProofSegment returns the segment to be used in the storage proof for
 // a given file contract.
 func (cs *ConsensusSet) StorageProofSegment(fcid types.FileContractID) (index uint64, err error) {
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"errors"
 	"path/filepath"
 	"testing"
 
@@ -199,3 +200,177 @@ func TestDatabaseClosing(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestCustomGenesis checks that a consensus set can be created from a custom
+// genesis block, and that inconsistent genesis blocks are rejected.
+func TestCustomGenesis(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, t.Name())
+
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	// Inconsistent genesis blocks should be rejected.
+	bad := types.Block{Transactions: []types.Transaction{{
+		SiafundOutputs: []types.SiafundOutput{{Value: types.NewCurrency64(1)}},
+	}}}
+	_, err = NewCustomGenesis(g, false, testdir, bad)
+	if err != errGenesisWrongSiafundCount {
+		t.Fatal("expected errGenesisWrongSiafundCount, got", err)
+	}
+	bad.MinerPayouts = []types.SiacoinOutput{{Value: types.NewCurrency64(1)}}
+	_, err = NewCustomGenesis(g, false, testdir, bad)
+	if err != errGenesisHasMinerPayouts {
+		t.Fatal("expected errGenesisHasMinerPayouts, got", err)
+	}
+
+	// Create a consensus set with a custom genesis block that allocates all
+	// siafunds to a single address.
+	genesis := types.Block{
+		Timestamp: types.GenesisTimestamp + 1,
+		Transactions: []types.Transaction{{
+			SiafundOutputs: []types.SiafundOutput{{
+				Value:      types.SiafundCount,
+				UnlockHash: types.UnlockHash{1},
+			}},
+		}},
+	}
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	cs, err := NewCustomGenesis(g, false, csDir, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.CurrentBlock().ID() != genesis.ID() {
+		t.Fatal("consensus set does not start at the custom genesis block")
+	}
+	sfo, err := cs.dbGetSiafundOutput(genesis.Transactions[0].SiafundOutputID(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sfo.Value.Equals(types.SiafundCount) {
+		t.Fatal("genesis siafund output has the wrong value:", sfo.Value)
+	}
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The blockchain should not load with the standard genesis block.
+	if _, err := New(g, false, csDir); err == nil {
+		t.Fatal("expected a genesis mismatch error")
+	}
+}
+
+// TestCustomMaturityDelay checks that a consensus set created with a custom
+// maturity delay uses it for delayed outputs, and that its database cannot be
+// loaded with a different delay.
+func TestCustomMaturityDelay(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, t.Name())
+
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	delay := types.MaturityDelay + 2
+	cs, err := NewCustom(g, false, csDir, Options{MaturityDelay: delay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	height, exists := cs.MinerPayoutMaturityHeight(types.GenesisBlock.MinerPayoutID(0))
+	if !exists || height != delay {
+		t.Fatalf("genesis payout should mature at %v, got %v (exists: %v)", delay, height, exists)
+	}
+	if cs.MaturityDelay() != delay {
+		t.Fatal("consensus set reports the wrong maturity delay:", cs.MaturityDelay())
+	}
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The blockchain should not load with the standard maturity delay.
+	if _, err := New(g, false, csDir); err != errWrongMaturityDelay {
+		t.Fatal("expected errWrongMaturityDelay, got", err)
+	}
+}
+
+// TestCustomBlockSizeLimit checks that a consensus set cannot be created with
+// a block size limit too small to hold the blocks the miner builds.
+func TestCustomBlockSizeLimit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, t.Name())
+
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	if _, err := NewCustom(g, false, csDir, Options{BlockSizeLimit: minBlockSizeLimit - 1}); err != errSmallBlockSizeLimit {
+		t.Fatal("expected errSmallBlockSizeLimit, got", err)
+	}
+	cs, err := NewCustom(g, false, csDir, Options{BlockSizeLimit: minBlockSizeLimit})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.BlockSizeLimit() != minBlockSizeLimit {
+		t.Fatal("consensus set reports the wrong block size limit:", cs.BlockSizeLimit())
+	}
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestCustomRules checks that a consensus set created with a custom
+// transaction rule rejects transactions that do not satisfy the rule.
+func TestCustomRules(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, t.Name())
+
+	g, err := gateway.New("localhost:0", false, filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	// Require every transaction to carry a memo in its arbitrary data.
+	errNoMemo := errors.New("transaction has no memo")
+	rule := func(txn types.Transaction, height types.BlockHeight) error {
+		if len(txn.ArbitraryData) == 0 {
+			return errNoMemo
+		}
+		return nil
+	}
+	cs, err := NewCustomRules(g, false, filepath.Join(testdir, modules.ConsensusDir), types.GenesisBlock, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	_, err = cs.TryTransactionSet([]types.Transaction{{}})
+	if err != errNoMemo {
+		t.Fatal("expected errNoMemo, got", err)
+	}
+	_, err = cs.TryTransactionSet([]types.Transaction{{ArbitraryData: [][]byte{[]byte("memo")}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
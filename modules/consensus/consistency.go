@@ -200,6 +200,7 @@ func checkDSCOs(tx *bolt.Tx) {
 	// another map to track which ids have appeared in the dsco set.
 	dscoTracker := make(map[types.BlockHeight]struct{})
 	idMap := make(map[types.SiacoinOutputID]struct{})
+	delay := maturityDelay(tx)
 
 	// Iterate through all the buckets looking for the delayed siacoin output
 	// buckets, and check that they are for the correct heights.
@@ -248,7 +249,7 @@ func checkDSCOs(tx *bolt.Tx) {
 
 		// Check that the minimum value has been achieved - the coinbase from
 		// an earlier block is guaranteed to be in the bucket.
-		minimumValue := types.CalculateCoinbase(height - types.MaturityDelay)
+		minimumValue := types.CalculateCoinbase(height - delay)
 		if total.Cmp(minimumValue) < 0 {
 			return errors.New("total number of coins in the delayed output bucket is incorrect")
 		}
@@ -261,8 +262,8 @@ func checkDSCOs(tx *bolt.Tx) {
 	// Check that all of the correct heights are represented.
 	currentHeight := blockHeight(tx)
 	expectedBuckets := 0
-	for i := currentHeight + 1; i <= currentHeight+types.MaturityDelay; i++ {
-		if i < types.MaturityDelay {
+	for i := currentHeight + 1; i <= currentHeight+delay; i++ {
+		if i < delay {
 			continue
 		}
 		_, exists := dscoTracker[i]
@@ -90,7 +90,7 @@ func (cs *ConsensusSet) childTargetOak(parentTotalTime int64, parentTotalTarget,
 		// Rules elsewhere in consensus ensure that the timestamp of the parent
 		// block has not been manipulated by more than a few hours, which is
 		// accurate enough for this logic to be safe.
-		expectedTime := int64(types.BlockFrequency*parentHeight) + int64(types.GenesisTimestamp)
+		expectedTime := int64(types.BlockFrequency*parentHeight) + int64(cs.blockRoot.Block.Timestamp)
 		delta = expectedTime - int64(parentTimestamp)
 	}
 	// Convert the delta in to a target block time.
@@ -218,14 +218,15 @@ func (cs *ConsensusSet) initOak(tx *bolt.Tx) error {
 	}
 
 	// Store base values for the genesis block.
-	totalTime, totalTarget, err := cs.storeBlockTotals(tx, 0, types.GenesisID, 0, types.GenesisTimestamp, types.GenesisTimestamp, types.RootDepth, types.RootTarget)
+	genesis := cs.blockRoot.Block
+	totalTime, totalTarget, err := cs.storeBlockTotals(tx, 0, genesis.ID(), 0, genesis.Timestamp, genesis.Timestamp, types.RootDepth, types.RootTarget)
 	if err != nil {
 		return errors.Extend(errors.New("unable to store genesis block totals"), err)
 	}
 
 	// The Oak fields have not been initialized, scan through the consensus set
 	// and set the fields for each block.
-	parentTimestamp := types.GenesisTimestamp
+	parentTimestamp := genesis.Timestamp
 	parentChildTarget := types.RootTarget
 	for i := types.BlockHeight(1); i <= height; i++ { // Skip Genesis block
 		// Fetch the processed block for the current block.
@@ -6,7 +6,6 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
-	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/NebulousLabs/bolt"
 )
@@ -118,9 +117,10 @@ func commitSiafundPoolDiff(tx *bolt.Tx, sfpd modules.SiafundPoolDiff, dir module
 // createUpcomingDelayeOutputdMaps creates the delayed siacoin output maps that
 // will be used when applying delayed siacoin outputs in the diff set.
 func createUpcomingDelayedOutputMaps(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
+	delay := maturityDelay(tx)
 	if dir == modules.DiffApply {
-		createDSCOBucket(tx, pb.Height+types.MaturityDelay)
-	} else if pb.Height >= types.MaturityDelay {
+		createDSCOBucket(tx, pb.Height+delay)
+	} else if pb.Height >= delay {
 		createDSCOBucket(tx, pb.Height)
 	}
 }
@@ -166,10 +166,11 @@ func commitNodeDiffs(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection)
 // are no longer in use.
 func deleteObsoleteDelayedOutputMaps(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
 	// There are no outputs that mature in the first MaturityDelay blocks.
-	if dir == modules.DiffApply && pb.Height >= types.MaturityDelay {
+	delay := maturityDelay(tx)
+	if dir == modules.DiffApply && pb.Height >= delay {
 		deleteDSCOBucket(tx, pb.Height)
 	} else if dir == modules.DiffRevert {
-		deleteDSCOBucket(tx, pb.Height+types.MaturityDelay)
+		deleteDSCOBucket(tx, pb.Height+delay)
 	}
 }
 
@@ -192,6 +193,7 @@ func commitDiffSet(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
 
 	createUpcomingDelayedOutputMaps(tx, pb, dir)
 	commitNodeDiffs(tx, pb, dir)
+	commitSupplyDiff(tx, pb, dir)
 	deleteObsoleteDelayedOutputMaps(tx, pb, dir)
 	updateCurrentPath(tx, pb, dir)
 }
@@ -211,7 +213,7 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// Create the bucket to hold all of the delayed siacoin outputs created by
 	// transactions this block. Needs to happen before any transactions are
 	// applied.
-	createDSCOBucket(tx, pb.Height+types.MaturityDelay)
+	createDSCOBucket(tx, pb.Height+maturityDelay(tx))
 
 	// Validate and apply each transaction in the block. They cannot be
 	// validated all at once because some transactions may not be valid until
@@ -229,6 +231,7 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// maturity, applying any contracts with missed storage proofs, and adding
 	// the miner payouts to the list of delayed outputs.
 	applyMaintenance(tx, pb)
+	commitSupplyDiff(tx, pb, modules.DiffApply)
 
 	// DiffsGenerated are only set to true after the block has been fully
 	// validated and integrated. This is required to prevent later blocks from
@@ -0,0 +1,113 @@
+package consensus
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// fileContractSubscriber adapts a modules.FileContractSubscriber to the
+// modules.ConsensusSetSubscriber interface. It is compared by value, so that
+// Unsubscribe can find the adapter of a subscriber without a separate index.
+type fileContractSubscriber struct {
+	subscriber modules.FileContractSubscriber
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.
+func (fcs fileContractSubscriber) ProcessConsensusChange(cc modules.ConsensusChange) {
+	fcs.subscriber.ProcessFileContractEvents(cc.ID, cc.FileContractEvents)
+}
+
+// storageProofPayouts returns the payouts of a file contract that resolved
+// with the given proof status, which mature at maturityHeight.
+func storageProofPayouts(id types.FileContractID, outputs []types.SiacoinOutput, status types.ProofStatus, maturityHeight types.BlockHeight) []modules.FileContractPayout {
+	payouts := make([]modules.FileContractPayout, len(outputs))
+	for i, sco := range outputs {
+		payouts[i] = modules.FileContractPayout{
+			ID:             id.StorageProofOutputID(status, uint64(i)),
+			SiacoinOutput:  sco,
+			MaturityHeight: maturityHeight,
+		}
+	}
+	return payouts
+}
+
+// fileContractEvents returns the file contract events of a processed block,
+// in the order that they happened when the block was applied.
+func fileContractEvents(pb *processedBlock, delay types.BlockHeight) []modules.FileContractEvent {
+	// Every contract that was revised, resolved by a storage proof, or
+	// expired appears in a diff that removes it. Diffs are in the order they
+	// were applied, so the last removal of each contract is its final state.
+	removed := make(map[types.FileContractID]types.FileContract)
+	for _, fcd := range pb.FileContractDiffs {
+		if fcd.Direction == modules.DiffRevert {
+			removed[fcd.ID] = fcd.FileContract
+		}
+	}
+
+	var events []modules.FileContractEvent
+	for _, txn := range pb.Block.Transactions {
+		for i, fc := range txn.FileContracts {
+			events = append(events, modules.FileContractEvent{
+				Direction:      modules.DiffApply,
+				ID:             txn.FileContractID(uint64(i)),
+				Type:           modules.FileContractCreated,
+				Height:         pb.Height,
+				RevisionNumber: fc.RevisionNumber,
+			})
+		}
+		for _, fcr := range txn.FileContractRevisions {
+			events = append(events, modules.FileContractEvent{
+				Direction:      modules.DiffApply,
+				ID:             fcr.ParentID,
+				Type:           modules.FileContractRevised,
+				Height:         pb.Height,
+				RevisionNumber: fcr.NewRevisionNumber,
+			})
+		}
+		for _, sp := range txn.StorageProofs {
+			fc := removed[sp.ParentID]
+			events = append(events, modules.FileContractEvent{
+				Direction:      modules.DiffApply,
+				ID:             sp.ParentID,
+				Type:           modules.FileContractValidProof,
+				Height:         pb.Height,
+				RevisionNumber: fc.RevisionNumber,
+				Payouts:        storageProofPayouts(sp.ParentID, fc.ValidProofOutputs, types.ProofValid, pb.Height+delay),
+			})
+		}
+	}
+
+	// Contracts that expire at this height are removed during maintenance,
+	// after the transactions of the block. A contract cannot be revised or
+	// proven in the block at which it expires.
+	for _, fcd := range pb.FileContractDiffs {
+		if fcd.Direction != modules.DiffRevert || fcd.FileContract.WindowEnd != pb.Height {
+			continue
+		}
+		events = append(events, modules.FileContractEvent{
+			Direction:      modules.DiffApply,
+			ID:             fcd.ID,
+			Type:           modules.FileContractMissedProof,
+			Height:         pb.Height,
+			RevisionNumber: fcd.FileContract.RevisionNumber,
+			Payouts:        storageProofPayouts(fcd.ID, fcd.FileContract.MissedProofOutputs, types.ProofMissed, pb.Height+delay),
+		})
+	}
+	return events
+}
+
+// ConsensusSetFileContractSubscribe adds a subscriber that receives the file
+// contract events of every consensus change that has occurred since the
+// change with the provided id. The special ids accepted by
+// ConsensusSetSubscribe are accepted as well.
+func (cs *ConsensusSet) ConsensusSetFileContractSubscribe(subscriber modules.FileContractSubscriber, start modules.ConsensusChangeID,
+	cancel <-chan struct{}) error {
+	return cs.ConsensusSetSubscribe(fileContractSubscriber{subscriber}, start, cancel)
+}
+
+// UnsubscribeFileContracts removes a subscriber that was added with
+// ConsensusSetFileContractSubscribe. If the subscriber is not found, no action
+// is taken.
+func (cs *ConsensusSet) UnsubscribeFileContracts(subscriber modules.FileContractSubscriber) {
+	cs.Unsubscribe(fileContractSubscriber{subscriber})
+}
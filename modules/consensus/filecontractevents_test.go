@@ -0,0 +1,120 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestFileContractEvents checks that fileContractEvents derives the lifecycle
+// events of file contracts from a processed block.
+func TestFileContractEvents(t *testing.T) {
+	height := types.BlockHeight(20)
+	created := types.FileContract{RevisionNumber: 1, WindowStart: 30, WindowEnd: 40}
+	revised := types.FileContract{RevisionNumber: 2, WindowStart: 30, WindowEnd: 40}
+	proven := types.FileContract{
+		RevisionNumber:    3,
+		WindowStart:       15,
+		WindowEnd:         25,
+		ValidProofOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(5)}},
+	}
+	expired := types.FileContract{
+		RevisionNumber:     4,
+		WindowStart:        10,
+		WindowEnd:          height,
+		MissedProofOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(6)}, {Value: types.NewCurrency64(7)}},
+	}
+	revisedID := types.FileContractID{1}
+	provenID := types.FileContractID{2}
+	expiredID := types.FileContractID{3}
+
+	txn := types.Transaction{
+		FileContracts: []types.FileContract{created},
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID:          revisedID,
+			NewRevisionNumber: 2,
+			NewWindowStart:    30,
+			NewWindowEnd:      40,
+		}},
+		StorageProofs: []types.StorageProof{{ParentID: provenID}},
+	}
+	createdID := txn.FileContractID(0)
+	pb := &processedBlock{
+		Block:  types.Block{Transactions: []types.Transaction{txn}},
+		Height: height,
+		FileContractDiffs: []modules.FileContractDiff{
+			{Direction: modules.DiffApply, ID: createdID, FileContract: created},
+			{Direction: modules.DiffRevert, ID: revisedID, FileContract: types.FileContract{RevisionNumber: 1, WindowStart: 30, WindowEnd: 40}},
+			{Direction: modules.DiffApply, ID: revisedID, FileContract: revised},
+			{Direction: modules.DiffRevert, ID: provenID, FileContract: proven},
+			{Direction: modules.DiffRevert, ID: expiredID, FileContract: expired},
+		},
+	}
+
+	events := fileContractEvents(pb, types.MaturityDelay)
+	expected := []struct {
+		id       types.FileContractID
+		typ      modules.FileContractEventType
+		revision uint64
+		payouts  int
+	}{
+		{createdID, modules.FileContractCreated, 1, 0},
+		{revisedID, modules.FileContractRevised, 2, 0},
+		{provenID, modules.FileContractValidProof, 3, 1},
+		{expiredID, modules.FileContractMissedProof, 4, 2},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %v events, got %v", len(expected), len(events))
+	}
+	for i, e := range expected {
+		fce := events[i]
+		if fce.ID != e.id || fce.Type != e.typ || fce.RevisionNumber != e.revision || len(fce.Payouts) != e.payouts {
+			t.Errorf("event %v is incorrect: %+v", i, fce)
+		}
+		if fce.Direction != modules.DiffApply || fce.Height != height {
+			t.Errorf("event %v has the wrong direction or height: %+v", i, fce)
+		}
+	}
+
+	// Payouts carry the ids and maturity heights of the delayed outputs.
+	payout := events[3].Payouts[1]
+	if payout.ID != expiredID.StorageProofOutputID(types.ProofMissed, 1) {
+		t.Error("missed proof payout has the wrong id")
+	}
+	if !payout.SiacoinOutput.Value.Equals64(7) || payout.MaturityHeight != height+types.MaturityDelay {
+		t.Error("missed proof payout is incorrect:", payout)
+	}
+	if events[2].Payouts[0].ID != provenID.StorageProofOutputID(types.ProofValid, 0) {
+		t.Error("valid proof payout has the wrong id")
+	}
+}
+
+// mockFileContractSubscriber records the file contract events it receives.
+type mockFileContractSubscriber struct {
+	events []modules.FileContractEvent
+}
+
+// ProcessFileContractEvents implements modules.FileContractSubscriber.
+func (m *mockFileContractSubscriber) ProcessFileContractEvents(_ modules.ConsensusChangeID, events []modules.FileContractEvent) {
+	m.events = append(m.events, events...)
+}
+
+// TestFileContractSubscriberAdapter checks that adapters of the same
+// subscriber compare equal, so that they can be unsubscribed.
+func TestFileContractSubscriberAdapter(t *testing.T) {
+	a, b := new(mockFileContractSubscriber), new(mockFileContractSubscriber)
+	var s modules.ConsensusSetSubscriber = fileContractSubscriber{a}
+	if s != (fileContractSubscriber{a}) {
+		t.Fatal("adapters of the same subscriber are not equal")
+	}
+	if s == (fileContractSubscriber{b}) {
+		t.Fatal("adapters of different subscribers are equal")
+	}
+	s.ProcessConsensusChange(modules.ConsensusChange{
+		FileContractEvents: []modules.FileContractEvent{{Type: modules.FileContractCreated}},
+	})
+	if len(a.events) != 1 || len(b.events) != 0 {
+		t.Fatal("events were not forwarded to the subscriber")
+	}
+}
@@ -70,6 +70,18 @@ func (cs *ConsensusSet) revertToBlock(tx *bolt.Tx, pb *processedBlock) (reverted
 	return revertedBlocks
 }
 
+// checkTransactionRules checks that every transaction in a block satisfies the
+// consensus set's custom transaction rule.
+func (cs *ConsensusSet) checkTransactionRules(pb *processedBlock) error {
+	for _, txn := range pb.Block.Transactions {
+		err := cs.checkTransactionRule(txn, pb.Height)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // applyUntilBlock will successively apply the blocks between the consensus
 // set's current path and 'pb'.
 func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (appliedBlocks []*processedBlock, err error) {
@@ -81,7 +93,10 @@ func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (applie
 		if block.DiffsGenerated {
 			commitDiffSet(tx, block, modules.DiffApply)
 		} else {
-			err := generateAndApplyDiff(tx, block)
+			err := cs.checkTransactionRules(block)
+			if err == nil {
+				err = generateAndApplyDiff(tx, block)
+			}
 			if err != nil {
 				// Mark the block as invalid.
 				cs.dosBlocks[block.Block.ID()] = struct{}{}
@@ -0,0 +1,132 @@
+package consensus
+
+// light.go contains the entry point for light verification of blocks. A light
+// client does not keep the consensus database, and instead checks the
+// transactions of a block against outputs that are proven to exist by
+// Merkle proofs into block headers that the client already trusts.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errInvalidOutputProof     = errors.New("siacoin output proof does not match the block header")
+	errLightUnsupported       = errors.New("transaction depends on consensus state that cannot be verified with siacoin output proofs")
+	errOutputProofOutOfBounds = errors.New("siacoin output proof does not point to a transaction in the block")
+	errUnknownProofHeader     = errors.New("siacoin output proof references an unknown block header")
+)
+
+// BuildSiacoinOutputProof returns a proof that the transaction at index
+// txnIndex of b, and therefore each of the siacoin outputs it creates, is part
+// of b.
+func BuildSiacoinOutputProof(b types.Block, txnIndex int) (modules.SiacoinOutputProof, error) {
+	if txnIndex < 0 || txnIndex >= len(b.Transactions) {
+		return modules.SiacoinOutputProof{}, errOutputProofOutOfBounds
+	}
+	leafIndex := uint64(len(b.MinerPayouts) + txnIndex)
+	tree := crypto.NewTree()
+	if err := tree.SetIndex(leafIndex); err != nil {
+		return modules.SiacoinOutputProof{}, err
+	}
+	for _, payout := range b.MinerPayouts {
+		tree.Push(encoding.Marshal(payout))
+	}
+	for _, txn := range b.Transactions {
+		tree.Push(encoding.Marshal(txn))
+	}
+	_, proofSet, _, numLeaves := tree.Prove()
+
+	// The first element of the proof set is the leaf itself, which is
+	// replaced by the transaction in the proof.
+	hashSet := make([]crypto.Hash, 0, len(proofSet))
+	for i := 1; i < len(proofSet); i++ {
+		var h crypto.Hash
+		copy(h[:], proofSet[i])
+		hashSet = append(hashSet, h)
+	}
+	return modules.SiacoinOutputProof{
+		Header:      b.Header(),
+		Transaction: b.Transactions[txnIndex],
+		LeafIndex:   leafIndex,
+		NumLeaves:   numLeaves,
+		HashSet:     hashSet,
+	}, nil
+}
+
+// verifySiacoinOutputProof checks that the transaction of a proof is part of
+// the block whose header is included in the proof.
+func verifySiacoinOutputProof(proof modules.SiacoinOutputProof) bool {
+	return crypto.VerifySegment(encoding.Marshal(proof.Transaction), proof.HashSet, proof.NumLeaves, proof.LeafIndex, proof.Header.MerkleRoot)
+}
+
+// VerifyBlockWithProofs checks the transactions of b, a block at the provided
+// height, against the siacoin outputs created by the transactions in proofs.
+// sizeLimit is the maximum encoded size of a block on the network, which is
+// types.BlockSizeLimit unless the network uses custom consensus options.
+// knownHeader should return true for the ids of block headers that the caller
+// trusts to be in the current path; proofs into any other block are rejected.
+//
+// The same transaction rules are applied as when a block is accepted by the
+// consensus set, but the existence of spent outputs is established by the
+// proofs rather than the consensus database. Because proofs cannot show that
+// an output is unspent, light verification does not detect double spends of
+// outputs spent in earlier blocks. Transactions that depend on other consensus
+// state (storage proofs, file contract revisions and siafund inputs) cannot
+// be verified and cause the block to be rejected.
+func VerifyBlockWithProofs(b types.Block, height types.BlockHeight, sizeLimit uint64, proofs []modules.SiacoinOutputProof, knownHeader func(types.BlockID) bool) error {
+	if uint64(len(encoding.Marshal(b))) > sizeLimit {
+		return errLargeBlock
+	}
+	if !checkMinerPayouts(b, height) {
+		return errBadMinerPayouts
+	}
+
+	// Build the partial set of siacoin outputs from the proofs.
+	outputs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	for _, proof := range proofs {
+		if !knownHeader(proof.Header.ID()) {
+			return errUnknownProofHeader
+		}
+		if !verifySiacoinOutputProof(proof) {
+			return errInvalidOutputProof
+		}
+		for i, sco := range proof.Transaction.SiacoinOutputs {
+			outputs[proof.Transaction.SiacoinOutputID(uint64(i))] = sco
+		}
+	}
+	getOutput := func(id types.SiacoinOutputID) (types.SiacoinOutput, bool) {
+		sco, exists := outputs[id]
+		return sco, exists
+	}
+
+	// Validate and apply each transaction in order, so that transactions may
+	// spend the outputs of earlier transactions in the block, and outputs
+	// cannot be spent twice within the block.
+	for _, txn := range b.Transactions {
+		if len(txn.StorageProofs) > 0 || len(txn.FileContractRevisions) > 0 || len(txn.SiafundInputs) > 0 {
+			return errLightUnsupported
+		}
+		// Transactions are checked against the height of the parent block,
+		// as they are when the block is accepted by the consensus set.
+		err := txn.StandaloneValid(height - 1)
+		if err != nil {
+			return err
+		}
+		err = checkSiacoins(txn, height-1, getOutput)
+		if err != nil {
+			return err
+		}
+		for _, sci := range txn.SiacoinInputs {
+			delete(outputs, sci.ParentID)
+		}
+		for i, sco := range txn.SiacoinOutputs {
+			outputs[txn.SiacoinOutputID(uint64(i))] = sco
+		}
+	}
+	return nil
+}
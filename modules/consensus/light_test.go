@@ -0,0 +1,96 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestVerifyBlockWithProofs checks that light verification accepts a block
+// that spends proven outputs, and rejects blocks that spend unproven outputs
+// or that use proofs into unknown blocks.
+func TestVerifyBlockWithProofs(t *testing.T) {
+	const height = 10
+	var uc types.UnlockConditions
+	value := types.NewCurrency64(1000)
+
+	// The parent block creates an output that can be spent without
+	// signatures.
+	creator := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Value: value, UnlockHash: uc.UnlockHash()}},
+	}
+	parent := types.Block{
+		MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(height - 1)}},
+		Transactions: []types.Transaction{{ArbitraryData: [][]byte{[]byte("filler")}}, creator},
+	}
+	proof, err := BuildSiacoinOutputProof(parent, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	knownHeader := func(id types.BlockID) bool { return id == parent.ID() }
+
+	// A block with two transactions, the second spending the output created
+	// by the first.
+	spend := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: creator.SiacoinOutputID(0), UnlockConditions: uc}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: value, UnlockHash: uc.UnlockHash()}},
+	}
+	child := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: spend.SiacoinOutputID(0), UnlockConditions: uc}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: value, UnlockHash: types.UnlockHash{1}}},
+	}
+	b := types.Block{
+		ParentID:     parent.ID(),
+		MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(height)}},
+		Transactions: []types.Transaction{spend, child},
+	}
+	err = VerifyBlockWithProofs(b, height, types.BlockSizeLimit, []modules.SiacoinOutputProof{proof}, knownHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the proof, the spent output is unknown.
+	err = VerifyBlockWithProofs(b, height, types.BlockSizeLimit, nil, knownHeader)
+	if err != errMissingSiacoinOutput {
+		t.Fatal("expected errMissingSiacoinOutput, got", err)
+	}
+
+	// Proofs into blocks that the caller does not know are rejected.
+	err = VerifyBlockWithProofs(b, height, types.BlockSizeLimit, []modules.SiacoinOutputProof{proof}, func(types.BlockID) bool { return false })
+	if err != errUnknownProofHeader {
+		t.Fatal("expected errUnknownProofHeader, got", err)
+	}
+
+	// A proof whose transaction was altered no longer matches the header.
+	forged := proof
+	forged.Transaction.SiacoinOutputs = []types.SiacoinOutput{{Value: value.Mul64(2), UnlockHash: uc.UnlockHash()}}
+	err = VerifyBlockWithProofs(b, height, types.BlockSizeLimit, []modules.SiacoinOutputProof{forged}, knownHeader)
+	if err != errInvalidOutputProof {
+		t.Fatal("expected errInvalidOutputProof, got", err)
+	}
+
+	// An output cannot be spent twice within the block.
+	double := b
+	double.Transactions = []types.Transaction{spend, spend}
+	err = VerifyBlockWithProofs(double, height, types.BlockSizeLimit, []modules.SiacoinOutputProof{proof}, knownHeader)
+	if err != errMissingSiacoinOutput && err != errRepeatSiacoinOutput {
+		t.Fatal("expected double spend to be rejected, got", err)
+	}
+
+	// Transactions depending on other consensus state cannot be verified.
+	unsupported := b
+	unsupported.Transactions = []types.Transaction{{SiafundInputs: []types.SiafundInput{{}}}}
+	err = VerifyBlockWithProofs(unsupported, height, types.BlockSizeLimit, nil, knownHeader)
+	if err != errLightUnsupported {
+		t.Fatal("expected errLightUnsupported, got", err)
+	}
+
+	// The miner payouts must still match the block subsidy.
+	overpaid := b
+	overpaid.MinerPayouts = []types.SiacoinOutput{{Value: types.CalculateCoinbase(height).Mul64(2)}}
+	err = VerifyBlockWithProofs(overpaid, height, types.BlockSizeLimit, []modules.SiacoinOutputProof{proof}, knownHeader)
+	if err != errBadMinerPayouts {
+		t.Fatal("expected errBadMinerPayouts, got", err)
+	}
+}
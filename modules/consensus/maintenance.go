@@ -21,13 +21,14 @@ var (
 // applyMinerPayouts adds a block's miner payouts to the consensus set as
 // delayed siacoin outputs.
 func applyMinerPayouts(tx *bolt.Tx, pb *processedBlock) {
+	delay := maturityDelay(tx)
 	for i := range pb.Block.MinerPayouts {
 		mpid := pb.Block.MinerPayoutID(uint64(i))
 		dscod := modules.DelayedSiacoinOutputDiff{
 			Direction:      modules.DiffApply,
 			ID:             mpid,
 			SiacoinOutput:  pb.Block.MinerPayouts[i],
-			MaturityHeight: pb.Height + types.MaturityDelay,
+			MaturityHeight: pb.Height + delay,
 		}
 		pb.DelayedSiacoinOutputDiffs = append(pb.DelayedSiacoinOutputDiffs, dscod)
 		commitDelayedSiacoinOutputDiff(tx, dscod, modules.DiffApply)
@@ -40,7 +41,7 @@ func applyMinerPayouts(tx *bolt.Tx, pb *processedBlock) {
 func applyMaturedSiacoinOutputs(tx *bolt.Tx, pb *processedBlock) {
 	// Skip this step if the blockchain is not old enough to have maturing
 	// outputs.
-	if pb.Height < types.MaturityDelay {
+	if pb.Height < maturityDelay(tx) {
 		return
 	}
 
@@ -117,6 +118,7 @@ func applyMissedStorageProof(tx *bolt.Tx, pb *processedBlock, fcid types.FileCon
 	}
 
 	// Add all of the outputs in the missed proof outputs to the consensus set.
+	delay := maturityDelay(tx)
 	for i, mpo := range fc.MissedProofOutputs {
 		// Sanity check - output should not already exist.
 		spoid := fcid.StorageProofOutputID(types.ProofMissed, uint64(i))
@@ -129,7 +131,7 @@ func applyMissedStorageProof(tx *bolt.Tx, pb *processedBlock, fcid types.FileCon
 			Direction:      modules.DiffApply,
 			ID:             spoid,
 			SiacoinOutput:  mpo,
-			MaturityHeight: pb.Height + types.MaturityDelay,
+			MaturityHeight: pb.Height + delay,
 		}
 		dscods = append(dscods, dscod)
 	}
@@ -0,0 +1,136 @@
+package consensus
+
+import (
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// maxOrphanBlocks is the maximum number of orphan blocks that the
+	// consensus set holds while waiting for their parents. Orphans cannot be
+	// validated, so anyone can submit them; the bound limits the memory an
+	// attacker can consume to maxOrphanBlocks * blockSizeLimit.
+	maxOrphanBlocks = build.Select(build.Var{
+		Standard: 32,
+		Dev:      32,
+		Testing:  4,
+	}).(int)
+)
+
+// orphanCache holds orphan blocks, keyed by the id of their missing parent,
+// so that they can be accepted once the parent arrives. When the cache is
+// full, the oldest orphan is evicted.
+type orphanCache struct {
+	blocks   map[types.BlockID]types.Block
+	children map[types.BlockID][]types.BlockID // parent id -> orphan ids
+	order    []types.BlockID                   // orphan ids, oldest first
+	max      int
+}
+
+// newOrphanCache returns an empty orphanCache that holds up to max orphans.
+func newOrphanCache(max int) *orphanCache {
+	return &orphanCache{
+		blocks:   make(map[types.BlockID]types.Block),
+		children: make(map[types.BlockID][]types.BlockID),
+		max:      max,
+	}
+}
+
+// add adds an orphan block to the cache, evicting the oldest orphan if the
+// cache is full. Blocks that are already cached are ignored.
+func (oc *orphanCache) add(b types.Block, id types.BlockID) {
+	if _, exists := oc.blocks[id]; exists || oc.max <= 0 {
+		return
+	}
+	if len(oc.order) >= oc.max {
+		oc.remove(oc.order[0])
+	}
+	oc.blocks[id] = b
+	oc.children[b.ParentID] = append(oc.children[b.ParentID], id)
+	oc.order = append(oc.order, id)
+}
+
+// remove removes an orphan from the cache.
+func (oc *orphanCache) remove(id types.BlockID) {
+	b, exists := oc.blocks[id]
+	if !exists {
+		return
+	}
+	delete(oc.blocks, id)
+	for i, oid := range oc.order {
+		if oid == id {
+			oc.order = append(oc.order[:i], oc.order[i+1:]...)
+			break
+		}
+	}
+	siblings := oc.children[b.ParentID]
+	for i, sid := range siblings {
+		if sid == id {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(oc.children, b.ParentID)
+	} else {
+		oc.children[b.ParentID] = siblings
+	}
+}
+
+// takeChildren removes the orphans whose parent is parentID from the cache
+// and returns them, oldest first.
+func (oc *orphanCache) takeChildren(parentID types.BlockID) []types.Block {
+	ids := oc.children[parentID]
+	if len(ids) == 0 {
+		return nil
+	}
+	children := make([]types.Block, 0, len(ids))
+	for _, id := range append([]types.BlockID(nil), ids...) {
+		children = append(children, oc.blocks[id])
+		oc.remove(id)
+	}
+	return children
+}
+
+// len returns the number of orphans in the cache.
+func (oc *orphanCache) len() int {
+	return len(oc.order)
+}
+
+// adoptOrphans removes the cached orphans whose parents are among blocks and
+// are now in the block tree, and returns them. The caller must hold the
+// consensus set's lock.
+func (cs *ConsensusSet) adoptOrphans(blocks []types.Block) (adopted []types.Block) {
+	if cs.orphans.len() == 0 {
+		return nil
+	}
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		blockMap := tx.Bucket(BlockMap)
+		for _, b := range blocks {
+			id := b.ID()
+			if blockMap.Get(id[:]) != nil {
+				adopted = append(adopted, cs.orphans.takeChildren(id)...)
+			}
+		}
+		return nil
+	})
+	return adopted
+}
+
+// managedAcceptOrphans tries to add orphans whose parents have arrived to the
+// consensus set. Orphans that extend the longest chain are relayed, like
+// blocks submitted through AcceptBlock. The children of accepted orphans are
+// adopted in turn.
+func (cs *ConsensusSet) managedAcceptOrphans(orphans []types.Block) {
+	for _, b := range orphans {
+		extended, err := cs.managedAcceptBlocks([]types.Block{b})
+		if err != nil {
+			cs.log.Debugln("WARN: failed to accept a cached orphan block:", err)
+		}
+		if extended {
+			cs.managedBroadcastBlock(b)
+		}
+	}
+}
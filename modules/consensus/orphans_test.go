@@ -0,0 +1,50 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// orphanBlock returns a block with the given parent and nonce, along with its
+// id.
+func orphanBlock(parent types.BlockID, nonce byte) (types.Block, types.BlockID) {
+	b := types.Block{ParentID: parent, Nonce: types.BlockNonce{nonce}}
+	return b, b.ID()
+}
+
+// TestOrphanCache checks that orphans are returned when their parent arrives
+// and that the oldest orphans are evicted when the cache is full.
+func TestOrphanCache(t *testing.T) {
+	oc := newOrphanCache(3)
+	parent := types.BlockID{1}
+	b1, id1 := orphanBlock(parent, 1)
+	b2, _ := orphanBlock(parent, 2)
+	b3, id3 := orphanBlock(id1, 3)
+	oc.add(b1, id1)
+	oc.add(b1, id1)
+	oc.add(b2, b2.ID())
+	oc.add(b3, id3)
+	if oc.len() != 3 {
+		t.Fatal("expected 3 orphans, got", oc.len())
+	}
+
+	// Adding a fourth orphan evicts the oldest.
+	b4, _ := orphanBlock(types.BlockID{2}, 4)
+	oc.add(b4, b4.ID())
+	if oc.len() != 3 {
+		t.Fatal("cache should be bounded, got", oc.len())
+	}
+	if children := oc.takeChildren(parent); len(children) != 1 || children[0].ID() != b2.ID() {
+		t.Fatal("expected only the newest child of the parent to remain, got", children)
+	}
+	if children := oc.takeChildren(parent); len(children) != 0 {
+		t.Fatal("children should be removed once taken, got", children)
+	}
+	if children := oc.takeChildren(id1); len(children) != 1 || children[0].ID() != id3 {
+		t.Fatal("orphans should be kept when their parent is evicted, got", children)
+	}
+	if oc.len() != 1 || len(oc.children) != 1 {
+		t.Fatal("cache should only hold the fourth orphan, got", oc.len(), len(oc.children))
+	}
+}
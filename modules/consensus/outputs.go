@@ -0,0 +1,67 @@
+package consensus
+
+import (
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// forEachSiacoinOutput calls fn on every unspent siacoin output in the
+// database, stopping at the first error returned by fn.
+func forEachSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.SiacoinOutput) error) error {
+	return tx.Bucket(SiacoinOutputs).ForEach(func(k, v []byte) error {
+		var id types.SiacoinOutputID
+		var sco types.SiacoinOutput
+		copy(id[:], k)
+		if err := encoding.Unmarshal(v, &sco); err != nil {
+			return err
+		}
+		return fn(id, sco)
+	})
+}
+
+// forEachSiafundOutput calls fn on every unspent siafund output in the
+// database, stopping at the first error returned by fn.
+func forEachSiafundOutput(tx *bolt.Tx, fn func(types.SiafundOutputID, types.SiafundOutput) error) error {
+	return tx.Bucket(SiafundOutputs).ForEach(func(k, v []byte) error {
+		var id types.SiafundOutputID
+		var sfo types.SiafundOutput
+		copy(id[:], k)
+		if err := encoding.Unmarshal(v, &sfo); err != nil {
+			return err
+		}
+		return fn(id, sfo)
+	})
+}
+
+// ForEachSiacoinOutput calls fn on every unspent siacoin output in the
+// current consensus set, stopping at and returning the first error returned
+// by fn. All outputs are read from a single database transaction, so the set
+// that fn sees is the one at a single height, even if blocks are applied
+// while the outputs are walked. A slow fn can delay the application of blocks:
+// the read transaction stays open until the walk is done, and blocks any write
+// that needs to remap the database.
+func (cs *ConsensusSet) ForEachSiacoinOutput(fn func(types.SiacoinOutputID, types.SiacoinOutput) error) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	return cs.db.View(func(tx *bolt.Tx) error {
+		return forEachSiacoinOutput(tx, fn)
+	})
+}
+
+// ForEachSiafundOutput calls fn on every unspent siafund output in the
+// current consensus set, stopping at and returning the first error returned
+// by fn. Like ForEachSiacoinOutput, all outputs are read from a single
+// database transaction.
+func (cs *ConsensusSet) ForEachSiafundOutput(fn func(types.SiafundOutputID, types.SiafundOutput) error) error {
+	if err := cs.tg.Add(); err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+	return cs.db.View(func(tx *bolt.Tx) error {
+		return forEachSiafundOutput(tx, fn)
+	})
+}
@@ -0,0 +1,86 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestForEachOutput checks that ForEachSiacoinOutput and ForEachSiafundOutput
+// visit every unspent output exactly once, and that they stop on the first
+// error of the callback.
+func TestForEachOutput(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	var numSiacoinOutputs, numSiafundOutputs int
+	_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+		numSiacoinOutputs = tx.Bucket(SiacoinOutputs).Stats().KeyN
+		numSiafundOutputs = tx.Bucket(SiafundOutputs).Stats().KeyN
+		return nil
+	})
+	if numSiacoinOutputs == 0 || numSiafundOutputs == 0 {
+		t.Fatal("tester should have siacoin and siafund outputs")
+	}
+
+	seenSiacoins := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	err = cst.cs.ForEachSiacoinOutput(func(id types.SiacoinOutputID, sco types.SiacoinOutput) error {
+		if _, ok := seenSiacoins[id]; ok {
+			t.Error("siacoin output visited twice:", id)
+		}
+		seenSiacoins[id] = sco
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seenSiacoins) != numSiacoinOutputs {
+		t.Fatalf("visited %v siacoin outputs, expected %v", len(seenSiacoins), numSiacoinOutputs)
+	}
+	_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+		for id, sco := range seenSiacoins {
+			if stored, err := getSiacoinOutput(tx, id); err != nil || !stored.Value.Equals(sco.Value) || stored.UnlockHash != sco.UnlockHash {
+				t.Error("visited siacoin output does not match the database:", id, err)
+			}
+		}
+		return nil
+	})
+
+	var siafunds types.Currency
+	seenSiafunds := 0
+	err = cst.cs.ForEachSiafundOutput(func(id types.SiafundOutputID, sfo types.SiafundOutput) error {
+		seenSiafunds++
+		siafunds = siafunds.Add(sfo.Value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seenSiafunds != numSiafundOutputs {
+		t.Fatalf("visited %v siafund outputs, expected %v", seenSiafunds, numSiafundOutputs)
+	}
+	if !siafunds.Equals(types.SiafundCount) {
+		t.Fatal("siafund outputs do not add up to the siafund count:", siafunds)
+	}
+
+	// The walk stops on the first error of the callback.
+	errStop := errors.New("stop")
+	visited := 0
+	err = cst.cs.ForEachSiacoinOutput(func(types.SiacoinOutputID, types.SiacoinOutput) error {
+		visited++
+		return errStop
+	})
+	if err != errStop || visited != 1 {
+		t.Fatal("walk did not stop on the callback's error:", err, visited)
+	}
+}
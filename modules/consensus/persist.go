@@ -47,6 +47,13 @@ func (cs *ConsensusSet) loadDB() error {
 			return err
 		}
 
+		// Initialize the supply totals, scanning the blockchain if the
+		// database predates them.
+		err = cs.initSupply(tx)
+		if err != nil {
+			return err
+		}
+
 		// Check that the genesis block is correct - typically only incorrect
 		// in the event of developer binaries vs. release binaires.
 		genesisID, err := getPath(tx, 0)
@@ -56,6 +63,11 @@ func (cs *ConsensusSet) loadDB() error {
 		if genesisID != cs.blockRoot.Block.ID() {
 			return errors.New("Blockchain has wrong genesis block, exiting.")
 		}
+
+		// Check that the database was created with the same maturity delay.
+		if maturityDelay(tx) != cs.maturityDelay {
+			return errWrongMaturityDelay
+		}
 		return nil
 	})
 }
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"bytes"
 	"math/big"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -49,6 +50,35 @@ func (pb *processedBlock) heavierThan(cmp *processedBlock) bool {
 	return requirement.Cmp(pb.Depth) > 0 // Inversed, because the smaller target is actually heavier.
 }
 
+// winsTie returns true if the blockNode is exactly as heavy as 'cmp' and has
+// the numerically smaller block ID. Because the rule only depends on the two
+// blocks, every node settles on the same block regardless of the order in
+// which the blocks arrived.
+func (pb *processedBlock) winsTie(cmp *processedBlock) bool {
+	if pb.Depth != cmp.Depth {
+		return false
+	}
+	id, cmpID := pb.Block.ID(), cmp.Block.ID()
+	return bytes.Compare(id[:], cmpID[:]) < 0
+}
+
+// replaces returns true if the blockNode should replace 'cmp' as the tip of
+// the current path. 'cmp' is expected to be the current block node. The
+// blockNode must be heavier than 'cmp' by the surpass threshold, or, once
+// both blocks are past the TieBreakHardforkBlock, it must win the tie
+// against an equally heavy 'cmp'. Older nodes stay on whichever of two
+// equally heavy blocks they saw first, so the tie-breaker is only applied
+// after the hardfork.
+func (pb *processedBlock) replaces(cmp *processedBlock) bool {
+	if pb.heavierThan(cmp) {
+		return true
+	}
+	if pb.Height < types.TieBreakHardforkBlock || cmp.Height < types.TieBreakHardforkBlock {
+		return false
+	}
+	return pb.winsTie(cmp)
+}
+
 // childDepth returns the depth of a blockNode's child nodes. The depth is the
 // "sum" of the current depth and current difficulty. See target.Add for more
 // detailed information.
@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	"bytes"
 	"path/filepath"
 	"testing"
 
@@ -141,6 +142,71 @@ func TestUnitHeavierThan(t *testing.T) {
 	}
 }
 
+// TestUnitWinsTie probes the winsTie method of the processedBlock type.
+func TestUnitWinsTie(t *testing.T) {
+	pb1 := new(processedBlock)
+	pb1.Depth[0] = 64
+	pb2 := new(processedBlock)
+	pb2.Depth[0] = 64
+	pb2.Block.Nonce[0] = 1
+
+	// Order the nodes so that low has the smaller block ID.
+	low, high := pb1, pb2
+	lowID, highID := low.Block.ID(), high.Block.ID()
+	if bytes.Compare(lowID[:], highID[:]) > 0 {
+		low, high = high, low
+	}
+	if !low.winsTie(high) {
+		t.Error("node with the smaller id should win the tie")
+	}
+	if high.winsTie(low) {
+		t.Error("node with the larger id should lose the tie")
+	}
+	if low.winsTie(low) {
+		t.Error("node should not win a tie against itself")
+	}
+
+	// Nodes of different weight are not tied.
+	high.Depth[0] = 60
+	if low.winsTie(high) || high.winsTie(low) {
+		t.Error("nodes of different weight should not be tied")
+	}
+}
+
+// TestUnitReplaces probes the replaces method of the processedBlock type.
+func TestUnitReplaces(t *testing.T) {
+	pbLight := new(processedBlock)
+	pbLight.Depth[0] = 64
+	pbLight.ChildTarget[0] = 200
+	pbHeavy := new(processedBlock)
+	pbHeavy.Depth[0] = 16
+	pbHeavy.ChildTarget[0] = 200
+	if !pbHeavy.replaces(pbLight) || pbLight.replaces(pbHeavy) {
+		t.Error("replaces disagrees with heavierThan")
+	}
+
+	// Create two equally heavy nodes, with low having the smaller block ID.
+	low, high := new(processedBlock), new(processedBlock)
+	low.Depth[0], high.Depth[0] = 64, 64
+	low.ChildTarget[0], high.ChildTarget[0] = 200, 200
+	high.Block.Nonce[0] = 1
+	lowID, highID := low.Block.ID(), high.Block.ID()
+	if bytes.Compare(lowID[:], highID[:]) > 0 {
+		low, high = high, low
+	}
+
+	// Before the hardfork, ties are not broken.
+	low.Height, high.Height = types.TieBreakHardforkBlock-1, types.TieBreakHardforkBlock-1
+	if low.replaces(high) || high.replaces(low) {
+		t.Error("tie was broken before the hardfork")
+	}
+	// After the hardfork, the smaller id wins.
+	low.Height, high.Height = types.TieBreakHardforkBlock, types.TieBreakHardforkBlock
+	if !low.replaces(high) || high.replaces(low) {
+		t.Error("tie was not broken in favor of the smaller id after the hardfork")
+	}
+}
+
 // TestChildDepth probes the childDeath method of the blockNode type.
 func TestChildDepth(t *testing.T) {
 	// Try adding to equal weight nodes, result should be half.
@@ -3,6 +3,7 @@ package consensus
 import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 
 	siasync "github.com/NebulousLabs/Sia/sync"
 	"github.com/NebulousLabs/bolt"
@@ -49,6 +50,12 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 			sfpd.Direction = modules.DiffRevert
 			cc.SiafundPoolDiffs = append(cc.SiafundPoolDiffs, sfpd)
 		}
+		fces := fileContractEvents(revertedBlock, maturityDelay(tx))
+		for i := len(fces) - 1; i >= 0; i-- {
+			fce := fces[i]
+			fce.Direction = modules.DiffRevert
+			cc.FileContractEvents = append(cc.FileContractEvents, fce)
+		}
 	}
 	for _, appliedBlockID := range ce.AppliedBlocks {
 		appliedBlock, err := getBlockMap(tx, appliedBlockID)
@@ -73,8 +80,12 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		for _, sfpd := range appliedBlock.SiafundPoolDiffs {
 			cc.SiafundPoolDiffs = append(cc.SiafundPoolDiffs, sfpd)
 		}
+		cc.FileContractEvents = append(cc.FileContractEvents, fileContractEvents(appliedBlock, maturityDelay(tx))...)
 	}
 
+	cc.ReorgDepth = types.BlockHeight(len(cc.RevertedBlocks))
+	cc.RevertedTransactions = revertedTransactions(cc.RevertedBlocks, cc.AppliedBlocks)
+
 	// Grab the child target and the minimum valid child timestamp.
 	recentBlock := ce.AppliedBlocks[len(ce.AppliedBlocks)-1]
 	pb, err := getBlockMap(tx, recentBlock)
@@ -95,10 +106,36 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 	return cc, nil
 }
 
+// revertedTransactions returns the IDs of the transactions of the reverted
+// blocks that are not confirmed again by the applied blocks, in the order
+// that they were reverted.
+func revertedTransactions(reverted, applied []types.Block) []types.TransactionID {
+	if len(reverted) == 0 {
+		return nil
+	}
+	confirmed := make(map[types.TransactionID]struct{})
+	for _, block := range applied {
+		for _, txn := range block.Transactions {
+			confirmed[txn.ID()] = struct{}{}
+		}
+	}
+	var txids []types.TransactionID
+	for _, block := range reverted {
+		for i := len(block.Transactions) - 1; i >= 0; i-- {
+			txid := block.Transactions[i].ID()
+			if _, exists := confirmed[txid]; !exists {
+				txids = append(txids, txid)
+			}
+		}
+	}
+	return txids
+}
+
 // readLockUpdateSubscribers will inform all subscribers of a new update to the
 // consensus set. updateSubscribers does not alter the changelog, the changelog
-// must be updated beforehand.
-func (cs *ConsensusSet) updateSubscribers(ce changeEntry) {
+// must be updated beforehand. The consensus change that was sent to the
+// subscribers is returned.
+func (cs *ConsensusSet) updateSubscribers(ce changeEntry) modules.ConsensusChange {
 	// Get the consensus change and send it to all subscribers.
 	var cc modules.ConsensusChange
 	err := cs.db.View(func(tx *bolt.Tx) error {
@@ -109,11 +146,12 @@ func (cs *ConsensusSet) updateSubscribers(ce changeEntry) {
 	})
 	if err != nil {
 		cs.log.Critical("computeConsensusChange failed:", err)
-		return
+		return modules.ConsensusChange{}
 	}
 	for _, subscriber := range cs.subscribers {
 		subscriber.ProcessConsensusChange(cc)
 	}
+	return cc
 }
 
 // managedInitializeSubscribe will take a subscriber and feed them all of the
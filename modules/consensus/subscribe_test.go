@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // mockSubscriber receives and holds changes to the consensus set, remembering
@@ -148,3 +149,32 @@ func TestUnsubscribe(t *testing.T) {
 		t.Error("mock subscriber was not correctly unsubscribed")
 	}
 }
+
+// TestRevertedTransactions checks that revertedTransactions reports the
+// transactions of reverted blocks in the order they were reverted, leaving out
+// those that the applied blocks confirm again.
+func TestRevertedTransactions(t *testing.T) {
+	txn := func(data string) types.Transaction {
+		return types.Transaction{ArbitraryData: [][]byte{[]byte(data)}}
+	}
+	a, b, c, d := txn("a"), txn("b"), txn("c"), txn("d")
+	reverted := []types.Block{
+		{Transactions: []types.Transaction{c, d}},
+		{Transactions: []types.Transaction{a, b}},
+	}
+	applied := []types.Block{{Transactions: []types.Transaction{b}}}
+
+	if txids := revertedTransactions(nil, applied); txids != nil {
+		t.Fatal("expected no reverted transactions, got", txids)
+	}
+	txids := revertedTransactions(reverted, applied)
+	expected := []types.TransactionID{d.ID(), c.ID(), a.ID()}
+	if len(txids) != len(expected) {
+		t.Fatalf("expected %v reverted transactions, got %v", len(expected), len(txids))
+	}
+	for i := range expected {
+		if txids[i] != expected[i] {
+			t.Errorf("reverted transaction %v is incorrect", i)
+		}
+	}
+}
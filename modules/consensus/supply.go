@@ -0,0 +1,174 @@
+package consensus
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	errSupplyMismatch = errors.New("issued siacoin supply does not match the coinbase schedule")
+)
+
+var (
+	// BucketSupply is a database bucket that tracks the number of siacoins
+	// that have been issued and burned at the current height. The totals are
+	// updated as blocks are applied and reverted, so that the supply does not
+	// need to be recomputed by scanning the blockchain.
+	BucketSupply = []byte("Supply")
+
+	// FieldSupplyIssued is a field in BucketSupply containing the total
+	// number of siacoins that have been created by coinbases.
+	FieldSupplyIssued = []byte("Issued")
+
+	// FieldSupplyBurned is a field in BucketSupply containing the total
+	// number of siacoins that have been sent to the void and can never be
+	// spent.
+	FieldSupplyBurned = []byte("Burned")
+)
+
+// blockSupplyChange returns the number of siacoins issued and burned by a
+// processed block. A block issues exactly its coinbase - fees are not new
+// coins. Any delayed output created by the block that is paid to the void
+// (such as the missed proof output of a file contract or a miner payout with
+// a blank unlock hash) is considered burned.
+func blockSupplyChange(pb *processedBlock) (issued, burned types.Currency) {
+	issued = types.CalculateCoinbase(pb.Height)
+	for _, dscod := range pb.DelayedSiacoinOutputDiffs {
+		if dscod.Direction == modules.DiffApply && dscod.SiacoinOutput.UnlockHash == (types.UnlockHash{}) {
+			burned = burned.Add(dscod.SiacoinOutput.Value)
+		}
+	}
+	return issued, burned
+}
+
+// getSupply returns the number of siacoins issued and burned at the current
+// height.
+func getSupply(tx *bolt.Tx) (issued, burned types.Currency) {
+	bucket := tx.Bucket(BucketSupply)
+	err := encoding.Unmarshal(bucket.Get(FieldSupplyIssued), &issued)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	err = encoding.Unmarshal(bucket.Get(FieldSupplyBurned), &burned)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return issued, burned
+}
+
+// setSupply stores the number of siacoins issued and burned at the current
+// height.
+func setSupply(tx *bolt.Tx, issued, burned types.Currency) error {
+	bucket := tx.Bucket(BucketSupply)
+	err := bucket.Put(FieldSupplyIssued, encoding.Marshal(issued))
+	if err != nil {
+		return err
+	}
+	return bucket.Put(FieldSupplyBurned, encoding.Marshal(burned))
+}
+
+// commitSupplyDiff adds or removes the siacoins issued and burned by a block
+// from the supply totals.
+func commitSupplyDiff(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
+	issued, burned := getSupply(tx)
+	blockIssued, blockBurned := blockSupplyChange(pb)
+	if dir == modules.DiffApply {
+		issued = issued.Add(blockIssued)
+		burned = burned.Add(blockBurned)
+	} else {
+		issued = issued.Sub(blockIssued)
+		burned = burned.Sub(blockBurned)
+	}
+
+	// Sanity check - the issued supply should always match the coinbase
+	// schedule for the height of the resulting block.
+	if build.DEBUG {
+		height := pb.Height
+		if dir == modules.DiffRevert {
+			height--
+		}
+		if !issued.Equals(types.CalculateNumSiacoins(height)) {
+			panic(errSupplyMismatch)
+		}
+	}
+	err := setSupply(tx, issued, burned)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// initSupply will initialize the supply totals. This is separate from the
+// initialization process for compatibility reasons - older databases will not
+// have the supply bucket, in which case the totals are computed by scanning
+// the current path once.
+func (cs *ConsensusSet) initSupply(tx *bolt.Tx) error {
+	if tx.Bucket(BucketSupply) != nil {
+		return nil
+	}
+	_, err := tx.CreateBucket(BucketSupply)
+	if err != nil {
+		return err
+	}
+
+	// The genesis block pays its coinbase to the void.
+	issued := types.CalculateCoinbase(0)
+	burned := types.CalculateCoinbase(0)
+	height := blockHeight(tx)
+	for i := types.BlockHeight(1); i <= height; i++ { // Skip Genesis block
+		id, err := getPath(tx, i)
+		if err != nil {
+			return err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		blockIssued, blockBurned := blockSupplyChange(pb)
+		issued = issued.Add(blockIssued)
+		burned = burned.Add(blockBurned)
+	}
+	return setSupply(tx, issued, burned)
+}
+
+// TotalSupply returns the number of siacoins that have been issued at the
+// current height.
+func (cs *ConsensusSet) TotalSupply() (issued types.Currency) {
+	err := cs.tg.Add()
+	if err != nil {
+		return types.ZeroCurrency
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		issued, _ = getSupply(tx)
+		return nil
+	})
+	return issued
+}
+
+// CirculatingSupply returns the number of siacoins that have been issued at
+// the current height, excluding siacoins that are known to have been burned.
+func (cs *ConsensusSet) CirculatingSupply() (circulating types.Currency) {
+	err := cs.tg.Add()
+	if err != nil {
+		return types.ZeroCurrency
+	}
+	defer cs.tg.Done()
+	cs.mu.RLock()
+	defer cs.mu.RUnlock()
+
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		issued, burned := getSupply(tx)
+		circulating = issued.Sub(burned)
+		return nil
+	})
+	return circulating
+}
@@ -0,0 +1,74 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestBlockSupplyChange checks that only the coinbase is counted as issued,
+// and that only outputs created for the void are counted as burned.
+func TestBlockSupplyChange(t *testing.T) {
+	pb := &processedBlock{
+		Height: 10,
+		DelayedSiacoinOutputDiffs: []modules.DelayedSiacoinOutputDiff{
+			{
+				// Missed proof output paid to the void.
+				Direction:     modules.DiffApply,
+				SiacoinOutput: types.SiacoinOutput{Value: types.NewCurrency64(30)},
+			},
+			{
+				// Miner payout paid to an address.
+				Direction:     modules.DiffApply,
+				SiacoinOutput: types.SiacoinOutput{Value: types.NewCurrency64(500), UnlockHash: types.UnlockHash{1}},
+			},
+			{
+				// Matured void output being removed from the delayed set.
+				Direction:     modules.DiffRevert,
+				SiacoinOutput: types.SiacoinOutput{Value: types.NewCurrency64(7)},
+			},
+		},
+	}
+	issued, burned := blockSupplyChange(pb)
+	if !issued.Equals(types.CalculateCoinbase(10)) {
+		t.Error("wrong issued supply:", issued)
+	}
+	if !burned.Equals64(30) {
+		t.Error("wrong burned supply:", burned)
+	}
+}
+
+// TestIntegrationSupplyReorg checks that the supply totals follow the
+// coinbase schedule as blocks are applied and reverted.
+func TestIntegrationSupplyReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rs := createReorgSets(t.Name())
+	defer rs.Close()
+
+	checkSupply := func(cst *consensusSetTester) {
+		total := cst.cs.TotalSupply()
+		if expected := types.CalculateNumSiacoins(cst.cs.Height()); !total.Equals(expected) {
+			t.Fatalf("total supply is %v, expected %v", total, expected)
+		}
+		// The genesis miner payout is always burned.
+		if circulating := cst.cs.CirculatingSupply(); circulating.Cmp(total.Sub(types.CalculateCoinbase(0))) > 0 {
+			t.Fatalf("circulating supply %v includes the genesis payout", circulating)
+		}
+	}
+	checkSupply(rs.cstMain)
+
+	rs.cstMain.testMissedStorageProofBlocks()
+	checkSupply(rs.cstMain)
+	circulating := rs.cstMain.cs.CirculatingSupply()
+
+	// Reorg the blocks out and back in, the totals should be unchanged.
+	rs.fullReorg()
+	checkSupply(rs.cstMain)
+	if c := rs.cstMain.cs.CirculatingSupply(); !c.Equals(circulating) {
+		t.Fatalf("circulating supply changed after reorg: %v -> %v", circulating, c)
+	}
+}
@@ -209,7 +209,7 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 	for moreAvailable {
 		// Read a slice of blocks from the wire.
 		var newBlocks []types.Block
-		if err := encoding.ReadObject(conn, &newBlocks, uint64(MaxCatchUpBlocks)*types.BlockSizeLimit); err != nil {
+		if err := encoding.ReadObject(conn, &newBlocks, uint64(MaxCatchUpBlocks)*cs.blockSizeLimit); err != nil {
 			return err
 		}
 		if err := encoding.ReadObject(conn, &moreAvailable, 1); err != nil {
@@ -523,7 +523,7 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 			return err
 		}
 		var block types.Block
-		if err := encoding.ReadObject(conn, &block, types.BlockSizeLimit); err != nil {
+		if err := encoding.ReadObject(conn, &block, cs.blockSizeLimit); err != nil {
 			return err
 		}
 		chainExtended, err := cs.managedAcceptBlocks([]types.Block{block})
@@ -20,6 +20,7 @@ var (
 	errLowRevisionNumber          = errors.New("transaction has a file contract with an outdated revision number")
 	errMissingSiacoinOutput       = errors.New("transaction spends a nonexisting siacoin output")
 	errMissingSiafundOutput       = errors.New("transaction spends a nonexisting siafund output")
+	errRepeatSiacoinOutput        = errors.New("transaction creates a siacoin output whose id is already in the consensus set")
 	errSiacoinInputOutputMismatch = errors.New("siacoin inputs do not equal siacoin outputs for transaction")
 	errSiafundInputOutputMismatch = errors.New("siafund inputs do not equal siafund outputs for transaction")
 	errUnfinishedFileContract     = errors.New("file contract window has not yet openend")
@@ -31,20 +32,33 @@ var (
 // context of the current consensus set.
 func validSiacoins(tx *bolt.Tx, t types.Transaction) error {
 	scoBucket := tx.Bucket(SiacoinOutputs)
+	return checkSiacoins(t, blockHeight(tx), func(id types.SiacoinOutputID) (sco types.SiacoinOutput, exists bool) {
+		scoBytes := scoBucket.Get(id[:])
+		if scoBytes == nil {
+			return types.SiacoinOutput{}, false
+		}
+		err := encoding.Unmarshal(scoBytes, &sco)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		return sco, true
+	})
+}
+
+// checkSiacoins checks that the siacoin inputs and outputs of a transaction
+// are valid at the provided height, using getOutput to look up the outputs
+// that exist. Separating the rules from the lookup allows them to be applied
+// to sets of outputs that are not stored in the database.
+func checkSiacoins(t types.Transaction, height types.BlockHeight, getOutput func(types.SiacoinOutputID) (types.SiacoinOutput, bool)) error {
 	var inputSum types.Currency
 	for _, sci := range t.SiacoinInputs {
 		// Check that the input spends an existing output.
-		scoBytes := scoBucket.Get(sci.ParentID[:])
-		if scoBytes == nil {
+		sco, exists := getOutput(sci.ParentID)
+		if !exists {
 			return errMissingSiacoinOutput
 		}
 
 		// Check that the unlock conditions match the required unlock hash.
-		var sco types.SiacoinOutput
-		err := encoding.Unmarshal(scoBytes, &sco)
-		if build.DEBUG && err != nil {
-			panic(err)
-		}
 		if sci.UnlockConditions.UnlockHash() != sco.UnlockHash {
 			return errWrongUnlockConditions
 		}
@@ -54,6 +68,20 @@ func validSiacoins(tx *bolt.Tx, t types.Transaction) error {
 	if !inputSum.Equals(t.SiacoinOutputSum()) {
 		return errSiacoinInputOutputMismatch
 	}
+
+	// Check that none of the created outputs already exist. Output ids are
+	// derived from the transaction, so a collision means that the same
+	// transaction is being applied twice, which would overwrite the existing
+	// output. Blocks before the hardfork were accepted without this check, so
+	// it only applies from the RepeatOutputHardforkBlock onwards.
+	if height < types.RepeatOutputHardforkBlock {
+		return nil
+	}
+	for i := range t.SiacoinOutputs {
+		if _, exists := getOutput(t.SiacoinOutputID(uint64(i))); exists {
+			return errRepeatSiacoinOutput
+		}
+	}
 	return nil
 }
 
@@ -313,6 +341,15 @@ func validTransaction(tx *bolt.Tx, t types.Transaction) error {
 	return nil
 }
 
+// checkTransactionRule checks that a transaction satisfies the consensus
+// set's custom transaction rule, if one has been provided.
+func (cs *ConsensusSet) checkTransactionRule(t types.Transaction, height types.BlockHeight) error {
+	if cs.transactionRule == nil {
+		return nil
+	}
+	return cs.transactionRule(t, height)
+}
+
 // tryTransactionSet applies the input transactions to the consensus set to
 // determine if they are valid. An error is returned IFF they are not a valid
 // set in the current consensus set. The size of the transactions and the set
@@ -334,7 +371,11 @@ func (cs *ConsensusSet) tryTransactionSet(txns []types.Transaction) (modules.Con
 	err := cs.db.Update(func(tx *bolt.Tx) error {
 		diffHolder.Height = blockHeight(tx)
 		for _, txn := range txns {
-			err := validTransaction(tx, txn)
+			err := cs.checkTransactionRule(txn, diffHolder.Height+1)
+			if err != nil {
+				return err
+			}
+			err = validTransaction(tx, txn)
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,140 @@
+package consensus
+
+import (
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// consensusView implements modules.ConsensusView on top of a read-only bolt
+// transaction. The transaction is cleared when the view's callback returns,
+// so that the view cannot outlive it.
+type consensusView struct {
+	tx *bolt.Tx
+}
+
+// open returns true if the view can still be used. Using a view after its
+// callback has returned is a developer error.
+func (v *consensusView) open() bool {
+	if v.tx == nil {
+		build.Critical("ConsensusView used after its View callback returned")
+		return false
+	}
+	return true
+}
+
+// BlockAtHeight returns the block at the given height in the current path.
+func (v *consensusView) BlockAtHeight(height types.BlockHeight) (types.Block, bool) {
+	if !v.open() {
+		return types.Block{}, false
+	}
+	id, err := getPath(v.tx, height)
+	if err != nil {
+		return types.Block{}, false
+	}
+	pb, err := getBlockMap(v.tx, id)
+	if err != nil {
+		return types.Block{}, false
+	}
+	return pb.Block, true
+}
+
+// BlockByID returns the block with the given id and its height.
+func (v *consensusView) BlockByID(id types.BlockID) (types.Block, types.BlockHeight, bool) {
+	if !v.open() {
+		return types.Block{}, 0, false
+	}
+	pb, err := getBlockMap(v.tx, id)
+	if err != nil {
+		return types.Block{}, 0, false
+	}
+	return pb.Block, pb.Height, true
+}
+
+// CurrentBlock returns the latest block in the current path.
+func (v *consensusView) CurrentBlock() types.Block {
+	if !v.open() {
+		return types.Block{}
+	}
+	return currentProcessedBlock(v.tx).Block
+}
+
+// FileContract returns the unresolved file contract with the given id.
+func (v *consensusView) FileContract(id types.FileContractID) (types.FileContract, bool) {
+	if !v.open() {
+		return types.FileContract{}, false
+	}
+	fc, err := getFileContract(v.tx, id)
+	return fc, err == nil
+}
+
+// Height returns the height of the current path.
+func (v *consensusView) Height() types.BlockHeight {
+	if !v.open() {
+		return 0
+	}
+	return blockHeight(v.tx)
+}
+
+// InCurrentPath returns true if the block is in the current path.
+func (v *consensusView) InCurrentPath(id types.BlockID) bool {
+	if !v.open() {
+		return false
+	}
+	pb, err := getBlockMap(v.tx, id)
+	if err != nil {
+		return false
+	}
+	pathID, err := getPath(v.tx, pb.Height)
+	return err == nil && pathID == id
+}
+
+// SiacoinOutput returns the unspent siacoin output with the given id.
+func (v *consensusView) SiacoinOutput(id types.SiacoinOutputID) (types.SiacoinOutput, bool) {
+	if !v.open() {
+		return types.SiacoinOutput{}, false
+	}
+	sco, err := getSiacoinOutput(v.tx, id)
+	return sco, err == nil
+}
+
+// SiafundOutput returns the unspent siafund output with the given id.
+func (v *consensusView) SiafundOutput(id types.SiafundOutputID) (types.SiafundOutput, bool) {
+	if !v.open() {
+		return types.SiafundOutput{}, false
+	}
+	sfo, err := getSiafundOutput(v.tx, id)
+	return sfo, err == nil
+}
+
+// SiafundPool returns the number of siacoins in the siafund pool.
+func (v *consensusView) SiafundPool() types.Currency {
+	if !v.open() {
+		return types.Currency{}
+	}
+	return getSiafundPool(v.tx)
+}
+
+// View calls fn with a read-only view of the consensus set. Every lookup made
+// through the view shares a single read transaction, so fn sees a consistent
+// snapshot even if blocks are accepted concurrently. Because the transaction
+// is read-only, the view cannot modify the consensus set.
+//
+// fn must not call methods on the ConsensusSet that write to the database,
+// such as AcceptBlock, as they may wait for the read transaction to finish.
+func (cs *ConsensusSet) View(fn func(modules.ConsensusView) error) error {
+	// A call to a closed database can cause undefined behavior.
+	err := cs.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cs.tg.Done()
+
+	return cs.db.View(func(tx *bolt.Tx) error {
+		v := &consensusView{tx: tx}
+		defer func() { v.tx = nil }()
+		return fn(v)
+	})
+}
@@ -0,0 +1,87 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestView checks that the lookups of a ConsensusView agree with the
+// consensus set, and that a view cannot be used after its callback returns.
+func TestView(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	height := cst.cs.Height()
+	current := cst.cs.CurrentBlock()
+	genesis := types.GenesisBlock
+
+	// The view should agree with the database about whether a miner payout
+	// is an unspent siacoin output.
+	payoutBlock, _ := cst.cs.BlockAtHeight(height - types.MaturityDelay - 1)
+	payoutID := payoutBlock.MinerPayoutID(0)
+	payout, payoutErr := cst.cs.dbGetSiacoinOutput(payoutID)
+	var saved modules.ConsensusView
+	err = cst.cs.View(func(v modules.ConsensusView) error {
+		saved = v
+		if v.Height() != height {
+			t.Error("view height does not match consensus height:", v.Height(), height)
+		}
+		if v.CurrentBlock().ID() != current.ID() {
+			t.Error("view current block does not match consensus current block")
+		}
+		if b, exists := v.BlockAtHeight(height); !exists || b.ID() != current.ID() {
+			t.Error("BlockAtHeight did not return the current block")
+		}
+		if _, exists := v.BlockAtHeight(height + 1); exists {
+			t.Error("BlockAtHeight returned a block above the current height")
+		}
+		if b, h, exists := v.BlockByID(current.ID()); !exists || h != height || b.ID() != current.ID() {
+			t.Error("BlockByID did not return the current block")
+		}
+		if !v.InCurrentPath(genesis.ID()) || v.InCurrentPath(types.BlockID{1}) {
+			t.Error("InCurrentPath is incorrect")
+		}
+
+		sco, exists := v.SiacoinOutput(payoutID)
+		if exists != (payoutErr == nil) || sco.Value.Cmp(payout.Value) != 0 {
+			t.Error("SiacoinOutput does not match the database")
+		}
+		if _, exists := v.SiacoinOutput(types.SiacoinOutputID{}); exists {
+			t.Error("SiacoinOutput returned a nonexistent output")
+		}
+		if _, exists := v.SiafundOutput(types.SiafundOutputID{}); exists {
+			t.Error("SiafundOutput returned a nonexistent output")
+		}
+		if _, exists := v.FileContract(types.FileContractID{}); exists {
+			t.Error("FileContract returned a nonexistent contract")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The callback's error should be returned by View.
+	errCallback := errors.New("callback error")
+	if err := cst.cs.View(func(modules.ConsensusView) error { return errCallback }); err != errCallback {
+		t.Fatal("expected callback error, got", err)
+	}
+
+	// Using the view after the callback returns is a developer error.
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic when using a view after its callback returned")
+		}
+	}()
+	saved.Height()
+}
@@ -466,10 +466,10 @@ func dbCalculateBlockFacts(tx *bolt.Tx, cs modules.ConsensusSet, block types.Blo
 
 	// calculate maturity timestamp
 	var maturityTimestamp types.Timestamp
-	if bf.Height > types.MaturityDelay {
-		oldBlock, exists := cs.BlockAtHeight(bf.Height - types.MaturityDelay)
+	if delay := cs.MaturityDelay(); bf.Height > delay {
+		oldBlock, exists := cs.BlockAtHeight(bf.Height - delay)
 		if !exists {
-			panic(fmt.Sprint("ConsensusSet is missing block at height", bf.Height-types.MaturityDelay))
+			panic(fmt.Sprint("ConsensusSet is missing block at height", bf.Height-delay))
 		}
 		maturityTimestamp = oldBlock.Timestamp
 	}
@@ -137,6 +137,25 @@ type (
 		Version    string     `json:"version"`
 	}
 
+	// PeerBandwidth reports the number of bytes exchanged with a single peer
+	// over the lifetime of its connection.
+	PeerBandwidth struct {
+		NetAddress NetAddress `json:"netaddress"`
+		Download   uint64     `json:"download"`
+		Upload     uint64     `json:"upload"`
+	}
+
+	// GatewayBandwidth reports the number of bytes exchanged with peers since
+	// the Gateway was started, along with the current bandwidth limits. The
+	// limits are in bytes per second; a limit of zero means unlimited.
+	GatewayBandwidth struct {
+		Download      uint64          `json:"download"`
+		Upload        uint64          `json:"upload"`
+		DownloadLimit int64           `json:"downloadlimit"`
+		UploadLimit   int64           `json:"uploadlimit"`
+		Peers         []PeerBandwidth `json:"peers"`
+	}
+
 	// A PeerConn is the connection type used when communicating with peers during
 	// an RPC. It is identical to a net.Conn with the additional RPCAddr method.
 	// This method acts as an identifier for peers and is the address that the
@@ -171,6 +190,15 @@ type (
 		// Peers returns the addresses that the Gateway is currently connected to.
 		Peers() []Peer
 
+		// Bandwidth returns the number of bytes exchanged with peers, both in
+		// total and for each connected peer, along with the current limits.
+		Bandwidth() GatewayBandwidth
+
+		// SetBandwidthLimits sets the maximum rate, in bytes per second, at
+		// which the Gateway downloads from and uploads to peers. A limit of
+		// zero removes the corresponding limit.
+		SetBandwidthLimits(download, upload int64) error
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
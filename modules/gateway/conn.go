@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
@@ -19,6 +20,72 @@ func (pc peerConn) RPCAddr() modules.NetAddress {
 	return pc.dialbackAddr
 }
 
+// bandwidthCounter tracks the number of bytes downloaded from and uploaded to
+// peers. The fields are accessed atomically, and must remain 64-bit aligned.
+type bandwidthCounter struct {
+	download uint64
+	upload   uint64
+}
+
+// record adds the supplied byte counts to bc.
+func (bc *bandwidthCounter) record(download, upload int) {
+	if download > 0 {
+		atomic.AddUint64(&bc.download, uint64(download))
+	}
+	if upload > 0 {
+		atomic.AddUint64(&bc.upload, uint64(upload))
+	}
+}
+
+// totals returns the number of bytes downloaded and uploaded so far.
+func (bc *bandwidthCounter) totals() (download, upload uint64) {
+	return atomic.LoadUint64(&bc.download), atomic.LoadUint64(&bc.upload)
+}
+
+// meteredConn wraps a modules.PeerConn, recording the traffic that passes
+// through it against both the peer and the Gateway, and throttling it
+// according to the Gateway's bandwidth limits.
+type meteredConn struct {
+	modules.PeerConn
+	g *Gateway
+	p *peer
+}
+
+// Read implements the io.Reader interface. The read is performed first and
+// the limiter is charged afterwards, delaying the next read until the rate
+// drops below the download limit.
+func (mc meteredConn) Read(b []byte) (int, error) {
+	n, err := mc.PeerConn.Read(b)
+	mc.p.bandwidth.record(n, 0)
+	mc.g.bandwidth.record(n, 0)
+	if n > 0 && mc.g.downloadLimit.Wait(n, mc.g.threads.StopChan()) && err == nil {
+		err = errGatewayStopped
+	}
+	return n, err
+}
+
+// Write implements the io.Writer interface. Write blocks until the upload
+// limit permits len(b) bytes to be sent.
+func (mc meteredConn) Write(b []byte) (int, error) {
+	if mc.g.uploadLimit.Wait(len(b), mc.g.threads.StopChan()) {
+		return 0, errGatewayStopped
+	}
+	n, err := mc.PeerConn.Write(b)
+	mc.p.bandwidth.record(0, n)
+	mc.g.bandwidth.record(0, n)
+	return n, err
+}
+
+// meter wraps conn so that its traffic is accounted to p and limited by the
+// Gateway's bandwidth limits.
+func (g *Gateway) meter(p *peer, conn modules.PeerConn) modules.PeerConn {
+	return meteredConn{
+		PeerConn: conn,
+		g:        g,
+		p:        p,
+	}
+}
+
 // dial will dial the input address and return a connection. dial appropriately
 // handles things like clean shutdown, fast shutdown, and chooses the correct
 // communication protocol.
@@ -110,12 +110,23 @@ import (
 )
 
 var (
-	errNoPeers     = errors.New("no peers")
-	errUnreachable = errors.New("peer did not respond to ping")
+	errGatewayStopped = errors.New("gateway is shutting down")
+	errNoPeers        = errors.New("no peers")
+	errUnreachable    = errors.New("peer did not respond to ping")
 )
 
 // Gateway implements the modules.Gateway interface.
 type Gateway struct {
+	// bandwidth tracks the total traffic exchanged with all peers. It must be
+	// the first field to guarantee 64-bit alignment of its atomically
+	// accessed counters.
+	//
+	// downloadLimit and uploadLimit throttle the traffic exchanged with
+	// peers. A limit of zero means that the traffic is not throttled.
+	bandwidth     bandwidthCounter
+	downloadLimit *siasync.RateLimit
+	uploadLimit   *siasync.RateLimit
+
 	listener net.Listener
 	myAddr   modules.NetAddress
 	port     string
@@ -201,6 +212,9 @@ func New(addr string, bootstrap bool, persistDir string) (*Gateway, error) {
 		nodes: make(map[modules.NetAddress]*node),
 		peers: make(map[modules.NetAddress]*peer),
 
+		downloadLimit: siasync.NewRateLimit(0),
+		uploadLimit:   siasync.NewRateLimit(0),
+
 		persistDir: persistDir,
 	}
 
@@ -14,8 +14,9 @@ import (
 )
 
 var (
-	errPeerExists       = errors.New("already connected to this peer")
-	errPeerRejectedConn = errors.New("peer rejected connection")
+	errNegativeBandwidthLimit = errors.New("bandwidth limits cannot be negative")
+	errPeerExists             = errors.New("already connected to this peer")
+	errPeerRejectedConn       = errors.New("peer rejected connection")
 )
 
 // insufficientVersionError indicates a peer's version is insufficient.
@@ -35,6 +36,10 @@ func (s invalidVersionError) Error() string {
 }
 
 type peer struct {
+	// bandwidth must be the first field to guarantee 64-bit alignment of
+	// its atomically accessed counters.
+	bandwidth bandwidthCounter
+
 	modules.Peer
 	sess streamSession
 }
@@ -512,6 +517,39 @@ func (g *Gateway) Peers() []modules.Peer {
 	return peers
 }
 
+// Bandwidth returns the traffic exchanged with peers and the current
+// bandwidth limits.
+func (g *Gateway) Bandwidth() modules.GatewayBandwidth {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var gb modules.GatewayBandwidth
+	gb.Download, gb.Upload = g.bandwidth.totals()
+	gb.DownloadLimit = g.downloadLimit.Limit()
+	gb.UploadLimit = g.uploadLimit.Limit()
+	for _, p := range g.peers {
+		download, upload := p.bandwidth.totals()
+		gb.Peers = append(gb.Peers, modules.PeerBandwidth{
+			NetAddress: p.NetAddress,
+			Download:   download,
+			Upload:     upload,
+		})
+	}
+	return gb
+}
+
+// SetBandwidthLimits sets the download and upload limits, in bytes per
+// second, applied to traffic exchanged with peers. A limit of zero disables
+// the corresponding limit.
+func (g *Gateway) SetBandwidthLimits(download, upload int64) error {
+	if download < 0 || upload < 0 {
+		return errNegativeBandwidthLimit
+	}
+	g.downloadLimit.SetLimit(download)
+	g.uploadLimit.SetLimit(upload)
+	g.log.Printf("INFO: bandwidth limits set to %v B/s download, %v B/s upload", download, upload)
+	return nil
+}
+
 // Online returns true if the node is connected to the internet. During testing
 // we always assume that the node is online
 func (g *Gateway) Online() bool {
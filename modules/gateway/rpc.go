@@ -54,6 +54,7 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 		g.mu.Unlock()
 		return err
 	}
+	conn = g.meter(peer, conn)
 	defer conn.Close()
 
 	// write header
@@ -168,6 +169,7 @@ func (g *Gateway) threadedListenPeer(p *peer) {
 			g.log.Debugf("Peer connection with %v closed: %v\n", p.NetAddress, err)
 			break
 		}
+		conn = g.meter(p, conn)
 		// Set the default deadline on the conn.
 		err = conn.SetDeadline(time.Now().Add(rpcStdDeadline))
 		if err != nil {
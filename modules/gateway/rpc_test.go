@@ -631,3 +631,88 @@ func TestRPCRatelimit(t *testing.T) {
 		t.Error("ratelimit does not seem to be effective", expected, elapsed)
 	}
 }
+
+// TestBandwidth checks that the Gateway records the traffic exchanged with
+// peers and throttles it according to its bandwidth limits.
+func TestBandwidth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newNamedTestingGateway(t, "1")
+	defer g1.Close()
+	g2 := newNamedTestingGateway(t, "2")
+	defer g2.Close()
+
+	// "recv" reads 500-byte chunks until the caller is done, then replies.
+	g2.RegisterRPC("recv", func(conn modules.PeerConn) error {
+		var n uint64
+		if err := encoding.ReadObject(conn, &n, 8); err != nil {
+			return err
+		}
+		buf := make([]byte, 500)
+		for i := uint64(0); i < n; i++ {
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return err
+			}
+		}
+		return encoding.WriteObject(conn, "done")
+	})
+	sendChunks := func(n uint64) error {
+		return g1.RPC(g2.Address(), "recv", func(conn modules.PeerConn) error {
+			if err := encoding.WriteObject(conn, n); err != nil {
+				return err
+			}
+			for i := uint64(0); i < n; i++ {
+				if _, err := conn.Write(make([]byte, 500)); err != nil {
+					return err
+				}
+			}
+			var resp string
+			return encoding.ReadObject(conn, &resp, 16)
+		})
+	}
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sendChunks(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the aggregate and per-peer counters should reflect the RPC.
+	gb := g1.Bandwidth()
+	if gb.Upload < 1000 || gb.Download == 0 {
+		t.Fatalf("unexpected totals: %v up, %v down", gb.Upload, gb.Download)
+	}
+	if len(gb.Peers) != 1 || gb.Peers[0].NetAddress != g2.Address() {
+		t.Fatal("expected a single peer entry for g2:", gb.Peers)
+	}
+	if gb.Peers[0].Upload < 1000 || gb.Peers[0].Download == 0 {
+		t.Fatal("peer totals were not recorded:", gb.Peers[0])
+	}
+	if gb2 := g2.Bandwidth(); gb2.Download < 1000 {
+		t.Fatal("g2 did not record the download:", gb2.Download)
+	}
+
+	// Negative limits should be rejected.
+	if err := g1.SetBandwidthLimits(-1, 0); err != errNegativeBandwidthLimit {
+		t.Fatal("expected errNegativeBandwidthLimit, got", err)
+	}
+
+	// With an upload limit of 1000 B/s, sending 3000 bytes should take at
+	// least a second once the initial burst has been spent.
+	if err := g1.SetBandwidthLimits(0, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if gb := g1.Bandwidth(); gb.UploadLimit != 1000 || gb.DownloadLimit != 0 {
+		t.Fatal("limits were not set:", gb.DownloadLimit, gb.UploadLimit)
+	}
+	start := time.Now()
+	if err := sendChunks(6); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatal("upload was not throttled:", elapsed)
+	}
+}
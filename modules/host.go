@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"time"
+
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -44,6 +46,21 @@ var (
 	// received more than workingThreshold settings calls over the duration of
 	// workingStatusFrequency.
 	HostWorkingStatusWorking = HostWorkingStatus("working")
+
+	// HostRetainNone is the data retention policy under which the host
+	// deletes the data of a contract as soon as the contract ends.
+	HostRetainNone = HostDataRetention("none")
+
+	// HostRetainBlocks is the data retention policy under which the host
+	// keeps the data of a contract for DataRetentionBlocks blocks after the
+	// contract ends, so that a renter renewing late does not need to upload
+	// the data again.
+	HostRetainBlocks = HostDataRetention("blocks")
+
+	// HostRetainUntilFull is the data retention policy under which the host
+	// keeps the data of a contract after it ends until the storage is needed
+	// for new uploads.
+	HostRetainUntilFull = HostDataRetention("untilfull")
 )
 
 type (
@@ -70,6 +87,11 @@ type (
 		StorageRevenue          types.Currency `json:"storagerevenue"`
 		TransactionFeeExpenses  types.Currency `json:"transactionfeeexpenses"`
 
+		// CollateralReserve is the amount of siacoins held in the host's
+		// collateral reserve. The reserve outputs are locked in the wallet
+		// and only spent on collateral.
+		CollateralReserve types.Currency `json:"collateralreserve"`
+
 		// Bandwidth financial metrics.
 		DownloadBandwidthRevenue          types.Currency `json:"downloadbandwidthrevenue"`
 		PotentialDownloadBandwidthRevenue types.Currency `json:"potentialdownloadbandwidthrevenue"`
@@ -86,16 +108,111 @@ type (
 		NetAddress           NetAddress        `json:"netaddress"`
 		WindowSize           types.BlockHeight `json:"windowsize"`
 
+		// ProofWindowBuffer is the number of blocks before the end of the
+		// proof window by which the host aims to have its storage proof
+		// confirmed. Proofs that have not been confirmed, or that have been
+		// reverted by a reorg, are resubmitted until the buffer is reached.
+		ProofWindowBuffer types.BlockHeight `json:"proofwindowbuffer"`
+
+		// MaxOpenConns is the maximum number of incoming connections that
+		// the host handles at once. Connections beyond the limit are closed
+		// as soon as they are accepted.
+		MaxOpenConns uint64 `json:"maxopenconns"`
+
+		// ConnReadTimeout and ConnWriteTimeout limit how long a single read
+		// from or write to a renter may take. ConnIdleTimeout limits how long
+		// the host waits for a renter to begin an RPC, or the next iteration
+		// of an RPC, before closing the connection.
+		ConnIdleTimeout  time.Duration `json:"connidletimeout"`
+		ConnReadTimeout  time.Duration `json:"connreadtimeout"`
+		ConnWriteTimeout time.Duration `json:"connwritetimeout"`
+
+		// MaxRenterConns is the maximum number of connections that a single
+		// renter may have open at once. Renters are identified by the public
+		// key of their contracts, or by their IP address until an RPC has
+		// proven that they own a contract.
+		MaxRenterConns uint64 `json:"maxrenterconns"`
+
+		// MaxBandwidth is the total rate in bytes per second at which the
+		// host transfers data with renters. It is divided evenly among the
+		// renters that have connections open, so that one renter cannot
+		// starve the others. Zero means that bandwidth is not limited.
+		MaxBandwidth uint64 `json:"maxbandwidth"`
+
+		// DataRetention is the policy for the data of contracts that have
+		// ended, and DataRetentionBlocks is the number of blocks that the
+		// data is kept for under HostRetainBlocks. Whatever the policy,
+		// retained data is deleted when its storage is needed for new
+		// uploads.
+		DataRetention       HostDataRetention `json:"dataretention"`
+		DataRetentionBlocks types.BlockHeight `json:"dataretentionblocks"`
+
+		// MinAnnounceInterval is the minimum amount of time between two
+		// automatic announcements, which the host makes when its external
+		// address changes. MaxAnnounceFees caps the fees that automatic
+		// announcements spend within each AnnounceFeePeriod; zero means that
+		// the fees are not capped. Announcements requested by the user are
+		// not limited, but their fees count towards the cap.
+		MinAnnounceInterval time.Duration  `json:"minannounceinterval"`
+		MaxAnnounceFees     types.Currency `json:"maxannouncefees"`
+		AnnounceFeePeriod   time.Duration  `json:"announcefeeperiod"`
+
 		Collateral       types.Currency `json:"collateral"`
 		CollateralBudget types.Currency `json:"collateralbudget"`
 		MaxCollateral    types.Currency `json:"maxcollateral"`
 
+		// When the collateral reserve drops below CollateralReserveThreshold,
+		// the host moves funds from the wallet into the reserve until it
+		// reaches CollateralReserveTarget. A zero target disables the reserve.
+		CollateralReserveTarget    types.Currency `json:"collateralreservetarget"`
+		CollateralReserveThreshold types.Currency `json:"collateralreservethreshold"`
+
 		MinContractPrice          types.Currency `json:"mincontractprice"`
+		MinContractValue          types.Currency `json:"mincontractvalue"`
 		MinDownloadBandwidthPrice types.Currency `json:"mindownloadbandwidthprice"`
 		MinStoragePrice           types.Currency `json:"minstorageprice"`
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
 	}
 
+	// ContractTerms describes a file contract that a renter has asked the host
+	// to form or renew. It is passed to the host's ContractFilter after the
+	// contract has passed the host's own checks, so the terms are known to be
+	// consistent with the host's settings.
+	ContractTerms struct {
+		// RenterPublicKey is the key the renter will use to sign revisions
+		// of the contract. It identifies the renter across contracts.
+		RenterPublicKey types.SiaPublicKey
+
+		// Renewal is true if the contract renews an existing contract held
+		// by the host.
+		Renewal bool
+
+		// CurrentHeight is the host's block height at the time of the
+		// request. The duration of the contract is WindowEnd - CurrentHeight.
+		CurrentHeight types.BlockHeight
+
+		// WindowStart and WindowEnd bound the proof window of the contract.
+		WindowStart types.BlockHeight
+		WindowEnd   types.BlockHeight
+
+		// FileSize is the amount of data the contract starts with. It is
+		// zero for new contracts, and the size of the existing data for
+		// renewals.
+		FileSize uint64
+
+		// Payout is the total value of the contract. RenterPayout is the
+		// portion that returns to the renter if the host submits a valid
+		// proof, and HostPayout is the portion paid to the host, including
+		// the host's collateral.
+		Payout       types.Currency
+		RenterPayout types.Currency
+		HostPayout   types.Currency
+	}
+
+	// A ContractFilter decides whether the host accepts a contract with the
+	// given terms. Returning false rejects the contract.
+	ContractFilter func(ContractTerms) bool
+
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
 	// has been made to the host.
 	HostNetworkMetrics struct {
@@ -108,6 +225,44 @@ type (
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
 	}
 
+	// HostAnnouncementMetrics reports the host's announcements.
+	// LastAnnouncement is the time of the most recent announcement, and
+	// AnnouncementFees is the total of the fees that announcements have paid.
+	// PeriodAnnouncementFees is the part of those fees that was paid in the
+	// current announcement fee period, which counts towards MaxAnnounceFees.
+	HostAnnouncementMetrics struct {
+		LastAnnouncement       time.Time      `json:"lastannouncement"`
+		AnnouncementFees       types.Currency `json:"announcementfees"`
+		PeriodAnnouncementFees types.Currency `json:"periodannouncementfees"`
+	}
+
+	// HostUtilizationMetrics reports how much of the host's capacity is in
+	// use and how often the host accepts the contracts renters propose.
+	// StorageUtilization is the percentage of the host's storage that holds
+	// data. The acceptance counts cover the most recent contract formations
+	// and renewals, and RejectionReasons breaks the rejections down by
+	// reason. AverageContractValue is the average potential revenue of the
+	// host's active contracts.
+	HostUtilizationMetrics struct {
+		StorageUtilization   float64           `json:"storageutilization"`
+		ContractsAccepted    uint64            `json:"contractsaccepted"`
+		ContractsRejected    uint64            `json:"contractsrejected"`
+		AcceptRate           float64           `json:"acceptrate"`
+		RejectionReasons     map[string]uint64 `json:"rejectionreasons"`
+		AverageContractValue types.Currency    `json:"averagecontractvalue"`
+	}
+
+	// RPCError describes an RPC call to the host that failed. Type is one of
+	// "communication", "connection", "consensus", "internal", or "other",
+	// matching the categories used in the host's log.
+	RPCError struct {
+		Time          time.Time  `json:"time"`
+		RPC           string     `json:"rpc"`
+		RenterAddress NetAddress `json:"renteraddress"`
+		Type          string     `json:"type"`
+		Error         string     `json:"error"`
+	}
+
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
@@ -125,6 +280,11 @@ type (
 	// "checking", "working", or "not working.
 	HostWorkingStatus string
 
+	// HostDataRetention is the policy that decides how long the host keeps
+	// the data of a contract after the contract ends. Can be one of "none",
+	// "blocks", or "untilfull".
+	HostDataRetention string
+
 	// HostConnectabilityStatus reports the connectability state of a host. Can be
 	// one of "checking", "connectable", or "not connectable"
 	HostConnectabilityStatus string
@@ -133,12 +293,41 @@ type (
 	// things such as announcements, settings, and implementing all of the RPCs
 	// of the host protocol.
 	Host interface {
-		// Announce submits a host announcement to the blockchain.
+		// Announce submits a host announcement to the blockchain, after
+		// checking that the host is reachable at the announced address.
 		Announce() error
 
-		// AnnounceAddress submits an announcement using the given address.
+		// AnnounceAddress submits an announcement using the given address,
+		// after checking that the host is reachable at that address.
 		AnnounceAddress(NetAddress) error
 
+		// AnnouncementMetrics returns the time and cost of the host's
+		// announcements.
+		AnnouncementMetrics() HostAnnouncementMetrics
+
+		// ForceAnnounce submits a host announcement to the blockchain
+		// without checking that the host is reachable at the announced
+		// address.
+		ForceAnnounce() error
+
+		// ForceAnnounceAddress submits an announcement using the given
+		// address without checking that the host is reachable at it.
+		ForceAnnounceAddress(NetAddress) error
+
+		// Decommission permanently retires the host. The host stops
+		// accepting new contracts and uploads, and tells renters that it
+		// serves downloads until the deadline, so that they can move their
+		// data to other hosts.
+		Decommission(deadline types.BlockHeight) error
+
+		// DecommissionStatus returns the decommission status of the host.
+		DecommissionStatus() HostDecommission
+
+		// Earnings returns the revenue of the storage obligations that
+		// succeeded after the given block height, or the lifetime revenue
+		// of the host if the height is zero.
+		Earnings(since types.BlockHeight) (types.Currency, error)
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -150,6 +339,9 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// Maintenance returns whether the host is in maintenance mode.
+		Maintenance() bool
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
@@ -157,9 +349,28 @@ type (
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// UtilizationMetrics returns the storage utilization and contract
+		// acceptance rate of the host.
+		UtilizationMetrics() HostUtilizationMetrics
+
+		// RecentErrors returns the most recent RPC calls to the host that
+		// failed, newest first.
+		RecentErrors() []RPCError
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetMaintenance enables or disables maintenance mode. In maintenance
+		// mode the host rejects new contracts and uploads, but continues to
+		// serve downloads and submit storage proofs. The mode persists across
+		// restarts.
+		SetMaintenance(bool) error
+
+		// SetContractFilter sets a filter that is consulted before the host
+		// forms or renews a contract. Contracts for which the filter returns
+		// false are rejected. A nil filter accepts all contracts.
+		SetContractFilter(ContractFilter)
+
 		// StorageObligations returns the set of storage obligations held by
 		// the host.
 		StorageObligations() []StorageObligation
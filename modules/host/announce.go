@@ -2,9 +2,14 @@ package host
 
 import (
 	"errors"
+	"net"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 var (
@@ -15,8 +20,54 @@ var (
 	// errUnknownAddress is returned if the host is unable to determine a
 	// public address for itself to use in the announcement.
 	errUnknownAddress = errors.New("host cannot announce, does not seem to have a valid address.")
+
+	// errAnnounceTooSoon is returned if an automatic announcement is
+	// attempted less than MinAnnounceInterval after the previous announcement.
+	errAnnounceTooSoon = errors.New("host announced too recently to announce automatically again")
+
+	// errAnnounceFeeCap is returned if an automatic announcement would bring
+	// the fees spent on announcements in the current period above
+	// MaxAnnounceFees.
+	errAnnounceFeeCap = errors.New("automatic announcement would exceed the announcement fee cap")
+
+	// errUnreachableAddress is returned if the host could not reach itself at
+	// the address it was asked to announce.
+	errUnreachableAddress = errors.New("host is not reachable at the announced address, check that the external port is forwarded to the host")
 )
 
+// managedCheckReachable verifies that the host can be reached at addr by
+// requesting its settings there, the same way that renters scan hosts. The
+// settings must be signed by the host's key, which ensures that addr leads to
+// this host rather than to some other service. When the host is behind NAT,
+// addr is the external endpoint, which may have a different port than the
+// one the host is listening on.
+func (h *Host) managedCheckReachable(addr modules.NetAddress) error {
+	h.mu.RLock()
+	var pubKey crypto.PublicKey
+	copy(pubKey[:], h.publicKey.Key)
+	h.mu.RUnlock()
+
+	dialer := &net.Dialer{
+		Cancel:  h.tg.StopChan(),
+		Timeout: connectabilityCheckTimeout,
+	}
+	conn, err := dialer.Dial("tcp", string(addr))
+	if err != nil {
+		return build.ComposeErrors(errUnreachableAddress, err)
+	}
+	defer conn.Close()
+	err = encoding.WriteObject(conn, modules.RPCSettings)
+	if err != nil {
+		return build.ComposeErrors(errUnreachableAddress, err)
+	}
+	var settings modules.HostExternalSettings
+	err = crypto.ReadSignedObject(conn, &settings, modules.NegotiateMaxHostExternalSettingsLen, pubKey)
+	if err != nil {
+		return build.ComposeErrors(errUnreachableAddress, err)
+	}
+	return nil
+}
+
 // managedAnnounce creates an announcement transaction and submits it to the network.
 func (h *Host) managedAnnounce(addr modules.NetAddress) error {
 	// The wallet needs to be unlocked to add fees to the transaction, and the
@@ -44,8 +95,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) error {
 
 	// Create a transaction, with a fee, that contains the full announcement.
 	txnBuilder := h.wallet.StartTransaction()
-	_, fee := h.tpool.FeeEstimation()
-	fee = fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+	fee := h.announcementFee()
 	err = txnBuilder.FundSiacoins(fee)
 	if err != nil {
 		txnBuilder.Drop()
@@ -68,13 +118,87 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) error {
 
 	h.mu.Lock()
 	h.announced = true
+	h.recordAnnouncement(time.Now(), fee)
+	err = h.saveSync()
 	h.mu.Unlock()
+	if err != nil {
+		h.log.Println("Could not save the host after announcing:", err)
+	}
 	h.log.Printf("INFO: Successfully announced as %v", addr)
 	return nil
 }
 
-// Announce creates a host announcement transaction.
+// announcementFee returns the miner fee that the host pays for an
+// announcement.
+func (h *Host) announcementFee() types.Currency {
+	_, fee := h.tpool.FeeEstimation()
+	return fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+}
+
+// periodAnnouncementFees returns the fees that announcements have spent in the
+// announcement fee period that contains now.
+func (h *Host) periodAnnouncementFees(now time.Time) types.Currency {
+	if now.Sub(h.announceFeePeriodStart) >= h.settings.AnnounceFeePeriod {
+		return types.ZeroCurrency
+	}
+	return h.announcementMetrics.PeriodAnnouncementFees
+}
+
+// recordAnnouncement records an announcement made at time now that paid fee,
+// starting a new announcement fee period if the current one has ended.
+func (h *Host) recordAnnouncement(now time.Time, fee types.Currency) {
+	if now.Sub(h.announceFeePeriodStart) >= h.settings.AnnounceFeePeriod {
+		h.announceFeePeriodStart = now
+		h.announcementMetrics.PeriodAnnouncementFees = types.ZeroCurrency
+	}
+	h.announcementMetrics.LastAnnouncement = now
+	h.announcementMetrics.AnnouncementFees = h.announcementMetrics.AnnouncementFees.Add(fee)
+	h.announcementMetrics.PeriodAnnouncementFees = h.announcementMetrics.PeriodAnnouncementFees.Add(fee)
+	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Add(fee)
+}
+
+// managedAutoAnnounce announces addr on behalf of the host itself, such as
+// after its external address has changed. Unlike announcements requested by
+// the user, automatic announcements are made at most once per
+// MinAnnounceInterval, and not at all once they would bring the fees spent on
+// announcements in the current period above MaxAnnounceFees. This keeps a
+// host whose address flaps from draining its wallet.
+func (h *Host) managedAutoAnnounce(addr modules.NetAddress) error {
+	now := time.Now()
+	fee := h.announcementFee()
+	h.mu.RLock()
+	last := h.announcementMetrics.LastAnnouncement
+	interval := h.settings.MinAnnounceInterval
+	maxFees := h.settings.MaxAnnounceFees
+	spent := h.periodAnnouncementFees(now)
+	h.mu.RUnlock()
+
+	if !last.IsZero() && now.Sub(last) < interval {
+		return errAnnounceTooSoon
+	}
+	if !maxFees.IsZero() && spent.Add(fee).Cmp(maxFees) > 0 {
+		return errAnnounceFeeCap
+	}
+	return h.managedAnnounce(addr)
+}
+
+// Announce creates a host announcement transaction. The host must be
+// reachable at the announced address.
 func (h *Host) Announce() error {
+	return h.managedAnnounceSelf(true)
+}
+
+// ForceAnnounce is like Announce, but skips checking that the host is
+// reachable at the announced address. This is useful for hosts behind routers
+// that cannot connect to their own external address.
+func (h *Host) ForceAnnounce() error {
+	return h.managedAnnounceSelf(false)
+}
+
+// managedAnnounceSelf announces the host's configured or automatically
+// discovered address, optionally checking that the host is reachable at the
+// address first.
+func (h *Host) managedAnnounceSelf(checkReachable bool) error {
 	err := h.tg.Add()
 	if err != nil {
 		return err
@@ -109,15 +233,37 @@ func (h *Host) Announce() error {
 	if annAddr.IsLocal() && build.Release != "testing" {
 		return errors.New("announcement requested with local net address")
 	}
+	if checkReachable {
+		err = h.managedCheckReachable(annAddr)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Address has cleared inspection, perform the announcement.
 	return h.managedAnnounce(annAddr)
 }
 
 // AnnounceAddress submits a host announcement to the blockchain to announce a
-// specific address. If there is no error, the host's address will be updated
-// to the supplied address.
+// specific address. The address may use a different port than the one the
+// host is listening on, as is the case for hosts behind a router that forwards
+// an external port to the host. The host must be reachable at the address. If
+// there is no error, the host's address will be updated to the supplied
+// address.
 func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
+	return h.managedAnnounceAddress(addr, true)
+}
+
+// ForceAnnounceAddress is like AnnounceAddress, but skips checking that the
+// host is reachable at the address. This is useful for hosts behind routers
+// that cannot connect to their own external address.
+func (h *Host) ForceAnnounceAddress(addr modules.NetAddress) error {
+	return h.managedAnnounceAddress(addr, false)
+}
+
+// managedAnnounceAddress announces addr and updates the host's net address,
+// optionally checking that the host is reachable at addr first.
+func (h *Host) managedAnnounceAddress(addr modules.NetAddress, checkReachable bool) error {
 	err := h.tg.Add()
 	if err != nil {
 		return err
@@ -129,9 +275,15 @@ func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
 	if err != nil {
 		return build.ExtendErr("announcement requested with bad net address", err)
 	}
-	if addr.IsLocal() {
+	if addr.IsLocal() && build.Release != "testing" {
 		return errors.New("announcement requested with local net address")
 	}
+	if checkReachable {
+		err = h.managedCheckReachable(addr)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Attempt the actual announcement.
 	err = h.managedAnnounce(addr)
@@ -146,3 +298,13 @@ func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
 	h.mu.Unlock()
 	return nil
 }
+
+// AnnouncementMetrics returns the time of the host's most recent announcement
+// and the fees that its announcements have paid.
+func (h *Host) AnnouncementMetrics() modules.HostAnnouncementMetrics {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	am := h.announcementMetrics
+	am.PeriodAnnouncementFees = h.periodAnnouncementFees(time.Now())
+	return am
+}
@@ -2,6 +2,8 @@ package host
 
 import (
 	"bytes"
+	"io"
+	"net"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
@@ -52,6 +54,34 @@ func newAnnouncementFinder(cs modules.ConsensusSet) (*announcementFinder, error)
 	return af, nil
 }
 
+// newPortForward listens on a new local port and forwards every connection to
+// target, mimicking a router that forwards an external port to the host.
+func newPortForward(target string) (net.Listener, error) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				hostConn, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer hostConn.Close()
+				go io.Copy(hostConn, conn)
+				io.Copy(conn, hostConn)
+			}()
+		}
+	}()
+	return l, nil
+}
+
 // TestHostAnnounce checks that the host announce function is operating
 // correctly.
 func TestHostAnnounce(t *testing.T) {
@@ -115,9 +145,14 @@ func TestHostAnnounceAddress(t *testing.T) {
 	}
 	defer af.Close()
 
-	// Create an announcement, then use the address finding module to scan the
-	// blockchain for the host's address.
-	addr := modules.NetAddress("foo.com:1234")
+	// Forward another port to the host, then announce that port and use the
+	// address finding module to scan the blockchain for the host's address.
+	pf, err := newPortForward(ht.host.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pf.Close()
+	addr := modules.NetAddress(pf.Addr().String())
 	err = ht.host.AnnounceAddress(addr)
 	if err != nil {
 		t.Fatal(err)
@@ -137,6 +172,66 @@ func TestHostAnnounceAddress(t *testing.T) {
 	}
 }
 
+// TestHostAnnounceUnreachable checks that the host refuses to announce an
+// address that does not lead back to the host, unless forced to.
+func TestHostAnnounceUnreachable(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Listen on an address that accepts connections but does not speak the
+	// host protocol.
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := modules.NetAddress(l.Addr().String())
+
+	err = ht.host.AnnounceAddress(addr)
+	if err == nil {
+		t.Fatal("announcing an unreachable address should fail")
+	}
+	if ht.host.InternalSettings().NetAddress == addr {
+		t.Fatal("net address should not be updated after a failed announcement")
+	}
+
+	// Forcing the announcement skips the reachability check.
+	err = ht.host.ForceAnnounceAddress(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.InternalSettings().NetAddress != addr {
+		t.Fatal("net address was not updated after a forced announcement")
+	}
+
+	// Announcing the configured address is checked in the same way, and can
+	// be forced in the same way.
+	err = ht.host.Announce()
+	if err == nil {
+		t.Fatal("announcing an unreachable configured address should fail")
+	}
+	err = ht.host.ForceAnnounce()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestHostAnnounceCheckUnlockHash verifies that the host's unlock hash is
 // checked when an announcement is performed.
 func TestHostAnnounceCheckUnlockHash(t *testing.T) {
@@ -193,3 +288,65 @@ func TestHostAnnounceCheckUnlockHash(t *testing.T) {
 		t.Fatal("host unlock has did not exist in wallet")
 	}
 }
+
+// TestHostAutoAnnounceLimits checks that automatic announcements are limited
+// by the announcement interval and fee cap, and that the host reports the
+// time and cost of its announcements.
+func TestHostAutoAnnounceLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	before := ht.host.AnnouncementMetrics()
+	fee := ht.host.announcementFee()
+	if err := ht.host.Announce(); err != nil {
+		t.Fatal(err)
+	}
+	am := ht.host.AnnouncementMetrics()
+	if !am.LastAnnouncement.After(before.LastAnnouncement) {
+		t.Fatal("announcement time was not recorded:", am.LastAnnouncement)
+	}
+	if !am.AnnouncementFees.Equals(before.AnnouncementFees.Add(fee)) {
+		t.Fatal("announcement fee was not recorded:", am.AnnouncementFees)
+	}
+	if am.PeriodAnnouncementFees.IsZero() {
+		t.Fatal("announcement fee was not counted towards the period")
+	}
+
+	// An automatic announcement right after a manual one is held back.
+	addr := ht.host.autoAddress
+	if err := ht.host.managedAutoAnnounce(addr); err != errAnnounceTooSoon {
+		t.Fatal("expected errAnnounceTooSoon, got", err)
+	}
+
+	// Without an interval, the fee cap holds back announcements that would
+	// exceed it.
+	settings := ht.host.InternalSettings()
+	settings.MinAnnounceInterval = 0
+	settings.MaxAnnounceFees = am.PeriodAnnouncementFees
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedAutoAnnounce(addr); err != errAnnounceFeeCap {
+		t.Fatal("expected errAnnounceFeeCap, got", err)
+	}
+	settings.MaxAnnounceFees = am.PeriodAnnouncementFees.Add(fee.Mul64(2))
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedAutoAnnounce(addr); err != nil {
+		t.Fatal(err)
+	}
+
+	// The fee period must be positive.
+	settings.AnnounceFeePeriod = 0
+	if err := ht.host.SetInternalSettings(settings); err != errBadAnnounceSettings {
+		t.Fatal("expected errBadAnnounceSettings, got", err)
+	}
+}
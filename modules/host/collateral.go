@@ -0,0 +1,230 @@
+package host
+
+// collateral.go manages the host's collateral reserve. The reserve is a wallet
+// address dedicated to the host that is kept funded up to a configured target,
+// so that the host has confirmed funds available to put up as collateral. When
+// the reserve drops below the configured threshold, the host moves funds from
+// the rest of the wallet into the reserve.
+//
+// The outputs of the reserve are locked in the wallet, so that the wallet does
+// not use them to fund other transactions. They are only released while the
+// host funds the collateral of a file contract that the rest of the wallet
+// cannot cover. Wallet locks are only held in memory, so the host keeps track
+// of the reserve outputs and locks them again when it starts.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errBadCollateralReserve is returned if the collateral reserve threshold
+	// is larger than the target, in which case the reserve could never be
+	// topped up to a level above the threshold.
+	errBadCollateralReserve = errors.New("collateral reserve threshold must not exceed the collateral reserve target")
+)
+
+// collateralReserveOutputIDs returns the ids of the outputs in the collateral
+// reserve.
+func (h *Host) collateralReserveOutputIDs() []types.SiacoinOutputID {
+	ids := make([]types.SiacoinOutputID, 0, len(h.collateralReserveOutputs))
+	for id := range h.collateralReserveOutputs {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// lockCollateralReserveOutput locks a reserve output in the wallet. An output
+// that is already locked, for example an unconfirmed top-up, stays locked.
+func (h *Host) lockCollateralReserveOutput(id types.SiacoinOutputID) {
+	_ = h.wallet.LockOutput(types.OutputID(id))
+}
+
+// loadCollateralReserve restores the reserve outputs loaded from the persist
+// file and locks them in the wallet, which does not persist its locks.
+func (h *Host) loadCollateralReserve(ids []types.SiacoinOutputID) {
+	h.collateralReserveOutputs = make(map[types.SiacoinOutputID]struct{})
+	for _, id := range ids {
+		h.collateralReserveOutputs[id] = struct{}{}
+		h.lockCollateralReserveOutput(id)
+	}
+}
+
+// resetCollateralReserve empties the collateral reserve ahead of a rescan,
+// releasing the locks on its outputs. Outputs that still exist are added and
+// locked again as the rescan applies them.
+func (h *Host) resetCollateralReserve() {
+	for id := range h.collateralReserveOutputs {
+		_ = h.wallet.UnlockOutput(types.OutputID(id))
+	}
+	h.collateralReserveOutputs = make(map[types.SiacoinOutputID]struct{})
+	h.financialMetrics.CollateralReserve = types.ZeroCurrency
+}
+
+// updateCollateralReserve updates the level of the collateral reserve using
+// the siacoin output diffs of a consensus change. New outputs at the reserve
+// address are locked in the wallet. Outputs that are spent, for example to
+// fund the collateral of a file contract, are removed from the reserve and
+// released.
+func (h *Host) updateCollateralReserve(cc modules.ConsensusChange) {
+	if h.collateralReserveAddress == (types.UnlockHash{}) {
+		return
+	}
+	reserve := h.financialMetrics.CollateralReserve
+	for _, diff := range cc.SiacoinOutputDiffs {
+		if diff.SiacoinOutput.UnlockHash != h.collateralReserveAddress {
+			continue
+		}
+		if diff.Direction == modules.DiffApply {
+			reserve = reserve.Add(diff.SiacoinOutput.Value)
+			h.collateralReserveOutputs[diff.ID] = struct{}{}
+			h.lockCollateralReserveOutput(diff.ID)
+			h.collateralTopUpPending = false
+			continue
+		}
+		if reserve.Cmp(diff.SiacoinOutput.Value) >= 0 {
+			reserve = reserve.Sub(diff.SiacoinOutput.Value)
+		} else {
+			reserve = types.ZeroCurrency
+		}
+		delete(h.collateralReserveOutputs, diff.ID)
+		_ = h.wallet.UnlockOutput(types.OutputID(diff.ID))
+	}
+	h.financialMetrics.CollateralReserve = reserve
+}
+
+// managedFundCollateral adds 'amount' siacoins of collateral to the
+// transaction of builder. The rest of the wallet is used first. If it cannot
+// cover the collateral, the reserve outputs are released for the duration of
+// the call and locked again afterwards; reserve outputs that were spent leave
+// the reserve once the spend is confirmed.
+func (h *Host) managedFundCollateral(builder modules.TransactionBuilder, amount types.Currency) error {
+	err := builder.FundSiacoins(amount)
+	if err == nil {
+		return nil
+	}
+
+	// The host lock is held while the reserve is released, so that consensus
+	// changes cannot add or remove reserve outputs in the meantime.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.collateralReserveOutputs) == 0 {
+		return err
+	}
+	for id := range h.collateralReserveOutputs {
+		_ = h.wallet.UnlockOutput(types.OutputID(id))
+	}
+	err = builder.FundSiacoins(amount)
+	for id := range h.collateralReserveOutputs {
+		h.lockCollateralReserveOutput(id)
+	}
+	return err
+}
+
+// collateralTopUpAmount returns the amount of siacoins that should be moved
+// into the collateral reserve. Zero is returned if the reserve is disabled,
+// has not dropped below the threshold, or if a previous top-up is still
+// waiting to be confirmed.
+func (h *Host) collateralTopUpAmount() types.Currency {
+	target := h.settings.CollateralReserveTarget
+	if target.IsZero() || h.financialMetrics.CollateralReserve.Cmp(h.settings.CollateralReserveThreshold) >= 0 {
+		return types.ZeroCurrency
+	}
+	if h.collateralTopUpPending && h.blockHeight < h.collateralTopUpHeight+collateralTopUpTimeout {
+		return types.ZeroCurrency
+	}
+	if h.financialMetrics.CollateralReserve.Cmp(target) >= 0 {
+		return types.ZeroCurrency
+	}
+	return target.Sub(h.financialMetrics.CollateralReserve)
+}
+
+// threadedTopUpCollateralReserve moves 'amount' siacoins from the wallet into
+// the collateral reserve.
+func (h *Host) threadedTopUpCollateralReserve(amount types.Currency) {
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	err = h.managedTopUpCollateralReserve(amount)
+	if err != nil {
+		h.log.Println("Unable to top up the collateral reserve:", err)
+		h.mu.Lock()
+		h.collateralTopUpPending = false
+		h.mu.Unlock()
+		return
+	}
+	h.log.Println("Submitted a collateral reserve top-up of", amount.HumanString())
+}
+
+// managedTopUpCollateralReserve builds, signs and submits a transaction that
+// sends 'amount' siacoins from the wallet to the collateral reserve address,
+// creating the address if the host does not have one yet.
+func (h *Host) managedTopUpCollateralReserve(amount types.Currency) error {
+	if !h.wallet.Unlocked() {
+		return modules.ErrLockedWallet
+	}
+	h.mu.RLock()
+	addr := h.collateralReserveAddress
+	h.mu.RUnlock()
+	if addr == (types.UnlockHash{}) {
+		uc, err := h.wallet.NextAddress()
+		if err != nil {
+			return err
+		}
+		addr = uc.UnlockHash()
+		h.mu.Lock()
+		h.collateralReserveAddress = addr
+		err = h.saveSync()
+		h.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	fee := feeRecommendation.Mul64(collateralTopUpTxnSize)
+	builder := h.wallet.StartTransaction()
+	err := builder.FundSiacoins(amount.Add(fee))
+	if err != nil {
+		builder.Drop()
+		return err
+	}
+	builder.AddMinerFee(fee)
+	builder.AddSiacoinOutput(types.SiacoinOutput{
+		Value:      amount,
+		UnlockHash: addr,
+	})
+	txnSet, err := builder.Sign(true)
+	if err != nil {
+		builder.Drop()
+		return err
+	}
+	// Lock the new reserve output right away, so that the wallet does not
+	// spend it while it is unconfirmed.
+	txn := txnSet[len(txnSet)-1]
+	for i, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash == addr {
+			h.lockCollateralReserveOutput(txn.SiacoinOutputID(uint64(i)))
+		}
+	}
+	err = h.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		for i, sco := range txn.SiacoinOutputs {
+			if sco.UnlockHash == addr {
+				_ = h.wallet.UnlockOutput(types.OutputID(txn.SiacoinOutputID(uint64(i))))
+			}
+		}
+		builder.Drop()
+		return err
+	}
+
+	h.mu.Lock()
+	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Add(fee)
+	h.mu.Unlock()
+	return nil
+}
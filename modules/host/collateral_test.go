@@ -0,0 +1,125 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// lockWallet is a wallet that records the outputs locked through the
+// output-lock API.
+type lockWallet struct {
+	modules.Wallet
+	locked map[types.OutputID]struct{}
+}
+
+// LockOutput locks an output.
+func (w *lockWallet) LockOutput(id types.OutputID) error {
+	w.locked[id] = struct{}{}
+	return nil
+}
+
+// UnlockOutput unlocks an output.
+func (w *lockWallet) UnlockOutput(id types.OutputID) error {
+	delete(w.locked, id)
+	return nil
+}
+
+// TestCollateralReserve checks that the collateral reserve follows the outputs
+// at the reserve address, that the reserve outputs are locked in the wallet
+// while they are part of the reserve, and that a top-up is only requested
+// when the reserve has dropped below the threshold and no top-up is pending.
+func TestCollateralReserve(t *testing.T) {
+	w := &lockWallet{locked: make(map[types.OutputID]struct{})}
+	h := &Host{
+		wallet:                   w,
+		collateralReserveAddress: types.UnlockHash{1},
+		collateralReserveOutputs: make(map[types.SiacoinOutputID]struct{}),
+		settings: modules.HostInternalSettings{
+			CollateralReserveTarget:    types.NewCurrency64(100),
+			CollateralReserveThreshold: types.NewCurrency64(40),
+		},
+	}
+	diff := func(dir modules.DiffDirection, id byte, uh types.UnlockHash, value uint64) modules.SiacoinOutputDiff {
+		return modules.SiacoinOutputDiff{
+			Direction:     dir,
+			ID:            types.SiacoinOutputID{id},
+			SiacoinOutput: types.SiacoinOutput{Value: types.NewCurrency64(value), UnlockHash: uh},
+		}
+	}
+	isLocked := func(id byte) bool {
+		_, locked := w.locked[types.OutputID{id}]
+		return locked
+	}
+
+	// An empty reserve should be topped up to the target.
+	if amount := h.collateralTopUpAmount(); !amount.Equals64(100) {
+		t.Fatal("expected a top-up of 100, got", amount)
+	}
+
+	// While a top-up is pending, no further top-ups are requested until the
+	// timeout expires.
+	h.collateralTopUpPending = true
+	if amount := h.collateralTopUpAmount(); !amount.IsZero() {
+		t.Fatal("top-up requested while another is pending:", amount)
+	}
+	h.blockHeight = collateralTopUpTimeout
+	if amount := h.collateralTopUpAmount(); !amount.Equals64(100) {
+		t.Fatal("expected a top-up of 100 after the timeout, got", amount)
+	}
+
+	// Confirming the top-up fills the reserve and clears the pending flag.
+	// Outputs to other addresses are ignored.
+	h.updateCollateralReserve(modules.ConsensusChange{
+		SiacoinOutputDiffs: []modules.SiacoinOutputDiff{
+			diff(modules.DiffApply, 1, types.UnlockHash{1}, 100),
+			diff(modules.DiffApply, 2, types.UnlockHash{2}, 500),
+		},
+	})
+	if !h.financialMetrics.CollateralReserve.Equals64(100) || h.collateralTopUpPending {
+		t.Fatal("reserve was not updated:", h.financialMetrics.CollateralReserve, h.collateralTopUpPending)
+	}
+	if !isLocked(1) || isLocked(2) {
+		t.Fatal("only the reserve output should be locked:", w.locked)
+	}
+	if amount := h.collateralTopUpAmount(); !amount.IsZero() {
+		t.Fatal("full reserve should not be topped up:", amount)
+	}
+
+	// Spending the reserve output and returning change below the threshold
+	// triggers a top-up back to the target.
+	h.updateCollateralReserve(modules.ConsensusChange{
+		SiacoinOutputDiffs: []modules.SiacoinOutputDiff{
+			diff(modules.DiffRevert, 1, types.UnlockHash{1}, 100),
+			diff(modules.DiffApply, 3, types.UnlockHash{1}, 30),
+		},
+	})
+	if !h.financialMetrics.CollateralReserve.Equals64(30) {
+		t.Fatal("expected a reserve of 30, got", h.financialMetrics.CollateralReserve)
+	}
+	if isLocked(1) || !isLocked(3) {
+		t.Fatal("spent reserve output should be released and the change locked:", w.locked)
+	}
+	if ids := h.collateralReserveOutputIDs(); len(ids) != 1 || ids[0] != (types.SiacoinOutputID{3}) {
+		t.Fatal("unexpected reserve outputs:", ids)
+	}
+
+	// Reserve outputs loaded from the persist file are locked again, as the
+	// wallet does not persist its locks.
+	w.locked = make(map[types.OutputID]struct{})
+	h.loadCollateralReserve([]types.SiacoinOutputID{{3}})
+	if !isLocked(3) {
+		t.Fatal("loaded reserve output was not locked")
+	}
+	if amount := h.collateralTopUpAmount(); !amount.Equals64(70) {
+		t.Fatal("expected a top-up of 70, got", amount)
+	}
+
+	// A zero target disables the reserve.
+	h.settings.CollateralReserveTarget = types.ZeroCurrency
+	h.settings.CollateralReserveThreshold = types.ZeroCurrency
+	if amount := h.collateralTopUpAmount(); !amount.IsZero() {
+		t.Fatal("disabled reserve should not be topped up:", amount)
+	}
+}
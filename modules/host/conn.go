@@ -0,0 +1,96 @@
+package host
+
+import (
+	"net"
+	"time"
+)
+
+// A timeoutConn wraps an incoming connection so that every read and write is
+// bounded by the host's connection timeouts, in addition to any deadline set
+// by the RPC handlers. This prevents a slow or malicious renter from tying up
+// a connection indefinitely by sending or receiving data very slowly.
+type timeoutConn struct {
+	net.Conn
+
+	idleTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+
+	// readDeadline and writeDeadline are the deadlines set by the RPC
+	// handlers. idle is set when the next read is waiting for the renter to
+	// begin a request, and should be bounded by the idle timeout.
+	readDeadline  time.Time
+	writeDeadline time.Time
+	idle          bool
+}
+
+// newTimeoutConn wraps conn using the host's connection timeouts. Until the
+// renter sends its first request, the connection is considered idle.
+func newTimeoutConn(conn net.Conn, idleTimeout, readTimeout, writeTimeout time.Duration) *timeoutConn {
+	return &timeoutConn{
+		Conn:         conn,
+		idleTimeout:  idleTimeout,
+		readTimeout:  readTimeout,
+		writeTimeout: writeTimeout,
+		idle:         true,
+	}
+}
+
+// earliestDeadline returns the earlier of deadline and the time timeout from
+// now. A zero deadline means that no deadline has been set.
+func earliestDeadline(deadline time.Time, timeout time.Duration) time.Time {
+	t := time.Now().Add(timeout)
+	if !deadline.IsZero() && deadline.Before(t) {
+		return deadline
+	}
+	return t
+}
+
+// Read reads from the connection, giving up after the read timeout, or the
+// idle timeout if the host is waiting for the renter to begin a request.
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	timeout := c.readTimeout
+	if c.idle {
+		timeout = c.idleTimeout
+		c.idle = false
+	}
+	if err := c.Conn.SetReadDeadline(earliestDeadline(c.readDeadline, timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+// Write writes to the connection, giving up after the write timeout.
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(earliestDeadline(c.writeDeadline, c.writeTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+// SetDeadline sets the read and write deadlines of the connection.
+func (c *timeoutConn) SetDeadline(t time.Time) error {
+	c.readDeadline, c.writeDeadline = t, t
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline of the connection.
+func (c *timeoutConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline of the connection.
+func (c *timeoutConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline = t
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// awaitRenter marks the next read from conn as waiting for the renter to
+// begin the next iteration of an RPC, so that it is bounded by the idle
+// timeout instead of the read timeout.
+func awaitRenter(conn net.Conn) {
+	if tc, ok := conn.(*timeoutConn); ok {
+		tc.idle = true
+	}
+}
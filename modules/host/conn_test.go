@@ -0,0 +1,55 @@
+package host
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestTimeoutConn checks that reads from a timeoutConn are bounded by the
+// idle timeout while waiting for a request and by the read timeout
+// otherwise, and that deadlines set by the RPC handlers still apply.
+func TestTimeoutConn(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := newTimeoutConn(c1, 200*time.Millisecond, 50*time.Millisecond, 50*time.Millisecond)
+	defer conn.Close()
+
+	// The first read waits for the idle timeout, which is longer than the
+	// read timeout.
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		c2.Write([]byte{1})
+	}()
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatal("idle read should not time out:", err)
+	}
+
+	// Later reads are bounded by the read timeout.
+	start := time.Now()
+	if _, err := conn.Read(buf); err == nil {
+		t.Fatal("read should have timed out")
+	} else if time.Since(start) > 150*time.Millisecond {
+		t.Fatal("read took longer than the read timeout:", time.Since(start))
+	}
+
+	// Writes are bounded by the write timeout, since nobody is reading.
+	start = time.Now()
+	if _, err := conn.Write(buf); err == nil {
+		t.Fatal("write should have timed out")
+	} else if time.Since(start) > 150*time.Millisecond {
+		t.Fatal("write took longer than the write timeout:", time.Since(start))
+	}
+
+	// A deadline set by an RPC handler that is earlier than the idle timeout
+	// still applies.
+	conn.SetDeadline(time.Now().Add(10 * time.Millisecond))
+	awaitRenter(conn)
+	start = time.Now()
+	if _, err := conn.Read(buf); err == nil {
+		t.Fatal("read should have timed out")
+	} else if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("read ignored the connection deadline:", time.Since(start))
+	}
+}
@@ -9,6 +9,15 @@ import (
 )
 
 const (
+	// collateralTopUpTimeout defines the number of blocks that the host will
+	// wait for a top-up of the collateral reserve to confirm before
+	// submitting another one.
+	collateralTopUpTimeout = 6
+
+	// collateralTopUpTxnSize is the estimated size in bytes of a transaction
+	// that tops up the collateral reserve, used to calculate its fee.
+	collateralTopUpTxnSize = 750
+
 	// defaultMaxDuration defines the maximum number of blocks into the future
 	// that the host will accept for the duration of an incoming file contract
 	// obligation. 6 months is chosen because hosts are expected to be
@@ -26,6 +35,18 @@ const (
 	// connection.
 	iteratedConnectionTime = 1200 * time.Second
 
+	// maxRecentErrors is the number of failed RPC calls that the host
+	// remembers for RecentErrors.
+	maxRecentErrors = 100
+
+	// maxContractDecisions is the number of contract formations and renewals
+	// whose outcome the host remembers for UtilizationMetrics.
+	maxContractDecisions = 1000
+
+	// maxRPCErrorLen is the maximum length in bytes of an error message
+	// reported by RecentErrors. Longer messages are truncated.
+	maxRPCErrorLen = 512
+
 	// resubmissionTimeout defines the number of blocks that a host will wait
 	// before attempting to resubmit a transaction to the blockchain.
 	// Typically, this transaction will contain either a file contract, a file
@@ -142,6 +163,77 @@ var (
 		Testing:  types.BlockHeight(5),   // 5 seconds.
 	}).(types.BlockHeight)
 
+	// defaultProofWindowBuffer is the default number of blocks before the end
+	// of the proof window by which the host aims to have its storage proof
+	// confirmed.
+	defaultProofWindowBuffer = build.Select(build.Var{
+		Dev:      types.BlockHeight(6),  // 36 seconds.
+		Standard: types.BlockHeight(36), // 6 hours.
+		Testing:  types.BlockHeight(1),  // 1 second.
+	}).(types.BlockHeight)
+
+	// defaultMaxOpenConns is the default number of incoming connections that
+	// the host will handle at once.
+	defaultMaxOpenConns = build.Select(build.Var{
+		Dev:      uint64(256),
+		Standard: uint64(512),
+		Testing:  uint64(256),
+	}).(uint64)
+
+	// defaultMaxRenterConns is the default number of connections that a
+	// single renter may have open at once.
+	defaultMaxRenterConns = build.Select(build.Var{
+		Dev:      uint64(32),
+		Standard: uint64(32),
+		Testing:  uint64(64),
+	}).(uint64)
+
+	// defaultConnIdleTimeout is the default amount of time that the host will
+	// wait for a renter to begin an RPC, or the next iteration of an RPC.
+	defaultConnIdleTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 2 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// defaultConnReadTimeout is the default amount of time that a single read
+	// from a renter may take.
+	defaultConnReadTimeout = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// defaultConnWriteTimeout is the default amount of time that a single
+	// write to a renter may take. Writes can contain whole sectors, so the
+	// timeout is generous enough for slow connections.
+	defaultConnWriteTimeout = build.Select(build.Var{
+		Dev:      2 * time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// defaultMinAnnounceInterval is the default minimum amount of time
+	// between two automatic announcements. It keeps a host whose external
+	// address flaps from announcing every time the address is checked.
+	defaultMinAnnounceInterval = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 6 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// defaultAnnounceFeePeriod is the default period within which the fees
+	// of automatic announcements are capped by defaultMaxAnnounceFees.
+	defaultAnnounceFeePeriod = build.Select(build.Var{
+		Dev:      24 * time.Hour,
+		Standard: 30 * 24 * time.Hour,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
+	// defaultMaxAnnounceFees is the default cap on the fees that automatic
+	// announcements may spend within an announcement fee period.
+	defaultMaxAnnounceFees = types.SiacoinPrecision.Mul64(50)
+
 	// logAllLimit is the number of errors of each type that the host will log
 	// before switching to probabilistic logging. If there are not many errors,
 	// it is reasonable that all errors get logged. If there are lots of
@@ -238,6 +330,10 @@ var (
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
+
+	// bucketRetainedSectors contains the serialized 'retainedSectors' of
+	// storage obligations that have ended, sorted by their file contract id.
+	bucketRetainedSectors = []byte("BucketRetainedSectors")
 )
 
 // init runs a series of sanity checks to verify that the constants have sane
@@ -249,4 +345,10 @@ func init() {
 	if revisionSubmissionBuffer < resubmissionTimeout {
 		build.Critical("revision submission buffer needs to be larger than or equal to the resubmission timeout")
 	}
+
+	// The default window should leave time to submit a storage proof and
+	// wait for it to be confirmed before the proof window buffer is reached.
+	if defaultWindowSize < defaultProofWindowBuffer+resubmissionTimeout {
+		build.Critical("default window size needs to be larger than or equal to the proof window buffer plus the resubmission timeout")
+	}
 }
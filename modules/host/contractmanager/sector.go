@@ -31,6 +31,10 @@ var (
 	// the maximum number of virtual sectors for that sector id already exist.
 	errMaxVirtualSectors = errors.New("sector collides with a physical sector that already has the maximum allowed number of virtual sectors")
 
+	// errPartialSectorBounds is returned when a partial sector read extends
+	// past the end of the sector.
+	errPartialSectorBounds = errors.New("requested range extends past the end of the sector")
+
 	// ErrSectorNotFound is returned when a lookup for a sector fails.
 	ErrSectorNotFound = errors.New("could not find the desired sector")
 )
@@ -66,8 +70,15 @@ type (
 // readSector will read the sector in the file, starting from the provided
 // location.
 func readSector(f file, sectorIndex uint32) ([]byte, error) {
-	b := make([]byte, modules.SectorSize)
-	_, err := f.ReadAt(b, int64(uint64(sectorIndex)*modules.SectorSize))
+	return readPartialSector(f, sectorIndex, 0, modules.SectorSize)
+}
+
+// readPartialSector will read length bytes of the sector in the file,
+// starting offset bytes into the sector. Only the requested range is read
+// from disk.
+func readPartialSector(f file, sectorIndex uint32, offset, length uint64) ([]byte, error) {
+	b := make([]byte, length)
+	_, err := f.ReadAt(b, int64(uint64(sectorIndex)*modules.SectorSize+offset))
 	if err != nil {
 		return nil, build.ExtendErr("unable to read within storage folder", err)
 	}
@@ -126,6 +137,16 @@ func (cm *ContractManager) managedSectorID(sectorRoot crypto.Hash) (id sectorID)
 // ReadSector will read a sector from the storage manager, returning the bytes
 // that match the input sector root.
 func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
+	return cm.ReadPartialSector(root, 0, modules.SectorSize)
+}
+
+// ReadPartialSector will read length bytes of a sector from the storage
+// manager, starting offset bytes into the sector. Only the requested range is
+// read from disk, so serving a small part of a sector is cheap.
+func (cm *ContractManager) ReadPartialSector(root crypto.Hash, offset, length uint64) ([]byte, error) {
+	if offset > modules.SectorSize || length > modules.SectorSize-offset {
+		return nil, errPartialSectorBounds
+	}
 	err := cm.tg.Add()
 	if err != nil {
 		return nil, err
@@ -152,8 +173,8 @@ func (cm *ContractManager) ReadSector(root crypto.Hash) ([]byte, error) {
 		return nil, ErrSectorNotFound
 	}
 
-	// Read the sector.
-	sectorData, err := readSector(sf.sectorFile, sl.index)
+	// Read the requested part of the sector.
+	sectorData, err := readPartialSector(sf.sectorFile, sl.index, offset, length)
 	if err != nil {
 		atomic.AddUint64(&sf.atomicFailedReads, 1)
 		return nil, build.ExtendErr("unable to fetch sector", err)
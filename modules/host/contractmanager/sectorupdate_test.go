@@ -1906,3 +1906,77 @@ func TestFailingStorageFolder(t *testing.T) {
 		}
 	}
 }
+
+// readAtRecorder is a file that records the ranges passed to ReadAt.
+type readAtRecorder struct {
+	file
+	data  []byte
+	reads [][2]int64
+}
+
+// ReadAt records the range being read before reading it from data.
+func (r *readAtRecorder) ReadAt(b []byte, off int64) (int, error) {
+	r.reads = append(r.reads, [2]int64{off, int64(len(b))})
+	return copy(b, r.data[off:]), nil
+}
+
+// TestReadPartialSector checks that only the requested range of a sector is
+// read from disk, and that the range matches the stored data.
+func TestReadPartialSector(t *testing.T) {
+	data := fastrand.Bytes(int(modules.SectorSize * 3))
+	f := &readAtRecorder{data: data}
+	offset, length := modules.SectorSize/2, uint64(64)
+	b, err := readPartialSector(f, 1, offset, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := modules.SectorSize + offset
+	if !bytes.Equal(b, data[start:start+length]) {
+		t.Fatal("partial read returned the wrong data")
+	}
+	if len(f.reads) != 1 || f.reads[0] != [2]int64{int64(start), int64(length)} {
+		t.Fatal("expected a single read of the requested range, got", f.reads)
+	}
+}
+
+// TestContractManagerReadPartialSector reads a range from the middle of a
+// stored sector.
+func TestContractManagerReadPartialSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, data := randSector()
+	err = cmt.cm.AddSector(root, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset, length := modules.SectorSize/4, modules.SectorSize/2
+	partial, err := cmt.cm.ReadPartialSector(root, offset, length)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(partial, data[offset:offset+length]) {
+		t.Fatal("wrong range of the sector provided")
+	}
+	_, err = cmt.cm.ReadPartialSector(root, offset, modules.SectorSize)
+	if err != errPartialSectorBounds {
+		t.Fatal("expected errPartialSectorBounds, got", err)
+	}
+}
@@ -0,0 +1,87 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errDecommissionDeadline is returned if the deadline passed to
+	// Decommission has already been reached.
+	errDecommissionDeadline = errors.New("decommission deadline must be in the future")
+
+	// errDecommissioning is returned if the renter attempts to form a new
+	// contract, renew a contract or upload data while the host is
+	// decommissioning. Downloads and storage proofs are unaffected.
+	errDecommissioning = ErrorCommunication("host is decommissioning and is not accepting new contracts or uploads")
+)
+
+// decommissioning returns whether the host is being decommissioned. The caller
+// must hold the host's lock.
+func (h *Host) decommissioning() bool {
+	return h.decommissionDeadline != 0
+}
+
+// Decommission permanently retires the host. The host stops accepting new
+// contracts, renewals and uploads, and reports the deadline to renters
+// through RPCDecommission, serving downloads and submitting storage proofs
+// until then so that renters can move their data to other hosts. Calling
+// Decommission again changes the deadline.
+func (h *Host) Decommission(deadline types.BlockHeight) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if deadline <= h.blockHeight {
+		return errDecommissionDeadline
+	}
+	h.decommissionDeadline = deadline
+	h.revisionNumber++
+	h.log.Println("Host is decommissioning, serving downloads until height", deadline)
+	return h.saveSync()
+}
+
+// DecommissionStatus returns the decommission status of the host.
+func (h *Host) DecommissionStatus() modules.HostDecommission {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.decommissionStatus()
+}
+
+// decommissionStatus returns the decommission status of the host. The caller
+// must hold the host's lock.
+func (h *Host) decommissionStatus() modules.HostDecommission {
+	return modules.HostDecommission{
+		Decommissioning: h.decommissioning(),
+		Deadline:        h.decommissionDeadline,
+		Height:          h.blockHeight,
+	}
+}
+
+// managedRPCDecommission is an rpc that returns the signed decommission status
+// of the host.
+func (h *Host) managedRPCDecommission(conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime))
+
+	var status modules.HostDecommission
+	var secretKey crypto.SecretKey
+	h.mu.RLock()
+	status = h.decommissionStatus()
+	secretKey = h.secretKey
+	h.mu.RUnlock()
+
+	err := crypto.WriteSignedObject(conn, status, secretKey)
+	if err != nil {
+		return ErrorConnection("failed WriteSignedObject during RPCDecommission: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package host
+
+import (
+	"net"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestDecommissionStatus checks that the decommission status reflects the
+// deadline, and that deadlines that have already been reached are rejected.
+func TestDecommissionStatus(t *testing.T) {
+	h := &Host{blockHeight: 10}
+	if status := h.DecommissionStatus(); status.Decommissioning || status.Height != 10 {
+		t.Fatal("new host should not be decommissioning:", status)
+	}
+	if err := h.Decommission(10); err != errDecommissionDeadline {
+		t.Fatal("expected errDecommissionDeadline, got", err)
+	}
+	if h.decommissioning() {
+		t.Fatal("rejected deadline should not start decommissioning")
+	}
+
+	h.decommissionDeadline = 20
+	status := h.DecommissionStatus()
+	if !status.Decommissioning || status.Deadline != 20 {
+		t.Fatal("decommission status is incorrect:", status)
+	}
+}
+
+// TestRPCDecommission checks that the host sends its signed decommission
+// status in response to RPCDecommission.
+func TestRPCDecommission(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	h := &Host{
+		blockHeight:          10,
+		decommissionDeadline: 30,
+		secretKey:            sk,
+	}
+
+	hostConn, renterConn := net.Pipe()
+	defer hostConn.Close()
+	defer renterConn.Close()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- h.managedRPCDecommission(hostConn)
+	}()
+
+	var status modules.HostDecommission
+	err := crypto.ReadSignedObject(renterConn, &status, modules.NegotiateMaxHostExternalSettingsLen, pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	expected := modules.HostDecommission{Decommissioning: true, Deadline: 30, Height: types.BlockHeight(10)}
+	if status != expected {
+		t.Fatal("received the wrong decommission status:", status)
+	}
+}
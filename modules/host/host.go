@@ -69,6 +69,7 @@ import (
 	"net"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -124,6 +125,13 @@ type Host struct {
 	atomicSettingsCalls     uint64
 	atomicUnrecognizedCalls uint64
 
+	// atomicOpenConns is the number of incoming connections that the host is
+	// currently handling, which is limited by the MaxOpenConns setting.
+	atomicOpenConns uint64
+
+	// renters enforces the per-renter connection and bandwidth limits.
+	renters *renterLimiter
+
 	// Error management. There are a few different types of errors returned by
 	// the host. These errors intentionally not persistent, so that the logging
 	// limits of each error type will be reset each time the host is reset.
@@ -160,6 +168,59 @@ type Host struct {
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
 
+	// maintenance indicates that the host is refusing new contracts and
+	// uploads while continuing to serve downloads and submit storage proofs.
+	maintenance bool
+
+	// decommissionDeadline is the height until which a decommissioning host
+	// serves downloads. It is zero if the host is not decommissioning.
+	decommissionDeadline types.BlockHeight
+
+	// reservedStorage is the number of bytes that uploads in progress have
+	// reserved but not yet written to the storage manager. Reservations are
+	// held from the moment an upload is accepted until its sectors are
+	// committed, so that concurrent uploads cannot oversubscribe the host.
+	reservedStorage uint64
+
+	// retainedStorage is the number of bytes held by the retained sectors of
+	// ended obligations. Retained sectors are removed when an upload needs
+	// their storage, so they are advertised as remaining storage.
+	retainedStorage uint64
+
+	// announcementMetrics records the time and fees of the host's
+	// announcements. announceFeePeriodStart is the start of the period whose
+	// fees are counted against MaxAnnounceFees.
+	announcementMetrics    modules.HostAnnouncementMetrics
+	announceFeePeriodStart time.Time
+
+	// collateralReserveAddress is the wallet address that holds the host's
+	// collateral reserve, and collateralReserveOutputs are the outputs at
+	// that address, which are locked in the wallet so that they are only
+	// spent on collateral. collateralTopUpPending is set while a top-up of
+	// the reserve is waiting to be confirmed, and collateralTopUpHeight is
+	// the height at which it was submitted.
+	collateralReserveAddress types.UnlockHash
+	collateralReserveOutputs map[types.SiacoinOutputID]struct{}
+	collateralTopUpHeight    types.BlockHeight
+	collateralTopUpPending   bool
+
+	// contractFilter is consulted before forming or renewing a contract. A
+	// nil filter accepts all contracts.
+	contractFilter modules.ContractFilter
+
+	// recentErrors is a ring buffer of the most recent failed RPC calls.
+	// recentErrorsIndex is the position at which the next error is stored.
+	recentErrors      []modules.RPCError
+	recentErrorsIndex int
+
+	// contractDecisions is a ring buffer of the outcomes of the most recent
+	// contract formations and renewals, holding the rejection reason of each
+	// rejected contract and an empty string for each accepted contract.
+	// contractDecisionsIndex is the position at which the next outcome is
+	// stored.
+	contractDecisions      []string
+	contractDecisionsIndex int
+
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
@@ -230,10 +291,12 @@ func newHost(dependencies dependencies, cs modules.ConsensusSet, tpool modules.T
 		wallet:       wallet,
 		dependencies: dependencies,
 
+		collateralReserveOutputs: make(map[types.SiacoinOutputID]struct{}),
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
 
 		persistDir: persistDir,
 	}
+	h.renters = newRenterLimiter(h.tg.StopChan())
 
 	// Call stop in the event of a partial startup.
 	var err error
@@ -381,6 +444,22 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if settings.CollateralReserveThreshold.Cmp(settings.CollateralReserveTarget) > 0 {
+		return errBadCollateralReserve
+	}
+	if settings.ProofWindowBuffer+resubmissionTimeout > settings.WindowSize {
+		return errBadProofWindowBuffer
+	}
+	if settings.MaxOpenConns == 0 || settings.MaxRenterConns == 0 || settings.ConnIdleTimeout <= 0 || settings.ConnReadTimeout <= 0 || settings.ConnWriteTimeout <= 0 {
+		return errBadConnSettings
+	}
+	if err := validDataRetention(settings); err != nil {
+		return err
+	}
+	if settings.MinAnnounceInterval < 0 || settings.AnnounceFeePeriod <= 0 {
+		return errBadAnnounceSettings
+	}
+
 	if settings.NetAddress != "" {
 		err := settings.NetAddress.IsValid()
 		if err != nil {
@@ -397,6 +476,7 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 
 	h.settings = settings
 	h.revisionNumber++
+	h.renters.setLimits(settings.MaxRenterConns, settings.MaxBandwidth)
 
 	err = h.saveSync()
 	if err != nil {
@@ -405,6 +485,44 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 	return nil
 }
 
+// Maintenance returns whether the host is in maintenance mode.
+func (h *Host) Maintenance() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.maintenance
+}
+
+// SetMaintenance enables or disables maintenance mode. While in maintenance
+// mode the host rejects new contracts, renewals and revisions, but continues
+// to serve downloads and submit storage proofs for existing contracts. The
+// mode is persisted, so that a host restarted during maintenance stays in
+// maintenance mode.
+func (h *Host) SetMaintenance(maintenance bool) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.maintenance != maintenance {
+		h.revisionNumber++
+	}
+	h.maintenance = maintenance
+	h.log.Println("Maintenance mode set to", maintenance)
+	return h.saveSync()
+}
+
+// SetContractFilter sets the filter that decides whether the host accepts a
+// contract, allowing operators to apply their own policy on top of the host
+// settings. A nil filter accepts all contracts.
+func (h *Host) SetContractFilter(filter modules.ContractFilter) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.contractFilter = filter
+}
+
 // InternalSettings returns the settings of a host.
 func (h *Host) InternalSettings() modules.HostInternalSettings {
 	h.mu.RLock()
@@ -318,6 +318,130 @@ func TestNilValues(t *testing.T) {
 	}
 }
 
+// TestHostMaintenance checks that maintenance mode is reflected in the
+// host's external settings, can be toggled, and persists across restarts.
+func TestHostMaintenance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	if ht.host.Maintenance() {
+		t.Fatal("host should not start in maintenance mode")
+	}
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	err = ht.host.SetInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.SetMaintenance(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.Maintenance() {
+		t.Fatal("host did not enter maintenance mode")
+	}
+	if ht.host.ExternalSettings().AcceptingContracts {
+		t.Error("host in maintenance mode should not advertise that it is accepting contracts")
+	}
+	if !ht.host.InternalSettings().AcceptingContracts {
+		t.Error("maintenance mode should not modify the internal settings")
+	}
+
+	// Restart the host; it should still be in maintenance mode.
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rebootHost, err := New(ht.cs, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host = rebootHost
+	if !ht.host.Maintenance() {
+		t.Fatal("maintenance mode was not persisted")
+	}
+
+	err = ht.host.SetMaintenance(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ht.host.ExternalSettings().AcceptingContracts {
+		t.Error("host should accept contracts after leaving maintenance mode")
+	}
+}
+
+// TestContractFilter checks that the host's contract filter receives the
+// terms of the proposed contract and can reject it.
+func TestContractFilter(t *testing.T) {
+	h := &Host{blockHeight: 10}
+	_, renterPK := crypto.GenerateKeyPair()
+	fc := types.FileContract{
+		FileSize:    64,
+		WindowStart: 100,
+		WindowEnd:   110,
+		Payout:      types.NewCurrency64(1000),
+		ValidProofOutputs: []types.SiacoinOutput{
+			{Value: types.NewCurrency64(600)},
+			{Value: types.NewCurrency64(361)},
+		},
+	}
+	txnSet := []types.Transaction{{FileContracts: []types.FileContract{fc}}}
+
+	// With no filter, every contract is accepted.
+	if err := h.managedCheckContractFilter(txnSet, renterPK, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The filter should see the terms of the contract.
+	var terms modules.ContractTerms
+	h.SetContractFilter(func(ct modules.ContractTerms) bool {
+		terms = ct
+		return ct.WindowEnd-ct.CurrentHeight <= 50
+	})
+	if err := h.managedCheckContractFilter(txnSet, renterPK, true); err != errRejectedByFilter {
+		t.Fatal("expected errRejectedByFilter, got", err)
+	}
+	expected := modules.ContractTerms{
+		RenterPublicKey: types.Ed25519PublicKey(renterPK),
+		Renewal:         true,
+		CurrentHeight:   10,
+		WindowStart:     100,
+		WindowEnd:       110,
+		FileSize:        64,
+		Payout:          types.NewCurrency64(1000),
+		RenterPayout:    types.NewCurrency64(600),
+		HostPayout:      types.NewCurrency64(361),
+	}
+	if terms.RenterPublicKey.String() != expected.RenterPublicKey.String() || terms.Renewal != expected.Renewal ||
+		terms.CurrentHeight != expected.CurrentHeight || terms.WindowStart != expected.WindowStart ||
+		terms.WindowEnd != expected.WindowEnd || terms.FileSize != expected.FileSize ||
+		!terms.Payout.Equals(expected.Payout) || !terms.RenterPayout.Equals(expected.RenterPayout) ||
+		!terms.HostPayout.Equals(expected.HostPayout) {
+		t.Fatalf("filter received wrong terms: %+v", terms)
+	}
+
+	// A contract satisfying the filter is accepted.
+	h.blockHeight = 80
+	if err := h.managedCheckContractFilter(txnSet, renterPK, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Clearing the filter accepts all contracts again.
+	h.blockHeight = 10
+	h.SetContractFilter(nil)
+	if err := h.managedCheckContractFilter(txnSet, renterPK, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestSetAndGetInternalSettings checks that the functions for interacting with
 // the host's internal settings object are working as expected.
 func TestSetAndGetInternalSettings(t *testing.T) {
@@ -89,6 +89,11 @@ var (
 	// length.
 	errIllegalOffsetAndLength = ErrorCommunication("renter is trying to do a modify with an illegal offset and length")
 
+	// errInsufficientStorage is returned if the renter attempts to upload more
+	// data than the host has room for, after accounting for the storage that
+	// has been reserved by other uploads in progress.
+	errInsufficientStorage = ErrorCommunication("host does not have enough remaining storage for the upload")
+
 	// errLargeSector is returned if the renter sends a RevisionAction that has
 	// data which creates a sector that is larger than what the host uses.
 	errLargeSector = ErrorCommunication("renter has sent a sector that exceeds the host's sector size")
@@ -99,6 +104,11 @@ var (
 	// will not accept revisions once the window start is too close.
 	errLateRevision = ErrorCommunication("renter is requesting revision after the revision deadline")
 
+	// errMaintenanceMode is returned if the renter attempts to form a new
+	// contract or revise an existing contract while the host is in
+	// maintenance mode. Downloads and storage proofs are unaffected.
+	errMaintenanceMode = ErrorCommunication("host is in maintenance mode and is not accepting new contracts or uploads")
+
 	// errLongDuration is returned if the renter proposes a file contract with
 	// an experation that is too far into the future according to the host's
 	// settings.
@@ -112,6 +122,10 @@ var (
 	// host valid proof output during a file contract revision.
 	errLowHostValidOutput = ErrorCommunication("rejected for low paying host valid output")
 
+	// errLowContractValue is returned if the renter proposes a file contract
+	// that allocates fewer funds than the host's minimum contract value.
+	errLowContractValue = ErrorCommunication("rejected for low value contract")
+
 	// errLowTransactionFees is returned if the renter provides a transaction
 	// that the host does not feel is able to make it onto the blockchain.
 	errLowTransactionFees = ErrorCommunication("rejected for including too few transaction fees")
@@ -125,6 +139,10 @@ var (
 	// formation.
 	errMismatchedHostPayouts = ErrorCommunication("rejected because host valid and missed payouts are not the same value")
 
+	// errRejectedByFilter is returned if the host's contract filter declines
+	// the proposed file contract.
+	errRejectedByFilter = ErrorCommunication("rejected by host contract policy")
+
 	// errSmallWindow is returned if the renter suggests a storage proof window
 	// that is too small.
 	errSmallWindow = ErrorCommunication("rejected for small window size")
@@ -134,6 +152,37 @@ var (
 	errUnknownModification = ErrorCommunication("renter is attempting an action that the host does not understand")
 )
 
+// managedCheckContractFilter passes the terms of the proposed file contract to
+// the host's contract filter, returning errRejectedByFilter if the filter
+// declines the contract. The transaction set is expected to have already been
+// verified.
+func (h *Host) managedCheckContractFilter(txnSet []types.Transaction, renterPK crypto.PublicKey, renewal bool) error {
+	h.mu.RLock()
+	filter := h.contractFilter
+	blockHeight := h.blockHeight
+	h.mu.RUnlock()
+	if filter == nil {
+		return nil
+	}
+
+	fc := txnSet[len(txnSet)-1].FileContracts[0]
+	terms := modules.ContractTerms{
+		RenterPublicKey: types.Ed25519PublicKey(renterPK),
+		Renewal:         renewal,
+		CurrentHeight:   blockHeight,
+		WindowStart:     fc.WindowStart,
+		WindowEnd:       fc.WindowEnd,
+		FileSize:        fc.FileSize,
+		Payout:          fc.Payout,
+		RenterPayout:    fc.ValidProofOutputs[0].Value,
+		HostPayout:      fc.ValidProofOutputs[1].Value,
+	}
+	if !filter(terms) {
+		return errRejectedByFilter
+	}
+	return nil
+}
+
 // createRevisionSignature creates a signature for a file contract revision
 // that signs on the file contract revision. The renter should have already
 // provided the signature. createRevisionSignature will check to make sure that
@@ -220,6 +269,8 @@ func (h *Host) managedFinalizeContract(builder modules.TransactionBuilder, rente
 
 		OriginTransactionSet:   fullTxnSet,
 		RevisionTransactionSet: []types.Transaction{revisionTransaction},
+
+		ProofVersion: currentProofVersion,
 	}
 
 	// Get a lock on the storage obligation.
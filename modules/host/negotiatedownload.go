@@ -33,7 +33,10 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	// Extend the deadline for the download.
 	conn.SetDeadline(time.Now().Add(modules.NegotiateDownloadTime))
 
-	// The renter will either accept or reject the host's settings.
+	// The renter will either accept or reject the host's settings. Between
+	// iterations the renter may be idle, so the wait is limited by the idle
+	// timeout rather than the read timeout.
+	awaitRenter(conn)
 	err = modules.ReadNegotiationAcceptance(conn)
 	if err == modules.ErrStopResponse {
 		return err // managedRPCDownload will catch this and exit gracefully
@@ -87,13 +90,13 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 			return extendErr("payment verification failed: ", err)
 		}
 
-		// Load the sectors and build the data payload.
+		// Load the requested part of each sector and build the data payload.
 		for _, request := range requests {
-			sectorData, err := h.ReadSector(request.MerkleRoot)
+			sectorData, err := h.ReadPartialSector(request.MerkleRoot, request.Offset, request.Length)
 			if err != nil {
 				return extendErr("failed to load sector: ", ErrorInternal(err.Error()))
 			}
-			payload = append(payload, sectorData[request.Offset:request.Offset+request.Length])
+			payload = append(payload, sectorData)
 		}
 		return nil
 	}()
@@ -0,0 +1,64 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestVerifyPaymentRevision checks that a download is only accepted if the
+// payment revision moves at least the bandwidth cost from the renter to the
+// host.
+func TestVerifyPaymentRevision(t *testing.T) {
+	existing := types.FileContractRevision{
+		NewRevisionNumber: 1,
+		NewWindowStart:    revisionSubmissionBuffer + 100,
+		NewValidProofOutputs: []types.SiacoinOutput{
+			{Value: types.NewCurrency64(1000)},
+			{Value: types.NewCurrency64(500)},
+		},
+		NewMissedProofOutputs: []types.SiacoinOutput{
+			{Value: types.NewCurrency64(1000)},
+			{Value: types.NewCurrency64(500)},
+			{Value: types.ZeroCurrency},
+		},
+	}
+	// pay returns a revision of the existing revision that takes 'fromRenter'
+	// from the renter and gives 'toHost' to the host.
+	pay := func(fromRenter, toHost uint64) types.FileContractRevision {
+		rev := existing
+		rev.NewRevisionNumber++
+		rev.NewValidProofOutputs = []types.SiacoinOutput{
+			{Value: existing.NewValidProofOutputs[0].Value.Sub(types.NewCurrency64(fromRenter))},
+			{Value: existing.NewValidProofOutputs[1].Value.Add(types.NewCurrency64(toHost))},
+		}
+		rev.NewMissedProofOutputs = append([]types.SiacoinOutput(nil), existing.NewMissedProofOutputs...)
+		rev.NewMissedProofOutputs[0].Value = rev.NewValidProofOutputs[0].Value
+		return rev
+	}
+	expected := types.NewCurrency64(100)
+
+	// Paying exactly the bandwidth cost, or more, is accepted.
+	if err := verifyPaymentRevision(existing, pay(100, 100), 0, expected); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyPaymentRevision(existing, pay(150, 150), 0, expected); err != nil {
+		t.Fatal(err)
+	}
+	// Paying less than the bandwidth cost is rejected.
+	if err := verifyPaymentRevision(existing, pay(99, 99), 0, expected); err == nil {
+		t.Fatal("underpaying revision was accepted")
+	}
+	// The money taken from the renter must all go to the host.
+	if err := verifyPaymentRevision(existing, pay(100, 50), 0, expected); err == nil {
+		t.Fatal("revision that did not pay the host was accepted")
+	}
+	// A host that does not charge for bandwidth still requires a new
+	// revision, even if it transfers nothing.
+	if err := verifyPaymentRevision(existing, pay(0, 0), 0, types.ZeroCurrency); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyPaymentRevision(existing, existing, 0, types.ZeroCurrency); err != errBadRevisionNumber {
+		t.Fatal("expected errBadRevisionNumber, got", err)
+	}
+}
@@ -1,6 +1,7 @@
 package host
 
 import (
+	"fmt"
 	"net"
 	"time"
 
@@ -39,7 +40,7 @@ func (h *Host) managedAddCollateral(settings modules.HostInternalSettings, txnSe
 	fc := txn.FileContracts[0]
 	hostPortion := contractCollateral(settings, fc)
 	builder = h.wallet.RegisterTransaction(txn, parents)
-	err = builder.FundSiacoins(hostPortion)
+	err = h.managedFundCollateral(builder, hostPortion)
 	if err != nil {
 		builder.Drop()
 		return nil, nil, nil, nil, extendErr("could not add collateral: ", ErrorInternal(err.Error()))
@@ -106,18 +107,45 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 		return extendErr("could not read renter public key: ", ErrorConnection(err.Error()))
 	}
 
+	// The host may have entered maintenance mode or started decommissioning
+	// after sending its settings.
+	h.mu.RLock()
+	maintenance := h.maintenance
+	decommissioning := h.decommissioning()
+	h.mu.RUnlock()
+	if maintenance {
+		h.managedRecordContractDecision(rejectMaintenance)
+		modules.WriteNegotiationRejection(conn, errMaintenanceMode) // Error ignored to preserve type in extendErr
+		return errMaintenanceMode
+	}
+	if decommissioning {
+		h.managedRecordContractDecision(rejectDecommissioning)
+		modules.WriteNegotiationRejection(conn, errDecommissioning) // Error ignored to preserve type in extendErr
+		return errDecommissioning
+	}
+
 	// The host verifies that the file contract coming over the wire is
 	// acceptable.
 	err = h.managedVerifyNewContract(txnSet, renterPK)
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
+		h.managedRecordContractDecision(rejectInvalid)
 		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("contract verification failed: ", err)
 	}
+	// The host's contract filter may decline contracts that are otherwise
+	// acceptable.
+	err = h.managedCheckContractFilter(txnSet, renterPK, false)
+	if err != nil {
+		h.managedRecordContractDecision(rejectFiltered)
+		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		return extendErr("contract filter rejected contract: ", err)
+	}
 	// The host adds collateral to the transaction.
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddCollateral(settings, txnSet)
 	if err != nil {
+		h.managedRecordContractDecision(rejectCollateral)
 		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("failed to add collateral: ", err)
 	}
@@ -175,10 +203,12 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
 		// why to the renter.
+		h.managedRecordContractDecision(rejectFinalization)
 		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
 		return extendErr("contract finalization failed: ", err)
 	}
 	defer h.managedUnlockStorageObligation(newSOID)
+	h.managedRecordContractDecision("")
 	err = modules.WriteNegotiationAcceptance(conn)
 	if err != nil {
 		return extendErr("failed to write acceptance after contract finalization: ", ErrorConnection(err.Error()))
@@ -264,6 +294,12 @@ func (h *Host) managedVerifyNewContract(txnSet []types.Transaction, renterPK cry
 	if fc.ValidProofOutputs[1].Value.Cmp(settings.MinContractPrice) < 0 {
 		return errLowHostValidOutput
 	}
+	// Check that the renter has allocated enough funds to the contract for it
+	// to be worth the overhead of forming it.
+	if fc.ValidProofOutputs[0].Value.Cmp(settings.MinContractValue) < 0 {
+		s := fmt.Sprintf("contract value %v is below the host minimum of %v: ", fc.ValidProofOutputs[0].Value, settings.MinContractValue)
+		return extendErr(s, errLowContractValue)
+	}
 	// Check that the collateral does not exceed the maximum amount of
 	// collateral allowed.
 	expectedCollateral := contractCollateral(settings, fc)
@@ -139,6 +139,14 @@ func (h *Host) managedRPCRecentRevision(conn net.Conn) (types.FileContractID, st
 		}
 	}()
 
+	// Now that the renter has proven that it owns the contract, count the
+	// connection towards the renter's limits.
+	err = identifyRenter(conn, recentRevision.UnlockConditions.PublicKeys[0])
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		return types.FileContractID{}, storageObligation{}, err
+	}
+
 	// Send the file contract revision and the corresponding signatures to the
 	// renter.
 	err = modules.WriteNegotiationAcceptance(conn)
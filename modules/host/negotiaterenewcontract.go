@@ -2,6 +2,7 @@ package host
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -52,7 +53,7 @@ func (h *Host) managedAddRenewCollateral(so storageObligation, settings modules.
 	fc := txn.FileContracts[0]
 	hostPortion := renewContractCollateral(so, settings, fc)
 	builder = h.wallet.RegisterTransaction(txn, parents)
-	err = builder.FundSiacoins(hostPortion)
+	err = h.managedFundCollateral(builder, hostPortion)
 	if err != nil {
 		builder.Drop()
 		return nil, nil, nil, nil, extendErr("could not add collateral: ", ErrorInternal(err.Error()))
@@ -120,16 +121,39 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 
 	h.mu.RLock()
 	settings := h.externalSettings()
+	maintenance := h.maintenance
+	decommissioning := h.decommissioning()
 	h.mu.RUnlock()
 
+	// A renewal creates a new contract, which is not allowed in maintenance
+	// mode or while decommissioning.
+	if maintenance {
+		h.managedRecordContractDecision(rejectMaintenance)
+		modules.WriteNegotiationRejection(conn, errMaintenanceMode) // Error is ignored to preserve type for extendErr
+		return errMaintenanceMode
+	}
+	if decommissioning {
+		h.managedRecordContractDecision(rejectDecommissioning)
+		modules.WriteNegotiationRejection(conn, errDecommissioning) // Error is ignored to preserve type for extendErr
+		return errDecommissioning
+	}
+
 	// Verify that the transaction coming over the wire is a proper renewal.
 	err = h.managedVerifyRenewedContract(so, txnSet, renterPK)
 	if err != nil {
+		h.managedRecordContractDecision(rejectInvalid)
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("verification of renewal failed: ", err)
 	}
+	err = h.managedCheckContractFilter(txnSet, renterPK, true)
+	if err != nil {
+		h.managedRecordContractDecision(rejectFiltered)
+		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
+		return extendErr("contract filter rejected renewal: ", err)
+	}
 	txnBuilder, newParents, newInputs, newOutputs, err := h.managedAddRenewCollateral(so, settings, txnSet)
 	if err != nil {
+		h.managedRecordContractDecision(rejectCollateral)
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("failed to add collateral: ", err)
 	}
@@ -188,10 +212,12 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 	h.mu.RUnlock()
 	hostTxnSignatures, hostRevisionSignature, newSOID, err := h.managedFinalizeContract(txnBuilder, renterPK, renterTxnSignatures, renterRevisionSignature, so.SectorRoots, renewCollateral, renewRevenue, renewRisk)
 	if err != nil {
+		h.managedRecordContractDecision(rejectFinalization)
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("failed to finalize contract: ", err)
 	}
 	defer h.managedUnlockStorageObligation(newSOID)
+	h.managedRecordContractDecision("")
 	err = modules.WriteNegotiationAcceptance(conn)
 	if err != nil {
 		return extendErr("failed to write acceptance: ", ErrorConnection(err.Error()))
@@ -261,6 +287,13 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 		return errBadPayoutUnlockHashes
 	}
 
+	// Check that the renter has allocated enough funds to the renewed contract
+	// for it to be worth the overhead of forming it.
+	if fc.ValidProofOutputs[0].Value.Cmp(internalSettings.MinContractValue) < 0 {
+		s := fmt.Sprintf("contract value %v is below the host minimum of %v: ", fc.ValidProofOutputs[0].Value, internalSettings.MinContractValue)
+		return extendErr(s, errLowContractValue)
+	}
+
 	// Check that the collateral does not exceed the maximum amount of
 	// collateral allowed.
 	expectedCollateral := renewContractCollateral(so, externalSettings, fc)
@@ -28,7 +28,10 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 
 	// The renter will either accept or reject the settings + revision
 	// transaction. It may also return a stop response to indicate that it
-	// wishes to terminate the revision loop.
+	// wishes to terminate the revision loop. Between iterations the renter
+	// may be idle, so the wait is limited by the idle timeout rather than the
+	// read timeout.
+	awaitRenter(conn)
 	err = modules.ReadNegotiationAcceptance(conn)
 	if err == modules.ErrStopResponse {
 		return err // managedRPCReviseContract will catch this and exit gracefully
@@ -41,6 +44,8 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	settings := h.settings
 	secretKey := h.secretKey
 	blockHeight := h.blockHeight
+	maintenance := h.maintenance
+	decommissioning := h.decommissioning()
 	h.mu.RUnlock()
 
 	// The renter is going to send its intended modifications, followed by the
@@ -56,6 +61,18 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 		return extendErr("unable to read proposed revision: ", ErrorConnection(err.Error()))
 	}
 
+	// Revisions are not accepted while the host is in maintenance mode.
+	if maintenance {
+		modules.WriteNegotiationRejection(conn, errMaintenanceMode) // Error is ignored so that the error type can be preserved in extendErr.
+		return errMaintenanceMode
+	}
+	// Nor are they accepted while the host is decommissioning, as renters
+	// should be moving their data off of the host.
+	if decommissioning {
+		modules.WriteNegotiationRejection(conn, errDecommissioning) // Error is ignored so that the error type can be preserved in extendErr.
+		return errDecommissioning
+	}
+
 	// First read all of the modifications. Then make the modifications, but
 	// with the ability to reverse them. Then verify the file contract revision
 	// correctly accounts for the changes.
@@ -142,6 +159,17 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
 		return extendErr("rejected proposed modifications: ", err)
 	}
+	// Reserve room for the new sectors before accepting the revision. The
+	// reservation is held until the sectors have been committed, so that
+	// concurrent uploads are checked against each other as well as against
+	// the storage that has already been used.
+	reservation := uint64(len(sectorsGained)) * modules.SectorSize
+	err = h.managedReserveStorage(reservation)
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
+		return extendErr("rejected proposed modifications: ", err)
+	}
+	defer h.managedReleaseStorage(reservation)
 	// Revision is acceptable, write an acceptance string.
 	err = modules.WriteNegotiationAcceptance(conn)
 	if err != nil {
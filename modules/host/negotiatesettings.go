@@ -23,9 +23,51 @@ func (h *Host) capacity() (total, remaining uint64) {
 	return total, remaining
 }
 
+// managedReserveStorage reserves size bytes of the host's remaining storage
+// for an upload, removing the retained data of ended contracts if it is
+// needed. errInsufficientStorage is returned if the remaining storage, less
+// the storage reserved by other uploads, is smaller than size. Every
+// successful reservation must be returned with managedReleaseStorage.
+func (h *Host) managedReserveStorage(size uint64) error {
+	if size == 0 {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.freeRetainedStorage(size) {
+		return errInsufficientStorage
+	}
+	h.reservedStorage += size
+	return nil
+}
+
+// managedReleaseStorage returns a reservation made by managedReserveStorage.
+func (h *Host) managedReleaseStorage(size uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if size > h.reservedStorage {
+		h.log.Critical("released more storage than was reserved")
+		size = h.reservedStorage
+	}
+	h.reservedStorage -= size
+}
+
 // externalSettings compiles and returns the external settings for the host.
 func (h *Host) externalSettings() modules.HostExternalSettings {
 	totalStorage, remainingStorage := h.capacity()
+	// Retained sectors are removed to make room for uploads, so their
+	// storage is available to renters. Sectors that are shared with active
+	// obligations are not freed by removing them, so the sum is capped.
+	remainingStorage += h.retainedStorage
+	if remainingStorage > totalStorage {
+		remainingStorage = totalStorage
+	}
+	// Storage reserved by uploads in progress is not available to renters.
+	if h.reservedStorage < remainingStorage {
+		remainingStorage -= h.reservedStorage
+	} else {
+		remainingStorage = 0
+	}
 	var netAddr modules.NetAddress
 	if h.settings.NetAddress != "" {
 		netAddr = h.settings.NetAddress
@@ -33,7 +75,7 @@ func (h *Host) externalSettings() modules.HostExternalSettings {
 		netAddr = h.autoAddress
 	}
 	return modules.HostExternalSettings{
-		AcceptingContracts:   h.settings.AcceptingContracts,
+		AcceptingContracts:   h.settings.AcceptingContracts && !h.maintenance && !h.decommissioning(),
 		MaxDownloadBatchSize: h.settings.MaxDownloadBatchSize,
 		MaxDuration:          h.settings.MaxDuration,
 		MaxReviseBatchSize:   h.settings.MaxReviseBatchSize,
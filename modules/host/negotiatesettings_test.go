@@ -0,0 +1,75 @@
+package host
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// fixedCapacityStorageManager is a storage manager with a single storage
+// folder of a fixed capacity, none of which has been used.
+type fixedCapacityStorageManager struct {
+	modules.StorageManager
+	capacity uint64
+}
+
+// StorageFolders returns the single storage folder of the storage manager.
+func (sm fixedCapacityStorageManager) StorageFolders() []modules.StorageFolderMetadata {
+	return []modules.StorageFolderMetadata{{
+		Capacity:          sm.capacity,
+		CapacityRemaining: sm.capacity,
+	}}
+}
+
+// TestReserveStorageConcurrent hammers a host that is at capacity with
+// concurrent reservations, checking that exactly as many uploads are accepted
+// as there is room for.
+func TestReserveStorageConcurrent(t *testing.T) {
+	const sectors = 10
+	h := &Host{
+		StorageManager: fixedCapacityStorageManager{capacity: sectors * modules.SectorSize},
+	}
+
+	// Try to reserve a sector from many more threads than there are sectors.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var accepted, rejected int
+	for i := 0; i < sectors*5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := h.managedReserveStorage(modules.SectorSize)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				accepted++
+			} else if err == errInsufficientStorage {
+				rejected++
+			} else {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != sectors || rejected != sectors*4 {
+		t.Fatalf("expected %v accepted and %v rejected reservations, got %v and %v", sectors, sectors*4, accepted, rejected)
+	}
+	if rs := h.externalSettings().RemainingStorage; rs != 0 {
+		t.Fatal("reserved storage should not be advertised as remaining:", rs)
+	}
+
+	// Uploads without new sectors should still be accepted.
+	if err := h.managedReserveStorage(0); err != nil {
+		t.Fatal(err)
+	}
+
+	// Releasing a reservation should make room for exactly one more sector.
+	h.managedReleaseStorage(modules.SectorSize)
+	if err := h.managedReserveStorage(2 * modules.SectorSize); err != errInsufficientStorage {
+		t.Fatal("expected errInsufficientStorage, got", err)
+	}
+	if err := h.managedReserveStorage(modules.SectorSize); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -172,6 +172,9 @@ func (h *Host) initNetworking(address string) (err error) {
 		<-threadedListenerClosedChan
 	})
 
+	// Apply the per-renter limits to incoming connections.
+	h.renters.setLimits(h.settings.MaxRenterConns, h.settings.MaxBandwidth)
+
 	// Set the initial working state of the host
 	h.workingStatus = modules.HostWorkingStatusChecking
 
@@ -243,6 +246,7 @@ func (h *Host) initNetworking(address string) (err error) {
 // threadedHandleConn handles an incoming connection to the host, typically an
 // RPC.
 func (h *Host) threadedHandleConn(conn net.Conn) {
+	defer atomic.AddUint64(&h.atomicOpenConns, ^uint64(0))
 	err := h.tg.Add()
 	if err != nil {
 		return
@@ -262,7 +266,9 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}()
 
 	// Set an initial duration that is generous, but finite. RPCs can extend
-	// this if desired.
+	// this if desired. Reads and writes are further limited by the
+	// connection timeouts, and the renter must send the specifier within the
+	// idle timeout.
 	err = conn.SetDeadline(time.Now().Add(5 * time.Minute))
 	if err != nil {
 		h.log.Println("WARN: could not set deadline on connection:", err)
@@ -293,6 +299,8 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	case modules.RPCSettings:
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = extendErr("incoming RPCSettings failed: ", h.managedRPCSettings(conn))
+	case modules.RPCDecommission:
+		err = extendErr("incoming RPCDecommission failed: ", h.managedRPCDecommission(conn))
 	case rpcSettingsDeprecated:
 		h.log.Debugln("Received deprecated settings call")
 	default:
@@ -301,6 +309,7 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}
 	if err != nil {
 		atomic.AddUint64(&h.atomicErroredCalls, 1)
+		h.managedRecordRPCError(id, modules.NetAddress(conn.RemoteAddr().String()), err)
 		err = extendErr("error with "+conn.RemoteAddr().String()+": ", err)
 		h.managedLogError(err)
 	}
@@ -319,7 +328,24 @@ func (h *Host) threadedListen(closeChan chan struct{}) {
 			return
 		}
 
-		go h.threadedHandleConn(conn)
+		// Refuse the connection if the host is already handling as many
+		// connections as MaxOpenConns allows, so that a flood of
+		// connections cannot exhaust the host's resources.
+		h.mu.RLock()
+		settings := h.settings
+		h.mu.RUnlock()
+		if atomic.LoadUint64(&h.atomicOpenConns) >= settings.MaxOpenConns {
+			h.log.Debugln("WARN: too many open connections, refusing connection from", conn.RemoteAddr())
+			conn.Close()
+		} else if rc, err := h.renters.newConn(conn); err != nil {
+			// The connection would exceed the connection limit of the
+			// renter's IP address.
+			h.log.Debugln("WARN: refusing connection from", conn.RemoteAddr(), "-", err)
+			conn.Close()
+		} else {
+			atomic.AddUint64(&h.atomicOpenConns, 1)
+			go h.threadedHandleConn(newTimeoutConn(rc, settings.ConnIdleTimeout, settings.ConnReadTimeout, settings.ConnWriteTimeout))
+		}
 
 		// Soft-sleep to ratelimit the number of incoming connections.
 		select {
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -29,6 +30,20 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Announcements.
+	AnnouncementMetrics    modules.HostAnnouncementMetrics `json:"announcementmetrics"`
+	AnnounceFeePeriodStart time.Time                       `json:"announcefeeperiodstart"`
+
+	// Collateral reserve.
+	CollateralReserveAddress types.UnlockHash        `json:"collateralreserveaddress"`
+	CollateralReserveOutputs []types.SiacoinOutputID `json:"collateralreserveoutputs"`
+
+	// Decommissioning.
+	DecommissionDeadline types.BlockHeight `json:"decommissiondeadline"`
+
+	// Maintenance mode.
+	Maintenance bool `json:"maintenance"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -47,6 +62,20 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Announcements.
+		AnnouncementMetrics:    h.announcementMetrics,
+		AnnounceFeePeriodStart: h.announceFeePeriodStart,
+
+		// Collateral reserve.
+		CollateralReserveAddress: h.collateralReserveAddress,
+		CollateralReserveOutputs: h.collateralReserveOutputIDs(),
+
+		// Decommissioning.
+		DecommissionDeadline: h.decommissionDeadline,
+
+		// Maintenance mode.
+		Maintenance: h.maintenance,
 	}
 }
 
@@ -59,6 +88,19 @@ func (h *Host) establishDefaults() error {
 		MaxDuration:          defaultMaxDuration,
 		MaxReviseBatchSize:   uint64(defaultMaxReviseBatchSize),
 		WindowSize:           defaultWindowSize,
+		ProofWindowBuffer:    defaultProofWindowBuffer,
+
+		MaxOpenConns:     defaultMaxOpenConns,
+		ConnIdleTimeout:  defaultConnIdleTimeout,
+		ConnReadTimeout:  defaultConnReadTimeout,
+		ConnWriteTimeout: defaultConnWriteTimeout,
+		MaxRenterConns:   defaultMaxRenterConns,
+
+		DataRetention: modules.HostRetainNone,
+
+		MinAnnounceInterval: defaultMinAnnounceInterval,
+		MaxAnnounceFees:     defaultMaxAnnounceFees,
+		AnnounceFeePeriod:   defaultAnnounceFeePeriod,
 
 		Collateral:       defaultCollateral,
 		CollateralBudget: defaultCollateralBudget,
@@ -106,7 +148,49 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.log.Printf("WARN: NetAddress '%v' loaded from persist is invalid: %v", p.Settings.NetAddress, err)
 		h.settings.NetAddress = ""
 	}
+	// COMPATv1.3.1 - settings saved before the connection limits were added
+	// have no open connection limit or timeouts.
+	if h.settings.MaxOpenConns == 0 {
+		h.settings.MaxOpenConns = defaultMaxOpenConns
+	}
+	if h.settings.ConnIdleTimeout == 0 {
+		h.settings.ConnIdleTimeout = defaultConnIdleTimeout
+	}
+	if h.settings.ConnReadTimeout == 0 {
+		h.settings.ConnReadTimeout = defaultConnReadTimeout
+	}
+	if h.settings.ConnWriteTimeout == 0 {
+		h.settings.ConnWriteTimeout = defaultConnWriteTimeout
+	}
+	// COMPATv1.3.1 - settings saved before the per-renter limits were added
+	// have no renter connection limit.
+	if h.settings.MaxRenterConns == 0 {
+		h.settings.MaxRenterConns = defaultMaxRenterConns
+	}
+	// COMPATv1.3.1 - settings saved before the data retention policy was
+	// added deleted the data of contracts as soon as they ended.
+	if h.settings.DataRetention == "" {
+		h.settings.DataRetention = modules.HostRetainNone
+	}
+	// COMPATv1.3.1 - settings saved before announcements were limited have
+	// no announcement fee period.
+	if h.settings.AnnounceFeePeriod == 0 {
+		h.settings.MinAnnounceInterval = defaultMinAnnounceInterval
+		h.settings.MaxAnnounceFees = defaultMaxAnnounceFees
+		h.settings.AnnounceFeePeriod = defaultAnnounceFeePeriod
+	}
+	// COMPATv1.3.1 - settings saved before the proof window buffer was added
+	// have no buffer. The default is only used if it fits the window size.
+	if h.settings.ProofWindowBuffer == 0 && defaultProofWindowBuffer+resubmissionTimeout <= h.settings.WindowSize {
+		h.settings.ProofWindowBuffer = defaultProofWindowBuffer
+	}
 	h.unlockHash = p.UnlockHash
+	h.announcementMetrics = p.AnnouncementMetrics
+	h.announceFeePeriodStart = p.AnnounceFeePeriodStart
+	h.collateralReserveAddress = p.CollateralReserveAddress
+	h.loadCollateralReserve(p.CollateralReserveOutputs)
+	h.decommissionDeadline = p.DecommissionDeadline
+	h.maintenance = p.Maintenance
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -130,6 +214,7 @@ func (h *Host) initDB() (err error) {
 		buckets := [][]byte{
 			bucketActionItems,
 			bucketStorageObligations,
+			bucketRetainedSectors,
 		}
 		for _, bucket := range buckets {
 			_, err := tx.CreateBucketIfNotExists(bucket)
@@ -193,6 +278,22 @@ func (h *Host) load() error {
 		return err
 	}
 
+	// Count the storage held by the retained sectors of ended obligations.
+	h.retainedStorage = 0
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRetainedSectors).ForEach(func(_, v []byte) error {
+			var rs retainedSectors
+			if err := json.Unmarshal(v, &rs); err != nil {
+				return err
+			}
+			h.retainedStorage += uint64(len(rs.SectorRoots)) * modules.SectorSize
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
 	return h.initConsensusSubscription()
 }
 
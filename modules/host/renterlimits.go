@@ -0,0 +1,219 @@
+package host
+
+import (
+	"net"
+	"sync"
+
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// errTooManyRenterConns is returned when a renter already has as many open
+// connections to the host as the MaxRenterConns setting allows.
+var errTooManyRenterConns = ErrorCommunication("renter has too many open connections to the host")
+
+// A renterLimiter enforces the per-renter connection limit and divides the
+// host's bandwidth evenly among the renters that have connections open, so
+// that a single renter cannot starve the others. Renters are identified by
+// the public key of their contracts once an RPC has proven ownership of a
+// contract, and by their IP address until then.
+type renterLimiter struct {
+	maxConns  uint64
+	bandwidth uint64 // bytes per second shared by all renters, 0 for no limit
+	renters   map[string]*renterState
+
+	mu   sync.Mutex
+	stop <-chan struct{}
+}
+
+// renterState tracks the connections and bandwidth use of a single renter.
+// The renter's share of the bandwidth is enforced by its RateLimit, whose
+// limit is adjusted whenever a renter joins or leaves.
+type renterState struct {
+	conns     uint64
+	bandwidth *siasync.RateLimit
+}
+
+// A renterConn is an incoming connection that counts towards the connection
+// limit of its renter and shares its renter's bandwidth.
+type renterConn struct {
+	net.Conn
+	key      string // guarded by limiter.mu
+	limiter  *renterLimiter
+	released bool // guarded by limiter.mu
+}
+
+// newRenterLimiter returns a renterLimiter without limits. Transfers that are
+// waiting for bandwidth stop waiting when stop is closed.
+func newRenterLimiter(stop <-chan struct{}) *renterLimiter {
+	return &renterLimiter{
+		renters: make(map[string]*renterState),
+		stop:    stop,
+	}
+}
+
+// renterKey returns the key that identifies the renter with the public key
+// spk.
+func renterKey(spk types.SiaPublicKey) string {
+	return spk.String()
+}
+
+// addrKey returns the key that identifies a renter that has not proven
+// ownership of a contract yet.
+func addrKey(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
+
+// setLimits updates the connection and bandwidth limits.
+func (rl *renterLimiter) setLimits(maxConns, bandwidth uint64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.maxConns = maxConns
+	rl.bandwidth = bandwidth
+	rl.allocate()
+}
+
+// allocate divides the bandwidth evenly among the renters. The caller must
+// hold the lock.
+func (rl *renterLimiter) allocate() {
+	if len(rl.renters) == 0 {
+		return
+	}
+	share := int64(rl.bandwidth / uint64(len(rl.renters)))
+	if rl.bandwidth > 0 && share == 0 {
+		// A limit of zero would disable limiting, so every renter gets at
+		// least one byte per second.
+		share = 1
+	}
+	for _, rs := range rl.renters {
+		rs.bandwidth.AdjustLimit(share)
+	}
+}
+
+// register adds a connection to the renter identified by key, returning
+// errTooManyRenterConns if the renter is at its connection limit. The caller
+// must hold the lock.
+func (rl *renterLimiter) register(key string) error {
+	rs, exists := rl.renters[key]
+	if rl.maxConns > 0 && exists && rs.conns >= rl.maxConns {
+		return errTooManyRenterConns
+	}
+	if !exists {
+		rs = &renterState{bandwidth: siasync.NewRateLimit(0)}
+		rl.renters[key] = rs
+		rl.allocate()
+	}
+	rs.conns++
+	return nil
+}
+
+// unregister removes a connection from the renter identified by key. The
+// caller must hold the lock.
+func (rl *renterLimiter) unregister(key string) {
+	rs, exists := rl.renters[key]
+	if !exists {
+		return
+	}
+	rs.conns--
+	if rs.conns == 0 {
+		delete(rl.renters, key)
+		rl.allocate()
+	}
+}
+
+// newConn registers an incoming connection under the address of the remote
+// peer.
+func (rl *renterLimiter) newConn(conn net.Conn) (*renterConn, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	key := addrKey(conn.RemoteAddr())
+	if err := rl.register(key); err != nil {
+		return nil, err
+	}
+	return &renterConn{
+		Conn:    conn,
+		key:     key,
+		limiter: rl,
+	}, nil
+}
+
+// wait charges n bytes to the renter identified by key, and blocks until the
+// renter's share of the bandwidth allows the transfer.
+func (rl *renterLimiter) wait(key string, n int) {
+	rl.mu.Lock()
+	rs, exists := rl.renters[key]
+	rl.mu.Unlock()
+	if !exists || n == 0 {
+		return
+	}
+	rs.bandwidth.Wait(n, rl.stop)
+}
+
+// identify moves the connection to the renter identified by key, returning
+// errTooManyRenterConns if that renter is at its connection limit.
+func (rc *renterConn) identify(key string) error {
+	rl := rc.limiter
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rc.released || rc.key == key {
+		return nil
+	}
+	if err := rl.register(key); err != nil {
+		return err
+	}
+	rl.unregister(rc.key)
+	rc.key = key
+	return nil
+}
+
+// currentKey returns the key of the renter that the connection belongs to.
+func (rc *renterConn) currentKey() string {
+	rc.limiter.mu.Lock()
+	defer rc.limiter.mu.Unlock()
+	return rc.key
+}
+
+// Read reads from the connection, then waits until the renter's bandwidth
+// allows the data that was read.
+func (rc *renterConn) Read(b []byte) (int, error) {
+	n, err := rc.Conn.Read(b)
+	rc.limiter.wait(rc.currentKey(), n)
+	return n, err
+}
+
+// Write writes to the connection, then waits until the renter's bandwidth
+// allows the data that was written.
+func (rc *renterConn) Write(b []byte) (int, error) {
+	n, err := rc.Conn.Write(b)
+	rc.limiter.wait(rc.currentKey(), n)
+	return n, err
+}
+
+// Close closes the connection and removes it from its renter.
+func (rc *renterConn) Close() error {
+	rl := rc.limiter
+	rl.mu.Lock()
+	if !rc.released {
+		rc.released = true
+		rl.unregister(rc.key)
+	}
+	rl.mu.Unlock()
+	return rc.Conn.Close()
+}
+
+// identifyRenter attributes conn to the renter with the public key spk, so
+// that it counts towards that renter's limits instead of those of its IP
+// address. Connections that are not subject to renter limits are ignored.
+func identifyRenter(conn net.Conn, spk types.SiaPublicKey) error {
+	if tc, ok := conn.(*timeoutConn); ok {
+		conn = tc.Conn
+	}
+	if rc, ok := conn.(*renterConn); ok {
+		return rc.identify(renterKey(spk))
+	}
+	return nil
+}
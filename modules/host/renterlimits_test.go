@@ -0,0 +1,135 @@
+package host
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// limitedPipe returns a renterConn registered with rl, whose remote end is
+// drained in the background.
+func limitedPipe(t *testing.T, rl *renterLimiter) *renterConn {
+	local, remote := net.Pipe()
+	go io.Copy(ioutil.Discard, remote)
+	rc, err := rl.newConn(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rc
+}
+
+// TestRenterConnLimit checks that renters are limited to MaxRenterConns
+// connections, and that identified connections count towards the renter's
+// public key rather than its address.
+func TestRenterConnLimit(t *testing.T) {
+	rl := newRenterLimiter(nil)
+	rl.setLimits(2, 0)
+	rc1 := limitedPipe(t, rl)
+	rc2 := limitedPipe(t, rl)
+	local, _ := net.Pipe()
+	if _, err := rl.newConn(local); err != errTooManyRenterConns {
+		t.Fatal("expected errTooManyRenterConns, got", err)
+	}
+
+	// Moving the connections to renters frees up the address.
+	renter1 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	renter2 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	if err := rc1.identify(renterKey(renter1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := identifyRenter(newTimeoutConn(rc2, time.Minute, time.Minute, time.Minute), renter1); err != nil {
+		t.Fatal(err)
+	}
+	rc3 := limitedPipe(t, rl)
+	if err := rc3.identify(renterKey(renter1)); err != errTooManyRenterConns {
+		t.Fatal("expected errTooManyRenterConns, got", err)
+	}
+	if err := rc3.identify(renterKey(renter2)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing a connection releases its slot exactly once.
+	rc1.Close()
+	rc1.Close()
+	if n := rl.renters[renterKey(renter1)].conns; n != 1 {
+		t.Fatal("expected 1 connection for the renter, got", n)
+	}
+	rc2.Close()
+	rc3.Close()
+	if len(rl.renters) != 0 {
+		t.Fatal("closed connections should not be tracked, got", len(rl.renters))
+	}
+}
+
+// TestRenterBandwidthFairness simulates two renters competing for the host's
+// bandwidth, one of them over several connections, and checks that each
+// renter receives an equal share.
+func TestRenterBandwidthFairness(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	const bandwidth = 1 << 22 // 4 MiB/s
+	const duration = time.Second
+	rl := newRenterLimiter(nil)
+	rl.setLimits(10, bandwidth)
+
+	renters := []struct {
+		key   types.SiaPublicKey
+		conns int
+		sent  uint64
+	}{
+		{key: types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}, conns: 4},
+		{key: types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}, conns: 1},
+	}
+	var conns []*renterConn
+	for i := range renters {
+		for j := 0; j < renters[i].conns; j++ {
+			rc := limitedPipe(t, rl)
+			if err := rc.identify(renterKey(renters[i].key)); err != nil {
+				t.Fatal(err)
+			}
+			conns = append(conns, rc)
+		}
+	}
+
+	// Every connection writes as fast as it can until the deadline.
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(duration)
+	buf := make([]byte, 1<<14)
+	i := 0
+	for r := range renters {
+		for j := 0; j < renters[r].conns; j++ {
+			rc := conns[i]
+			i++
+			wg.Add(1)
+			go func(sent *uint64) {
+				defer wg.Done()
+				for time.Now().Before(deadline) {
+					n, err := rc.Write(buf)
+					if err != nil {
+						return
+					}
+					atomic.AddUint64(sent, uint64(n))
+				}
+			}(&renters[r].sent)
+		}
+	}
+	wg.Wait()
+	for _, rc := range conns {
+		rc.Close()
+	}
+
+	sent1, sent2 := float64(renters[0].sent), float64(renters[1].sent)
+	if ratio := sent1 / sent2; ratio < 0.75 || ratio > 1.33 {
+		t.Fatalf("bandwidth was not divided fairly: %v bytes vs %v bytes", sent1, sent2)
+	}
+	if total := sent1 + sent2; total > 1.5*bandwidth*duration.Seconds() {
+		t.Fatalf("renters exceeded the bandwidth limit: %v bytes in %v", total, duration)
+	}
+}
@@ -0,0 +1,185 @@
+package host
+
+// retention.go implements the host's data retention policy. When a storage
+// obligation ends, its sectors are either removed straight away or recorded
+// in the retained sectors bucket. Retained sectors are removed by the
+// maintenance done on every consensus change once the policy no longer
+// retains them, or earlier if their storage is needed for an upload.
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// errBadDataRetention is returned if the data retention policy is not
+	// one of the known policies, or if the blocks policy is configured to
+	// retain data for zero blocks.
+	errBadDataRetention = errors.New("data retention must be none, untilfull, or blocks with a nonzero number of blocks")
+)
+
+// retainedSectors are the sectors of a storage obligation that has ended,
+// kept according to the data retention policy. EndHeight is the height at
+// which the obligation ended.
+type retainedSectors struct {
+	EndHeight   types.BlockHeight
+	SectorRoots []crypto.Hash
+}
+
+// validDataRetention returns an error if the data retention settings are not
+// valid.
+func validDataRetention(settings modules.HostInternalSettings) error {
+	switch settings.DataRetention {
+	case modules.HostRetainNone, modules.HostRetainUntilFull:
+		return nil
+	case modules.HostRetainBlocks:
+		if settings.DataRetentionBlocks == 0 {
+			return errBadDataRetention
+		}
+		return nil
+	default:
+		return errBadDataRetention
+	}
+}
+
+// retainsData returns true if the sectors of a storage obligation that has
+// ended with the given status should be retained. The sectors of obligations
+// that were rejected are never retained, since the renter never had a
+// contract for them.
+func (h *Host) retainsData(sos storageObligationStatus) bool {
+	if sos == obligationRejected {
+		return false
+	}
+	return h.settings.DataRetention == modules.HostRetainBlocks || h.settings.DataRetention == modules.HostRetainUntilFull
+}
+
+// putRetainedSectors records the sectors of a storage obligation that has
+// ended at the current height.
+func (h *Host) putRetainedSectors(tx *bolt.Tx, soid types.FileContractID, roots []crypto.Hash) error {
+	rsBytes, err := json.Marshal(retainedSectors{
+		EndHeight:   h.blockHeight,
+		SectorRoots: roots,
+	})
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketRetainedSectors).Put(soid[:], rsBytes); err != nil {
+		return err
+	}
+	h.retainedStorage += uint64(len(roots)) * modules.SectorSize
+	return nil
+}
+
+// retainedSectorIDs returns the ids of the storage obligations with retained
+// sectors that satisfy the filter, oldest first.
+func retainedSectorIDs(tx *bolt.Tx, filter func(retainedSectors) bool) ([]types.FileContractID, error) {
+	type entry struct {
+		id types.FileContractID
+		rs retainedSectors
+	}
+	var entries []entry
+	err := tx.Bucket(bucketRetainedSectors).ForEach(func(k, v []byte) error {
+		var e entry
+		copy(e.id[:], k)
+		if err := json.Unmarshal(v, &e.rs); err != nil {
+			return err
+		}
+		if filter(e.rs) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].rs.EndHeight < entries[j].rs.EndHeight
+	})
+	ids := make([]types.FileContractID, len(entries))
+	for i, e := range entries {
+		ids[i] = e.id
+	}
+	return ids, nil
+}
+
+// releaseRetainedSectors removes the retained sectors of a storage
+// obligation from the host.
+func (h *Host) releaseRetainedSectors(tx *bolt.Tx, soid types.FileContractID) error {
+	b := tx.Bucket(bucketRetainedSectors)
+	var rs retainedSectors
+	if err := json.Unmarshal(b.Get(soid[:]), &rs); err != nil {
+		return err
+	}
+	// Error is not checked, we want to call remove on every sector even if
+	// there are problems - disk health information will be updated.
+	_ = h.RemoveSectorBatch(rs.SectorRoots)
+	if err := b.Delete(soid[:]); err != nil {
+		return err
+	}
+	size := uint64(len(rs.SectorRoots)) * modules.SectorSize
+	if size > h.retainedStorage {
+		size = h.retainedStorage
+	}
+	h.retainedStorage -= size
+	return nil
+}
+
+// pruneRetainedSectors removes the retained sectors that the data retention
+// policy no longer retains. It is called on every consensus change.
+func (h *Host) pruneRetainedSectors(tx *bolt.Tx) error {
+	policy, blocks := h.settings.DataRetention, h.settings.DataRetentionBlocks
+	if policy == modules.HostRetainUntilFull {
+		return nil
+	}
+	ids, err := retainedSectorIDs(tx, func(rs retainedSectors) bool {
+		return policy != modules.HostRetainBlocks || rs.EndHeight+blocks <= h.blockHeight
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := h.releaseRetainedSectors(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// freeRetainedStorage removes retained sectors, oldest first, until at least
+// size bytes of storage are available beyond the storage reserved by uploads
+// in progress. It returns true if enough storage is available.
+func (h *Host) freeRetainedStorage(size uint64) bool {
+	available := func() bool {
+		_, remaining := h.capacity()
+		return h.reservedStorage <= remaining && size <= remaining-h.reservedStorage
+	}
+	if available() {
+		return true
+	}
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		ids, err := retainedSectorIDs(tx, func(retainedSectors) bool { return true })
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if available() {
+				return nil
+			}
+			if err := h.releaseRetainedSectors(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		h.log.Println("Error removing retained sectors:", err)
+	}
+	return available()
+}
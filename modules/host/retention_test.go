@@ -0,0 +1,157 @@
+package host
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// retentionStorage is a storage manager with a single storage folder, in
+// which removing a sector frees a sector of space.
+type retentionStorage struct {
+	modules.StorageManager
+	remaining uint64
+}
+
+// RemoveSectorBatch frees the space of the removed sectors.
+func (rs *retentionStorage) RemoveSectorBatch(roots []crypto.Hash) error {
+	rs.remaining += uint64(len(roots)) * modules.SectorSize
+	return nil
+}
+
+// StorageFolders returns the single storage folder.
+func (rs *retentionStorage) StorageFolders() []modules.StorageFolderMetadata {
+	return []modules.StorageFolderMetadata{{
+		Capacity:          100 * modules.SectorSize,
+		CapacityRemaining: rs.remaining,
+	}}
+}
+
+// TestDataRetention checks that the data of ended storage obligations is
+// removed according to the data retention policy, and that retained data is
+// removed, oldest first, when its storage is needed.
+func TestDataRetention(t *testing.T) {
+	dir := build.TempDir(modules.HostDir, t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(dir, dbFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketRetainedSectors)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := new(retentionStorage)
+	h := &Host{
+		StorageManager: storage,
+		db:             db,
+		log:            persist.NewLogger(ioutil.Discard),
+		settings: modules.HostInternalSettings{
+			DataRetention:       modules.HostRetainBlocks,
+			DataRetentionBlocks: 10,
+		},
+	}
+
+	// Invalid policies are rejected.
+	for _, s := range []modules.HostInternalSettings{
+		{DataRetention: "forever"},
+		{DataRetention: modules.HostRetainBlocks},
+	} {
+		if err := validDataRetention(s); err != errBadDataRetention {
+			t.Fatalf("expected errBadDataRetention for %+v, got %v", s, err)
+		}
+	}
+	if err := validDataRetention(h.settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rejected obligations are never retained.
+	if h.retainsData(obligationRejected) || !h.retainsData(obligationSucceeded) {
+		t.Fatal("wrong obligations are retained")
+	}
+
+	// Retain one sector of an obligation ending at height 0, and two of one
+	// ending at height 5.
+	retain := func(id types.FileContractID, height types.BlockHeight, sectors int) {
+		h.blockHeight = height
+		err := h.db.Update(func(tx *bolt.Tx) error {
+			return h.putRetainedSectors(tx, id, make([]crypto.Hash, sectors))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	retain(types.FileContractID{1}, 0, 1)
+	retain(types.FileContractID{2}, 5, 2)
+	if h.retainedStorage != 3*modules.SectorSize {
+		t.Fatal("expected 3 retained sectors, got", h.retainedStorage/modules.SectorSize)
+	}
+
+	// The storage of retained sectors is advertised as remaining storage.
+	if rs := h.externalSettings().RemainingStorage; rs != 3*modules.SectorSize {
+		t.Fatal("expected 3 sectors of remaining storage, got", rs/modules.SectorSize)
+	}
+	prune := func(height types.BlockHeight) {
+		h.blockHeight = height
+		err := h.db.Update(func(tx *bolt.Tx) error {
+			return h.pruneRetainedSectors(tx)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first obligation's sectors are removed once they have been
+	// retained for DataRetentionBlocks blocks.
+	prune(9)
+	if storage.remaining != 0 {
+		t.Fatal("sectors removed before the retention period ended")
+	}
+	prune(10)
+	if storage.remaining != modules.SectorSize {
+		t.Fatal("sectors not removed after the retention period ended")
+	}
+
+	// Under the untilfull policy, sectors are only removed when their storage
+	// is needed.
+	h.settings.DataRetention = modules.HostRetainUntilFull
+	prune(100)
+	if storage.remaining != modules.SectorSize {
+		t.Fatal("sectors removed by the untilfull policy")
+	}
+	if !h.freeRetainedStorage(modules.SectorSize) || storage.remaining != modules.SectorSize {
+		t.Fatal("sectors removed although enough storage was available")
+	}
+	if !h.freeRetainedStorage(2 * modules.SectorSize) {
+		t.Fatal("retained sectors were not removed to make space")
+	}
+	if storage.remaining != 3*modules.SectorSize {
+		t.Fatal("expected 3 sectors of free space, got", storage.remaining/modules.SectorSize)
+	}
+	if h.freeRetainedStorage(4 * modules.SectorSize) {
+		t.Fatal("reported more storage than is available")
+	}
+
+	// Under the none policy, all retained sectors are removed.
+	retain(types.FileContractID{3}, 100, 1)
+	h.settings.DataRetention = modules.HostRetainNone
+	prune(100)
+	if storage.remaining != 4*modules.SectorSize || h.retainedStorage != 0 {
+		t.Fatal("retained sectors not removed by the none policy")
+	}
+}
@@ -0,0 +1,85 @@
+package host
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// rpcErrorType returns the category of an error returned by an RPC, as
+// reported in modules.RPCError.
+func rpcErrorType(err error) string {
+	switch err.(type) {
+	case ErrorCommunication:
+		return "communication"
+	case ErrorConnection:
+		return "connection"
+	case ErrorConsensus:
+		return "consensus"
+	case ErrorInternal:
+		return "internal"
+	default:
+		return "other"
+	}
+}
+
+// scrubErrorMessage prepares an error message to be shown to the host
+// operator. Errors from decoding renter input can contain arbitrary bytes
+// sent by the renter, so unprintable characters are replaced, and the message
+// is truncated to maxRPCErrorLen bytes.
+func scrubErrorMessage(msg string) string {
+	msg = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '?'
+		}
+		return r
+	}, msg)
+	if len(msg) <= maxRPCErrorLen {
+		return msg
+	}
+	// Don't cut a multi-byte character in half.
+	cut := maxRPCErrorLen
+	for cut > 0 && !utf8.RuneStart(msg[cut]) {
+		cut--
+	}
+	return msg[:cut] + "..."
+}
+
+// managedRecordRPCError adds a failed RPC call to the host's recent errors,
+// replacing the oldest error once maxRecentErrors have been recorded.
+func (h *Host) managedRecordRPCError(rpc types.Specifier, renter modules.NetAddress, err error) {
+	rpcErr := modules.RPCError{
+		Time:          time.Now(),
+		RPC:           rpc.String(),
+		RenterAddress: renter,
+		Type:          rpcErrorType(err),
+		Error:         scrubErrorMessage(err.Error()),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.recentErrors) < maxRecentErrors {
+		h.recentErrors = append(h.recentErrors, rpcErr)
+	} else {
+		h.recentErrors[h.recentErrorsIndex] = rpcErr
+	}
+	h.recentErrorsIndex = (h.recentErrorsIndex + 1) % maxRecentErrors
+}
+
+// RecentErrors returns the most recent RPC calls to the host that failed,
+// newest first. At most maxRecentErrors are kept, and they are not persisted
+// across restarts.
+func (h *Host) RecentErrors() []modules.RPCError {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	errs := make([]modules.RPCError, 0, len(h.recentErrors))
+	for i := 1; i <= len(h.recentErrors); i++ {
+		j := (h.recentErrorsIndex - i + len(h.recentErrors)) % len(h.recentErrors)
+		errs = append(errs, h.recentErrors[j])
+	}
+	return errs
+}
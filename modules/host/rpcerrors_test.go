@@ -0,0 +1,67 @@
+package host
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestScrubErrorMessage checks that unprintable characters are replaced and
+// that long messages are truncated.
+func TestScrubErrorMessage(t *testing.T) {
+	if s := scrubErrorMessage("bad\x00input\n\xff"); s != "bad?input??" {
+		t.Error("unprintable characters not replaced:", strconv.Quote(s))
+	}
+	long := strings.Repeat("é", maxRPCErrorLen)
+	s := scrubErrorMessage(long)
+	if len(s) > maxRPCErrorLen+len("...") || !strings.HasSuffix(s, "...") {
+		t.Error("long message not truncated:", len(s))
+	}
+	if strings.ContainsRune(s, '?') {
+		t.Error("multi-byte character was cut in half")
+	}
+}
+
+// TestRecentErrors checks that the host keeps only the most recent RPC errors
+// and returns them newest first.
+func TestRecentErrors(t *testing.T) {
+	h := new(Host)
+	if len(h.RecentErrors()) != 0 {
+		t.Fatal("new host should have no recent errors")
+	}
+
+	addr := modules.NetAddress("127.0.0.1:1234")
+	h.managedRecordRPCError(modules.RPCSettings, addr, ErrorCommunication("first"))
+	h.managedRecordRPCError(modules.RPCDownload, addr, ErrorConnection("second"))
+	errs := h.RecentErrors()
+	if len(errs) != 2 {
+		t.Fatal("expected 2 recent errors, got", len(errs))
+	}
+	if errs[0].Error != "connection error: second" || errs[0].Type != "connection" || errs[0].RPC != modules.RPCDownload.String() {
+		t.Error("newest error reported incorrectly:", errs[0])
+	}
+	if errs[1].Error != "communication error: first" || errs[1].Type != "communication" || errs[1].RenterAddress != addr {
+		t.Error("oldest error reported incorrectly:", errs[1])
+	}
+
+	// Record more than maxRecentErrors errors; only the last maxRecentErrors
+	// should be kept.
+	for i := 0; i < maxRecentErrors+5; i++ {
+		h.managedRecordRPCError(modules.RPCSettings, addr, errors.New(strconv.Itoa(i)))
+	}
+	errs = h.RecentErrors()
+	if len(errs) != maxRecentErrors {
+		t.Fatal("expected", maxRecentErrors, "recent errors, got", len(errs))
+	}
+	for i, e := range errs {
+		if exp := strconv.Itoa(maxRecentErrors + 4 - i); e.Error != exp {
+			t.Fatalf("error %v: expected %v, got %v", i, exp, e.Error)
+		}
+		if e.Type != "other" {
+			t.Fatal("expected type other, got", e.Type)
+		}
+	}
+}
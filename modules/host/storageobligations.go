@@ -94,6 +94,21 @@ var (
 	// revisionSubmissionBuffer blocks.
 	errNoBuffer = errors.New("file contract rejected because storage proof window is too close")
 
+	// errBadConnSettings is returned if the host is configured without a
+	// connection backlog, without a renter connection limit, or with a
+	// connection timeout of zero, which would leave it unable to accept or
+	// serve connections.
+	errBadConnSettings = errors.New("accept backlog, renter connection limit and connection timeouts must be nonzero")
+
+	// errBadAnnounceSettings is returned if the host is given a negative
+	// announcement interval or an announcement fee period that is not
+	// positive.
+	errBadAnnounceSettings = errors.New("announcement interval must not be negative and announcement fee period must be positive")
+
+	// errBadProofWindowBuffer is returned if the proof window buffer leaves no
+	// time in the host's minimum proof window to submit a storage proof.
+	errBadProofWindowBuffer = errors.New("proof window buffer plus the resubmission timeout must not exceed the window size")
+
 	// errNoStorageObligation is returned if the requested storage obligation
 	// is not found in the database.
 	errNoStorageObligation = errors.New("storage obligation not found in database")
@@ -145,6 +160,18 @@ type storageObligation struct {
 	ProofConstructed    bool
 	ProofConfirmed      bool
 	ObligationStatus    storageObligationStatus
+
+	// RevenueHeight is the block height at which the revenue of the storage
+	// obligation was realized. It is only set for obligations that
+	// succeeded, and is zero for obligations that succeeded before it was
+	// tracked.
+	RevenueHeight types.BlockHeight
+
+	// ProofVersion is the version of the storage proof construction that is
+	// used to prove the obligation. It is set to currentProofVersion when
+	// the contract is formed and never changes, so that obligations keep
+	// being proven the same way when the construction is upgraded.
+	ProofVersion uint64
 }
 
 // getStorageObligation fetches a storage obligation from the database tx.
@@ -275,6 +302,19 @@ func (so storageObligation) proofDeadline() types.BlockHeight {
 	return so.OriginTransactionSet[len(so.OriginTransactionSet)-1].FileContracts[0].WindowEnd
 }
 
+// proofSubmissionDeadline returns the height by which the host aims to have
+// the storage proof of the obligation confirmed, leaving 'buffer' blocks
+// before the end of the proof window to protect against reorgs. The deadline
+// is never earlier than the first attempt to submit the proof.
+func (so storageObligation) proofSubmissionDeadline(buffer types.BlockHeight) types.BlockHeight {
+	first := so.expiration() + resubmissionTimeout
+	deadline := so.proofDeadline()
+	if deadline < first+buffer {
+		return first
+	}
+	return deadline - buffer
+}
+
 // value returns the value of fulfilling the storage obligation to the host.
 func (so storageObligation) value() types.Currency {
 	return so.ContractCost.Add(so.PotentialDownloadRevenue).Add(so.PotentialStorageRevenue).Add(so.PotentialUploadRevenue).Add(so.RiskedCollateral)
@@ -409,7 +449,10 @@ func (h *Host) managedAddStorageObligation(so storageObligation) error {
 	// The storage proof should be submitted
 	err5 := h.queueActionItem(so.expiration()+resubmissionTimeout, soid)
 	err6 := h.queueActionItem(so.expiration()+resubmissionTimeout*2, soid) // Paranoia
-	err = composeErrors(err1, err2, err3, err4, err5, err6)
+	// Make a final attempt to get the storage proof confirmed before the
+	// proof window buffer is reached.
+	err7 := h.queueActionItem(so.proofSubmissionDeadline(h.settings.ProofWindowBuffer), soid)
+	err = composeErrors(err1, err2, err3, err4, err5, err6, err7)
 	if err != nil {
 		h.log.Println("Error with transaction set, redacting obligation, id", so.id())
 		return composeErrors(err, h.removeStorageObligation(so, obligationRejected))
@@ -532,8 +575,12 @@ func (h *Host) modifyStorageObligation(so storageObligation, sectorsRemoved []cr
 // either due to failure or success.
 func (h *Host) removeStorageObligation(so storageObligation, sos storageObligationStatus) error {
 	// Error is not checked, we want to call remove on every sector even if
-	// there are problems - disk health information will be updated.
-	_ = h.RemoveSectorBatch(so.SectorRoots)
+	// there are problems - disk health information will be updated. Sectors
+	// that are kept by the data retention policy are removed later.
+	retain := len(so.SectorRoots) > 0 && h.retainsData(sos)
+	if !retain {
+		_ = h.RemoveSectorBatch(so.SectorRoots)
+	}
 
 	// Update the host revenue metrics based on the status of the obligation.
 	if sos == obligationUnresolved {
@@ -568,6 +615,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.StorageRevenue = h.financialMetrics.StorageRevenue.Add(so.PotentialStorageRevenue)
 		h.financialMetrics.DownloadBandwidthRevenue = h.financialMetrics.DownloadBandwidthRevenue.Add(so.PotentialDownloadRevenue)
 		h.financialMetrics.UploadBandwidthRevenue = h.financialMetrics.UploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
+		so.RevenueHeight = h.blockHeight
 	}
 	if sos == obligationFailed {
 		// Remove the obligation statistics as potential risk and income.
@@ -590,8 +638,12 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	// objects with little purpose once storage proofs are no longer needed.
 	h.financialMetrics.ContractCount--
 	so.ObligationStatus = sos
+	roots := so.SectorRoots
 	so.SectorRoots = nil
 	return h.db.Update(func(tx *bolt.Tx) error {
+		if retain && h.putRetainedSectors(tx, so.id(), roots) != nil {
+			_ = h.RemoveSectorBatch(roots)
+		}
 		return putStorageObligation(tx, so)
 	})
 }
@@ -615,6 +667,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 	var so storageObligation
 	h.mu.RLock()
 	blockHeight := h.blockHeight
+	proofWindowBuffer := h.settings.ProofWindowBuffer
 	err = h.db.View(func(tx *bolt.Tx) error {
 		so, err = getStorageObligation(tx, soid)
 		return err
@@ -756,43 +809,19 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			return
 		}
 
-		// Get the index of the segment, and the index of the sector containing
-		// the segment.
+		// Get the index of the challenged segment, and build the proof for it
+		// using the proof version of the obligation.
 		segmentIndex, err := h.cs.StorageProofSegment(so.id())
 		if err != nil {
 			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
 			return
 		}
-		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
-		// Pull the corresponding sector into memory.
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
+		sp, err := h.buildStorageProof(so, segmentIndex)
 		if err != nil {
-			h.log.Debugln(err)
+			h.log.Printf("Host unable to build a storage proof for %v with proof version %v: %v", so.id(), so.ProofVersion, err)
 			return
 		}
 
-		// Build the storage proof for just the sector.
-		sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
-		base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
-
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
-			log2SectorSize++
-		}
-		ct := crypto.NewCachedTree(log2SectorSize)
-		ct.SetIndex(segmentIndex)
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
-		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
-
 		// Create and build the transaction with the storage proof.
 		builder := h.wallet.StartTransaction()
 		_, feeRecommendation := h.tpool.FeeEstimation()
@@ -824,9 +853,14 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
 
 		// Queue another action item to check whether the storage proof
-		// got confirmed.
+		// got confirmed. If there is time before the proof window buffer is
+		// reached, check early so that the proof can be resubmitted if it
+		// was not confirmed or was reverted.
 		h.mu.Lock()
 		err = h.queueActionItem(so.proofDeadline(), so.id())
+		if blockHeight+resubmissionTimeout <= so.proofSubmissionDeadline(proofWindowBuffer) {
+			err = composeErrors(err, h.queueActionItem(blockHeight+resubmissionTimeout, so.id()))
+		}
 		h.mu.Unlock()
 		if err != nil {
 			h.log.Println("Error queuing action item:", err)
@@ -893,3 +927,32 @@ func (h *Host) StorageObligations() (sos []modules.StorageObligation) {
 
 	return sos
 }
+
+// Earnings returns the revenue of all storage obligations that succeeded after
+// the provided block height, including the contract compensation and the
+// storage and bandwidth revenue. A height of zero returns the lifetime
+// earnings of the host. Obligations that succeeded before their revenue height
+// was tracked are only counted in the lifetime earnings.
+func (h *Host) Earnings(since types.BlockHeight) (earnings types.Currency, err error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus != obligationSucceeded || (since > 0 && so.RevenueHeight <= since) {
+				return nil
+			}
+			earnings = earnings.Add(so.ContractCost).Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue)
+			return nil
+		})
+	})
+	if err != nil {
+		return types.Currency{}, build.ExtendErr("database failed to provide storage obligations:", err)
+	}
+	return earnings, nil
+}
@@ -4,8 +4,50 @@ import (
 	"testing"
 
 	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
 )
 
+// TestProofSubmissionDeadline checks that the proof submission deadline
+// leaves the proof window buffer before the end of the proof window, and never
+// falls before the first attempt to submit the proof.
+func TestProofSubmissionDeadline(t *testing.T) {
+	t.Parallel()
+	so := storageObligation{
+		OriginTransactionSet: []types.Transaction{{
+			FileContracts: []types.FileContract{{
+				WindowStart: 100,
+				WindowEnd:   200,
+			}},
+		}},
+	}
+	tests := []struct {
+		buffer   types.BlockHeight
+		deadline types.BlockHeight
+	}{
+		{0, 200},
+		{36, 164},
+		{200 - 100 - resubmissionTimeout, 100 + resubmissionTimeout},
+		{1000, 100 + resubmissionTimeout},
+	}
+	for _, tt := range tests {
+		if d := so.proofSubmissionDeadline(tt.buffer); d != tt.deadline {
+			t.Errorf("buffer %v: expected deadline %v, got %v", tt.buffer, tt.deadline, d)
+		}
+	}
+
+	// A revision moves the deadline along with the proof window.
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			NewWindowStart: 300,
+			NewWindowEnd:   400,
+		}},
+	}}
+	if d := so.proofSubmissionDeadline(36); d != 364 {
+		t.Error("expected deadline 364 after revision, got", d)
+	}
+}
+
 // TestStorageObligationID checks that the return function of the storage
 // obligation returns the correct value for the obligaiton id.
 func TestStorageObligationID(t *testing.T) {
@@ -80,3 +122,65 @@ func TestStorageObligationID(t *testing.T) {
 		t.Error("id function of storage obligation incorrect for file contracts with dependencies")
 	}
 }
+
+// TestEarnings checks that Earnings only counts the revenue of obligations
+// that succeeded after the requested height, and that obligations without a
+// revenue height are included in the lifetime earnings.
+func TestEarnings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	obligation := func(i byte, status storageObligationStatus, height types.BlockHeight) storageObligation {
+		return storageObligation{
+			OriginTransactionSet: []types.Transaction{{
+				FileContracts: []types.FileContract{{}},
+				ArbitraryData: [][]byte{{i}},
+			}},
+			ContractCost:             types.NewCurrency64(1),
+			PotentialStorageRevenue:  types.NewCurrency64(10),
+			PotentialDownloadRevenue: types.NewCurrency64(100),
+			PotentialUploadRevenue:   types.NewCurrency64(1000),
+			ObligationStatus:         status,
+			RevenueHeight:            height,
+		}
+	}
+	err = ht.host.db.Update(func(tx *bolt.Tx) error {
+		for _, so := range []storageObligation{
+			obligation(0, obligationSucceeded, 5),
+			obligation(1, obligationSucceeded, 20),
+			obligation(2, obligationFailed, 0),
+			obligation(3, obligationUnresolved, 0),
+			obligation(4, obligationSucceeded, 0),
+		} {
+			if err := putStorageObligation(tx, so); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	earnings, err := ht.host.Earnings(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !earnings.Equals64(3333) {
+		t.Fatal("expected lifetime earnings of 3333, got", earnings)
+	}
+	earnings, err = ht.host.Earnings(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !earnings.Equals64(1111) {
+		t.Fatal("expected recent earnings of 1111, got", earnings)
+	}
+}
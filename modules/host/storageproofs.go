@@ -0,0 +1,86 @@
+package host
+
+// storageproofs.go builds the storage proofs that the host submits for its
+// storage obligations. Every obligation records the version of the proof
+// construction that was current when its contract was formed, and is always
+// proven with that construction. A new construction can be introduced by
+// adding a version to proofBuilders and making it the currentProofVersion,
+// while obligations formed before the change keep being proven the old way.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// proofVersionMerkle builds a Merkle proof of the challenged segment
+	// from the sector roots of the obligation. It is the zero value, so
+	// obligations that predate proof versions use it.
+	proofVersionMerkle uint64 = 0
+
+	// currentProofVersion is the proof version recorded for new obligations.
+	currentProofVersion = proofVersionMerkle
+)
+
+var (
+	// errUnknownProofVersion is returned if a storage obligation records a
+	// proof version that the host does not know how to build.
+	errUnknownProofVersion = errors.New("storage obligation has an unknown proof version")
+)
+
+// A proofBuilder builds the storage proof of a storage obligation for the
+// segment at segmentIndex.
+type proofBuilder func(h *Host, so storageObligation, segmentIndex uint64) (types.StorageProof, error)
+
+// proofBuilders maps each proof version to the construction of its proofs.
+var proofBuilders = map[uint64]proofBuilder{
+	proofVersionMerkle: buildMerkleStorageProof,
+}
+
+// buildStorageProof builds the storage proof of so for the segment at
+// segmentIndex, using the proof construction of the obligation's version.
+func (h *Host) buildStorageProof(so storageObligation, segmentIndex uint64) (types.StorageProof, error) {
+	buildProof, exists := proofBuilders[so.ProofVersion]
+	if !exists {
+		return types.StorageProof{}, errUnknownProofVersion
+	}
+	return buildProof(h, so, segmentIndex)
+}
+
+// buildMerkleStorageProof builds a storage proof by reading the sector that
+// contains the segment, proving the segment within the sector, and extending
+// the proof to the Merkle root of the file using the cached sector roots.
+func buildMerkleStorageProof(h *Host, so storageObligation, segmentIndex uint64) (types.StorageProof, error) {
+	// Pull the sector containing the segment into memory.
+	sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
+	sectorRoot := so.SectorRoots[sectorIndex]
+	sectorBytes, err := h.ReadSector(sectorRoot)
+	if err != nil {
+		return types.StorageProof{}, err
+	}
+
+	// Build the storage proof for just the sector.
+	sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
+	base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
+
+	// Using the sector, build a cached root.
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	ct.SetIndex(segmentIndex)
+	for _, root := range so.SectorRoots {
+		ct.Push(root)
+	}
+	hashSet := ct.Prove(base, cachedHashSet)
+	sp := types.StorageProof{
+		ParentID: so.id(),
+		HashSet:  hashSet,
+	}
+	copy(sp.Segment[:], base)
+	return sp, nil
+}
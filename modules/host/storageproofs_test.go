@@ -0,0 +1,86 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+// TestBuildStorageProofVersions checks that each storage obligation is proven
+// with the construction of its own proof version.
+func TestBuildStorageProofVersions(t *testing.T) {
+	// Register two test versions whose proofs can be told apart.
+	const oldVersion, newVersion, unknownVersion = 1000, 1001, 1002
+	proofBuilders[oldVersion] = func(*Host, storageObligation, uint64) (types.StorageProof, error) {
+		return types.StorageProof{Segment: [crypto.SegmentSize]byte{1}}, nil
+	}
+	proofBuilders[newVersion] = func(*Host, storageObligation, uint64) (types.StorageProof, error) {
+		return types.StorageProof{Segment: [crypto.SegmentSize]byte{2}}, nil
+	}
+	defer delete(proofBuilders, oldVersion)
+	defer delete(proofBuilders, newVersion)
+
+	if _, exists := proofBuilders[currentProofVersion]; !exists {
+		t.Fatal("the current proof version has no proof construction")
+	}
+
+	h := new(Host)
+	oldSO := storageObligation{ProofVersion: oldVersion}
+	newSO := storageObligation{ProofVersion: newVersion}
+	if sp, err := h.buildStorageProof(oldSO, 0); err != nil {
+		t.Fatal(err)
+	} else if sp.Segment[0] != 1 {
+		t.Fatal("old obligation was not proven with the old construction")
+	}
+	if sp, err := h.buildStorageProof(newSO, 0); err != nil {
+		t.Fatal(err)
+	} else if sp.Segment[0] != 2 {
+		t.Fatal("new obligation was not proven with the new construction")
+	}
+	if _, err := h.buildStorageProof(storageObligation{ProofVersion: unknownVersion}, 0); err != errUnknownProofVersion {
+		t.Fatal("expected errUnknownProofVersion, got", err)
+	}
+}
+
+// TestBuildMerkleStorageProof checks that the original proof construction,
+// which is used by obligations that predate proof versions, produces valid
+// proofs.
+func TestBuildMerkleStorageProof(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Store two sectors for an obligation without a recorded proof version.
+	var so storageObligation
+	var data []byte
+	for i := 0; i < 2; i++ {
+		sector := fastrand.Bytes(int(modules.SectorSize))
+		root := crypto.MerkleRoot(sector)
+		if err := ht.host.AddSector(root, sector); err != nil {
+			t.Fatal(err)
+		}
+		so.SectorRoots = append(so.SectorRoots, root)
+		data = append(data, sector...)
+	}
+	so.OriginTransactionSet = []types.Transaction{{
+		FileContracts: []types.FileContract{{FileMerkleRoot: crypto.MerkleRoot(data)}},
+	}}
+
+	numSegments := 2 * modules.SectorSize / crypto.SegmentSize
+	segmentIndex := numSegments - 3
+	sp, err := ht.host.buildStorageProof(so, segmentIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !crypto.VerifySegment(sp.Segment[:], sp.HashSet, numSegments, segmentIndex, so.merkleRoot()) {
+		t.Fatal("storage proof does not verify against the obligation's Merkle root")
+	}
+}
@@ -23,6 +23,7 @@ func (h *Host) initRescan() error {
 	var allObligations []storageObligation
 	// Reset all of the consensus-relevant variables in the host.
 	h.blockHeight = 0
+	h.resetCollateralReserve()
 
 	// Reset all of the storage obligations.
 	err := h.db.Update(func(tx *bolt.Tx) error {
@@ -189,6 +190,10 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 						if err != nil {
 							continue
 						}
+						// The proof was reverted, resubmit it right away so
+						// that it can be confirmed again before the proof
+						// window closes.
+						actionItems = append(actionItems, sp.ParentID)
 					}
 				}
 			}
@@ -291,6 +296,11 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 				}
 			}
 		}
+
+		// Remove the data of ended contracts that is no longer retained.
+		if err := h.pruneRetainedSectors(tx); err != nil {
+			h.log.Println("Error removing retained sectors:", err)
+		}
 		return nil
 	})
 	if err != nil {
@@ -300,6 +310,14 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 		go h.threadedHandleActionItem(actionItems[i])
 	}
 
+	// Track the collateral reserve, and top it up if it has run low.
+	h.updateCollateralReserve(cc)
+	if amount := h.collateralTopUpAmount(); !amount.IsZero() {
+		h.collateralTopUpPending = true
+		h.collateralTopUpHeight = h.blockHeight
+		go h.threadedTopUpCollateralReserve(amount)
+	}
+
 	// Update the host's recent change pointer to point to the most recent
 	// change.
 	h.recentChange = cc.ID
@@ -81,10 +81,12 @@ func (h *Host) managedLearnHostname() {
 	// address has changed.
 	if hostAcceptingContracts || hostContractCount > 0 {
 		h.log.Println("Host external IP address changed from", hostAutoAddress, "to", autoAddress, "- performing host announcement.")
-		err = h.managedAnnounce(autoAddress)
+		err = h.managedAutoAnnounce(autoAddress)
 		if err != nil {
 			// Set h.announced to false, as the address has changed yet the
-			// renewed annoucement has failed.
+			// renewed annoucement has failed. If the announcement was only
+			// held back by the announcement limits, it is retried the next
+			// time the hostname is checked.
 			h.mu.Lock()
 			h.announced = false
 			h.mu.Unlock()
@@ -0,0 +1,63 @@
+package host
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// Reasons for which the host rejects a contract, as reported by
+// UtilizationMetrics.
+const (
+	rejectMaintenance     = "maintenance"
+	rejectDecommissioning = "decommissioning"
+	rejectInvalid         = "invalid"
+	rejectFiltered        = "filtered"
+	rejectCollateral      = "collateral"
+	rejectFinalization    = "finalization"
+)
+
+// managedRecordContractDecision records the outcome of a contract formation
+// or renewal, replacing the oldest outcome once maxContractDecisions have been
+// recorded. An empty reason records an accepted contract.
+func (h *Host) managedRecordContractDecision(reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.contractDecisions) < maxContractDecisions {
+		h.contractDecisions = append(h.contractDecisions, reason)
+	} else {
+		h.contractDecisions[h.contractDecisionsIndex] = reason
+	}
+	h.contractDecisionsIndex = (h.contractDecisionsIndex + 1) % maxContractDecisions
+}
+
+// UtilizationMetrics returns the storage utilization of the host, the
+// acceptance rate of the last maxContractDecisions contract formations and
+// renewals, and the average potential revenue of the host's active contracts.
+// The acceptance counts are not persisted across restarts.
+func (h *Host) UtilizationMetrics() modules.HostUtilizationMetrics {
+	total, remaining := h.capacity()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	um := modules.HostUtilizationMetrics{
+		RejectionReasons: make(map[string]uint64),
+	}
+	if total > 0 && remaining <= total {
+		um.StorageUtilization = 100 * float64(total-remaining) / float64(total)
+	}
+	for _, reason := range h.contractDecisions {
+		if reason == "" {
+			um.ContractsAccepted++
+		} else {
+			um.ContractsRejected++
+			um.RejectionReasons[reason]++
+		}
+	}
+	if len(h.contractDecisions) > 0 {
+		um.AcceptRate = float64(um.ContractsAccepted) / float64(len(h.contractDecisions))
+	}
+	if fm := h.financialMetrics; fm.ContractCount > 0 {
+		value := fm.PotentialContractCompensation.Add(fm.PotentialStorageRevenue).Add(fm.PotentialDownloadBandwidthRevenue).Add(fm.PotentialUploadBandwidthRevenue)
+		um.AverageContractValue = value.Div64(fm.ContractCount)
+	}
+	return um
+}
@@ -0,0 +1,47 @@
+package host
+
+import (
+	"testing"
+)
+
+// TestUtilizationMetrics checks that the host reports the acceptance rate of
+// the most recent contracts, broken down by rejection reason.
+func TestUtilizationMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	um := ht.host.UtilizationMetrics()
+	if um.ContractsAccepted != 0 || um.ContractsRejected != 0 || um.AcceptRate != 0 {
+		t.Fatal("new host should have no contract decisions:", um)
+	}
+	if um.StorageUtilization != 0 || !um.AverageContractValue.IsZero() {
+		t.Fatal("new host should have no utilization:", um)
+	}
+
+	ht.host.managedRecordContractDecision("")
+	ht.host.managedRecordContractDecision("")
+	ht.host.managedRecordContractDecision("")
+	ht.host.managedRecordContractDecision(rejectFiltered)
+	um = ht.host.UtilizationMetrics()
+	if um.ContractsAccepted != 3 || um.ContractsRejected != 1 || um.AcceptRate != 0.75 {
+		t.Fatal("wrong acceptance counts:", um)
+	}
+	if len(um.RejectionReasons) != 1 || um.RejectionReasons[rejectFiltered] != 1 {
+		t.Fatal("wrong rejection reasons:", um.RejectionReasons)
+	}
+
+	// Only the most recent maxContractDecisions outcomes are counted.
+	for i := 0; i < maxContractDecisions; i++ {
+		ht.host.managedRecordContractDecision(rejectInvalid)
+	}
+	um = ht.host.UtilizationMetrics()
+	if um.ContractsAccepted != 0 || um.ContractsRejected != maxContractDecisions || um.RejectionReasons[rejectInvalid] != maxContractDecisions {
+		t.Fatal("old contract decisions were not forgotten:", um)
+	}
+}
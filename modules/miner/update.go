@@ -13,7 +13,7 @@ func (m *Miner) addMapElementTxns(elem *mapElement) {
 	candidateSet := elem.set
 
 	// Check if heap for highest fee transactions has space.
-	if m.blockMapHeap.size+candidateSet.size < types.BlockSizeLimit-5e3 {
+	if m.blockMapHeap.size+candidateSet.size < m.cs.BlockSizeLimit()-5e3 {
 		m.pushToBlock(elem)
 		return
 	}
@@ -29,7 +29,7 @@ func (m *Miner) addMapElementTxns(elem *mapElement) {
 	var averageFeeOfBottomSets types.Currency
 	for {
 		// Check if the candidateSet can fit in the block.
-		if m.blockMapHeap.size-sizeOfBottomSets+candidateSet.size < types.BlockSizeLimit-5e3 {
+		if m.blockMapHeap.size-sizeOfBottomSets+candidateSet.size < m.cs.BlockSizeLimit()-5e3 {
 			// Place candidate into block,
 			m.pushToBlock(elem)
 			// Place transactions removed from block heap into
@@ -115,7 +115,7 @@ func (m *Miner) deleteMapElementTxns(id splitSetID) {
 		m.removeSplitSetFromUnsolvedBlock(id)
 
 		// Promote sets from overflow heap to block if possible.
-		for overflowElem, canPromote := m.peekAtOverflow(); canPromote && m.blockMapHeap.size+overflowElem.set.size < types.BlockSizeLimit-5e3; {
+		for overflowElem, canPromote := m.peekAtOverflow(); canPromote && m.blockMapHeap.size+overflowElem.set.size < m.cs.BlockSizeLimit()-5e3; {
 			promotedElem := m.popFromOverflow()
 			m.pushToBlock(promotedElem)
 		}
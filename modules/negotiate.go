@@ -145,6 +145,11 @@ var (
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.Specifier{'H', 'o', 's', 't', 'A', 'n', 'n', 'o', 'u', 'n', 'c', 'e', 'm', 'e', 'n', 't'}
 
+	// RPCDecommission is the specifier for requesting the decommission
+	// status of a host. Hosts that do not support the RPC close the
+	// connection, and are not decommissioning.
+	RPCDecommission = types.Specifier{'D', 'e', 'c', 'o', 'm', 'm', 'i', 's', 's', 'i', 'o', 'n'}
+
 	// RPCDownload is the specifier for downloading a file from a host.
 	RPCDownload = types.Specifier{'D', 'o', 'w', 'n', 'l', 'o', 'a', 'd', 2}
 
@@ -256,6 +261,19 @@ type (
 		Version        string `json:"version"`
 	}
 
+	// HostDecommission is the decommission status of a host, signed by the
+	// host in response to RPCDecommission. A decommissioning host has
+	// stopped accepting new contracts and uploads, and serves downloads
+	// until Deadline so that renters can move their data to other hosts.
+	// Deadline is zero if the host is not decommissioning. Height is the
+	// host's block height when the status was signed, so that renters can
+	// recognize stale statuses.
+	HostDecommission struct {
+		Decommissioning bool              `json:"decommissioning"`
+		Deadline        types.BlockHeight `json:"deadline"`
+		Height          types.BlockHeight `json:"height"`
+	}
+
 	// A RevisionAction is a description of an edit to be performed on a file
 	// contract. Three types are allowed, 'ActionDelete', 'ActionInsert', and
 	// 'ActionModify'. ActionDelete just takes a sector index, indicating which
@@ -58,12 +58,15 @@ type DownloadInfo struct {
 	SiaPath     string         `json:"siapath"`
 	Destination DownloadWriter `json:"destination"`
 	Filesize    uint64         `json:"filesize"`
+	Priority    int            `json:"priority"`
 	Received    uint64         `json:"received"`
 	StartTime   time.Time      `json:"starttime"`
+	Stuck       bool           `json:"stuck"`
 	Error       string         `json:"error"`
 }
 
 // DownloadWriter provides an interface which all output writers have to implement.
+// Like io.WriterAt, WriteAt must not retain b, which is reused by the renter.
 type DownloadWriter interface {
 	WriteAt(b []byte, off int64) (int, error)
 	Destination() string
@@ -89,6 +92,107 @@ type FileInfo struct {
 	UploadedBytes  uint64            `json:"uploadedbytes"`
 	UploadProgress float64           `json:"uploadprogress"`
 	Expiration     types.BlockHeight `json:"expiration"`
+	Hash           crypto.Hash       `json:"hash"`
+	Diversity      float64           `json:"diversity"`
+	Downloads      uint64            `json:"downloads"`
+	BytesServed    uint64            `json:"bytesserved"`
+	LastAccess     time.Time         `json:"lastaccess"`
+	Metadata       map[string]string `json:"metadata"`
+
+	// HealthMargin is the number of online pieces that the least redundant
+	// chunk of the file can lose before it becomes unrecoverable. A negative
+	// margin means that some chunks cannot currently be recovered.
+	HealthMargin int `json:"healthmargin"`
+
+	// DegradedChunks is the number of chunks with fewer online pieces than
+	// the erasure code produces. See FileHealth for the details.
+	DegradedChunks uint64 `json:"degradedchunks"`
+}
+
+// FileHealth describes where the pieces of every chunk of a file are stored,
+// so that the durability of the file can be analyzed chunk by chunk. It is
+// built from the renter's records and the contractor's view of which hosts
+// are online; no data is transferred from the hosts.
+type FileHealth struct {
+	SiaPath   string        `json:"siapath"`
+	MinPieces int           `json:"minpieces"`
+	NumPieces int           `json:"numpieces"`
+	Chunks    []ChunkHealth `json:"chunks"`
+}
+
+// ChunkHealth describes the pieces of a single chunk of a file. Margin is
+// the number of distinct online pieces beyond the MinPieces needed to
+// recover the chunk; a negative margin means the chunk cannot currently be
+// recovered.
+type ChunkHealth struct {
+	Pieces       []PieceHealth `json:"pieces"`
+	OnlinePieces int           `json:"onlinepieces"`
+	Margin       int           `json:"margin"`
+}
+
+// PieceHealth describes a single stored copy of a piece of a chunk.
+type PieceHealth struct {
+	Piece    uint64               `json:"piece"`
+	Host     NetAddress           `json:"host"`
+	Contract types.FileContractID `json:"contract"`
+	Online   bool                 `json:"online"`
+}
+
+// UploadManifest lists, for every chunk of a file, the hash of the chunk's
+// data and the hosts and Merkle roots of its stored pieces. A chunk is
+// confirmed once all of its pieces have been stored on hosts; an interrupted
+// upload resumes with the chunks that are not complete, and the manifest can
+// be used to verify the file against the hosts later.
+type UploadManifest struct {
+	SiaPath   string          `json:"siapath"`
+	Size      uint64          `json:"size"`
+	ChunkSize uint64          `json:"chunksize"`
+	MinPieces int             `json:"minpieces"`
+	NumPieces int             `json:"numpieces"`
+	Hash      crypto.Hash     `json:"hash"`
+	Chunks    []ChunkManifest `json:"chunks"`
+}
+
+// ChunkManifest describes a single chunk of an UploadManifest. Hash is zero
+// if the hash of the chunk's data is unknown.
+type ChunkManifest struct {
+	Hash      crypto.Hash     `json:"hash"`
+	Confirmed bool            `json:"confirmed"`
+	Pieces    []PieceManifest `json:"pieces"`
+}
+
+// PieceManifest describes a single stored copy of a piece of a chunk.
+type PieceManifest struct {
+	Piece      uint64               `json:"piece"`
+	Host       NetAddress           `json:"host"`
+	Contract   types.FileContractID `json:"contract"`
+	MerkleRoot crypto.Hash          `json:"merkleroot"`
+}
+
+// RepairQueue describes the chunks that the renter's background health scan
+// has queued for repair. On every new block the renter scans a few of its
+// files and queues the chunks that have too few pieces on online hosts.
+// PausedReason is set when the most recent scan queued nothing because a
+// limit, such as the allowance, had been reached.
+type RepairQueue struct {
+	Chunks         []RepairChunk     `json:"chunks"`
+	ChunksRepaired uint64            `json:"chunksrepaired"`
+	ChunksFailed   uint64            `json:"chunksfailed"`
+	FilesScanned   uint64            `json:"filesscanned"`
+	LastScanHeight types.BlockHeight `json:"lastscanheight"`
+	PausedReason   string            `json:"pausedreason"`
+}
+
+// RepairChunk describes a chunk in the repair queue. Status is either
+// "queued", if the chunk is waiting for the repair loop, or "repairing", if
+// its pieces are being uploaded.
+type RepairChunk struct {
+	SiaPath         string            `json:"siapath"`
+	Chunk           uint64            `json:"chunk"`
+	Status          string            `json:"status"`
+	PiecesCompleted int               `json:"piecescompleted"`
+	PiecesNeeded    int               `json:"piecesneeded"`
+	QueuedHeight    types.BlockHeight `json:"queuedheight"`
 }
 
 // A HostDBEntry represents one host entry in the Renter's host DB. It
@@ -113,6 +217,10 @@ type HostDBEntry struct {
 
 	LastHistoricUpdate types.BlockHeight
 
+	// Decommission is the decommission status most recently reported by the
+	// host. It is only queried when the host stops accepting contracts.
+	Decommission HostDecommission `json:"decommission"`
+
 	// The public key of the host, stored separately to minimize risk of certain
 	// MitM based vulnerabilities.
 	PublicKey types.SiaPublicKey `json:"publickey"`
@@ -167,6 +275,107 @@ type RenterSettings struct {
 	Allowance Allowance `json:"allowance"`
 }
 
+// RenterLimits bound the resources that the Renter devotes to transferring
+// data with hosts. Every limit must be greater than zero.
+type RenterLimits struct {
+	// MaxConcurrentDownloads is the number of pieces that may be scheduled
+	// for download at once. Scheduled pieces are held in memory until their
+	// chunk is recovered. Fewer pieces are scheduled when there are fewer
+	// responsive hosts to download them from. A limit below the number of
+	// pieces needed to recover a chunk is raised to that number, so that
+	// every chunk can be downloaded.
+	MaxConcurrentDownloads int `json:"maxconcurrentdownloads"`
+
+	// MaxConcurrentUploads is the number of pieces that may be uploaded to
+	// hosts at once.
+	MaxConcurrentUploads int `json:"maxconcurrentuploads"`
+
+	// MaxHostConnections is the number of connections to hosts that the
+	// workers may hold open at once, counting both uploads and downloads.
+	MaxHostConnections int `json:"maxhostconnections"`
+
+	// MaxDownloadWorkers is the number of workers that may fetch pieces for
+	// a single download at once.
+	MaxDownloadWorkers int `json:"maxdownloadworkers"`
+
+	// MaxUploadWorkers is the number of workers that may upload pieces of a
+	// single chunk at once.
+	MaxUploadWorkers int `json:"maxuploadworkers"`
+
+	// DownloadBufferChunks is the number of consecutive recovered chunks
+	// that a download to a file collects in memory before writing them to
+	// the file at once. Larger buffers mean fewer, larger writes, which
+	// helps slow or network-backed filesystems at the cost of memory.
+	DownloadBufferChunks int `json:"downloadbufferchunks"`
+
+	// RepairScanFiles is the number of files whose health the renter checks
+	// on each new block. Files are checked in turn, so every file is checked
+	// once every (number of files / RepairScanFiles) blocks.
+	RepairScanFiles int `json:"repairscanfiles"`
+
+	// RepairThreshold is the percentage of a chunk's pieces that must be
+	// stored on online hosts. Chunks below it are queued for repair by the
+	// health scan.
+	RepairThreshold int `json:"repairthreshold"`
+
+	// HostFailureThreshold is the number of consecutive failed piece
+	// downloads after which a host is skipped by downloads.
+	HostFailureThreshold int `json:"hostfailurethreshold"`
+
+	// HostFailureCooldown is the number of seconds that a host is skipped
+	// for once it has failed HostFailureThreshold times in a row. After the
+	// cooldown a single piece is downloaded from the host to probe it; the
+	// host is used again if the probe succeeds, and skipped for another
+	// cooldown if it fails.
+	HostFailureCooldown int `json:"hostfailurecooldown"`
+}
+
+// HostPreference controls how the Renter trades cost against speed when
+// choosing which hosts to download pieces from. It ranges from
+// HostPreferCheapest, which ranks hosts only by their download price, to
+// HostPreferFastest, which ranks hosts only by their measured latency.
+type HostPreference float64
+
+const (
+	// HostPreferCheapest ranks hosts by download price alone.
+	HostPreferCheapest HostPreference = 0
+
+	// HostPreferBalanced weighs download price and latency equally. It is
+	// the Renter's default preference.
+	HostPreferBalanced HostPreference = 0.5
+
+	// HostPreferFastest ranks hosts by measured latency alone.
+	HostPreferFastest HostPreference = 1
+)
+
+// RenterBandwidth reports the Renter's bandwidth limits, in bytes per second,
+// and how they are divided between the files that are currently being
+// transferred. A limit of zero means that the direction is not limited.
+type RenterBandwidth struct {
+	DownloadLimit int64                       `json:"downloadlimit"`
+	UploadLimit   int64                       `json:"uploadlimit"`
+	Downloads     []RenterBandwidthAllocation `json:"downloads"`
+	Uploads       []RenterBandwidthAllocation `json:"uploads"`
+}
+
+// RenterBandwidthAllocation is the share of a bandwidth limit allocated to the
+// transfers of a file at a priority. Rate is in bytes per second, and is zero
+// if the direction is not limited.
+type RenterBandwidthAllocation struct {
+	SiaPath   string `json:"siapath"`
+	Priority  int    `json:"priority"`
+	Transfers int    `json:"transfers"`
+	Rate      int64  `json:"rate"`
+}
+
+// RenterLimitsUtilization reports how much of each of the RenterLimits is
+// currently in use.
+type RenterLimitsUtilization struct {
+	ActiveDownloads int `json:"activedownloads"`
+	ActiveUploads   int `json:"activeuploads"`
+	HostConnections int `json:"hostconnections"`
+}
+
 // HostDBScans represents a sortable slice of scans.
 type HostDBScans []HostDBScan
 
@@ -295,12 +504,50 @@ type Renter interface {
 	// downloads of `offset` and `length` type.
 	Download(params RenterDownloadParameters) error
 
+	// DownloadWithPriority downloads a file to destination. Downloads with a
+	// higher priority are given workers before lower priority downloads.
+	DownloadWithPriority(nickname, destination string, priority int) error
+
+	// DownloadFastest downloads a file to destination, requesting each piece
+	// from several hosts at once and keeping the first valid response.
+	DownloadFastest(nickname, destination string) error
+
+	// DownloadByHash downloads the file whose contents have the given hash
+	// to destination. Files are hashed when they are uploaded.
+	DownloadByHash(hash crypto.Hash, destination string) error
+
 	// DownloadQueue lists all the files that have been scheduled for download.
 	DownloadQueue() []DownloadInfo
 
+	// ExpiredFiles returns the names of all files that can no longer be
+	// recovered because every contract storing their pieces has expired.
+	ExpiredFiles() []string
+
+	// PruneExpiredFiles removes the metadata of all files reported by
+	// ExpiredFiles, returning the names of the removed files.
+	PruneExpiredFiles() ([]string, error)
+
+	// ExportManifest writes a recovery manifest of a file to w. The
+	// manifest holds everything needed to download the file without the
+	// renter's file database, including the key the file is encrypted with.
+	ExportManifest(path string, w io.Writer) error
+
 	// FileList returns information on all of the files stored by the renter.
 	FileList() []FileInfo
 
+	// FileHealth returns, for every chunk of a file, which hosts hold which
+	// pieces and how many pieces the chunk can lose before it becomes
+	// unrecoverable.
+	FileHealth(path string) (FileHealth, error)
+
+	// UploadManifest returns the hash, confirmation state, hosts and piece
+	// Merkle roots of every chunk of a file.
+	UploadManifest(path string) (UploadManifest, error)
+
+	// RepairQueue returns the chunks that the background health scan has
+	// queued for repair, along with the progress of the repairs.
+	RepairQueue() RepairQueue
+
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.SiaPublicKey) (HostDBEntry, bool)
 
@@ -316,9 +563,27 @@ type Renter interface {
 	// storage and data operations.
 	PriceEstimation() RenterPriceEstimation
 
+	// EstimateUploadCost estimates the cost of uploading a file of the given
+	// size with the given redundancy and storing it for duration blocks,
+	// based on the current prices of the hosts it would be uploaded to.
+	EstimateUploadCost(fileSize uint64, duration types.BlockHeight, redundancy float64) (types.Currency, error)
+
+	// RecoverFromManifest downloads the file described by a recovery
+	// manifest to destination, without adding it to the renter.
+	RecoverFromManifest(manifest io.Reader, destination string) error
+
+	// Redownload repairs a previously downloaded file that has been
+	// corrupted on disk, downloading only the chunks that fail verification
+	// and writing them over the local file in place.
+	Redownload(destination string) error
+
 	// RenameFile changes the path of a file.
 	RenameFile(path, newPath string) error
 
+	// SetFileMetadata replaces the user-supplied metadata of a file, such as
+	// tags or a MIME type.
+	SetFileMetadata(path string, meta map[string]string) error
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry) HostScoreBreakdown
@@ -333,6 +598,39 @@ type Renter interface {
 	// SetSettings sets the Renter's settings.
 	SetSettings(RenterSettings) error
 
+	// Limits returns the Renter's current resource limits, along with how
+	// much of each limit is currently in use.
+	Limits() (RenterLimits, RenterLimitsUtilization)
+
+	// SetLimits sets the Renter's resource limits.
+	SetLimits(RenterLimits) error
+
+	// HostPreference returns the cost-versus-speed preference that the
+	// Renter uses for downloads that do not specify their own.
+	HostPreference() HostPreference
+
+	// SetHostPreference sets the cost-versus-speed preference that the
+	// Renter uses for downloads that do not specify their own.
+	SetHostPreference(HostPreference) error
+
+	// SettingsTolerance returns the fraction by which a host's prices may
+	// increase, or its maximum duration decrease, between the time the host
+	// was selected and the time an upload or contract formation begins.
+	// Operations with hosts whose settings drifted further are aborted.
+	SettingsTolerance() float64
+
+	// SetSettingsTolerance sets the tolerance returned by SettingsTolerance.
+	SetSettingsTolerance(float64) error
+
+	// Bandwidth returns the Renter's bandwidth limits and how they are
+	// currently divided between transfers.
+	Bandwidth() RenterBandwidth
+
+	// SetBandwidthLimits sets the maximum rate, in bytes per second, at
+	// which the Renter downloads from and uploads to hosts. A limit of zero
+	// disables the corresponding limit.
+	SetBandwidthLimits(download, upload int64) error
+
 	// ShareFiles creates a '.sia' file that can be shared with others.
 	ShareFiles(paths []string, shareDest string) error
 
@@ -341,6 +639,10 @@ type Renter interface {
 
 	// Upload uploads a file using the input parameters.
 	Upload(FileUploadParams) error
+
+	// VerifyFile checks that every chunk of a file can be recovered from
+	// the hosts storing it, without writing the file to disk.
+	VerifyFile(path string) error
 }
 
 // RenterDownloadParameters defines the parameters passed to the Renter's
@@ -350,6 +652,21 @@ type RenterDownloadParameters struct {
 	Httpwriter  io.Writer
 	Length      uint64
 	Offset      uint64
+	Priority    int
 	Siapath     string
 	Destination string
+
+	// TempDir is the directory that a download to Destination is written to
+	// before it is moved to Destination. It should be on the same filesystem
+	// as Destination. If it is empty, the directory of Destination is used.
+	TempDir string
+
+	// HostPreference overrides the Renter's host preference for this
+	// download. If it is nil, the Renter's preference is used.
+	HostPreference *HostPreference
+
+	// Fastest races several hosts for every piece of the download and keeps
+	// whichever piece arrives first, trading extra bandwidth for lower
+	// latency.
+	Fastest bool
 }
@@ -0,0 +1,215 @@
+package renter
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+	siasync "github.com/NebulousLabs/Sia/sync"
+)
+
+const (
+	// maxBandwidthPriorityShift bounds the priorities that are distinguished
+	// when dividing bandwidth. A priority further from zero receives the same
+	// share as this bound.
+	maxBandwidthPriorityShift = 16
+)
+
+var (
+	errNegativeBandwidthLimit = errors.New("bandwidth limits must not be negative")
+)
+
+type (
+	// bandwidthScheduler divides the renter's download and upload bandwidth
+	// between the files that are being transferred. Every file being
+	// transferred at a priority is a bandwidth operation with its own
+	// RateLimit, which all of the workers transferring the file wait on. The
+	// limit of each direction is divided between its operations in
+	// proportion to their weight, which doubles with every priority level,
+	// so that large low priority transfers cannot starve high priority ones
+	// and are not starved themselves.
+	bandwidthScheduler struct {
+		downloads bandwidthPool
+		uploads   bandwidthPool
+		mu        sync.Mutex
+	}
+
+	// bandwidthPool holds the limit and the operations of one direction.
+	// debt is the bandwidth that removed operations used beyond their share
+	// and had not yet paid back; it is charged to the remaining operations,
+	// so that removing an operation does not forgive its debt.
+	bandwidthPool struct {
+		limit int64
+		debt  int64
+		ops   map[bandwidthKey]*bandwidthOp
+	}
+
+	// bandwidthKey identifies a bandwidth operation.
+	bandwidthKey struct {
+		siapath  string
+		priority int
+	}
+
+	// bandwidthOp is the bandwidth allocated to the transfers of a file. It
+	// exists as long as any worker is transferring a piece of the file.
+	bandwidthOp struct {
+		key       bandwidthKey
+		pool      *bandwidthPool
+		transfers int
+		rl        *siasync.RateLimit
+	}
+)
+
+// newBandwidthScheduler returns a bandwidthScheduler that does not limit
+// either direction.
+func newBandwidthScheduler() *bandwidthScheduler {
+	return &bandwidthScheduler{
+		downloads: bandwidthPool{ops: make(map[bandwidthKey]*bandwidthOp)},
+		uploads:   bandwidthPool{ops: make(map[bandwidthKey]*bandwidthOp)},
+	}
+}
+
+// bandwidthWeight returns the weight of an operation with the given priority.
+func bandwidthWeight(priority int) float64 {
+	if priority > maxBandwidthPriorityShift {
+		priority = maxBandwidthPriorityShift
+	} else if priority < -maxBandwidthPriorityShift {
+		priority = -maxBandwidthPriorityShift
+	}
+	return math.Pow(2, float64(priority))
+}
+
+// allocate divides the limit of the pool, and any debt of removed
+// operations, between its operations. The caller must hold the scheduler's
+// lock.
+func (bp *bandwidthPool) allocate() {
+	var total float64
+	for _, op := range bp.ops {
+		total += bandwidthWeight(op.key.priority)
+	}
+	for _, op := range bp.ops {
+		rate := int64(0)
+		if bp.limit > 0 {
+			// A rate of zero would disable limiting, so every operation
+			// gets at least one byte per second.
+			rate = int64(float64(bp.limit) * bandwidthWeight(op.key.priority) / total)
+			if rate < 1 {
+				rate = 1
+			}
+		}
+		op.rl.AdjustLimit(rate)
+	}
+
+	// Debt is only owed while the pool is limited. If there are no
+	// operations, it is kept for the next one.
+	if bp.limit <= 0 {
+		bp.debt = 0
+	}
+	if bp.debt == 0 || len(bp.ops) == 0 {
+		return
+	}
+	for _, op := range bp.ops {
+		op.rl.Charge(int64(math.Ceil(float64(bp.debt) * bandwidthWeight(op.key.priority) / total)))
+	}
+	bp.debt = 0
+}
+
+// allocations returns the current allocations of the pool, ordered by rate
+// and then by siapath. The caller must hold the scheduler's lock.
+func (bp *bandwidthPool) allocations() []modules.RenterBandwidthAllocation {
+	allocs := make([]modules.RenterBandwidthAllocation, 0, len(bp.ops))
+	for _, op := range bp.ops {
+		allocs = append(allocs, modules.RenterBandwidthAllocation{
+			SiaPath:   op.key.siapath,
+			Priority:  op.key.priority,
+			Transfers: op.transfers,
+			Rate:      op.rl.Limit(),
+		})
+	}
+	sort.Slice(allocs, func(i, j int) bool {
+		if allocs[i].Rate != allocs[j].Rate {
+			return allocs[i].Rate > allocs[j].Rate
+		}
+		if allocs[i].SiaPath != allocs[j].SiaPath {
+			return allocs[i].SiaPath < allocs[j].SiaPath
+		}
+		return allocs[i].Priority > allocs[j].Priority
+	})
+	return allocs
+}
+
+// acquire registers a transfer of a piece of the file at siapath, and returns
+// the operation that the transfer must wait on. Every call to acquire must be
+// followed by a call to release once the transfer has finished.
+func (bs *bandwidthScheduler) acquire(bp *bandwidthPool, siapath string, priority int) *bandwidthOp {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	key := bandwidthKey{siapath: siapath, priority: priority}
+	op, exists := bp.ops[key]
+	if !exists {
+		op = &bandwidthOp{
+			key:  key,
+			pool: bp,
+			rl:   siasync.NewRateLimit(0),
+		}
+		bp.ops[key] = op
+		bp.allocate()
+	}
+	op.transfers++
+	return op
+}
+
+// release unregisters a transfer registered by acquire. The operation is
+// removed once it has no transfers left, and its bandwidth is divided
+// between the remaining operations, which also take over its debt.
+func (bs *bandwidthScheduler) release(op *bandwidthOp) {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	op.transfers--
+	if op.transfers == 0 {
+		delete(op.pool.ops, op.key)
+		op.pool.debt += op.rl.Debt()
+		op.pool.allocate()
+	}
+}
+
+// tryWait reports whether the operation may transfer n bytes now, and if so
+// charges them to the operation. It does not block, so that a worker whose
+// transfer has no bandwidth left can do other work in the meantime.
+func (op *bandwidthOp) tryWait(n uint64) bool {
+	return op.rl.TryWait(int(n))
+}
+
+// Bandwidth returns the renter's bandwidth limits and how they are currently
+// divided between transfers.
+func (r *Renter) Bandwidth() modules.RenterBandwidth {
+	bs := r.bandwidth
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	return modules.RenterBandwidth{
+		DownloadLimit: bs.downloads.limit,
+		UploadLimit:   bs.uploads.limit,
+		Downloads:     bs.downloads.allocations(),
+		Uploads:       bs.uploads.allocations(),
+	}
+}
+
+// SetBandwidthLimits sets the download and upload limits, in bytes per
+// second, shared by all of the renter's transfers with hosts. A limit of zero
+// disables the corresponding limit.
+func (r *Renter) SetBandwidthLimits(download, upload int64) error {
+	if download < 0 || upload < 0 {
+		return errNegativeBandwidthLimit
+	}
+	bs := r.bandwidth
+	bs.mu.Lock()
+	bs.downloads.limit = download
+	bs.uploads.limit = upload
+	bs.downloads.allocate()
+	bs.uploads.allocate()
+	bs.mu.Unlock()
+	r.log.Printf("INFO: bandwidth limits set to %v B/s download, %v B/s upload", download, upload)
+	return nil
+}
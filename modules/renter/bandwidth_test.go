@@ -0,0 +1,97 @@
+package renter
+
+import (
+	"testing"
+)
+
+// TestBandwidthScheduler checks that the bandwidth limits are divided between
+// operations by priority.
+func TestBandwidthScheduler(t *testing.T) {
+	bs := newBandwidthScheduler()
+	bs.downloads.limit = 3000
+
+	// A single operation receives the entire limit, no matter how many
+	// transfers it has.
+	low := bs.acquire(&bs.downloads, "foo", 0)
+	bs.acquire(&bs.downloads, "foo", 0)
+	if low.transfers != 2 || len(bs.downloads.ops) != 1 {
+		t.Fatal("transfers of the same file should share an operation")
+	}
+	if low.rl.Limit() != 3000 {
+		t.Fatal("wrong rate:", low.rl.Limit())
+	}
+
+	// An operation with a higher priority receives twice the share.
+	high := bs.acquire(&bs.downloads, "bar", 1)
+	if low.rl.Limit() != 1000 || high.rl.Limit() != 2000 {
+		t.Fatal("wrong rates:", low.rl.Limit(), high.rl.Limit())
+	}
+	allocs := bs.downloads.allocations()
+	if len(allocs) != 2 || allocs[0].SiaPath != "bar" || allocs[0].Rate != 2000 || allocs[1].Transfers != 2 {
+		t.Fatalf("wrong allocations: %+v", allocs)
+	}
+
+	// Uploads are allocated separately, and are not limited.
+	up := bs.acquire(&bs.uploads, "foo", 0)
+	if up.rl.Limit() != 0 || low.rl.Limit() != 1000 {
+		t.Fatal("uploads should not affect downloads")
+	}
+
+	// Once the high priority operation is done, its share is returned.
+	bs.release(high)
+	if low.rl.Limit() != 3000 || len(bs.downloads.ops) != 1 {
+		t.Fatal("bandwidth was not returned:", low.rl.Limit())
+	}
+	bs.release(low)
+	bs.release(low)
+	if len(bs.downloads.ops) != 0 {
+		t.Fatal("operation should be removed once its transfers are done")
+	}
+
+	// Every operation is limited, even if its share rounds to zero.
+	bs.downloads.limit = 1
+	tiny := bs.acquire(&bs.downloads, "baz", -maxBandwidthPriorityShift-5)
+	bs.acquire(&bs.downloads, "qux", maxBandwidthPriorityShift)
+	if tiny.rl.Limit() != 1 {
+		t.Fatal("operation should be limited to at least one byte per second:", tiny.rl.Limit())
+	}
+}
+
+// TestBandwidthSchedulerDebt checks that the debt of a released operation is
+// charged to the operations that remain, or to the next one.
+func TestBandwidthSchedulerDebt(t *testing.T) {
+	bs := newBandwidthScheduler()
+	bs.downloads.limit = 1000
+	// An operation that transfers more than its burst goes into debt.
+	big := bs.acquire(&bs.downloads, "foo", 0)
+	if !big.tryWait(3000) {
+		t.Fatal("transfer larger than the burst should proceed")
+	}
+	debt := big.rl.Debt()
+	if debt < 1900 {
+		t.Fatal("operation should be in debt:", debt)
+	}
+
+	// With no other operations, the debt is kept for the next one.
+	// The debt is repaid slowly while the test runs, so some slack is
+	// allowed.
+	bs.release(big)
+	if bs.downloads.debt < debt-100 || bs.downloads.debt > debt {
+		t.Fatal("debt was not carried over to the pool:", bs.downloads.debt, debt)
+	}
+	next := bs.acquire(&bs.downloads, "bar", 0)
+	if bs.downloads.debt != 0 || next.rl.Debt() < debt-1100 {
+		t.Fatal("debt was not charged to the next operation:", next.rl.Debt())
+	}
+	if next.tryWait(1) {
+		t.Fatal("next operation should wait for the debt to be repaid")
+	}
+
+	// Removing the limit forgives the debt.
+	bs.release(next)
+	bs.downloads.limit = 0
+	bs.downloads.allocate()
+	if bs.downloads.debt != 0 {
+		t.Fatal("debt should be dropped when the pool is not limited")
+	}
+}
@@ -6,7 +6,29 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 )
 
+const (
+	// downloadStuckTimeoutFactor is the number of times that the slowest host
+	// of a started download may time out a piece without the download making
+	// progress before the download is reported as stuck. Timed out pieces
+	// are retried on other hosts, so a download is only stuck once its
+	// retries have timed out too.
+	downloadStuckTimeoutFactor = 2
+
+	// downloadStuckCancelFactor is the number of stuck timeouts that a started
+	// download may go without making progress before it is cancelled.
+	downloadStuckCancelFactor = 12
+)
+
 var (
+	// bandwidthRetryInterval is how long a worker waits before retrying the
+	// transfers that it deferred because their share of the renter's
+	// bandwidth was used up.
+	bandwidthRetryInterval = build.Select(build.Var{
+		Dev:      250 * time.Millisecond,
+		Standard: 250 * time.Millisecond,
+		Testing:  50 * time.Millisecond,
+	}).(time.Duration)
+
 	// chunkDownloadTimeout defines the maximum amount of time to wait for a
 	// chunk download to finish before returning in the download-to-upload repair
 	// loop
@@ -33,6 +55,99 @@ var (
 		Testing:  3,
 	}).(int)
 
+	// defaultMaxConcurrentPieceUploads limits the number of pieces that the
+	// workers will upload to hosts in parallel. Each worker uploads to its
+	// own host, so this caps the fan-out of an upload across the worker pool.
+	defaultMaxConcurrentPieceUploads = build.Select(build.Var{
+		Dev:      10,
+		Standard: 30,
+		Testing:  10,
+	}).(int)
+
+	// defaultMaxHostConnections limits the number of connections that the
+	// workers hold open to hosts at once. It defaults to enough connections
+	// to saturate both the upload and download limits.
+	defaultMaxHostConnections = defaultMaxActiveDownloadPieces + defaultMaxConcurrentPieceUploads
+
+	// defaultMaxDownloadWorkers limits the number of workers that fetch
+	// pieces for a single download in parallel.
+	defaultMaxDownloadWorkers = build.Select(build.Var{
+		Dev:      10,
+		Standard: 30,
+		Testing:  5,
+	}).(int)
+
+	// defaultMaxUploadWorkers limits the number of workers that upload
+	// pieces of a single chunk in parallel.
+	defaultMaxUploadWorkers = build.Select(build.Var{
+		Dev:      10,
+		Standard: 30,
+		Testing:  10,
+	}).(int)
+
+	// defaultDownloadBufferChunks is the number of recovered chunks that a
+	// download to a file collects before writing them out. A chunk is
+	// already written with a single call, so by default nothing more is
+	// buffered.
+	defaultDownloadBufferChunks = 1
+
+	// defaultPieceDownloadTimeout is the amount of time that a worker allows
+	// its host to deliver a piece before it has measured the host's
+	// latency. It also caps the adaptive timeout of slower hosts.
+	defaultPieceDownloadTimeout = build.Select(build.Var{
+		Dev:      2 * time.Minute,
+		Standard: 10 * time.Minute,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
+
+	// minPieceDownloadTimeout is the shortest amount of time that a worker
+	// allows its host to deliver a piece, however fast the host has been.
+	minPieceDownloadTimeout = build.Select(build.Var{
+		Dev:      5 * time.Second,
+		Standard: 20 * time.Second,
+		Testing:  2 * time.Second,
+	}).(time.Duration)
+
+	// defaultRepairScanFiles is the number of files whose health is checked
+	// on each new block.
+	defaultRepairScanFiles = build.Select(build.Var{
+		Dev:      5,
+		Standard: 10,
+		Testing:  5,
+	}).(int)
+
+	// defaultRepairThreshold is the percentage of a chunk's pieces that must
+	// be on online hosts before the health scan queues the chunk for repair.
+	// It matches the point at which a repair is willing to download the
+	// chunk from the network, when more than 25% of the redundancy is
+	// missing.
+	defaultRepairThreshold = 75
+
+	// defaultHostFailureThreshold is the number of consecutive failed piece
+	// downloads after which a host is skipped by the download loop.
+	defaultHostFailureThreshold = build.Select(build.Var{
+		Dev:      2,
+		Standard: 3,
+		Testing:  1,
+	}).(int)
+
+	// defaultHostFailureCooldown is the number of seconds that a host is
+	// skipped for once it has failed too many times in a row.
+	defaultHostFailureCooldown = build.Select(build.Var{
+		Dev:      60,
+		Standard: 600,
+		Testing:  30,
+	}).(int)
+
+	// maxRepairQueueChunks is the number of chunks that may be waiting in
+	// the repair queue. The health scan stops queuing chunks once it is
+	// reached, so that a large degraded file does not flood the uploader.
+	maxRepairQueueChunks = build.Select(build.Var{
+		Dev:      100,
+		Standard: 500,
+		Testing:  20,
+	}).(int)
+
 	// maxScheduledDownloads specifies the number of chunks that can be downloaded
 	// for auto repair at once. If the limit is reached new ones will only be scheduled
 	// once old ones are scheduled for upload
@@ -42,6 +157,15 @@ var (
 		Testing:  5,
 	}).(int)
 
+	// minDownloadStuckTimeout is the shortest amount of time that a started
+	// download may go without making progress before it is reported as
+	// stuck, however fast its hosts have been.
+	minDownloadStuckTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 5 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
 	// rebuildChunkHeapInterval defines how long the renter sleeps between
 	// checking on the filesystem health.
 	rebuildChunkHeapInterval = build.Select(build.Var{
@@ -10,6 +10,7 @@ package contractor
 import (
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
@@ -26,6 +27,8 @@ var (
 	errNilTpool  = errors.New("cannot create contractor with nil transaction pool")
 	errNilWallet = errors.New("cannot create contractor with nil wallet")
 
+	errBadSettingsTolerance = errors.New("settings tolerance must be a finite, non-negative fraction")
+
 	// COMPATv1.0.4-lts
 	// metricsContractID identifies a special contract that contains aggregate
 	// financial metrics from older contractors
@@ -148,6 +151,25 @@ func (c *Contractor) CurrentPeriod() types.BlockHeight {
 	return c.currentPeriod
 }
 
+// SettingsTolerance returns the fraction by which a host's prices may
+// increase, or its maximum duration decrease, between the time the host was
+// selected and the time an upload or contract formation begins.
+func (c *Contractor) SettingsTolerance() float64 {
+	return c.contracts.SettingsTolerance()
+}
+
+// SetSettingsTolerance sets the tolerance returned by SettingsTolerance.
+// Uploads and contract formations that are already underway keep the
+// tolerance they were started with.
+func (c *Contractor) SetSettingsTolerance(tolerance float64) error {
+	if tolerance < 0 || math.IsNaN(tolerance) || math.IsInf(tolerance, 0) {
+		return errBadSettingsTolerance
+	}
+	c.contracts.SetSettingsTolerance(tolerance)
+	c.log.Println("INFO: settings tolerance set to", tolerance)
+	return nil
+}
+
 // ResolveID returns the ID of the most recent renewal of id.
 func (c *Contractor) ResolveID(id types.FileContractID) types.FileContractID {
 	c.mu.RLock()
@@ -2,6 +2,7 @@ package contractor
 
 import (
 	"errors"
+	"math"
 	"os"
 	"reflect"
 	"testing"
@@ -125,6 +126,28 @@ func TestAllowance(t *testing.T) {
 	}
 }
 
+// TestSettingsTolerance tests the SetSettingsTolerance method.
+func TestSettingsTolerance(t *testing.T) {
+	var stub newStub
+	c, err := New(stub, stub, stub, stub, build.TempDir("contractor", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for _, tolerance := range []float64{-0.1, math.NaN(), math.Inf(1)} {
+		if err := c.SetSettingsTolerance(tolerance); err != errBadSettingsTolerance {
+			t.Errorf("expected %v for tolerance %v, got %v", errBadSettingsTolerance, tolerance, err)
+		}
+	}
+	if err := c.SetSettingsTolerance(0.2); err != nil {
+		t.Fatal(err)
+	}
+	if tolerance := c.SettingsTolerance(); tolerance != 0.2 {
+		t.Fatal("expected a tolerance of 0.2, got", tolerance)
+	}
+}
+
 // stubHostDB mocks the hostDB dependency using zero-valued implementations of
 // its methods.
 type stubHostDB struct{}
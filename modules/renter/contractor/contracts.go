@@ -107,6 +107,14 @@ func (c *Contractor) managedMarkContractsUtility() {
 				u.GoodForRenew = false
 				return
 			}
+			// Contract has no utility if the host is decommissioning. The
+			// contract is still used for downloads, so the repair loop can
+			// move the data to other hosts before the deadline.
+			if host.Decommission.Decommissioning {
+				u.GoodForUpload = false
+				u.GoodForRenew = false
+				return
+			}
 			// Contract has no utility if renew has already completed. (grab some
 			// extra values while we have the mutex)
 			c.mu.RLock()
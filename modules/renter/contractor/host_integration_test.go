@@ -654,3 +654,59 @@ func TestContractPresenceLeak(t *testing.T) {
 		t.Fatalf("Expected to get equal errors, got %q and %q.", errors[0], errors[1])
 	}
 }
+
+// TestIntegrationDecommissioningHost tests that contracts with a host that is
+// decommissioning are no longer used for uploading or renewed.
+func TestIntegrationDecommissioningHost(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.managedMarkContractsUtility()
+	if u, ok := c.ContractUtility(contract.ID); !ok || !u.GoodForUpload || !u.GoodForRenew {
+		t.Fatal("contract with a healthy host should be good for upload and renew:", u)
+	}
+
+	// decommission the host and wait for the hostdb to pick up the status.
+	err = h.Decommission(c.blockHeight + 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		entry, ok := c.hdb.Host(h.PublicKey())
+		if !ok {
+			return errors.New("no entry for host in db")
+		}
+		if !entry.Decommission.Decommissioning {
+			return errors.New("host is not reported as decommissioning")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the contract should no longer be used for uploading or renewed.
+	c.managedMarkContractsUtility()
+	if u, ok := c.ContractUtility(contract.ID); !ok || u.GoodForUpload || u.GoodForRenew {
+		t.Fatal("contract with a decommissioning host should not be good for upload or renew:", u)
+	}
+}
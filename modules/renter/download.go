@@ -7,8 +7,11 @@ package renter
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,32 +19,56 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/types"
 )
 
 const (
-	defaultFilePerm         = 0666
-	downloadFailureCooldown = time.Minute * 30
+	defaultFilePerm = 0666
 )
 
 var (
 	errInsufficientHosts  = errors.New("insufficient hosts to recover file")
 	errInsufficientPieces = errors.New("couldn't fetch enough pieces to recover data")
 	errPrevErr            = errors.New("download could not be completed due to a previous error")
+	errDownloadStuck      = errors.New("download was cancelled after making no progress for too long")
 
-	// maxActiveDownloadPieces determines the maximum number of pieces that are
-	// allowed to be concurrently downloading. More pieces means more
-	// parallelism, but also more RAM usage.
-	//
-	// TODO: Allow this number to be established in the renter settings.
-	maxActiveDownloadPieces = build.Select(build.Var{
+	// defaultMaxActiveDownloadPieces determines the default maximum number of
+	// pieces that are allowed to be concurrently downloading. More pieces
+	// means more parallelism, but also more RAM usage. The limit can be
+	// changed through the renter limits.
+	defaultMaxActiveDownloadPieces = build.Select(build.Var{
 		Standard: int(60),
 		Dev:      int(10),
 		Testing:  int(5),
 	}).(int)
 )
 
+// recoverPieces and recoverBuffers hold the piece slices and the recovery
+// buffers of recovered chunks, so that they are reused by the chunks recovered
+// after them instead of being allocated for every chunk.
+var (
+	recoverPieces  = sync.Pool{New: func() interface{} { return new([][]byte) }}
+	recoverBuffers = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+)
+
+// maxReportedDeficiencies is the maximum number of chunks listed in the
+// message of an insufficientHostsError.
+const maxReportedDeficiencies = 5
+
 type (
+	// chunkDeficiency describes a chunk that does not have enough pieces on
+	// online hosts to be recovered.
+	chunkDeficiency struct {
+		Chunk     uint64
+		Available int
+		Required  int
+	}
+
+	// insufficientHostsError is returned when a file cannot be recovered,
+	// and lists each chunk that is missing pieces.
+	insufficientHostsError []chunkDeficiency
+
 	// chunkDownload tracks the progress of a chunk. The chunk download object
 	// should only be read or modified inside of the main download loop thread.
 	chunkDownload struct {
@@ -56,12 +83,32 @@ type (
 		// have tried to fetch a piece of the chunk.
 		completedPieces map[uint64][]byte
 		workerAttempts  map[types.FileContractID]bool
+
+		// spare is the number of entries of the chunk in the download loop
+		// beyond the MinPieces entries that are counted as active pieces.
+		// Only downloads that race hosts have spare entries. Once the chunk
+		// has been recovered, its remaining entries are discarded and cancel
+		// is closed, so that the workers still fetching pieces of the chunk
+		// from slower hosts stop transferring data that is no longer needed.
+		// cancel is nil for chunks that do not race hosts.
+		spare     int
+		recovered bool
+		cancel    chan struct{}
 	}
 
 	// A download is a file download that has been queued by the renter.
 	download struct {
-		// Progress variables.
+		// Progress variables. atomicLastProgress is the time, in unix
+		// nanoseconds, at which the download last had a chunk scheduled or
+		// received data. It is zero until the first chunk is scheduled, so
+		// that downloads waiting in the queue are not considered stuck.
+		// atomicStuckTimeout is the amount of time, in nanoseconds, that the
+		// download may go without progress before it is stuck, as last
+		// derived from the latency of its hosts. It is zero until first
+		// derived.
 		atomicDataReceived uint64
+		atomicLastProgress int64
+		atomicStuckTimeout int64
 		downloadComplete   bool
 		downloadErr        error
 		finishedChunks     map[uint64]bool
@@ -73,12 +120,21 @@ type (
 		startTime    time.Time
 
 		// Static information about the file - can be read without a lock.
-		chunkSize   uint64
-		destination modules.DownloadWriter
-		erasureCode modules.ErasureCoder
-		fileSize    uint64
-		masterKey   crypto.TwofishKey
-		numChunks   uint64
+		// Downloads with a higher priority are given workers before downloads
+		// with a lower priority. hostPreference determines whether cheaper or
+		// faster workers are chosen for the download. Fastest downloads
+		// request pieces from more hosts than needed and keep the first
+		// pieces to arrive.
+		chunkSize      uint64
+		destination    modules.DownloadWriter
+		erasureCode    modules.ErasureCoder
+		fastest        bool
+		fileSize       uint64
+		hostPreference modules.HostPreference
+		masterKey      crypto.TwofishKey
+		numChunks      uint64
+		pieceSize      uint64
+		priority       int
 
 		// pieceSet contains a sparse map of the chunk indices to be downloaded to
 		// their piece data.
@@ -112,16 +168,51 @@ type (
 		// unless no more workers exist who can download pieces for that chunk,
 		// in which case the download has failed.
 		//
+		// downloadWorkers counts the active workers of each download, so that
+		// no single download uses more than the allowed number of workers.
+		//
+		// limits are the renter limits in effect for the current iteration.
+		//
+		// responsiveWorkers counts the workers that have not failed a
+		// download recently, whether they are idle or busy. New chunks are
+		// only scheduled while there are enough responsive workers to
+		// download their pieces in parallel.
+		//
 		// resultChan is the channel that is used to receive completed worker
 		// downloads.
-		activePieces     int
-		activeWorkers    map[types.FileContractID]struct{}
-		availableWorkers []*worker
-		incompleteChunks []*chunkDownload
-		resultChan       chan finishedDownload
+		activePieces      int
+		activeWorkers     map[types.FileContractID]struct{}
+		availableWorkers  []*worker
+		downloadWorkers   map[*download]int
+		incompleteChunks  []*chunkDownload
+		limits            modules.RenterLimits
+		responsiveWorkers int
+		resultChan        chan finishedDownload
 	}
 )
 
+// Error implements the error interface.
+func (e insufficientHostsError) Error() string {
+	reported := e
+	if len(reported) > maxReportedDeficiencies {
+		reported = reported[:maxReportedDeficiencies]
+	}
+	var buf bytes.Buffer
+	buf.WriteString(errInsufficientHosts.Error())
+	for i, cd := range reported {
+		if i == 0 {
+			buf.WriteString(": ")
+		} else {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "chunk %v has %v of %v required pieces", cd.Chunk, cd.Available, cd.Required)
+	}
+	if len(e) > len(reported) {
+		fmt.Fprintf(&buf, " (and %v more chunks)", len(e)-len(reported))
+	}
+	return buf.String()
+}
+
 // newSectionDownload initializes and returns a download object for the specified chunk.
 func (r *Renter) newSectionDownload(f *file, destination modules.DownloadWriter, offset, length uint64) *download {
 	d := newDownload(f, destination)
@@ -146,6 +237,23 @@ func (r *Renter) newSectionDownload(f *file, destination modules.DownloadWriter,
 	}
 
 	d.initPieceSet(f, r)
+	go r.threadedCancelStuckDownload(d)
+	return d
+}
+
+// newChunksDownload initializes and returns a download object for the
+// specified chunks of a file. Each chunk is written to the destination at its
+// offset within the file, leaving the rest of the destination untouched.
+func (r *Renter) newChunksDownload(f *file, destination modules.DownloadWriter, chunks []uint64) *download {
+	d := newDownload(f, destination)
+	d.offset = 0
+	d.length = f.size
+	for _, i := range chunks {
+		d.finishedChunks[i] = false
+	}
+
+	d.initPieceSet(f, r)
+	go r.threadedCancelStuckDownload(d)
 	return d
 }
 
@@ -159,6 +267,7 @@ func newDownload(f *file, destination modules.DownloadWriter) *download {
 		fileSize:         f.size,
 		masterKey:        f.masterKey,
 		numChunks:        f.numChunks(),
+		pieceSize:        f.pieceSize,
 		siapath:          f.name,
 		downloadFinished: make(chan struct{}),
 		finishedChunks:   make(map[uint64]bool),
@@ -207,6 +316,95 @@ func (d *download) Err() error {
 	return d.downloadErr
 }
 
+// Stuck returns true if the download is still running but has not made any
+// progress for longer than its stuck timeout. A stuck download has not
+// failed, but its hosts are too slow for it to make progress.
+func (d *download) Stuck() bool {
+	d.mu.Lock()
+	complete := d.downloadComplete
+	d.mu.Unlock()
+	timeout := time.Duration(atomic.LoadInt64(&d.atomicStuckTimeout))
+	return !complete && timeout != 0 && d.timeSinceProgress() > timeout
+}
+
+// timeSinceProgress returns the amount of time since the download last made
+// progress, or zero if the download has not started yet.
+func (d *download) timeSinceProgress() time.Duration {
+	lastProgress := atomic.LoadInt64(&d.atomicLastProgress)
+	if lastProgress == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(0, lastProgress))
+}
+
+// managedCancelIfStuck fails the download if it has not made any progress
+// for downloadStuckCancelFactor times its stuck timeout. It returns true if
+// the download has completed, either because it was cancelled or for any
+// other reason.
+func (d *download) managedCancelIfStuck() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	timeout := time.Duration(atomic.LoadInt64(&d.atomicStuckTimeout))
+	if !d.downloadComplete && timeout != 0 && d.timeSinceProgress() > timeout*downloadStuckCancelFactor {
+		d.fail(errDownloadStuck)
+	}
+	return d.downloadComplete
+}
+
+// managedStuckTimeout returns the amount of time that the download may go
+// without making progress before it is stuck, which is
+// downloadStuckTimeoutFactor times the longest piece download timeout of the
+// workers holding pieces of the download. Downloads from fast hosts are
+// reported quickly, while slow hosts are given as long as they usually need.
+func (r *Renter) managedStuckTimeout(d *download) time.Duration {
+	var slowest time.Duration
+	id := r.mu.RLock()
+	for _, pieces := range d.pieceSet {
+		for fcid := range pieces {
+			w, exists := r.workerPool[fcid]
+			if !exists {
+				continue
+			}
+			if timeout := w.downloadTimeout(); timeout > slowest {
+				slowest = timeout
+			}
+		}
+	}
+	r.mu.RUnlock(id)
+	if slowest == 0 {
+		slowest = defaultPieceDownloadTimeout
+	}
+	timeout := slowest * downloadStuckTimeoutFactor
+	if timeout < minDownloadStuckTimeout {
+		return minDownloadStuckTimeout
+	}
+	return timeout
+}
+
+// threadedCancelStuckDownload periodically derives the stuck timeout of the
+// download from the latency of its hosts and checks whether the download is
+// stuck, cancelling it once it has been stuck for too long.
+func (r *Renter) threadedCancelStuckDownload(d *download) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	for {
+		timeout := r.managedStuckTimeout(d)
+		atomic.StoreInt64(&d.atomicStuckTimeout, int64(timeout))
+		select {
+		case <-d.downloadFinished:
+			return
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(timeout):
+		}
+		if d.managedCancelIfStuck() {
+			return
+		}
+	}
+}
+
 // fail will mark the download as complete, but with the provided error.
 func (d *download) fail(err error) {
 	if d.downloadComplete {
@@ -216,17 +414,56 @@ func (d *download) fail(err error) {
 
 	d.downloadComplete = true
 	d.downloadErr = err
-	close(d.downloadFinished)
 	// TODO: log the error from Close().
 	d.destination.Close()
+	close(d.downloadFinished)
+}
+
+// getRecoverPieces returns a slice of numPieces nil pieces from recoverPieces.
+func getRecoverPieces(numPieces int) *[][]byte {
+	pieces := recoverPieces.Get().(*[][]byte)
+	if cap(*pieces) < numPieces {
+		*pieces = make([][]byte, numPieces)
+	}
+	*pieces = (*pieces)[:numPieces]
+	return pieces
+}
+
+// putRecoverPieces returns pieces to recoverPieces. The pieces are cleared
+// first, so that the pool does not keep their data alive or hand it to the
+// next chunk.
+func putRecoverPieces(pieces *[][]byte) {
+	all := (*pieces)[:cap(*pieces)]
+	for i := range all {
+		all[i] = nil
+	}
+	recoverPieces.Put(pieces)
+}
+
+// getRecoverBuffer returns an empty buffer from recoverBuffers that can hold
+// size bytes without growing.
+func getRecoverBuffer(size uint64) *bytes.Buffer {
+	buf := recoverBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	buf.Grow(int(size))
+	return buf
+}
+
+// putRecoverBuffer empties buf and returns it to recoverBuffers. Only the
+// bytes written after the next call to getRecoverBuffer can be read from it.
+func putRecoverBuffer(buf *bytes.Buffer) {
+	buf.Reset()
+	recoverBuffers.Put(buf)
 }
 
 // recoverChunk takes a chunk that has had a sufficient number of pieces
 // downloaded and verifies, decrypts and decodes them into the file.
 func (cd *chunkDownload) recoverChunk() error {
 	// Assemble the chunk from the download.
+	pieces := getRecoverPieces(cd.download.erasureCode.NumPieces())
+	defer putRecoverPieces(pieces)
+	chunk := *pieces
 	cd.download.mu.Lock()
-	chunk := make([][]byte, cd.download.erasureCode.NumPieces())
 	for pieceIndex, pieceData := range cd.completedPieces {
 		chunk[pieceIndex] = pieceData
 	}
@@ -246,6 +483,11 @@ func (cd *chunkDownload) recoverChunk() error {
 			continue
 		}
 
+		// Strip the padding that was added to pieces smaller than a sector.
+		if encryptedSize := cd.download.pieceSize + crypto.TwofishOverhead; uint64(len(chunk[i])) > encryptedSize {
+			chunk[i] = chunk[i][:encryptedSize]
+		}
+
 		// Decrypt the piece.
 		key := deriveKey(cd.download.masterKey, cd.index, uint64(i))
 		decryptedPiece, err := key.DecryptBytes(chunk[i])
@@ -255,12 +497,14 @@ func (cd *chunkDownload) recoverChunk() error {
 		chunk[i] = decryptedPiece
 	}
 
-	// Recover the chunk into a byte slice.
-	recoverWriter := new(bytes.Buffer)
+	// Recover the chunk into a byte slice. The destination does not retain
+	// the slice, so the buffer can be reused once the chunk is written.
 	recoverSize := cd.download.chunkSize
 	if cd.index == cd.download.numChunks-1 && cd.download.fileSize%cd.download.chunkSize != 0 {
 		recoverSize = cd.download.fileSize % cd.download.chunkSize
 	}
+	recoverWriter := getRecoverBuffer(recoverSize)
+	defer putRecoverBuffer(recoverWriter)
 	err := cd.download.erasureCode.Recover(chunk, recoverSize, recoverWriter)
 	if err != nil {
 		return build.ExtendErr("unable to recover chunk", err)
@@ -319,13 +563,13 @@ func (cd *chunkDownload) recoverChunk() error {
 		}
 	}
 	if nowComplete {
-		// Signal that the download is complete.
+		// Signal that the download is complete. The destination is closed
+		// first, so that any buffered data has been written by the time the
+		// download is reported as complete.
 		cd.download.downloadComplete = true
+		cd.download.downloadErr = cd.download.destination.Close()
 		close(cd.download.downloadFinished)
-		err = cd.download.destination.Close()
-		if err != nil {
-			return err
-		}
+		return cd.download.downloadErr
 	}
 	return nil
 }
@@ -343,6 +587,7 @@ func (r *Renter) addDownloadToChunkQueue(d *download) {
 	}
 
 	// Add the unfinished chunks one at a time.
+	var chunks []*chunkDownload
 	for i, isChunkFinished := range d.finishedChunks {
 		// Skip chunks that have already finished downloading.
 		if isChunkFinished {
@@ -360,8 +605,20 @@ func (r *Renter) addDownloadToChunkQueue(d *download) {
 		for fcid := range d.pieceSet[i] {
 			cd.workerAttempts[fcid] = false
 		}
-		r.chunkQueue = append(r.chunkQueue, cd)
+		chunks = append(chunks, cd)
 	}
+
+	// Queue the chunks behind every chunk of equal or higher priority, so
+	// that downloads of the same priority are served in the order that they
+	// were submitted.
+	pos := len(r.chunkQueue)
+	for pos > 0 && r.chunkQueue[pos-1].download.priority < d.priority {
+		pos--
+	}
+	queue := make([]*chunkDownload, 0, len(r.chunkQueue)+len(chunks))
+	queue = append(queue, r.chunkQueue[:pos]...)
+	queue = append(queue, chunks...)
+	r.chunkQueue = append(queue, r.chunkQueue[pos:]...)
 }
 
 // downloadIteration performs one iteration of the download loop.
@@ -375,6 +632,7 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 			// workaround and needs to be fixed
 			ds.activePieces = 0
 		}
+		atomic.StoreInt64(&r.atomicActiveDownloadPieces, 0)
 
 		// Nothing to do. Sleep until there is something to do, or until
 		// shutdown.
@@ -388,17 +646,22 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 
 	// Update the set of workers to include everyone in the worker pool.
 	r.managedUpdateWorkerPool()
+	now := time.Now()
+	r.hostBreaker.prune(now)
 	id := r.mu.Lock()
 	ds.availableWorkers = make([]*worker, 0, len(r.workerPool))
+	ds.responsiveWorkers = 0
 	for _, worker := range r.workerPool {
-		// Ignore workers that are already in the active set of workers.
-		_, exists := ds.activeWorkers[worker.contract.ID]
-		if exists {
+		// Ignore workers whose host has been failing, unless the host's
+		// cooldown has passed and it can be probed.
+		if !r.hostBreaker.allow(worker.breakerKey(), now) {
 			continue
 		}
+		ds.responsiveWorkers++
 
-		// Ignore workers that have a download failure recently.
-		if time.Since(worker.downloadRecentFailure) < downloadFailureCooldown {
+		// Ignore workers that are already in the active set of workers.
+		_, exists := ds.activeWorkers[worker.contract.ID]
+		if exists {
 			continue
 		}
 
@@ -411,10 +674,12 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 	r.mu.Unlock(id)
 
 	// Add new chunks to the extent that resources allow.
+	ds.limits = r.managedLimits()
 	r.managedScheduleNewChunks(ds)
 
 	// Check for incomplete chunks, and assign workers to them where possible.
 	r.managedScheduleIncompleteChunks(ds)
+	atomic.StoreInt64(&r.atomicActiveDownloadPieces, int64(ds.activePieces))
 
 	// Wait for workers to return after downloading pieces.
 	r.managedWaitOnDownloadWork(ds)
@@ -425,9 +690,20 @@ func (r *Renter) managedDownloadIteration(ds *downloadState) {
 // managedScheduleIncompleteChunks also checks whether a chunk is unable to be
 // completed.
 func (r *Renter) managedScheduleIncompleteChunks(ds *downloadState) {
+	// Offer the available workers to the highest priority downloads first.
+	sort.SliceStable(ds.incompleteChunks, func(i, j int) bool {
+		return ds.incompleteChunks[i].download.priority > ds.incompleteChunks[j].download.priority
+	})
+
 	var newIncompleteChunks []*chunkDownload
 loop:
 	for _, incompleteChunk := range ds.incompleteChunks {
+		// Drop the spare entries of chunks that have already been recovered
+		// from the pieces of faster hosts.
+		if incompleteChunk.recovered {
+			continue
+		}
+
 		// Drop this chunk if the file download has failed in any way.
 		incompleteChunk.download.mu.Lock()
 		downloadComplete := incompleteChunk.download.downloadComplete
@@ -435,7 +711,7 @@ loop:
 		if downloadComplete {
 			// The download has most likely failed. No need to complete this
 			// chunk.
-			ds.activePieces--                                       // For the current incomplete chunk.
+			ds.dropChunkEntry(incompleteChunk)                      // For the current incomplete chunk.
 			ds.activePieces -= len(incompleteChunk.completedPieces) // For all completed pieces.
 
 			// Clear the set of completed pieces so that we do not
@@ -444,9 +720,18 @@ loop:
 			continue
 		}
 
+		// If the download already has as many active workers as it is
+		// allowed, keep the chunk until one of them returns.
+		if ds.downloadWorkers[incompleteChunk.download] >= ds.limits.MaxDownloadWorkers {
+			newIncompleteChunks = append(newIncompleteChunks, incompleteChunk)
+			continue
+		}
+
 		// Try to find a worker that is able to pick up the slack on the
-		// incomplete download from the set of available workers.
-		for i, worker := range ds.availableWorkers {
+		// incomplete download from the set of available workers, choosing
+		// between them according to the host preference of the download.
+		var candidates []*worker
+		for _, worker := range ds.availableWorkers {
 			scheduled, exists := incompleteChunk.workerAttempts[worker.contract.ID]
 			if scheduled || !exists {
 				// Either this worker does not contain a piece of this chunk,
@@ -454,12 +739,14 @@ loop:
 				// piece for this chunk.
 				continue
 			}
-
-			piece, exists := incompleteChunk.download.pieceSet[incompleteChunk.index][worker.contract.ID]
-			if !exists {
+			if _, exists := incompleteChunk.download.pieceSet[incompleteChunk.index][worker.contract.ID]; !exists {
 				continue
 			}
-
+			candidates = append(candidates, worker)
+		}
+		if len(candidates) > 0 {
+			worker := candidates[preferredWorker(candidates, incompleteChunk.download.hostPreference)]
+			piece := incompleteChunk.download.pieceSet[incompleteChunk.index][worker.contract.ID]
 			dw := downloadWork{
 				dataRoot:      piece.MerkleRoot,
 				pieceIndex:    piece.Piece,
@@ -467,8 +754,15 @@ loop:
 				resultChan:    ds.resultChan,
 			}
 			incompleteChunk.workerAttempts[worker.contract.ID] = true
-			ds.availableWorkers = append(ds.availableWorkers[:i], ds.availableWorkers[i+1:]...)
+			r.hostBreaker.dispatched(worker.breakerKey())
+			for i := range ds.availableWorkers {
+				if ds.availableWorkers[i] == worker {
+					ds.availableWorkers = append(ds.availableWorkers[:i], ds.availableWorkers[i+1:]...)
+					break
+				}
+			}
 			ds.activeWorkers[worker.contract.ID] = struct{}{}
+			ds.downloadWorkers[incompleteChunk.download]++
 			select {
 			case worker.priorityDownloadChan <- dw:
 			default:
@@ -497,10 +791,22 @@ loop:
 		// or the active set is able to pick up the slack. Verify that they are
 		// safe to be scheduled, and then schedule them if so.
 
+		// Spare entries are not needed to recover the chunk, so running out
+		// of workers for them does not fail the download.
+		if incompleteChunk.spare > 0 {
+			incompleteChunk.spare--
+			continue
+		}
+
 		// Cannot find workers to complete this download, fail the download
 		// connected to this chunk.
-		r.log.Println("Not enough workers to finish download:", errInsufficientHosts)
-		incompleteChunk.download.fail(errInsufficientHosts)
+		err := insufficientHostsError{{
+			Chunk:     incompleteChunk.index,
+			Available: len(incompleteChunk.completedPieces),
+			Required:  incompleteChunk.download.erasureCode.MinPieces(),
+		}}
+		r.log.Println("Not enough workers to finish download:", err)
+		incompleteChunk.download.fail(err)
 
 		// Clear out the piece burden for this chunk.
 		ds.activePieces--                                       // for the current incomplete chunk
@@ -512,6 +818,16 @@ loop:
 	ds.incompleteChunks = newIncompleteChunks
 }
 
+// dropChunkEntry removes an entry of cd from the download loop, releasing the
+// active piece that it holds unless it is a spare entry.
+func (ds *downloadState) dropChunkEntry(cd *chunkDownload) {
+	if cd.spare > 0 {
+		cd.spare--
+		return
+	}
+	ds.activePieces--
+}
+
 // managedScheduleNewChunks uses the set of available workers to schedule new
 // chunks if there are resources available to begin downloading them.
 func (r *Renter) managedScheduleNewChunks(ds *downloadState) {
@@ -527,12 +843,29 @@ func (r *Renter) managedScheduleNewChunks(ds *downloadState) {
 		nextChunk := r.chunkQueue[0]
 
 		// Check whether there are enough resources to perform the download.
-		if ds.activePieces+nextChunk.download.erasureCode.MinPieces() > maxActiveDownloadPieces {
+		// A chunk cannot be recovered from fewer than MinPieces pieces, so
+		// the limit is raised to MinPieces if it is lower; otherwise the
+		// chunk could never be scheduled.
+		minPieces := nextChunk.download.erasureCode.MinPieces()
+		maxPieces := ds.limits.MaxConcurrentDownloads
+		if maxPieces < minPieces {
+			maxPieces = minPieces
+		}
+		if ds.activePieces+minPieces > maxPieces {
 			// There is a limited amount of RAM available, and scheduling the
 			// next piece would consume too much RAM.
 			return
 		}
 
+		// Check whether there are enough responsive workers to download the
+		// pieces of the chunk alongside the pieces already scheduled. Pieces
+		// beyond that would only wait for a busy worker. A chunk is always
+		// scheduled when nothing else is, so that downloads with fewer hosts
+		// than pieces still make progress or fail.
+		if ds.activePieces > 0 && ds.activePieces+nextChunk.download.erasureCode.MinPieces() > ds.responsiveWorkers {
+			return
+		}
+
 		// Chunk is set to be downloaded. Clear it from the queue.
 		r.chunkQueue = r.chunkQueue[1:]
 
@@ -547,10 +880,27 @@ func (r *Renter) managedScheduleNewChunks(ds *downloadState) {
 		}
 
 		// Add an incomplete chunk entry for every piece of the download.
-		for i := 0; i < nextChunk.download.erasureCode.MinPieces(); i++ {
+		atomic.StoreInt64(&nextChunk.download.atomicLastProgress, time.Now().UnixNano())
+		for i := 0; i < minPieces; i++ {
 			ds.incompleteChunks = append(ds.incompleteChunks, nextChunk)
 		}
-		ds.activePieces += nextChunk.download.erasureCode.MinPieces()
+		ds.activePieces += minPieces
+
+		// Fastest downloads race up to twice as many hosts as needed for
+		// the chunk. The spare entries do not count towards the active
+		// pieces, as only the first MinPieces pieces to arrive are kept.
+		if nextChunk.download.fastest {
+			nextChunk.cancel = make(chan struct{})
+			nextChunk.spare = len(nextChunk.download.pieceSet[nextChunk.index]) - minPieces
+			if nextChunk.spare > minPieces {
+				nextChunk.spare = minPieces
+			} else if nextChunk.spare < 0 {
+				nextChunk.spare = 0
+			}
+			for i := 0; i < nextChunk.spare; i++ {
+				ds.incompleteChunks = append(ds.incompleteChunks, nextChunk)
+			}
+		}
 	}
 }
 
@@ -577,6 +927,11 @@ func (r *Renter) managedWaitOnDownloadWork(ds *downloadState) {
 	// Prepare the piece.
 	workerID := finishedDownload.workerID
 	delete(ds.activeWorkers, workerID)
+	d := finishedDownload.chunkDownload.download
+	ds.downloadWorkers[d]--
+	if ds.downloadWorkers[d] <= 0 {
+		delete(ds.downloadWorkers, d)
+	}
 
 	// Fetch the corresponding worker.
 	id := r.mu.RLock()
@@ -591,8 +946,27 @@ func (r *Renter) managedWaitOnDownloadWork(ds *downloadState) {
 	cd := finishedDownload.chunkDownload
 	if finishedDownload.err != nil {
 		r.log.Debugln("Error when downloading a piece:", finishedDownload.err)
-		worker.downloadRecentFailure = time.Now()
-		ds.incompleteChunks = append(ds.incompleteChunks, cd)
+		// A worker that was killed before connecting, or a piece that was
+		// no longer needed, says nothing about the health of its host.
+		if finishedDownload.err == errWorkerKilled || finishedDownload.err == errPieceCancelled {
+			r.hostBreaker.abandoned(worker.breakerKey())
+		} else {
+			limits := r.managedLimits()
+			cooldown := time.Duration(limits.HostFailureCooldown) * time.Second
+			if r.hostBreaker.recordFailure(worker.breakerKey(), limits.HostFailureThreshold, cooldown, time.Now()) {
+				r.log.Debugf("Skipping host %v for %v after repeated download failures", worker.breakerKey(), cooldown)
+			}
+		}
+		if !cd.recovered {
+			ds.incompleteChunks = append(ds.incompleteChunks, cd)
+		}
+		return
+	}
+	r.hostBreaker.recordSuccess(worker.breakerKey())
+
+	// Discard pieces of chunks that were already recovered from the pieces
+	// of faster hosts.
+	if cd.recovered {
 		return
 	}
 
@@ -604,16 +978,21 @@ func (r *Renter) managedWaitOnDownloadWork(ds *downloadState) {
 	}
 	cd.completedPieces[finishedDownload.pieceIndex] = finishedDownload.data
 	atomic.AddUint64(&cd.download.atomicDataReceived, cd.download.reportedPieceSize)
+	atomic.StoreInt64(&cd.download.atomicLastProgress, time.Now().UnixNano())
 
 	// If the chunk has completed, perform chunk recovery.
 	if len(cd.completedPieces) == cd.download.erasureCode.MinPieces() {
 		err := cd.recoverChunk()
+		cd.recovered = true
+		if cd.cancel != nil {
+			close(cd.cancel)
+		}
 		ds.activePieces -= len(cd.completedPieces)
 		cd.completedPieces = make(map[uint64][]byte)
 		if err != nil {
 			r.log.Println("Download failed - could not recover a chunk:", err)
 			cd.download.mu.Lock()
-			cd.download.fail(err)
+			cd.download.fail(fmt.Errorf("chunk %v: %v", cd.index, err))
 			cd.download.mu.Unlock()
 		}
 	}
@@ -634,6 +1013,7 @@ func (r *Renter) threadedDownloadLoop() {
 	ds := &downloadState{
 		activeWorkers:    make(map[types.FileContractID]struct{}),
 		availableWorkers: availableWorkers,
+		downloadWorkers:  make(map[*download]int),
 		incompleteChunks: make([]*chunkDownload, 0),
 		resultChan:       make(chan finishedDownload),
 	}
@@ -690,26 +1070,70 @@ func (dw *DownloadBufferWriter) Close() error {
 }
 
 // DownloadFileWriter is a file-backed implementation of DownloadWriter.
+// Consecutive writes can be collected in a buffer and written to the file with
+// a single call, see NewBufferedDownloadFileWriter.
 type DownloadFileWriter struct {
 	f        *os.File
 	location string
 	offset   uint64
 	written  uint64
 	length   uint64
+
+	// buf holds data that has been written to the DownloadFileWriter but not
+	// yet to the file. It starts at bufOff within the file, and is flushed
+	// once it reaches bufferSize bytes.
+	buf        []byte
+	bufOff     int64
+	bufferSize uint64
+	mu         sync.Mutex
 }
 
 // NewDownloadFileWriter creates a new instance of a DownloadWriter backed by the file named.
 func NewDownloadFileWriter(fname string, offset, length uint64) (*DownloadFileWriter, error) {
+	return NewBufferedDownloadFileWriter(fname, offset, length, 0)
+}
+
+// NewBufferedDownloadFileWriter creates a new instance of a DownloadWriter
+// backed by the file named, which collects consecutive writes until it holds
+// bufferSize bytes before writing them to the file. A write that does not
+// continue the buffered data flushes the buffer first. A bufferSize of 0
+// writes everything immediately.
+func NewBufferedDownloadFileWriter(fname string, offset, length, bufferSize uint64) (*DownloadFileWriter, error) {
 	l, err := os.OpenFile(fname, os.O_CREATE|os.O_WRONLY, defaultFilePerm)
 	if err != nil {
 		return nil, err
 	}
 	return &DownloadFileWriter{
-		f:        l,
-		location: fname,
-		offset:   offset,
-		written:  0,
-		length:   length,
+		f:          l,
+		location:   fname,
+		offset:     offset,
+		written:    0,
+		length:     length,
+		bufferSize: bufferSize,
+	}, nil
+}
+
+// newTempDownloadFileWriter creates a new instance of a DownloadWriter backed
+// by a new temporary file in tempDir, which reports destination as its
+// location. The temporary file is moved to destination by commitDownload once
+// the download has completed. If tempDir is empty, the temporary file is
+// created next to destination.
+func newTempDownloadFileWriter(destination, tempDir string, offset, length, bufferSize uint64) (*DownloadFileWriter, error) {
+	if tempDir == "" {
+		tempDir = filepath.Dir(destination)
+	}
+	tempName := filepath.Join(tempDir, "."+filepath.Base(destination)+"_"+persist.RandomSuffix())
+	f, err := os.OpenFile(tempName, os.O_CREATE|os.O_EXCL|os.O_WRONLY, defaultFilePerm)
+	if err != nil {
+		return nil, err
+	}
+	return &DownloadFileWriter{
+		f:          f,
+		location:   destination,
+		offset:     offset,
+		written:    0,
+		length:     length,
+		bufferSize: bufferSize,
 	}, nil
 }
 
@@ -719,23 +1143,59 @@ func (dw *DownloadFileWriter) Destination() string {
 	return dw.location
 }
 
+// flush writes the buffered data to the file. The caller must hold dw.mu.
+func (dw *DownloadFileWriter) flush() error {
+	if len(dw.buf) == 0 {
+		return nil
+	}
+	_, err := dw.f.WriteAt(dw.buf, dw.bufOff)
+	dw.buf = dw.buf[:0]
+	return err
+}
+
 // WriteAt writes the passed bytes at the specified offset.
 func (dw *DownloadFileWriter) WriteAt(b []byte, off int64) (int, error) {
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+
 	if dw.written+uint64(len(b)) > dw.length {
 		build.Critical("DownloadFileWriter write exceeds file length")
 	}
-	n, err := dw.f.WriteAt(b, off-int64(dw.offset))
-	if err != nil {
+	fileOff := off - int64(dw.offset)
+	if dw.bufferSize == 0 {
+		n, err := dw.f.WriteAt(b, fileOff)
+		if err != nil {
+			return n, err
+		}
+		dw.written += uint64(n)
 		return n, err
 	}
-	dw.written += uint64(n)
-	return n, err
+
+	// Flush the buffer if b does not continue it.
+	if len(dw.buf) > 0 && fileOff != dw.bufOff+int64(len(dw.buf)) {
+		if err := dw.flush(); err != nil {
+			return 0, err
+		}
+	}
+	if len(dw.buf) == 0 {
+		dw.bufOff = fileOff
+	}
+	dw.buf = append(dw.buf, b...)
+	dw.written += uint64(len(b))
+	if uint64(len(dw.buf)) >= dw.bufferSize {
+		if err := dw.flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
 }
 
-// Close implements DownloadWriter's Close method and releases the file opened
-// by the DownloadFileWriter.
+// Close implements DownloadWriter's Close method, writing any buffered data
+// and releasing the file opened by the DownloadFileWriter.
 func (dw *DownloadFileWriter) Close() error {
-	return dw.f.Close()
+	dw.mu.Lock()
+	defer dw.mu.Unlock()
+	return build.ComposeErrors(dw.flush(), dw.f.Close())
 }
 
 // DownloadHttpWriter is a http response writer-backed implementation of
@@ -778,9 +1238,10 @@ func (dw *DownloadHttpWriter) Close() error {
 // WriteAt buffers parts of the file until the entire file can be
 // flushed to the client. Returns the number of bytes written or an error.
 func (dw *DownloadHttpWriter) WriteAt(b []byte, off int64) (int, error) {
-	// Write bytes to buffer.
+	// Write bytes to buffer. The buffer holds a copy of b, as b may be
+	// reused by the caller once WriteAt returns.
 	offsetInBuffer := int(off) - dw.firstByteIndex
-	dw.buffer[offsetInBuffer] = b
+	dw.buffer[offsetInBuffer] = append([]byte(nil), b...)
 
 	// Send all chunks to the client that can be sent.
 	totalDataSent := 0
@@ -803,3 +1264,25 @@ func (dw *DownloadHttpWriter) WriteAt(b []byte, off int64) (int, error) {
 
 	return totalDataSent, nil
 }
+
+// downloadDiscardWriter is a DownloadWriter that discards all of the data
+// written to it. It is used to verify that a file can be recovered without
+// storing the recovered data.
+type downloadDiscardWriter struct{}
+
+// Destination implements the Destination method of the DownloadWriter
+// interface.
+func (downloadDiscardWriter) Destination() string {
+	return "discard"
+}
+
+// WriteAt implements the WriteAt method of the DownloadWriter interface,
+// discarding b.
+func (downloadDiscardWriter) WriteAt(b []byte, off int64) (int, error) {
+	return len(b), nil
+}
+
+// Close implements the Close method of the DownloadWriter interface.
+func (downloadDiscardWriter) Close() error {
+	return nil
+}
@@ -1,11 +1,19 @@
 package renter
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
 )
 
 // TestRenterDownloadFileWriter verifies that the renter's DownloadFileWriter
@@ -50,3 +58,549 @@ func TestRenterDownloadFileWriter(t *testing.T) {
 		t.Fatal("expected read to return file already closed, got", err, "instead.")
 	}
 }
+
+// TestRenterBufferedDownloadFileWriter checks that a buffered
+// DownloadFileWriter collects consecutive writes and writes everything to the
+// file by the time it is closed.
+func TestRenterBufferedDownloadFileWriter(t *testing.T) {
+	testPath, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(testPath)
+	testPath = filepath.Join(testPath, "testfile")
+	df, err := NewBufferedDownloadFileWriter(testPath, 100, 30, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileSize := func() int64 {
+		fi, err := os.Stat(testPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fi.Size()
+	}
+
+	// Writes smaller than the buffer are held back until the buffer fills.
+	if _, err := df.WriteAt(bytes.Repeat([]byte{1}, 4), 100); err != nil {
+		t.Fatal(err)
+	}
+	if fileSize() != 0 {
+		t.Fatal("small write should have been buffered")
+	}
+	if _, err := df.WriteAt(bytes.Repeat([]byte{2}, 6), 104); err != nil {
+		t.Fatal(err)
+	}
+	if fileSize() != 10 {
+		t.Fatal("full buffer should have been written, file size is", fileSize())
+	}
+
+	// A write that does not continue the buffer flushes it.
+	if _, err := df.WriteAt(bytes.Repeat([]byte{4}, 5), 125); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := df.WriteAt(bytes.Repeat([]byte{3}, 5), 110); err != nil {
+		t.Fatal(err)
+	}
+	if fileSize() != 30 {
+		t.Fatal("out of order write should have flushed the buffer, file size is", fileSize())
+	}
+
+	if err := df.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(testPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected []byte
+	expected = append(expected, bytes.Repeat([]byte{1}, 4)...)
+	expected = append(expected, bytes.Repeat([]byte{2}, 6)...)
+	expected = append(expected, bytes.Repeat([]byte{3}, 5)...)
+	expected = append(expected, make([]byte, 10)...)
+	expected = append(expected, bytes.Repeat([]byte{4}, 5)...)
+	if !bytes.Equal(data, expected) {
+		t.Fatal("file has the wrong contents:", data)
+	}
+}
+
+// TestVerifyFileTrivial checks the cases of VerifyFile that do not require
+// downloading any data.
+func TestVerifyFileTrivial(t *testing.T) {
+	r := &Renter{
+		files: make(map[string]*file),
+		mu:    siasync.New(modules.SafeMutexDelay, 1),
+	}
+	if err := r.VerifyFile("missing"); err == nil {
+		t.Fatal("expected verifying a missing file to fail")
+	}
+
+	rsc, _ := NewRSCode(1, 1)
+	r.files["empty"] = newFile("empty", rsc, 100, 0)
+	if err := r.VerifyFile("empty"); err != nil {
+		t.Fatal("expected an empty file to be recoverable, got", err)
+	}
+}
+
+// TestRedownloadTrivial checks the cases of Redownload that do not require
+// downloading any data, along with the detection of corrupt chunks.
+func TestRedownloadTrivial(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := &Renter{
+		files: make(map[string]*file),
+		mu:    siasync.New(modules.SafeMutexDelay, 1),
+	}
+
+	// A file that does not correspond to anything the renter has uploaded
+	// cannot be repaired.
+	data := fastrand.Bytes(1000)
+	dst := filepath.Join(dir, "dst")
+	if err := ioutil.WriteFile(dst, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Redownload(dst); err != errUnknownDestination {
+		t.Fatal("expected errUnknownDestination, got", err)
+	}
+	if err := r.Redownload("relative"); err == nil {
+		t.Fatal("expected a relative destination to be rejected")
+	}
+
+	// Once the renter knows the chunk hashes of the file, an intact local
+	// file is found and left untouched.
+	rsc, _ := NewRSCode(1, 1)
+	f := newFile("foo", rsc, 100, uint64(len(data)))
+	_, f.chunkHashes, err = hashChunks(bytes.NewReader(data), f.chunkSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.files[f.name] = f
+	if err := r.Redownload(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupting a chunk, or truncating the file, should be detected.
+	corrupt := append([]byte(nil), data...)
+	corrupt[450] ^= 1
+	chunks, err := corruptChunks(bytes.NewReader(corrupt[:850]), f.chunkSize(), f.chunkHashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 || chunks[0] != 4 || chunks[1] != 8 || chunks[2] != 9 {
+		t.Fatal("wrong corrupt chunks:", chunks)
+	}
+}
+
+// TestCommitDownload checks that a download written to a temporary file is
+// only moved to its destination if it matches the uploaded data.
+func TestCommitDownload(t *testing.T) {
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	data := fastrand.Bytes(1000)
+	rsc, _ := NewRSCode(1, 1)
+	f := newFile("foo", rsc, 100, uint64(len(data)))
+	_, f.chunkHashes, err = hashChunks(bytes.NewReader(data), f.chunkSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst := filepath.Join(dir, "dst")
+
+	// A corrupt download leaves the destination untouched.
+	dw, err := newTempDownloadFileWriter(dst, "", 0, uint64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dw.Destination() != dst || filepath.Dir(dw.f.Name()) != dir {
+		t.Fatal("temporary file created in the wrong place:", dw.Destination(), dw.f.Name())
+	}
+	corrupt := append([]byte(nil), data...)
+	corrupt[0]++
+	if _, err := dw.WriteAt(corrupt, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := dw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := commitDownload(f, dw.f.Name(), dst, 0, uint64(len(data))); err == nil {
+		t.Fatal("expected a corrupt download to be rejected")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatal("corrupt download was moved to the destination")
+	}
+
+	// An intact download written to a separate temp dir is moved to the
+	// destination.
+	tempDir := filepath.Join(dir, "temp")
+	if err := os.Mkdir(tempDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	dw, err = newTempDownloadFileWriter(dst, tempDir, 0, uint64(len(data)), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(dw.f.Name()) != tempDir {
+		t.Fatal("temporary file created in the wrong place:", dw.f.Name())
+	}
+	if _, err := dw.WriteAt(data, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := dw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := commitDownload(f, dw.f.Name(), dst, 0, uint64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	downloaded, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(downloaded, data) {
+		t.Fatal("destination does not match the uploaded data")
+	}
+	if _, err := os.Stat(dw.f.Name()); !os.IsNotExist(err) {
+		t.Fatal("temporary file was not removed")
+	}
+}
+
+// TestDownloadPriority checks that chunks of higher priority downloads are
+// queued and given workers before chunks of lower priority downloads.
+func TestDownloadPriority(t *testing.T) {
+	r := &Renter{hostBreaker: newHostBreaker()}
+	fcid := types.FileContractID{1}
+	newPriorityDownload := func(priority int) *download {
+		return &download{
+			erasureCode:    &rsCode{numPieces: 1, dataPieces: 1},
+			finishedChunks: map[uint64]bool{0: false},
+			pieceSet: map[uint64]map[types.FileContractID]pieceData{
+				0: {fcid: {}},
+			},
+			priority: priority,
+		}
+	}
+
+	// Downloads of equal priority should stay in submission order, behind
+	// any higher priority downloads.
+	low1, high1 := newPriorityDownload(0), newPriorityDownload(5)
+	low2, high2 := newPriorityDownload(0), newPriorityDownload(5)
+	for _, d := range []*download{low1, high1, low2, high2} {
+		r.addDownloadToChunkQueue(d)
+	}
+	expected := []*download{high1, high2, low1, low2}
+	if len(r.chunkQueue) != len(expected) {
+		t.Fatal("wrong number of queued chunks:", len(r.chunkQueue))
+	}
+	for i, cd := range r.chunkQueue {
+		if cd.download != expected[i] {
+			t.Fatalf("chunk %v belongs to the download with priority %v", i, cd.download.priority)
+		}
+	}
+
+	// A single available worker should be handed the high priority chunk,
+	// even though the low priority chunk became incomplete first.
+	w := &worker{
+		contract:             modules.RenterContract{ID: fcid},
+		priorityDownloadChan: make(chan downloadWork, 1),
+	}
+	lowChunk, highChunk := r.chunkQueue[2], r.chunkQueue[0]
+	ds := &downloadState{
+		activeWorkers:    make(map[types.FileContractID]struct{}),
+		availableWorkers: []*worker{w},
+		downloadWorkers:  make(map[*download]int),
+		incompleteChunks: []*chunkDownload{lowChunk, highChunk},
+		limits:           modules.RenterLimits{MaxDownloadWorkers: 1},
+	}
+	r.managedScheduleIncompleteChunks(ds)
+	select {
+	case dw := <-w.priorityDownloadChan:
+		if dw.chunkDownload != highChunk {
+			t.Fatal("worker was given the low priority chunk")
+		}
+	default:
+		t.Fatal("worker was not given any work")
+	}
+	if len(ds.incompleteChunks) != 1 || ds.incompleteChunks[0] != lowChunk {
+		t.Fatal("low priority chunk should be waiting for the busy worker")
+	}
+}
+
+// TestDownloadStuck checks that a started download is reported as stuck once
+// it stops making progress, and is cancelled if it stays stuck.
+func TestDownloadStuck(t *testing.T) {
+	d := &download{downloadFinished: make(chan struct{}), destination: downloadDiscardWriter{}}
+	stuckTimeout := time.Minute
+	atomic.StoreInt64(&d.atomicStuckTimeout, int64(stuckTimeout))
+
+	// A download that has not been started is never stuck.
+	if d.Stuck() || d.managedCancelIfStuck() {
+		t.Fatal("queued download should not be stuck")
+	}
+
+	// A download that recently made progress is not stuck.
+	atomic.StoreInt64(&d.atomicLastProgress, time.Now().UnixNano())
+	if d.Stuck() || d.managedCancelIfStuck() {
+		t.Fatal("download making progress should not be stuck")
+	}
+
+	// A download without progress for longer than the stuck timeout is stuck,
+	// but is not cancelled yet.
+	atomic.StoreInt64(&d.atomicLastProgress, time.Now().Add(-stuckTimeout-time.Second).UnixNano())
+	if !d.Stuck() {
+		t.Fatal("download should be stuck")
+	}
+	if d.managedCancelIfStuck() {
+		t.Fatal("download should not be cancelled yet")
+	}
+
+	// Once the cancel timeout has passed the download should fail.
+	atomic.StoreInt64(&d.atomicLastProgress, time.Now().Add(-stuckTimeout*downloadStuckCancelFactor-time.Second).UnixNano())
+	if !d.managedCancelIfStuck() {
+		t.Fatal("stuck download was not cancelled")
+	}
+	if d.Err() != errDownloadStuck {
+		t.Fatal("expected errDownloadStuck, got", d.Err())
+	}
+	if d.Stuck() {
+		t.Fatal("a failed download should not be reported as stuck")
+	}
+}
+
+// TestDownloadStuckTimeout checks that the stuck timeout of a download is
+// derived from the latency of the hosts holding its pieces.
+func TestDownloadStuckTimeout(t *testing.T) {
+	r := &Renter{
+		mu:         siasync.New(modules.SafeMutexDelay, 1),
+		workerPool: make(map[types.FileContractID]*worker),
+	}
+	fastID, slowID := types.FileContractID{1}, types.FileContractID{2}
+	fast, slow := new(worker), new(worker)
+	r.workerPool[fastID] = fast
+	r.workerPool[slowID] = slow
+	d := &download{pieceSet: map[uint64]map[types.FileContractID]pieceData{
+		0: {fastID: {}},
+		1: {fastID: {}, slowID: {}},
+	}}
+
+	// Hosts that have not been measured use the default piece timeout.
+	if timeout := r.managedStuckTimeout(d); timeout != defaultPieceDownloadTimeout*downloadStuckTimeoutFactor {
+		t.Fatal("expected the default stuck timeout, got", timeout)
+	}
+
+	// The slowest host sets the timeout.
+	fast.recordDownloadLatency(time.Millisecond)
+	slow.recordDownloadLatency(4 * minPieceDownloadTimeout)
+	if timeout := r.managedStuckTimeout(d); timeout != slow.downloadTimeout()*downloadStuckTimeoutFactor {
+		t.Fatal("stuck timeout should follow the slowest host, got", timeout)
+	}
+
+	// Fast hosts get the minimum timeout.
+	delete(r.workerPool, slowID)
+	if timeout := r.managedStuckTimeout(d); timeout != minDownloadStuckTimeout {
+		t.Fatal("expected the minimum stuck timeout, got", timeout)
+	}
+}
+
+// TestScheduleNewChunksResponsiveWorkers checks that new chunks are only
+// scheduled while there are enough responsive workers to download them.
+func TestScheduleNewChunksResponsiveWorkers(t *testing.T) {
+	r := &Renter{}
+	for i := 0; i < 3; i++ {
+		d := &download{
+			erasureCode:    &rsCode{numPieces: 4, dataPieces: 2},
+			finishedChunks: map[uint64]bool{0: false},
+		}
+		r.addDownloadToChunkQueue(d)
+	}
+	ds := &downloadState{
+		limits:            modules.RenterLimits{MaxConcurrentDownloads: 100},
+		responsiveWorkers: 4,
+	}
+
+	// Four workers can download the pieces of two chunks.
+	r.managedScheduleNewChunks(ds)
+	if ds.activePieces != 4 || len(r.chunkQueue) != 1 {
+		t.Fatalf("expected 4 active pieces and 1 queued chunk, got %v and %v", ds.activePieces, len(r.chunkQueue))
+	}
+
+	// With a single responsive worker, a chunk is still scheduled when
+	// nothing else is.
+	ds = &downloadState{
+		limits:            modules.RenterLimits{MaxConcurrentDownloads: 100},
+		responsiveWorkers: 1,
+	}
+	r.managedScheduleNewChunks(ds)
+	if ds.activePieces != 2 || len(r.chunkQueue) != 0 {
+		t.Fatalf("expected 2 active pieces and no queued chunks, got %v and %v", ds.activePieces, len(r.chunkQueue))
+	}
+}
+
+// TestDownloadFastestSpares checks that fastest downloads request extra
+// pieces of each chunk without counting them as active pieces, and that
+// running out of hosts for the extra pieces does not fail the download.
+func TestDownloadFastestSpares(t *testing.T) {
+	r := &Renter{hostBreaker: newHostBreaker()}
+	fcid1, fcid2, fcid3 := types.FileContractID{1}, types.FileContractID{2}, types.FileContractID{3}
+	d := &download{
+		erasureCode:    &rsCode{numPieces: 3, dataPieces: 1},
+		fastest:        true,
+		finishedChunks: map[uint64]bool{0: false},
+		pieceSet: map[uint64]map[types.FileContractID]pieceData{
+			0: {fcid1: {}, fcid2: {}, fcid3: {}},
+		},
+	}
+	r.addDownloadToChunkQueue(d)
+	ds := &downloadState{
+		activeWorkers:     make(map[types.FileContractID]struct{}),
+		downloadWorkers:   make(map[*download]int),
+		limits:            modules.RenterLimits{MaxConcurrentDownloads: 100, MaxDownloadWorkers: 3},
+		responsiveWorkers: 3,
+	}
+
+	// A chunk that needs one piece should be raced between two hosts.
+	r.managedScheduleNewChunks(ds)
+	if ds.activePieces != 1 || len(ds.incompleteChunks) != 2 {
+		t.Fatalf("expected 1 active piece and 2 entries, got %v and %v", ds.activePieces, len(ds.incompleteChunks))
+	}
+	cd := ds.incompleteChunks[0]
+	if cd.spare != 1 {
+		t.Fatal("expected 1 spare entry, got", cd.spare)
+	}
+	if cd.cancel == nil || cd.cancelled() {
+		t.Fatal("raced chunk should be cancellable but not cancelled")
+	}
+
+	// With a single available worker, the spare entry is dropped instead of
+	// failing the download.
+	w := &worker{
+		contract:             modules.RenterContract{ID: fcid1},
+		priorityDownloadChan: make(chan downloadWork, 1),
+	}
+	ds.availableWorkers = []*worker{w}
+	r.managedScheduleIncompleteChunks(ds)
+	select {
+	case <-w.priorityDownloadChan:
+	default:
+		t.Fatal("worker was not given any work")
+	}
+	if len(ds.incompleteChunks) != 0 || cd.spare != 0 || ds.activePieces != 1 {
+		t.Fatalf("spare entry was not dropped: %v entries, %v spare, %v active pieces", len(ds.incompleteChunks), cd.spare, ds.activePieces)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatal("download should not have failed:", err)
+	}
+
+	// Entries of a chunk that has been recovered are discarded.
+	cd.recovered = true
+	ds.incompleteChunks = []*chunkDownload{cd}
+	r.managedScheduleIncompleteChunks(ds)
+	if len(ds.incompleteChunks) != 0 || ds.activePieces != 1 {
+		t.Fatal("entry of a recovered chunk was not discarded")
+	}
+}
+
+// TestDownloadCancelledPiece checks that a worker does not fetch a piece of a
+// chunk that has already been recovered from the pieces of faster hosts.
+func TestDownloadCancelledPiece(t *testing.T) {
+	r := &Renter{
+		bandwidth:   newBandwidthScheduler(),
+		hostBreaker: newHostBreaker(),
+	}
+	w := &worker{
+		contract: modules.RenterContract{ID: types.FileContractID{1}},
+		killChan: make(chan struct{}),
+		renter:   r,
+	}
+	cd := &chunkDownload{
+		download: &download{siapath: "foo"},
+		cancel:   make(chan struct{}),
+	}
+	close(cd.cancel)
+
+	resultChan := make(chan finishedDownload)
+	go w.download(downloadWork{chunkDownload: cd, resultChan: resultChan})
+	select {
+	case fd := <-resultChan:
+		if fd.err != errPieceCancelled {
+			t.Fatal("expected errPieceCancelled, got", fd.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not return the cancelled piece")
+	}
+}
+
+// TestDownloadDeferred checks that a worker defers a download whose share of
+// the bandwidth is used up instead of blocking on it, and fails the deferred
+// download when it is dropped.
+func TestDownloadDeferred(t *testing.T) {
+	r := &Renter{
+		bandwidth:   newBandwidthScheduler(),
+		hostBreaker: newHostBreaker(),
+	}
+	r.bandwidth.downloads.limit = 1000
+	w := &worker{
+		contract: modules.RenterContract{ID: types.FileContractID{1}},
+		killChan: make(chan struct{}),
+		renter:   r,
+	}
+
+	// Use up the bandwidth of the file.
+	op := r.bandwidth.acquire(&r.bandwidth.downloads, "foo", 0)
+	defer r.bandwidth.release(op)
+	if !op.tryWait(3000) {
+		t.Fatal("transfer larger than the burst should proceed")
+	}
+
+	resultChan := make(chan finishedDownload)
+	cd := &chunkDownload{download: &download{siapath: "foo"}}
+	w.download(downloadWork{chunkDownload: cd, resultChan: resultChan})
+	if len(w.deferredDownloads) != 1 {
+		t.Fatal("download should have been deferred")
+	}
+	if w.retryDeferredDownloads() || len(w.deferredDownloads) != 1 {
+		t.Fatal("download should have been deferred again")
+	}
+
+	w.dropDeferredDownloads()
+	select {
+	case fd := <-resultChan:
+		if fd.err != errWorkerKilled {
+			t.Fatal("expected errWorkerKilled, got", fd.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not return the deferred piece")
+	}
+	if len(w.deferredDownloads) != 0 {
+		t.Fatal("deferred downloads were not dropped")
+	}
+}
+
+// TestRecoverPiecesPool checks that piece slices are cleared before they are
+// reused for another chunk.
+func TestRecoverPiecesPool(t *testing.T) {
+	pieces := getRecoverPieces(4)
+	for i := range *pieces {
+		(*pieces)[i] = []byte{byte(i)}
+	}
+	putRecoverPieces(pieces)
+
+	// Slices of any size must come back empty, whether or not they reuse
+	// the returned slice.
+	for _, n := range []int{2, 4, 8} {
+		pieces = getRecoverPieces(n)
+		if len(*pieces) != n {
+			t.Fatalf("expected %v pieces, got %v", n, len(*pieces))
+		}
+		for i, piece := range *pieces {
+			if piece != nil {
+				t.Fatalf("piece %v was not cleared", i)
+			}
+		}
+		(*pieces)[0] = []byte{1}
+		putRecoverPieces(pieces)
+	}
+}
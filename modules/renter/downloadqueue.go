@@ -3,10 +3,15 @@ package renter
 import (
 	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"sync/atomic"
+	"time"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // Download performs a file download using the passed parameters.
@@ -34,6 +39,12 @@ func (r *Renter) Download(p modules.RenterDownloadParameters) error {
 	if p.Destination != "" && !filepath.IsAbs(p.Destination) {
 		return errors.New("destination must be an absolute path")
 	}
+	if isHttpResp && p.TempDir != "" {
+		return errors.New("temp dir cannot be specified when downloading to http response")
+	}
+	if p.TempDir != "" && !filepath.IsAbs(p.TempDir) {
+		return errors.New("temp dir must be an absolute path")
+	}
 	if p.Offset == file.size {
 		return errors.New("offset equals filesize")
 	}
@@ -46,21 +57,43 @@ func (r *Renter) Download(p modules.RenterDownloadParameters) error {
 		return fmt.Errorf("offset and length combination invalid, max byte is at index %d", file.size-1)
 	}
 
+	// Use the renter's host preference unless the download overrides it.
+	hostPreference := r.HostPreference()
+	if p.HostPreference != nil {
+		if !validHostPreference(*p.HostPreference) {
+			return errBadHostPreference
+		}
+		hostPreference = *p.HostPreference
+	}
+
 	// Instantiate the correct DownloadWriter implementation
 	// (e.g. content written to file or response body).
+	// Downloads to a file are written to a temporary file, so that no
+	// partial file is ever visible at the destination.
 	var dw modules.DownloadWriter
+	var tempName string
 	if isHttpResp {
 		dw = NewDownloadHttpWriter(p.Httpwriter, p.Offset, p.Length)
 	} else {
-		dfw, err := NewDownloadFileWriter(p.Destination, p.Offset, p.Length)
+		bufferSize := uint64(r.managedLimits().DownloadBufferChunks) * file.chunkSize()
+		dfw, err := newTempDownloadFileWriter(p.Destination, p.TempDir, p.Offset, p.Length, bufferSize)
 		if err != nil {
 			return err
 		}
 		dw = dfw
+		tempName = dfw.f.Name()
+	}
+	removeTemp := func() {
+		if tempName != "" {
+			os.Remove(tempName)
+		}
 	}
 
 	// Create the download object and add it to the queue.
 	d := r.newSectionDownload(file, dw, p.Offset, p.Length)
+	d.priority = p.Priority
+	d.hostPreference = hostPreference
+	d.fastest = p.Fastest
 
 	lockID = r.mu.Lock()
 	r.downloadQueue = append(r.downloadQueue, d)
@@ -73,12 +106,336 @@ func (r *Renter) Download(p modules.RenterDownloadParameters) error {
 	// error itself.
 	select {
 	case <-d.downloadFinished:
-		return d.Err()
+		if err := d.Err(); err != nil {
+			removeTemp()
+			if _, ok := err.(insufficientHostsError); ok {
+				err = r.managedDiagnoseDownload(file, p.Offset, p.Length, err)
+			}
+			return err
+		}
+		if tempName != "" {
+			if err := commitDownload(file, tempName, p.Destination, p.Offset, p.Length); err != nil {
+				removeTemp()
+				return err
+			}
+		}
+		r.managedRecordAccess(file, p.Length)
+		return nil
 	case <-r.tg.StopChan():
+		removeTemp()
 		return errors.New("download interrupted by shutdown")
 	}
 }
 
+// commitDownload moves a completed download from the temporary file tempName
+// to destination. Downloads of a whole file are first checked against the
+// chunk hashes of the file, so that a corrupt download never replaces the
+// destination. The rename is atomic only if tempName and destination are on
+// the same filesystem.
+func commitDownload(f *file, tempName, destination string, offset, length uint64) error {
+	f.mu.RLock()
+	size := f.size
+	chunkHashes := f.chunkHashes
+	f.mu.RUnlock()
+	if offset == 0 && length == size && len(chunkHashes) > 0 {
+		handle, err := os.Open(tempName)
+		if err != nil {
+			return err
+		}
+		corrupt, err := corruptChunks(handle, f.chunkSize(), chunkHashes)
+		handle.Close()
+		if err != nil {
+			return err
+		} else if len(corrupt) > 0 {
+			return fmt.Errorf("downloaded data does not match the uploaded file, %v chunks are corrupt", len(corrupt))
+		}
+	}
+	return os.Rename(tempName, destination)
+}
+
+// managedDiagnoseDownload replaces the error of a download that failed for
+// lack of hosts with a report of every chunk in the downloaded range that is
+// missing pieces on online hosts. If no such chunks are found, for example
+// because the hosts went offline only briefly, the original error is
+// returned.
+func (r *Renter) managedDiagnoseDownload(f *file, offset, length uint64, err error) error {
+	isOffline := func(id types.FileContractID) bool {
+		return r.hostContractor.IsOffline(r.hostContractor.ResolveID(id))
+	}
+	f.mu.RLock()
+	deficient := f.checkHosts(isOffline)
+	chunkSize := f.chunkSize()
+	f.mu.RUnlock()
+
+	minChunk := offset / chunkSize
+	maxChunk := (offset + length - 1) / chunkSize
+	var inRange insufficientHostsError
+	for _, cd := range deficient {
+		if cd.Chunk >= minChunk && cd.Chunk <= maxChunk {
+			inRange = append(inRange, cd)
+		}
+	}
+	if len(inRange) == 0 {
+		return err
+	}
+	return inRange
+}
+
+// managedRecordAccess updates the access statistics of a file after length
+// bytes of it have been downloaded. The statistics are saved with the next
+// batch of metadata changes rather than on every download.
+func (r *Renter) managedRecordAccess(f *file, length uint64) {
+	lockID := r.mu.Lock()
+	defer r.mu.Unlock(lockID)
+	f.mu.Lock()
+	f.access.Downloads++
+	f.access.BytesServed += length
+	f.access.LastAccess = time.Now()
+	name := f.name
+	f.mu.Unlock()
+	// The file may have been deleted while it was being downloaded.
+	if r.files[name] == f {
+		r.persistDirty = true
+	}
+}
+
+// DownloadWithPriority downloads the file with the given nickname to
+// destination. Downloads with a higher priority are given preference over
+// lower priority downloads when the renter assigns workers, so that
+// interactive downloads are not starved by large background downloads.
+func (r *Renter) DownloadWithPriority(nickname, destination string, priority int) error {
+	return r.Download(modules.RenterDownloadParameters{
+		Siapath:     nickname,
+		Destination: destination,
+		Priority:    priority,
+	})
+}
+
+// DownloadFastest downloads the file with the given nickname to destination,
+// requesting every piece from more hosts than needed and keeping the pieces
+// that arrive first. This lowers the latency of the download at the cost of
+// extra bandwidth, as the responses of the slower hosts are discarded.
+func (r *Renter) DownloadFastest(nickname, destination string) error {
+	return r.Download(modules.RenterDownloadParameters{
+		Siapath:     nickname,
+		Destination: destination,
+		Fastest:     true,
+	})
+}
+
+// DownloadByHash downloads the file whose contents match hash to destination.
+// If several files share the same contents, the one with the
+// lexicographically smallest siapath is downloaded.
+func (r *Renter) DownloadByHash(hash crypto.Hash, destination string) error {
+	if hash == (crypto.Hash{}) {
+		return ErrUnknownHash
+	}
+	var siapath string
+	lockID := r.mu.RLock()
+	for name, f := range r.files {
+		f.mu.RLock()
+		match := f.hash == hash
+		f.mu.RUnlock()
+		if match && (siapath == "" || name < siapath) {
+			siapath = name
+		}
+	}
+	r.mu.RUnlock(lockID)
+	if siapath == "" {
+		return ErrUnknownHash
+	}
+
+	return r.Download(modules.RenterDownloadParameters{
+		Siapath:     siapath,
+		Destination: destination,
+	})
+}
+
+// VerifyFile checks that every chunk of a file can be recovered from the
+// hosts storing it by running the file through the download pipeline without
+// writing the downloaded data anywhere. The returned error identifies the
+// first chunk that could not be recovered.
+func (r *Renter) VerifyFile(nickname string) error {
+	lockID := r.mu.RLock()
+	file, exists := r.files[nickname]
+	r.mu.RUnlock(lockID)
+	if !exists {
+		return errors.New(fmt.Sprintf("no file with that path: %s", nickname))
+	}
+	file.mu.RLock()
+	size := file.size
+	file.mu.RUnlock()
+	if size == 0 {
+		// There is no data that needs to be recovered.
+		return nil
+	}
+
+	// The download is not added to the download queue, as no data is written
+	// to the user's filesystem.
+	d := r.newSectionDownload(file, downloadDiscardWriter{}, 0, size)
+	select {
+	case r.newDownloads <- d:
+	case <-r.tg.StopChan():
+		return errors.New("verification interrupted by shutdown")
+	}
+	select {
+	case <-d.downloadFinished:
+		return d.Err()
+	case <-r.tg.StopChan():
+		return errors.New("verification interrupted by shutdown")
+	}
+}
+
+// corruptChunks returns the indices of the chunks of local whose contents do
+// not match chunkHashes. Chunks that are missing because local is truncated
+// are considered corrupt as well.
+func corruptChunks(local io.Reader, chunkSize uint64, chunkHashes []crypto.Hash) ([]uint64, error) {
+	_, localHashes, err := hashChunks(local, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	var corrupt []uint64
+	for i, h := range chunkHashes {
+		if i >= len(localHashes) || localHashes[i] != h {
+			corrupt = append(corrupt, uint64(i))
+		}
+	}
+	return corrupt, nil
+}
+
+// redownloadSource returns the file that was downloaded to destination. The
+// most recent complete download of a whole file to destination is preferred.
+// Otherwise, the destination is hashed and the file sharing the most chunk
+// hashes with it is returned, so that the source can be found even if the
+// download happened before the renter was restarted.
+func (r *Renter) redownloadSource(destination string) (*file, error) {
+	lockID := r.mu.RLock()
+	for i := len(r.downloadQueue) - 1; i >= 0; i-- {
+		d := r.downloadQueue[i]
+		if d.destination.Destination() != destination || d.offset != 0 || d.length != d.fileSize {
+			continue
+		}
+		d.mu.Lock()
+		complete := d.downloadComplete && d.downloadErr == nil
+		d.mu.Unlock()
+		if f, exists := r.files[d.siapath]; complete && exists {
+			r.mu.RUnlock(lockID)
+			return f, nil
+		}
+	}
+	var candidates []*file
+	for _, f := range r.files {
+		f.mu.RLock()
+		if len(f.chunkHashes) > 0 {
+			candidates = append(candidates, f)
+		}
+		f.mu.RUnlock()
+	}
+	r.mu.RUnlock(lockID)
+
+	// Hash the destination once for every chunk size in use. The hashing is
+	// done without holding the renter lock, as the destination may be large.
+	localHashes := make(map[uint64]map[crypto.Hash]struct{})
+	var best *file
+	var bestName string
+	bestMatches := 0
+	for _, f := range candidates {
+		local, ok := localHashes[f.chunkSize()]
+		if !ok {
+			handle, err := os.Open(destination)
+			if err != nil {
+				return nil, err
+			}
+			_, hashes, err := hashChunks(handle, f.chunkSize())
+			handle.Close()
+			if err != nil {
+				return nil, err
+			}
+			local = make(map[crypto.Hash]struct{})
+			for _, h := range hashes {
+				local[h] = struct{}{}
+			}
+			localHashes[f.chunkSize()] = local
+		}
+		f.mu.RLock()
+		name := f.name
+		matches := 0
+		for _, h := range f.chunkHashes {
+			if _, ok := local[h]; ok {
+				matches++
+			}
+		}
+		f.mu.RUnlock()
+		if matches > bestMatches || (matches == bestMatches && matches > 0 && name < bestName) {
+			best, bestName, bestMatches = f, name, matches
+		}
+	}
+	if best == nil {
+		return nil, errUnknownDestination
+	}
+	return best, nil
+}
+
+// Redownload repairs a previously downloaded file at destination that has
+// been corrupted on disk. The chunks of the local file are compared against
+// the chunk hashes recorded when the file was uploaded, and only the chunks
+// that fail verification are downloaded again and written over the corrupt
+// data in place.
+func (r *Renter) Redownload(destination string) error {
+	if !filepath.IsAbs(destination) {
+		return errors.New("destination must be an absolute path")
+	}
+	file, err := r.redownloadSource(destination)
+	if err != nil {
+		return err
+	}
+	file.mu.RLock()
+	size := file.size
+	chunkHashes := file.chunkHashes
+	file.mu.RUnlock()
+	if len(chunkHashes) == 0 {
+		return errNoChunkHashes
+	}
+
+	handle, err := os.Open(destination)
+	if err != nil {
+		return err
+	}
+	corrupt, err := corruptChunks(handle, file.chunkSize(), chunkHashes)
+	handle.Close()
+	if err != nil {
+		return err
+	}
+
+	if len(corrupt) > 0 {
+		dw, err := NewDownloadFileWriter(destination, 0, size)
+		if err != nil {
+			return err
+		}
+		d := r.newChunksDownload(file, dw, corrupt)
+		lockID := r.mu.Lock()
+		r.downloadQueue = append(r.downloadQueue, d)
+		r.mu.Unlock(lockID)
+		select {
+		case r.newDownloads <- d:
+		case <-r.tg.StopChan():
+			return errors.New("redownload interrupted by shutdown")
+		}
+
+		select {
+		case <-d.downloadFinished:
+			if err := d.Err(); err != nil {
+				return err
+			}
+		case <-r.tg.StopChan():
+			return errors.New("redownload interrupted by shutdown")
+		}
+	}
+
+	// Remove any trailing data if the local file has grown.
+	return os.Truncate(destination, int64(size))
+}
+
 // DownloadQueue returns the list of downloads in the queue.
 func (r *Renter) DownloadQueue() []modules.DownloadInfo {
 	lockID := r.mu.RLock()
@@ -93,9 +450,11 @@ func (r *Renter) DownloadQueue() []modules.DownloadInfo {
 			SiaPath:     d.siapath,
 			Destination: d.destination,
 			Filesize:    d.length,
+			Priority:    d.priority,
 			StartTime:   d.startTime,
 		}
 		downloads[i].Received = atomic.LoadUint64(&d.atomicDataReceived)
+		downloads[i].Stuck = d.Stuck()
 
 		if err := d.Err(); err != nil {
 			downloads[i].Error = err.Error()
@@ -0,0 +1,124 @@
+package renter
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/hostdb"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// estimateSettingsTimeout is the amount of time that a host has to return
+	// its settings when estimating the cost of an upload.
+	estimateSettingsTimeout = 30 * time.Second
+)
+
+var (
+	errEstimateBadRedundancy = errors.New("redundancy must be at least 1")
+	errEstimateNoHosts       = errors.New("no hosts are available to estimate the upload cost")
+	errEstimateZeroDuration  = errors.New("duration must be greater than zero")
+)
+
+// uploadedBytes returns the number of bytes that hosts will store when a file
+// of size fileSize is uploaded with the given redundancy. Each piece of each
+// chunk occupies a full sector on a host.
+func uploadedBytes(fileSize uint64, redundancy float64) uint64 {
+	pieceSize := filePieceSize(fileSize, defaultDataPieces)
+	chunkSize := pieceSize * uint64(defaultDataPieces)
+	numChunks := (fileSize + chunkSize - 1) / chunkSize
+	if numChunks == 0 {
+		numChunks = 1
+	}
+	numPieces := uint64(math.Ceil(redundancy * float64(defaultDataPieces)))
+	return numChunks * numPieces * modules.SectorSize
+}
+
+// uploadCost returns the cost of storing fileSize bytes with the given
+// redundancy for duration blocks, spread evenly across hosts with the given
+// settings. The cost includes both storage and upload bandwidth.
+func uploadCost(settings []modules.HostExternalSettings, fileSize uint64, duration types.BlockHeight, redundancy float64) types.Currency {
+	if len(settings) == 0 {
+		return types.ZeroCurrency
+	}
+	var storagePrice, uploadPrice types.Currency
+	for _, s := range settings {
+		storagePrice = storagePrice.Add(s.StoragePrice)
+		uploadPrice = uploadPrice.Add(s.UploadBandwidthPrice)
+	}
+	bytes := uploadedBytes(fileSize, redundancy)
+	storageCost := storagePrice.Mul64(bytes).Mul64(uint64(duration))
+	uploadCost := uploadPrice.Mul64(bytes)
+	return storageCost.Add(uploadCost).Div64(uint64(len(settings)))
+}
+
+// fetchHostSettings requests the current settings of a host.
+func fetchHostSettings(host modules.HostDBEntry, cancel <-chan struct{}) (modules.HostExternalSettings, error) {
+	var settings modules.HostExternalSettings
+	deadline := time.Now().Add(estimateSettingsTimeout)
+	err := hostdb.CallHost(host.NetAddress, host.PublicKey, modules.RPCSettings, &settings, modules.NegotiateMaxHostExternalSettingsLen, deadline, cancel)
+	return settings, err
+}
+
+// managedEstimationHosts returns the hosts that an upload would be sent to.
+// If the renter has contracts, the hosts of those contracts are used,
+// otherwise the hosts that the renter would be likely to form contracts with
+// are used.
+func (r *Renter) managedEstimationHosts() []modules.HostDBEntry {
+	var hosts []modules.HostDBEntry
+	for _, c := range r.hostContractor.Contracts() {
+		host, ok := r.hostDB.Host(c.HostPublicKey)
+		if ok {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = r.hostDB.RandomHosts(defaultDataPieces+defaultParityPieces, nil)
+	}
+	return hosts
+}
+
+// EstimateUploadCost estimates the cost of uploading a file of fileSize bytes
+// with the given redundancy and storing it for duration blocks. The estimate
+// is based on the current settings of the hosts that the file would be
+// uploaded to, falling back to the settings in the hostdb for hosts that
+// cannot be reached.
+func (r *Renter) EstimateUploadCost(fileSize uint64, duration types.BlockHeight, redundancy float64) (types.Currency, error) {
+	if err := r.tg.Add(); err != nil {
+		return types.ZeroCurrency, err
+	}
+	defer r.tg.Done()
+	if duration == 0 {
+		return types.ZeroCurrency, errEstimateZeroDuration
+	}
+	if redundancy < 1 {
+		return types.ZeroCurrency, errEstimateBadRedundancy
+	}
+
+	hosts := r.managedEstimationHosts()
+	if len(hosts) == 0 {
+		return types.ZeroCurrency, errEstimateNoHosts
+	}
+
+	// Fetch the settings of every host in parallel.
+	settings := make([]modules.HostExternalSettings, len(hosts))
+	var wg sync.WaitGroup
+	for i := range hosts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s, err := fetchHostSettings(hosts[i], r.tg.StopChan())
+			if err != nil {
+				r.log.Debugf("Unable to fetch settings of %v for cost estimation: %v", hosts[i].NetAddress, err)
+				s = hosts[i].HostExternalSettings
+			}
+			settings[i] = s
+		}(i)
+	}
+	wg.Wait()
+
+	return uploadCost(settings, fileSize, duration, redundancy), nil
+}
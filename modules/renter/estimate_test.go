@@ -0,0 +1,38 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestUploadCost checks that the upload cost estimate averages the prices of
+// the hosts and scales with the duration and redundancy.
+func TestUploadCost(t *testing.T) {
+	settings := []modules.HostExternalSettings{
+		{StoragePrice: types.NewCurrency64(1), UploadBandwidthPrice: types.NewCurrency64(10)},
+		{StoragePrice: types.NewCurrency64(3), UploadBandwidthPrice: types.NewCurrency64(30)},
+	}
+	const fileSize = 1e6
+	bytes := uploadedBytes(fileSize, 1)
+	if bytes == 0 || bytes%modules.SectorSize != 0 {
+		t.Fatal("uploaded bytes should be a non-zero number of sectors:", bytes)
+	}
+
+	// Average storage price is 2, average upload price is 20.
+	expected := types.NewCurrency64(bytes).Mul64(2 * 100).Add(types.NewCurrency64(bytes).Mul64(20))
+	if cost := uploadCost(settings, fileSize, 100, 1); !cost.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected, cost)
+	}
+
+	// Tripling the redundancy triples the number of pieces stored.
+	if uploadedBytes(fileSize, 3) != 3*bytes {
+		t.Fatal("redundancy of 3 should store three times as many bytes:", uploadedBytes(fileSize, 3), bytes)
+	}
+
+	// Without any hosts, nothing can be estimated.
+	if cost := uploadCost(nil, fileSize, 100, 1); !cost.IsZero() {
+		t.Fatal("expected zero cost without hosts, got", cost)
+	}
+}
@@ -0,0 +1,112 @@
+package renter
+
+import (
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// health returns the location of every piece of the file, grouped by chunk.
+// Pieces stored with hosts that are offline are included, but do not count
+// towards the margin of their chunk. Pieces of a chunk are sorted by piece
+// index. Pieces recorded for chunks beyond the end of the file are ignored.
+//
+// NOTE: the pieces are taken from the renter's records. The host protocol has
+// no RPC that proves a host still holds a piece without transferring it, so
+// VerifyFile has to be used to confirm that the pieces can be downloaded.
+func (f *file) health(isOffline func(types.FileContractID) bool) modules.FileHealth {
+	fh := modules.FileHealth{
+		SiaPath:   f.name,
+		MinPieces: f.erasureCode.MinPieces(),
+		NumPieces: f.erasureCode.NumPieces(),
+		Chunks:    make([]modules.ChunkHealth, f.numChunks()),
+	}
+	online := make([]map[uint64]struct{}, len(fh.Chunks))
+	for _, fc := range f.contracts {
+		offline := isOffline(fc.ID)
+		for _, p := range fc.Pieces {
+			if p.Chunk >= uint64(len(fh.Chunks)) {
+				continue
+			}
+			chunk := &fh.Chunks[p.Chunk]
+			chunk.Pieces = append(chunk.Pieces, modules.PieceHealth{
+				Piece:    p.Piece,
+				Host:     fc.IP,
+				Contract: fc.ID,
+				Online:   !offline,
+			})
+			if offline {
+				continue
+			}
+			if online[p.Chunk] == nil {
+				online[p.Chunk] = make(map[uint64]struct{})
+			}
+			online[p.Chunk][p.Piece] = struct{}{}
+		}
+	}
+	for i := range fh.Chunks {
+		chunk := &fh.Chunks[i]
+		sort.Slice(chunk.Pieces, func(a, b int) bool {
+			return chunk.Pieces[a].Piece < chunk.Pieces[b].Piece
+		})
+		chunk.OnlinePieces = len(online[i])
+		chunk.Margin = chunk.OnlinePieces - fh.MinPieces
+	}
+	return fh
+}
+
+// healthSummary returns the smallest margin of any chunk of the file, and the
+// number of chunks that have fewer online pieces than the erasure code
+// produces. It counts the same pieces as health without building the
+// per-piece report.
+func (f *file) healthSummary(isOffline func(types.FileContractID) bool) (margin int, degraded uint64) {
+	type chunkPiece struct {
+		chunk, piece uint64
+	}
+	online := make(map[chunkPiece]struct{})
+	onlinePieces := make([]int, f.numChunks())
+	for _, fc := range f.contracts {
+		if isOffline(fc.ID) {
+			continue
+		}
+		for _, p := range fc.Pieces {
+			cp := chunkPiece{p.Chunk, p.Piece}
+			if _, exists := online[cp]; exists || p.Chunk >= uint64(len(onlinePieces)) {
+				continue
+			}
+			online[cp] = struct{}{}
+			onlinePieces[p.Chunk]++
+		}
+	}
+
+	minPieces, numPieces := f.erasureCode.MinPieces(), f.erasureCode.NumPieces()
+	margin = numPieces - minPieces
+	for _, n := range onlinePieces {
+		if n-minPieces < margin {
+			margin = n - minPieces
+		}
+		if n < numPieces {
+			degraded++
+		}
+	}
+	return margin, degraded
+}
+
+// FileHealth returns, for every chunk of a file, which hosts hold which pieces
+// and how many pieces the chunk can lose before it becomes unrecoverable.
+func (r *Renter) FileHealth(nickname string) (modules.FileHealth, error) {
+	lockID := r.mu.RLock()
+	f, exists := r.files[nickname]
+	r.mu.RUnlock(lockID)
+	if !exists {
+		return modules.FileHealth{}, ErrUnknownPath
+	}
+
+	isOffline := func(id types.FileContractID) bool {
+		return r.hostContractor.IsOffline(r.hostContractor.ResolveID(id))
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.health(isOffline), nil
+}
@@ -0,0 +1,70 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestFileHealth checks that the health of a file reports every stored piece
+// and counts only distinct online pieces towards the margin of a chunk.
+func TestFileHealth(t *testing.T) {
+	rsc, _ := NewRSCode(2, 2)
+	f := &file{
+		name:        "foo",
+		size:        400,
+		erasureCode: rsc,
+		pieceSize:   100,
+		contracts:   make(map[types.FileContractID]fileContract),
+	}
+	// Chunk 0 has pieces 0-2 online and piece 3 offline. Chunk 1 has pieces
+	// 0 and 1, with piece 0 stored twice.
+	offlineID := types.FileContractID{4}
+	addPieces := func(id types.FileContractID, pieces ...pieceData) {
+		f.contracts[id] = fileContract{ID: id, IP: "foo.com:1234", Pieces: pieces}
+	}
+	addPieces(types.FileContractID{1}, pieceData{Chunk: 0, Piece: 0}, pieceData{Chunk: 1, Piece: 0})
+	addPieces(types.FileContractID{2}, pieceData{Chunk: 0, Piece: 1}, pieceData{Chunk: 1, Piece: 1})
+	addPieces(types.FileContractID{3}, pieceData{Chunk: 0, Piece: 2}, pieceData{Chunk: 1, Piece: 0})
+	addPieces(offlineID, pieceData{Chunk: 0, Piece: 3})
+	isOffline := func(id types.FileContractID) bool {
+		return id == offlineID
+	}
+
+	fh := f.health(isOffline)
+	if fh.SiaPath != "foo" || fh.MinPieces != 2 || fh.NumPieces != 4 || len(fh.Chunks) != 2 {
+		t.Fatalf("unexpected file health: %+v", fh)
+	}
+	if c := fh.Chunks[0]; len(c.Pieces) != 4 || c.OnlinePieces != 3 || c.Margin != 1 {
+		t.Fatalf("unexpected health of chunk 0: %+v", c)
+	}
+	if c := fh.Chunks[1]; len(c.Pieces) != 3 || c.OnlinePieces != 2 || c.Margin != 0 {
+		t.Fatalf("unexpected health of chunk 1: %+v", c)
+	}
+	for _, c := range fh.Chunks {
+		for i, p := range c.Pieces {
+			if i > 0 && p.Piece < c.Pieces[i-1].Piece {
+				t.Fatal("pieces are not sorted:", c.Pieces)
+			}
+			if p.Online == (p.Contract == offlineID) {
+				t.Fatal("piece has the wrong online status:", p)
+			}
+		}
+	}
+
+	margin, degraded := f.healthSummary(isOffline)
+	if margin != 0 || degraded != 2 {
+		t.Fatalf("expected a margin of 0 and 2 degraded chunks, got %v and %v", margin, degraded)
+	}
+
+	// A piece recorded for a chunk past the end of the file, as corrupt
+	// metadata could contain, should be ignored.
+	addPieces(types.FileContractID{5}, pieceData{Chunk: 2, Piece: 0})
+	if fh := f.health(isOffline); len(fh.Chunks) != 2 {
+		t.Fatalf("unexpected file health: %+v", fh)
+	}
+	margin, degraded = f.healthSummary(isOffline)
+	if margin != 0 || degraded != 2 {
+		t.Fatalf("expected a margin of 0 and 2 degraded chunks, got %v and %v", margin, degraded)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -18,6 +20,11 @@ var (
 	ErrEmptyFilename = errors.New("filename must be a nonempty string")
 	ErrPathOverload  = errors.New("a file already exists at that location")
 	ErrUnknownPath   = errors.New("no file known with that path")
+	ErrUnknownHash   = errors.New("no file known with that hash")
+
+	errEmptyMetadataKey   = errors.New("file metadata keys must be nonempty strings")
+	errNoChunkHashes      = errors.New("no chunk hashes were recorded for the file, it must be downloaded again in full")
+	errUnknownDestination = errors.New("destination does not correspond to any file known to the renter")
 )
 
 // A file is a single file that has been uploaded to the network. Files are
@@ -33,10 +40,24 @@ type file struct {
 	erasureCode modules.ErasureCoder // Static - can be accessed without lock.
 	pieceSize   uint64               // Static - can be accessed without lock.
 	mode        uint32               // actually an os.FileMode
+	hash        crypto.Hash          // hash of the file contents; zero if unknown
+	chunkHashes []crypto.Hash        // hash of the contents of each chunk; nil if unknown
+	access      fileAccessStats      // persisted in the renter metadata, not the .sia file
+	metadata    map[string]string    // user-supplied annotations; nil if none
+	confirmed   []byte               // bitfield of fully uploaded chunks that have not lost pieces; persisted in the renter metadata
 
 	mu sync.RWMutex
 }
 
+// fileAccessStats records how often a file has been downloaded. Statistics
+// are kept by the local renter only, and are not included in shared .sia
+// files.
+type fileAccessStats struct {
+	Downloads   uint64
+	BytesServed uint64
+	LastAccess  time.Time
+}
+
 // A fileContract is a contract covering an arbitrary number of file pieces.
 // Chunk/Piece metadata is used to split the raw contract data appropriately.
 type fileContract struct {
@@ -84,6 +105,13 @@ func (f *file) numChunks() uint64 {
 
 // available indicates whether the file is ready to be downloaded.
 func (f *file) available(isOffline func(types.FileContractID) bool) bool {
+	return len(f.checkHosts(isOffline)) == 0
+}
+
+// checkHosts returns the chunks of the file that cannot be recovered from the
+// pieces stored on online hosts, along with the number of pieces available
+// for each of them. The chunks are returned in ascending order.
+func (f *file) checkHosts(isOffline func(types.FileContractID) bool) insufficientHostsError {
 	chunkPieces := make([]int, f.numChunks())
 	for _, fc := range f.contracts {
 		if isOffline(fc.ID) {
@@ -93,12 +121,17 @@ func (f *file) available(isOffline func(types.FileContractID) bool) bool {
 			chunkPieces[p.Chunk]++
 		}
 	}
-	for _, n := range chunkPieces {
+	var deficient insufficientHostsError
+	for i, n := range chunkPieces {
 		if n < f.erasureCode.MinPieces() {
-			return false
+			deficient = append(deficient, chunkDeficiency{
+				Chunk:     uint64(i),
+				Available: n,
+				Required:  f.erasureCode.MinPieces(),
+			})
 		}
 	}
-	return true
+	return deficient
 }
 
 // uploadedBytes indicates how many bytes of the file have been uploaded via
@@ -174,6 +207,23 @@ func (f *file) expiration() types.BlockHeight {
 	return lowest
 }
 
+// expired indicates whether every contract holding a piece of the file has
+// ended, leaving the file permanently unrecoverable. A contract is considered
+// ended once its proof window has opened and neither it nor any of its
+// renewals is still held by the contractor. Files without any contracts are
+// never considered expired, since they may still be waiting to be uploaded.
+func (f *file) expired(height types.BlockHeight, isActive func(types.FileContractID) bool) bool {
+	if len(f.contracts) == 0 {
+		return false
+	}
+	for _, fc := range f.contracts {
+		if fc.WindowStart > height || isActive(fc.ID) {
+			return false
+		}
+	}
+	return true
+}
+
 // newFile creates a new file object.
 func newFile(name string, code modules.ErasureCoder, pieceSize, fileSize uint64) *file {
 	return &file{
@@ -186,6 +236,27 @@ func newFile(name string, code modules.ErasureCoder, pieceSize, fileSize uint64)
 	}
 }
 
+// aliasesOf returns the sorted nicknames of the aliases of the file named
+// name. The caller must hold the renter's lock.
+func (r *Renter) aliasesOf(name string) []string {
+	var aliases []string
+	for alias, target := range r.aliases {
+		if target == name {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+// retargetAliases points the aliases of the file named oldName at newName.
+// The caller must hold the renter's lock.
+func (r *Renter) retargetAliases(oldName, newName string) {
+	for _, alias := range r.aliasesOf(oldName) {
+		r.aliases[alias] = newName
+	}
+}
+
 // DeleteFile removes a file entry from the renter and deletes its data from
 // the hosts it is stored on.
 //
@@ -198,31 +269,119 @@ func (r *Renter) DeleteFile(nickname string) error {
 		r.mu.Unlock(lockID)
 		return ErrUnknownPath
 	}
+	inUse := r.removeFile(nickname)
+	err := r.saveSync()
+	r.mu.Unlock(lockID)
+	if err != nil || inUse {
+		return err
+	}
+
+	// delete the file's associated contract data.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// TODO: delete the sectors of the file as well.
+
+	return nil
+}
+
+// removeFile removes the file entry with the given nickname from the renter.
+// If the file has aliases, the first of them takes over the file's data so
+// that the pieces are kept for the others. removeFile returns true if the
+// file's data is still used by an alias or by the original of an alias. The
+// caller must hold the renter's lock, and must save the renter afterwards.
+func (r *Renter) removeFile(nickname string) bool {
+	f := r.files[nickname]
+	tracked, isTracked := r.tracking[nickname]
 	delete(r.files, nickname)
 	delete(r.tracking, nickname)
 
-	err := persist.RemoveFile(filepath.Join(r.persistDir, f.name+ShareExtension))
+	// An alias has no data of its own.
+	if _, isAlias := r.aliases[nickname]; isAlias {
+		delete(r.aliases, nickname)
+		return true
+	}
+
+	aliases := r.aliasesOf(nickname)
+	if len(aliases) > 0 {
+		heir := aliases[0]
+		delete(r.aliases, heir)
+		r.retargetAliases(nickname, heir)
+		if isTracked {
+			r.tracking[heir] = tracked
+		}
+		f.mu.Lock()
+		f.name = heir
+		err := r.saveFile(f)
+		f.mu.Unlock()
+		if err != nil {
+			r.log.Println("WARN: couldn't save file :", err)
+		}
+	}
+
+	err := persist.RemoveFile(filepath.Join(r.persistDir, nickname+ShareExtension))
 	if err != nil {
 		r.log.Println("WARN: couldn't remove file :", err)
 	}
+	return len(aliases) > 0
+}
 
-	r.saveSync()
-	r.mu.Unlock(lockID)
+// expiredFiles returns the names of all files whose contracts have expired.
+// expiredFiles must be called while holding the renter lock.
+func (r *Renter) expiredFiles() []string {
+	height := r.cs.Height()
+	isActive := func(id types.FileContractID) bool {
+		_, exists := r.hostContractor.ContractByID(r.hostContractor.ResolveID(id))
+		return exists
+	}
 
-	// delete the file's associated contract data.
-	f.mu.Lock()
-	defer f.mu.Unlock()
+	var names []string
+	for name, f := range r.files {
+		f.mu.RLock()
+		expired := f.expired(height, isActive)
+		f.mu.RUnlock()
+		if expired {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
 
-	// TODO: delete the sectors of the file as well.
+// ExpiredFiles returns the names of all files that can no longer be recovered
+// because every contract storing their pieces has expired.
+func (r *Renter) ExpiredFiles() []string {
+	lockID := r.mu.RLock()
+	defer r.mu.RUnlock(lockID)
+	return r.expiredFiles()
+}
 
-	return nil
+// PruneExpiredFiles removes the metadata of all files that can no longer be
+// recovered because every contract storing their pieces has expired. The
+// names of the removed files are returned. Files that are still recoverable
+// are never removed.
+func (r *Renter) PruneExpiredFiles() ([]string, error) {
+	lockID := r.mu.Lock()
+	defer r.mu.Unlock(lockID)
+
+	names := r.expiredFiles()
+	for _, name := range names {
+		r.removeFile(name)
+	}
+	if len(names) == 0 {
+		return names, nil
+	}
+	r.log.Printf("INFO: pruned %v expired files", len(names))
+	return names, r.saveSync()
 }
 
 // FileList returns all of the files that the renter has.
 func (r *Renter) FileList() []modules.FileInfo {
+	var names []string
 	var files []*file
 	lockID := r.mu.RLock()
-	for _, f := range r.files {
+	for name, f := range r.files {
+		names = append(names, name)
 		files = append(files, f)
 	}
 	r.mu.RUnlock(lockID)
@@ -234,17 +393,20 @@ func (r *Renter) FileList() []modules.FileInfo {
 	}
 
 	var fileList []modules.FileInfo
-	for _, f := range files {
+	for i, f := range files {
 		lockId := r.mu.RLock()
-		f.mu.RLock()
 		renewing := true
 		var localPath string
-		tf, exists := r.tracking[f.name]
+		tf, exists := r.tracking[names[i]]
 		if exists {
 			localPath = tf.RepairPath
 		}
+		r.mu.RUnlock(lockId)
+
+		f.mu.RLock()
+		margin, degraded := f.healthSummary(isOffline)
 		fileList = append(fileList, modules.FileInfo{
-			SiaPath:        f.name,
+			SiaPath:        names[i],
 			LocalPath:      localPath,
 			Filesize:       f.size,
 			Renewing:       renewing,
@@ -253,13 +415,57 @@ func (r *Renter) FileList() []modules.FileInfo {
 			UploadedBytes:  f.uploadedBytes(),
 			UploadProgress: f.uploadProgress(),
 			Expiration:     f.expiration(),
+			Hash:           f.hash,
+			Diversity:      f.diversity(),
+			Downloads:      f.access.Downloads,
+			BytesServed:    f.access.BytesServed,
+			LastAccess:     f.access.LastAccess,
+			Metadata:       copyMetadata(f.metadata),
+			HealthMargin:   margin,
+			DegradedChunks: degraded,
 		})
 		f.mu.RUnlock()
-		r.mu.RUnlock(lockId)
 	}
 	return fileList
 }
 
+// copyMetadata returns a copy of a file's metadata, or nil if the file has no
+// metadata.
+func copyMetadata(meta map[string]string) map[string]string {
+	if len(meta) == 0 {
+		return nil
+	}
+	c := make(map[string]string, len(meta))
+	for k, v := range meta {
+		c[k] = v
+	}
+	return c
+}
+
+// SetFileMetadata replaces the metadata of a file with meta. The metadata is
+// not interpreted by the renter; applications can use it to store
+// annotations such as tags, MIME types, or descriptions. A nil or empty map
+// clears the metadata.
+func (r *Renter) SetFileMetadata(nickname string, meta map[string]string) error {
+	lockID := r.mu.Lock()
+	defer r.mu.Unlock(lockID)
+
+	for k := range meta {
+		if k == "" {
+			return errEmptyMetadataKey
+		}
+	}
+	file, exists := r.files[nickname]
+	if !exists {
+		return ErrUnknownPath
+	}
+
+	file.mu.Lock()
+	defer file.mu.Unlock()
+	file.metadata = copyMetadata(meta)
+	return r.saveFile(file)
+}
+
 // RenameFile takes an existing file and changes the nickname. The original
 // file must exist, and there must not be any file that already has the
 // replacement nickname.
@@ -282,6 +488,15 @@ func (r *Renter) RenameFile(currentName, newName string) error {
 		return ErrPathOverload
 	}
 
+	// An alias is renamed without touching the file it shares.
+	if target, isAlias := r.aliases[currentName]; isAlias {
+		delete(r.files, currentName)
+		delete(r.aliases, currentName)
+		r.files[newName] = file
+		r.aliases[newName] = target
+		return r.saveSync()
+	}
+
 	// Modify the file and save it to disk.
 	file.mu.Lock()
 	file.name = newName
@@ -298,6 +513,7 @@ func (r *Renter) RenameFile(currentName, newName string) error {
 		delete(r.tracking, currentName)
 		r.tracking[newName] = t
 	}
+	r.retargetAliases(currentName, newName)
 	err = r.saveSync()
 	if err != nil {
 		return err
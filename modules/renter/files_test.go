@@ -1,10 +1,13 @@
 package renter
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -70,6 +73,52 @@ func TestFileAvailable(t *testing.T) {
 	}
 }
 
+// TestFileCheckHosts checks that checkHosts reports every chunk that is
+// missing pieces, along with the number of pieces that are available.
+func TestFileCheckHosts(t *testing.T) {
+	rsc, _ := NewRSCode(3, 10)
+	f := &file{
+		size:        3000,
+		erasureCode: rsc,
+		pieceSize:   100,
+	}
+	neverOffline := func(types.FileContractID) bool {
+		return false
+	}
+
+	// Give every chunk 3 pieces, except chunk 1 which only gets 2.
+	f.contracts = make(map[types.FileContractID]fileContract)
+	for p := uint64(0); p < 3; p++ {
+		fc := fileContract{ID: types.FileContractID{byte(p)}}
+		for i := uint64(0); i < f.numChunks(); i++ {
+			if i == 1 && p == 2 {
+				continue
+			}
+			fc.Pieces = append(fc.Pieces, pieceData{Chunk: i, Piece: p})
+		}
+		f.contracts[fc.ID] = fc
+	}
+
+	deficient := f.checkHosts(neverOffline)
+	if len(deficient) != 1 || deficient[0] != (chunkDeficiency{Chunk: 1, Available: 2, Required: 3}) {
+		t.Fatal("unexpected deficient chunks:", deficient)
+	}
+	if deficient.Error() != "insufficient hosts to recover file: chunk 1 has 2 of 3 required pieces" {
+		t.Fatal("unexpected error message:", deficient.Error())
+	}
+
+	// Taking a host offline makes every chunk deficient.
+	deficient = f.checkHosts(func(fcid types.FileContractID) bool {
+		return fcid == types.FileContractID{0}
+	})
+	if uint64(len(deficient)) != f.numChunks() {
+		t.Fatal("expected every chunk to be deficient, got", deficient)
+	}
+	if !strings.HasSuffix(deficient.Error(), fmt.Sprintf("(and %v more chunks)", len(deficient)-maxReportedDeficiencies)) {
+		t.Fatal("long error message was not truncated:", deficient.Error())
+	}
+}
+
 // TestFileUploadedBytes tests that uploadedBytes() returns a value equal to
 // the number of sectors stored via contract times the size of each sector.
 func TestFileUploadedBytes(t *testing.T) {
@@ -248,6 +297,45 @@ func TestFileExpiration(t *testing.T) {
 	}
 }
 
+// TestFileExpired probes the expired method of the file type.
+func TestFileExpired(t *testing.T) {
+	f := &file{
+		contracts: make(map[types.FileContractID]fileContract),
+	}
+	active := make(map[types.FileContractID]bool)
+	isActive := func(id types.FileContractID) bool {
+		return active[id]
+	}
+
+	if f.expired(1000, isActive) {
+		t.Error("file with no contracts should not be expired")
+	}
+
+	// Add two contracts whose windows have not yet opened.
+	f.contracts[types.FileContractID{0}] = fileContract{ID: types.FileContractID{0}, WindowStart: 50}
+	f.contracts[types.FileContractID{1}] = fileContract{ID: types.FileContractID{1}, WindowStart: 100}
+	if f.expired(40, isActive) {
+		t.Error("file with open contracts should not be expired")
+	}
+
+	// Once only one contract has ended, the file is still recoverable.
+	if f.expired(75, isActive) {
+		t.Error("file with a surviving contract should not be expired")
+	}
+
+	// Once both contracts have ended, the file is expired.
+	if !f.expired(100, isActive) {
+		t.Error("file with only ended contracts should be expired")
+	}
+
+	// A contract that is still held by the contractor, e.g. because it was
+	// renewed, keeps the file recoverable.
+	active[types.FileContractID{1}] = true
+	if f.expired(100, isActive) {
+		t.Error("file with a renewed contract should not be expired")
+	}
+}
+
 // TestRenterFileListLocalPath verifies that FileList() returns the correct
 // local path information for an uploaded file.
 func TestRenterFileListLocalPath(t *testing.T) {
@@ -450,3 +538,106 @@ func TestRenterRenameFile(t *testing.T) {
 		t.Error("renaming should have updated the entry in the tracking set")
 	}
 }
+
+// TestRenterDuplicateFile checks that only fully uploaded files with the same
+// contents and erasure coding are reported as duplicates.
+func TestRenterDuplicateFile(t *testing.T) {
+	rsc, _ := NewRSCode(1, 1)
+	orig := newFile("orig", rsc, 2, 2)
+	orig.hash = crypto.HashBytes([]byte("contents"))
+	orig.contracts[types.FileContractID{1}] = fileContract{
+		ID:     types.FileContractID{1},
+		Pieces: []pieceData{{Chunk: 0, Piece: 0}, {Chunk: 0, Piece: 1}},
+	}
+	r := &Renter{files: map[string]*file{"orig": orig}}
+
+	// A file with different contents or erasure coding is not a duplicate.
+	f := newFile("new", rsc, 2, 2)
+	f.hash = crypto.HashBytes([]byte("other contents"))
+	if r.duplicateFile(f) != nil {
+		t.Fatal("file with different contents reported as duplicate")
+	}
+	f.hash = orig.hash
+	f.erasureCode, _ = NewRSCode(1, 2)
+	if r.duplicateFile(f) != nil {
+		t.Fatal("file with different erasure coding reported as duplicate")
+	}
+	f.erasureCode = rsc
+	if r.duplicateFile(f) != orig {
+		t.Fatal("identical file not reported as duplicate")
+	}
+	if r.duplicateFile(orig) != nil {
+		t.Fatal("file reported as a duplicate of itself")
+	}
+
+	// A file that is still uploading is not a duplicate.
+	orig.contracts[types.FileContractID{1}] = fileContract{
+		ID:     types.FileContractID{1},
+		Pieces: []pieceData{{Chunk: 0, Piece: 0}},
+	}
+	if r.duplicateFile(f) != nil {
+		t.Fatal("partially uploaded file reported as duplicate")
+	}
+}
+
+// TestRenterAliases checks that aliases share the data of their original
+// through renames and deletions, and that the data is kept until the last
+// name referring to it is deleted.
+func TestRenterAliases(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	f := newTestingFile()
+	f.name = "orig"
+	id := rt.renter.mu.Lock()
+	rt.renter.files["orig"] = f
+	rt.renter.tracking["orig"] = trackedFile{"foo"}
+	for _, alias := range []string{"a", "b"} {
+		rt.renter.files[alias] = f
+		rt.renter.aliases[alias] = "orig"
+	}
+	rt.renter.mu.Unlock(id)
+
+	// Every name is listed.
+	if files := rt.renter.FileList(); len(files) != 3 {
+		t.Fatal("expected 3 files, got", len(files))
+	}
+
+	// Renaming the original retargets the aliases, and renaming an alias
+	// leaves the original alone.
+	if err := rt.renter.RenameFile("orig", "orig2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.RenameFile("b", "b2"); err != nil {
+		t.Fatal(err)
+	}
+	if rt.renter.aliases["a"] != "orig2" || rt.renter.aliases["b2"] != "orig2" || f.name != "orig2" {
+		t.Fatal("aliases were not updated by the renames:", rt.renter.aliases, f.name)
+	}
+
+	// Deleting the original hands its data to the first alias.
+	if err := rt.renter.DeleteFile("orig2"); err != nil {
+		t.Fatal(err)
+	}
+	if rt.renter.files["a"] != f || f.name != "a" || rt.renter.tracking["a"].RepairPath != "foo" {
+		t.Fatal("first alias did not take over the deleted file")
+	}
+	if _, isAlias := rt.renter.aliases["a"]; isAlias || rt.renter.aliases["b2"] != "a" {
+		t.Fatal("aliases were not updated by the deletion:", rt.renter.aliases)
+	}
+
+	// Deleting an alias leaves the data in place.
+	if err := rt.renter.DeleteFile("b2"); err != nil {
+		t.Fatal(err)
+	}
+	files := rt.renter.FileList()
+	if len(files) != 1 || files[0].SiaPath != "a" || len(rt.renter.aliases) != 0 {
+		t.Fatal("deleting an alias removed the wrong entries:", files, rt.renter.aliases)
+	}
+}
@@ -0,0 +1,136 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+var (
+	// hostBreakerExpiry is how long the failures of a host are remembered
+	// after its last failure. It keeps a host that failed during one download
+	// from being retried immediately by the next, without holding on to the
+	// state of hosts that have not been used in a long time.
+	hostBreakerExpiry = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+type (
+	// hostBreaker is a circuit breaker for the hosts that the renter downloads
+	// from, keyed by host address. A host is closed, and used normally, until
+	// it fails a number of times in a row. It is then open, and skipped, for a
+	// cooldown. Once the cooldown passes, a single probe download is allowed;
+	// if it succeeds the host is closed again, and if it fails the host is
+	// opened for another cooldown.
+	//
+	// The state is held by the renter rather than by a download, so that a
+	// host that is known to be failing is not retried by the next download.
+	hostBreaker struct {
+		hosts map[string]*hostBreakerState
+		mu    sync.Mutex
+	}
+
+	// hostBreakerState is the failure history of a single host.
+	hostBreakerState struct {
+		failures    int
+		lastFailure time.Time
+		openUntil   time.Time
+		probing     bool
+	}
+)
+
+// newHostBreaker returns a hostBreaker in which every host is closed.
+func newHostBreaker() *hostBreaker {
+	return &hostBreaker{
+		hosts: make(map[string]*hostBreakerState),
+	}
+}
+
+// breakerKey returns the key under which the worker's host is tracked by the
+// hostBreaker. Hosts are tracked by address, falling back to the public key
+// for hosts that are not known to the hostdb.
+func (w *worker) breakerKey() string {
+	if w.hostAddress != "" {
+		return string(w.hostAddress)
+	}
+	return w.hostPubKey.String()
+}
+
+// allow returns true if a download may be sent to the host. A host whose
+// cooldown has passed is allowed until a probe download is sent to it.
+func (hb *hostBreaker) allow(host string, now time.Time) bool {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	s, exists := hb.hosts[host]
+	if !exists || s.openUntil.IsZero() {
+		return true
+	}
+	return !s.probing && !now.Before(s.openUntil)
+}
+
+// dispatched informs the hostBreaker that a download has been sent to the
+// host. If the host is open, the download is its probe, and no other download
+// is allowed until the probe returns.
+func (hb *hostBreaker) dispatched(host string) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	if s, exists := hb.hosts[host]; exists && !s.openUntil.IsZero() {
+		s.probing = true
+	}
+}
+
+// abandoned informs the hostBreaker that a download sent to the host was
+// given up without reaching the host, because the worker was killed or the
+// piece was no longer needed. If the download was a probe, another probe is
+// allowed, so that the host is not skipped until it expires.
+func (hb *hostBreaker) abandoned(host string) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	if s, exists := hb.hosts[host]; exists {
+		s.probing = false
+	}
+}
+
+// recordSuccess closes the host and forgets its failures.
+func (hb *hostBreaker) recordSuccess(host string) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	delete(hb.hosts, host)
+}
+
+// recordFailure counts a failed download from the host, opening it for the
+// cooldown if it has failed threshold times in a row or if the download was a
+// probe. It returns true if the host was opened.
+func (hb *hostBreaker) recordFailure(host string, threshold int, cooldown time.Duration, now time.Time) bool {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	s, exists := hb.hosts[host]
+	if !exists {
+		s = new(hostBreakerState)
+		hb.hosts[host] = s
+	}
+	s.failures++
+	s.lastFailure = now
+	if !s.probing && s.failures < threshold {
+		return false
+	}
+	s.openUntil = now.Add(cooldown)
+	s.probing = false
+	return true
+}
+
+// prune forgets the hosts that have not failed within the hostBreakerExpiry.
+// A host that is still in its cooldown is kept.
+func (hb *hostBreaker) prune(now time.Time) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+	for host, s := range hb.hosts {
+		if now.Sub(s.lastFailure) > hostBreakerExpiry && now.After(s.openUntil) {
+			delete(hb.hosts, host)
+		}
+	}
+}
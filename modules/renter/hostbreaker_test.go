@@ -0,0 +1,77 @@
+package renter
+
+import (
+	"testing"
+	"time"
+)
+
+// TestHostBreaker checks that a host is skipped after failing repeatedly, and
+// that a single probe after the cooldown decides whether it is used again.
+func TestHostBreaker(t *testing.T) {
+	hb := newHostBreaker()
+	host := "host.com:9982"
+	cooldown := time.Minute
+	now := time.Now()
+
+	// The host stays closed until it fails threshold times in a row.
+	if hb.recordFailure(host, 2, cooldown, now) {
+		t.Fatal("host opened after a single failure")
+	}
+	if !hb.allow(host, now) {
+		t.Fatal("host skipped before reaching the failure threshold")
+	}
+	if !hb.recordFailure(host, 2, cooldown, now) {
+		t.Fatal("host not opened after reaching the failure threshold")
+	}
+	if hb.allow(host, now.Add(cooldown/2)) {
+		t.Fatal("host allowed during its cooldown")
+	}
+
+	// After the cooldown, a single probe is allowed. A failed probe opens
+	// the host again straight away.
+	later := now.Add(cooldown)
+	if !hb.allow(host, later) {
+		t.Fatal("host not allowed after its cooldown")
+	}
+	hb.dispatched(host)
+	if hb.allow(host, later) {
+		t.Fatal("second download allowed while the host is being probed")
+	}
+	if !hb.recordFailure(host, 2, cooldown, later) {
+		t.Fatal("host not opened after a failed probe")
+	}
+	if hb.allow(host, later) {
+		t.Fatal("host allowed after a failed probe")
+	}
+
+	// An abandoned probe allows another probe.
+	later = later.Add(cooldown)
+	hb.dispatched(host)
+	hb.abandoned(host)
+	if !hb.allow(host, later) {
+		t.Fatal("host skipped after its probe was abandoned")
+	}
+
+	// A successful probe closes the host and forgets its failures.
+	later = later.Add(cooldown)
+	hb.dispatched(host)
+	hb.recordSuccess(host)
+	if !hb.allow(host, later) {
+		t.Fatal("host skipped after a successful probe")
+	}
+	if hb.recordFailure(host, 2, cooldown, later) {
+		t.Fatal("failures were not forgotten after a successful probe")
+	}
+
+	// Failures are forgotten once they expire, but an open host is kept
+	// until its cooldown passes.
+	hb.prune(later.Add(hostBreakerExpiry + time.Second))
+	if len(hb.hosts) != 0 {
+		t.Fatal("expired host was not pruned")
+	}
+	hb.recordFailure(host, 1, 2*hostBreakerExpiry, later)
+	hb.prune(later.Add(hostBreakerExpiry + time.Second))
+	if len(hb.hosts) != 1 {
+		t.Fatal("host in its cooldown was pruned")
+	}
+}
@@ -30,6 +30,10 @@ const (
 	// allowed to be before being ignored as a DoS attempt.
 	maxSettingsLen = 10e3
 
+	// maxDecommissionLen indicates how long in bytes the signed decommission
+	// status of a host is allowed to be before being ignored as a DoS attempt.
+	maxDecommissionLen = 1e3
+
 	// minScans specifies the number of scans that a host should have before the
 	// scans start getting compressed.
 	minScans = 12
@@ -12,6 +12,7 @@ import (
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/fastrand"
 )
 
@@ -213,6 +214,39 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 	}
 }
 
+// CallHost connects to the host at netAddr, sends the rpc specifier and reads
+// the response of the host, signed with pubKey, into obj. The call fails if it
+// has not completed by deadline, and is aborted early if cancel is closed.
+func CallHost(netAddr modules.NetAddress, pubKey types.SiaPublicKey, rpc types.Specifier, obj interface{}, maxLen uint64, deadline time.Time, cancel <-chan struct{}) error {
+	dialer := &net.Dialer{
+		Cancel:   cancel,
+		Timeout:  hostRequestTimeout,
+		Deadline: deadline,
+	}
+	conn, err := dialer.Dial("tcp", string(netAddr))
+	if err != nil {
+		return err
+	}
+	connCloseChan := make(chan struct{})
+	go func() {
+		select {
+		case <-cancel:
+		case <-connCloseChan:
+		}
+		conn.Close()
+	}()
+	defer close(connCloseChan)
+	conn.SetDeadline(deadline)
+
+	err = encoding.WriteObject(conn, rpc)
+	if err != nil {
+		return err
+	}
+	var pubkey crypto.PublicKey
+	copy(pubkey[:], pubKey.Key)
+	return crypto.ReadSignedObject(conn, obj, maxLen, pubkey)
+}
+
 // managedScanHost will connect to a host and grab the settings, verifying
 // uptime and updating to the host's preferences.
 func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
@@ -227,40 +261,27 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	hdb.mu.RUnlock()
 
 	var settings modules.HostExternalSettings
-	err := func() error {
-		dialer := &net.Dialer{
-			Cancel:  hdb.tg.StopChan(),
-			Timeout: hostRequestTimeout,
-		}
-		conn, err := dialer.Dial("tcp", string(netAddr))
-		if err != nil {
-			return err
-		}
-		connCloseChan := make(chan struct{})
-		go func() {
-			select {
-			case <-hdb.tg.StopChan():
-			case <-connCloseChan:
-			}
-			conn.Close()
-		}()
-		defer close(connCloseChan)
-		conn.SetDeadline(time.Now().Add(hostScanDeadline))
-
-		err = encoding.WriteObject(conn, modules.RPCSettings)
-		if err != nil {
-			return err
-		}
-		var pubkey crypto.PublicKey
-		copy(pubkey[:], pubKey.Key)
-		return crypto.ReadSignedObject(conn, &settings, maxSettingsLen, pubkey)
-	}()
+	err := CallHost(netAddr, pubKey, modules.RPCSettings, &settings, maxSettingsLen, time.Now().Add(hostScanDeadline), hdb.tg.StopChan())
 	if err != nil {
 		hdb.log.Debugf("Scan of host at %v failed: %v", netAddr, err)
 
 	} else {
 		hdb.log.Debugf("Scan of host at %v succeeded.", netAddr)
 		entry.HostExternalSettings = settings
+
+		// A host that stops accepting contracts may be decommissioning, in
+		// which case the renter should move its data off of the host before
+		// the deadline. Hosts that do not support RPCDecommission close the
+		// connection and are treated as not decommissioning.
+		var status modules.HostDecommission
+		if !settings.AcceptingContracts {
+			decErr := CallHost(netAddr, pubKey, modules.RPCDecommission, &status, maxDecommissionLen, time.Now().Add(hostScanDeadline), hdb.tg.StopChan())
+			if decErr != nil {
+				hdb.log.Debugf("Decommission status of host at %v unavailable: %v", netAddr, decErr)
+				status = modules.HostDecommission{}
+			}
+		}
+		entry.Decommission = status
 	}
 
 	// Update the host tree to have a new entry, including the new error. Then
@@ -0,0 +1,87 @@
+package renter
+
+import (
+	"net"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// ipv4SubnetBits and ipv6SubnetBits are the prefix lengths used to group
+	// hosts into subnets. Hosts within the same subnet are assumed to be run
+	// by the same operator, and therefore to be prone to correlated failures.
+	ipv4SubnetBits = 24
+	ipv6SubnetBits = 64
+)
+
+// hostSubnet returns an identifier for the subnet of a host's address. Hosts
+// that announce a hostname instead of an IP are identified by the hostname. The
+// empty string is returned if the address is unknown, in which case the host
+// should be treated as independent of every other host.
+func hostSubnet(addr modules.NetAddress) string {
+	host := addr.Host()
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return host
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return (&net.IPNet{IP: ip4.Mask(net.CIDRMask(ipv4SubnetBits, 32)), Mask: net.CIDRMask(ipv4SubnetBits, 32)}).String()
+	}
+	return (&net.IPNet{IP: ip.Mask(net.CIDRMask(ipv6SubnetBits, 128)), Mask: net.CIDRMask(ipv6SubnetBits, 128)}).String()
+}
+
+// diversity returns the fraction of the hosts storing pieces of the file's
+// least diverse chunk that are in distinct subnets. A diversity of 1 means that
+// no two pieces of any chunk are stored within the same subnet. Files without
+// any uploaded pieces have a diversity of 0.
+func (f *file) diversity() float64 {
+	hosts := make([]int, f.numChunks())
+	subnets := make([]map[string]struct{}, f.numChunks())
+	for _, fc := range f.contracts {
+		subnet := hostSubnet(fc.IP)
+		if subnet == "" {
+			// Unknown addresses can't be grouped, count them as unique.
+			subnet = string(fc.ID[:])
+		}
+		counted := make(map[uint64]struct{})
+		for _, p := range fc.Pieces {
+			if _, exists := counted[p.Chunk]; exists {
+				continue
+			}
+			counted[p.Chunk] = struct{}{}
+			hosts[p.Chunk]++
+			if subnets[p.Chunk] == nil {
+				subnets[p.Chunk] = make(map[string]struct{})
+			}
+			subnets[p.Chunk][subnet] = struct{}{}
+		}
+	}
+
+	diversity := -1.0
+	for i := range hosts {
+		if hosts[i] == 0 {
+			return 0
+		}
+		d := float64(len(subnets[i])) / float64(hosts[i])
+		if diversity < 0 || d < diversity {
+			diversity = d
+		}
+	}
+	if diversity < 0 {
+		return 0
+	}
+	return diversity
+}
+
+// diverseHostAvailable returns true if any host that could still receive a
+// piece of the chunk is in a subnet that does not yet store a piece of the
+// chunk. The chunk's mutex must be held.
+func (uc *unfinishedChunk) diverseHostAvailable() bool {
+	for host := range uc.unusedHosts {
+		subnet := uc.hostSubnets[host]
+		if subnet == "" || uc.usedSubnets[subnet] == 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestHostSubnet checks that hosts are grouped into the expected subnets.
+func TestHostSubnet(t *testing.T) {
+	tests := []struct {
+		addr   modules.NetAddress
+		subnet string
+	}{
+		{"1.2.3.4:9982", "1.2.3.0/24"},
+		{"1.2.3.200:9982", "1.2.3.0/24"},
+		{"[2001:db8:1:2:3::1]:9982", "2001:db8:1:2::/64"},
+		{"host.example.com:9982", "host.example.com"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if subnet := hostSubnet(test.addr); subnet != test.subnet {
+			t.Errorf("expected subnet of %q to be %q, got %q", test.addr, test.subnet, subnet)
+		}
+	}
+}
+
+// TestFileDiversity checks that the diversity of a file reflects how many of
+// the hosts storing each chunk share a subnet.
+func TestFileDiversity(t *testing.T) {
+	rsc, _ := NewRSCode(1, 3)
+	f := &file{
+		size:        100,
+		pieceSize:   100,
+		contracts:   make(map[types.FileContractID]fileContract),
+		erasureCode: rsc,
+	}
+	if d := f.diversity(); d != 0 {
+		t.Fatal("expected a file without pieces to have 0 diversity, got", d)
+	}
+
+	f.contracts[types.FileContractID{0}] = fileContract{
+		ID:     types.FileContractID{0},
+		IP:     "1.2.3.4:9982",
+		Pieces: []pieceData{{Chunk: 0, Piece: 0}},
+	}
+	f.contracts[types.FileContractID{1}] = fileContract{
+		ID:     types.FileContractID{1},
+		IP:     "5.6.7.8:9982",
+		Pieces: []pieceData{{Chunk: 0, Piece: 1}},
+	}
+	if d := f.diversity(); d != 1 {
+		t.Fatal("expected hosts in distinct subnets to have a diversity of 1, got", d)
+	}
+
+	f.contracts[types.FileContractID{2}] = fileContract{
+		ID:     types.FileContractID{2},
+		IP:     "1.2.3.5:9982",
+		Pieces: []pieceData{{Chunk: 0, Piece: 2}},
+	}
+	if d := f.diversity(); d != 2.0/3 {
+		t.Fatal("expected two of three hosts to be in distinct subnets, got", d)
+	}
+}
+
+// TestDiverseHostAvailable checks that a chunk only defers to other hosts when
+// they are in a subnet that does not yet store a piece of the chunk.
+func TestDiverseHostAvailable(t *testing.T) {
+	uc := &unfinishedChunk{
+		unusedHosts: map[string]struct{}{"a": {}, "b": {}},
+		hostSubnets: map[string]string{"a": "1.2.3.0/24", "b": "1.2.3.0/24", "c": "5.6.7.0/24"},
+		usedSubnets: map[string]int{"1.2.3.0/24": 1},
+	}
+	if uc.diverseHostAvailable() {
+		t.Fatal("all unused hosts share a used subnet")
+	}
+	uc.unusedHosts["c"] = struct{}{}
+	if !uc.diverseHostAvailable() {
+		t.Fatal("host c is in an unused subnet")
+	}
+}
@@ -0,0 +1,127 @@
+package renter
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errBadHostPreference = errors.New("host preference must be between 0 (cheapest) and 1 (fastest)")
+)
+
+// validHostPreference returns true if p lies between HostPreferCheapest and
+// HostPreferFastest.
+func validHostPreference(p modules.HostPreference) bool {
+	return p >= modules.HostPreferCheapest && p <= modules.HostPreferFastest
+}
+
+// HostPreference returns the cost-versus-speed preference used for downloads
+// that do not specify their own.
+func (r *Renter) HostPreference() modules.HostPreference {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.hostPreference
+}
+
+// SetHostPreference sets the cost-versus-speed preference used for downloads
+// that do not specify their own. Downloads that are already queued keep the
+// preference they were started with.
+func (r *Renter) SetHostPreference(p modules.HostPreference) error {
+	if !validHostPreference(p) {
+		return errBadHostPreference
+	}
+	id := r.mu.Lock()
+	r.hostPreference = p
+	r.mu.Unlock(id)
+	r.log.Println("INFO: renter host preference set to", p)
+	return nil
+}
+
+// recordDownloadLatency folds the duration of a successful piece download
+// into the worker's moving average latency and the variance around it. As in
+// the TCP retransmission timer, the first sample sets the variance as if the
+// latency deviated by half of it, so that a single download is not trusted
+// to predict the next one exactly.
+func (w *worker) recordDownloadLatency(d time.Duration) {
+	old := atomic.LoadInt64(&w.atomicDownloadLatency)
+	if old == 0 {
+		atomic.StoreUint64(&w.atomicDownloadLatencyVariance, math.Float64bits(float64(d/2)*float64(d/2)))
+		atomic.StoreInt64(&w.atomicDownloadLatency, int64(d))
+		return
+	}
+	diff := float64(int64(d) - old)
+	variance := math.Float64frombits(atomic.LoadUint64(&w.atomicDownloadLatencyVariance))
+	atomic.StoreUint64(&w.atomicDownloadLatencyVariance, math.Float64bits(3*(variance+diff*diff/4)/4))
+	atomic.StoreInt64(&w.atomicDownloadLatency, (3*old+int64(d))/4)
+}
+
+// downloadTimeout returns the amount of time that the worker allows its host
+// to deliver a piece, which is the moving average latency plus three
+// standard deviations, kept between minPieceDownloadTimeout and
+// defaultPieceDownloadTimeout. Fast and steady hosts get short timeouts so
+// that their pieces fail over quickly, while slow hosts get as long as they
+// usually need. Workers that have not downloaded anything yet use
+// defaultPieceDownloadTimeout.
+func (w *worker) downloadTimeout() time.Duration {
+	mean := atomic.LoadInt64(&w.atomicDownloadLatency)
+	if mean == 0 {
+		return defaultPieceDownloadTimeout
+	}
+	variance := math.Float64frombits(atomic.LoadUint64(&w.atomicDownloadLatencyVariance))
+	timeout := time.Duration(float64(mean) + 3*math.Sqrt(variance))
+	if timeout < minPieceDownloadTimeout {
+		return minPieceDownloadTimeout
+	} else if timeout > defaultPieceDownloadTimeout {
+		return defaultPieceDownloadTimeout
+	}
+	return timeout
+}
+
+// fraction returns x/max as a float64, or 0 if max is zero.
+func fraction(x, max types.Currency) float64 {
+	if max.IsZero() {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(x.Big(), max.Big()).Float64()
+	return f
+}
+
+// preferredWorker returns the index of the worker in workers that best
+// matches the host preference p. Each worker's download price and latency are
+// scaled against the most expensive and slowest of the workers, and the two
+// are weighted by p; the worker with the lowest weighted cost is chosen.
+// Workers that have not downloaded anything yet are assumed to be fast, so
+// that their latency gets measured. Ties go to the earlier worker.
+func preferredWorker(workers []*worker, p modules.HostPreference) int {
+	var maxPrice types.Currency
+	var maxLatency int64
+	latencies := make([]int64, len(workers))
+	for i, w := range workers {
+		if w.downloadPrice.Cmp(maxPrice) > 0 {
+			maxPrice = w.downloadPrice
+		}
+		latencies[i] = atomic.LoadInt64(&w.atomicDownloadLatency)
+		if latencies[i] > maxLatency {
+			maxLatency = latencies[i]
+		}
+	}
+
+	best, bestCost := 0, 0.0
+	for i, w := range workers {
+		var latency float64
+		if maxLatency > 0 {
+			latency = float64(latencies[i]) / float64(maxLatency)
+		}
+		cost := (1-float64(p))*fraction(w.downloadPrice, maxPrice) + float64(p)*latency
+		if i == 0 || cost < bestCost {
+			best, bestCost = i, cost
+		}
+	}
+	return best
+}
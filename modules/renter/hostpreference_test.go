@@ -0,0 +1,120 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestPreferredWorker checks that preferredWorker trades price against
+// latency according to the host preference.
+func TestPreferredWorker(t *testing.T) {
+	cheap := &worker{downloadPrice: types.NewCurrency64(10), atomicDownloadLatency: int64(time.Second)}
+	fast := &worker{downloadPrice: types.NewCurrency64(100), atomicDownloadLatency: int64(10 * time.Millisecond)}
+	workers := []*worker{fast, cheap}
+
+	if i := preferredWorker(workers, modules.HostPreferCheapest); workers[i] != cheap {
+		t.Error("cheapest preference should choose the cheap worker")
+	}
+	if i := preferredWorker(workers, modules.HostPreferFastest); workers[i] != fast {
+		t.Error("fastest preference should choose the fast worker")
+	}
+
+	// A worker that is a little faster but much more expensive only wins
+	// when speed is weighted heavily.
+	slightlyFaster := &worker{downloadPrice: types.NewCurrency64(100), atomicDownloadLatency: int64(800 * time.Millisecond)}
+	workers = []*worker{slightlyFaster, cheap}
+	if i := preferredWorker(workers, modules.HostPreferBalanced); workers[i] != cheap {
+		t.Error("balanced preference should choose the much cheaper worker")
+	}
+	if i := preferredWorker(workers, modules.HostPreferFastest); workers[i] != slightlyFaster {
+		t.Error("fastest preference should choose the faster worker")
+	}
+
+	// Unmeasured workers are assumed to be fast.
+	unmeasured := &worker{downloadPrice: types.NewCurrency64(100)}
+	workers = []*worker{cheap, unmeasured}
+	if i := preferredWorker(workers, modules.HostPreferFastest); workers[i] != unmeasured {
+		t.Error("fastest preference should try the unmeasured worker")
+	}
+}
+
+// TestRecordDownloadLatency checks that the latency of a worker is a moving
+// average of its downloads.
+func TestRecordDownloadLatency(t *testing.T) {
+	w := new(worker)
+	w.recordDownloadLatency(time.Second)
+	if w.atomicDownloadLatency != int64(time.Second) {
+		t.Fatal("first sample should set the latency, got", time.Duration(w.atomicDownloadLatency))
+	}
+	w.recordDownloadLatency(5 * time.Second)
+	if w.atomicDownloadLatency != int64(2*time.Second) {
+		t.Fatal("expected a latency of 2s, got", time.Duration(w.atomicDownloadLatency))
+	}
+}
+
+// TestDownloadTimeout checks that the download timeout of a worker adapts to
+// the latency of its host, and that workers without history use the default.
+func TestDownloadTimeout(t *testing.T) {
+	w := new(worker)
+	if timeout := w.downloadTimeout(); timeout != defaultPieceDownloadTimeout {
+		t.Fatal("unmeasured worker should use the default timeout, got", timeout)
+	}
+
+	// A single sample is assumed to deviate by half of the latency.
+	latency := 4 * minPieceDownloadTimeout
+	w.recordDownloadLatency(latency)
+	if timeout := w.downloadTimeout(); timeout != latency*5/2 {
+		t.Fatal("expected a timeout of", latency*5/2, "got", timeout)
+	}
+
+	// Steady downloads shrink the deviation, and with it the timeout.
+	for i := 0; i < 20; i++ {
+		w.recordDownloadLatency(latency)
+	}
+	if timeout := w.downloadTimeout(); timeout < latency || timeout > latency*11/10 {
+		t.Fatal("steady host should have a timeout close to its latency, got", timeout)
+	}
+
+	// Timeouts stay within their bounds.
+	fast := new(worker)
+	fast.recordDownloadLatency(time.Millisecond)
+	if timeout := fast.downloadTimeout(); timeout != minPieceDownloadTimeout {
+		t.Fatal("fast host should get the minimum timeout, got", timeout)
+	}
+	slow := new(worker)
+	slow.recordDownloadLatency(defaultPieceDownloadTimeout)
+	if timeout := slow.downloadTimeout(); timeout != defaultPieceDownloadTimeout {
+		t.Fatal("slow host should get the default timeout, got", timeout)
+	}
+}
+
+// TestSetHostPreference checks that out-of-range host preferences are
+// rejected.
+func TestSetHostPreference(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+
+	if p := rt.renter.HostPreference(); p != modules.HostPreferBalanced {
+		t.Fatal("expected the balanced preference by default, got", p)
+	}
+	if err := rt.renter.SetHostPreference(modules.HostPreferCheapest); err != nil {
+		t.Fatal(err)
+	}
+	if p := rt.renter.HostPreference(); p != modules.HostPreferCheapest {
+		t.Fatal("host preference was not set, got", p)
+	}
+	for _, p := range []modules.HostPreference{-0.1, 1.1} {
+		if err := rt.renter.SetHostPreference(p); err != errBadHostPreference {
+			t.Errorf("expected errBadHostPreference for %v, got %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package renter
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	errNonPositiveLimit = errors.New("renter limits must be greater than zero")
+	errRepairThreshold  = errors.New("repair threshold must be a percentage no greater than 100")
+	errWorkerKilled     = errors.New("worker was killed before it could connect to the host")
+	errPieceCancelled   = errors.New("piece is no longer needed by its chunk")
+)
+
+// managedLimits returns the renter's current resource limits.
+func (r *Renter) managedLimits() modules.RenterLimits {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.limits
+}
+
+// managedAcquireHostConnection blocks until the worker is allowed to open a
+// connection to its host. False is returned if the worker was killed while
+// waiting, in which case no connection may be opened.
+func (w *worker) managedAcquireHostConnection() bool {
+	if w.renter.hostConnections.Request(1, w.killChan) {
+		return false
+	}
+	atomic.AddInt64(&w.renter.atomicOpenHostConnections, 1)
+	return true
+}
+
+// managedReleaseHostConnection returns a connection acquired through
+// managedAcquireHostConnection.
+func (w *worker) managedReleaseHostConnection() {
+	atomic.AddInt64(&w.renter.atomicOpenHostConnections, -1)
+	w.renter.hostConnections.Release(1)
+}
+
+// Limits returns the renter's current resource limits along with how much of
+// each limit is in use.
+func (r *Renter) Limits() (modules.RenterLimits, modules.RenterLimitsUtilization) {
+	return r.managedLimits(), modules.RenterLimitsUtilization{
+		ActiveDownloads: int(atomic.LoadInt64(&r.atomicActiveDownloadPieces)),
+		ActiveUploads:   int(atomic.LoadInt64(&r.atomicActiveUploadPieces)),
+		HostConnections: int(atomic.LoadInt64(&r.atomicOpenHostConnections)),
+	}
+}
+
+// SetLimits sets the renter's resource limits. Lowering a limit does not
+// interrupt work that is already in progress; new work is held back until
+// utilization falls below the new limit.
+func (r *Renter) SetLimits(l modules.RenterLimits) error {
+	if l.MaxConcurrentDownloads <= 0 || l.MaxConcurrentUploads <= 0 || l.MaxHostConnections <= 0 ||
+		l.MaxDownloadWorkers <= 0 || l.MaxUploadWorkers <= 0 || l.DownloadBufferChunks <= 0 ||
+		l.RepairScanFiles <= 0 || l.RepairThreshold <= 0 || l.HostFailureThreshold <= 0 ||
+		l.HostFailureCooldown <= 0 {
+		return errNonPositiveLimit
+	}
+	if l.RepairThreshold > 100 {
+		return errRepairThreshold
+	}
+
+	id := r.mu.Lock()
+	r.limits = l
+	r.mu.Unlock(id)
+	r.uploadSlots.SetLimit(l.MaxConcurrentUploads)
+	r.hostConnections.SetLimit(l.MaxHostConnections)
+	r.log.Printf("INFO: renter limits set to %+v", l)
+	return nil
+}
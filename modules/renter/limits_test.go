@@ -0,0 +1,73 @@
+package renter
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	siasync "github.com/NebulousLabs/Sia/sync"
+)
+
+// TestSetLimits checks that the renter limits can be changed, and that the
+// host connection limit is enforced for the workers.
+func TestSetLimits(t *testing.T) {
+	r := &Renter{
+		uploadSlots:     siasync.NewLimiter(defaultMaxConcurrentPieceUploads),
+		hostConnections: siasync.NewLimiter(defaultMaxHostConnections),
+		log:             persist.NewLogger(ioutil.Discard),
+		mu:              siasync.New(modules.SafeMutexDelay, 1),
+	}
+
+	// Limits of zero should be rejected.
+	if err := r.SetLimits(modules.RenterLimits{}); err != errNonPositiveLimit {
+		t.Fatal("expected errNonPositiveLimit, got", err)
+	}
+
+	limits := modules.RenterLimits{
+		MaxConcurrentDownloads: 4,
+		MaxConcurrentUploads:   3,
+		MaxHostConnections:     1,
+		MaxDownloadWorkers:     2,
+		MaxUploadWorkers:       2,
+		DownloadBufferChunks:   2,
+		RepairScanFiles:        3,
+		RepairThreshold:        50,
+		HostFailureThreshold:   2,
+		HostFailureCooldown:    10,
+	}
+	tooHigh := limits
+	tooHigh.RepairThreshold = 101
+	if err := r.SetLimits(tooHigh); err != errRepairThreshold {
+		t.Fatal("expected errRepairThreshold, got", err)
+	}
+	if err := r.SetLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	current, utilization := r.Limits()
+	if current != limits {
+		t.Fatal("limits were not updated:", current)
+	}
+	if utilization != (modules.RenterLimitsUtilization{}) {
+		t.Fatal("idle renter reports utilization:", utilization)
+	}
+
+	// With a single host connection available, a second worker should not be
+	// able to connect until the first releases its connection.
+	w1 := &worker{renter: r, killChan: make(chan struct{})}
+	w2 := &worker{renter: r, killChan: make(chan struct{})}
+	if !w1.managedAcquireHostConnection() {
+		t.Fatal("worker could not acquire a host connection")
+	}
+	if _, u := r.Limits(); u.HostConnections != 1 {
+		t.Fatal("expected 1 host connection, got", u.HostConnections)
+	}
+	close(w2.killChan)
+	if w2.managedAcquireHostConnection() {
+		t.Fatal("killed worker acquired a host connection beyond the limit")
+	}
+	w1.managedReleaseHostConnection()
+	if _, u := r.Limits(); u.HostConnections != 0 {
+		t.Fatal("expected 0 host connections, got", u.HostConnections)
+	}
+}
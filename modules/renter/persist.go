@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -35,9 +36,41 @@ var (
 	}
 
 	shareHeader  = [15]byte{'S', 'i', 'a', ' ', 'S', 'h', 'a', 'r', 'e', 'd', ' ', 'F', 'i', 'l', 'e'}
-	shareVersion = "0.4"
+	shareVersion = "0.7"
+
+	// COMPATv0.4 - files shared before version 0.5 do not include the hash
+	// of the file contents.
+	shareVersionNoHash = "0.4"
+
+	// COMPATv0.5 - files shared before version 0.6 do not include the hashes
+	// of the chunks of the file contents.
+	shareVersionNoChunkHashes = "0.5"
+
+	// COMPATv0.6 - files shared before version 0.7 do not include the
+	// metadata of the file.
+	shareVersionNoMetadata = "0.6"
 )
 
+// A fileMetadataEntry is a single key-value pair of a file's metadata. The
+// metadata is encoded as a list of entries, sorted by key, because maps
+// cannot be encoded directly.
+type fileMetadataEntry struct {
+	Key   string
+	Value string
+}
+
+// metadataEntries converts a file's metadata into a sorted list of entries.
+func metadataEntries(meta map[string]string) []fileMetadataEntry {
+	entries := make([]fileMetadataEntry, 0, len(meta))
+	for k, v := range meta {
+		entries = append(entries, fileMetadataEntry{Key: k, Value: v})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
 // MarshalSia implements the encoding.SiaMarshaller interface, writing the
 // file data to w.
 func (f *file) MarshalSia(w io.Writer) error {
@@ -178,11 +211,42 @@ func (r *Renter) saveFile(f *file) error {
 	return handle.CommitSync()
 }
 
+// saveDirty saves the renter metadata if it has unsaved changes. The caller
+// must hold the renter's lock, but not the lock of any file.
+func (r *Renter) saveDirty() {
+	if !r.persistDirty {
+		return
+	}
+	if err := r.saveSync(); err != nil {
+		r.log.Println("WARN: could not save the renter metadata:", err)
+		return
+	}
+	r.persistDirty = false
+}
+
 // saveSync stores the current renter data to disk and then syncs to disk.
 func (r *Renter) saveSync() error {
+	access := make(map[string]fileAccessStats)
+	confirmed := make(map[string][]byte)
+	for name, f := range r.files {
+		if _, isAlias := r.aliases[name]; isAlias {
+			continue
+		}
+		f.mu.RLock()
+		if f.access.Downloads > 0 {
+			access[name] = f.access
+		}
+		if len(f.confirmed) > 0 {
+			confirmed[name] = f.confirmed
+		}
+		f.mu.RUnlock()
+	}
 	data := struct {
-		Tracking map[string]trackedFile
-	}{r.tracking}
+		Tracking  map[string]trackedFile
+		Access    map[string]fileAccessStats
+		Confirmed map[string][]byte
+		Aliases   map[string]string
+	}{r.tracking, access, confirmed, r.aliases}
 
 	return persist.SaveJSON(saveMetadata, data, filepath.Join(r.persistDir, PersistFilename))
 }
@@ -227,6 +291,9 @@ func (r *Renter) load() error {
 	// Load contracts, repair set, and entropy.
 	data := struct {
 		Tracking  map[string]trackedFile
+		Access    map[string]fileAccessStats
+		Confirmed map[string][]byte
+		Aliases   map[string]string
 		Repairing map[string]string // COMPATv0.4.8
 	}{}
 	err = persist.LoadJSON(saveMetadata, &data, filepath.Join(r.persistDir, PersistFilename))
@@ -236,6 +303,23 @@ func (r *Renter) load() error {
 	if data.Tracking != nil {
 		r.tracking = data.Tracking
 	}
+	for name, access := range data.Access {
+		if f, exists := r.files[name]; exists {
+			f.access = access
+		}
+	}
+	for name, confirmed := range data.Confirmed {
+		if f, exists := r.files[name]; exists {
+			f.confirmed = confirmed
+		}
+	}
+	for alias, name := range data.Aliases {
+		f, exists := r.files[name]
+		if _, taken := r.files[alias]; exists && !taken {
+			r.files[alias] = f
+			r.aliases[alias] = name
+		}
+	}
 
 	return nil
 }
@@ -257,9 +341,10 @@ func shareFiles(files []*file, w io.Writer) error {
 	zip, _ := gzip.NewWriterLevel(w, gzip.BestSpeed)
 	enc := encoding.NewEncoder(zip)
 
-	// Encode each file.
+	// Encode each file, followed by the hash of its contents, the hashes of
+	// each of its chunks, and its metadata.
 	for _, f := range files {
-		err = enc.Encode(f)
+		err = enc.EncodeAll(f, f.hash, f.chunkHashes, metadataEntries(f.metadata))
 		if err != nil {
 			return err
 		}
@@ -343,7 +428,7 @@ func (r *Renter) loadSharedFiles(reader io.Reader) ([]string, error) {
 		return nil, err
 	} else if header != shareHeader {
 		return nil, ErrBadFile
-	} else if version != shareVersion {
+	} else if version != shareVersion && version != shareVersionNoHash && version != shareVersionNoChunkHashes && version != shareVersionNoMetadata {
 		return nil, ErrIncompatible
 	}
 
@@ -362,6 +447,31 @@ func (r *Renter) loadSharedFiles(reader io.Reader) ([]string, error) {
 		if err != nil {
 			return nil, err
 		}
+		if version != shareVersionNoHash {
+			err = dec.Decode(&files[i].hash)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if version == shareVersion || version == shareVersionNoMetadata {
+			err = dec.Decode(&files[i].chunkHashes)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if version == shareVersion {
+			var entries []fileMetadataEntry
+			err = dec.Decode(&entries)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if files[i].metadata == nil {
+					files[i].metadata = make(map[string]string)
+				}
+				files[i].metadata[e.Key] = e.Value
+			}
+		}
 
 		// Make sure the file's name does not conflict with existing files.
 		dupCount := 0
@@ -2,6 +2,7 @@ package renter
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -11,6 +12,8 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	siasync "github.com/NebulousLabs/Sia/sync"
 	"github.com/NebulousLabs/fastrand"
 )
 
@@ -27,6 +30,7 @@ func newTestingFile() *file {
 		masterKey:   crypto.GenerateTwofishKey(),
 		erasureCode: rsc,
 		pieceSize:   encoding.DecUint64(data[6:8]),
+		hash:        crypto.HashBytes(data),
 	}
 }
 
@@ -69,6 +73,125 @@ func TestFileMarshalling(t *testing.T) {
 	}
 }
 
+// TestShareFilesHash checks that the hash of a file's contents survives
+// sharing and loading, and that it can be used to look the file up.
+func TestShareFilesHash(t *testing.T) {
+	r := &Renter{
+		files:      make(map[string]*file),
+		persistDir: build.TempDir("renter", t.Name()),
+		mu:         siasync.New(modules.SafeMutexDelay, 1),
+	}
+	if err := os.MkdirAll(r.persistDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	savedFile := newTestingFile()
+	savedFile.chunkHashes = []crypto.Hash{{1}, {2}}
+	buf := new(bytes.Buffer)
+	if err := shareFiles([]*file{savedFile}, buf); err != nil {
+		t.Fatal(err)
+	}
+	names, err := r.loadSharedFiles(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || r.files[names[0]].hash != savedFile.hash {
+		t.Fatal("file hash was not loaded")
+	}
+	if loaded := r.files[names[0]].chunkHashes; len(loaded) != 2 || loaded[0] != savedFile.chunkHashes[0] || loaded[1] != savedFile.chunkHashes[1] {
+		t.Fatal("chunk hashes were not loaded:", loaded)
+	}
+
+	// Files shared before file hashes were introduced should load with a
+	// zero hash.
+	handle, err := os.Open(filepath.Join("..", "..", "compatibility", "siafile_v0.4.8.sia"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer handle.Close()
+	names, err = r.loadSharedFiles(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || r.files[names[0]].hash != (crypto.Hash{}) {
+		t.Fatal("compatibility file not loaded properly:", names)
+	}
+
+	// Looking up a hash that no file has should fail without starting a
+	// download.
+	if err := r.DownloadByHash(crypto.Hash{1}, "/tmp/foo"); err != ErrUnknownHash {
+		t.Fatal("expected ErrUnknownHash, got", err)
+	}
+	if err := r.DownloadByHash(crypto.Hash{}, "/tmp/foo"); err != ErrUnknownHash {
+		t.Fatal("expected ErrUnknownHash for the zero hash, got", err)
+	}
+}
+
+// TestShareFilesMetadata checks that the metadata of a file survives sharing
+// and loading, and that files shared before metadata was introduced still
+// load.
+func TestShareFilesMetadata(t *testing.T) {
+	r := &Renter{
+		files:      make(map[string]*file),
+		persistDir: build.TempDir("renter", t.Name()),
+		mu:         siasync.New(modules.SafeMutexDelay, 1),
+	}
+	if err := os.MkdirAll(r.persistDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	savedFile := newTestingFile()
+	r.files[savedFile.name] = savedFile
+
+	// Setting metadata with an empty key should fail.
+	if err := r.SetFileMetadata(savedFile.name, map[string]string{"": "foo"}); err != errEmptyMetadataKey {
+		t.Fatal("expected errEmptyMetadataKey, got", err)
+	}
+	if err := r.SetFileMetadata("missing", nil); err != ErrUnknownPath {
+		t.Fatal("expected ErrUnknownPath, got", err)
+	}
+	meta := map[string]string{"mime": "text/plain", "tags": "foo,bar"}
+	if err := r.SetFileMetadata(savedFile.name, meta); err != nil {
+		t.Fatal(err)
+	}
+	meta["mime"] = "changed"
+	if savedFile.metadata["mime"] != "text/plain" {
+		t.Fatal("file metadata aliases the caller's map")
+	}
+
+	// Share and load the file.
+	buf := new(bytes.Buffer)
+	if err := shareFiles([]*file{savedFile}, buf); err != nil {
+		t.Fatal(err)
+	}
+	names, err := r.loadSharedFiles(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := r.files[names[0]].metadata
+	if len(loaded) != 2 || loaded["mime"] != "text/plain" || loaded["tags"] != "foo,bar" {
+		t.Fatal("metadata was not loaded:", loaded)
+	}
+
+	// Files shared before metadata was introduced should load without
+	// metadata.
+	buf.Reset()
+	err = encoding.NewEncoder(buf).EncodeAll(shareHeader, shareVersionNoMetadata, uint64(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zip := gzip.NewWriter(buf)
+	if err := encoding.NewEncoder(zip).EncodeAll(savedFile, savedFile.hash, savedFile.chunkHashes); err != nil {
+		t.Fatal(err)
+	}
+	zip.Close()
+	names, err = r.loadSharedFiles(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := r.files[names[0]]; f.hash != savedFile.hash || f.metadata != nil {
+		t.Fatal("file without metadata was not loaded properly")
+	}
+}
+
 // TestFileShareLoad tests the sharing/loading functions of the renter.
 func TestFileShareLoad(t *testing.T) {
 	if testing.Short() {
@@ -229,6 +352,48 @@ func TestRenterSaveLoad(t *testing.T) {
 	}
 }
 
+// TestFileAccessStatsPersist checks that file access statistics are recorded
+// and persisted with the next save of the renter metadata.
+func TestFileAccessStatsPersist(t *testing.T) {
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	f := newTestingFile()
+	r := &Renter{
+		files:      map[string]*file{f.name: f},
+		tracking:   make(map[string]trackedFile),
+		persistDir: dir,
+		mu:         siasync.New(modules.SafeMutexDelay, 1),
+	}
+	r.managedRecordAccess(f, 100)
+	r.managedRecordAccess(f, 50)
+	if f.access.Downloads != 2 || f.access.BytesServed != 150 || f.access.LastAccess.IsZero() {
+		t.Fatal("access statistics were not recorded:", f.access)
+	}
+	if !r.persistDirty {
+		t.Fatal("recorded access statistics were not marked for saving")
+	}
+	id := r.mu.Lock()
+	r.saveDirty()
+	r.mu.Unlock(id)
+
+	// Load the metadata into a renter that knows about the same file.
+	loaded := &file{name: f.name}
+	r2 := &Renter{
+		files:      map[string]*file{f.name: loaded},
+		tracking:   make(map[string]trackedFile),
+		persistDir: dir,
+		mu:         siasync.New(modules.SafeMutexDelay, 1),
+	}
+	if err := r2.load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.access.Downloads != 2 || loaded.access.BytesServed != 150 || !loaded.access.LastAccess.Equal(f.access.LastAccess) {
+		t.Fatal("access statistics were not loaded:", loaded.access)
+	}
+}
+
 // TestRenterPaths checks that the renter properly handles nicknames
 // containing the path separator ("/").
 func TestRenterPaths(t *testing.T) {
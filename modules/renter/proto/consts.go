@@ -22,6 +22,17 @@ var (
 		Testing:  5 * time.Second,
 	}).(time.Duration)
 
+	// defaultSettingsTolerance is the default fraction by which a host's
+	// prices may increase (or its maximum duration decrease) between the time
+	// the host was selected and the time an upload or contract formation
+	// begins. If the host's settings have drifted further than this, the
+	// operation is aborted.
+	defaultSettingsTolerance = build.Select(build.Var{
+		Dev:      0.10,
+		Standard: 0.05,
+		Testing:  0.05,
+	}).(float64)
+
 	// hostPriceLeeway is the amount of flexibility we give to hosts when
 	// choosing how much to pay for file uploads. If the host does not have the
 	// most recent block yet, the host will be expecting a slightly larger
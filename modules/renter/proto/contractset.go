@@ -21,6 +21,11 @@ type ContractSet struct {
 	wal       *writeaheadlog.WAL
 	dir       string
 	mu        sync.Mutex
+
+	// settingsTolerance is the fraction by which a host's settings may drift
+	// from the settings it was selected with before uploads and contract
+	// formation are aborted.
+	settingsTolerance float64
 }
 
 // Acquire looks up the contract with the specified FileContractID and locks
@@ -73,6 +78,22 @@ func (cs *ContractSet) Len() int {
 	return len(cs.contracts)
 }
 
+// SetSettingsTolerance sets the fraction by which a host's prices may increase,
+// or its maximum duration decrease, between host selection and the start of
+// an upload or contract formation.
+func (cs *ContractSet) SetSettingsTolerance(tolerance float64) {
+	cs.mu.Lock()
+	cs.settingsTolerance = tolerance
+	cs.mu.Unlock()
+}
+
+// SettingsTolerance returns the tolerance set by SetSettingsTolerance.
+func (cs *ContractSet) SettingsTolerance() float64 {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.settingsTolerance
+}
+
 // Return returns a locked contract to the set and unlocks it. The contract
 // must have been previously acquired by Acquire. If the contract is not
 // present in the set, Return panics.
@@ -153,6 +174,8 @@ func NewContractSet(dir string) (*ContractSet, error) {
 		contracts: make(map[types.FileContractID]*SafeContract),
 		wal:       wal,
 		dir:       dir,
+
+		settingsTolerance: defaultSettingsTolerance,
 	}
 
 	// Load the contract files.
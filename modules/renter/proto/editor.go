@@ -33,7 +33,8 @@ type Editor struct {
 	host        modules.HostDBEntry
 	hdb         hostDB
 
-	height types.BlockHeight
+	height    types.BlockHeight
+	tolerance float64
 }
 
 // shutdown terminates the revision loop and signals the goroutine spawned in
@@ -116,7 +117,7 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 
 	// initiate revision
 	extendDeadline(he.conn, modules.NegotiateSettingsTime)
-	if err := startRevision(he.conn, he.host); err != nil {
+	if err := startRevision(he.conn, he.host, he.tolerance); err != nil {
 		return modules.RenterContract{}, crypto.Hash{}, err
 	}
 
@@ -200,6 +201,7 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 		host:        host,
 		hdb:         hdb,
 		height:      currentHeight,
+		tolerance:   cs.SettingsTolerance(),
 		contractID:  id,
 		contractSet: cs,
 		conn:        conn,
@@ -126,11 +126,18 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 		return modules.RenterContract{}, err
 	}
 
-	// Verify the host's settings and confirm its identity.
+	// Verify the host's settings and confirm its identity. The contract was
+	// constructed using the settings the host was selected with, so abort if
+	// the host has since raised its prices.
+	selectedSettings := host.HostExternalSettings
 	host, err = verifySettings(conn, host)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
+	if err = checkSettingsDrift(host.PublicKey, selectedSettings, host.HostExternalSettings, cs.SettingsTolerance()); err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error ignored so that the drift error is returned.
+		return modules.RenterContract{}, err
+	}
 	if !host.AcceptingContracts {
 		return modules.RenterContract{}, errors.New("host is not accepting contracts")
 	}
@@ -2,6 +2,7 @@ package proto
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"time"
 
@@ -16,13 +17,19 @@ import (
 func extendDeadline(conn net.Conn, d time.Duration) { _ = conn.SetDeadline(time.Now().Add(d)) }
 
 // startRevision is run at the beginning of each revision iteration. It reads
-// the host's settings confirms that the values are acceptable, and writes an acceptance.
-func startRevision(conn net.Conn, host modules.HostDBEntry) error {
+// the host's settings confirms that the values are acceptable, and writes an
+// acceptance. If the host's prices have drifted beyond 'tolerance' since the
+// host was selected, a rejection is written instead.
+func startRevision(conn net.Conn, host modules.HostDBEntry, tolerance float64) error {
 	// verify the host's settings and confirm its identity
-	_, err := verifySettings(conn, host)
+	recvHost, err := verifySettings(conn, host)
 	if err != nil {
 		return err
 	}
+	if err := checkSettingsDrift(host.PublicKey, host.HostExternalSettings, recvHost.HostExternalSettings, tolerance); err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error ignored so that the drift error is returned.
+		return err
+	}
 	return modules.WriteNegotiationAcceptance(conn)
 }
 
@@ -37,6 +44,32 @@ func startDownload(conn net.Conn, host modules.HostDBEntry) error {
 	return modules.WriteNegotiationAcceptance(conn)
 }
 
+// checkSettingsDrift compares the settings a host was selected with against
+// the settings it is currently advertising. An error naming the host and the
+// changed field is returned if any price has increased by more than
+// 'tolerance', or if the maximum duration has decreased by more than
+// 'tolerance'.
+func checkSettingsDrift(hostKey types.SiaPublicKey, expected, recv modules.HostExternalSettings, tolerance float64) error {
+	prices := []struct {
+		field    string
+		old, new types.Currency
+	}{
+		{"contract price", expected.ContractPrice, recv.ContractPrice},
+		{"storage price", expected.StoragePrice, recv.StoragePrice},
+		{"upload bandwidth price", expected.UploadBandwidthPrice, recv.UploadBandwidthPrice},
+		{"download bandwidth price", expected.DownloadBandwidthPrice, recv.DownloadBandwidthPrice},
+	}
+	for _, p := range prices {
+		if p.new.Cmp(p.old.MulFloat(1+tolerance)) > 0 {
+			return fmt.Errorf("host %v raised its %v from %v to %v, which exceeds the tolerance of %v%%", hostKey, p.field, p.old, p.new, tolerance*100)
+		}
+	}
+	if float64(recv.MaxDuration) < float64(expected.MaxDuration)*(1-tolerance) {
+		return fmt.Errorf("host %v reduced its max duration from %v to %v, which exceeds the tolerance of %v%%", hostKey, expected.MaxDuration, recv.MaxDuration, tolerance*100)
+	}
+	return nil
+}
+
 // verifySettings reads a signed HostSettings object from conn, validates the
 // signature, and checks for discrepancies between the known settings and the
 // received settings. If there is a discrepancy, the hostDB is notified. The
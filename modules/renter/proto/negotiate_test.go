@@ -62,3 +62,44 @@ func TestNegotiateRevisionStopResponse(t *testing.T) {
 	}
 	rConn.Close()
 }
+
+// TestCheckSettingsDrift tests that checkSettingsDrift rejects hosts which
+// have raised their prices or reduced their max duration beyond the tolerance.
+func TestCheckSettingsDrift(t *testing.T) {
+	expected := modules.HostExternalSettings{
+		ContractPrice:          types.NewCurrency64(1000),
+		StoragePrice:           types.NewCurrency64(1000),
+		UploadBandwidthPrice:   types.NewCurrency64(1000),
+		DownloadBandwidthPrice: types.NewCurrency64(1000),
+		MaxDuration:            1000,
+	}
+	var hostKey types.SiaPublicKey
+
+	// Identical settings and small changes are acceptable.
+	if err := checkSettingsDrift(hostKey, expected, expected, 0.05); err != nil {
+		t.Fatal(err)
+	}
+	recv := expected
+	recv.StoragePrice = types.NewCurrency64(1040)
+	recv.MaxDuration = 960
+	if err := checkSettingsDrift(hostKey, expected, recv, 0.05); err != nil {
+		t.Fatal(err)
+	}
+	// Price decreases are always acceptable.
+	recv.ContractPrice = types.ZeroCurrency
+	if err := checkSettingsDrift(hostKey, expected, recv, 0.05); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes beyond the tolerance are rejected.
+	recv = expected
+	recv.UploadBandwidthPrice = types.NewCurrency64(1100)
+	if err := checkSettingsDrift(hostKey, expected, recv, 0.05); err == nil {
+		t.Error("expected price increase to be rejected")
+	}
+	recv = expected
+	recv.MaxDuration = 900
+	if err := checkSettingsDrift(hostKey, expected, recv, 0.05); err == nil {
+		t.Error("expected duration decrease to be rejected")
+	}
+}
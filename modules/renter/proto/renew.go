@@ -133,11 +133,18 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 		// callers can check using IsRevisionMismatch
 		return modules.RenterContract{}, err
 	}
-	// verify the host's settings and confirm its identity
+	// verify the host's settings and confirm its identity. The renewal was
+	// constructed using the settings the host was selected with, so abort if
+	// the host has since raised its prices.
+	selectedSettings := host.HostExternalSettings
 	host, err = verifySettings(conn, host)
 	if err != nil {
 		return modules.RenterContract{}, errors.New("settings exchange failed: " + err.Error())
 	}
+	if err = checkSettingsDrift(host.PublicKey, selectedSettings, host.HostExternalSettings, cs.SettingsTolerance()); err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error ignored so that the drift error is returned.
+		return modules.RenterContract{}, err
+	}
 	if !host.AcceptingContracts {
 		return modules.RenterContract{}, errors.New("host is not accepting contracts")
 	}
@@ -0,0 +1,193 @@
+package renter
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// ErrBadManifest is returned when recovering from data that is not a
+	// recovery manifest.
+	ErrBadManifest = errors.New("not a recovery manifest")
+
+	manifestHeader  = [12]byte{'S', 'i', 'a', ' ', 'M', 'a', 'n', 'i', 'f', 'e', 's', 't'}
+	manifestVersion = "1.0"
+)
+
+// A manifestHost records the public key of the host of one of a file's
+// contracts, so that the file's pieces can be found again after the contract
+// has been renewed under an id that the manifest does not know.
+type manifestHost struct {
+	Contract  types.FileContractID
+	PublicKey types.SiaPublicKey
+}
+
+// writeManifest writes the recovery manifest of f to w. The manifest holds
+// the same data as a .sia file, including the master key, erasure code
+// parameters, and the pieces held by each contract, followed by the hash of
+// the file, the hashes of its chunks, its metadata, and the host of each of
+// its contracts.
+func writeManifest(f *file, hosts []manifestHost, w io.Writer) error {
+	err := encoding.NewEncoder(w).EncodeAll(manifestHeader, manifestVersion)
+	if err != nil {
+		return err
+	}
+	zip, _ := gzip.NewWriterLevel(w, gzip.BestSpeed)
+	err = encoding.NewEncoder(zip).EncodeAll(f, f.hash, f.chunkHashes, metadataEntries(f.metadata), hosts)
+	if err != nil {
+		return err
+	}
+	return zip.Close()
+}
+
+// readManifest reads a recovery manifest written by writeManifest.
+func readManifest(r io.Reader) (*file, []manifestHost, error) {
+	var header [12]byte
+	var version string
+	err := encoding.NewDecoder(r).DecodeAll(&header, &version)
+	if err != nil {
+		return nil, nil, err
+	} else if header != manifestHeader {
+		return nil, nil, ErrBadManifest
+	} else if version != manifestVersion {
+		return nil, nil, ErrIncompatible
+	}
+	unzip, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	f := new(file)
+	var entries []fileMetadataEntry
+	var hosts []manifestHost
+	err = encoding.NewDecoder(unzip).DecodeAll(f, &f.hash, &f.chunkHashes, &entries, &hosts)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range entries {
+		if f.metadata == nil {
+			f.metadata = make(map[string]string)
+		}
+		f.metadata[e.Key] = e.Value
+	}
+	return f, hosts, nil
+}
+
+// ExportManifest writes a recovery manifest of a file to w. The manifest
+// contains everything needed to download the file without the renter's file
+// database, and must be kept private, since it includes the key that the
+// file is encrypted with.
+func (r *Renter) ExportManifest(nickname string, w io.Writer) error {
+	lockID := r.mu.RLock()
+	f, exists := r.files[nickname]
+	r.mu.RUnlock(lockID)
+	if !exists {
+		return ErrUnknownPath
+	}
+
+	f.mu.RLock()
+	ids := make([]types.FileContractID, 0, len(f.contracts))
+	for id := range f.contracts {
+		ids = append(ids, id)
+	}
+	f.mu.RUnlock()
+	var hosts []manifestHost
+	for _, id := range ids {
+		if c, ok := r.hostContractor.ContractByID(r.hostContractor.ResolveID(id)); ok {
+			hosts = append(hosts, manifestHost{Contract: id, PublicKey: c.HostPublicKey})
+		}
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return writeManifest(f, hosts, w)
+}
+
+// RecoverFromManifest downloads the file described by a recovery manifest to
+// destination. The file is not added to the renter. Pieces stored with
+// contracts that the renter no longer knows are downloaded using the
+// renter's current contract with the same host, if there is one.
+func (r *Renter) RecoverFromManifest(manifest io.Reader, destination string) error {
+	if !filepath.IsAbs(destination) {
+		return errors.New("destination must be an absolute path")
+	}
+	f, hosts, err := readManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	// Move the pieces of unknown contracts to the current contract with
+	// their host.
+	current := make(map[string]types.FileContractID)
+	for _, c := range r.hostContractor.Contracts() {
+		current[c.HostPublicKey.String()] = c.ID
+	}
+	for _, h := range hosts {
+		fc, exists := f.contracts[h.Contract]
+		if !exists {
+			continue
+		}
+		if _, known := r.hostContractor.ContractByID(r.hostContractor.ResolveID(h.Contract)); known {
+			continue
+		}
+		id, ok := current[h.PublicKey.String()]
+		if !ok {
+			continue
+		}
+		delete(f.contracts, h.Contract)
+		if existing, ok := f.contracts[id]; ok {
+			fc.Pieces = append(existing.Pieces, fc.Pieces...)
+		}
+		fc.ID = id
+		f.contracts[id] = fc
+	}
+
+	// An empty file has no pieces to download.
+	if f.size == 0 {
+		handle, err := os.Create(destination)
+		if err != nil {
+			return err
+		}
+		return handle.Close()
+	}
+
+	bufferSize := uint64(r.managedLimits().DownloadBufferChunks) * f.chunkSize()
+	dfw, err := newTempDownloadFileWriter(destination, "", 0, f.size, bufferSize)
+	if err != nil {
+		return err
+	}
+	tempName := dfw.f.Name()
+
+	d := r.newSectionDownload(f, dfw, 0, f.size)
+	d.hostPreference = r.HostPreference()
+	lockID := r.mu.Lock()
+	r.downloadQueue = append(r.downloadQueue, d)
+	r.mu.Unlock(lockID)
+	select {
+	case r.newDownloads <- d:
+	case <-r.tg.StopChan():
+		os.Remove(tempName)
+		return errors.New("recovery interrupted by shutdown")
+	}
+
+	select {
+	case <-d.downloadFinished:
+		if err := d.Err(); err != nil {
+			os.Remove(tempName)
+			return err
+		}
+		if err := commitDownload(f, tempName, destination, 0, f.size); err != nil {
+			os.Remove(tempName)
+			return err
+		}
+		return nil
+	case <-r.tg.StopChan():
+		os.Remove(tempName)
+		return errors.New("recovery interrupted by shutdown")
+	}
+}
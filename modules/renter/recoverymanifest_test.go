@@ -0,0 +1,210 @@
+package renter
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/renter/contractor"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/fastrand"
+)
+
+// recoveryContractor is a hostContractor that serves sectors from memory. It
+// only knows the contracts it was created with, and cannot resolve the
+// renewals of other contracts. Methods not used by recovery are left to the
+// embedded interface and panic if called.
+type recoveryContractor struct {
+	hostContractor
+	contracts []modules.RenterContract
+	sectors   map[crypto.Hash][]byte
+
+	mu          sync.Mutex
+	downloaders map[types.FileContractID]int
+}
+
+func (rc *recoveryContractor) Close() error { return nil }
+
+func (rc *recoveryContractor) Contracts() []modules.RenterContract { return rc.contracts }
+
+func (rc *recoveryContractor) ContractByID(id types.FileContractID) (modules.RenterContract, bool) {
+	for _, c := range rc.contracts {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return modules.RenterContract{}, false
+}
+
+func (rc *recoveryContractor) IsOffline(types.FileContractID) bool { return false }
+
+func (rc *recoveryContractor) ResolveID(id types.FileContractID) types.FileContractID { return id }
+
+func (rc *recoveryContractor) Downloader(id types.FileContractID, _ <-chan struct{}) (contractor.Downloader, error) {
+	if _, ok := rc.ContractByID(id); !ok {
+		return nil, errors.New("no record of that contract")
+	}
+	rc.mu.Lock()
+	rc.downloaders[id]++
+	rc.mu.Unlock()
+	return sectorDownloader(rc.sectors), nil
+}
+
+// sectorDownloader is a contractor.Downloader that serves sectors from
+// memory.
+type sectorDownloader map[crypto.Hash][]byte
+
+func (sd sectorDownloader) Sector(root crypto.Hash) ([]byte, error) {
+	sector, ok := sd[root]
+	if !ok {
+		return nil, errors.New("no record of that sector")
+	}
+	return append([]byte(nil), sector...), nil
+}
+
+func (sd sectorDownloader) Close() error { return nil }
+
+// TestRecoveryManifest checks that a file and the hosts of its contracts
+// survive a round trip through a recovery manifest, and that other data is
+// rejected.
+func TestRecoveryManifest(t *testing.T) {
+	f := newTestingFile()
+	f.chunkHashes = []crypto.Hash{{1}, {2}}
+	f.metadata = map[string]string{"owner": "alice"}
+	hosts := []manifestHost{{
+		Contract:  types.FileContractID{1},
+		PublicKey: types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1, 2, 3}},
+	}}
+
+	buf := new(bytes.Buffer)
+	if err := writeManifest(f, hosts, buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, loadedHosts, err := readManifest(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := equalFiles(f, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.hash != f.hash || len(loaded.chunkHashes) != 2 || loaded.chunkHashes[1] != f.chunkHashes[1] {
+		t.Fatal("file hashes were not recovered")
+	}
+	if loaded.metadata["owner"] != "alice" {
+		t.Fatal("metadata was not recovered:", loaded.metadata)
+	}
+	if len(loadedHosts) != 1 || loadedHosts[0].Contract != hosts[0].Contract || loadedHosts[0].PublicKey.String() != hosts[0].PublicKey.String() {
+		t.Fatal("hosts were not recovered:", loadedHosts)
+	}
+
+	// A shared file is not a recovery manifest.
+	buf.Reset()
+	if err := shareFiles([]*file{f}, buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readManifest(buf); err != ErrBadManifest {
+		t.Fatal("expected ErrBadManifest, got", err)
+	}
+
+	// Manifests of another version are rejected.
+	buf.Reset()
+	if err := encoding.NewEncoder(buf).EncodeAll(manifestHeader, "0.9"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := readManifest(buf); err != ErrIncompatible {
+		t.Fatal("expected ErrIncompatible, got", err)
+	}
+}
+
+// TestRecoverFromManifest checks that a file can be downloaded from its
+// recovery manifest, including pieces held by a contract that has since been
+// renewed under an id the renter does not know.
+func TestRecoverFromManifest(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Split a file into three chunks, each of which needs both of the pieces
+	// that are stored with a host.
+	rsc, _ := NewRSCode(2, 1)
+	data := fastrand.Bytes(300)
+	f := newFile("foo", rsc, 64, uint64(len(data)))
+	var err error
+	f.hash, f.chunkHashes, err = hashChunks(bytes.NewReader(data), f.chunkSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostA := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	hostB := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	oldID, renewedID := types.FileContractID{2}, types.FileContractID{3}
+	hc := &recoveryContractor{
+		contracts: []modules.RenterContract{
+			{ID: types.FileContractID{1}, HostPublicKey: hostA},
+			{ID: renewedID, HostPublicKey: hostB},
+		},
+		sectors:     make(map[crypto.Hash][]byte),
+		downloaders: make(map[types.FileContractID]int),
+	}
+	for chunkIndex := uint64(0); chunkIndex < f.numChunks(); chunkIndex++ {
+		chunk := make([]byte, f.chunkSize())
+		copy(chunk, data[chunkIndex*f.chunkSize():])
+		pieces, err := rsc.Encode(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for pieceIndex, id := range []types.FileContractID{{1}, oldID} {
+			key := deriveKey(f.masterKey, chunkIndex, uint64(pieceIndex))
+			sector := key.EncryptBytes(pieces[pieceIndex])
+			root := crypto.MerkleRoot(sector)
+			hc.sectors[root] = sector
+			fc := f.contracts[id]
+			fc.ID = id
+			fc.Pieces = append(fc.Pieces, pieceData{Chunk: chunkIndex, Piece: uint64(pieceIndex), MerkleRoot: root})
+			f.contracts[id] = fc
+		}
+	}
+	buf := new(bytes.Buffer)
+	hosts := []manifestHost{{Contract: types.FileContractID{1}, PublicKey: hostA}, {Contract: oldID, PublicKey: hostB}}
+	if err := writeManifest(f, hosts, buf); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := newContractorTester(t.Name(), stubHostDB{}, hc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Close()
+	dst := filepath.Join(build.TempDir("renter", t.Name()), "recovered")
+	if err := rt.renter.RecoverFromManifest(buf, dst); err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(recovered, data) {
+		t.Fatal("recovered file does not match the original")
+	}
+
+	// The pieces of the old contract should have been downloaded through its
+	// renewal.
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+	if hc.downloaders[renewedID] == 0 {
+		t.Fatal("pieces of the renewed contract were not downloaded")
+	}
+	id := rt.renter.mu.RLock()
+	_, exists := rt.renter.files[f.name]
+	rt.renter.mu.RUnlock(id)
+	if exists {
+		t.Fatal("recovered file should not be added to the renter")
+	}
+}
@@ -142,6 +142,13 @@ type hostContractor interface {
 
 	// ResolveID returns the most recent renewal of the specified ID.
 	ResolveID(types.FileContractID) types.FileContractID
+
+	// SettingsTolerance returns the fraction by which a host's prices may
+	// increase between host selection and an upload or contract formation.
+	SettingsTolerance() float64
+
+	// SetSettingsTolerance sets the tolerance returned by SettingsTolerance.
+	SetSettingsTolerance(float64) error
 }
 
 // A trackedFile contains metadata about files being tracked by the Renter.
@@ -156,12 +163,31 @@ type trackedFile struct {
 // A Renter is responsible for tracking all of the files that a user has
 // uploaded to Sia, as well as the locations and health of these files.
 type Renter struct {
+	// Utilization of the resource limits, reported through Limits. The
+	// atomic fields come first to keep them 64-bit aligned.
+	atomicActiveDownloadPieces int64
+	atomicActiveUploadPieces   int64
+	atomicOpenHostConnections  int64
+
 	// File management.
 	//
 	// tracking contains a list of files that the user intends to maintain. By
 	// default, files loaded through sharing are not maintained by the user.
-	files    map[string]*file
-	tracking map[string]trackedFile // map from nickname to metadata
+	//
+	// aliases maps the nickname of each alias to the nickname of the file
+	// whose contents it shares. An alias is an entry in files that points to
+	// the same *file as the original, so the original's pieces are the only
+	// record of the data.
+	//
+	// persistDirty is set when file metadata that is saved with the tracked
+	// files, such as the confirmed chunks or the access statistics, has
+	// changed without being saved.
+	// Such changes are saved in batches by saveDirty rather than one at a
+	// time.
+	files        map[string]*file
+	tracking     map[string]trackedFile // map from nickname to metadata
+	aliases      map[string]string
+	persistDirty bool
 
 	// Work management.
 	//
@@ -177,6 +203,40 @@ type Renter struct {
 	newUploads    chan *file
 	workerPool    map[types.FileContractID]*worker
 
+	// Repair queue. repairQueue holds the chunks that the health scan has
+	// queued for repair, keyed by repairKey, and repairStats holds the
+	// progress counters reported by RepairQueue; both are protected by the
+	// renter's mutex. repairRequests carries newly queued chunks to the
+	// repair loop, and repairScanLock prevents health scans from
+	// overlapping.
+	repairQueue      map[string]*repairEntry
+	repairRequests   chan repairRequest
+	repairScanCursor int
+	repairScanLock   siasync.TryMutex
+	repairStats      modules.RepairQueue
+
+	// Resource limits. limits holds the current modules.RenterLimits and is
+	// protected by the renter's mutex. uploadSlots limits the number of
+	// workers that are uploading a piece to their host at the same time, and
+	// hostConnections limits the number of connections that the workers hold
+	// open to hosts.
+	limits          modules.RenterLimits
+	uploadSlots     *siasync.Limiter
+	hostConnections *siasync.Limiter
+
+	// hostPreference is the cost-versus-speed preference used to choose
+	// workers for downloads that do not specify their own. It is protected
+	// by the renter's mutex.
+	hostPreference modules.HostPreference
+
+	// bandwidth divides the renter's download and upload bandwidth between
+	// the files that are being transferred.
+	bandwidth *bandwidthScheduler
+
+	// hostBreaker tracks the hosts that have failed downloads, so that
+	// downloads can route around hosts that are persistently failing.
+	hostBreaker *hostBreaker
+
 	// Memory management - baseMemory tracks how much memory the renter is
 	// allowed to consume, memoryAvailable tracks how much more memory the
 	// renter can allocate before hitting the cap, and newMemory is a channel
@@ -236,11 +296,33 @@ func newRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.Transac
 	r := &Renter{
 		files:    make(map[string]*file),
 		tracking: make(map[string]trackedFile),
+		aliases:  make(map[string]string),
 
 		newDownloads: make(chan *download),
 		newUploads:   make(chan *file),
 		workerPool:   make(map[types.FileContractID]*worker),
 
+		repairQueue:    make(map[string]*repairEntry),
+		repairRequests: make(chan repairRequest),
+
+		limits: modules.RenterLimits{
+			MaxConcurrentDownloads: defaultMaxActiveDownloadPieces,
+			MaxConcurrentUploads:   defaultMaxConcurrentPieceUploads,
+			MaxHostConnections:     defaultMaxHostConnections,
+			MaxDownloadWorkers:     defaultMaxDownloadWorkers,
+			MaxUploadWorkers:       defaultMaxUploadWorkers,
+			DownloadBufferChunks:   defaultDownloadBufferChunks,
+			RepairScanFiles:        defaultRepairScanFiles,
+			RepairThreshold:        defaultRepairThreshold,
+			HostFailureThreshold:   defaultHostFailureThreshold,
+			HostFailureCooldown:    defaultHostFailureCooldown,
+		},
+		uploadSlots:     siasync.NewLimiter(defaultMaxConcurrentPieceUploads),
+		hostConnections: siasync.NewLimiter(defaultMaxHostConnections),
+		hostPreference:  modules.HostPreferBalanced,
+		bandwidth:       newBandwidthScheduler(),
+		hostBreaker:     newHostBreaker(),
+
 		baseMemory:      defaultMemory,
 		memoryAvailable: defaultMemory,
 		newMemory:       make(chan struct{}, 1),
@@ -278,6 +360,14 @@ func newRenter(g modules.Gateway, cs modules.ConsensusSet, tpool modules.Transac
 		return nil
 	})
 
+	// Save any unsaved file metadata on shutdown.
+	r.tg.OnStop(func() error {
+		id := r.mu.Lock()
+		defer r.mu.Unlock(id)
+		r.saveDirty()
+		return nil
+	})
+
 	return r, nil
 }
 
@@ -410,6 +500,18 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	return nil
 }
 
+// SettingsTolerance returns the fraction by which a host's prices may
+// increase, or its maximum duration decrease, between the time the host was
+// selected and the time an upload or contract formation begins.
+func (r *Renter) SettingsTolerance() float64 {
+	return r.hostContractor.SettingsTolerance()
+}
+
+// SetSettingsTolerance sets the tolerance returned by SettingsTolerance.
+func (r *Renter) SetSettingsTolerance(tolerance float64) error {
+	return r.hostContractor.SetSettingsTolerance(tolerance)
+}
+
 // hostdb passthroughs
 func (r *Renter) ActiveHosts() []modules.HostDBEntry                      { return r.hostDB.ActiveHosts() }
 func (r *Renter) AllHosts() []modules.HostDBEntry                         { return r.hostDB.AllHosts() }
@@ -434,6 +536,10 @@ func (r *Renter) ProcessConsensusChange(cc modules.ConsensusChange) {
 	id := r.mu.Lock()
 	r.lastEstimation = modules.RenterPriceEstimation{}
 	r.mu.Unlock(id)
+
+	if cc.Synced {
+		go r.threadedScanFileHealth()
+	}
 }
 
 // Enforce that Renter satisfies the modules.Renter interface.
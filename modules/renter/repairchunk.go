@@ -24,6 +24,11 @@ func (r *Renter) managedDistributeChunkToWorkers(uc *unfinishedChunk) {
 		workers = append(workers, worker)
 	}
 	r.mu.RUnlock(id)
+	if len(workers) == 0 {
+		uc.mu.Lock()
+		uc.finished = true
+		uc.mu.Unlock()
+	}
 	for _, worker := range workers {
 		worker.managedQueueChunkRepair(uc)
 	}
@@ -156,7 +161,7 @@ func (r *Renter) managedFetchLogicalChunkData(chunk *unfinishedChunk, download b
 	// needing to ignore the EOF errors, because the chunk size should always
 	// match the tail end of the file. Until then, we ignore io.EOF.
 	chunk.logicalChunkData = make([]byte, chunk.length)
-	_, err = osFile.ReadAt(chunk.logicalChunkData, chunk.offset)
+	n, err := osFile.ReadAt(chunk.logicalChunkData, chunk.offset)
 	if err != nil && err != io.EOF && download {
 		chunk.logicalChunkData = nil
 		return r.managedDownloadLogicalChunkData(chunk)
@@ -165,6 +170,18 @@ func (r *Renter) managedFetchLogicalChunkData(chunk *unfinishedChunk, download b
 		return errors.Extend(err, errors.New("failed to read file locally"))
 	}
 
+	// A resumed upload must not mix data from a modified local file with the
+	// pieces that were already uploaded, so the data is checked against the
+	// hash recorded when the upload started.
+	err = chunk.renterFile.verifyChunkData(chunk.index, chunk.logicalChunkData[:n])
+	if err != nil && download {
+		chunk.logicalChunkData = nil
+		return r.managedDownloadLogicalChunkData(chunk)
+	} else if err != nil {
+		chunk.logicalChunkData = nil
+		return err
+	}
+
 	// Data successfully read from disk.
 	return nil
 }
@@ -0,0 +1,254 @@
+package renter
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// repairEntry is a chunk in the repair queue. chunk is nil until the repair
+// loop has picked up the entry and built the unfinished chunk.
+type repairEntry struct {
+	siaPath      string
+	index        uint64
+	onlinePieces int
+	numPieces    int
+	queuedHeight types.BlockHeight
+	chunk        *unfinishedChunk
+}
+
+// repairRequest asks the repair loop to repair chunks of a file.
+type repairRequest struct {
+	file    *file
+	siaPath string
+	chunks  []uint64
+}
+
+// repairKey returns the key of a chunk in the repair queue.
+func repairKey(siaPath string, index uint64) string {
+	return fmt.Sprintf("%v/%v", siaPath, index)
+}
+
+// pruneRepairQueue removes the chunks that the repair loop has finished with
+// from the repair queue, counting whether they were fully repaired. The
+// caller must hold the renter's lock.
+func (r *Renter) pruneRepairQueue() {
+	for key, entry := range r.repairQueue {
+		if entry.chunk == nil {
+			continue
+		}
+		entry.chunk.mu.Lock()
+		finished := entry.chunk.finished
+		repaired := entry.chunk.piecesCompleted >= entry.chunk.piecesNeeded
+		entry.chunk.mu.Unlock()
+		if !finished {
+			continue
+		}
+		if repaired {
+			r.repairStats.ChunksRepaired++
+		} else {
+			r.repairStats.ChunksFailed++
+		}
+		delete(r.repairQueue, key)
+	}
+}
+
+// managedRepairPausedReason returns why the health scan should not queue
+// more repairs, or the empty string if it may. Repairs spend the allowance
+// and upload bandwidth, so the scan holds back while either is used up.
+func (r *Renter) managedRepairPausedReason(limits modules.RenterLimits) string {
+	if !r.g.Online() {
+		return "renter is offline"
+	}
+	if r.hostContractor.Allowance().Funds.IsZero() {
+		return "no allowance is set"
+	}
+	if r.hostContractor.PeriodSpending().Unspent.IsZero() {
+		return "allowance is exhausted"
+	}
+	if int(atomic.LoadInt64(&r.atomicActiveUploadPieces)) >= limits.MaxConcurrentUploads {
+		return "upload limit reached"
+	}
+	return ""
+}
+
+// threadedScanFileHealth checks the health of the next RepairScanFiles files
+// and queues the chunks that have fewer than RepairThreshold percent of their
+// pieces on online hosts for repair. It is called on every new block, so
+// that every file is checked regularly without checking all of them at once.
+func (r *Renter) threadedScanFileHealth() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	if !r.repairScanLock.TryLock() {
+		// The previous scan is still waiting for the repair loop.
+		return
+	}
+	defer r.repairScanLock.Unlock()
+
+	height := r.cs.Height()
+	limits := r.managedLimits()
+	paused := r.managedRepairPausedReason(limits)
+
+	// Pick the files to scan, taking the tracked files in turn by name.
+	id := r.mu.Lock()
+	r.pruneRepairQueue()
+	r.repairStats.LastScanHeight = height
+	r.repairStats.PausedReason = paused
+	var names []string
+	for name := range r.tracking {
+		if _, exists := r.files[name]; exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var files []*file
+	for i := 0; i < limits.RepairScanFiles && i < len(names); i++ {
+		files = append(files, r.files[names[(r.repairScanCursor+i)%len(names)]])
+	}
+	if len(names) > 0 {
+		r.repairScanCursor = (r.repairScanCursor + len(files)) % len(names)
+	}
+	r.mu.Unlock(id)
+	if paused != "" {
+		return
+	}
+
+	isOffline := func(id types.FileContractID) bool {
+		return r.hostContractor.IsOffline(r.hostContractor.ResolveID(id))
+	}
+	for _, f := range files {
+		f.mu.RLock()
+		fh := f.health(isOffline)
+		f.mu.RUnlock()
+
+		// Queue the unhealthy chunks that are not queued already.
+		var chunks []uint64
+		id := r.mu.Lock()
+		r.repairStats.FilesScanned++
+		for i, chunk := range fh.Chunks {
+			if chunk.OnlinePieces*100 >= limits.RepairThreshold*fh.NumPieces {
+				continue
+			}
+			key := repairKey(fh.SiaPath, uint64(i))
+			if _, queued := r.repairQueue[key]; queued {
+				continue
+			}
+			if len(r.repairQueue) >= maxRepairQueueChunks {
+				r.repairStats.PausedReason = "repair queue is full"
+				break
+			}
+			r.repairQueue[key] = &repairEntry{
+				siaPath:      fh.SiaPath,
+				index:        uint64(i),
+				onlinePieces: chunk.OnlinePieces,
+				numPieces:    fh.NumPieces,
+				queuedHeight: height,
+			}
+			chunks = append(chunks, uint64(i))
+		}
+		r.mu.Unlock(id)
+		if len(chunks) == 0 {
+			continue
+		}
+
+		select {
+		case r.repairRequests <- repairRequest{file: f, siaPath: fh.SiaPath, chunks: chunks}:
+		case <-r.tg.StopChan():
+			return
+		}
+	}
+}
+
+// managedInsertRepairChunks adds the chunks of a repair request to the chunk
+// heap. Chunks that the repair loop does not consider incomplete, for
+// example because the file was deleted or the contracts holding its pieces
+// are still good for upload, are dropped from the repair queue.
+func (r *Renter) managedInsertRepairChunks(req repairRequest, ch *chunkHeap, hosts map[string]string) {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+
+	wanted := make(map[uint64]struct{})
+	for _, index := range req.chunks {
+		wanted[index] = struct{}{}
+	}
+	for _, uc := range r.buildUnfinishedChunks(req.file, hosts) {
+		if _, ok := wanted[uc.index]; !ok {
+			continue
+		}
+		entry, queued := r.repairQueue[repairKey(req.siaPath, uc.index)]
+		if !queued || entry.chunk != nil {
+			continue
+		}
+		entry.chunk = uc
+		heap.Push(ch, uc)
+		delete(wanted, uc.index)
+	}
+	for index := range wanted {
+		delete(r.repairQueue, repairKey(req.siaPath, index))
+	}
+	r.saveDirty()
+}
+
+// managedDropRepairChunks removes the chunks of a discarded chunk heap from
+// the repair queue. The repair loop never worked on them, so they would
+// otherwise be reported as repairing forever and count towards
+// maxRepairQueueChunks. The health scan queues them again if they still need
+// to be repaired.
+func (r *Renter) managedDropRepairChunks(ch *chunkHeap) {
+	discarded := make(map[*unfinishedChunk]struct{}, ch.Len())
+	for _, uc := range *ch {
+		discarded[uc] = struct{}{}
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	for key, entry := range r.repairQueue {
+		if _, ok := discarded[entry.chunk]; ok {
+			delete(r.repairQueue, key)
+		}
+	}
+}
+
+// RepairQueue returns the chunks that the health scan has queued for repair,
+// ordered by the height at which they were queued.
+func (r *Renter) RepairQueue() modules.RepairQueue {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.pruneRepairQueue()
+
+	rq := r.repairStats
+	rq.Chunks = make([]modules.RepairChunk, 0, len(r.repairQueue))
+	for _, entry := range r.repairQueue {
+		rc := modules.RepairChunk{
+			SiaPath:         entry.siaPath,
+			Chunk:           entry.index,
+			Status:          "queued",
+			PiecesCompleted: entry.onlinePieces,
+			PiecesNeeded:    entry.numPieces,
+			QueuedHeight:    entry.queuedHeight,
+		}
+		if entry.chunk != nil {
+			entry.chunk.mu.Lock()
+			rc.Status = "repairing"
+			rc.PiecesCompleted = entry.chunk.piecesCompleted
+			rc.PiecesNeeded = entry.chunk.piecesNeeded
+			entry.chunk.mu.Unlock()
+		}
+		rq.Chunks = append(rq.Chunks, rc)
+	}
+	sort.Slice(rq.Chunks, func(i, j int) bool {
+		a, b := rq.Chunks[i], rq.Chunks[j]
+		if a.QueuedHeight != b.QueuedHeight {
+			return a.QueuedHeight < b.QueuedHeight
+		} else if a.SiaPath != b.SiaPath {
+			return a.SiaPath < b.SiaPath
+		}
+		return a.Chunk < b.Chunk
+	})
+	return rq
+}
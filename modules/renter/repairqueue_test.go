@@ -0,0 +1,74 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	siasync "github.com/NebulousLabs/Sia/sync"
+)
+
+// TestRepairQueue checks that the repair queue reports the progress of its
+// chunks in the order they were queued, and that finished chunks are removed
+// and counted.
+func TestRepairQueue(t *testing.T) {
+	r := &Renter{
+		repairQueue: make(map[string]*repairEntry),
+		mu:          siasync.New(modules.SafeMutexDelay, 1),
+	}
+	repairing := &unfinishedChunk{piecesCompleted: 2, piecesNeeded: 4}
+	repaired := &unfinishedChunk{piecesCompleted: 4, piecesNeeded: 4, finished: true}
+	failed := &unfinishedChunk{piecesCompleted: 3, piecesNeeded: 4, finished: true}
+	r.repairQueue[repairKey("foo", 1)] = &repairEntry{siaPath: "foo", index: 1, onlinePieces: 1, numPieces: 4, queuedHeight: 10}
+	r.repairQueue[repairKey("bar", 0)] = &repairEntry{siaPath: "bar", index: 0, queuedHeight: 5, chunk: repairing}
+	r.repairQueue[repairKey("bar", 1)] = &repairEntry{siaPath: "bar", index: 1, queuedHeight: 5, chunk: repaired}
+	r.repairQueue[repairKey("foo", 0)] = &repairEntry{siaPath: "foo", index: 0, queuedHeight: 10, chunk: failed}
+
+	rq := r.RepairQueue()
+	if rq.ChunksRepaired != 1 || rq.ChunksFailed != 1 {
+		t.Fatalf("expected 1 repaired and 1 failed chunk, got %v and %v", rq.ChunksRepaired, rq.ChunksFailed)
+	}
+	expected := []modules.RepairChunk{
+		{SiaPath: "bar", Chunk: 0, Status: "repairing", PiecesCompleted: 2, PiecesNeeded: 4, QueuedHeight: 5},
+		{SiaPath: "foo", Chunk: 1, Status: "queued", PiecesCompleted: 1, PiecesNeeded: 4, QueuedHeight: 10},
+	}
+	if len(rq.Chunks) != len(expected) {
+		t.Fatalf("expected %v queued chunks, got %+v", len(expected), rq.Chunks)
+	}
+	for i := range expected {
+		if rq.Chunks[i] != expected[i] {
+			t.Fatalf("chunk %v: expected %+v, got %+v", i, expected[i], rq.Chunks[i])
+		}
+	}
+
+	// Once the remaining chunk in progress finishes, it should be counted.
+	repairing.mu.Lock()
+	repairing.piecesCompleted = 4
+	repairing.finished = true
+	repairing.mu.Unlock()
+	rq = r.RepairQueue()
+	if rq.ChunksRepaired != 2 || len(rq.Chunks) != 1 {
+		t.Fatalf("finished chunk was not removed from the queue: %+v", rq)
+	}
+}
+
+// TestDropRepairChunks checks that the repair queue forgets the chunks of a
+// discarded chunk heap, so that they can be queued again.
+func TestDropRepairChunks(t *testing.T) {
+	r := &Renter{
+		repairQueue: make(map[string]*repairEntry),
+		mu:          siasync.New(modules.SafeMutexDelay, 1),
+	}
+	discarded := &unfinishedChunk{piecesNeeded: 4}
+	working := &unfinishedChunk{piecesNeeded: 4}
+	r.repairQueue[repairKey("foo", 0)] = &repairEntry{siaPath: "foo", index: 0, chunk: discarded}
+	r.repairQueue[repairKey("foo", 1)] = &repairEntry{siaPath: "foo", index: 1, chunk: working}
+	r.repairQueue[repairKey("foo", 2)] = &repairEntry{siaPath: "foo", index: 2}
+
+	r.managedDropRepairChunks(&chunkHeap{discarded})
+	if _, ok := r.repairQueue[repairKey("foo", 0)]; ok {
+		t.Fatal("chunk of the discarded heap is still queued")
+	}
+	if len(r.repairQueue) != 2 {
+		t.Fatal("chunks that are not on the heap should stay queued:", len(r.repairQueue))
+	}
+}
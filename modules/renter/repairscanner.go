@@ -61,11 +61,20 @@ type unfinishedChunk struct {
 
 	// Worker synchronization fields. The mutex only protects these fields.
 	mu               sync.Mutex
-	pieceUsage       []bool              // one per piece. 'false' = piece not uploaded. 'true' = piece uploaded.
-	piecesCompleted  int                 // number of pieces that have been fully uploaded.
-	piecesRegistered int                 // number of pieces that are being uploaded, but aren't finished yet.
-	unusedHosts      map[string]struct{} // hosts that aren't yet storing any pieces
-	workersRemaining int                 // number of workers who have received the chunk, but haven't finished processing it.
+	pieceUsage       []bool               // one per piece. 'false' = piece not uploaded. 'true' = piece uploaded.
+	piecesCompleted  int                  // number of pieces that have been fully uploaded.
+	piecesRegistered int                  // number of pieces that are being uploaded, but aren't finished yet.
+	unusedHosts      map[string]struct{}  // hosts that aren't yet storing any pieces
+	workersRemaining int                  // number of workers who have received the chunk, but haven't finished processing it.
+	finished         bool                 // set once no more pieces of the chunk will be uploaded.
+	standbyWorkers   map[*worker]struct{} // workers that put the chunk on standby; woken when a piece is released.
+
+	// Host diversity fields, also protected by the mutex. hostSubnets maps
+	// each candidate host to its subnet and is shared between chunks, it must
+	// not be modified. usedSubnets counts the pieces of the chunk that are
+	// stored or being uploaded within each subnet.
+	hostSubnets map[string]string
+	usedSubnets map[string]int
 }
 
 // Implementation of heap.Interface for chunkHeap.
@@ -88,7 +97,7 @@ func (ch *chunkHeap) Pop() interface{} {
 // TODO / NOTE: This code can be substantially simplified once the files store
 // the HostPubKey instead of the FileContractID, and can be simplified even
 // further once the layout is per-chunk instead of per-filecontract.
-func (r *Renter) buildUnfinishedChunks(f *file, hosts map[string]struct{}) []*unfinishedChunk {
+func (r *Renter) buildUnfinishedChunks(f *file, hosts map[string]string) []*unfinishedChunk {
 	// Files are not threadsafe.
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -125,6 +134,8 @@ func (r *Renter) buildUnfinishedChunks(f *file, hosts map[string]struct{}) []*un
 			piecesNeeded:  f.erasureCode.NumPieces(),
 			pieceUsage:    make([]bool, f.erasureCode.NumPieces()),
 			unusedHosts:   make(map[string]struct{}),
+			hostSubnets:   hosts,
+			usedSubnets:   make(map[string]int),
 		}
 		// Every chunk can have a different set of unused hosts.
 		for host := range hosts {
@@ -161,6 +172,9 @@ func (r *Renter) buildUnfinishedChunks(f *file, hosts map[string]struct{}) []*un
 				newUnfinishedChunks[piece.Chunk].pieceUsage[piece.Piece] = true
 				newUnfinishedChunks[piece.Chunk].piecesCompleted++
 				delete(newUnfinishedChunks[piece.Chunk].unusedHosts, hpk.String())
+				if subnet := hosts[hpk.String()]; subnet != "" {
+					newUnfinishedChunks[piece.Chunk].usedSubnets[subnet]++
+				}
 			} else if exists {
 				// TODO / NOTE: This host has a piece, but it's the same piece
 				// that another host has. We may want to take action (such as
@@ -182,30 +196,48 @@ func (r *Renter) buildUnfinishedChunks(f *file, hosts map[string]struct{}) []*un
 		}
 	}
 
-	// Iterate through the set of newUnfinishedChunks and remove any that are
-	// completed.
+	// Iterate through the set of newUnfinishedChunks and remove the confirmed
+	// chunks, so that a resumed upload skips the chunks that are already
+	// stored on hosts. A confirmed chunk that has lost pieces since it was
+	// uploaded has its confirmation reset and is repaired. Completed chunks
+	// that are not confirmed yet are confirmed, which covers files that were
+	// uploaded before chunks were confirmed.
 	incompleteChunks := newUnfinishedChunks[:0]
 	for i := 0; i < len(newUnfinishedChunks); i++ {
-		if newUnfinishedChunks[i].piecesCompleted < newUnfinishedChunks[i].piecesNeeded {
-			incompleteChunks = append(incompleteChunks, newUnfinishedChunks[i])
+		complete := newUnfinishedChunks[i].piecesCompleted >= newUnfinishedChunks[i].piecesNeeded
+		if f.chunkConfirmed(uint64(i)) && complete {
+			continue
+		}
+		if complete {
+			r.persistDirty = f.confirmChunk(uint64(i)) || r.persistDirty
+			continue
 		}
+		r.persistDirty = f.unconfirmChunk(uint64(i)) || r.persistDirty
+		incompleteChunks = append(incompleteChunks, newUnfinishedChunks[i])
 	}
 	return incompleteChunks
 }
 
 // managedBuildChunkHeap will iterate through all of the files in the renter and
 // construct a chunk heap.
-func (r *Renter) managedBuildChunkHeap(hosts map[string]struct{}) *chunkHeap {
+func (r *Renter) managedBuildChunkHeap(hosts map[string]string) *chunkHeap {
 	// Loop through the whole set of files to build the chunk heap.
 	ch := new(chunkHeap)
 	heap.Init(ch)
 	id := r.mu.Lock()
-	for _, file := range r.files {
+	for name, file := range r.files {
+		// An alias shares the chunks of its original.
+		if _, isAlias := r.aliases[name]; isAlias {
+			continue
+		}
 		unfinishedChunks := r.buildUnfinishedChunks(file, hosts)
 		for i := 0; i < len(unfinishedChunks); i++ {
 			heap.Push(ch, unfinishedChunks[i])
 		}
 	}
+	// Save the chunks that were confirmed or unconfirmed while building the
+	// heap.
+	r.saveDirty()
 	r.mu.Unlock(id)
 
 	// Init the heap.
@@ -214,12 +246,13 @@ func (r *Renter) managedBuildChunkHeap(hosts map[string]struct{}) *chunkHeap {
 
 // managedInsertFileIntoChunkHeap will insert all of the chunks of a file into the
 // chunk heap.
-func (r *Renter) managedInsertFileIntoChunkHeap(f *file, ch *chunkHeap, hosts map[string]struct{}) {
+func (r *Renter) managedInsertFileIntoChunkHeap(f *file, ch *chunkHeap, hosts map[string]string) {
 	id := r.mu.Lock()
 	unfinishedChunks := r.buildUnfinishedChunks(f, hosts)
 	for i := 0; i < len(unfinishedChunks); i++ {
 		heap.Push(ch, unfinishedChunks[i])
 	}
+	r.saveDirty()
 	r.mu.Unlock(id)
 }
 
@@ -234,7 +267,7 @@ func (r *Renter) managedInsertFileIntoChunkHeap(f *file, ch *chunkHeap, hosts ma
 // memory refresh signal is received, it should just call 'AcquireMemory' on a
 // pool object or something, and then that object can worry about breaking and
 // stuff, and can also make sure that the memory goes to only one place.
-func (r *Renter) managedPrepareNextChunk(ch *chunkHeap, hosts map[string]struct{}) {
+func (r *Renter) managedPrepareNextChunk(ch *chunkHeap, hosts map[string]string) {
 	// Grab the next chunk, loop until we have enough memory, update the amount
 	// of memory available, and then spin up a thread to asynchronously handle
 	// the rest of the chunk tasks.
@@ -260,6 +293,9 @@ func (r *Renter) managedPrepareNextChunk(ch *chunkHeap, hosts map[string]struct{
 		if !workDistributed {
 			// Release any data that did not get distributed to workers.
 			r.managedMemoryAvailableAdd(nextChunk.memoryNeeded - nextChunk.memoryReleased)
+			nextChunk.mu.Lock()
+			nextChunk.finished = true
+			nextChunk.mu.Unlock()
 		}
 		// Sanity check, make sure memory was returned properly.
 		if nextChunk.logicalChunkData != nil {
@@ -271,17 +307,23 @@ func (r *Renter) managedPrepareNextChunk(ch *chunkHeap, hosts map[string]struct{
 
 // managedRefreshHostsAndWorkers will reset the set of hosts and the set of
 // workers for the renter.
-func (r *Renter) managedRefreshHostsAndWorkers() map[string]struct{} {
+func (r *Renter) managedRefreshHostsAndWorkers() map[string]string {
 	// Grab the current set of contracts and use them to build a list of hosts
 	// that are available for uploading. The hosts are assembled into a map
-	// where the key is the String() representation of the host's SiaPublicKey.
+	// where the key is the String() representation of the host's SiaPublicKey
+	// and the value is the subnet of the host, which is used to spread the
+	// pieces of a chunk across independent hosts.
 	//
 	// TODO / NOTE: This code can be removed once files store the HostPubKey
 	// of the hosts they are using, instead of just the FileContractID.
 	currentContracts := r.hostContractor.Contracts()
-	hosts := make(map[string]struct{})
+	hosts := make(map[string]string)
 	for _, contract := range currentContracts {
-		hosts[contract.HostPublicKey.String()] = struct{}{}
+		var subnet string
+		if host, exists := r.hostDB.Host(contract.HostPublicKey); exists {
+			subnet = hostSubnet(host.NetAddress)
+		}
+		hosts[contract.HostPublicKey.String()] = subnet
 	}
 
 	// Refresh the worker pool as well.
@@ -349,16 +391,29 @@ func (r *Renter) threadedRepairScan() {
 			} else if chunkHeap.Len() > 0 {
 				r.managedPrepareNextChunk(chunkHeap, hosts)
 			} else {
+				// Save the chunks that were confirmed since the last save
+				// while the loop is idle.
+				id := r.mu.Lock()
+				r.saveDirty()
+				r.mu.Unlock(id)
+
 				// Block until the rebuild signal is received.
 				select {
 				case newFile := <-r.newUploads:
 					// If a new file is received, add its chunks to the repair
 					// heap and loop to start working through those chunks.
-					// Update the worker pool before processing the file, as it
-					// may have been a while since the previous update.
+					// Update the worker pool before processing the file, as
+					// it may have been a while since the previous update.
 					hosts = r.managedRefreshHostsAndWorkers()
 					r.managedInsertFileIntoChunkHeap(newFile, chunkHeap, hosts)
 					continue
+				case req := <-r.repairRequests:
+					// The health scan queued chunks for repair. Because
+					// requests are only accepted once the heap is empty, a
+					// backlog of repairs holds back the scan.
+					hosts = r.managedRefreshHostsAndWorkers()
+					r.managedInsertRepairChunks(req, chunkHeap, hosts)
+					continue
 				case <-rebuildHeapSignal:
 					// If the rebuild heap signal is received, break out to the
 					// outer loop which will check the health of all filess
@@ -371,5 +426,9 @@ func (r *Renter) threadedRepairScan() {
 				}
 			}
 		}
+
+		// The heap is rebuilt from scratch, so the queued repairs that are
+		// still on it have to be queued again.
+		r.managedDropRepairChunks(chunkHeap)
 	}
 }
@@ -3,12 +3,15 @@ package renter
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 )
 
 var (
@@ -42,10 +45,36 @@ var (
 	errInsufficientContracts = errors.New("not enough contracts to upload file")
 	errUploadDirectory       = errors.New("cannot upload directory")
 
-	// Erasure-coded piece size
-	pieceSize = modules.SectorSize - crypto.TwofishOverhead
+	// maxPieceSize is the largest erasure-coded piece size. An encrypted piece
+	// of this size fills an entire sector.
+	maxPieceSize = modules.SectorSize - crypto.TwofishOverhead
+
+	// minPieceSize is the smallest erasure-coded piece size that will be
+	// chosen for a file. Pieces smaller than a sector are padded to a full
+	// sector when uploaded, but small pieces reduce the memory and erasure
+	// coding overhead of uploading and downloading small files.
+	minPieceSize = build.Select(build.Var{
+		Dev:      uint64(1 << 12), // 4 KiB
+		Standard: uint64(1 << 16), // 64 KiB
+		Testing:  uint64(1 << 9),  // 512 bytes
+	}).(uint64)
 )
 
+// filePieceSize returns the piece size to use for a file of the given size.
+// Small files get pieces just large enough to fit the whole file in a single
+// chunk, while large files use the largest piece size that fits in a sector.
+func filePieceSize(fileSize uint64, minPieces int) uint64 {
+	size := (fileSize + uint64(minPieces) - 1) / uint64(minPieces)
+	size = (size + crypto.SegmentSize - 1) / crypto.SegmentSize * crypto.SegmentSize
+	if size < minPieceSize {
+		size = minPieceSize
+	}
+	if size > maxPieceSize {
+		size = maxPieceSize
+	}
+	return size
+}
+
 // validateSiapath checks that a Siapath is a legal filename.
 // ../ is disallowed to prevent directory traversal, and paths must not begin
 // with / or be empty.
@@ -120,6 +149,7 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	}
 
 	// Create file object.
+	pieceSize := filePieceSize(uint64(fileInfo.Size()), up.ErasureCode.MinPieces())
 	f := newFile(up.SiaPath, up.ErasureCode, pieceSize, uint64(fileInfo.Size()))
 	f.mode = uint32(fileInfo.Mode())
 
@@ -136,7 +166,130 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return err
 	}
 
-	// Send the upload to the repair loop.
-	r.newUploads <- f
+	// Hash the file in the background, and send it to the repair loop once
+	// it is known not to be a duplicate.
+	go r.threadedHashNewUpload(f)
+	return nil
+}
+
+// threadedHashNewUpload hashes a new upload off the repair loop, so that
+// hashing a large file does not hold up repairs, and then hands the file to
+// the repair loop unless it turned out to be a duplicate.
+func (r *Renter) threadedHashNewUpload(f *file) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	if !r.managedHashNewUpload(f) {
+		return
+	}
+	select {
+	case r.newUploads <- f:
+	case <-r.tg.StopChan():
+	}
+}
+
+// managedHashNewUpload hashes the contents of a new upload, both so that the
+// file can later be found by DownloadByHash and so that contents already
+// stored on the network are not uploaded again. If an identical file has
+// already been uploaded, the new file is replaced by an alias of it. The
+// return value reports whether f still needs to be uploaded.
+func (r *Renter) managedHashNewUpload(f *file) bool {
+	id := r.mu.RLock()
+	source := r.tracking[f.name].RepairPath
+	r.mu.RUnlock(id)
+
+	handle, err := os.Open(source)
+	if err != nil {
+		r.log.Println("WARN: could not open file for hashing:", err)
+		return true
+	}
+	hash, chunkHashes, err := hashChunks(handle, f.chunkSize())
+	handle.Close()
+	if err != nil {
+		r.log.Println("WARN: could not hash file:", err)
+		return true
+	}
+
+	id = r.mu.Lock()
+	defer r.mu.Unlock(id)
+	f.mu.Lock()
+	f.hash = hash
+	f.chunkHashes = chunkHashes
+	name := f.name
+	f.mu.Unlock()
+	// The file may have been deleted while it was being hashed.
+	if r.files[name] != f {
+		return false
+	}
+
+	dup := r.duplicateFile(f)
+	if dup == nil {
+		f.mu.Lock()
+		err := r.saveFile(f)
+		f.mu.Unlock()
+		if err != nil {
+			r.log.Println("WARN: could not save file hash:", err)
+		}
+		return true
+	}
+	r.files[name] = dup
+	r.aliases[name] = dup.name
+	delete(r.tracking, name)
+	if err := persist.RemoveFile(filepath.Join(r.persistDir, name+ShareExtension)); err != nil {
+		r.log.Println("WARN: couldn't remove file :", err)
+	}
+	if err := r.saveSync(); err != nil {
+		r.log.Println("WARN: could not save alias:", err)
+	}
+	r.log.Printf("%v has the same contents as %v, skipping upload", name, dup.name)
+	return false
+}
+
+// duplicateFile returns a fully uploaded file other than f with the same
+// contents and erasure coding as f, or nil if there is none. The caller must
+// hold the renter's lock.
+func (r *Renter) duplicateFile(f *file) *file {
+	if f.hash == (crypto.Hash{}) {
+		return nil
+	}
+	for _, g := range r.files {
+		if g == f {
+			continue
+		}
+		g.mu.RLock()
+		match := g.hash == f.hash && g.size == f.size &&
+			g.erasureCode.MinPieces() == f.erasureCode.MinPieces() &&
+			g.erasureCode.NumPieces() == f.erasureCode.NumPieces() &&
+			g.uploadProgress() >= 100
+		g.mu.RUnlock()
+		if match {
+			return g
+		}
+	}
 	return nil
 }
+
+// hashChunks reads all of r, returning the hash of its contents along with
+// the hash of each chunkSize segment of its contents. The final segment is
+// hashed without padding.
+func hashChunks(r io.Reader, chunkSize uint64) (crypto.Hash, []crypto.Hash, error) {
+	h := crypto.NewHash()
+	var chunkHashes []crypto.Hash
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			chunkHashes = append(chunkHashes, crypto.HashBytes(buf[:n]))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return crypto.Hash{}, nil, err
+		}
+	}
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	return hash, chunkHashes, nil
+}
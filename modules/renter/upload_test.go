@@ -1,10 +1,12 @@
 package renter
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 )
 
@@ -72,3 +74,126 @@ func TestRenterUploadInode(t *testing.T) {
 		t.Fatal("expected errUploadDirectory, got", err)
 	}
 }
+
+// TestFilePieceSize checks that the piece size of a file grows with the size
+// of the file, staying between minPieceSize and maxPieceSize.
+func TestFilePieceSize(t *testing.T) {
+	tests := []struct {
+		fileSize  uint64
+		minPieces int
+		pieceSize uint64
+	}{
+		// Tiny files use the minimum piece size.
+		{0, 1, minPieceSize},
+		{1, 10, minPieceSize},
+		{minPieceSize * 10, 10, minPieceSize},
+
+		// Medium files are rounded up to a whole number of segments, and fit
+		// in a single chunk.
+		{minPieceSize*10 + 1, 10, minPieceSize + crypto.SegmentSize},
+		{minPieceSize * 2, 1, minPieceSize * 2},
+
+		// Large files are capped at the largest piece size.
+		{maxPieceSize * 10, 10, maxPieceSize},
+		{modules.SectorSize * 1000, 10, maxPieceSize},
+	}
+	for _, test := range tests {
+		pieceSize := filePieceSize(test.fileSize, test.minPieces)
+		if pieceSize != test.pieceSize {
+			t.Errorf("filePieceSize(%v, %v): expected %v, got %v", test.fileSize, test.minPieces, test.pieceSize, pieceSize)
+		}
+		if pieceSize*uint64(test.minPieces) < test.fileSize && pieceSize != maxPieceSize {
+			t.Errorf("file of size %v does not fit in a single chunk with piece size %v", test.fileSize, pieceSize)
+		}
+	}
+}
+
+// TestHashChunks checks that hashChunks hashes both the whole contents and
+// each chunk of the contents, without padding the final chunk.
+func TestHashChunks(t *testing.T) {
+	data := []byte("0123456789")
+	hash, chunkHashes, err := hashChunks(bytes.NewReader(data), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != crypto.HashBytes(data) {
+		t.Error("wrong hash for the whole contents")
+	}
+	expected := []crypto.Hash{
+		crypto.HashBytes(data[:4]),
+		crypto.HashBytes(data[4:8]),
+		crypto.HashBytes(data[8:]),
+	}
+	if len(chunkHashes) != len(expected) {
+		t.Fatalf("expected %v chunk hashes, got %v", len(expected), len(chunkHashes))
+	}
+	for i := range expected {
+		if chunkHashes[i] != expected[i] {
+			t.Errorf("wrong hash for chunk %v", i)
+		}
+	}
+
+	// Contents that fit exactly into chunks should not produce an empty
+	// final chunk.
+	_, chunkHashes, err = hashChunks(bytes.NewReader(data[:8]), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunkHashes) != 2 {
+		t.Fatal("expected 2 chunk hashes, got", len(chunkHashes))
+	}
+}
+
+// TestWakeStandbyWorkers checks that the workers holding a chunk on standby
+// are signaled when a piece of the chunk is released.
+func TestWakeStandbyWorkers(t *testing.T) {
+	w1 := &worker{uploadChan: make(chan struct{}, 1)}
+	w2 := &worker{uploadChan: make(chan struct{}, 1)}
+	idle := &worker{uploadChan: make(chan struct{}, 1)}
+	uc := &unfinishedChunk{standbyWorkers: map[*worker]struct{}{w1: {}, w2: {}}}
+
+	// Waking twice must not block on workers that have not yet picked up the
+	// first signal.
+	uc.wakeStandbyWorkers()
+	uc.wakeStandbyWorkers()
+	for _, w := range []*worker{w1, w2} {
+		select {
+		case <-w.uploadChan:
+		default:
+			t.Fatal("standby worker was not woken")
+		}
+	}
+	select {
+	case <-idle.uploadChan:
+		t.Fatal("worker without the chunk on standby was woken")
+	default:
+	}
+}
+
+// TestDeferPiece checks that a piece deferred for lack of bandwidth is
+// returned to the chunk, and that the chunk is put on standby.
+func TestDeferPiece(t *testing.T) {
+	w := &worker{uploadChan: make(chan struct{}, 1)}
+	host := w.hostPubKey.String()
+	uc := &unfinishedChunk{
+		hostSubnets:      map[string]string{host: "subnet"},
+		pieceUsage:       []bool{true, false},
+		piecesRegistered: 1,
+		unusedHosts:      make(map[string]struct{}),
+		usedSubnets:      map[string]int{"subnet": 1},
+	}
+
+	w.managedDeferPiece(uc, 0)
+	if uc.piecesRegistered != 0 || uc.pieceUsage[0] || uc.usedSubnets["subnet"] != 0 {
+		t.Fatal("piece was not returned to the chunk")
+	}
+	if _, ok := uc.unusedHosts[host]; !ok {
+		t.Fatal("host should remain a candidate for the chunk")
+	}
+	if len(w.standbyChunks) != 1 || !w.uploadsDeferred {
+		t.Fatal("chunk was not put on standby")
+	}
+	if _, ok := uc.standbyWorkers[w]; !ok {
+		t.Fatal("worker was not registered to be woken")
+	}
+}
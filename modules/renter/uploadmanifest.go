@@ -0,0 +1,125 @@
+package renter
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errLocalFileChanged is returned when the data of a chunk read from the
+	// local copy of a file does not match the hash recorded when the upload
+	// started, which means the local file was modified.
+	errLocalFileChanged = errors.New("local file has changed since the upload started")
+)
+
+// chunkConfirmed returns whether all pieces of the chunk have been stored on
+// hosts at least once.
+func (f *file) chunkConfirmed(chunk uint64) bool {
+	i := chunk / 8
+	return i < uint64(len(f.confirmed)) && f.confirmed[i]&(1<<(chunk%8)) != 0
+}
+
+// confirmChunk marks the chunk as fully uploaded. It returns false if the
+// chunk was already confirmed.
+func (f *file) confirmChunk(chunk uint64) bool {
+	if f.chunkConfirmed(chunk) {
+		return false
+	}
+	if n := (f.numChunks() + 7) / 8; uint64(len(f.confirmed)) < n {
+		confirmed := make([]byte, n)
+		copy(confirmed, f.confirmed)
+		f.confirmed = confirmed
+	}
+	f.confirmed[chunk/8] |= 1 << (chunk % 8)
+	return true
+}
+
+// unconfirmChunk clears the confirmation of a chunk that has lost pieces, so
+// that it is repaired like a chunk that was never fully uploaded. It returns
+// false if the chunk was not confirmed.
+func (f *file) unconfirmChunk(chunk uint64) bool {
+	if !f.chunkConfirmed(chunk) {
+		return false
+	}
+	f.confirmed[chunk/8] &^= 1 << (chunk % 8)
+	return true
+}
+
+// manifest returns the upload manifest of the file. The pieces of each chunk
+// are sorted by piece index.
+func (f *file) manifest() modules.UploadManifest {
+	m := modules.UploadManifest{
+		SiaPath:   f.name,
+		Size:      f.size,
+		ChunkSize: f.chunkSize(),
+		MinPieces: f.erasureCode.MinPieces(),
+		NumPieces: f.erasureCode.NumPieces(),
+		Hash:      f.hash,
+		Chunks:    make([]modules.ChunkManifest, f.numChunks()),
+	}
+	for _, fc := range f.contracts {
+		for _, p := range fc.Pieces {
+			chunk := &m.Chunks[p.Chunk]
+			chunk.Pieces = append(chunk.Pieces, modules.PieceManifest{
+				Piece:      p.Piece,
+				Host:       fc.IP,
+				Contract:   fc.ID,
+				MerkleRoot: p.MerkleRoot,
+			})
+		}
+	}
+	for i := range m.Chunks {
+		chunk := &m.Chunks[i]
+		if i < len(f.chunkHashes) {
+			chunk.Hash = f.chunkHashes[i]
+		}
+		chunk.Confirmed = f.chunkConfirmed(uint64(i))
+		sort.Slice(chunk.Pieces, func(a, b int) bool {
+			return chunk.Pieces[a].Piece < chunk.Pieces[b].Piece
+		})
+	}
+	return m
+}
+
+// verifyChunkData returns errLocalFileChanged if data, read from the local
+// copy of the file, does not match the hash recorded for the chunk when the
+// upload started. Chunks without a recorded hash are not verified.
+func (f *file) verifyChunkData(chunk uint64, data []byte) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if chunk < uint64(len(f.chunkHashes)) && crypto.HashBytes(data) != f.chunkHashes[chunk] {
+		return errLocalFileChanged
+	}
+	return nil
+}
+
+// managedConfirmChunk marks the chunk of f as fully uploaded, so that the
+// chunk is known to be complete when an interrupted upload is resumed. The
+// confirmation is saved in a batch with others, when the repair loop next
+// builds its heap or runs out of chunks, or when the renter shuts down.
+func (r *Renter) managedConfirmChunk(f *file, chunk uint64) {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	f.mu.Lock()
+	if f.confirmChunk(chunk) {
+		r.persistDirty = true
+	}
+	f.mu.Unlock()
+}
+
+// UploadManifest returns the hash, confirmation state, hosts and piece Merkle
+// roots of every chunk of a file.
+func (r *Renter) UploadManifest(nickname string) (modules.UploadManifest, error) {
+	lockID := r.mu.RLock()
+	f, exists := r.files[nickname]
+	r.mu.RUnlock(lockID)
+	if !exists {
+		return modules.UploadManifest{}, ErrUnknownPath
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.manifest(), nil
+}
@@ -0,0 +1,126 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestConfirmChunk checks that chunks can be confirmed and unconfirmed
+// individually.
+func TestConfirmChunk(t *testing.T) {
+	rsc, _ := NewRSCode(1, 1)
+	f := &file{size: 1000, erasureCode: rsc, pieceSize: 100}
+	if f.chunkConfirmed(0) || f.chunkConfirmed(9) {
+		t.Fatal("new file should have no confirmed chunks")
+	}
+	if !f.confirmChunk(9) || f.confirmChunk(9) {
+		t.Fatal("confirmChunk should only report newly confirmed chunks")
+	}
+	if !f.confirmChunk(2) {
+		t.Fatal("chunk 2 was already confirmed")
+	}
+	for i := uint64(0); i < f.numChunks(); i++ {
+		if f.chunkConfirmed(i) != (i == 2 || i == 9) {
+			t.Error("chunk", i, "has the wrong confirmation state")
+		}
+	}
+	if len(f.confirmed) != 2 {
+		t.Error("expected a 2 byte bitfield, got", len(f.confirmed))
+	}
+
+	// A chunk that loses pieces is no longer confirmed.
+	if !f.unconfirmChunk(9) || f.unconfirmChunk(9) || f.unconfirmChunk(0) {
+		t.Fatal("unconfirmChunk should only report previously confirmed chunks")
+	}
+	if f.chunkConfirmed(9) || !f.chunkConfirmed(2) {
+		t.Fatal("only chunk 9 should have been unconfirmed")
+	}
+}
+
+// TestManagedConfirmChunk checks that confirming a chunk marks the renter
+// metadata as unsaved, so that confirmations are saved in batches.
+func TestManagedConfirmChunk(t *testing.T) {
+	r := &Renter{mu: siasync.New(modules.SafeMutexDelay, 1)}
+	rsc, _ := NewRSCode(1, 1)
+	f := &file{size: 1000, erasureCode: rsc, pieceSize: 100}
+
+	r.managedConfirmChunk(f, 3)
+	if !f.chunkConfirmed(3) || !r.persistDirty {
+		t.Fatal("confirmed chunk should be marked for saving")
+	}
+	r.persistDirty = false
+	r.managedConfirmChunk(f, 3)
+	if r.persistDirty {
+		t.Fatal("confirming a chunk again should not mark it for saving")
+	}
+}
+
+// TestUploadManifest checks that the manifest lists the hash, confirmation
+// state and stored pieces of every chunk.
+func TestUploadManifest(t *testing.T) {
+	rsc, _ := NewRSCode(1, 1)
+	data := []byte("0123456789abcdefghij")
+	f := &file{
+		name:        "foo",
+		size:        uint64(len(data)),
+		erasureCode: rsc,
+		pieceSize:   10,
+		contracts:   make(map[types.FileContractID]fileContract),
+	}
+	f.hash, f.chunkHashes, _ = hashChunks(bytes.NewReader(data), f.chunkSize())
+	f.contracts[types.FileContractID{1}] = fileContract{
+		ID: types.FileContractID{1},
+		IP: "foo.com:1234",
+		Pieces: []pieceData{
+			{Chunk: 0, Piece: 1, MerkleRoot: crypto.Hash{1}},
+			{Chunk: 1, Piece: 0, MerkleRoot: crypto.Hash{2}},
+		},
+	}
+	f.contracts[types.FileContractID{2}] = fileContract{
+		ID:     types.FileContractID{2},
+		IP:     "bar.com:1234",
+		Pieces: []pieceData{{Chunk: 0, Piece: 0, MerkleRoot: crypto.Hash{3}}},
+	}
+	f.confirmChunk(0)
+
+	m := f.manifest()
+	if m.SiaPath != "foo" || m.Size != 20 || m.ChunkSize != 10 || m.Hash != f.hash || len(m.Chunks) != 2 {
+		t.Fatalf("unexpected manifest: %+v", m)
+	}
+	chunk := m.Chunks[0]
+	if !chunk.Confirmed || chunk.Hash != crypto.HashBytes(data[:10]) || len(chunk.Pieces) != 2 {
+		t.Fatalf("unexpected manifest of chunk 0: %+v", chunk)
+	}
+	if chunk.Pieces[0].Piece != 0 || chunk.Pieces[0].Host != "bar.com:1234" || chunk.Pieces[0].MerkleRoot != (crypto.Hash{3}) {
+		t.Error("pieces of chunk 0 are incorrect:", chunk.Pieces)
+	}
+	if chunk := m.Chunks[1]; chunk.Confirmed || len(chunk.Pieces) != 1 || chunk.Pieces[0].Contract != (types.FileContractID{1}) {
+		t.Errorf("unexpected manifest of chunk 1: %+v", chunk)
+	}
+}
+
+// TestVerifyChunkData checks that chunk data read from a modified local file
+// is rejected.
+func TestVerifyChunkData(t *testing.T) {
+	rsc, _ := NewRSCode(1, 1)
+	data := []byte("0123456789abcde")
+	f := &file{size: uint64(len(data)), erasureCode: rsc, pieceSize: 10}
+	if err := f.verifyChunkData(0, []byte("anything")); err != nil {
+		t.Fatal("chunks without a recorded hash should not be verified:", err)
+	}
+	_, f.chunkHashes, _ = hashChunks(bytes.NewReader(data), f.chunkSize())
+	if err := f.verifyChunkData(0, data[:10]); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.verifyChunkData(1, data[10:]); err != nil {
+		t.Fatal("the final chunk should be verified without padding:", err)
+	}
+	if err := f.verifyChunkData(1, []byte("ABCDE")); err != errLocalFileChanged {
+		t.Fatal("expected errLocalFileChanged, got", err)
+	}
+}
@@ -17,10 +17,27 @@ import (
 // interacted with exclusively by the primary worker thread, and only one of
 // those ever exists at a time.
 type worker struct {
-	// The contract and host used by this worker.
-	contract   modules.RenterContract
-	hostPubKey types.SiaPublicKey
-	renter     *Renter
+	// atomicDownloadLatency is a moving average of the time in nanoseconds
+	// that the worker takes to download a piece from its host, or zero if no
+	// piece has been downloaded yet. It is written by the worker thread and
+	// read by the download loop.
+	atomicDownloadLatency int64
+
+	// atomicDownloadLatencyVariance holds the bits of the moving variance, in
+	// square nanoseconds, of the worker's piece download times. Together with
+	// the average latency it sets the timeout of the worker's downloads. It is
+	// only written by the worker thread, but is read by the stuck download
+	// checks.
+	atomicDownloadLatencyVariance uint64
+
+	// The contract and host used by this worker. downloadPrice and
+	// hostAddress are the host's download bandwidth price and address at the
+	// time the worker was created.
+	contract      modules.RenterContract
+	downloadPrice types.Currency
+	hostAddress   modules.NetAddress
+	hostPubKey    types.SiaPublicKey
+	renter        *Renter
 
 	// Channels that inform the worker of kill signals and of new work.
 	downloadChan         chan downloadWork // higher priority than all uploads
@@ -28,8 +45,15 @@ type worker struct {
 	priorityDownloadChan chan downloadWork // higher priority than downloads (used for user-initiated downloads)
 	uploadChan           chan struct{}     // lowest priority
 
-	// Operation failure statistics for the worker.
-	downloadRecentFailure     time.Time // Only modified by the primary download loop.
+	// deferredDownloads are downloads that the worker received while their
+	// share of the renter's bandwidth was used up. They are retried ahead of
+	// upload work. uploadsDeferred is set when an upload was deferred for the
+	// same reason, and pauses uploading until the worker next wakes.
+	deferredDownloads []downloadWork
+	uploadsDeferred   bool
+
+	// Operation failure statistics for the worker. Download failures are
+	// tracked per host by the renter's hostBreaker.
 	uploadRecentFailure       time.Time // Only modified by primary repair loop.
 	uploadConsecutiveFailures int
 
@@ -56,6 +80,7 @@ func (w *worker) threadedWorkLoop() {
 	// The worker may have upload chunks and it needs to drop them before
 	// terminating.
 	defer w.managedKillUploading()
+	defer w.dropDeferredDownloads()
 
 	for {
 		// Check for priority downloads.
@@ -74,24 +99,34 @@ func (w *worker) threadedWorkLoop() {
 		default:
 		}
 
-		// Perform one step of processing upload work.
-		chunk, pieceIndex := w.managedNextChunk()
-		if chunk != nil {
-			w.managedUpload(chunk, pieceIndex)
+		// Retry the downloads that were deferred for lack of bandwidth.
+		if w.retryDeferredDownloads() {
 			continue
 		}
 
+		// Perform one step of processing upload work.
+		if !w.uploadsDeferred {
+			chunk, pieceIndex := w.managedNextChunk()
+			if chunk != nil {
+				w.managedUpload(chunk, pieceIndex)
+				continue
+			}
+		}
+
 		// Determine the maximum amount of time to wait for any standby chunks.
 		var sleepDuration time.Duration
 		w.mu.Lock()
 		numStandby := len(w.standbyChunks)
 		w.mu.Unlock()
-		if numStandby > 0 {
+		if len(w.deferredDownloads) > 0 || w.uploadsDeferred {
+			sleepDuration = bandwidthRetryInterval
+		} else if numStandby > 0 {
 			// TODO: Pick a random time instead of just a constant time.
 			sleepDuration = time.Second * 3 // TODO: Constant
 		} else {
 			sleepDuration = time.Hour // TODO: Constant
 		}
+		w.uploadsDeferred = false
 
 		// Block until new work is received via the upload or download channels,
 		// or until the standby chunks are ready to be revisited, or until a
@@ -129,9 +164,12 @@ func (r *Renter) managedUpdateWorkerPool() {
 		lockID := r.mu.Lock()
 		_, exists := r.workerPool[id]
 		if !exists {
+			host, _ := r.hostDB.Host(contract.HostPublicKey)
 			worker := &worker{
-				contract:   contract,
-				hostPubKey: contract.HostPublicKey,
+				contract:      contract,
+				downloadPrice: host.DownloadBandwidthPrice,
+				hostAddress:   host.NetAddress,
+				hostPubKey:    contract.HostPublicKey,
 
 				downloadChan:         make(chan downloadWork, 1),
 				killChan:             make(chan struct{}),
@@ -1,10 +1,20 @@
 package renter
 
 import (
+	"errors"
+	"time"
+
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
 
+var (
+	// errPieceDownloadTimeout is returned by a worker whose host took longer
+	// than the worker's download timeout to deliver a piece.
+	errPieceDownloadTimeout = errors.New("host took too long to deliver the piece")
+)
+
 type (
 	// downloadWork contains instructions to download a piece from a host, and
 	// a channel for returning the results.
@@ -31,21 +41,84 @@ type (
 	}
 )
 
+// cancelled returns whether the chunk has been recovered and no longer needs
+// the pieces that are still being downloaded.
+func (cd *chunkDownload) cancelled() bool {
+	select {
+	case <-cd.cancel:
+		return true
+	default:
+		return false
+	}
+}
+
 // download will perform some download work.
 func (w *worker) download(dw downloadWork) {
-	d, err := w.renter.hostContractor.Downloader(w.contract.ID, w.renter.tg.StopChan())
+	cd := dw.chunkDownload
+	d := cd.download
+	done := make(chan struct{})
+	defer close(done)
+
+	// Take the download's share of the renter's bandwidth before connecting
+	// to the host, so that no connection is held open while waiting. If the
+	// share is used up, the download is deferred and retried later, so that
+	// the worker can serve other work in the meantime instead of blocking.
+	op := w.renter.bandwidth.acquire(&w.renter.bandwidth.downloads, d.siapath, d.priority)
+	defer w.renter.bandwidth.release(op)
+	if !cd.cancelled() && !op.tryWait(modules.SectorSize) {
+		w.deferredDownloads = append(w.deferredDownloads, dw)
+		return
+	}
+	if cd.cancelled() || !w.managedAcquireHostConnection() {
+		err := errWorkerKilled
+		if cd.cancelled() {
+			err = errPieceCancelled
+		}
+		w.failDownload(dw, err)
+		return
+	}
+	defer w.managedReleaseHostConnection()
+
+	// The connection to the host is closed if the host takes longer than the
+	// worker's timeout to connect and deliver the piece, or if the chunk no
+	// longer needs the piece.
+	start := time.Now()
+	timeout := w.downloadTimeout()
+	cancel := make(chan struct{})
+	go func() {
+		select {
+		case <-time.After(timeout):
+		case <-w.renter.tg.StopChan():
+		case <-cd.cancel:
+		case <-done:
+			return
+		}
+		close(cancel)
+	}()
+
+	downloader, err := w.renter.hostContractor.Downloader(w.contract.ID, cancel)
 	if err != nil {
-		go func() {
-			select {
-			case dw.resultChan <- finishedDownload{dw.chunkDownload, nil, err, dw.pieceIndex, w.contract.ID}:
-			case <-w.renter.tg.StopChan():
-			}
-		}()
+		if cd.cancelled() {
+			err = errPieceCancelled
+		} else if time.Since(start) >= timeout {
+			err = errPieceDownloadTimeout
+		}
+		w.failDownload(dw, err)
 		return
 	}
-	defer d.Close()
+	defer downloader.Close()
 
-	data, err := d.Sector(dw.dataRoot)
+	data, err := downloader.Sector(dw.dataRoot)
+	if err == nil {
+		w.recordDownloadLatency(time.Since(start))
+	} else if cd.cancelled() {
+		err = errPieceCancelled
+	} else if time.Since(start) >= timeout {
+		// Count the timeout as a slow download, so that a host that has
+		// become slower is given more time on its next try.
+		w.recordDownloadLatency(timeout)
+		err = errPieceDownloadTimeout
+	}
 	go func() {
 		select {
 		case dw.resultChan <- finishedDownload{dw.chunkDownload, data, err, dw.pieceIndex, w.contract.ID}:
@@ -53,3 +126,34 @@ func (w *worker) download(dw downloadWork) {
 		}
 	}()
 }
+
+// failDownload returns an error for a piece that the worker did not
+// download.
+func (w *worker) failDownload(dw downloadWork, err error) {
+	go func() {
+		select {
+		case dw.resultChan <- finishedDownload{dw.chunkDownload, nil, err, dw.pieceIndex, w.contract.ID}:
+		case <-w.renter.tg.StopChan():
+		}
+	}()
+}
+
+// retryDeferredDownloads retries the downloads that were deferred for lack of
+// bandwidth. It returns true if any of them was performed.
+func (w *worker) retryDeferredDownloads() bool {
+	deferred := w.deferredDownloads
+	w.deferredDownloads = nil
+	for _, dw := range deferred {
+		w.download(dw)
+	}
+	return len(w.deferredDownloads) < len(deferred)
+}
+
+// dropDeferredDownloads fails the downloads that were deferred for lack of
+// bandwidth, so that the chunks can fetch the pieces from other workers.
+func (w *worker) dropDeferredDownloads() {
+	for _, dw := range w.deferredDownloads {
+		w.failDownload(dw, errWorkerKilled)
+	}
+	w.deferredDownloads = nil
+}
@@ -1,13 +1,22 @@
 package renter
 
 import (
+	"sync/atomic"
 	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
 )
 
 // dropChunk will remove a worker from the responsibility of tracking a chunk.
 func (w *worker) dropChunk(uc *unfinishedChunk) {
 	uc.mu.Lock()
 	uc.workersRemaining--
+	if uc.workersRemaining == 0 {
+		uc.finished = true
+	}
+	// The host will not receive a piece of this chunk, so it no longer counts
+	// as a candidate for spreading the chunk across subnets.
+	delete(uc.unusedHosts, w.hostPubKey.String())
 	uc.mu.Unlock()
 	w.renter.managedReleaseIdleChunkPieces(uc)
 	w.renter.heapWG.Done()
@@ -73,6 +82,7 @@ func (w *worker) processChunk(uc *unfinishedChunk) (nextChunk *unfinishedChunk,
 		return nil, 0
 	}
 	goodForUpload := utility.GoodForUpload
+	maxWorkers := w.renter.managedLimits().MaxUploadWorkers
 
 	// Determine what sort of help this chunk needs.
 	uc.mu.Lock()
@@ -80,6 +90,12 @@ func (w *worker) processChunk(uc *unfinishedChunk) (nextChunk *unfinishedChunk,
 	chunkComplete := uc.piecesNeeded <= uc.piecesCompleted
 	needsHelp := uc.piecesNeeded > uc.piecesCompleted+uc.piecesRegistered
 
+	// If the maximum number of workers are already uploading pieces of the
+	// chunk, wait on standby until one of them finishes.
+	if uc.piecesRegistered >= maxWorkers {
+		needsHelp = false
+	}
+
 	// If the chunk does not need help from this worker, release the chunk.
 	if chunkComplete || !candidateHost || !goodForUpload {
 		// This worker no longer needs to track this chunk.
@@ -88,6 +104,14 @@ func (w *worker) processChunk(uc *unfinishedChunk) (nextChunk *unfinishedChunk,
 		return nil, 0
 	}
 
+	// If the host shares a subnet with a host that already has a piece of the
+	// chunk, defer to hosts in other subnets so that the pieces are spread
+	// across independent hosts. The host is only used if no such host remains.
+	subnet := uc.hostSubnets[w.hostPubKey.String()]
+	if needsHelp && subnet != "" && uc.usedSubnets[subnet] > 0 && uc.diverseHostAvailable() {
+		needsHelp = false
+	}
+
 	// If the chunk needs help from this worker, find a piece to upload and
 	// return the stats for that piece.
 	index := 0
@@ -101,18 +125,40 @@ func (w *worker) processChunk(uc *unfinishedChunk) (nextChunk *unfinishedChunk,
 			}
 		}
 		delete(uc.unusedHosts, w.hostPubKey.String())
+		if subnet != "" {
+			uc.usedSubnets[subnet]++
+		}
 		uc.piecesRegistered++
 		uc.mu.Unlock()
 		return uc, uint64(index)
 	}
-	uc.mu.Unlock()
 
 	// The chunk could need help from this worker, but only if other workers who
-	// are performing uploads experience failures. Put this chunk on standby.
+	// are performing uploads experience failures. Put this chunk on standby,
+	// and register the worker so that it is woken as soon as a piece is
+	// released.
+	if uc.standbyWorkers == nil {
+		uc.standbyWorkers = make(map[*worker]struct{})
+	}
+	uc.standbyWorkers[w] = struct{}{}
+	uc.mu.Unlock()
 	w.standbyChunks = append(w.standbyChunks, uc)
 	return nil, 0
 }
 
+// wakeStandbyWorkers signals the workers that put the chunk on standby, so
+// that a piece released by a failed or abandoned upload falls back to another
+// host right away instead of waiting for the standby chunks to be revisited.
+// The caller must hold the chunk's lock.
+func (uc *unfinishedChunk) wakeStandbyWorkers() {
+	for w := range uc.standbyWorkers {
+		select {
+		case w.uploadChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
 // managedQueueChunkRepair will take a chunk and add it to the worker's repair stack.
 func (w *worker) managedQueueChunkRepair(uc *unfinishedChunk) {
 	// Check that the worker is allowed to be uploading.
@@ -153,13 +199,88 @@ func (w *worker) uploadFailed(uc *unfinishedChunk, pieceIndex uint64) {
 	uc.mu.Lock()
 	uc.piecesRegistered--
 	uc.pieceUsage[pieceIndex] = false
+	if subnet := uc.hostSubnets[w.hostPubKey.String()]; subnet != "" {
+		uc.usedSubnets[subnet]--
+	}
+	uc.wakeStandbyWorkers()
 	uc.mu.Unlock()
 	w.dropChunk(uc)
 	w.dropUploadChunks()
 }
 
+// unregisterPiece releases a piece that the worker selected for upload but
+// will not upload, without penalizing the worker. Another worker will be able
+// to pick up the piece.
+func (w *worker) unregisterPiece(uc *unfinishedChunk, pieceIndex uint64) {
+	uc.mu.Lock()
+	uc.piecesRegistered--
+	uc.pieceUsage[pieceIndex] = false
+	if subnet := uc.hostSubnets[w.hostPubKey.String()]; subnet != "" {
+		uc.usedSubnets[subnet]--
+	}
+	uc.wakeStandbyWorkers()
+	uc.mu.Unlock()
+	w.dropChunk(uc)
+}
+
+// managedDeferPiece releases a piece that the worker selected for upload but
+// has no bandwidth to upload yet, and puts the chunk on standby so that the
+// worker can select a piece of it again later.
+func (w *worker) managedDeferPiece(uc *unfinishedChunk, pieceIndex uint64) {
+	uc.mu.Lock()
+	uc.piecesRegistered--
+	uc.pieceUsage[pieceIndex] = false
+	if subnet := uc.hostSubnets[w.hostPubKey.String()]; subnet != "" {
+		uc.usedSubnets[subnet]--
+	}
+	uc.unusedHosts[w.hostPubKey.String()] = struct{}{}
+	if uc.standbyWorkers == nil {
+		uc.standbyWorkers = make(map[*worker]struct{})
+	}
+	uc.standbyWorkers[w] = struct{}{}
+	uc.mu.Unlock()
+
+	w.mu.Lock()
+	w.standbyChunks = append(w.standbyChunks, uc)
+	w.mu.Unlock()
+	w.uploadsDeferred = true
+}
+
 // managedUpload will perform some upload work.
 func (w *worker) managedUpload(uc *unfinishedChunk, pieceIndex uint64) {
+	// Take the file's share of the renter's upload bandwidth before taking an
+	// upload slot. If the share is used up, the piece is returned and the
+	// chunk is put on standby, so that the worker can serve downloads while
+	// the bandwidth accumulates instead of blocking.
+	uc.renterFile.mu.RLock()
+	siapath := uc.renterFile.name
+	uc.renterFile.mu.RUnlock()
+	op := w.renter.bandwidth.acquire(&w.renter.bandwidth.uploads, siapath, 0)
+	defer w.renter.bandwidth.release(op)
+	if !op.tryWait(modules.SectorSize) {
+		w.managedDeferPiece(uc, pieceIndex)
+		return
+	}
+
+	// Wait for an upload slot, so that only a limited number of pieces are
+	// uploaded in parallel across all of the workers.
+	// The worker's kill channel is closed on shutdown, so it covers both
+	// cancellation cases.
+	if w.renter.uploadSlots.Request(1, w.killChan) {
+		w.unregisterPiece(uc, pieceIndex)
+		return
+	}
+	atomic.AddInt64(&w.renter.atomicActiveUploadPieces, 1)
+	defer func() {
+		atomic.AddInt64(&w.renter.atomicActiveUploadPieces, -1)
+		w.renter.uploadSlots.Release(1)
+	}()
+	if !w.managedAcquireHostConnection() {
+		w.unregisterPiece(uc, pieceIndex)
+		return
+	}
+	defer w.managedReleaseHostConnection()
+
 	// Open an editing connection to the host.
 	e, err := w.renter.hostContractor.Editor(w.contract.ID, w.renter.tg.StopChan())
 	if err != nil {
@@ -171,9 +292,18 @@ func (w *worker) managedUpload(uc *unfinishedChunk, pieceIndex uint64) {
 	}
 	defer e.Close()
 
+	// Hosts only accept full sectors, so pieces smaller than a sector are
+	// padded before they are uploaded.
+	data := uc.physicalChunkData[pieceIndex]
+	if uint64(len(data)) < modules.SectorSize {
+		padded := make([]byte, modules.SectorSize)
+		copy(padded, data)
+		data = padded
+	}
+
 	// Perform the upload, and update the failure stats based on the success of
 	// the upload attempt.
-	root, err := e.Upload(uc.physicalChunkData[pieceIndex])
+	root, err := e.Upload(data)
 	if err != nil {
 		w.renter.log.Debugln("Worker failed to upload via the editor:", err)
 		w.mu.Lock()
@@ -216,7 +346,11 @@ func (w *worker) managedUpload(uc *unfinishedChunk, pieceIndex uint64) {
 	uc.piecesCompleted++
 	uc.physicalChunkData[pieceIndex] = nil
 	uc.memoryReleased += uint64(releaseSize)
+	complete := uc.piecesCompleted == uc.piecesNeeded
 	uc.mu.Unlock()
+	if complete {
+		w.renter.managedConfirmChunk(uc.renterFile, uc.index)
+	}
 	w.renter.managedMemoryAvailableAdd(uint64(releaseSize))
 	w.dropChunk(uc)
 }
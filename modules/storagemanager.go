@@ -84,6 +84,10 @@ type (
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
 
+		// ReadPartialSector will read length bytes of a sector from the
+		// storage manager, starting offset bytes into the sector.
+		ReadPartialSector(sectorRoot crypto.Hash, offset, length uint64) ([]byte, error)
+
 		// RemoveSector will remove a sector from the storage manager. The
 		// height at which the sector expires should be provided, so that the
 		// auto-expiry information for that sector can be properly updated.
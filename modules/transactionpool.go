@@ -52,6 +52,26 @@ var (
 	TransactionPoolDir = "transactionpool"
 )
 
+const (
+	// TransactionStateUnknown indicates that the transaction pool has no
+	// record of the transaction, either because it was never submitted or
+	// because it was dropped from the pool.
+	TransactionStateUnknown TransactionState = iota
+
+	// TransactionStatePending indicates that the transaction is in the
+	// transaction pool waiting to be confirmed.
+	TransactionStatePending
+
+	// TransactionStateConfirmed indicates that the transaction has been
+	// confirmed on the blockchain.
+	TransactionStateConfirmed
+
+	// TransactionStateConflicted indicates that the transaction was dropped
+	// from the transaction pool because it is no longer valid, for example
+	// because one of its inputs was spent by a conflicting transaction.
+	TransactionStateConflicted
+)
+
 type (
 	// ConsensusConflict implements the error interface, and indicates that a
 	// transaction was rejected due to being incompatible with the current
@@ -59,6 +79,17 @@ type (
 	// it is unlikely that the transaction will ever be valid.
 	ConsensusConflict string
 
+	// TransactionState describes the fate of a transaction that was submitted
+	// to the transaction pool.
+	TransactionState int
+
+	// TransactionStatus reports the state of a transaction. ConfirmationHeight
+	// is only meaningful if the state is TransactionStateConfirmed.
+	TransactionStatus struct {
+		State              TransactionState  `json:"state"`
+		ConfirmationHeight types.BlockHeight `json:"confirmationheight"`
+	}
+
 	// TransactionSetID is a type-safe wrapper for a crypto.Hash that represents
 	// the ID of an entire transaction set.
 	TransactionSetID crypto.Hash
@@ -102,6 +133,11 @@ type (
 		// transactions.
 		AcceptTransactionSet([]types.Transaction) error
 
+		// AcceptTransactionSetNoBroadcast accepts a set of potentially
+		// interdependent transactions without relaying them to peers. The set
+		// can be relayed later by calling Broadcast.
+		AcceptTransactionSetNoBroadcast([]types.Transaction) error
+
 		// Broadcast broadcasts a transaction set to all of the transaction pool's
 		// peers.
 		Broadcast(ts []types.Transaction)
@@ -124,6 +160,12 @@ type (
 		// that make this condition necessary.
 		PurgeTransactionPool()
 
+		// ReplaceTransactions replaces unconfirmed transactions of the same
+		// transaction set with transactions that spend the same inputs and
+		// pay a higher total miner fee, allowing transactions whose fees are
+		// too low to be confirmed to be resubmitted.
+		ReplaceTransactions(ids []types.TransactionID, replacements []types.Transaction) error
+
 		// TransactionList returns a list of all transactions in the transaction
 		// pool. The transactions are provided in an order that can acceptably be
 		// put into a block.
@@ -138,6 +180,15 @@ type (
 		// corresponding to the provided transaction id.
 		Transaction(id types.TransactionID) (txn types.Transaction, unconfirmedParents []types.Transaction, exists bool)
 
+		// TransactionStatus reports whether the transaction with the provided
+		// id is pending, confirmed, or was dropped due to a conflict.
+		TransactionStatus(id types.TransactionID) (TransactionStatus, error)
+
+		// ValidateTransactionSet checks whether a set of potentially
+		// interdependent transactions would be accepted, without adding it to
+		// the transaction pool or relaying it to peers.
+		ValidateTransactionSet([]types.Transaction) error
+
 		// Unsubscribe removes a subscriber from the transaction pool.
 		// This is necessary for clean shutdown of the miner.
 		Unsubscribe(TransactionPoolSubscriber)
@@ -156,6 +207,20 @@ func (cc ConsensusConflict) Error() string {
 	return string(cc)
 }
 
+// String returns a human-readable description of the transaction state.
+func (ts TransactionState) String() string {
+	switch ts {
+	case TransactionStatePending:
+		return "pending"
+	case TransactionStateConfirmed:
+		return "confirmed"
+	case TransactionStateConflicted:
+		return "conflicted"
+	default:
+		return "unknown"
+	}
+}
+
 // CalculateFee returns the fee-per-byte of a transaction set.
 func CalculateFee(ts []types.Transaction) types.Currency {
 	var sum types.Currency
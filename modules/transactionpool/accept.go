@@ -21,6 +21,13 @@ var (
 	errFullTransactionPool = errors.New("transaction pool cannot accept more transactions")
 	errLowMinerFees        = errors.New("transaction set needs more miner fees to be accepted")
 	errObjectConflict      = errors.New("transaction set conflicts with an existing transaction set")
+
+	// Errors returned by ReplaceTransactions.
+	errNoReplacement           = errors.New("transaction set does not replace any transaction in the pool")
+	errReplaceUnknown          = errors.New("transactions to replace are not in the same transaction set of the pool")
+	errReplacementFeeTooLow    = errors.New("replacement transactions must pay a higher miner fee than the originals")
+	errReplacementHasChildren  = errors.New("transactions to replace have unconfirmed children")
+	errReplacementMissingInput = errors.New("replacement transactions must spend every input of the originals")
 )
 
 // relatedObjectIDs determines all of the object ids related to a transaction.
@@ -101,8 +108,9 @@ func (tp *TransactionPool) checkTransactionSetComposition(ts []types.Transaction
 }
 
 // handleConflicts detects whether the conflicts in the transaction pool are
-// legal children of the new transaction pool set or not.
-func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+// legal children of the new transaction pool set or not. If validateOnly is
+// set, the transaction pool is not modified.
+func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID, txnFn func([]types.Transaction) (modules.ConsensusChange, error), validateOnly bool) error {
 	// Create a list of all the transaction ids that compose the set of
 	// conflicts.
 	conflictMap := make(map[types.TransactionID]TransactionSetID)
@@ -142,7 +150,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 				conflicts = append(conflicts, conflict)
 			}
 		}
-		return tp.handleConflicts(dedupSet, conflicts, txnFn)
+		return tp.handleConflicts(dedupSet, conflicts, txnFn, validateOnly)
 	}
 
 	// Merge all of the conflict sets with the input set (input set goes last
@@ -191,6 +199,9 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	if err != nil {
 		return modules.NewConsensusConflict("provided transaction set has prereqs, but is still invalid: " + err.Error())
 	}
+	if validateOnly {
+		return nil
+	}
 
 	// Remove the conflicts from the transaction pool.
 	for conflict := range supersetMap {
@@ -229,8 +240,9 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 }
 
 // acceptTransactionSet verifies that a transaction set is allowed to be in the
-// transaction pool, and then adds it to the transaction pool.
-func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+// transaction pool, and then adds it to the transaction pool. If validateOnly
+// is set, the set is verified but the transaction pool is not modified.
+func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error), validateOnly bool) error {
 	if len(ts) == 0 {
 		return errEmptySet
 	}
@@ -284,12 +296,15 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 		}
 	}
 	if len(conflicts) > 0 {
-		return tp.handleConflicts(ts, conflicts, txnFn)
+		return tp.handleConflicts(ts, conflicts, txnFn, validateOnly)
 	}
 	cc, err := txnFn(ts)
 	if err != nil {
 		return modules.NewConsensusConflict("provided transaction set is standalone and invalid: " + err.Error())
 	}
+	if validateOnly {
+		return nil
+	}
 
 	// Add the transaction set to the pool.
 	setID := TransactionSetID(crypto.HashObject(ts))
@@ -323,28 +338,299 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction, txnFn fu
 //
 // TODO: Break into component sets when the set gets accepted.
 func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
-	// assert on consensus set to get special method
-	cs, ok := tp.consensusSet.(interface {
-		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
+	return tp.managedAcceptTransactionSet(ts, true)
+}
+
+// AcceptTransactionSetNoBroadcast adds a transaction set to the unconfirmed
+// set of transactions without relaying it to connected peers. The set can be
+// relayed later using Broadcast. If the set is included in a block mined by
+// this node, it is propagated to the network as part of the block.
+func (tp *TransactionPool) AcceptTransactionSetNoBroadcast(ts []types.Transaction) error {
+	return tp.managedAcceptTransactionSet(ts, false)
+}
+
+// ValidateTransactionSet checks whether a transaction set would be accepted
+// by the transaction pool, without adding it to the pool or relaying it.
+func (tp *TransactionPool) ValidateTransactionSet(ts []types.Transaction) error {
+	return tp.managedLockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		return tp.acceptTransactionSet(ts, txnFn, true)
 	})
-	if !ok {
-		return errors.New("consensus set does not support LockedTryTransactionSet method")
+}
+
+// ReplaceTransactions replaces the unconfirmed transactions with the given
+// ids, which must belong to the same transaction set, by replacements. The
+// replacements must spend every input of the originals and pay a higher total
+// miner fee. They take the place of the first original in its set, in the
+// order given, so the unconfirmed parents of the originals are kept. The
+// originals are reported as conflicted from then on. The new set is relayed
+// to peers, who replace the originals in their own pools by the same rules.
+func (tp *TransactionPool) ReplaceTransactions(ids []types.TransactionID, replacements []types.Transaction) error {
+	return tp.managedLockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		newSet, err := tp.replaceTransactions(ids, replacements, txnFn)
+		if err != nil {
+			return err
+		}
+		go tp.gateway.Broadcast("RelayTransactionSet", newSet, tp.gateway.Peers())
+		tp.updateSubscribersTransactions()
+		return nil
+	})
+}
+
+// replaceTransactions replaces the transactions with the given ids by
+// replacements, returning the transaction set that now holds the
+// replacements. If the new set is rejected, the pool is left unchanged.
+func (tp *TransactionPool) replaceTransactions(ids []types.TransactionID, replacements []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) ([]types.Transaction, error) {
+	if len(ids) == 0 || len(replacements) == 0 {
+		return nil, errEmptySet
+	}
+
+	// Find the set holding the originals.
+	replaced := make(map[types.TransactionID]struct{})
+	for _, id := range ids {
+		replaced[id] = struct{}{}
+	}
+	var setID TransactionSetID
+	var set []types.Transaction
+	for sid, tSet := range tp.transactionSets {
+		for _, txn := range tSet {
+			if _, ok := replaced[txn.ID()]; ok {
+				setID, set = sid, tSet
+				break
+			}
+		}
+		if set != nil {
+			break
+		}
+	}
+	var originals, remaining []types.Transaction
+	first := -1
+	for i, txn := range set {
+		if _, ok := replaced[txn.ID()]; ok {
+			originals = append(originals, txn)
+			if first < 0 {
+				first = i
+			}
+		} else {
+			remaining = append(remaining, txn)
+		}
+	}
+	if len(originals) != len(replaced) {
+		return nil, errReplaceUnknown
+	}
+
+	// Collect the outputs created by the originals. Their ids change when
+	// the originals are replaced.
+	outputs := make(map[ObjectID]struct{})
+	for _, txn := range originals {
+		for i := range txn.SiacoinOutputs {
+			outputs[ObjectID(txn.SiacoinOutputID(uint64(i)))] = struct{}{}
+		}
+		for i := range txn.SiafundOutputs {
+			outputs[ObjectID(txn.SiafundOutputID(uint64(i)))] = struct{}{}
+		}
 	}
 
-	return cs.LockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+	// The replacements must conflict with every input that the originals
+	// spend from outside of themselves, so that the originals can never be
+	// confirmed alongside them, and must pay miners more than the originals
+	// do.
+	spent := make(map[ObjectID]struct{})
+	var originalFees, replacementFees types.Currency
+	for _, txn := range replacements {
+		for _, sci := range txn.SiacoinInputs {
+			spent[ObjectID(sci.ParentID)] = struct{}{}
+		}
+		for _, sfi := range txn.SiafundInputs {
+			spent[ObjectID(sfi.ParentID)] = struct{}{}
+		}
+		for _, fee := range txn.MinerFees {
+			replacementFees = replacementFees.Add(fee)
+		}
+	}
+	for _, txn := range originals {
+		var inputs []ObjectID
+		for _, sci := range txn.SiacoinInputs {
+			inputs = append(inputs, ObjectID(sci.ParentID))
+		}
+		for _, sfi := range txn.SiafundInputs {
+			inputs = append(inputs, ObjectID(sfi.ParentID))
+		}
+		for _, oid := range inputs {
+			_, internal := outputs[oid]
+			_, ok := spent[oid]
+			if !internal && !ok {
+				return nil, errReplacementMissingInput
+			}
+		}
+		for _, fee := range txn.MinerFees {
+			originalFees = originalFees.Add(fee)
+		}
+	}
+	if replacementFees.Cmp(originalFees) <= 0 {
+		return nil, errReplacementFeeTooLow
+	}
+
+	// Transactions that are not being replaced cannot spend the outputs of
+	// the originals.
+	for _, txn := range remaining {
+		for _, sci := range txn.SiacoinInputs {
+			if _, ok := outputs[ObjectID(sci.ParentID)]; ok {
+				return nil, errReplacementHasChildren
+			}
+		}
+		for _, sfi := range txn.SiafundInputs {
+			if _, ok := outputs[ObjectID(sfi.ParentID)]; ok {
+				return nil, errReplacementHasChildren
+			}
+		}
+	}
+
+	// Remove the original set from the pool and add the new set in its
+	// place, restoring the original set if the new set is rejected.
+	newSet := append(append(append([]types.Transaction(nil), remaining[:first]...), replacements...), remaining[first:]...)
+	diffs := tp.transactionSetDiffs[setID]
+	var objects []ObjectID
+	for oid, sid := range tp.knownObjects {
+		if sid == setID {
+			objects = append(objects, oid)
+			delete(tp.knownObjects, oid)
+		}
+	}
+	setSize := len(encoding.Marshal(set))
+	delete(tp.transactionSets, setID)
+	delete(tp.transactionSetDiffs, setID)
+	tp.transactionListSize -= setSize
+	if err := tp.acceptTransactionSet(newSet, txnFn, false); err != nil {
+		tp.transactionSets[setID] = set
+		tp.transactionSetDiffs[setID] = diffs
+		for _, oid := range objects {
+			tp.knownObjects[oid] = setID
+		}
+		tp.transactionListSize += setSize
+		return nil, err
+	}
+	for id := range replaced {
+		delete(tp.transactionHeights, id)
+		tp.conflictedTransactions[id] = tp.blockHeight
+	}
+	return newSet, nil
+}
+
+// acceptReplacement accepts a transaction set that double spends
+// transactions in the pool because it replaces them, such as a set relayed by
+// a peer after ReplaceTransactions. The transactions of ts that spend inputs
+// of transactions in the pool replace those transactions, following the
+// rules of replaceTransactions, and the rest of ts is then accepted as usual.
+// If ts does not replace any transaction, errNoReplacement is returned.
+func (tp *TransactionPool) acceptReplacement(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+	// Find the transaction in the pool that spends each input.
+	pooled := make(map[types.TransactionID]struct{})
+	spenders := make(map[ObjectID]types.TransactionID)
+	for _, set := range tp.transactionSets {
+		for _, txn := range set {
+			id := txn.ID()
+			pooled[id] = struct{}{}
+			for _, sci := range txn.SiacoinInputs {
+				spenders[ObjectID(sci.ParentID)] = id
+			}
+			for _, sfi := range txn.SiafundInputs {
+				spenders[ObjectID(sfi.ParentID)] = id
+			}
+		}
+	}
+
+	// A new transaction that spends an input of a transaction in the pool
+	// replaces that transaction.
+	var ids []types.TransactionID
+	var replacements []types.Transaction
+	replaced := make(map[types.TransactionID]struct{})
+	for _, txn := range ts {
+		if _, ok := pooled[txn.ID()]; ok || tp.transactionConfirmed(tp.dbTx, txn.ID()) {
+			continue
+		}
+		var inputs []ObjectID
+		for _, sci := range txn.SiacoinInputs {
+			inputs = append(inputs, ObjectID(sci.ParentID))
+		}
+		for _, sfi := range txn.SiafundInputs {
+			inputs = append(inputs, ObjectID(sfi.ParentID))
+		}
+		replacement := false
+		for _, oid := range inputs {
+			id, ok := spenders[oid]
+			if !ok {
+				continue
+			}
+			replacement = true
+			if _, ok := replaced[id]; !ok {
+				replaced[id] = struct{}{}
+				ids = append(ids, id)
+			}
+		}
+		if replacement {
+			replacements = append(replacements, txn)
+		}
+	}
+	if len(ids) == 0 {
+		return errNoReplacement
+	}
+	if _, err := tp.replaceTransactions(ids, replacements, txnFn); err != nil {
+		return err
+	}
+
+	// Accept the transactions of ts that were not part of the replacement,
+	// such as the children of the replacements.
+	err := tp.acceptTransactionSet(ts, txnFn, false)
+	if err == modules.ErrDuplicateTransactionSet {
+		return nil
+	}
+	return err
+}
+
+// managedAcceptTransactionSet adds a transaction set to the unconfirmed set
+// of transactions, relaying it to connected peers if broadcast is set. A set
+// that double spends transactions in the pool is accepted if it is a valid
+// replacement of them.
+func (tp *TransactionPool) managedAcceptTransactionSet(ts []types.Transaction, broadcast bool) error {
+	return tp.managedLockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
 		tp.mu.Lock()
 		defer tp.mu.Unlock()
-		err := tp.acceptTransactionSet(ts, txnFn)
+		err := tp.acceptTransactionSet(ts, txnFn, false)
+		if _, conflict := err.(modules.ConsensusConflict); conflict {
+			if replaceErr := tp.acceptReplacement(ts, txnFn); replaceErr != errNoReplacement {
+				err = replaceErr
+			}
+		}
 		if err != nil {
 			return err
 		}
-		go tp.gateway.Broadcast("RelayTransactionSet", ts, tp.gateway.Peers())
+		if broadcast {
+			go tp.gateway.Broadcast("RelayTransactionSet", ts, tp.gateway.Peers())
+		}
 		// Notify subscribers of an accepted transaction set
 		tp.updateSubscribersTransactions()
 		return nil
 	})
 }
 
+// managedLockedTryTransactionSet calls fn while the consensus set is locked,
+// providing a function that validates transaction sets against the current
+// consensus state.
+func (tp *TransactionPool) managedLockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error {
+	// assert on consensus set to get special method
+	cs, ok := tp.consensusSet.(interface {
+		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
+	})
+	if !ok {
+		return errors.New("consensus set does not support LockedTryTransactionSet method")
+	}
+	return cs.LockedTryTransactionSet(fn)
+}
+
 // relayTransactionSet is an RPC that accepts a transaction set from a peer. If
 // the accept is successful, the transaction will be relayed to the gateway's
 // other peers.
@@ -365,7 +651,7 @@ func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
 	}()
 
 	var ts []types.Transaction
-	err = encoding.ReadObject(conn, &ts, types.BlockSizeLimit)
+	err = encoding.ReadObject(conn, &ts, tp.consensusSet.BlockSizeLimit())
 	if err != nil {
 		return err
 	}
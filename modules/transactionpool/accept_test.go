@@ -2,6 +2,7 @@ package transactionpool
 
 import (
 	"testing"
+	"time"
 
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -58,6 +59,65 @@ func TestAcceptTransactionSet(t *testing.T) {
 	}
 }
 
+// TestValidateAndAcceptNoBroadcast checks that a transaction set can be
+// validated without being added to the pool, and added to the pool without
+// being broadcast, and that a set added without broadcasting is still mined.
+func TestValidateAndAcceptNoBroadcast(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Build a transaction set without submitting it.
+	b := tpt.wallet.StartTransaction()
+	fee := types.SiacoinPrecision.Mul64(3)
+	err = b.FundSiacoins(fee.Add(types.NewCurrency64(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.AddMinerFee(fee)
+	b.AddSiacoinOutput(types.SiacoinOutput{Value: types.NewCurrency64(100)})
+	txns, err := b.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Validating the set should not add it to the pool.
+	if err := tpt.tpool.ValidateTransactionSet(txns); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != 0 {
+		t.Fatal("validating a transaction set added it to the pool")
+	}
+
+	// Accepting the set without broadcasting should add it to the pool.
+	if err := tpt.tpool.AcceptTransactionSetNoBroadcast(txns); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.transactionSets) != 1 {
+		t.Fatal("transaction set was not added to the pool")
+	}
+	if err := tpt.tpool.ValidateTransactionSet(txns); err != modules.ErrDuplicateTransactionSet {
+		t.Fatal("expected ErrDuplicateTransactionSet, got", err)
+	}
+
+	// The set should be included in the next block.
+	block, _ := tpt.miner.FindBlock()
+	if len(block.Transactions) == 0 {
+		t.Fatal("transaction set accepted without broadcasting was not mined")
+	}
+	if err := tpt.cs.AcceptBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("transaction pool was not emptied after mining a block")
+	}
+}
+
 // TestConflictingTransactionSets tries to add two transaction sets
 // to the transaction pool that are each legal individually, but double spend
 // an output.
@@ -752,3 +812,85 @@ func TestPartialConfirmationWeave(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestAcceptReplacement checks that a replacement relayed by a peer replaces
+// the original transactions in the pool, even though it double spends them.
+func TestAcceptReplacement(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+	tpt2, err := blankTpoolTester(t.Name() + "-tpt2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt2.Close()
+
+	// connect the testers and wait for them to have the same current block
+	err = tpt2.gateway.Connect(tpt.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	success := false
+	for start := time.Now(); time.Since(start) < time.Minute; time.Sleep(time.Millisecond * 100) {
+		if tpt.cs.CurrentBlock().ID() == tpt2.cs.CurrentBlock().ID() {
+			success = true
+			break
+		}
+	}
+	if !success {
+		t.Fatal("testers did not have the same block height after one minute")
+	}
+
+	// Send a transaction and wait for it to reach the second pool.
+	txns, err := tpt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(1000), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := txns[len(txns)-1]
+	success = false
+	for start := time.Now(); time.Since(start) < time.Minute; time.Sleep(time.Millisecond * 100) {
+		if _, _, exists := tpt2.tpool.Transaction(original.ID()); exists {
+			success = true
+			break
+		}
+	}
+	if !success {
+		t.Fatal("transaction was not relayed to the second pool")
+	}
+
+	// Replace the transaction with one that pays a higher fee. The
+	// replacement should take the place of the original in both pools.
+	var fee types.Currency
+	for _, f := range original.MinerFees {
+		fee = fee.Add(f)
+	}
+	bumped, err := tpt.wallet.BumpFee(original.ID(), fee.Add(types.SiacoinPrecision))
+	if err != nil {
+		t.Fatal(err)
+	}
+	success = false
+	for start := time.Now(); time.Since(start) < time.Minute; time.Sleep(time.Millisecond * 100) {
+		if _, _, exists := tpt2.tpool.Transaction(bumped.ID()); exists {
+			success = true
+			break
+		}
+	}
+	if !success {
+		t.Fatal("replacement was not accepted by the second pool")
+	}
+	if _, _, exists := tpt2.tpool.Transaction(original.ID()); exists {
+		t.Fatal("original is still in the second pool")
+	}
+	status, err := tpt2.tpool.TransactionStatus(original.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != modules.TransactionStateConflicted {
+		t.Fatal("original should have been replaced, state is", status.State)
+	}
+}
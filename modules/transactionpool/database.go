@@ -65,6 +65,25 @@ func (tp *TransactionPool) deleteTransaction(tx *bolt.Tx, id types.TransactionID
 	return tx.Bucket(bucketConfirmedTransactions).Delete(id[:])
 }
 
+// getConfirmationHeight returns the height at which a confirmed transaction
+// was added to the blockchain. The bool is false if the transaction has not
+// been confirmed.
+func (tp *TransactionPool) getConfirmationHeight(tx *bolt.Tx, id types.TransactionID) (types.BlockHeight, bool) {
+	heightBytes := tx.Bucket(bucketConfirmedTransactions).Get(id[:])
+	if heightBytes == nil {
+		return 0, false
+	}
+	// Transactions confirmed by older versions of the transaction pool were
+	// stored without a height.
+	var height types.BlockHeight
+	if len(heightBytes) > 0 {
+		if err := encoding.Unmarshal(heightBytes, &height); err != nil {
+			tp.log.Println("ERROR: could not decode confirmation height:", err)
+		}
+	}
+	return height, true
+}
+
 // getBlockHeight returns the most recent block height from the database.
 func (tp *TransactionPool) getBlockHeight(tx *bolt.Tx) (bh types.BlockHeight, err error) {
 	err = encoding.Unmarshal(tx.Bucket(bucketBlockHeight).Get(fieldBlockHeight), &bh)
@@ -118,7 +137,8 @@ func (tp *TransactionPool) putRecentConsensusChange(tx *bolt.Tx, cc modules.Cons
 	return tx.Bucket(bucketRecentConsensusChange).Put(fieldRecentConsensusChange, cc[:])
 }
 
-// putTransaction adds a transaction to the list of confirmed transactions.
-func (tp *TransactionPool) putTransaction(tx *bolt.Tx, id types.TransactionID) error {
-	return tx.Bucket(bucketConfirmedTransactions).Put(id[:], []byte{})
+// putTransaction adds a transaction to the list of confirmed transactions,
+// along with the height at which it was confirmed.
+func (tp *TransactionPool) putTransaction(tx *bolt.Tx, id types.TransactionID, height types.BlockHeight) error {
+	return tx.Bucket(bucketConfirmedTransactions).Put(id[:], encoding.Marshal(height))
 }
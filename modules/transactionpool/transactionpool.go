@@ -52,6 +52,11 @@ type (
 		transactionSetDiffs map[TransactionSetID]*modules.ConsensusChange
 		transactionListSize int
 
+		// conflictedTransactions tracks transactions that were dropped from
+		// the pool after a consensus change made them invalid, along with the
+		// height at which they were dropped.
+		conflictedTransactions map[types.TransactionID]types.BlockHeight
+
 		// Variables related to the blockchain.
 		blockHeight     types.BlockHeight
 		recentMedians   []types.Currency
@@ -94,6 +99,8 @@ func New(cs modules.ConsensusSet, g modules.Gateway, persistDir string) (*Transa
 		transactionSets:     make(map[TransactionSetID][]types.Transaction),
 		transactionSetDiffs: make(map[TransactionSetID]*modules.ConsensusChange),
 
+		conflictedTransactions: make(map[types.TransactionID]types.BlockHeight),
+
 		persistDir: persistDir,
 	}
 
@@ -256,6 +263,36 @@ func (tp *TransactionPool) Transaction(id types.TransactionID) (types.Transactio
 	return txn, necessaryParents, exists
 }
 
+// TransactionStatus reports whether the transaction with the provided id is
+// pending in the transaction pool, confirmed on the blockchain, or was dropped
+// from the pool because it conflicts with the current consensus set.
+func (tp *TransactionPool) TransactionStatus(id types.TransactionID) (modules.TransactionStatus, error) {
+	if err := tp.tg.Add(); err != nil {
+		return modules.TransactionStatus{}, err
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	if height, confirmed := tp.getConfirmationHeight(tp.dbTx, id); confirmed {
+		return modules.TransactionStatus{
+			State:              modules.TransactionStateConfirmed,
+			ConfirmationHeight: height,
+		}, nil
+	}
+	for _, tSet := range tp.transactionSets {
+		for _, txn := range tSet {
+			if txn.ID() == id {
+				return modules.TransactionStatus{State: modules.TransactionStatePending}, nil
+			}
+		}
+	}
+	if _, conflicted := tp.conflictedTransactions[id]; conflicted {
+		return modules.TransactionStatus{State: modules.TransactionStateConflicted}, nil
+	}
+	return modules.TransactionStatus{State: modules.TransactionStateUnknown}, nil
+}
+
 // Broadcast broadcasts a transaction set to all of the transaction pool's
 // peers.
 func (tp *TransactionPool) Broadcast(ts []types.Transaction) {
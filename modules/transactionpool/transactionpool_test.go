@@ -210,6 +210,66 @@ func TestGetTransaction(t *testing.T) {
 	}
 }
 
+// TestTransactionStatus checks that the transaction pool correctly reports the
+// status of a transaction as it moves from unknown to pending to confirmed.
+func TestTransactionStatus(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	txnBuilder := tpt.wallet.StartTransaction()
+	err = txnBuilder.FundSiacoins(types.NewCurrency64(35e6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddSiacoinOutput(types.SiacoinOutput{Value: types.NewCurrency64(35e6)})
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := txnSet[len(txnSet)-1].ID()
+
+	status, err := tpt.tpool.TransactionStatus(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != modules.TransactionStateUnknown {
+		t.Fatal("expected unknown transaction, got", status.State)
+	}
+
+	err = tpt.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = tpt.tpool.TransactionStatus(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != modules.TransactionStatePending {
+		t.Fatal("expected pending transaction, got", status.State)
+	}
+
+	_, err = tpt.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = tpt.tpool.TransactionStatus(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != modules.TransactionStateConfirmed {
+		t.Fatal("expected confirmed transaction, got", status.State)
+	}
+	if status.ConfirmationHeight != tpt.cs.Height() {
+		t.Fatal("wrong confirmation height:", status.ConfirmationHeight, tpt.cs.Height())
+	}
+}
+
 // TestBlockFeeEstimation checks that the fee estimation algorithm is reasonably
 // on target when the tpool is relying on blockchain based fee estimation.
 func TestFeeEstimation(t *testing.T) {
@@ -197,7 +197,8 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			tp.blockHeight++
 		}
 		for _, txn := range block.Transactions {
-			err := tp.putTransaction(tp.dbTx, txn.ID())
+			delete(tp.conflictedTransactions, txn.ID())
+			err := tp.putTransaction(tp.dbTx, txn.ID(), tp.blockHeight)
 			if err != nil {
 				tp.log.Println("ERROR: could not add a transaction:", err)
 			}
@@ -232,7 +233,7 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			totalSize += sizeSum
 		}
 		// Add an extra zero-fee tranasction for any unused block space.
-		remaining := int(types.BlockSizeLimit) - totalSize
+		remaining := int(tp.consensusSet.BlockSizeLimit()) - totalSize
 		fees = append(fees, feeSummary{
 			fee:  types.ZeroCurrency,
 			size: remaining, // fine if remaining is zero.
@@ -247,7 +248,7 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			// Instead of grabbing the full median, look at the 75%-ile. It's
 			// going to be cheaper than the 50%-ile, but it still got into a
 			// block.
-			if uint64(progress) > types.BlockSizeLimit/4 {
+			if uint64(progress) > tp.consensusSet.BlockSizeLimit()/4 {
 				tp.recentMedians = append(tp.recentMedians, fees[i].fee)
 				break
 			}
@@ -333,6 +334,15 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 		unconfirmedSets[i] = validTxns
 	}
 
+	// prune conflicted transactions older than maxTxnAge, after which they
+	// are reported as unknown. A reorg can leave the block height below the
+	// height at which a transaction conflicted.
+	for id, height := range tp.conflictedTransactions {
+		if tp.blockHeight > height && tp.blockHeight-height > maxTxnAge {
+			delete(tp.conflictedTransactions, id)
+		}
+	}
+
 	// Scan through the reverted blocks and re-add any transactions that got
 	// reverted to the tpool.
 	for i := len(cc.RevertedBlocks) - 1; i >= 0; i-- {
@@ -346,7 +356,7 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 			}
 
 			// Try adding the transaction back into the transaction pool.
-			tp.acceptTransactionSet([]types.Transaction{txn}, cc.TryTransactionSet) // Error is ignored.
+			tp.acceptTransactionSet([]types.Transaction{txn}, cc.TryTransactionSet, false) // Error is ignored.
 		}
 	}
 
@@ -366,11 +376,14 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// more rules need to be put in place.
 	for _, set := range unconfirmedSets {
 		for _, txn := range set {
-			err := tp.acceptTransactionSet([]types.Transaction{txn}, cc.TryTransactionSet)
+			err := tp.acceptTransactionSet([]types.Transaction{txn}, cc.TryTransactionSet, false)
 			if err != nil {
 				// The transaction is no longer valid, delete it from the
 				// heights map to prevent a memory leak.
 				delete(tp.transactionHeights, txn.ID())
+				if _, ok := err.(modules.ConsensusConflict); ok {
+					tp.conflictedTransactions[txn.ID()] = tp.blockHeight
+				}
 			}
 		}
 	}
@@ -3,10 +3,12 @@ package modules
 import (
 	"bytes"
 	"errors"
+	"io"
 
 	"github.com/NebulousLabs/entropy-mnemonics"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -23,6 +25,32 @@ const (
 	WalletDir = "wallet"
 )
 
+const (
+	// FundLargestFirst funds transactions using the largest available outputs
+	// first, minimizing the number of inputs in the transaction. This is the
+	// default strategy.
+	FundLargestFirst FundingStrategy = iota
+
+	// FundConsolidateDust funds transactions using the smallest available
+	// outputs first, provided that the amount can be covered without
+	// exceeding the wallet's input budget. Otherwise the builder falls back to
+	// FundLargestFirst. Consolidating small outputs into a spend reduces the
+	// fragmentation of the wallet, and therefore the fees paid in the future.
+	FundConsolidateDust
+)
+
+const (
+	// WalletEventReorg is the type of a WalletEvent that is emitted when a
+	// reorg reverts confirmed wallet transactions.
+	WalletEventReorg WalletEventType = "reorg"
+)
+
+const (
+	// DefaultWalletAccount is the account that holds all of the wallet's
+	// funds that are not held by an account created with CreateAccount.
+	DefaultWalletAccount WalletAccountID = 0
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -41,9 +69,43 @@ var (
 	// ErrLowBalance is returned if the wallet does not have enough funds to
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
+
+	// ErrNonStandardMessageKey is returned when signing or verifying a
+	// message with an address that is not controlled by a single ed25519
+	// key.
+	ErrNonStandardMessageKey = errors.New("messages can only be signed by addresses with a single ed25519 key")
+
+	// ErrUnlockConditionsMismatch is returned when verifying a message with
+	// unlock conditions that do not match the address of the signer.
+	ErrUnlockConditionsMismatch = errors.New("unlock conditions do not match the address")
+
+	// SpecifierSignedMessage is prepended to a message before it is hashed and
+	// signed. The prefix ensures that a message signature can never be
+	// replayed as a transaction signature.
+	SpecifierSignedMessage = types.Specifier{'S', 'i', 'g', 'n', 'e', 'd', ' ', 'M', 'e', 's', 's', 'a', 'g', 'e'}
 )
 
 type (
+	// FundingStrategy determines how the transaction builder selects outputs
+	// when funding a transaction with siacoins.
+	FundingStrategy int
+
+	// WalletAccountID identifies an account of the wallet.
+	WalletAccountID uint64
+
+	// WalletAccount is a logically separate set of addresses within the
+	// wallet. Each account derives its addresses from its own path of the
+	// primary seed, and transactions funded by an account only spend that
+	// account's outputs. Accounts only separate siacoins; siafunds are held
+	// by the default account.
+	WalletAccount struct {
+		ID        WalletAccountID `json:"id"`
+		Name      string          `json:"name"`
+		Addresses uint64          `json:"addresses"`
+
+		ConfirmedSiacoinBalance types.Currency `json:"confirmedsiacoinbalance"`
+	}
+
 	// Seed is cryptographic entropy that is used to derive spendable wallet
 	// addresses.
 	Seed [crypto.EntropySize]byte
@@ -90,6 +152,10 @@ type (
 	// Because of the block subsidy, a block is considered as a transaction.
 	// Since there is technically no transaction id for the block subsidy, the
 	// block id is used instead.
+	//
+	// Note is the note that the user attached to the transaction with
+	// SetTransactionNote. The wallet stores notes separately, so the note is
+	// not part of the encoding of a ProcessedTransaction.
 	ProcessedTransaction struct {
 		Transaction           types.Transaction   `json:"transaction"`
 		TransactionID         types.TransactionID `json:"transactionid"`
@@ -98,6 +164,8 @@ type (
 
 		Inputs  []ProcessedInput  `json:"inputs"`
 		Outputs []ProcessedOutput `json:"outputs"`
+
+		Note string `json:"note"`
 	}
 
 	// TransactionBuilder is used to construct custom transactions. A transaction
@@ -131,9 +199,27 @@ type (
 		// failed.
 		FundSiafunds(amount types.Currency) error
 
+		// SetFundingStrategy sets the strategy used by 'FundSiacoins' to
+		// select the outputs that fund the transaction. The default is
+		// FundLargestFirst.
+		SetFundingStrategy(FundingStrategy)
+
+		// SetFundingAccount sets the account whose outputs are used by
+		// 'FundSiacoins' and 'FundSiafunds' to fund the transaction. Refunds
+		// and siafund claims are sent back to the same account. The default
+		// is DefaultWalletAccount.
+		SetFundingAccount(WalletAccountID)
+
 		// AddParents adds a set of parents to the transaction.
 		AddParents([]types.Transaction)
 
+		// MergeTransactionSet merges an independently built transaction set
+		// into the transaction. The last transaction of the set is combined
+		// with the transaction, and the remaining transactions are added as
+		// parents unless they are already present. The covered fields of the
+		// merged signatures are adjusted to the combined transaction.
+		MergeTransactionSet([]types.Transaction) error
+
 		// AddMinerFee adds a miner fee to the transaction, returning the index
 		// of the miner fee within the transaction.
 		AddMinerFee(fee types.Currency) uint64
@@ -144,7 +230,9 @@ type (
 		AddSiacoinInput(types.SiacoinInput) uint64
 
 		// AddSiacoinOutput adds a siacoin output to the transaction, returning
-		// the index of the siacoin output within the transaction.
+		// the index of the siacoin output within the transaction. Outputs keep
+		// the order in which they are added, and the id of an output is
+		// derived from its index.
 		AddSiacoinOutput(types.SiacoinOutput) uint64
 
 		// AddFileContract adds a file contract to the transaction, returning
@@ -198,6 +286,12 @@ type (
 		// transaction should be dropped.
 		Sign(wholeTransaction bool) ([]types.Transaction, error)
 
+		// Export returns the transaction set, with all parents prepended to
+		// the transaction. An error is returned if any fields covered by the
+		// signatures added in 'Sign' have been modified since signing, as the
+		// signatures would no longer be valid.
+		Export() ([]types.Transaction, error)
+
 		// View returns the incomplete transaction along with all of its
 		// parents.
 		View() (txn types.Transaction, parents []types.Transaction)
@@ -289,6 +383,16 @@ type (
 		// as a primary seed.
 		// LoadBackup(masterKey, backupMasterKey crypto.TwofishKey, string) error
 
+		// ExportKeys writes every spendable key in the wallet, including keys
+		// that cannot be regenerated from a seed, to the provided writer,
+		// encrypted under a passphrase.
+		ExportKeys(io.Writer, string) error
+
+		// ImportKeys loads a set of keys written by ExportKeys into the
+		// wallet. The master key is used to encrypt the keys before saving
+		// them to disk.
+		ImportKeys(crypto.TwofishKey, io.Reader, string) error
+
 		// Load033xWallet will load a version 0.3.3.x wallet from disk and add all of
 		// the keys in the wallet as unseeded keys.
 		Load033xWallet(crypto.TwofishKey, string) error
@@ -340,6 +444,12 @@ type (
 		// refund transactions.
 		ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siacoinClaimBalance types.Currency)
 
+		// SpendableBalance returns the confirmed siacoin balance of the
+		// wallet, excluding any outputs that are timelocked beyond the given
+		// height. Such outputs are counted by ConfirmedBalance but cannot be
+		// used to fund transactions yet.
+		SpendableBalance(height types.BlockHeight) types.Currency
+
 		// UnconfirmedBalance returns the unconfirmed balance of the wallet.
 		// Outgoing funds and incoming funds are reported separately. Refund
 		// outputs are included, meaning that sending a single coin to
@@ -355,6 +465,14 @@ type (
 		// transactions related to a given address.
 		AddressUnconfirmedTransactions(types.UnlockHash) []ProcessedTransaction
 
+		// IsReused returns true if the address has received funds in more
+		// than one confirmed transaction.
+		IsReused(types.UnlockHash) bool
+
+		// ReusedAddresses returns every address of the wallet that has
+		// received funds in more than one confirmed transaction.
+		ReusedAddresses() []types.UnlockHash
+
 		// Transaction returns the transaction with the given id. The bool
 		// indicates whether the transaction is in the wallet database. The
 		// wallet only stores transactions that are related to the wallet.
@@ -377,12 +495,24 @@ type (
 		// blockchain.
 		Rescanning() bool
 
+		// Events returns the most recent events of the wallet, oldest first.
+		Events() []WalletEvent
+
 		// Settings returns the Wallet's current settings.
 		Settings() WalletSettings
 
 		// SetSettings sets the Wallet's settings.
 		SetSettings(WalletSettings)
 
+		// FeePolicy returns the policy used to choose the fees of
+		// transactions created by the wallet.
+		FeePolicy() WalletFeePolicy
+
+		// SetFeePolicy sets the policy used to choose the fees of
+		// transactions created by the wallet. A change threshold higher than
+		// the fee of a large transaction is rejected.
+		SetFeePolicy(WalletFeePolicy) error
+
 		// StartTransaction is a convenience method that calls
 		// RegisterTransaction(types.Transaction{}, nil)
 		StartTransaction() TransactionBuilder
@@ -393,9 +523,72 @@ type (
 		// are also returned to the caller.
 		SendSiacoins(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
 
-		// SendSiacoinsMulti sends coins to multiple addresses.
+		// SendSiacoinsFromAccount is like SendSiacoins, but only spends the
+		// outputs of the given account.
+		SendSiacoinsFromAccount(account WalletAccountID, amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// SendSiacoinsMulti sends coins to multiple addresses. The outputs
+		// are added to the last transaction of the returned set in the order
+		// given, so that output i has that transaction's SiacoinOutputID(i).
 		SendSiacoinsMulti(outputs []types.SiacoinOutput) ([]types.Transaction, error)
 
+		// Sweep sends the entire spendable siacoin balance of the wallet,
+		// minus the transaction fee, to an address in a single transaction
+		// with no change output. The transaction is automatically given to
+		// the transaction pool, and is also returned to the caller.
+		Sweep(dest types.UnlockHash) (types.Transaction, error)
+
+		// BumpFee replaces a transaction that is waiting in the transaction
+		// pool with one that spends the same inputs but pays newFee in miner
+		// fees, taking the increase from the transaction's change. The
+		// replacement is submitted to the transaction pool and returned.
+		BumpFee(id types.TransactionID, newFee types.Currency) (types.Transaction, error)
+
+		// LockOutput reserves an output of the wallet, so that it is not used
+		// to fund transactions until it is unlocked with UnlockOutput. Locks
+		// are not persisted, and are released when the wallet restarts or
+		// the output is spent.
+		LockOutput(id types.OutputID) error
+
+		// UnlockOutput releases an output that was reserved with LockOutput.
+		UnlockOutput(id types.OutputID) error
+
+		// ReconcileSpentOutputs removes the outputs that no longer lock any
+		// funds from the set of outputs recently spent by the wallet, and
+		// returns how many were removed.
+		ReconcileSpentOutputs() (int, error)
+
+		// SetTransactionNote attaches a note, such as "rent payment", to a
+		// confirmed or unconfirmed transaction of the wallet. The note is
+		// reported in the transaction's ProcessedTransaction. An empty note
+		// removes the transaction's note.
+		SetTransactionNote(txid types.TransactionID, note string) error
+
+		// CreateAccount creates a new account with the given name, deriving
+		// its addresses from the primary seed. The ID of the account is
+		// returned.
+		CreateAccount(name string) (WalletAccountID, error)
+
+		// Accounts returns the accounts of the wallet, including the default
+		// account, along with their confirmed balances.
+		Accounts() ([]WalletAccount, error)
+
+		// NextAccountAddress returns an address of the given account that is
+		// ready to receive siacoins.
+		NextAccountAddress(account WalletAccountID) (types.UnlockConditions, error)
+
+		// SchedulePayment schedules a payment of amount to dest every
+		// interval blocks, starting interval blocks from now, until the
+		// payment is canceled. The ID of the scheduled payment is returned.
+		SchedulePayment(dest types.UnlockHash, amount types.Currency, interval types.BlockHeight) (string, error)
+
+		// ScheduledPayments returns the payments that are currently
+		// scheduled.
+		ScheduledPayments() ([]ScheduledPayment, error)
+
+		// CancelScheduledPayment cancels a scheduled payment.
+		CancelScheduledPayment(id string) error
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -405,20 +598,125 @@ type (
 		// DustThreshold returns the quantity per byte below which a Currency is
 		// considered to be Dust.
 		DustThreshold() types.Currency
+
+		// SignMessage signs an arbitrary message with the key of the provided
+		// address, proving that the wallet controls the address. The
+		// signature can be checked with VerifyMessage.
+		SignMessage(uh types.UnlockHash, message []byte) (crypto.Signature, error)
+
+		// UnlockConditions returns the unlock conditions of an address that
+		// belongs to the wallet.
+		UnlockConditions(uh types.UnlockHash) (types.UnlockConditions, error)
+	}
+
+	// ScheduledPayment is a payment that the wallet makes every Interval
+	// blocks until it is canceled. If a payment cannot be made, for example
+	// because the wallet is locked or has insufficient funds, it remains due
+	// and is retried at the next block; LastError describes why it failed.
+	ScheduledPayment struct {
+		ID           string            `json:"id"`
+		Destination  types.UnlockHash  `json:"destination"`
+		Amount       types.Currency    `json:"amount"`
+		Interval     types.BlockHeight `json:"interval"`
+		NextHeight   types.BlockHeight `json:"nextheight"`
+		PaymentsMade uint64            `json:"paymentsmade"`
+		LastError    string            `json:"lasterror"`
+	}
+
+	// WalletEventType identifies the kind of a WalletEvent.
+	WalletEventType string
+
+	// WalletEvent is a change to the wallet's transaction history that the
+	// user should be alerted to. Unconfirmed lists transactions that were
+	// confirmed before the event and are waiting in the transaction pool to
+	// be confirmed again. Reversed lists transactions that were confirmed
+	// before the event and are no longer valid, including miner payouts of
+	// reverted blocks; any payment received in them has been reversed.
+	WalletEvent struct {
+		Type        WalletEventType       `json:"type"`
+		Height      types.BlockHeight     `json:"height"`
+		ReorgDepth  types.BlockHeight     `json:"reorgdepth"`
+		Unconfirmed []types.TransactionID `json:"unconfirmed"`
+		Reversed    []types.TransactionID `json:"reversed"`
 	}
 
 	// WalletSettings control the behavior of the Wallet.
 	WalletSettings struct {
 		NoDefrag bool `json:"noDefrag"`
 	}
+
+	// FeeSpeed is the target confirmation speed of a transaction, which
+	// determines how much of the transaction pool's fee estimate is paid.
+	FeeSpeed string
+
+	// WalletFeePolicy controls the fees that the wallet adds to the
+	// transactions it creates. The fee per byte is chosen from the
+	// transaction pool's estimate according to Speed, but is never less than
+	// MinFee. Change below ChangeThreshold is added to the miner fee instead
+	// of being returned to the wallet as a dust output; a ChangeThreshold of
+	// zero uses the wallet's DustThreshold.
+	WalletFeePolicy struct {
+		MinFee          types.Currency `json:"minfee"`
+		Speed           FeeSpeed       `json:"speed"`
+		ChangeThreshold types.Currency `json:"changethreshold"`
+	}
+)
+
+const (
+	// FeeSpeedConservative pays the minimum estimated fee. The transaction
+	// may take several blocks to confirm when the network is congested.
+	FeeSpeedConservative FeeSpeed = "conservative"
+
+	// FeeSpeedNormal pays the maximum estimated fee, which should be enough
+	// for the transaction to be included in the next few blocks.
+	FeeSpeedNormal FeeSpeed = "normal"
+
+	// FeeSpeedFast pays a multiple of the maximum estimated fee, so that the
+	// transaction is preferred by miners even during sudden congestion.
+	FeeSpeedFast FeeSpeed = "fast"
 )
 
+// MarshalSia implements the encoding.SiaMarshaler interface. The note is not
+// encoded.
+func (pt ProcessedTransaction) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(pt.Transaction, pt.TransactionID,
+		pt.ConfirmationHeight, pt.ConfirmationTimestamp, pt.Inputs, pt.Outputs)
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (pt *ProcessedTransaction) UnmarshalSia(r io.Reader) error {
+	*pt = ProcessedTransaction{}
+	return encoding.NewDecoder(r).DecodeAll(&pt.Transaction, &pt.TransactionID,
+		&pt.ConfirmationHeight, &pt.ConfirmationTimestamp, &pt.Inputs, &pt.Outputs)
+}
+
 // CalculateWalletTransactionID is a helper function for determining the id of
 // a wallet transaction.
 func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) WalletTransactionID {
 	return WalletTransactionID(crypto.HashAll(tid, oid))
 }
 
+// SignedMessageHash returns the hash that is signed when signing an arbitrary
+// message. The message is prefixed with SpecifierSignedMessage.
+func SignedMessageHash(message []byte) crypto.Hash {
+	return crypto.HashAll(SpecifierSignedMessage, message)
+}
+
+// VerifyMessage checks that sig is a valid signature of message by the owner of
+// the address uh. The unlock conditions of the address are required, because
+// the public key cannot be recovered from the address alone.
+func VerifyMessage(uh types.UnlockHash, uc types.UnlockConditions, message []byte, sig crypto.Signature) error {
+	if uc.UnlockHash() != uh {
+		return ErrUnlockConditionsMismatch
+	}
+	if len(uc.PublicKeys) != 1 || uc.SignaturesRequired != 1 || uc.PublicKeys[0].Algorithm != types.SignatureEd25519 || len(uc.PublicKeys[0].Key) != crypto.PublicKeySize {
+		return ErrNonStandardMessageKey
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], uc.PublicKeys[0].Key)
+	return crypto.VerifyHash(SignedMessageHash(message), pk, sig)
+}
+
 // SeedToString converts a wallet seed to a human friendly string.
 func SeedToString(seed Seed, did mnemonics.DictionaryID) (string, error) {
 	fullChecksum := crypto.HashObject(seed)
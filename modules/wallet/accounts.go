@@ -0,0 +1,355 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/bolt"
+)
+
+const (
+	// defaultAccountName is the name reported for the default account.
+	defaultAccountName = "default"
+)
+
+var (
+	errAccountNameEmpty = errors.New("account name must be nonempty")
+	errAccountNameTaken = errors.New("an account with that name already exists")
+	errUnknownAccount   = errors.New("no account with that ID")
+
+	// specifierAccountSeed is used to derive the seed of an account from the
+	// primary seed.
+	specifierAccountSeed = types.Specifier{'a', 'c', 'c', 'o', 'u', 'n', 't', ' ', 's', 'e', 'e', 'd'}
+)
+
+// accountEntry is the persisted state of an account created with
+// CreateAccount.
+type accountEntry struct {
+	Name     string
+	Progress uint64
+}
+
+// accountKeyIndex identifies a key of an account in the account lookahead.
+type accountKeyIndex struct {
+	account modules.WalletAccountID
+	index   uint64
+}
+
+// accountSeed derives the seed of an account from the primary seed. Every
+// account has its own seed, so its addresses are distinct from those of the
+// primary seed and of every other account.
+func accountSeed(primarySeed modules.Seed, id modules.WalletAccountID) modules.Seed {
+	return modules.Seed(crypto.HashAll(primarySeed, specifierAccountSeed, id))
+}
+
+// integrateAccount generates n keys of an account, starting from index
+// start, and loads them into the wallet.
+func (w *Wallet) integrateAccount(id modules.WalletAccountID, start, n uint64) {
+	seed := accountSeed(w.primarySeed, id)
+	defer crypto.SecureWipe(seed[:])
+	for _, sk := range generateKeys(seed, start, n) {
+		uh := sk.UnlockConditions.UnlockHash()
+		w.keys[uh] = sk
+		w.accountAddrs[uh] = id
+		delete(w.accountLookahead, uh)
+	}
+}
+
+// regenerateAccountLookahead generates the future keys of an account whose
+// progress is 'progress', so that funds sent to addresses that were handed
+// out but never seen by the wallet, for example before the wallet was
+// restored from its seed, are found. The lookahead has the same size as the
+// lookahead of the primary seed.
+func (w *Wallet) regenerateAccountLookahead(id modules.WalletAccountID, progress uint64) {
+	start := progress
+	if end := w.accountLookaheadEnd[id]; end > start {
+		start = end
+	}
+	end := progress + maxLookahead(progress)
+	if start >= end {
+		return
+	}
+	seed := accountSeed(w.primarySeed, id)
+	defer crypto.SecureWipe(seed[:])
+	for i, sk := range generateKeys(seed, start, end-start) {
+		w.accountLookahead[sk.UnlockConditions.UnlockHash()] = accountKeyIndex{
+			account: id,
+			index:   start + uint64(i),
+		}
+	}
+	w.accountLookaheadEnd[id] = end
+}
+
+// initAccountLookahead generates the lookahead of every account, and of the
+// accountRecoveryLookahead account IDs after the last account. Accounts are
+// numbered in the order they are created, so watching the next IDs finds the
+// accounts of a wallet that was restored from its seed.
+func (w *Wallet) initAccountLookahead(accounts map[modules.WalletAccountID]accountEntry) {
+	var lastID modules.WalletAccountID
+	for id, acct := range accounts {
+		w.regenerateAccountLookahead(id, acct.Progress)
+		if id > lastID {
+			lastID = id
+		}
+	}
+	for i := modules.WalletAccountID(1); i <= accountRecoveryLookahead; i++ {
+		w.regenerateAccountLookahead(lastID+i, 0)
+	}
+}
+
+// uniqueAccountName returns name, altered if necessary to differ from the
+// names of the existing accounts.
+func uniqueAccountName(tx *bolt.Tx, name string) (string, error) {
+	names := map[string]bool{defaultAccountName: true}
+	err := dbForEachAccount(tx, func(_ modules.WalletAccountID, acct accountEntry) {
+		names[acct.Name] = true
+	})
+	if err != nil {
+		return "", err
+	}
+	unique := name
+	for i := 2; names[unique]; i++ {
+		unique = fmt.Sprintf("%v (%v)", name, i)
+	}
+	return unique, nil
+}
+
+// recoverAccounts creates the entries of the accounts up to and including id
+// that are missing from the database, which happens when funds are found at
+// an address of an account that was created before the wallet was restored
+// from its seed. The lookahead is extended to the account IDs after id.
+func (w *Wallet) recoverAccounts(tx *bolt.Tx, id modules.WalletAccountID) error {
+	for recovered := id; recovered > 0; recovered-- {
+		_, err := dbGetAccount(tx, recovered)
+		if err == nil {
+			break
+		} else if err != errNoKey {
+			return err
+		}
+		name, err := uniqueAccountName(tx, fmt.Sprintf("recovered account %v", recovered))
+		if err != nil {
+			return err
+		}
+		if err := dbPutAccount(tx, recovered, accountEntry{Name: name}); err != nil {
+			return err
+		}
+		w.log.Printf("INFO: recovered account %v", recovered)
+	}
+	for i := modules.WalletAccountID(1); i <= accountRecoveryLookahead; i++ {
+		w.regenerateAccountLookahead(id+i, 0)
+	}
+	return nil
+}
+
+// advanceAccountLookahead moves the keys of an account up to index from the
+// lookahead to the set of spendable keys, recovering the account if it is
+// unknown. Returns true if a blockchain rescan is required, either because
+// many keys were added or because an account was recovered, which extends
+// the lookahead to account IDs whose earlier outputs have not been seen.
+func (w *Wallet) advanceAccountLookahead(tx *bolt.Tx, id modules.WalletAccountID, index uint64) (bool, error) {
+	acct, err := dbGetAccount(tx, id)
+	recovered := err == errNoKey
+	if recovered {
+		if err := w.recoverAccounts(tx, id); err != nil {
+			return false, err
+		}
+		acct, err = dbGetAccount(tx, id)
+	}
+	if err != nil {
+		return false, err
+	}
+	if index < acct.Progress {
+		return recovered, nil
+	}
+
+	n := index + 1 - acct.Progress
+	w.integrateAccount(id, acct.Progress, n)
+	acct.Progress = index + 1
+	if err := dbPutAccount(tx, id, acct); err != nil {
+		return false, err
+	}
+	w.regenerateAccountLookahead(id, acct.Progress)
+	return recovered || n > lookaheadRescanThreshold, nil
+}
+
+// updateAccountLookahead uses a consensus change to update the progress of
+// any account whose lookahead contains an unlock hash of the change. Returns
+// true if a blockchain rescan is required.
+func (w *Wallet) updateAccountLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool, error) {
+	largest := make(map[modules.WalletAccountID]uint64)
+	see := func(uh types.UnlockHash) {
+		if aki, ok := w.accountLookahead[uh]; ok {
+			if index, seen := largest[aki.account]; !seen || aki.index > index {
+				largest[aki.account] = aki.index
+			}
+		}
+	}
+	for _, diff := range cc.SiacoinOutputDiffs {
+		see(diff.SiacoinOutput.UnlockHash)
+	}
+	for _, diff := range cc.SiafundOutputDiffs {
+		see(diff.SiafundOutput.UnlockHash)
+	}
+
+	// Advance the accounts in order, so that recovered accounts are numbered
+	// consistently.
+	ids := make([]modules.WalletAccountID, 0, len(largest))
+	for id := range largest {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var rescan bool
+	for _, id := range ids {
+		r, err := w.advanceAccountLookahead(tx, id, largest[id])
+		if err != nil {
+			return false, err
+		}
+		rescan = rescan || r
+	}
+	return rescan, nil
+}
+
+// nextAccountAddress fetches the next address of an account. Addresses of the
+// default account are generated from the primary seed.
+func (w *Wallet) nextAccountAddress(tx *bolt.Tx, id modules.WalletAccountID) (types.UnlockConditions, error) {
+	if id == modules.DefaultWalletAccount {
+		return w.nextPrimarySeedAddress(tx)
+	}
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+	acct, err := dbGetAccount(tx, id)
+	if err == errNoKey {
+		return types.UnlockConditions{}, errUnknownAccount
+	} else if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	if acct.Progress+1 < acct.Progress {
+		return types.UnlockConditions{}, errSeedIndexOverflow
+	}
+	acct.Progress++
+	if err := dbPutAccount(tx, id, acct); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	w.integrateAccount(id, acct.Progress-1, 1)
+	w.regenerateAccountLookahead(id, acct.Progress)
+	return generateSpendableKey(accountSeed(w.primarySeed, id), acct.Progress-1).UnlockConditions, nil
+}
+
+// CreateAccount creates a new account with the given name. The account's
+// addresses are derived from the primary seed, so the wallet must be
+// unlocked.
+func (w *Wallet) CreateAccount(name string) (modules.WalletAccountID, error) {
+	if err := w.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer w.tg.Done()
+	if name == "" {
+		return 0, errAccountNameEmpty
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return 0, modules.ErrLockedWallet
+	}
+
+	// Accounts are numbered in the order they are created, starting at 1.
+	taken := name == defaultAccountName
+	var lastID modules.WalletAccountID
+	err := dbForEachAccount(w.dbTx, func(id modules.WalletAccountID, acct accountEntry) {
+		if acct.Name == name {
+			taken = true
+		}
+		if id > lastID {
+			lastID = id
+		}
+	})
+	if err != nil {
+		return 0, err
+	} else if taken {
+		return 0, errAccountNameTaken
+	}
+	id := lastID + 1
+	if err := dbPutAccount(w.dbTx, id, accountEntry{Name: name}); err != nil {
+		return 0, err
+	}
+	// The new account was already watched for recovery; watch the next
+	// unused ID in its place.
+	w.regenerateAccountLookahead(id, 0)
+	w.regenerateAccountLookahead(id+accountRecoveryLookahead, 0)
+	w.syncDB()
+	return id, nil
+}
+
+// Accounts returns the accounts of the wallet, ordered by ID, with the
+// default account first.
+func (w *Wallet) Accounts() ([]modules.WalletAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold := w.DustThreshold()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	progress, err := dbGetPrimarySeedProgress(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	accounts := []modules.WalletAccount{{
+		ID:        modules.DefaultWalletAccount,
+		Name:      defaultAccountName,
+		Addresses: progress,
+	}}
+	err = dbForEachAccount(w.dbTx, func(id modules.WalletAccountID, acct accountEntry) {
+		accounts = append(accounts, modules.WalletAccount{
+			ID:        id,
+			Name:      acct.Name,
+			Addresses: acct.Progress,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].ID < accounts[j].ID
+	})
+
+	// Sum the confirmed outputs of each account.
+	balances := make(map[modules.WalletAccountID]types.Currency)
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.Value.Cmp(dustThreshold) > 0 {
+			id := w.accountAddrs[sco.UnlockHash]
+			balances[id] = balances[id].Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range accounts {
+		accounts[i].ConfirmedSiacoinBalance = balances[accounts[i].ID]
+	}
+	return accounts, nil
+}
+
+// NextAccountAddress returns an address of the given account that is ready
+// to receive siacoins.
+func (w *Wallet) NextAccountAddress(id modules.WalletAccountID) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	uc, err := w.nextAccountAddress(w.dbTx, id)
+	w.syncDB() // ensure durability of reported address
+	w.mu.Unlock()
+	return uc, err
+}
@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestAccountSeed checks that every account derives a distinct seed from the
+// primary seed, and that the derivation is deterministic.
+func TestAccountSeed(t *testing.T) {
+	var primarySeed modules.Seed
+	primarySeed[0] = 1
+	seed1 := accountSeed(primarySeed, 1)
+	if seed1 != accountSeed(primarySeed, 1) {
+		t.Fatal("account seed is not deterministic")
+	}
+	if seed1 == primarySeed || seed1 == accountSeed(primarySeed, 2) {
+		t.Fatal("account seeds are not distinct")
+	}
+}
+
+// accountBalance returns the confirmed siacoin balance of an account.
+func (wt *walletTester) accountBalance(id modules.WalletAccountID) (types.Currency, error) {
+	accounts, err := wt.wallet.Accounts()
+	if err != nil {
+		return types.Currency{}, err
+	}
+	for _, a := range accounts {
+		if a.ID == id {
+			return a.ConfirmedSiacoinBalance, nil
+		}
+	}
+	return types.Currency{}, errUnknownAccount
+}
+
+// TestAccounts checks that accounts can be created and funded, and that
+// transactions funded by an account only spend that account's outputs.
+func TestAccounts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Invalid names should be rejected.
+	if _, err := wt.wallet.CreateAccount(""); err != errAccountNameEmpty {
+		t.Fatal("expected errAccountNameEmpty, got", err)
+	}
+	if _, err := wt.wallet.CreateAccount(defaultAccountName); err != errAccountNameTaken {
+		t.Fatal("expected errAccountNameTaken, got", err)
+	}
+	hot, err := wt.wallet.CreateAccount("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cold, err := wt.wallet.CreateAccount("cold")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hot != 1 || cold != 2 {
+		t.Fatal("accounts numbered incorrectly:", hot, cold)
+	}
+	if _, err := wt.wallet.CreateAccount("hot"); err != errAccountNameTaken {
+		t.Fatal("expected errAccountNameTaken, got", err)
+	}
+	if _, err := wt.wallet.NextAccountAddress(3); err != errUnknownAccount {
+		t.Fatal("expected errUnknownAccount, got", err)
+	}
+
+	// A new account has no funds to spend.
+	dest := types.UnlockHash{1}
+	if _, err := wt.wallet.SendSiacoinsFromAccount(hot, types.SiacoinPrecision, dest); err == nil {
+		t.Fatal("empty account should not be able to send siacoins")
+	}
+
+	// Fund the hot account from the default account.
+	uc, err := wt.wallet.NextAccountAddress(hot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(1000)
+	if _, err := wt.wallet.SendSiacoins(amount, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		balance, err := wt.accountBalance(hot)
+		if err != nil {
+			return err
+		} else if !balance.Equals(amount) {
+			return errors.New("hot account was not funded: " + balance.String())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sending from the hot account should leave the cold account alone, and
+	// return the change to the hot account.
+	if _, err := wt.wallet.SendSiacoinsFromAccount(hot, types.SiacoinPrecision, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		balance, err := wt.accountBalance(hot)
+		if err != nil {
+			return err
+		} else if balance.Cmp(amount.Sub(types.SiacoinPrecision)) >= 0 || balance.IsZero() {
+			return errors.New("hot account balance is incorrect: " + balance.String())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance, err := wt.accountBalance(cold); err != nil || !balance.IsZero() {
+		t.Fatal("cold account balance should be zero:", balance, err)
+	}
+}
+
+// TestAccountLookahead checks that funds sent to account addresses that the
+// wallet has not handed out yet are found, and that the accounts they belong
+// to are recovered if the wallet does not know them.
+func TestAccountLookahead(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	hot, err := wt.wallet.CreateAccount("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send to a future address of the hot account, and to an address of the
+	// next account, which has not been created.
+	unknown := hot + 1
+	amount := types.SiacoinPrecision.Mul64(100)
+	for _, aki := range []accountKeyIndex{{hot, 5}, {unknown, 3}} {
+		seed := accountSeed(wt.wallet.primarySeed, aki.account)
+		uh := generateSpendableKey(seed, aki.index).UnlockConditions.UnlockHash()
+		if _, err := wt.wallet.SendSiacoins(amount, uh); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		for _, id := range []modules.WalletAccountID{hot, unknown} {
+			balance, err := wt.accountBalance(id)
+			if err != nil {
+				return err
+			} else if !balance.Equals(amount) {
+				return errors.New("account was not funded: " + balance.String())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The recovered account should continue after the found address, and
+	// its name should not clash with accounts created later.
+	wt.wallet.mu.RLock()
+	acct, err := dbGetAccount(wt.wallet.dbTx, unknown)
+	wt.wallet.mu.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	} else if acct.Progress != 4 {
+		t.Fatal("recovered account has the wrong progress:", acct.Progress)
+	}
+	if _, err := wt.wallet.CreateAccount(acct.Name); err != errAccountNameTaken {
+		t.Fatal("expected errAccountNameTaken, got", err)
+	}
+}
+
+// TestAccountFundSiafunds checks that siafund funding only spends the outputs
+// of the funding account.
+func TestAccountFundSiafunds(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	hot, err := wt.wallet.CreateAccount("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.wallet.LoadSiagKeys(wt.walletMasterKey, []string{"../../types/siag0of1of1.siakey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The siafunds belong to the default account, so the hot account has
+	// none to spend.
+	builder := wt.wallet.StartTransaction()
+	builder.SetFundingAccount(hot)
+	if err := builder.FundSiafunds(types.NewCurrency64(1)); err != modules.ErrLowBalance {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+	builder.Drop()
+
+	builder = wt.wallet.StartTransaction()
+	if err := builder.FundSiafunds(types.NewCurrency64(1)); err != nil {
+		t.Fatal(err)
+	}
+	builder.Drop()
+}
@@ -0,0 +1,215 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errBumpNotPending is returned by BumpFee if the transaction is not
+	// waiting in the transaction pool, for example because it has already
+	// been confirmed.
+	errBumpNotPending = errors.New("only transactions waiting in the transaction pool can have their fee bumped")
+
+	// errBumpFeeNotHigher is returned by BumpFee if the new fee is not larger
+	// than the fee the transaction already pays.
+	errBumpFeeNotHigher = errors.New("new fee must be higher than the current fee of the transaction")
+
+	// errBumpForeignTransaction is returned by BumpFee if the transaction
+	// cannot be re-signed by the wallet alone.
+	errBumpForeignTransaction = errors.New("transaction spends outputs or revises contracts that the wallet cannot sign for")
+
+	// errBumpNoChange is returned by BumpFee if the transaction has no change
+	// output large enough to pay for the fee increase.
+	errBumpNoChange = errors.New("transaction has no change output large enough to pay the higher fee")
+)
+
+// canSign reports whether the wallet can produce every signature of txn.
+func (w *Wallet) canSign(txn types.Transaction) bool {
+	if len(txn.FileContractRevisions) != 0 || len(txn.StorageProofs) != 0 {
+		return false
+	}
+	for _, sci := range txn.SiacoinInputs {
+		if _, ok := w.keys[sci.UnlockConditions.UnlockHash()]; !ok {
+			return false
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if _, ok := w.keys[sfi.UnlockConditions.UnlockHash()]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// changeOutput returns the index of the last output of txn, other than skip,
+// that pays the wallet at least amount, or -1 if there is none.
+func (w *Wallet) changeOutput(txn types.Transaction, amount types.Currency, skip int) int {
+	for i := len(txn.SiacoinOutputs) - 1; i >= 0; i-- {
+		sco := txn.SiacoinOutputs[i]
+		if _, ok := w.keys[sco.UnlockHash]; ok && i != skip && sco.Value.Cmp(amount) >= 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// unsignedCopy returns a copy of txn without its signatures. The outputs and
+// inputs of the copy can be modified without affecting txn.
+func unsignedCopy(txn types.Transaction) types.Transaction {
+	return types.Transaction{
+		SiacoinInputs:  append([]types.SiacoinInput(nil), txn.SiacoinInputs...),
+		SiacoinOutputs: append([]types.SiacoinOutput(nil), txn.SiacoinOutputs...),
+		FileContracts:  txn.FileContracts,
+		SiafundInputs:  txn.SiafundInputs,
+		SiafundOutputs: txn.SiafundOutputs,
+		MinerFees:      append([]types.Currency(nil), txn.MinerFees...),
+		ArbitraryData:  txn.ArbitraryData,
+	}
+}
+
+// signAll signs every input of txn with the keys of the wallet.
+func (w *Wallet) signAll(txn *types.Transaction) {
+	for _, sci := range txn.SiacoinInputs {
+		addSignatures(txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()])
+	}
+	for _, sfi := range txn.SiafundInputs {
+		addSignatures(txn, types.FullCoveredFields, sfi.UnlockConditions, crypto.Hash(sfi.ParentID), w.keys[sfi.UnlockConditions.UnlockHash()])
+	}
+}
+
+// takeChange subtracts amount from output i of txn, removing the output if
+// nothing is left of it. It returns the new index of output j, which is
+// shifted down if it follows a removed output.
+func takeChange(txn *types.Transaction, i int, amount types.Currency, j int) int {
+	remaining := txn.SiacoinOutputs[i].Value.Sub(amount)
+	if !remaining.IsZero() {
+		txn.SiacoinOutputs[i].Value = remaining
+		return j
+	}
+	txn.SiacoinOutputs = append(txn.SiacoinOutputs[:i], txn.SiacoinOutputs[i+1:]...)
+	if j > i {
+		return j - 1
+	}
+	return j
+}
+
+// bumpTransaction returns the replacements for txn that make it pay newFee in
+// miner fees, along with the ids of the transactions they replace. The fee
+// increase is taken from the change of txn. If txn has no change, as is the
+// case for transactions funded by the transaction builder, the increase is
+// taken from the change of the unconfirmed parent that funds txn instead,
+// and the parent is replaced as well.
+func (w *Wallet) bumpTransaction(txn types.Transaction, parents []types.Transaction, newFee types.Currency) ([]types.TransactionID, []types.Transaction, error) {
+	if !w.canSign(txn) {
+		return nil, nil, errBumpForeignTransaction
+	}
+	var oldFee types.Currency
+	for _, fee := range txn.MinerFees {
+		oldFee = oldFee.Add(fee)
+	}
+	if newFee.Cmp(oldFee) <= 0 {
+		return nil, nil, errBumpFeeNotHigher
+	}
+	increase := newFee.Sub(oldFee)
+
+	bumped := unsignedCopy(txn)
+	bumped.MinerFees = []types.Currency{newFee}
+	if change := w.changeOutput(txn, increase, -1); change >= 0 {
+		takeChange(&bumped, change, increase, -1)
+		w.signAll(&bumped)
+		return []types.TransactionID{txn.ID()}, []types.Transaction{bumped}, nil
+	}
+
+	// Move the increase from the change of the funding parent to the output
+	// of the parent that txn spends.
+	for i := len(parents) - 1; i >= 0; i-- {
+		parent := parents[i]
+		funding := -1
+		var input int
+		for j, sci := range txn.SiacoinInputs {
+			for k := range parent.SiacoinOutputs {
+				if sci.ParentID == parent.SiacoinOutputID(uint64(k)) {
+					funding, input = k, j
+				}
+			}
+		}
+		if funding < 0 || !w.canSign(parent) {
+			continue
+		}
+		change := w.changeOutput(parent, increase, funding)
+		if change < 0 {
+			continue
+		}
+		bumpedParent := unsignedCopy(parent)
+		bumpedParent.SiacoinOutputs[funding].Value = bumpedParent.SiacoinOutputs[funding].Value.Add(increase)
+		funding = takeChange(&bumpedParent, change, increase, funding)
+		w.signAll(&bumpedParent)
+		bumped.SiacoinInputs[input].ParentID = bumpedParent.SiacoinOutputID(uint64(funding))
+		w.signAll(&bumped)
+		return []types.TransactionID{parent.ID(), txn.ID()}, []types.Transaction{bumpedParent, bumped}, nil
+	}
+	return nil, nil, errBumpNoChange
+}
+
+// BumpFee replaces an unconfirmed transaction of the wallet with one that
+// spends the same inputs but pays newFee in miner fees, taking the increase
+// from the change of the transaction or of the unconfirmed parent that funds
+// it. The replacement is signed, submitted to the transaction pool in place
+// of the original, and returned. Only transactions that are still waiting in
+// the transaction pool can be bumped.
+func (w *Wallet) BumpFee(id types.TransactionID, newFee types.Currency) (types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, err
+	}
+	defer w.tg.Done()
+	if !w.unlocked {
+		w.log.Println("Attempt to bump the fee of a transaction has failed - wallet is locked")
+		return types.Transaction{}, modules.ErrLockedWallet
+	}
+
+	status, err := w.tpool.TransactionStatus(id)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	txn, parents, exists := w.tpool.Transaction(id)
+	if status.State != modules.TransactionStatePending || !exists {
+		return types.Transaction{}, errBumpNotPending
+	}
+
+	w.mu.RLock()
+	ids, replacements, err := w.bumpTransaction(txn, parents, newFee)
+	w.mu.RUnlock()
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	if err := w.tpool.ReplaceTransactions(ids, replacements); err != nil {
+		w.log.Println("Attempt to bump the fee of a transaction has failed - transaction pool rejected the replacement:", err)
+		return types.Transaction{}, build.ExtendErr("unable to replace transaction", err)
+	}
+
+	// The output of a replaced parent that funds the transaction has a new
+	// id, and must not be used to fund other transactions.
+	bumped := replacements[len(replacements)-1]
+	if len(replacements) > 1 {
+		w.mu.Lock()
+		consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+		if err == nil {
+			for _, sci := range bumped.SiacoinInputs {
+				if err = dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+					break
+				}
+			}
+		}
+		w.mu.Unlock()
+		if err != nil {
+			w.log.Println("WARN: could not mark the funding output of a bumped transaction as spent:", err)
+		}
+	}
+	w.log.Println("Replaced transaction", id, "with", bumped.ID(), "paying fees", newFee.HumanString())
+	return bumped, nil
+}
@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestBumpFee checks that BumpFee replaces a pending transaction with one
+// that pays a higher fee, and that the replacement is confirmed in place of
+// the original.
+func TestBumpFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	dest := types.UnlockHash{1}
+	amount := types.SiacoinPrecision.Mul64(100)
+	txns, err := wt.wallet.SendSiacoins(amount, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := txns[len(txns)-1]
+	var oldFee types.Currency
+	for _, fee := range original.MinerFees {
+		oldFee = oldFee.Add(fee)
+	}
+
+	// The fee cannot be lowered.
+	if _, err := wt.wallet.BumpFee(original.ID(), oldFee); err != errBumpFeeNotHigher {
+		t.Fatal("expected errBumpFeeNotHigher, got", err)
+	}
+
+	newFee := oldFee.Add(types.SiacoinPrecision)
+	bumped, err := wt.wallet.BumpFee(original.ID(), newFee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bumped.MinerFees) != 1 || !bumped.MinerFees[0].Equals(newFee) {
+		t.Fatal("replacement does not pay the new fee:", bumped.MinerFees)
+	}
+	var paid bool
+	for _, sco := range bumped.SiacoinOutputs {
+		paid = paid || (sco.UnlockHash == dest && sco.Value.Equals(amount))
+	}
+	if !paid {
+		t.Fatal("replacement does not pay the destination:", bumped.SiacoinOutputs)
+	}
+	status, err := wt.tpool.TransactionStatus(original.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != modules.TransactionStateConflicted {
+		t.Fatal("original should have been replaced, state is", status.State)
+	}
+
+	// The replacement should be confirmed by the next block, after which it
+	// can no longer be bumped.
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	status, err = wt.tpool.TransactionStatus(bumped.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != modules.TransactionStateConfirmed {
+		t.Fatal("replacement was not confirmed, state is", status.State)
+	}
+	if _, err := wt.wallet.BumpFee(bumped.ID(), newFee.Add(types.SiacoinPrecision)); err != errBumpNotPending {
+		t.Fatal("expected errBumpNotPending, got", err)
+	}
+}
@@ -1,7 +1,10 @@
 package wallet
 
 import (
+	"time"
+
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 const (
@@ -15,9 +18,47 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// consolidateInputBudget is the maximum number of outputs that the
+	// FundConsolidateDust strategy will spend in a single funding.
+	consolidateInputBudget = 20
+
+	// sweepInputBudget is the maximum number of outputs that Sweep will
+	// spend in a single transaction, which keeps the transaction well below
+	// modules.TransactionSizeLimit.
+	sweepInputBudget = 80
+
+	// fastFeeMultiplier is the multiple of the transaction pool's maximum
+	// fee estimate that is paid by transactions sent with
+	// modules.FeeSpeedFast.
+	fastFeeMultiplier = 2
+
+	// maxChangeThresholdBytes bounds the change threshold of the fee policy
+	// to the fee of a transaction of this many bytes at the fast fee rate.
+	// Spending an output costs far less than that, so a higher threshold
+	// would only give change away to the miners.
+	maxChangeThresholdBytes = 2e3
+
+	// maxWalletEvents is the number of events that the wallet remembers for
+	// Events.
+	maxWalletEvents = 100
+
+	// maxTransactionNoteLen is the maximum length in bytes of a note
+	// attached to a transaction with SetTransactionNote.
+	maxTransactionNoteLen = 1024
+
+	// accountRecoveryLookahead is the number of account IDs after the last
+	// known account whose addresses are watched, so that the accounts of a
+	// wallet that is restored from its seed are found again.
+	accountRecoveryLookahead = 3
 )
 
 var (
+	// defaultMinFee is the default minimum fee per byte paid by transactions
+	// created by the wallet. It matches the minimum fee suggested by the
+	// transaction pool.
+	defaultMinFee = types.SiacoinPrecision.Div64(100).Div64(1e3)
+
 	// lookaheadBuffer together with lookaheadRescanThreshold defines the constant part
 	// of the maxLookahead
 	lookaheadBuffer = build.Select(build.Var{
@@ -26,6 +67,14 @@ var (
 		Testing:  uint64(40),
 	}).(uint64)
 
+	// spentOutputsReconcileInterval is how often the wallet removes entries
+	// that no longer lock any funds from its set of spent outputs.
+	spentOutputsReconcileInterval = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
 	// lookaheadRescanThreshold is the number of keys in the lookahead that will be
 	// generated before a complete wallet rescan is initialized.
 	lookaheadRescanThreshold = build.Select(build.Var{
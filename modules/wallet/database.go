@@ -41,6 +41,15 @@ var (
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketScheduledPayments maps the ID of a scheduled payment to the
+	// payment.
+	bucketScheduledPayments = []byte("bucketScheduledPayments")
+	// bucketAccounts maps the ID of a wallet account to its name and the
+	// number of addresses generated for it.
+	bucketAccounts = []byte("bucketAccounts")
+	// bucketTransactionNotes maps a TransactionID to the note that the user
+	// attached to it.
+	bucketTransactionNotes = []byte("bucketTransactionNotes")
 
 	dbBuckets = [][]byte{
 		bucketProcessedTransactions,
@@ -50,6 +59,9 @@ var (
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
 		bucketWallet,
+		bucketScheduledPayments,
+		bucketAccounts,
+		bucketTransactionNotes,
 	}
 
 	errNoKey = errors.New("key does not exist")
@@ -59,6 +71,7 @@ var (
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyFeePolicy              = []byte("keyFeePolicy")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
@@ -211,6 +224,45 @@ func dbGetSpentOutput(tx *bolt.Tx, id types.OutputID) (height types.BlockHeight,
 func dbDeleteSpentOutput(tx *bolt.Tx, id types.OutputID) error {
 	return dbDelete(tx.Bucket(bucketSpentOutputs), id)
 }
+func dbForEachSpentOutput(tx *bolt.Tx, fn func(types.OutputID, types.BlockHeight)) error {
+	return dbForEach(tx.Bucket(bucketSpentOutputs), fn)
+}
+
+func dbPutScheduledPayment(tx *bolt.Tx, sp modules.ScheduledPayment) error {
+	return dbPut(tx.Bucket(bucketScheduledPayments), sp.ID, sp)
+}
+func dbGetScheduledPayment(tx *bolt.Tx, id string) (sp modules.ScheduledPayment, err error) {
+	err = dbGet(tx.Bucket(bucketScheduledPayments), id, &sp)
+	return
+}
+func dbDeleteScheduledPayment(tx *bolt.Tx, id string) error {
+	return dbDelete(tx.Bucket(bucketScheduledPayments), id)
+}
+func dbForEachScheduledPayment(tx *bolt.Tx, fn func(string, modules.ScheduledPayment)) error {
+	return dbForEach(tx.Bucket(bucketScheduledPayments), fn)
+}
+
+func dbPutAccount(tx *bolt.Tx, id modules.WalletAccountID, acct accountEntry) error {
+	return dbPut(tx.Bucket(bucketAccounts), id, acct)
+}
+func dbGetAccount(tx *bolt.Tx, id modules.WalletAccountID) (acct accountEntry, err error) {
+	err = dbGet(tx.Bucket(bucketAccounts), id, &acct)
+	return
+}
+func dbForEachAccount(tx *bolt.Tx, fn func(modules.WalletAccountID, accountEntry)) error {
+	return dbForEach(tx.Bucket(bucketAccounts), fn)
+}
+
+func dbPutTransactionNote(tx *bolt.Tx, txid types.TransactionID, note string) error {
+	return dbPut(tx.Bucket(bucketTransactionNotes), txid, note)
+}
+func dbGetTransactionNote(tx *bolt.Tx, txid types.TransactionID) (note string, err error) {
+	err = dbGet(tx.Bucket(bucketTransactionNotes), txid, &note)
+	return
+}
+func dbDeleteTransactionNote(tx *bolt.Tx, txid types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketTransactionNotes), txid)
+}
 
 func dbPutAddrTransactions(tx *bolt.Tx, addr types.UnlockHash, txns []uint64) error {
 	return dbPut(tx.Bucket(bucketAddrTransactions), addr, txns)
@@ -434,6 +486,22 @@ func dbPutSiafundPool(tx *bolt.Tx, pool types.Currency) error {
 	return tx.Bucket(bucketWallet).Put(keySiafundPool, encoding.Marshal(pool))
 }
 
+// dbGetFeePolicy returns the fee policy set with SetFeePolicy, or errNoKey if
+// none was set.
+func dbGetFeePolicy(tx *bolt.Tx) (p modules.WalletFeePolicy, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyFeePolicy)
+	if b == nil {
+		return modules.WalletFeePolicy{}, errNoKey
+	}
+	err = encoding.Unmarshal(b, &p)
+	return
+}
+
+// dbPutFeePolicy stores the fee policy set with SetFeePolicy.
+func dbPutFeePolicy(tx *bolt.Tx, p modules.WalletFeePolicy) error {
+	return tx.Bucket(bucketWallet).Put(keyFeePolicy, encoding.Marshal(p))
+}
+
 // COMPATv121: these types were stored in the db in v1.2.2 and earlier.
 type (
 	v121ProcessedInput struct {
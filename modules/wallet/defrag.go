@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -15,9 +16,11 @@ var (
 // managedCreateDefragTransaction creates a transaction that spends multiple existing
 // wallet outputs into a single new address.
 func (w *Wallet) managedCreateDefragTransaction() ([]types.Transaction, error) {
-	// dustThreshold and minFee have to be obtained separate from the lock
+	// dustThreshold and minFee have to be obtained separate from the lock.
+	// Defrag transactions are not urgent, so they always pay the
+	// conservative fee.
 	dustThreshold := w.DustThreshold()
-	minFee, _ := w.tpool.FeeEstimation()
+	minFee := w.managedFeeDensity(modules.FeeSpeedConservative)
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
@@ -30,6 +33,10 @@ func (w *Wallet) managedCreateDefragTransaction() ([]types.Transaction, error) {
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		// Outputs of other accounts are left alone.
+		if w.accountAddrs[sco.UnlockHash] != modules.DefaultWalletAccount {
+			return
+		}
 		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
 			so.ids = append(so.ids, scoid)
 			so.outputs = append(so.outputs, sco)
@@ -109,6 +109,7 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	var primarySeedProgress uint64
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
+	accounts := make(map[modules.WalletAccountID]accountEntry)
 	err := func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
@@ -145,7 +146,10 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 			return err
 		}
 
-		return nil
+		// accounts
+		return dbForEachAccount(w.dbTx, func(id modules.WalletAccountID, acct accountEntry) {
+			accounts[id] = acct
+		})
 	}()
 	if err != nil {
 		return err
@@ -165,6 +169,12 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		w.primarySeed = primarySeed
 		w.regenerateLookahead(primarySeedProgress)
 
+		// accounts
+		for id, acct := range accounts {
+			w.integrateAccount(id, 0, acct.Progress)
+		}
+		w.initAccountLookahead(accounts)
+
 		// auxiliarySeedFiles
 		for _, sf := range auxiliarySeedFiles {
 			auxSeed, err := decryptSeedFile(masterKey, sf)
@@ -262,9 +272,7 @@ func (w *Wallet) wipeSecrets() {
 	// 'for i := range' must be used to prevent copies of secret data from
 	// being made.
 	for i := range w.keys {
-		for j := range w.keys[i].SecretKeys {
-			crypto.SecureWipe(w.keys[i].SecretKeys[j][:])
-		}
+		w.keys[i].Zeroize()
 	}
 	for i := range w.seeds {
 		crypto.SecureWipe(w.seeds[i][:])
@@ -334,9 +342,17 @@ func (w *Wallet) Reset() error {
 	if err != nil {
 		return err
 	}
+	// The fee policy is a setting of the wallet rather than of its seed, so
+	// it survives the reset.
+	if err := dbPutFeePolicy(w.dbTx, w.feePolicy); err != nil {
+		return err
+	}
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.accountAddrs = make(map[types.UnlockHash]modules.WalletAccountID)
+	w.accountLookahead = make(map[types.UnlockHash]accountKeyIndex)
+	w.accountLookaheadEnd = make(map[modules.WalletAccountID]uint64)
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// reorgEvent classifies the wallet transactions that were removed from the
+// confirmed history by a reorg. Transactions that the applied blocks confirm
+// again are left out. Transactions that the transaction pool accepted again
+// are unconfirmed; the transaction pool processes consensus changes before
+// the wallet, so they are already in the unconfirmed set. All other
+// transactions, including the miner payouts of reverted blocks, are reversed.
+// The caller must hold the wallet's lock.
+func (w *Wallet) reorgEvent(cc modules.ConsensusChange, reverted []modules.ProcessedTransaction) modules.WalletEvent {
+	minerPayouts := make(map[types.TransactionID]struct{})
+	for _, block := range cc.RevertedBlocks {
+		minerPayouts[types.TransactionID(block.ID())] = struct{}{}
+	}
+	stillReverted := make(map[types.TransactionID]struct{})
+	for _, txid := range cc.RevertedTransactions {
+		stillReverted[txid] = struct{}{}
+	}
+	unconfirmed := make(map[types.TransactionID]struct{})
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		unconfirmed[pt.TransactionID] = struct{}{}
+	}
+
+	event := modules.WalletEvent{
+		Type:       modules.WalletEventReorg,
+		ReorgDepth: cc.ReorgDepth,
+	}
+	for _, pt := range reverted {
+		if _, ok := minerPayouts[pt.TransactionID]; ok {
+			event.Reversed = append(event.Reversed, pt.TransactionID)
+		} else if _, ok := stillReverted[pt.TransactionID]; !ok {
+			continue
+		} else if _, ok := unconfirmed[pt.TransactionID]; ok {
+			event.Unconfirmed = append(event.Unconfirmed, pt.TransactionID)
+		} else {
+			event.Reversed = append(event.Reversed, pt.TransactionID)
+		}
+	}
+	return event
+}
+
+// recordReorg records a reorg event if the reorg reverted any confirmed
+// wallet transactions. The caller must hold the wallet's lock.
+func (w *Wallet) recordReorg(cc modules.ConsensusChange, reverted []modules.ProcessedTransaction) {
+	event := w.reorgEvent(cc, reverted)
+	if len(event.Unconfirmed) == 0 && len(event.Reversed) == 0 {
+		return
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		w.log.Println("ERROR: failed to get consensus height:", err)
+	}
+	event.Height = height
+	w.log.Printf("WARN: a reorg of depth %v reverted %v confirmed wallet transactions, %v of which were reversed",
+		event.ReorgDepth, len(event.Unconfirmed)+len(event.Reversed), len(event.Reversed))
+
+	w.events = append(w.events, event)
+	if len(w.events) > maxWalletEvents {
+		w.events = w.events[len(w.events)-maxWalletEvents:]
+	}
+}
+
+// Events returns the most recent events of the wallet, oldest first. At most
+// maxWalletEvents are kept, and they are not persisted across restarts.
+func (w *Wallet) Events() []modules.WalletEvent {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]modules.WalletEvent(nil), w.events...)
+}
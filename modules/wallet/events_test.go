@@ -0,0 +1,45 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestReorgEvent checks that reorgEvent classifies the reverted wallet
+// transactions by whether they were confirmed again, accepted by the
+// transaction pool again, or reversed.
+func TestReorgEvent(t *testing.T) {
+	block := types.Block{Timestamp: 1}
+	payoutID := types.TransactionID(block.ID())
+	reconfirmedID := types.TransactionID{1}
+	pooledID := types.TransactionID{2}
+	reversedID := types.TransactionID{3}
+
+	w := &Wallet{
+		unconfirmedProcessedTransactions: []modules.ProcessedTransaction{{TransactionID: pooledID}},
+	}
+	cc := modules.ConsensusChange{
+		RevertedBlocks:       []types.Block{block},
+		ReorgDepth:           1,
+		RevertedTransactions: []types.TransactionID{pooledID, reversedID},
+	}
+	reverted := []modules.ProcessedTransaction{
+		{TransactionID: reconfirmedID},
+		{TransactionID: pooledID},
+		{TransactionID: reversedID},
+		{TransactionID: payoutID},
+	}
+
+	event := w.reorgEvent(cc, reverted)
+	if event.Type != modules.WalletEventReorg || event.ReorgDepth != 1 {
+		t.Fatal("event has the wrong type or depth:", event)
+	}
+	if len(event.Unconfirmed) != 1 || event.Unconfirmed[0] != pooledID {
+		t.Error("expected the pooled transaction to be unconfirmed, got", event.Unconfirmed)
+	}
+	if len(event.Reversed) != 2 || event.Reversed[0] != reversedID || event.Reversed[1] != payoutID {
+		t.Error("expected the reversed transaction and the miner payout to be reversed, got", event.Reversed)
+	}
+}
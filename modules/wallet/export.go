@@ -0,0 +1,178 @@
+package wallet
+
+import (
+	"errors"
+	"io"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/fastrand"
+)
+
+const (
+	// The header and version of a key export. Do not change.
+	keyExportHeader  = "Sia Wallet Key Export"
+	keyExportVersion = "1.0"
+)
+
+var (
+	errKeyExportPassphrase = errors.New("incorrect passphrase, or the key export is corrupted")
+	errNoKeysImported      = errors.New("key export contains no keys that are not already in the wallet")
+)
+
+// keyExport is the struct that gets written when the keys of a wallet are
+// exported. The keys are encrypted with a key derived from a passphrase, using
+// Salt.
+type keyExport struct {
+	Header  string
+	Version string
+	Salt    [crypto.PassphraseSaltSize]byte
+	Keys    crypto.Ciphertext
+}
+
+// encryptKeyExport encrypts a set of spendable keys under a passphrase.
+func encryptKeyExport(keys []spendableKey, passphrase string) (keyExport, error) {
+	ke := keyExport{
+		Header:  keyExportHeader,
+		Version: keyExportVersion,
+	}
+	fastrand.Read(ke.Salt[:])
+	key, err := crypto.TwofishKeyFromPassphrase(passphrase, ke.Salt[:])
+	if err != nil {
+		return keyExport{}, err
+	}
+	plaintext := encoding.Marshal(keys)
+	ke.Keys = key.EncryptBytes(plaintext)
+	crypto.SecureWipe(plaintext)
+	crypto.SecureWipe(key[:])
+	return ke, nil
+}
+
+// decryptKeyExport decrypts the spendable keys of a key export using a
+// passphrase.
+func decryptKeyExport(ke keyExport, passphrase string) ([]spendableKey, error) {
+	if ke.Header != keyExportHeader {
+		return nil, ErrUnknownHeader
+	}
+	if ke.Version != keyExportVersion {
+		return nil, ErrUnknownVersion
+	}
+	key, err := crypto.TwofishKeyFromPassphrase(passphrase, ke.Salt[:])
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.SecureWipe(key[:])
+	plaintext, err := key.DecryptBytes(ke.Keys)
+	if err != nil {
+		return nil, errKeyExportPassphrase
+	}
+	defer crypto.SecureWipe(plaintext)
+	var keys []spendableKey
+	err = encoding.Unmarshal(plaintext, &keys)
+	if err != nil {
+		return nil, errKeyExportPassphrase
+	}
+	return keys, nil
+}
+
+// ExportKeys writes every spendable key of the wallet to dst, encrypted under
+// a passphrase. This includes both the keys generated from the wallet's seeds
+// and any keys that were loaded into the wallet, which cannot be recovered
+// from a seed.
+func (w *Wallet) ExportKeys(dst io.Writer, passphrase string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	if !w.unlocked {
+		w.mu.RUnlock()
+		return modules.ErrLockedWallet
+	}
+	keys := make([]spendableKey, 0, len(w.keys))
+	for _, sk := range w.keys {
+		keys = append(keys, sk)
+	}
+	w.mu.RUnlock()
+
+	ke, err := encryptKeyExport(keys, passphrase)
+	if err != nil {
+		return err
+	}
+	return encoding.NewEncoder(dst).Encode(ke)
+}
+
+// ImportKeys reads a set of keys that were written by ExportKeys and loads
+// them into the wallet as unseeded keys, making their outputs spendable. Keys
+// that are already in the wallet are skipped. The master key is used to
+// encrypt the keys before saving them to disk.
+func (w *Wallet) ImportKeys(masterKey crypto.TwofishKey, src io.Reader, passphrase string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	var ke keyExport
+	err := encoding.NewDecoder(src).Decode(&ke)
+	if err != nil {
+		return err
+	}
+	keys, err := decryptKeyExport(ke, passphrase)
+	if err != nil {
+		return err
+	}
+
+	// load the keys and reset the consensus change ID and height in preparation for rescan
+	err = func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		var keysLoaded int
+		for _, sk := range keys {
+			err := w.loadSpendableKey(masterKey, sk)
+			if err == errDuplicateSpendableKey {
+				continue
+			} else if err != nil {
+				return err
+			}
+			keysLoaded++
+			w.integrateSpendableKey(masterKey, sk)
+		}
+		if keysLoaded == 0 {
+			return errNoKeysImported
+		}
+
+		if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
+			return err
+		}
+		if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
+			return err
+		}
+		w.unconfirmedProcessedTransactions = nil
+		err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning)
+		if err != nil {
+			return err
+		}
+		return dbPutConsensusHeight(w.dbTx, 0)
+	}()
+	if err != nil {
+		return err
+	}
+
+	// rescan the blockchain
+	w.cs.Unsubscribe(w)
+	w.tpool.Unsubscribe(w)
+
+	done := make(chan struct{})
+	go w.rescanMessage(done)
+	defer close(done)
+
+	err = w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan())
+	if err != nil {
+		return err
+	}
+	w.tpool.TransactionPoolSubscribe(w)
+	return nil
+}
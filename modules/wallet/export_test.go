@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestKeyExport checks that keys survive a round trip through
+// encryptKeyExport and decryptKeyExport, and that the wrong passphrase is
+// rejected.
+func TestKeyExport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	var seed modules.Seed
+	keys := generateKeys(seed, 0, 3)
+
+	ke, err := encryptKeyExport(keys, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := decryptKeyExport(ke, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decrypted) != len(keys) {
+		t.Fatalf("expected %v keys, got %v", len(keys), len(decrypted))
+	}
+	for i := range keys {
+		if decrypted[i].UnlockConditions.UnlockHash() != keys[i].UnlockConditions.UnlockHash() ||
+			decrypted[i].SecretKeys[0] != keys[i].SecretKeys[0] {
+			t.Fatal("decrypted key does not match the original key", i)
+		}
+	}
+
+	if _, err := decryptKeyExport(ke, "wrong"); err != errKeyExportPassphrase {
+		t.Fatal("expected errKeyExportPassphrase, got", err)
+	}
+	ke.Version = "2.0"
+	if _, err := decryptKeyExport(ke, "passphrase"); err != ErrUnknownVersion {
+		t.Fatal("expected ErrUnknownVersion, got", err)
+	}
+}
+
+// TestIntegrationExportImportKeys exports the keys of a wallet that holds an
+// unseeded key, and checks that importing them into another wallet makes the
+// unseeded key's siafunds spendable.
+func TestIntegrationExportImportKeys(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	err = wt.wallet.LoadSiagKeys(wt.walletMasterKey, []string{"../../types/siag0of1of1.siakey"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = wt.wallet.ExportKeys(&buf, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wt2, err := createBlankWalletTester(t.Name() + "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt2.closeWt()
+	masterKey := crypto.GenerateTwofishKey()
+	if _, err := wt2.wallet.Encrypt(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt2.wallet.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing with the wrong passphrase should fail cleanly.
+	err = wt2.wallet.ImportKeys(masterKey, bytes.NewReader(buf.Bytes()), "wrong")
+	if err != errKeyExportPassphrase {
+		t.Fatal("expected errKeyExportPassphrase, got", err)
+	}
+
+	err = wt2.wallet.ImportKeys(masterKey, bytes.NewReader(buf.Bytes()), "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, siafundBal, _ := wt2.wallet.ConfirmedBalance()
+	if !siafundBal.Equals64(2000) {
+		t.Fatal("expecting a siafund balance of 2000 from the imported key, got", siafundBal)
+	}
+	_, err = wt2.wallet.SendSiafunds(types.NewCurrency64(12), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing the same keys again should not load anything.
+	err = wt2.wallet.ImportKeys(masterKey, bytes.NewReader(buf.Bytes()), "passphrase")
+	if err != errNoKeysImported {
+		t.Fatal("expected errNoKeysImported, got", err)
+	}
+}
@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errChangeThresholdTooHigh = errors.New("change threshold is higher than the fee of a large transaction")
+	errUnknownFeeSpeed        = errors.New("unknown fee speed")
+)
+
+// defaultFeePolicy returns the fee policy used by a new wallet. The default
+// minimum matches the minimum fee suggested by the transaction pool, below
+// which transactions are not reliably relayed once the network's pools fill
+// up.
+func defaultFeePolicy() modules.WalletFeePolicy {
+	return modules.WalletFeePolicy{
+		MinFee: defaultMinFee,
+		Speed:  modules.FeeSpeedNormal,
+	}
+}
+
+// policyFeeDensity returns the fee per byte that a transaction should pay
+// according to the given speed and minimum, where min and max are the fee
+// estimates of the transaction pool.
+func policyFeeDensity(speed modules.FeeSpeed, minFee, min, max types.Currency) types.Currency {
+	var fee types.Currency
+	switch speed {
+	case modules.FeeSpeedConservative:
+		fee = min
+	case modules.FeeSpeedFast:
+		fee = max.Mul64(fastFeeMultiplier)
+	default:
+		fee = max
+	}
+	if fee.Cmp(minFee) < 0 {
+		fee = minFee
+	}
+	return fee
+}
+
+// managedFeeDensity returns the fee per byte that should be paid by a
+// transaction created by the wallet at the given speed. The minimum fee of
+// the wallet's fee policy is always respected.
+func (w *Wallet) managedFeeDensity(speed modules.FeeSpeed) types.Currency {
+	min, max := w.tpool.FeeEstimation()
+	w.mu.RLock()
+	minFee := w.feePolicy.MinFee
+	w.mu.RUnlock()
+	return policyFeeDensity(speed, minFee, min, max)
+}
+
+// changeThreshold returns the value below which the change of a funding is
+// added to the miner fee instead of being returned to the wallet. The caller
+// must hold the wallet's lock.
+func (w *Wallet) changeThreshold(dustThreshold types.Currency) types.Currency {
+	if w.feePolicy.ChangeThreshold.IsZero() {
+		return dustThreshold
+	}
+	return w.feePolicy.ChangeThreshold
+}
+
+// maxChangeThreshold returns the highest change threshold allowed for a fee
+// policy with the given minimum fee, where min and max are the fee estimates
+// of the transaction pool.
+func maxChangeThreshold(minFee, min, max types.Currency) types.Currency {
+	return policyFeeDensity(modules.FeeSpeedFast, minFee, min, max).Mul64(maxChangeThresholdBytes)
+}
+
+// FeePolicy returns the policy used to choose the fees of transactions
+// created by the wallet.
+func (w *Wallet) FeePolicy() modules.WalletFeePolicy {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.feePolicy
+}
+
+// SetFeePolicy sets the policy used to choose the fees of transactions
+// created by the wallet, and saves it in the wallet's database. The change
+// threshold may not exceed the fee of a transaction of
+// maxChangeThresholdBytes at the fast fee rate.
+func (w *Wallet) SetFeePolicy(p modules.WalletFeePolicy) error {
+	switch p.Speed {
+	case modules.FeeSpeedConservative, modules.FeeSpeedNormal, modules.FeeSpeedFast:
+	default:
+		return errUnknownFeeSpeed
+	}
+	min, max := w.tpool.FeeEstimation()
+	if p.ChangeThreshold.Cmp(maxChangeThreshold(p.MinFee, min, max)) > 0 {
+		return errChangeThresholdTooHigh
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutFeePolicy(w.dbTx, p); err != nil {
+		return err
+	}
+	w.feePolicy = p
+	w.syncDB()
+	return nil
+}
@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestPolicyFeeDensity checks that each fee speed picks the expected multiple
+// of the transaction pool's estimate, and that the minimum fee is respected.
+func TestPolicyFeeDensity(t *testing.T) {
+	min, max := types.NewCurrency64(10), types.NewCurrency64(30)
+	tests := []struct {
+		speed    modules.FeeSpeed
+		minFee   types.Currency
+		expected types.Currency
+	}{
+		{modules.FeeSpeedConservative, types.ZeroCurrency, min},
+		{modules.FeeSpeedNormal, types.ZeroCurrency, max},
+		{modules.FeeSpeedFast, types.ZeroCurrency, max.Mul64(fastFeeMultiplier)},
+		{modules.FeeSpeedConservative, types.NewCurrency64(20), types.NewCurrency64(20)},
+		{modules.FeeSpeedNormal, types.NewCurrency64(20), max},
+		{modules.FeeSpeedFast, types.NewCurrency64(100), types.NewCurrency64(100)},
+	}
+	for _, tt := range tests {
+		if fee := policyFeeDensity(tt.speed, tt.minFee, min, max); !fee.Equals(tt.expected) {
+			t.Errorf("%v with minimum %v: expected %v, got %v", tt.speed, tt.minFee, tt.expected, fee)
+		}
+	}
+}
+
+// TestChangeThreshold checks that the change threshold of the fee policy is
+// used when set, and that the dust threshold is used otherwise.
+func TestChangeThreshold(t *testing.T) {
+	w := &Wallet{feePolicy: defaultFeePolicy()}
+	dustThreshold := types.NewCurrency64(30)
+	if ct := w.changeThreshold(dustThreshold); !ct.Equals(dustThreshold) {
+		t.Error("expected the dust threshold, got", ct)
+	}
+	w.feePolicy.ChangeThreshold = types.NewCurrency64(100)
+	if ct := w.changeThreshold(dustThreshold); !ct.Equals64(100) {
+		t.Error("expected the policy's change threshold, got", ct)
+	}
+}
+
+// TestSetFeePolicy checks that the fee policy can be updated, that unknown
+// speeds are rejected, and that sends respect the minimum fee.
+func TestSetFeePolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	if p := wt.wallet.FeePolicy(); p.Speed != modules.FeeSpeedNormal || !p.MinFee.Equals(defaultMinFee) {
+		t.Fatal("new wallet does not use the default fee policy:", p)
+	}
+	err = wt.wallet.SetFeePolicy(modules.WalletFeePolicy{Speed: "instant"})
+	if err != errUnknownFeeSpeed {
+		t.Fatal("expected errUnknownFeeSpeed, got", err)
+	}
+
+	// A change threshold above the fee of a large transaction is rejected.
+	min, max := wt.tpool.FeeEstimation()
+	limit := maxChangeThreshold(defaultMinFee, min, max)
+	p := defaultFeePolicy()
+	p.ChangeThreshold = limit.Add(types.NewCurrency64(1))
+	if err := wt.wallet.SetFeePolicy(p); err != errChangeThresholdTooHigh {
+		t.Fatal("expected errChangeThresholdTooHigh, got", err)
+	}
+	p.ChangeThreshold = limit
+	if err := wt.wallet.SetFeePolicy(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a minimum far above the pool's estimate and check that a send pays
+	// at least that much.
+	minFee := types.SiacoinPrecision.Div64(1e3)
+	err = wt.wallet.SetFeePolicy(modules.WalletFeePolicy{MinFee: minFee, Speed: modules.FeeSpeedConservative})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fees types.Currency
+	for _, txn := range txns {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	if fees.Cmp(minFee.Mul64(750)) < 0 {
+		t.Fatal("send paid less than the minimum fee:", fees)
+	}
+}
+
+// TestFeePolicyPersist checks that the fee policy is kept across restarts of
+// the wallet.
+func TestFeePolicyPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	p := modules.WalletFeePolicy{
+		MinFee: defaultMinFee.Mul64(2),
+		Speed:  modules.FeeSpeedFast,
+	}
+	if err := wt.wallet.SetFeePolicy(p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the wallet.
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w, err := New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet = w
+
+	if loaded := wt.wallet.FeePolicy(); loaded.Speed != p.Speed || !loaded.MinFee.Equals(p.MinFee) {
+		t.Fatal("fee policy was not persisted:", loaded)
+	}
+}
@@ -0,0 +1,60 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errUnknownAddress is returned when trying to sign a message with an
+	// address that does not belong to the wallet.
+	errUnknownAddress = errors.New("address does not belong to the wallet")
+)
+
+// SignMessage signs a message with the key of the provided address, proving
+// that the wallet controls the address without spending from it. The message
+// is hashed together with modules.SpecifierSignedMessage before signing, so
+// the signature is never valid as a transaction signature.
+func (w *Wallet) SignMessage(uh types.UnlockHash, message []byte) (crypto.Signature, error) {
+	if err := w.tg.Add(); err != nil {
+		return crypto.Signature{}, err
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.unlocked {
+		return crypto.Signature{}, modules.ErrLockedWallet
+	}
+
+	sk, exists := w.keys[uh]
+	if !exists {
+		return crypto.Signature{}, errUnknownAddress
+	}
+	if len(sk.SecretKeys) != 1 || sk.UnlockConditions.SignaturesRequired != 1 {
+		return crypto.Signature{}, modules.ErrNonStandardMessageKey
+	}
+	return crypto.SignHash(modules.SignedMessageHash(message), sk.SecretKeys[0]), nil
+}
+
+// UnlockConditions returns the unlock conditions of an address that belongs to
+// the wallet.
+func (w *Wallet) UnlockConditions(uh types.UnlockHash) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	defer w.tg.Done()
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+
+	sk, exists := w.keys[uh]
+	if !exists {
+		return types.UnlockConditions{}, errUnknownAddress
+	}
+	return sk.UnlockConditions, nil
+}
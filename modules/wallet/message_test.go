@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSignMessage checks that the wallet can sign a message with one of its
+// addresses, and that the signature can be verified using the unlock
+// conditions of the address.
+func TestSignMessage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uh := uc.UnlockHash()
+	message := []byte("proof of ownership")
+	sig, err := wt.wallet.SignMessage(uh, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	walletUC, err := wt.wallet.UnlockConditions(uh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if walletUC.UnlockHash() != uh {
+		t.Fatal("wallet returned the wrong unlock conditions")
+	}
+	if err := modules.VerifyMessage(uh, walletUC, message, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing with an address that does not belong to the wallet should fail.
+	if _, err := wt.wallet.SignMessage(types.UnlockHash{}, message); err != errUnknownAddress {
+		t.Fatal("expected errUnknownAddress, got", err)
+	}
+
+	// Signing with a locked wallet should fail.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SignMessage(uh, message); err != modules.ErrLockedWallet {
+		t.Fatal("expected ErrLockedWallet, got", err)
+	}
+}
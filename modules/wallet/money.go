@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"bytes"
 	"errors"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -57,6 +58,32 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalan
 	return
 }
 
+// SpendableBalance returns the portion of the confirmed siacoin balance that
+// can be spent at the given height. Unlike ConfirmedBalance, outputs whose
+// unlock conditions are timelocked beyond the given height are excluded, as
+// they cannot be used to fund transactions until the timelock expires.
+func (w *Wallet) SpendableBalance(height types.BlockHeight) (siacoinBalance types.Currency) {
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold := w.DustThreshold()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// ensure durability of reported balance
+	w.syncDB()
+
+	dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.Value.Cmp(dustThreshold) <= 0 {
+			return
+		}
+		if height < w.keys[sco.UnlockHash].UnlockConditions.Timelock {
+			return
+		}
+		siacoinBalance = siacoinBalance.Add(sco.Value)
+	})
+	return
+}
+
 // UnconfirmedBalance returns the number of outgoing and incoming siacoins in
 // the unconfirmed transaction set. Refund outputs are included in this
 // reporting.
@@ -84,7 +111,14 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 
 // SendSiacoins creates a transaction sending 'amount' to 'dest'. The transaction
 // is submitted to the transaction pool and is also returned.
-func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
+func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
+	return w.SendSiacoinsFromAccount(modules.DefaultWalletAccount, amount, dest)
+}
+
+// SendSiacoinsFromAccount creates a transaction sending 'amount' to 'dest',
+// funded by the outputs of 'account'. The transaction is submitted to the
+// transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsFromAccount(account modules.WalletAccountID, amount types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
 	if err := w.tg.Add(); err != nil {
 		return nil, err
 	}
@@ -94,7 +128,7 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 		return nil, modules.ErrLockedWallet
 	}
 
-	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee := w.managedFeeDensity(w.FeePolicy().Speed)
 	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
 	output := types.SiacoinOutput{
 		Value:      amount,
@@ -107,6 +141,7 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 			txnBuilder.Drop()
 		}
 	}()
+	txnBuilder.SetFundingAccount(account)
 	err = txnBuilder.FundSiacoins(amount.Add(tpoolFee))
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
@@ -136,7 +171,9 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 
 // SendSiacoinsMulti creates a transaction that includes the specified
 // outputs. The transaction is submitted to the transaction pool and is also
-// returned.
+// returned. The outputs are added to the last transaction of the set in
+// exactly the order given and before any other output, so output i can be
+// spent as SiacoinOutputID(i) of that transaction before it is broadcast.
 func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.Transaction, err error) {
 	if err := w.tg.Add(); err != nil {
 		return nil, err
@@ -155,7 +192,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	}()
 
 	// Add estimated transaction fee.
-	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee := w.managedFeeDensity(w.FeePolicy().Speed)
 	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
 	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
 	txnBuilder.AddMinerFee(tpoolFee)
@@ -173,6 +210,9 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 		return nil, build.ExtendErr("unable to fund transaction", err)
 	}
 
+	// The outputs must keep the caller's order, as output ids are derived
+	// from their index in the transaction. Change is returned by the parent
+	// transaction that FundSiacoins creates, not by this one.
 	for _, sco := range outputs {
 		txnBuilder.AddSiacoinOutput(sco)
 	}
@@ -204,7 +244,7 @@ func (w *Wallet) SendSiafunds(amount types.Currency, dest types.UnlockHash) ([]t
 		return nil, modules.ErrLockedWallet
 	}
 
-	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee := w.managedFeeDensity(w.FeePolicy().Speed)
 	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
 	tpoolFee = tpoolFee.Mul64(5)   // use large fee to ensure siafund transactions are selected by miners
 	output := types.SiafundOutput{
@@ -247,9 +287,14 @@ func (so sortedOutputs) Len() int {
 }
 
 // Less returns whether element 'i' is less than element 'j'. The currency
-// value of each output is used for comparison.
+// value of each output is used for comparison, and outputs of equal value are
+// ordered by id, so that the outputs chosen to fund a transaction do not
+// depend on the order in which they were collected.
 func (so sortedOutputs) Less(i, j int) bool {
-	return so.outputs[i].Value.Cmp(so.outputs[j].Value) < 0
+	if c := so.outputs[i].Value.Cmp(so.outputs[j].Value); c != 0 {
+		return c < 0
+	}
+	return bytes.Compare(so.ids[i][:], so.ids[j][:]) < 0
 }
 
 // Swap swaps two elements in the sortedOutputs set.
@@ -184,6 +184,89 @@ func TestIntegrationSortedOutputsSorting(t *testing.T) {
 	}
 }
 
+// TestSortedOutputsTies checks that outputs of equal value are sorted by id,
+// whatever order they are collected in.
+func TestSortedOutputsTies(t *testing.T) {
+	so := sortedOutputs{
+		ids: []types.SiacoinOutputID{{3}, {1}, {2}, {0}},
+		outputs: []types.SiacoinOutput{
+			{Value: types.NewCurrency64(5)},
+			{Value: types.NewCurrency64(5)},
+			{Value: types.NewCurrency64(1)},
+			{Value: types.NewCurrency64(5)},
+		},
+	}
+	sort.Sort(so)
+	expected := []types.SiacoinOutputID{{2}, {0}, {1}, {3}}
+	for i := range expected {
+		if so.ids[i] != expected[i] {
+			t.Fatal("outputs of equal value are not sorted by id:", so.ids)
+		}
+	}
+}
+
+// TestSendSiacoinsMultiOrder checks that SendSiacoinsMulti keeps the order of
+// the outputs it is given, so that building the same transaction again yields
+// the same transaction and output ids.
+func TestSendSiacoinsMultiOrder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Use decreasing values, so that sorting the outputs would reorder them.
+	numOutputs := 5
+	scos := make([]types.SiacoinOutput, numOutputs)
+	for i := range scos {
+		scos[i].Value = types.SiacoinPrecision.Mul64(uint64(numOutputs - i))
+		scos[i].UnlockHash = types.UnlockHash{byte(i + 1)}
+	}
+	txns, err := wt.wallet.SendSiacoinsMulti(scos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := txns[len(txns)-1]
+	if len(txn.SiacoinOutputs) != numOutputs {
+		t.Fatal("transaction has the wrong number of outputs:", len(txn.SiacoinOutputs))
+	}
+	for i, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash != scos[i].UnlockHash || !sco.Value.Equals(scos[i].Value) {
+			t.Fatal("output", i, "is out of order")
+		}
+	}
+
+	// Building the transaction twice from the same inputs and outputs gives
+	// the same ids. The builders are not dropped, as that would release the
+	// inputs of the sent transaction.
+	rebuild := func() types.Transaction {
+		tb := wt.wallet.StartTransaction()
+		for _, sci := range txn.SiacoinInputs {
+			tb.AddSiacoinInput(sci)
+		}
+		for _, fee := range txn.MinerFees {
+			tb.AddMinerFee(fee)
+		}
+		for _, sco := range scos {
+			tb.AddSiacoinOutput(sco)
+		}
+		rebuilt, _ := tb.View()
+		return rebuilt
+	}
+	first, second := rebuild(), rebuild()
+	if first.ID() != txn.ID() || second.ID() != txn.ID() {
+		t.Fatal("rebuilt transactions have different ids")
+	}
+	for i := range scos {
+		if first.SiacoinOutputID(uint64(i)) != txn.SiacoinOutputID(uint64(i)) || second.SiacoinOutputID(uint64(i)) != txn.SiacoinOutputID(uint64(i)) {
+			t.Fatal("rebuilt transactions have different output ids")
+		}
+	}
+}
+
 // TestSendSiacoinsFailed checks if SendSiacoins and SendSiacoinsMulti behave
 // correctly when funcing the Transaction succeeded but accepting it didn't.
 func TestSendSiacoinsAcceptTxnSetFailed(t *testing.T) {
@@ -256,3 +339,44 @@ func TestSendSiacoinsAcceptTxnSetFailed(t *testing.T) {
 		t.Fatalf("SendSiacoins failed: %v", err)
 	}
 }
+
+// TestSpendableBalance checks that SpendableBalance excludes outputs that are
+// timelocked beyond the supplied height, while ConfirmedBalance does not.
+func TestSpendableBalance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// With no timelocked outputs, the spendable balance should equal the
+	// confirmed balance.
+	height := wt.cs.Height()
+	confirmedBal, _, _ := wt.wallet.ConfirmedBalance()
+	if !wt.wallet.SpendableBalance(height).Equals(confirmedBal) {
+		t.Fatal("spendable balance should equal confirmed balance")
+	}
+
+	// Timelock every key holding an output past the current height.
+	wt.wallet.mu.Lock()
+	dbForEachSiacoinOutput(wt.wallet.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		sk := wt.wallet.keys[sco.UnlockHash]
+		sk.UnlockConditions.Timelock = height + 10
+		wt.wallet.keys[sco.UnlockHash] = sk
+	})
+	wt.wallet.mu.Unlock()
+
+	confirmedBal2, _, _ := wt.wallet.ConfirmedBalance()
+	if !confirmedBal2.Equals(confirmedBal) {
+		t.Fatal("confirmed balance should include timelocked outputs")
+	}
+	if !wt.wallet.SpendableBalance(height).IsZero() {
+		t.Fatal("spendable balance should exclude timelocked outputs")
+	}
+	if !wt.wallet.SpendableBalance(height + 10).Equals(confirmedBal) {
+		t.Fatal("outputs should be spendable once the timelock has passed")
+	}
+}
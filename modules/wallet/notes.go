@@ -0,0 +1,68 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errTransactionNoteTooLong = errors.New("transaction note is too long")
+	errUnknownTransaction     = errors.New("transaction is not related to the wallet")
+)
+
+// SetTransactionNote attaches a note to a confirmed or unconfirmed
+// transaction of the wallet. An empty note removes the transaction's note.
+// Notes are kept when the transaction is reverted or confirmed again.
+func (w *Wallet) SetTransactionNote(txid types.TransactionID, note string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	if len(note) > maxTransactionNoteLen {
+		return errTransactionNoteTooLong
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	known := false
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		if pt.TransactionID == txid {
+			known = true
+			break
+		}
+	}
+	if _, err := dbGetTransactionIndex(w.dbTx, txid); err == nil {
+		known = true
+	}
+	if !known {
+		return errUnknownTransaction
+	}
+
+	var err error
+	if note == "" {
+		err = dbDeleteTransactionNote(w.dbTx, txid)
+	} else {
+		err = dbPutTransactionNote(w.dbTx, txid, note)
+	}
+	if err != nil {
+		return err
+	}
+	w.syncDB()
+	return nil
+}
+
+// withNotes returns a copy of pts with the notes of the transactions filled
+// in. The caller must hold the wallet's lock.
+func (w *Wallet) withNotes(pts []modules.ProcessedTransaction) []modules.ProcessedTransaction {
+	if pts == nil {
+		return nil
+	}
+	noted := make([]modules.ProcessedTransaction, len(pts))
+	for i, pt := range pts {
+		pt.Note, _ = dbGetTransactionNote(w.dbTx, pt.TransactionID)
+		noted[i] = pt
+	}
+	return noted
+}
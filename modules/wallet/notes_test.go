@@ -0,0 +1,83 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestTransactionNotes checks that notes can be attached to confirmed and
+// unconfirmed transactions, and that they are reported with the
+// transactions.
+func TestTransactionNotes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Notes can only be attached to transactions of the wallet.
+	if err := wt.wallet.SetTransactionNote(types.TransactionID{}, "unknown"); err != errUnknownTransaction {
+		t.Fatal("expected errUnknownTransaction, got", err)
+	}
+
+	// Attach a note to a miner payout.
+	txns, err := wt.wallet.Transactions(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payout := txns[0].TransactionID
+	if err := wt.wallet.SetTransactionNote(payout, "mining"); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.SetTransactionNote(payout, strings.Repeat("a", maxTransactionNoteLen+1)); err != errTransactionNoteTooLong {
+		t.Fatal("expected errTransactionNoteTooLong, got", err)
+	}
+	if pt, found := wt.wallet.Transaction(payout); !found || pt.Note != "mining" {
+		t.Fatal("note was not reported by Transaction:", pt.Note)
+	}
+
+	// Attach a note to an unconfirmed transaction. The note is kept once the
+	// transaction is confirmed.
+	sent, err := wt.wallet.SendSiacoins(types.NewCurrency64(5000), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := sent[len(sent)-1].ID()
+	if err := wt.wallet.SetTransactionNote(txid, "rent payment"); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, pt := range wt.wallet.UnconfirmedTransactions() {
+		if pt.TransactionID == txid {
+			found = pt.Note == "rent payment"
+		}
+	}
+	if !found {
+		t.Fatal("note was not reported by UnconfirmedTransactions")
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if pt, found := wt.wallet.Transaction(txid); !found || pt.Note != "rent payment" {
+		t.Fatal("note was not kept when the transaction was confirmed:", pt.Note)
+	}
+
+	// An empty note removes the note.
+	if err := wt.wallet.SetTransactionNote(payout, ""); err != nil {
+		t.Fatal(err)
+	}
+	txns, err = wt.wallet.Transactions(0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pt := range txns {
+		if pt.Note != "" {
+			t.Fatal("note was not removed:", pt.Note)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package wallet
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errOutputAlreadyLocked is returned by LockOutput if the output has
+	// already been locked.
+	errOutputAlreadyLocked = errors.New("output is already locked")
+
+	// errOutputNotLocked is returned by UnlockOutput if the output has not
+	// been locked.
+	errOutputNotLocked = errors.New("output is not locked")
+)
+
+// LockOutput reserves the output with the given id, so that FundSiacoins and
+// FundSiafunds do not use it to fund transactions. Applications that build
+// several transactions at once can lock the outputs that they spend to keep
+// the transactions from conflicting, and must release them with UnlockOutput
+// once they are done with them. Locking an output that is already locked
+// fails, so that only one caller holds the reservation. Locks are only held
+// in memory and are released when the wallet restarts, or when the output is
+// spent.
+func (w *Wallet) LockOutput(id types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, locked := w.lockedOutputs[id]; locked {
+		return errOutputAlreadyLocked
+	}
+	w.lockedOutputs[id] = struct{}{}
+	return nil
+}
+
+// UnlockOutput releases an output that was reserved with LockOutput, allowing
+// the wallet to fund transactions with it again.
+func (w *Wallet) UnlockOutput(id types.OutputID) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, locked := w.lockedOutputs[id]; !locked {
+		return errOutputNotLocked
+	}
+	delete(w.lockedOutputs, id)
+	return nil
+}
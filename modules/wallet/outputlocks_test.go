@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestLockOutput checks that locked outputs are not used to fund
+// transactions until they are unlocked, and that the locks of spent outputs
+// are dropped.
+func TestLockOutput(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Lock every output of the wallet.
+	var ids []types.OutputID
+	wt.wallet.mu.Lock()
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(id types.SiacoinOutputID, _ types.SiacoinOutput) {
+		ids = append(ids, types.OutputID(id))
+	})
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range ids {
+		if err := wt.wallet.LockOutput(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wt.wallet.LockOutput(ids[0]); err != errOutputAlreadyLocked {
+		t.Fatal("expected errOutputAlreadyLocked, got", err)
+	}
+
+	// The wallet should not be able to fund a transaction.
+	b := wt.wallet.StartTransaction()
+	if err := b.FundSiacoins(types.SiacoinPrecision); err != modules.ErrIncompleteTransactions {
+		t.Fatal("expected ErrIncompleteTransactions, got", err)
+	}
+	b.Drop()
+
+	// After unlocking the outputs the transaction can be funded.
+	for _, id := range ids {
+		if err := wt.wallet.UnlockOutput(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := wt.wallet.UnlockOutput(ids[0]); err != errOutputNotLocked {
+		t.Fatal("expected errOutputNotLocked, got", err)
+	}
+	b = wt.wallet.StartTransaction()
+	if err := b.FundSiacoins(types.SiacoinPrecision); err != nil {
+		t.Fatal(err)
+	}
+	b.Drop()
+
+	// Locks are dropped once the output is spent.
+	txnSet, err := wt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spent := types.OutputID(txnSet[0].SiacoinInputs[0].ParentID)
+	if err := wt.wallet.LockOutput(spent); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	_, locked := wt.wallet.lockedOutputs[spent]
+	wt.wallet.mu.Unlock()
+	if locked {
+		t.Fatal("spent output is still locked")
+	}
+}
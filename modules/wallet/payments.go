@@ -0,0 +1,170 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+	"github.com/NebulousLabs/fastrand"
+)
+
+var (
+	errNoScheduledPayment = errors.New("no scheduled payment with that ID")
+	errZeroInterval       = errors.New("payment interval must be at least one block")
+	errZeroPayment        = errors.New("cannot schedule a payment of zero siacoins")
+)
+
+// SchedulePayment schedules a payment of amount to dest every interval
+// blocks, starting interval blocks from the current height. Payments are
+// made as the wallet processes new blocks, and are persisted so that they
+// survive restarts.
+func (w *Wallet) SchedulePayment(dest types.UnlockHash, amount types.Currency, interval types.BlockHeight) (string, error) {
+	if err := w.tg.Add(); err != nil {
+		return "", err
+	}
+	defer w.tg.Done()
+	if interval == 0 {
+		return "", errZeroInterval
+	} else if amount.IsZero() {
+		return "", errZeroPayment
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return "", err
+	}
+	sp := modules.ScheduledPayment{
+		ID:          hex.EncodeToString(fastrand.Bytes(8)),
+		Destination: dest,
+		Amount:      amount,
+		Interval:    interval,
+		NextHeight:  height + interval,
+	}
+	if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+		return "", err
+	}
+	w.syncDB()
+	w.log.Printf("Scheduled payment %v of %v to %v every %v blocks", sp.ID, amount.HumanString(), dest, interval)
+	return sp.ID, nil
+}
+
+// ScheduledPayments returns the payments that are currently scheduled,
+// ordered by the height at which they are next due.
+func (w *Wallet) ScheduledPayments() ([]modules.ScheduledPayment, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	payments := []modules.ScheduledPayment{}
+	err := dbForEachScheduledPayment(w.dbTx, func(_ string, sp modules.ScheduledPayment) {
+		payments = append(payments, sp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].NextHeight < payments[j].NextHeight
+	})
+	return payments, nil
+}
+
+// CancelScheduledPayment cancels a scheduled payment. Payments that have
+// already been submitted to the transaction pool are not affected.
+func (w *Wallet) CancelScheduledPayment(id string) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := dbGetScheduledPayment(w.dbTx, id); err == errNoKey {
+		return errNoScheduledPayment
+	} else if err != nil {
+		return err
+	}
+	if err := dbDeleteScheduledPayment(w.dbTx, id); err != nil {
+		return err
+	}
+	w.syncDB()
+	w.log.Println("Canceled scheduled payment", id)
+	return nil
+}
+
+// threadedMakeScheduledPayments makes every scheduled payment that is due at
+// the current height. A payment that cannot be made, because the wallet is
+// locked or underfunded, stays due and is retried when the next block
+// arrives. If several payments of the same schedule were missed, one is made
+// per block until the schedule has caught up.
+func (w *Wallet) threadedMakeScheduledPayments() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+	if !w.paymentLock.TryLock() {
+		return
+	}
+	defer w.paymentLock.Unlock()
+
+	// Collect the payments that are due.
+	w.mu.Lock()
+	unlocked := w.unlocked
+	height, err := dbGetConsensusHeight(w.dbTx)
+	var due []modules.ScheduledPayment
+	if err == nil {
+		err = dbForEachScheduledPayment(w.dbTx, func(_ string, sp modules.ScheduledPayment) {
+			if sp.NextHeight <= height {
+				due = append(due, sp)
+			}
+		})
+	}
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("ERROR: failed to load scheduled payments:", err)
+		return
+	}
+
+	for _, sp := range due {
+		var payErr error
+		if !unlocked {
+			payErr = modules.ErrLockedWallet
+		} else {
+			_, payErr = w.SendSiacoins(sp.Amount, sp.Destination)
+		}
+
+		w.mu.Lock()
+		// The payment may have been canceled while it was being made.
+		current, err := dbGetScheduledPayment(w.dbTx, sp.ID)
+		if err != nil {
+			w.mu.Unlock()
+			continue
+		}
+		if payErr != nil {
+			// Only log the first of a run of identical failures, so that a
+			// locked wallet doesn't fill the log.
+			if current.LastError != payErr.Error() {
+				w.log.Printf("WARN: scheduled payment %v could not be made: %v", sp.ID, payErr)
+			}
+			current.LastError = payErr.Error()
+		} else {
+			w.log.Printf("Made scheduled payment %v of %v to %v", sp.ID, sp.Amount.HumanString(), sp.Destination)
+			current.LastError = ""
+			current.NextHeight += current.Interval
+			current.PaymentsMade++
+		}
+		// Commit the update right away, so that a payment that was made is
+		// not made again if the wallet restarts.
+		if err := dbPutScheduledPayment(w.dbTx, current); err != nil {
+			w.log.Println("ERROR: failed to update scheduled payment:", err)
+		}
+		w.syncDB()
+		w.mu.Unlock()
+	}
+}
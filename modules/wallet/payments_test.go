@@ -0,0 +1,120 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// scheduledPayment returns the scheduled payment with the given ID.
+func (wt *walletTester) scheduledPayment(id string) (modules.ScheduledPayment, error) {
+	payments, err := wt.wallet.ScheduledPayments()
+	if err != nil {
+		return modules.ScheduledPayment{}, err
+	}
+	for _, sp := range payments {
+		if sp.ID == id {
+			return sp, nil
+		}
+	}
+	return modules.ScheduledPayment{}, errNoScheduledPayment
+}
+
+// TestScheduledPayments checks that scheduled payments are made every
+// interval blocks, are retried while the wallet is locked, and stop once they
+// are canceled.
+func TestScheduledPayments(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Invalid payments should be rejected.
+	dest := types.UnlockHash{1}
+	amount := types.SiacoinPrecision
+	if _, err := wt.wallet.SchedulePayment(dest, amount, 0); err != errZeroInterval {
+		t.Fatal("expected errZeroInterval, got", err)
+	}
+	if _, err := wt.wallet.SchedulePayment(dest, types.ZeroCurrency, 2); err != errZeroPayment {
+		t.Fatal("expected errZeroPayment, got", err)
+	}
+
+	height := wt.cs.Height()
+	id, err := wt.wallet.SchedulePayment(dest, amount, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := wt.scheduledPayment(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp.NextHeight != height+2 || sp.PaymentsMade != 0 {
+		t.Fatal("payment scheduled incorrectly:", sp)
+	}
+
+	// Mine blocks until the payment is due; it should be made once.
+	for i := 0; i < 2; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		sp, err := wt.scheduledPayment(id)
+		if err != nil {
+			return err
+		}
+		if sp.PaymentsMade != 1 || sp.NextHeight != height+4 {
+			return errors.New("payment was not made")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// While the wallet is locked, the payment should stay due and report why
+	// it was not made.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		sp, err := wt.scheduledPayment(id)
+		if err != nil {
+			return err
+		}
+		if sp.LastError != modules.ErrLockedWallet.Error() {
+			return errors.New("locked wallet was not reported: " + sp.LastError)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sp, _ := wt.scheduledPayment(id); sp.PaymentsMade != 1 {
+		t.Fatal("payment should not be made while the wallet is locked")
+	}
+
+	// Once canceled, the payment should no longer be listed.
+	if err := wt.wallet.CancelScheduledPayment(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.scheduledPayment(id); err != errNoScheduledPayment {
+		t.Fatal("payment still listed after being canceled")
+	}
+	if err := wt.wallet.CancelScheduledPayment(id); err != errNoScheduledPayment {
+		t.Fatal("expected errNoScheduledPayment, got", err)
+	}
+}
@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// managedReconcileSpentOutputs removes the entries of the spent outputs set
+// that no longer lock any funds. An entry is removed if it is older than
+// RespendTimeout, or if its output is not in the confirmed set because the
+// transaction spending it has been confirmed. Entries for outputs that are
+// created or spent by transactions in the transaction pool are kept, as the
+// confirmed set has not caught up with them yet.
+func (w *Wallet) managedReconcileSpentOutputs() (int, error) {
+	// Collect the outputs of unconfirmed transactions before acquiring the
+	// wallet lock, as the transaction pool calls into the wallet while
+	// holding its own lock.
+	pending := make(map[types.OutputID]struct{})
+	for _, txn := range w.tpool.TransactionList() {
+		for _, sci := range txn.SiacoinInputs {
+			pending[types.OutputID(sci.ParentID)] = struct{}{}
+		}
+		for i := range txn.SiacoinOutputs {
+			pending[types.OutputID(txn.SiacoinOutputID(uint64(i)))] = struct{}{}
+		}
+		for _, sfi := range txn.SiafundInputs {
+			pending[types.OutputID(sfi.ParentID)] = struct{}{}
+		}
+		for i := range txn.SiafundOutputs {
+			pending[types.OutputID(txn.SiafundOutputID(uint64(i)))] = struct{}{}
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return 0, err
+	}
+
+	// Entries cannot be deleted while iterating over the bucket, so the
+	// stale entries are collected first.
+	var stale []types.OutputID
+	err = dbForEachSpentOutput(w.dbTx, func(id types.OutputID, spendHeight types.BlockHeight) {
+		if spendHeight+RespendTimeout <= consensusHeight {
+			stale = append(stale, id)
+			return
+		}
+		if _, ok := pending[id]; ok {
+			return
+		}
+		_, scoErr := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(id))
+		_, sfoErr := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(id))
+		if scoErr == errNoKey && sfoErr == errNoKey {
+			stale = append(stale, id)
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range stale {
+		if err := dbDeleteSpentOutput(w.dbTx, id); err != nil {
+			return 0, err
+		}
+	}
+	if len(stale) > 0 {
+		w.log.Printf("Removed %v stale entries from the set of spent outputs", len(stale))
+	}
+	return len(stale), nil
+}
+
+// ReconcileSpentOutputs removes the entries of the wallet's spent outputs set
+// that no longer lock any funds, and returns how many were removed. The set is
+// reconciled every spentOutputsReconcileInterval, but it can be reconciled
+// immediately, for example after a rescan, so that its entries match the
+// confirmed set again.
+func (w *Wallet) ReconcileSpentOutputs() (int, error) {
+	if err := w.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer w.tg.Done()
+	return w.managedReconcileSpentOutputs()
+}
+
+// threadedReconcileSpentOutputs reconciles the spent outputs set every
+// spentOutputsReconcileInterval until the wallet is closed.
+func (w *Wallet) threadedReconcileSpentOutputs() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	for {
+		select {
+		case <-time.After(spentOutputsReconcileInterval):
+		case <-w.tg.StopChan():
+			return
+		}
+		if _, err := w.managedReconcileSpentOutputs(); err != nil {
+			w.log.Println("ERROR: failed to reconcile spent outputs:", err)
+		}
+	}
+}
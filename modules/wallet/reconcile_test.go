@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestReconcileSpentOutputs checks that reconciling the spent outputs set
+// removes entries for outputs that are not in the confirmed set, and keeps
+// the entries of outputs that are still spent by pending transactions.
+func TestReconcileSpentOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Spend some outputs in a pending transaction, and add an entry for an
+	// output that does not exist.
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), types.UnlockHash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet.mu.Lock()
+	height, err := dbGetConsensusHeight(wt.wallet.dbTx)
+	if err == nil {
+		err = dbPutSpentOutput(wt.wallet.dbTx, types.OutputID{1}, height)
+	}
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := wt.wallet.ReconcileSpentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	} else if removed != 1 {
+		t.Fatal("expected 1 entry to be removed, got", removed)
+	}
+	wt.wallet.mu.Lock()
+	defer wt.wallet.mu.Unlock()
+	if _, err := dbGetSpentOutput(wt.wallet.dbTx, types.OutputID{1}); err != errNoKey {
+		t.Fatal("entry for a missing output was not removed")
+	}
+	for _, txn := range txns {
+		for _, sci := range txn.SiacoinInputs {
+			if _, err := dbGetSpentOutput(wt.wallet.dbTx, types.OutputID(sci.ParentID)); err != nil {
+				t.Fatal("entry for an output spent by a pending transaction was removed:", err)
+			}
+		}
+	}
+}
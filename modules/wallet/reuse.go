@@ -0,0 +1,75 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// receivesFunds returns true if any output of pt pays uh.
+func receivesFunds(pt modules.ProcessedTransaction, uh types.UnlockHash) bool {
+	for _, output := range pt.Outputs {
+		if output.RelatedAddress != uh {
+			continue
+		}
+		switch output.FundType {
+		case types.SpecifierSiacoinOutput, types.SpecifierSiafundOutput, types.SpecifierMinerPayout:
+			return true
+		}
+	}
+	return false
+}
+
+// dbIsReused returns true if uh has received funds in more than one of the
+// confirmed transactions in bucketProcessedTransactions.
+func dbIsReused(tx *bolt.Tx, uh types.UnlockHash) bool {
+	txnIndices, _ := dbGetAddrTransactions(tx, uh)
+	var receipts int
+	for _, i := range txnIndices {
+		pt, err := dbGetProcessedTransaction(tx, i)
+		if err != nil {
+			continue
+		}
+		if receivesFunds(pt, uh) {
+			receipts++
+		}
+		if receipts > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsReused returns true if the address has received funds in more than one
+// confirmed transaction. Receiving funds at the same address repeatedly links
+// those payments together, so a fresh address from NextAddress should be used
+// for each payment.
+func (w *Wallet) IsReused(uh types.UnlockHash) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncDB()
+	return dbIsReused(w.dbTx, uh)
+}
+
+// ReusedAddresses returns every address of the wallet that has received funds
+// in more than one confirmed transaction, sorted.
+func (w *Wallet) ReusedAddresses() []types.UnlockHash {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.syncDB()
+
+	var addrs []types.UnlockHash
+	dbForEach(w.dbTx.Bucket(bucketAddrTransactions), func(uh types.UnlockHash, _ []uint64) {
+		if _, ok := w.keys[uh]; ok && dbIsReused(w.dbTx, uh) {
+			addrs = append(addrs, uh)
+		}
+	})
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i][:], addrs[j][:]) < 0
+	})
+	return addrs
+}
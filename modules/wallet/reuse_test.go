@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestReceivesFunds checks that only outputs paying an address count as the
+// address receiving funds.
+func TestReceivesFunds(t *testing.T) {
+	uh := types.UnlockHash{1}
+	pt := modules.ProcessedTransaction{
+		Inputs: []modules.ProcessedInput{{FundType: types.SpecifierSiacoinInput, RelatedAddress: uh}},
+		Outputs: []modules.ProcessedOutput{
+			{FundType: types.SpecifierSiacoinOutput, RelatedAddress: types.UnlockHash{2}},
+			{FundType: types.SpecifierMinerFee},
+		},
+	}
+	if receivesFunds(pt, uh) {
+		t.Fatal("spending from an address should not count as receiving funds")
+	}
+	pt.Outputs = append(pt.Outputs, modules.ProcessedOutput{FundType: types.SpecifierSiacoinOutput, RelatedAddress: uh})
+	if !receivesFunds(pt, uh) {
+		t.Fatal("siacoin output to the address should count as receiving funds")
+	}
+}
+
+// TestIsReused checks that an address is reported as reused once it has
+// received funds in two confirmed transactions.
+func TestIsReused(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := uc.UnlockHash()
+	for i := 0; i < 2; i++ {
+		if wt.wallet.IsReused(addr) {
+			t.Fatal("address reported as reused after", i, "payments")
+		}
+		_, err = wt.wallet.SendSiacoins(types.SiacoinPrecision, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wt.addBlockNoPayout(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !wt.wallet.IsReused(addr) {
+		t.Fatal("address should be reported as reused")
+	}
+	reused := wt.wallet.ReusedAddresses()
+	if len(reused) != 1 || reused[0] != addr {
+		t.Fatal("expected only the reused address, got", reused)
+	}
+}
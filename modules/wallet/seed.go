@@ -15,6 +15,10 @@ import (
 
 var (
 	errKnownSeed = errors.New("seed is already known")
+
+	// errSeedIndexOverflow is returned if generating the requested number of
+	// addresses would overflow the primary seed's key index.
+	errSeedIndexOverflow = errors.New("cannot generate that many addresses: seed index would overflow")
 )
 
 type (
@@ -124,6 +128,9 @@ func (w *Wallet) nextPrimarySeedAddresses(tx *bolt.Tx, n uint64) ([]types.Unlock
 	if err != nil {
 		return []types.UnlockConditions{}, err
 	}
+	if progress+n < progress {
+		return []types.UnlockConditions{}, errSeedIndexOverflow
+	}
 	if err = dbPutPrimarySeedProgress(tx, progress+n); err != nil {
 		return []types.UnlockConditions{}, err
 	}
@@ -366,7 +373,7 @@ func (w *Wallet) SweepSeed(seed modules.Seed) (coins, funds types.Currency, err
 	// scan blockchain for outputs, filtering out 'dust' (outputs that cost
 	// more in fees than they are worth)
 	s := newSeedScanner(seed, w.log)
-	_, maxFee := w.tpool.FeeEstimation()
+	maxFee := w.managedFeeDensity(w.FeePolicy().Speed)
 	const outputSize = 350 // approx. size in bytes of an output and accompanying signature
 	const maxOutputs = 50  // approx. number of outputs that a transaction can handle
 	s.dustThreshold = maxFee.Mul64(outputSize)
@@ -477,3 +477,67 @@ func TestGenerateKeys(t *testing.T) {
 		}
 	}
 }
+
+// TestNextAddresses checks that NextAddresses generates the same addresses as
+// sequential calls to NextAddress, and that it refuses to overflow the seed
+// index.
+func TestNextAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	wt, err := createBlankWalletTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	seed, err := wt.wallet.Encrypt(crypto.TwofishKey{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.wallet.Unlock(crypto.TwofishKey(crypto.HashObject(seed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ucs, err := wt.wallet.NextAddresses(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ucs) != 5 {
+		t.Fatal("expected 5 addresses, got", len(ucs))
+	}
+	for i, uc := range ucs {
+		if uc.UnlockHash() != generateSpendableKey(seed, uint64(i)).UnlockConditions.UnlockHash() {
+			t.Fatal("address", i, "does not match the address at that seed index")
+		}
+	}
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uc.UnlockHash() != generateSpendableKey(seed, 5).UnlockConditions.UnlockHash() {
+		t.Fatal("NextAddresses did not advance the seed index correctly")
+	}
+
+	// Generating addresses past the end of the seed index should fail without
+	// changing the seed progress.
+	wt.wallet.mu.Lock()
+	err = dbPutPrimarySeedProgress(wt.wallet.dbTx, ^uint64(0)-1)
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.NextAddresses(2); err != errSeedIndexOverflow {
+		t.Fatal("expected errSeedIndexOverflow, got", err)
+	}
+	wt.wallet.mu.Lock()
+	progress, err := dbGetPrimarySeedProgress(wt.wallet.dbTx)
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress != ^uint64(0)-1 {
+		t.Fatal("seed progress changed after a failed call:", progress)
+	}
+}
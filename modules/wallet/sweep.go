@@ -0,0 +1,147 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errNothingToSweep is returned by Sweep if the wallet has no spendable
+	// outputs.
+	errNothingToSweep = errors.New("wallet has no spendable outputs to sweep")
+
+	// errSweepFeeTooHigh is returned by Sweep if the transaction fee is at
+	// least as large as the value of the outputs being swept.
+	errSweepFeeTooHigh = errors.New("transaction fee exceeds the value of the swept outputs")
+)
+
+// sweepTransaction returns a signed transaction that spends every output in
+// 'so' to 'dest', paying 'fee' and leaving no change.
+func (w *Wallet) sweepTransaction(so sortedOutputs, total, fee types.Currency, dest types.UnlockHash) types.Transaction {
+	var txn types.Transaction
+	for i := range so.ids {
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: w.keys[so.outputs[i].UnlockHash].UnlockConditions,
+		})
+	}
+	txn.SiacoinOutputs = []types.SiacoinOutput{{
+		Value:      total.Sub(fee),
+		UnlockHash: dest,
+	}}
+	txn.MinerFees = []types.Currency{fee}
+	for _, sci := range txn.SiacoinInputs {
+		addSignatures(&txn, types.FullCoveredFields, sci.UnlockConditions, crypto.Hash(sci.ParentID), w.keys[sci.UnlockConditions.UnlockHash()])
+	}
+	return txn
+}
+
+// managedCreateSweepTransaction creates a transaction that spends the
+// wallet's spendable siacoin outputs to 'dest', and marks those outputs as
+// spent.
+func (w *Wallet) managedCreateSweepTransaction(dest types.UnlockHash) (types.Transaction, error) {
+	// dustThreshold and feePerByte have to be obtained separate from the
+	// lock.
+	dustThreshold := w.DustThreshold()
+	feePerByte := w.managedFeeDensity(w.FeePolicy().Speed)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+
+	// Collect the confirmed outputs that can be spent, skipping outputs that
+	// are dust, timelocked, or were spent recently.
+	var so sortedOutputs
+	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		// Outputs of other accounts are left alone.
+		if w.accountAddrs[sco.UnlockHash] != modules.DefaultWalletAccount {
+			return
+		}
+		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
+			so.ids = append(so.ids, scoid)
+			so.outputs = append(so.outputs, sco)
+		}
+	})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	if len(so.ids) == 0 {
+		return types.Transaction{}, errNothingToSweep
+	}
+
+	// Spend the largest outputs first, up to the input budget.
+	sort.Sort(sort.Reverse(so))
+	if len(so.ids) > sweepInputBudget {
+		so.ids = so.ids[:sweepInputBudget]
+		so.outputs = so.outputs[:sweepInputBudget]
+	}
+	var total types.Currency
+	for _, sco := range so.outputs {
+		total = total.Add(sco.Value)
+	}
+
+	// Size the transaction using the total as the fee, which encodes at
+	// least as long as the real fee and output value will, then pay for that
+	// size.
+	size := len(encoding.Marshal(w.sweepTransaction(so, total, total, dest)))
+	fee := feePerByte.Mul64(uint64(size))
+	if fee.Cmp(total) >= 0 {
+		return types.Transaction{}, errSweepFeeTooHigh
+	}
+	txn := w.sweepTransaction(so, total, fee, dest)
+
+	// Mark all outputs that were spent as spent.
+	for _, scoid := range so.ids {
+		if err = dbPutSpentOutput(w.dbTx, types.OutputID(scoid), consensusHeight); err != nil {
+			return types.Transaction{}, err
+		}
+	}
+	return txn, nil
+}
+
+// Sweep creates a transaction that sends the wallet's entire spendable
+// siacoin balance, minus the transaction fee, to 'dest' without a change
+// output. Outputs that are timelocked, recently spent, or dust are left in
+// the wallet. If the wallet holds more than sweepInputBudget spendable
+// outputs, only the largest are swept, and Sweep can be called again to sweep
+// the rest. The transaction is submitted to the transaction pool and is also
+// returned.
+func (w *Wallet) Sweep(dest types.UnlockHash) (txn types.Transaction, err error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, err
+	}
+	defer w.tg.Done()
+	if !w.unlocked {
+		w.log.Println("Attempt to sweep the wallet has failed - wallet is locked")
+		return types.Transaction{}, modules.ErrLockedWallet
+	}
+
+	txn, err = w.managedCreateSweepTransaction(dest)
+	if err != nil {
+		w.log.Println("Attempt to sweep the wallet has failed - failed to create transaction:", err)
+		return types.Transaction{}, build.ExtendErr("unable to create sweep transaction", err)
+	}
+	err = w.tpool.AcceptTransactionSet([]types.Transaction{txn})
+	if err != nil {
+		// The outputs were not spent, so allow them to be spent again.
+		w.mu.Lock()
+		for _, sci := range txn.SiacoinInputs {
+			dbDeleteSpentOutput(w.dbTx, types.OutputID(sci.ParentID))
+		}
+		w.mu.Unlock()
+		w.log.Println("Attempt to sweep the wallet has failed - transaction pool rejected transaction:", err)
+		return types.Transaction{}, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	w.log.Println("Submitted a sweep transaction for value", txn.SiacoinOutputs[0].Value.HumanString(), "with fees", txn.MinerFees[0].HumanString(), "ID:", txn.ID())
+	return txn, nil
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSweep checks that Sweep sends the whole balance of the wallet to the
+// destination in one transaction without change.
+func TestSweep(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	balance, _, _ := wt.wallet.ConfirmedBalance()
+	dest := types.UnlockHash{1}
+	txn, err := wt.wallet.Sweep(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.SiacoinOutputs) != 1 || txn.SiacoinOutputs[0].UnlockHash != dest {
+		t.Fatal("sweep should have a single output to the destination:", txn.SiacoinOutputs)
+	}
+	if len(txn.MinerFees) != 1 || txn.MinerFees[0].IsZero() {
+		t.Fatal("sweep should pay a fee:", txn.MinerFees)
+	}
+	if sum := txn.SiacoinOutputs[0].Value.Add(txn.MinerFees[0]); !sum.Equals(balance) {
+		t.Fatalf("sweep should spend the balance of %v, spent %v", balance, sum)
+	}
+
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _, _ := wt.wallet.ConfirmedBalance(); !balance.IsZero() {
+		t.Fatal("wallet should be empty after the sweep, balance is", balance)
+	}
+	_, err = wt.wallet.Sweep(dest)
+	if err == nil || !strings.Contains(err.Error(), errNothingToSweep.Error()) {
+		t.Fatal("expected errNothingToSweep, got", err)
+	}
+}
+
+// TestSweepFeeTooHigh checks that Sweep fails when the fee would consume the
+// whole balance.
+func TestSweepFeeTooHigh(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	err = wt.wallet.SetFeePolicy(modules.WalletFeePolicy{
+		MinFee: types.SiacoinPrecision.Mul64(1e9),
+		Speed:  modules.FeeSpeedNormal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = wt.wallet.Sweep(types.UnlockHash{1})
+	if err == nil || !strings.Contains(err.Error(), errSweepFeeTooHigh.Error()) {
+		t.Fatal("expected errSweepFeeTooHigh, got", err)
+	}
+
+	// The outputs should not have been marked as spent.
+	wt.wallet.mu.Lock()
+	defer wt.wallet.mu.Unlock()
+	err = dbForEachSiacoinOutput(wt.wallet.dbTx, func(scoid types.SiacoinOutputID, _ types.SiacoinOutput) {
+		if _, err := dbGetSpentOutput(wt.wallet.dbTx, types.OutputID(scoid)); err == nil {
+			t.Error("output was marked as spent after a failed sweep")
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"sort"
 
+	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
@@ -19,9 +20,26 @@ var (
 	// meaning that future calls to Sign will result in an invalid transaction.
 	errBuilderAlreadySigned = errors.New("sign has already been called on this transaction builder, multiple calls can cause issues")
 
+	// errCoveredFieldsChanged indicates that a field covered by one of the
+	// builder's signatures was modified after signing, meaning that the
+	// signature is no longer valid.
+	errCoveredFieldsChanged = errors.New("transaction fields covered by a signature were modified after signing")
+
+	// errEmptyTransactionSet indicates that a transaction set without any
+	// transactions was provided to be merged.
+	errEmptyTransactionSet = errors.New("cannot merge an empty transaction set")
+
+	// errMergeWholeTransaction indicates that a transaction set could not be
+	// merged because one of its signatures covers the whole transaction, and
+	// therefore cannot be adjusted to cover the merged transaction.
+	errMergeWholeTransaction = errors.New("cannot merge a transaction with a signature that covers the whole transaction")
+
 	// errDustOutput indicates an output is not spendable because it is dust.
 	errDustOutput = errors.New("output is too small")
 
+	// errOutputLocked indicates an output has been locked with LockOutput.
+	errOutputLocked = errors.New("output has been locked")
+
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
@@ -35,8 +53,15 @@ var (
 type transactionBuilder struct {
 	// 'signed' indicates that at least one transaction signature has been
 	// added to the wallet, meaning that future calls to 'Sign' will fail.
+	//
+	// 'sigHashes' maps the index of each signature added by 'Sign' to the
+	// hash of the fields it covered at signing time, so that modifications to
+	// the covered fields can be detected before the transaction is used.
 	parents     []types.Transaction
+	sigHashes   map[int]crypto.Hash
 	signed      bool
+	strategy    modules.FundingStrategy
+	account     modules.WalletAccountID
 	transaction types.Transaction
 
 	newParents            []int
@@ -97,6 +122,10 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	if output.Value.Cmp(dustThreshold) < 0 {
 		return errDustOutput
 	}
+	// Check that this output has not been reserved by the application.
+	if _, locked := w.lockedOutputs[types.OutputID(id)]; locked {
+		return errOutputLocked
+	}
 	// Check that this output has not recently been spent by the wallet.
 	spendHeight, err := dbGetSpentOutput(tx, types.OutputID(id))
 	if err == nil {
@@ -112,6 +141,29 @@ func (w *Wallet) checkOutput(tx *bolt.Tx, currentHeight types.BlockHeight, id ty
 	return nil
 }
 
+// canConsolidate returns true if 'amount' can be funded by the smallest
+// spendable outputs of the sorted set without exceeding
+// consolidateInputBudget inputs. 'so' is expected to be sorted in ascending
+// order.
+func (w *Wallet) canConsolidate(tx *bolt.Tx, currentHeight types.BlockHeight, so sortedOutputs, amount, dustThreshold types.Currency) bool {
+	var fund types.Currency
+	var inputs int
+	for i := range so.ids {
+		if inputs >= consolidateInputBudget {
+			break
+		}
+		if w.checkOutput(tx, currentHeight, so.ids[i], so.outputs[i], dustThreshold) != nil {
+			continue
+		}
+		fund = fund.Add(so.outputs[i].Value)
+		inputs++
+		if fund.Cmp(amount) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // FundSiacoins will add a siacoin input of exactly 'amount' to the
 // transaction. A parent transaction may be needed to achieve an input with the
 // correct value. The siacoin input will not be signed until 'Sign' is called
@@ -128,9 +180,13 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 		return err
 	}
 
-	// Collect a value-sorted set of siacoin outputs.
+	// Collect a value-sorted set of siacoin outputs. Only the outputs of the
+	// funding account are used.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(tb.wallet.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if tb.wallet.accountAddrs[sco.UnlockHash] != tb.account {
+			return
+		}
 		so.ids = append(so.ids, scoid)
 		so.outputs = append(so.outputs, sco)
 	})
@@ -140,16 +196,22 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	// Add all of the unconfirmed outputs as well.
 	for _, upt := range tb.wallet.unconfirmedProcessedTransactions {
 		for i, sco := range upt.Transaction.SiacoinOutputs {
-			// Determine if the output belongs to the wallet.
+			// Determine if the output belongs to the wallet and the account.
 			_, exists := tb.wallet.keys[sco.UnlockHash]
-			if !exists {
+			if !exists || tb.wallet.accountAddrs[sco.UnlockHash] != tb.account {
 				continue
 			}
 			so.ids = append(so.ids, upt.Transaction.SiacoinOutputID(uint64(i)))
 			so.outputs = append(so.outputs, sco)
 		}
 	}
-	sort.Sort(sort.Reverse(so))
+	// Outputs are spent largest-first unless the builder is consolidating
+	// dust and the amount can be covered by the smallest outputs within the
+	// input budget.
+	sort.Sort(so)
+	if tb.strategy != modules.FundConsolidateDust || !tb.wallet.canConsolidate(tb.wallet.dbTx, consensusHeight, so, amount, dustThreshold) {
+		sort.Sort(sort.Reverse(so))
+	}
 
 	// Create and fund a parent transaction that will add the correct amount of
 	// siacoins to the transaction.
@@ -166,7 +228,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 		sco := so.outputs[i]
 		// Check that the output can be spent.
 		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
-			if err == errSpendHeightTooHigh {
+			if err == errSpendHeightTooHigh || err == errOutputLocked {
 				potentialFund = potentialFund.Add(sco.Value)
 			}
 			continue
@@ -196,7 +258,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 
 	// Create and add the output that will be used to fund the standard
 	// transaction.
-	parentUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	parentUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -207,17 +269,27 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	}
 	parentTxn.SiacoinOutputs = append(parentTxn.SiacoinOutputs, exactOutput)
 
-	// Create a refund output if needed.
+	// Create a refund output if needed. Change below the change threshold
+	// would cost more in fees to spend than it is worth, so it is paid to the
+	// miners instead of being returned as a dust output.
 	if !amount.Equals(fund) {
-		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		refund, err := fund.SubChecked(amount)
 		if err != nil {
-			return err
-		}
-		refundOutput := types.SiacoinOutput{
-			Value:      fund.Sub(amount),
-			UnlockHash: refundUnlockConditions.UnlockHash(),
+			return build.ExtendErr("unable to compute refund", err)
+		}
+		if refund.Cmp(tb.wallet.changeThreshold(dustThreshold)) < 0 {
+			parentTxn.MinerFees = append(parentTxn.MinerFees, refund)
+		} else {
+			refundUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
+			if err != nil {
+				return err
+			}
+			refundOutput := types.SiacoinOutput{
+				Value:      refund,
+				UnlockHash: refundUnlockConditions.UnlockHash(),
+			}
+			parentTxn.SiacoinOutputs = append(parentTxn.SiacoinOutputs, refundOutput)
 		}
-		parentTxn.SiacoinOutputs = append(parentTxn.SiacoinOutputs, refundOutput)
 	}
 
 	// Sign all of the inputs to the parent transaction.
@@ -280,6 +352,11 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 			return err
 		}
 
+		// Only outputs of the funding account may be spent.
+		if tb.wallet.accountAddrs[sfo.UnlockHash] != tb.account {
+			continue
+		}
+
 		// Check that this output has not recently been spent by the wallet.
 		spendHeight, err := dbGetSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid))
 		if err != nil {
@@ -291,7 +368,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 		if consensusHeight < RespendTimeout {
 			allowedHeight = 0
 		}
-		if spendHeight > allowedHeight {
+		if _, locked := tb.wallet.lockedOutputs[types.OutputID(sfoid)]; spendHeight > allowedHeight || locked {
 			potentialFund = potentialFund.Add(sfo.Value)
 			continue
 		}
@@ -301,7 +378,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 		}
 
 		// Add a siafund input for this output.
-		parentClaimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		parentClaimUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -329,7 +406,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 
 	// Create and add the output that will be used to fund the standard
 	// transaction.
-	parentUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	parentUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -341,12 +418,16 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 
 	// Create a refund output if needed.
 	if !amount.Equals(fund) {
-		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		refundUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
+		refund, err := fund.SubChecked(amount)
+		if err != nil {
+			return build.ExtendErr("unable to compute refund", err)
+		}
 		refundOutput := types.SiafundOutput{
-			Value:      fund.Sub(amount),
+			Value:      refund,
 			UnlockHash: refundUnlockConditions.UnlockHash(),
 		}
 		parentTxn.SiafundOutputs = append(parentTxn.SiafundOutputs, refundOutput)
@@ -382,11 +463,98 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 	return nil
 }
 
+// SetFundingStrategy sets the strategy used by 'FundSiacoins' to select the
+// outputs that fund the transaction.
+func (tb *transactionBuilder) SetFundingStrategy(strategy modules.FundingStrategy) {
+	tb.strategy = strategy
+}
+
+// SetFundingAccount sets the account whose outputs are used by 'FundSiacoins'
+// and 'FundSiafunds' to fund the transaction.
+func (tb *transactionBuilder) SetFundingAccount(account modules.WalletAccountID) {
+	tb.account = account
+}
+
 // AddParents adds a set of parents to the transaction.
 func (tb *transactionBuilder) AddParents(newParents []types.Transaction) {
 	tb.parents = append(tb.parents, newParents...)
 }
 
+// MergeTransactionSet merges a transaction set that was built independently,
+// such as by another party, into the transaction. All transactions but the
+// last are treated as parents and are added unless the builder already has
+// them. The fields of the last transaction are appended to the transaction,
+// and the covered fields of its signatures are shifted to point at the fields'
+// new indices. Signatures that were already created over the original
+// transaction will need to be recomputed over the merged transaction.
+func (tb *transactionBuilder) MergeTransactionSet(txnSet []types.Transaction) error {
+	if tb.signed {
+		return errBuilderAlreadySigned
+	}
+	if len(txnSet) == 0 {
+		return errEmptyTransactionSet
+	}
+	txn := txnSet[len(txnSet)-1]
+	for _, sig := range txn.TransactionSignatures {
+		if sig.CoveredFields.WholeTransaction {
+			return errMergeWholeTransaction
+		}
+	}
+
+	// Add the parents that the builder does not already have.
+	known := make(map[types.TransactionID]struct{})
+	for _, parent := range tb.parents {
+		known[parent.ID()] = struct{}{}
+	}
+	for _, parent := range txnSet[:len(txnSet)-1] {
+		if _, exists := known[parent.ID()]; exists {
+			continue
+		}
+		known[parent.ID()] = struct{}{}
+		tb.parents = append(tb.parents, parent)
+	}
+
+	// Shift the covered fields of the merged signatures by the number of
+	// fields that precede them in the merged transaction.
+	shift := func(indices []uint64, offset int) []uint64 {
+		if indices == nil {
+			return nil
+		}
+		shifted := make([]uint64, len(indices))
+		for i, index := range indices {
+			shifted[i] = index + uint64(offset)
+		}
+		return shifted
+	}
+	t := &tb.transaction
+	for _, sig := range txn.TransactionSignatures {
+		cf := sig.CoveredFields
+		sig.CoveredFields = types.CoveredFields{
+			SiacoinInputs:         shift(cf.SiacoinInputs, len(t.SiacoinInputs)),
+			SiacoinOutputs:        shift(cf.SiacoinOutputs, len(t.SiacoinOutputs)),
+			FileContracts:         shift(cf.FileContracts, len(t.FileContracts)),
+			FileContractRevisions: shift(cf.FileContractRevisions, len(t.FileContractRevisions)),
+			StorageProofs:         shift(cf.StorageProofs, len(t.StorageProofs)),
+			SiafundInputs:         shift(cf.SiafundInputs, len(t.SiafundInputs)),
+			SiafundOutputs:        shift(cf.SiafundOutputs, len(t.SiafundOutputs)),
+			MinerFees:             shift(cf.MinerFees, len(t.MinerFees)),
+			ArbitraryData:         shift(cf.ArbitraryData, len(t.ArbitraryData)),
+			TransactionSignatures: shift(cf.TransactionSignatures, len(t.TransactionSignatures)),
+		}
+		t.TransactionSignatures = append(t.TransactionSignatures, sig)
+	}
+	t.SiacoinInputs = append(t.SiacoinInputs, txn.SiacoinInputs...)
+	t.SiacoinOutputs = append(t.SiacoinOutputs, txn.SiacoinOutputs...)
+	t.FileContracts = append(t.FileContracts, txn.FileContracts...)
+	t.FileContractRevisions = append(t.FileContractRevisions, txn.FileContractRevisions...)
+	t.StorageProofs = append(t.StorageProofs, txn.StorageProofs...)
+	t.SiafundInputs = append(t.SiafundInputs, txn.SiafundInputs...)
+	t.SiafundOutputs = append(t.SiafundOutputs, txn.SiafundOutputs...)
+	t.MinerFees = append(t.MinerFees, txn.MinerFees...)
+	t.ArbitraryData = append(t.ArbitraryData, txn.ArbitraryData...)
+	return nil
+}
+
 // AddMinerFee adds a miner fee to the transaction, returning the index of the
 // miner fee within the transaction.
 func (tb *transactionBuilder) AddMinerFee(fee types.Currency) uint64 {
@@ -479,6 +647,7 @@ func (tb *transactionBuilder) Drop() {
 	}
 
 	tb.parents = nil
+	tb.sigHashes = nil
 	tb.signed = false
 	tb.transaction = types.Transaction{}
 
@@ -500,9 +669,14 @@ func (tb *transactionBuilder) Drop() {
 // more fields to be added.
 //
 // Sign should not be called more than once. If, for some reason, there is an
-// error while calling Sign, the builder should be dropped.
+// error while calling Sign, the builder should be dropped. If the fields
+// covered by the first call were modified in the meantime, a second call will
+// return errCoveredFieldsChanged.
 func (tb *transactionBuilder) Sign(wholeTransaction bool) ([]types.Transaction, error) {
 	if tb.signed {
+		if err := tb.checkCoveredFields(); err != nil {
+			return nil, err
+		}
 		return nil, errBuilderAlreadySigned
 	}
 
@@ -570,11 +744,42 @@ func (tb *transactionBuilder) Sign(wholeTransaction bool) ([]types.Transaction,
 		tb.signed = true // Signed is set to true after one successful signature to indicate that future signings can cause issues.
 	}
 
+	// Record what each new signature covers so that later modifications can
+	// be detected.
+	tb.sigHashes = make(map[int]crypto.Hash)
+	for _, sigIndex := range tb.transactionSignatures {
+		tb.sigHashes[sigIndex] = tb.transaction.SigHash(sigIndex)
+	}
+
 	// Get the transaction set and delete the transaction from the registry.
 	txnSet := append(tb.parents, tb.transaction)
 	return txnSet, nil
 }
 
+// checkCoveredFields returns errCoveredFieldsChanged if any of the fields
+// covered by the signatures added in 'Sign' have been modified since signing.
+// The transaction returned by 'Sign' shares memory with the builder, so a
+// caller that mutates it will invalidate the signatures.
+func (tb *transactionBuilder) checkCoveredFields() error {
+	for sigIndex, hash := range tb.sigHashes {
+		if sigIndex >= len(tb.transaction.TransactionSignatures) || tb.transaction.SigHash(sigIndex) != hash {
+			return errCoveredFieldsChanged
+		}
+	}
+	return nil
+}
+
+// Export returns the transaction along with all of its parents, ready to be
+// submitted to the transaction pool. An error is returned if any fields
+// covered by the builder's signatures have been modified since 'Sign' was
+// called.
+func (tb *transactionBuilder) Export() ([]types.Transaction, error) {
+	if err := tb.checkCoveredFields(); err != nil {
+		return nil, err
+	}
+	return append(tb.parents, tb.transaction), nil
+}
+
 // ViewTransaction returns a transaction-in-progress along with all of its
 // parents, specified by id. An error is returned if the id is invalid.  Note
 // that ids become invalid for a transaction after 'SignTransaction' has been
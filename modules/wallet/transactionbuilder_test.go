@@ -4,6 +4,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -165,6 +166,50 @@ func TestDoubleSignError(t *testing.T) {
 	}
 }
 
+// TestModifiedCoveredFields checks that modifying a field covered by the
+// builder's signatures after a partial 'Sign' is detected by a second call to
+// 'Sign' and by 'Export'.
+func TestModifiedCoveredFields(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	b := wt.wallet.StartTransaction()
+	txnFund := types.NewCurrency64(100e9)
+	err = b.FundSiacoins(txnFund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = b.AddMinerFee(txnFund)
+	txnSet, err := b.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Adding new fields does not affect the signatures.
+	b.AddArbitraryData([]byte("uncovered"))
+	if _, err := b.Export(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Sign(false); err != errBuilderAlreadySigned {
+		t.Fatal("expected errBuilderAlreadySigned, got", err)
+	}
+
+	// Modifying the returned transaction changes the covered miner fee.
+	txnSet[len(txnSet)-1].MinerFees[0] = types.NewCurrency64(1)
+	if _, err := b.Export(); err != errCoveredFieldsChanged {
+		t.Fatal("expected errCoveredFieldsChanged, got", err)
+	}
+	if _, err := b.Sign(false); err != errCoveredFieldsChanged {
+		t.Fatal("expected errCoveredFieldsChanged, got", err)
+	}
+}
+
 // TestConcurrentBuilders checks that multiple transaction builders can safely
 // be opened at the same time, and that they will make valid transactions when
 // building concurrently.
@@ -444,3 +489,170 @@ func TestParallelBuilders(t *testing.T) {
 		t.Fatal("did not get the expected ending balance", expected, endingSCConfirmed, startingSCConfirmed)
 	}
 }
+
+// TestFundConsolidateDust checks that a builder using the FundConsolidateDust
+// strategy spends several small outputs instead of a single large one.
+func TestFundConsolidateDust(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Send a handful of small outputs to the wallet.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallOutput := types.SiacoinPrecision.Mul64(10)
+	outputs := make([]types.SiacoinOutput, 5)
+	for i := range outputs {
+		outputs[i] = types.SiacoinOutput{Value: smallOutput, UnlockHash: uc.UnlockHash()}
+	}
+	_, err = wt.wallet.SendSiacoinsMulti(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.addBlockNoPayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund an amount that requires several of the small outputs.
+	b := wt.wallet.StartTransaction()
+	b.SetFundingStrategy(modules.FundConsolidateDust)
+	err = b.FundSiacoins(smallOutput.Mul64(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, parents := b.View()
+	if len(parents) != 1 {
+		t.Fatal("expected a single parent, got", len(parents))
+	}
+	if len(parents[0].SiacoinInputs) != 3 {
+		t.Fatal("expected the parent to consolidate 3 small outputs, got", len(parents[0].SiacoinInputs))
+	}
+	b.Drop()
+}
+
+// TestFundSiacoinsDustChange checks that a near-exact funding pays its dust
+// change to the miners instead of creating a dust output.
+func TestFundSiacoinsDustChange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Send a handful of small outputs to the wallet.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallOutput := types.SiacoinPrecision.Mul64(10)
+	outputs := make([]types.SiacoinOutput, 5)
+	for i := range outputs {
+		outputs[i] = types.SiacoinOutput{Value: smallOutput, UnlockHash: uc.UnlockHash()}
+	}
+	_, err = wt.wallet.SendSiacoinsMulti(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = wt.addBlockNoPayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Use the highest change threshold that the wallet accepts.
+	policy := wt.wallet.FeePolicy()
+	min, max := wt.tpool.FeeEstimation()
+	policy.ChangeThreshold = maxChangeThreshold(policy.MinFee, min, max)
+	if err := wt.wallet.SetFeePolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fund slightly less than three of the small outputs. The change is below
+	// the threshold, so the parent should have no refund output and should
+	// pay the change as a miner fee.
+	dust := policy.ChangeThreshold.Div64(2)
+	b := wt.wallet.StartTransaction()
+	b.SetFundingStrategy(modules.FundConsolidateDust)
+	err = b.FundSiacoins(smallOutput.Mul64(3).Sub(dust))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, parents := b.View()
+	if len(parents) != 1 {
+		t.Fatal("expected a single parent, got", len(parents))
+	}
+	parent := parents[0]
+	if len(parent.SiacoinOutputs) != 1 {
+		t.Fatal("expected no refund output, got", len(parent.SiacoinOutputs)-1)
+	}
+	if len(parent.MinerFees) != 1 || !parent.MinerFees[0].Equals(dust) {
+		t.Fatal("expected the dust to be paid as a miner fee, got", parent.MinerFees)
+	}
+	b.Drop()
+}
+
+// TestMergeTransactionSet checks that merging a transaction set de-duplicates
+// shared parents and shifts the covered fields of the merged signatures.
+func TestMergeTransactionSet(t *testing.T) {
+	shared := types.Transaction{ArbitraryData: [][]byte{[]byte("shared")}}
+	ours := types.Transaction{ArbitraryData: [][]byte{[]byte("ours")}}
+	theirs := types.Transaction{ArbitraryData: [][]byte{[]byte("theirs")}}
+
+	tb := &transactionBuilder{parents: []types.Transaction{shared, ours}}
+	tb.AddSiacoinInput(types.SiacoinInput{ParentID: types.SiacoinOutputID{1}})
+	tb.AddSiacoinOutput(types.SiacoinOutput{Value: types.NewCurrency64(1)})
+	tb.AddTransactionSignature(types.TransactionSignature{ParentID: crypto.Hash{1}})
+
+	err := tb.MergeTransactionSet([]types.Transaction{shared, theirs, {
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: types.SiacoinOutputID{2}}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(2)}},
+		TransactionSignatures: []types.TransactionSignature{{
+			ParentID: crypto.Hash{2},
+			CoveredFields: types.CoveredFields{
+				SiacoinInputs:  []uint64{0},
+				SiacoinOutputs: []uint64{0},
+			},
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txn, parents := tb.View()
+	if len(parents) != 3 {
+		t.Fatal("expected the shared parent to be de-duplicated, got", len(parents), "parents")
+	}
+	if parents[2].ID() != theirs.ID() {
+		t.Fatal("expected the new parent to be appended")
+	}
+	if len(txn.SiacoinInputs) != 2 || len(txn.SiacoinOutputs) != 2 || len(txn.TransactionSignatures) != 2 {
+		t.Fatal("fields were not merged into the transaction")
+	}
+	cf := txn.TransactionSignatures[1].CoveredFields
+	if len(cf.SiacoinInputs) != 1 || cf.SiacoinInputs[0] != 1 || len(cf.SiacoinOutputs) != 1 || cf.SiacoinOutputs[0] != 1 {
+		t.Fatal("covered fields were not shifted:", cf)
+	}
+	if txn.SiacoinInputs[cf.SiacoinInputs[0]].ParentID != (types.SiacoinOutputID{2}) {
+		t.Fatal("shifted covered fields point at the wrong input")
+	}
+
+	// Signatures covering the whole transaction cannot be merged.
+	err = tb.MergeTransactionSet([]types.Transaction{{
+		TransactionSignatures: []types.TransactionSignature{{CoveredFields: types.CoveredFields{WholeTransaction: true}}},
+	}})
+	if err != errMergeWholeTransaction {
+		t.Fatal("expected errMergeWholeTransaction, got", err)
+	}
+	if err := tb.MergeTransactionSet(nil); err != errEmptyTransactionSet {
+		t.Fatal("expected errEmptyTransactionSet, got", err)
+	}
+}
@@ -32,7 +32,7 @@ func (w *Wallet) AddressTransactions(uh types.UnlockHash) (pts []modules.Process
 		}
 		pts = append(pts, pt)
 	}
-	return pts
+	return w.withNotes(pts)
 }
 
 // AddressUnconfirmedHistory returns all of the unconfirmed wallet transactions
@@ -63,7 +63,7 @@ func (w *Wallet) AddressUnconfirmedTransactions(uh types.UnlockHash) (pts []modu
 			pts = append(pts, pt)
 		}
 	}
-	return pts
+	return w.withNotes(pts)
 }
 
 // Transaction returns the transaction with the given id. 'False' is returned
@@ -82,6 +82,7 @@ func (w *Wallet) Transaction(txid types.TransactionID) (pt modules.ProcessedTran
 
 	// Retrieve the transaction
 	found = encoding.Unmarshal(w.dbTx.Bucket(bucketProcessedTransactions).Get(keyBytes), &pt) == nil
+	pt.Note, _ = dbGetTransactionNote(w.dbTx, txid)
 	return
 }
 
@@ -180,13 +181,14 @@ func (w *Wallet) Transactions(startHeight, endHeight types.BlockHeight) (pts []m
 			panic("Failed to decode the processed transaction")
 		}
 	}
+	pts = w.withNotes(pts)
 	return
 }
 
 // UnconfirmedTransactions returns the set of unconfirmed transactions that are
 // relevant to the wallet.
 func (w *Wallet) UnconfirmedTransactions() []modules.ProcessedTransaction {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.unconfirmedProcessedTransactions
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.withNotes(w.unconfirmedProcessedTransactions)
 }
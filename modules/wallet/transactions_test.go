@@ -354,7 +354,7 @@ func TestAddressTransactionRevertedBlock(t *testing.T) {
 
 	// Revert the block
 	wt.wallet.mu.Lock()
-	if err := wt.wallet.revertHistory(wt.wallet.dbTx, []types.Block{b}); err != nil {
+	if _, err := wt.wallet.revertHistory(wt.wallet.dbTx, []types.Block{b}); err != nil {
 		t.Fatal(err)
 	}
 	wt.wallet.mu.Unlock()
@@ -118,6 +118,7 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 		} else {
 			w.log.Println("Wallet has lost a spendable siacoin output:", diff.ID, "::", diff.SiacoinOutput.Value.HumanString())
 			err = dbDeleteSiacoinOutput(tx, diff.ID)
+			delete(w.lockedOutputs, types.OutputID(diff.ID))
 		}
 		if err != nil {
 			w.log.Severe("Could not update siacoin output:", err)
@@ -136,6 +137,7 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 		} else {
 			w.log.Println("Wallet has lost a spendable siafund output:", diff.ID, "::", diff.SiafundOutput.Value)
 			err = dbDeleteSiafundOutput(tx, diff.ID)
+			delete(w.lockedOutputs, types.OutputID(diff.ID))
 		}
 		if err != nil {
 			w.log.Severe("Could not update siafund output:", err)
@@ -156,8 +158,10 @@ func (w *Wallet) updateConfirmedSet(tx *bolt.Tx, cc modules.ConsensusChange) err
 }
 
 // revertHistory reverts any transaction history that was destroyed by reverted
-// blocks in the consensus change.
-func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
+// blocks in the consensus change. The processed transactions that were removed
+// from the history are returned in the order that they were reverted.
+func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) ([]modules.ProcessedTransaction, error) {
+	var pts []modules.ProcessedTransaction
 	for _, block := range reverted {
 		// Remove any transactions that have been reverted.
 		for i := len(block.Transactions) - 1; i >= 0; i-- {
@@ -172,6 +176,8 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 				w.log.Println("A wallet transaction has been reverted due to a reorg:", txid)
 				if err := dbDeleteLastProcessedTransaction(tx); err != nil {
 					w.log.Severe("Could not revert transaction:", err)
+				} else {
+					pts = append(pts, pt)
 				}
 			}
 		}
@@ -180,8 +186,14 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 		for i, mp := range block.MinerPayouts {
 			if w.isWalletAddress(mp.UnlockHash) {
 				w.log.Println("Miner payout has been reverted due to a reorg:", block.MinerPayoutID(uint64(i)), "::", mp.Value.HumanString())
-				if err := dbDeleteLastProcessedTransaction(tx); err != nil {
+				pt, err := dbGetLastProcessedTransaction(tx)
+				if err == nil {
+					err = dbDeleteLastProcessedTransaction(tx)
+				}
+				if err != nil {
 					w.log.Severe("Could not revert transaction:", err)
+				} else {
+					pts = append(pts, pt)
 				}
 				break // there will only ever be one miner transaction
 			}
@@ -191,15 +203,15 @@ func (w *Wallet) revertHistory(tx *bolt.Tx, reverted []types.Block) error {
 		if block.ID() != types.GenesisID {
 			consensusHeight, err := dbGetConsensusHeight(tx)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			err = dbPutConsensusHeight(tx, consensusHeight-1)
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
-	return nil
+	return pts, nil
 }
 
 // outputs and collects them in a map of SiacoinOutputID -> SiacoinOutput.
@@ -252,7 +264,7 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 			minerPT.Outputs = append(minerPT.Outputs, modules.ProcessedOutput{
 				ID:             types.OutputID(block.MinerPayoutID(uint64(i))),
 				FundType:       types.SpecifierMinerPayout,
-				MaturityHeight: consensusHeight + types.MaturityDelay,
+				MaturityHeight: consensusHeight + w.cs.MaturityDelay(),
 				WalletAddress:  w.isWalletAddress(mp.UnlockHash),
 				RelatedAddress: mp.UnlockHash,
 				Value:          mp.Value,
@@ -348,7 +360,7 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 			po := modules.ProcessedOutput{
 				ID:             types.OutputID(sfi.ParentID),
 				FundType:       types.SpecifierClaimOutput,
-				MaturityHeight: consensusHeight + types.MaturityDelay,
+				MaturityHeight: consensusHeight + w.cs.MaturityDelay(),
 				WalletAddress:  w.isWalletAddress(sfi.UnlockConditions.UnlockHash()),
 				RelatedAddress: sfi.ClaimUnlockHash,
 				Value:          siafundPool.Sub(sfo.ClaimStart).Mul(sfo.Value),
@@ -430,26 +442,38 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if needRescan, err := w.updateLookahead(w.dbTx, cc); err != nil {
+	needRescan, err := w.updateLookahead(w.dbTx, cc)
+	if err != nil {
 		w.log.Println("ERROR: failed to update lookahead:", err)
-	} else if needRescan {
+	}
+	if accountRescan, err := w.updateAccountLookahead(w.dbTx, cc); err != nil {
+		w.log.Println("ERROR: failed to update account lookahead:", err)
+	} else if accountRescan {
+		needRescan = true
+	}
+	if needRescan {
 		go w.threadedResetSubscriptions()
 	}
 	if err := w.updateConfirmedSet(w.dbTx, cc); err != nil {
 		w.log.Println("ERROR: failed to update confirmed set:", err)
 	}
-	if err := w.revertHistory(w.dbTx, cc.RevertedBlocks); err != nil {
+	reverted, err := w.revertHistory(w.dbTx, cc.RevertedBlocks)
+	if err != nil {
 		w.log.Println("ERROR: failed to revert consensus change:", err)
 	}
 	if err := w.applyHistory(w.dbTx, cc); err != nil {
 		w.log.Println("ERROR: failed to apply consensus change:", err)
 	}
+	if cc.ReorgDepth > 0 {
+		w.recordReorg(cc, reverted)
+	}
 	if err := dbPutConsensusChangeID(w.dbTx, cc.ID); err != nil {
 		w.log.Println("ERROR: failed to update consensus change ID:", err)
 	}
 
 	if cc.Synced {
 		go w.threadedDefragWallet()
+		go w.threadedMakeScheduledPayments()
 	}
 }
 
@@ -1,6 +1,7 @@
 package wallet
 
 import (
+	"math"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
@@ -71,3 +72,79 @@ func TestUpdate(t *testing.T) {
 		t.Fatal("transaction was not removed")
 	}
 }
+
+// TestReorgEvents checks that a reorg deeper than a confirmed transaction
+// removes the transaction from the confirmed history and records a reorg
+// event that reports it as reversed.
+func TestReorgEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Confirm a transaction and bury it under a few more blocks.
+	addr, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(10), addr.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txnSet[len(txnSet)-1].ID()
+	for i := 0; i < 3; i++ {
+		if _, err := wt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pt, ok := wt.wallet.Transaction(txid); !ok || pt.ConfirmationHeight == types.BlockHeight(math.MaxUint64) {
+		t.Fatal("transaction was not confirmed")
+	}
+
+	// Mine a heavier chain without the transaction in a second tester. The
+	// chains only share the genesis block, so every block of the first
+	// tester is reverted when the second chain is fed to it.
+	wt2, err := createWalletTester(t.Name()+"-2", &ProductionDependencies{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt2.closeWt()
+	depth := wt.cs.Height()
+	for wt2.cs.Height() <= depth {
+		if _, err := wt2.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for height := types.BlockHeight(1); height <= wt2.cs.Height(); height++ {
+		b, _ := wt2.cs.BlockAtHeight(height)
+		if err := wt.cs.AcceptBlock(b); err != nil && err != modules.ErrNonExtendingBlock {
+			t.Fatal(err)
+		}
+	}
+	if wt.cs.CurrentBlock().ID() != wt2.cs.CurrentBlock().ID() {
+		t.Fatal("first tester did not switch to the heavier chain")
+	}
+	if _, ok := wt.wallet.Transaction(txid); ok {
+		t.Fatal("reverted transaction is still confirmed")
+	}
+
+	events := wt.wallet.Events()
+	if len(events) != 1 {
+		t.Fatal("expected a single reorg event, got", len(events))
+	}
+	event := events[0]
+	if event.Type != modules.WalletEventReorg || event.ReorgDepth != depth {
+		t.Fatal("reorg event is incorrect:", event)
+	}
+	reversed := false
+	for _, id := range event.Reversed {
+		reversed = reversed || id == txid
+	}
+	if !reversed {
+		t.Fatal("reorg event does not report the transaction as reversed:", event)
+	}
+}
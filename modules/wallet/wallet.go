@@ -43,6 +43,15 @@ type spendableKey struct {
 	SecretKeys       []crypto.SecretKey
 }
 
+// Zeroize overwrites all of the secret keys of the spendable key. The secret
+// keys are wiped in place, which also wipes any spendableKey that shares the
+// same backing array.
+func (sk spendableKey) Zeroize() {
+	for i := range sk.SecretKeys {
+		sk.SecretKeys[i].Zeroize()
+	}
+}
+
 // Wallet is an object that tracks balances, creates keys and addresses,
 // manages building and sending transactions.
 type Wallet struct {
@@ -73,6 +82,17 @@ type Wallet struct {
 	keys      map[types.UnlockHash]spendableKey
 	lookahead map[types.UnlockHash]uint64
 
+	// accountAddrs maps the addresses of the accounts created with
+	// CreateAccount to their account. Addresses that are not in the map
+	// belong to the default account.
+	accountAddrs map[types.UnlockHash]modules.WalletAccountID
+
+	// accountLookahead holds the future keys of the accounts, like lookahead
+	// does for the primary seed, and accountLookaheadEnd is the index up to
+	// which the lookahead of each account has been generated.
+	accountLookahead    map[types.UnlockHash]accountKeyIndex
+	accountLookaheadEnd map[modules.WalletAccountID]uint64
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -82,6 +102,12 @@ type Wallet struct {
 	unconfirmedSets                  map[modules.TransactionSetID][]types.TransactionID
 	unconfirmedProcessedTransactions []modules.ProcessedTransaction
 
+	// lockedOutputs contains the outputs that have been reserved with
+	// LockOutput, which are not used to fund transactions. The locks are
+	// only held in memory, and are released when the wallet restarts or the
+	// output is spent.
+	lockedOutputs map[types.OutputID]struct{}
+
 	// The wallet's database tracks its seeds, keys, outputs, and
 	// transactions. A global db transaction is maintained in memory to avoid
 	// excessive disk writes. Any operations involving dbTx must hold an
@@ -97,6 +123,10 @@ type Wallet struct {
 	// initialization.
 	scanLock siasync.TryMutex
 
+	// paymentLock prevents scheduled payments from being made by more than
+	// one thread at a time.
+	paymentLock siasync.TryMutex
+
 	// The wallet's ThreadGroup tells tracked functions to shut down and
 	// blocks until they have all exited before returning from Close.
 	tg siasync.ThreadGroup
@@ -104,6 +134,13 @@ type Wallet struct {
 	// defragDisabled determines if the wallet is set to defrag outputs once it
 	// reaches a certain threshold
 	defragDisabled bool
+
+	// feePolicy determines the fees that are added to transactions created
+	// by the wallet.
+	feePolicy modules.WalletFeePolicy
+
+	// events holds the most recent wallet events, oldest first.
+	events []modules.WalletEvent
 }
 
 // New creates a new wallet, loading any known addresses from the input file
@@ -128,13 +165,20 @@ func newWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, persistDi
 		cs:    cs,
 		tpool: tpool,
 
-		keys:      make(map[types.UnlockHash]spendableKey),
-		lookahead: make(map[types.UnlockHash]uint64),
+		keys:         make(map[types.UnlockHash]spendableKey),
+		lookahead:    make(map[types.UnlockHash]uint64),
+		accountAddrs: make(map[types.UnlockHash]modules.WalletAccountID),
+
+		accountLookahead:    make(map[types.UnlockHash]accountKeyIndex),
+		accountLookaheadEnd: make(map[modules.WalletAccountID]uint64),
 
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
+		lockedOutputs:   make(map[types.OutputID]struct{}),
 
 		persistDir: persistDir,
 
+		feePolicy: defaultFeePolicy(),
+
 		deps: deps,
 	}
 	err := w.initPersist()
@@ -148,6 +192,13 @@ func newWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, persistDi
 		w.log.Critical("ERROR: failed to start database update:", err)
 	}
 
+	// load the fee policy, which is only stored once it has been set
+	if p, err := dbGetFeePolicy(w.dbTx); err == nil {
+		w.feePolicy = p
+	} else if err != errNoKey {
+		w.log.Println("WARN: could not load the fee policy:", err)
+	}
+
 	// COMPATv131 we need to create the bucketProcessedTxnIndex if it doesn't exist
 	if w.dbTx.Bucket(bucketProcessedTransactions).Stats().KeyN > 0 &&
 		w.dbTx.Bucket(bucketProcessedTxnIndex).Stats().KeyN == 0 {
@@ -168,6 +219,7 @@ func newWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, persistDi
 		}
 	})
 	go w.threadedDBUpdate()
+	go w.threadedReconcileSpentOutputs()
 
 	return w, nil
 }
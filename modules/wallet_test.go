@@ -0,0 +1,78 @@
+package modules
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestVerifyMessage checks that VerifyMessage only accepts signatures of the
+// domain-separated message hash made by the owner of the address.
+func TestVerifyMessage(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+	uh := uc.UnlockHash()
+	message := []byte("proof of ownership")
+	sig := crypto.SignHash(SignedMessageHash(message), sk)
+
+	if err := VerifyMessage(uh, uc, message, sig); err != nil {
+		t.Fatal("valid signature was rejected:", err)
+	}
+	if err := VerifyMessage(uh, uc, []byte("another message"), sig); err == nil {
+		t.Fatal("signature was accepted for a different message")
+	}
+	if err := VerifyMessage(types.UnlockHash{1}, uc, message, sig); err != ErrUnlockConditionsMismatch {
+		t.Fatal("expected ErrUnlockConditionsMismatch, got", err)
+	}
+
+	// A signature of the raw message hash, without the specifier, must not be
+	// accepted.
+	rawSig := crypto.SignHash(crypto.HashBytes(message), sk)
+	if err := VerifyMessage(uh, uc, message, rawSig); err == nil {
+		t.Fatal("signature without the message specifier was accepted")
+	}
+
+	// Multisig addresses cannot be used to sign messages.
+	_, pk2 := crypto.GenerateKeyPair()
+	multiUC := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk), types.Ed25519PublicKey(pk2)},
+		SignaturesRequired: 1,
+	}
+	if err := VerifyMessage(multiUC.UnlockHash(), multiUC, message, sig); err != ErrNonStandardMessageKey {
+		t.Fatal("expected ErrNonStandardMessageKey, got", err)
+	}
+}
+
+// TestProcessedTransactionEncoding checks that the note of a
+// ProcessedTransaction is not encoded, so that processed transactions stored
+// before notes were added can still be decoded.
+func TestProcessedTransactionEncoding(t *testing.T) {
+	pt := ProcessedTransaction{
+		TransactionID:         types.TransactionID{1},
+		ConfirmationHeight:    10,
+		ConfirmationTimestamp: 20,
+		Inputs:                []ProcessedInput{{FundType: types.SpecifierSiacoinInput, Value: types.NewCurrency64(30)}},
+		Outputs:               []ProcessedOutput{{FundType: types.SpecifierMinerPayout, Value: types.NewCurrency64(40)}},
+		Note:                  "rent payment",
+	}
+	old := encoding.MarshalAll(pt.Transaction, pt.TransactionID, pt.ConfirmationHeight,
+		pt.ConfirmationTimestamp, pt.Inputs, pt.Outputs)
+	if !bytes.Equal(encoding.Marshal(pt), old) {
+		t.Fatal("encoding of ProcessedTransaction has changed")
+	}
+
+	var decoded ProcessedTransaction
+	if err := encoding.Unmarshal(old, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	pt.Note = ""
+	if !bytes.Equal(encoding.Marshal(decoded), old) || decoded.Note != "" || decoded.ConfirmationHeight != pt.ConfirmationHeight {
+		t.Fatal("decoded ProcessedTransaction does not match:", decoded)
+	}
+}
@@ -97,6 +97,8 @@ type API struct {
 	tpool    modules.TransactionPool
 	wallet   modules.Wallet
 
+	builders builderSessions
+
 	router http.Handler
 }
 
@@ -118,6 +120,8 @@ func New(requiredUserAgent string, requiredPassword string, cs modules.Consensus
 		renter:   r,
 		tpool:    tp,
 		wallet:   w,
+
+		builders: builderSessions{sessions: make(map[string]*builderSession)},
 	}
 
 	// Register API handlers
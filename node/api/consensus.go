@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/NebulousLabs/Sia/types"
@@ -9,26 +10,62 @@ import (
 	"github.com/julienschmidt/httprouter"
 )
 
+// defaultBlockTimeWindow is the number of blocks over which /consensus
+// averages the block time when no window is requested, about one day of
+// blocks.
+const defaultBlockTimeWindow = 144
+
 // ConsensusGET contains general information about the consensus set, with tags
 // to support idiomatic json encodings.
 type ConsensusGET struct {
-	Synced       bool              `json:"synced"`
-	Height       types.BlockHeight `json:"height"`
-	CurrentBlock types.BlockID     `json:"currentblock"`
-	Target       types.Target      `json:"target"`
-	Difficulty   types.Currency    `json:"difficulty"`
+	Synced            bool              `json:"synced"`
+	Height            types.BlockHeight `json:"height"`
+	CurrentBlock      types.BlockID     `json:"currentblock"`
+	Target            types.Target      `json:"target"`
+	Difficulty        types.Currency    `json:"difficulty"`
+	TotalSupply       types.Currency    `json:"totalsupply"`
+	CirculatingSupply types.Currency    `json:"circulatingsupply"`
+
+	// AverageBlockTime is the average number of seconds between the last
+	// BlockTimeWindow blocks.
+	AverageBlockTime float64 `json:"averageblocktime"`
+	BlockTimeWindow  int     `json:"blocktimewindow"`
+}
+
+// ConsensusDoSBlocksGET contains the ids of all blocks that the consensus set
+// has marked as invalid.
+type ConsensusDoSBlocksGET struct {
+	DoSBlocks []types.BlockID `json:"dosblocks"`
 }
 
 // consensusHandler handles the API calls to /consensus.
 func (api *API) consensusHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	window := defaultBlockTimeWindow
+	if req.FormValue("window") != "" {
+		_, err := fmt.Sscan(req.FormValue("window"), &window)
+		if err != nil || window <= 0 {
+			WriteError(w, Error{"could not read 'window': must be a positive number of blocks"}, http.StatusBadRequest)
+			return
+		}
+	}
+	if h := int(api.cs.Height()); window > h {
+		window = h
+	}
+	avgInterval, _ := api.cs.BlockTimeStats(window)
+
 	cbid := api.cs.CurrentBlock().ID()
 	currentTarget, _ := api.cs.ChildTarget(cbid)
 	WriteJSON(w, ConsensusGET{
-		Synced:       api.cs.Synced(),
-		Height:       api.cs.Height(),
-		CurrentBlock: cbid,
-		Target:       currentTarget,
-		Difficulty:   currentTarget.Difficulty(),
+		Synced:            api.cs.Synced(),
+		Height:            api.cs.Height(),
+		CurrentBlock:      cbid,
+		Target:            currentTarget,
+		Difficulty:        currentTarget.Difficulty(),
+		TotalSupply:       api.cs.TotalSupply(),
+		CirculatingSupply: api.cs.CirculatingSupply(),
+
+		AverageBlockTime: avgInterval.Seconds(),
+		BlockTimeWindow:  window,
 	})
 }
 
@@ -48,3 +85,22 @@ func (api *API) consensusValidateTransactionsetHandler(w http.ResponseWriter, re
 	}
 	WriteSuccess(w)
 }
+
+// consensusDoSBlocksHandlerGET handles the API calls to /consensus/dosblocks.
+func (api *API) consensusDoSBlocksHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, ConsensusDoSBlocksGET{
+		DoSBlocks: api.cs.DoSBlocks(),
+	})
+}
+
+// consensusDoSBlocksClearHandlerPOST handles the API calls to
+// /consensus/dosblocks/clear/:id.
+func (api *API) consensusDoSBlocksClearHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	h, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error decoding block id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	api.cs.ClearDoSBlock(types.BlockID(h))
+	WriteSuccess(w)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/NebulousLabs/Sia/modules"
@@ -14,6 +15,12 @@ type GatewayGET struct {
 	Peers      []modules.Peer     `json:"peers"`
 }
 
+// GatewayBandwidthGET contains the fields returned by a GET call to
+// "/gateway/bandwidth".
+type GatewayBandwidthGET struct {
+	modules.GatewayBandwidth
+}
+
 // gatewayHandler handles the API call asking for the gatway status.
 func (api *API) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	peers := api.gateway.Peers()
@@ -49,3 +56,41 @@ func (api *API) gatewayDisconnectHandler(w http.ResponseWriter, req *http.Reques
 
 	WriteSuccess(w)
 }
+
+// gatewayBandwidthHandlerGET handles the API call asking for the traffic
+// exchanged with peers.
+func (api *API) gatewayBandwidthHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	gb := api.gateway.Bandwidth()
+	if gb.Peers == nil {
+		gb.Peers = make([]modules.PeerBandwidth, 0)
+	}
+	WriteJSON(w, GatewayBandwidthGET{gb})
+}
+
+// gatewayBandwidthHandlerPOST handles the API call to set the gateway's
+// bandwidth limits. Limits that are not supplied are left unchanged.
+func (api *API) gatewayBandwidthHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	current := api.gateway.Bandwidth()
+	download, upload := current.DownloadLimit, current.UploadLimit
+	if req.FormValue("downloadlimit") != "" {
+		_, err := fmt.Sscan(req.FormValue("downloadlimit"), &download)
+		if err != nil {
+			WriteError(w, Error{"unable to parse downloadlimit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("uploadlimit") != "" {
+		_, err := fmt.Sscan(req.FormValue("uploadlimit"), &upload)
+		if err != nil {
+			WriteError(w, Error{"unable to parse uploadlimit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := api.gateway.SetBandwidthLimits(download, upload)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
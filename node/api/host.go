@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
@@ -31,8 +32,24 @@ type (
 		FinancialMetrics     modules.HostFinancialMetrics     `json:"financialmetrics"`
 		InternalSettings     modules.HostInternalSettings     `json:"internalsettings"`
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
+		UtilizationMetrics   modules.HostUtilizationMetrics   `json:"utilizationmetrics"`
+		AnnouncementMetrics  modules.HostAnnouncementMetrics  `json:"announcementmetrics"`
 		ConnectabilityStatus modules.HostConnectabilityStatus `json:"connectabilitystatus"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
+		Maintenance          bool                             `json:"maintenance"`
+		Decommission         modules.HostDecommission         `json:"decommission"`
+	}
+
+	// HostEarningsGET contains the information that is returned from a
+	// /host/earnings call.
+	HostEarningsGET struct {
+		Earnings types.Currency `json:"earnings"`
+	}
+
+	// HostErrorsGET contains the information that is returned from a
+	// /host/errors call.
+	HostErrorsGET struct {
+		Errors []modules.RPCError `json:"errors"`
 	}
 
 	// HostEstimateScoreGET contains the information that is returned from a
@@ -75,12 +92,41 @@ func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httpr
 		FinancialMetrics:     fm,
 		InternalSettings:     is,
 		NetworkMetrics:       nm,
+		UtilizationMetrics:   api.host.UtilizationMetrics(),
+		AnnouncementMetrics:  api.host.AnnouncementMetrics(),
 		ConnectabilityStatus: cs,
 		WorkingStatus:        ws,
+		Maintenance:          api.host.Maintenance(),
+		Decommission:         api.host.DecommissionStatus(),
 	}
 	WriteJSON(w, hg)
 }
 
+// hostEarningsHandlerGET handles GET requests to the /host/earnings API
+// endpoint, returning the revenue the host earned after a given block height.
+func (api *API) hostEarningsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since types.BlockHeight
+	if req.FormValue("since") != "" {
+		_, err := fmt.Sscan(req.FormValue("since"), &since)
+		if err != nil {
+			WriteError(w, Error{"unable to parse since: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	earnings, err := api.host.Earnings(since)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostEarningsGET{Earnings: earnings})
+}
+
+// hostErrorsHandlerGET handles GET requests to the /host/errors API endpoint,
+// returning the most recent failed RPC calls to the host.
+func (api *API) hostErrorsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, HostErrorsGET{Errors: api.host.RecentErrors()})
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -135,6 +181,92 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.WindowSize = x
 	}
+	if req.FormValue("proofwindowbuffer") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("proofwindowbuffer"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ProofWindowBuffer = x
+	}
+	if req.FormValue("maxopenconns") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxopenconns"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxOpenConns = x
+	}
+	if req.FormValue("connidletimeout") != "" {
+		x, err := time.ParseDuration(req.FormValue("connidletimeout"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ConnIdleTimeout = x
+	}
+	if req.FormValue("connreadtimeout") != "" {
+		x, err := time.ParseDuration(req.FormValue("connreadtimeout"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ConnReadTimeout = x
+	}
+	if req.FormValue("connwritetimeout") != "" {
+		x, err := time.ParseDuration(req.FormValue("connwritetimeout"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ConnWriteTimeout = x
+	}
+	if req.FormValue("maxrenterconns") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxrenterconns"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxRenterConns = x
+	}
+	if req.FormValue("maxbandwidth") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxbandwidth"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxBandwidth = x
+	}
+	if req.FormValue("dataretention") != "" {
+		settings.DataRetention = modules.HostDataRetention(req.FormValue("dataretention"))
+	}
+	if req.FormValue("dataretentionblocks") != "" {
+		var x types.BlockHeight
+		_, err := fmt.Sscan(req.FormValue("dataretentionblocks"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DataRetentionBlocks = x
+	}
+	if req.FormValue("minannounceinterval") != "" {
+		x, err := time.ParseDuration(req.FormValue("minannounceinterval"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MinAnnounceInterval = x
+	}
+	if req.FormValue("maxannouncefees") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxannouncefees"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxAnnounceFees = x
+	}
+	if req.FormValue("announcefeeperiod") != "" {
+		x, err := time.ParseDuration(req.FormValue("announcefeeperiod"))
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.AnnounceFeePeriod = x
+	}
 
 	if req.FormValue("collateral") != "" {
 		var x types.Currency
@@ -152,6 +284,22 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.CollateralBudget = x
 	}
+	if req.FormValue("collateralreservetarget") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("collateralreservetarget"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.CollateralReserveTarget = x
+	}
+	if req.FormValue("collateralreservethreshold") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("collateralreservethreshold"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.CollateralReserveThreshold = x
+	}
 	if req.FormValue("maxcollateral") != "" {
 		var x types.Currency
 		_, err := fmt.Sscan(req.FormValue("maxcollateral"), &x)
@@ -169,6 +317,14 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.MinContractPrice = x
 	}
+	if req.FormValue("mincontractvalue") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("mincontractvalue"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MinContractValue = x
+	}
 	if req.FormValue("mindownloadbandwidthprice") != "" {
 		var x types.Currency
 		_, err := fmt.Sscan(req.FormValue("mindownloadbandwidthprice"), &x)
@@ -267,9 +423,18 @@ func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ http
 // hostAnnounceHandler handles the API call to get the host to announce itself
 // to the network.
 func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	var err error
-	if addr := req.FormValue("netaddress"); addr != "" {
+	force, err := scanBool(req.FormValue("force"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse force: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if addr := req.FormValue("netaddress"); addr != "" && force {
+		err = api.host.ForceAnnounceAddress(modules.NetAddress(addr))
+	} else if addr != "" {
 		err = api.host.AnnounceAddress(modules.NetAddress(addr))
+	} else if force {
+		err = api.host.ForceAnnounce()
 	} else {
 		err = api.host.Announce()
 	}
@@ -280,6 +445,39 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// hostMaintenanceHandler handles the API call to enable or disable the host's
+// maintenance mode.
+func (api *API) hostMaintenanceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var enabled bool
+	_, err := fmt.Sscan(req.FormValue("enabled"), &enabled)
+	if err != nil {
+		WriteError(w, Error{"could not read 'enabled' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.SetMaintenance(enabled)
+	if err != nil {
+		WriteError(w, Error{"could not set maintenance mode: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostDecommissionHandler handles the API call to decommission the host.
+func (api *API) hostDecommissionHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var deadline types.BlockHeight
+	_, err := fmt.Sscan(req.FormValue("deadline"), &deadline)
+	if err != nil {
+		WriteError(w, Error{"could not read 'deadline' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.host.Decommission(deadline)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
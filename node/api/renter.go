@@ -120,6 +120,38 @@ type (
 		Files []modules.FileInfo `json:"files"`
 	}
 
+	// RenterExpiredFiles lists the files that can no longer be recovered
+	// because all of their contracts have expired.
+	RenterExpiredFiles struct {
+		Files []string `json:"files"`
+	}
+
+	// RenterBandwidthGET contains the renter's bandwidth limits and how they
+	// are currently divided between transfers.
+	RenterBandwidthGET struct {
+		modules.RenterBandwidth
+	}
+
+	// RenterHostPreferenceGET contains the cost-versus-speed preference that
+	// the renter uses when choosing hosts to download from.
+	RenterHostPreferenceGET struct {
+		HostPreference modules.HostPreference `json:"hostpreference"`
+	}
+
+	// RenterSettingsToleranceGET contains the fraction by which a host's
+	// settings may drift between host selection and the start of an upload
+	// or contract formation.
+	RenterSettingsToleranceGET struct {
+		SettingsTolerance float64 `json:"settingstolerance"`
+	}
+
+	// RenterLimitsGET contains the renter's resource limits and their current
+	// utilization.
+	RenterLimitsGET struct {
+		Limits      modules.RenterLimits            `json:"limits"`
+		Utilization modules.RenterLimitsUtilization `json:"utilization"`
+	}
+
 	// RenterLoad lists files that were loaded into the renter.
 	RenterLoad struct {
 		FilesAdded []string `json:"filesadded"`
@@ -131,6 +163,29 @@ type (
 		modules.RenterPriceEstimation
 	}
 
+	// RenterHealthGET contains the location of every piece of a file,
+	// grouped by chunk.
+	RenterHealthGET struct {
+		modules.FileHealth
+	}
+
+	// RenterManifestGET contains the upload manifest of a file.
+	RenterManifestGET struct {
+		modules.UploadManifest
+	}
+
+	// RenterRepairsGET contains the chunks that the renter's health scan has
+	// queued for repair.
+	RenterRepairsGET struct {
+		modules.RepairQueue
+	}
+
+	// RenterCostGET contains the estimated cost returned by a GET call to
+	// /renter/cost.
+	RenterCostGET struct {
+		Cost types.Currency `json:"cost"`
+	}
+
 	// RenterShareASCII contains an ASCII-encoded .sia file.
 	RenterShareASCII struct {
 		ASCIIsia string `json:"asciisia"`
@@ -141,12 +196,38 @@ type (
 		SiaPath     string    `json:"siapath"`
 		Destination string    `json:"destination"`
 		Filesize    uint64    `json:"filesize"`
+		Priority    int       `json:"priority"`
 		Received    uint64    `json:"received"`
 		StartTime   time.Time `json:"starttime"`
+		Stuck       bool      `json:"stuck"`
 		Error       string    `json:"error"`
 	}
 )
 
+// PercentComplete returns the percentage of the file that has been
+// downloaded.
+func (di DownloadInfo) PercentComplete() float64 {
+	if di.Filesize == 0 {
+		return 100
+	}
+	return 100 * float64(di.Received) / float64(di.Filesize)
+}
+
+// ETA returns the estimated time until the download completes, based on the
+// average rate since the download started. ok is false if nothing has been
+// received yet.
+func (di DownloadInfo) ETA() (eta time.Duration, ok bool) {
+	if di.Received >= di.Filesize {
+		return 0, true
+	}
+	elapsed := time.Since(di.StartTime)
+	if di.Received == 0 || elapsed <= 0 {
+		return 0, false
+	}
+	rate := float64(di.Received) / elapsed.Seconds()
+	return time.Duration(float64(di.Filesize-di.Received) / rate * float64(time.Second)), true
+}
+
 // renterHandlerGET handles the API call to /renter.
 func (api *API) renterHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	settings := api.renter.Settings()
@@ -268,8 +349,10 @@ func (api *API) renterDownloadsHandler(w http.ResponseWriter, _ *http.Request, _
 			SiaPath:     d.SiaPath,
 			Destination: d.Destination.Destination(),
 			Filesize:    d.Filesize,
+			Priority:    d.Priority,
 			StartTime:   d.StartTime,
 			Received:    d.Received,
+			Stuck:       d.Stuck,
 			Error:       d.Error,
 		})
 	}
@@ -309,6 +392,17 @@ func (api *API) renterLoadAsciiHandler(w http.ResponseWriter, req *http.Request,
 	WriteJSON(w, RenterLoad{FilesAdded: files})
 }
 
+// renterManifestHandler handles the API call to report the upload manifest of
+// a file.
+func (api *API) renterManifestHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	manifest, err := api.renter.UploadManifest(strings.TrimPrefix(ps.ByName("siapath"), "/"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterManifestGET{manifest})
+}
+
 // renterRenameHandler handles the API call to rename a file entry in the
 // renter.
 func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -328,6 +422,165 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
+// renterExpiredFilesHandlerGET handles the API call to list the files whose
+// contracts have all expired.
+func (api *API) renterExpiredFilesHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	files := api.renter.ExpiredFiles()
+	if files == nil {
+		files = make([]string, 0)
+	}
+	WriteJSON(w, RenterExpiredFiles{
+		Files: files,
+	})
+}
+
+// renterExpiredFilesHandlerPOST handles the API call to remove the metadata of
+// the files whose contracts have all expired.
+func (api *API) renterExpiredFilesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	files, err := api.renter.PruneExpiredFiles()
+	if err != nil {
+		WriteError(w, Error{"unable to prune expired files: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if files == nil {
+		files = make([]string, 0)
+	}
+	WriteJSON(w, RenterExpiredFiles{
+		Files: files,
+	})
+}
+
+// renterLimitsHandlerGET handles the API call asking for the renter's
+// resource limits.
+func (api *API) renterLimitsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	limits, utilization := api.renter.Limits()
+	WriteJSON(w, RenterLimitsGET{
+		Limits:      limits,
+		Utilization: utilization,
+	})
+}
+
+// renterLimitsHandlerPOST handles the API call to set the renter's resource
+// limits. Limits that are not supplied are left unchanged.
+func (api *API) renterLimitsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	limits, _ := api.renter.Limits()
+	fields := []struct {
+		name  string
+		value *int
+	}{
+		{"maxconcurrentdownloads", &limits.MaxConcurrentDownloads},
+		{"maxconcurrentuploads", &limits.MaxConcurrentUploads},
+		{"maxhostconnections", &limits.MaxHostConnections},
+		{"maxdownloadworkers", &limits.MaxDownloadWorkers},
+		{"maxuploadworkers", &limits.MaxUploadWorkers},
+		{"downloadbufferchunks", &limits.DownloadBufferChunks},
+		{"repairscanfiles", &limits.RepairScanFiles},
+		{"repairthreshold", &limits.RepairThreshold},
+		{"hostfailurethreshold", &limits.HostFailureThreshold},
+		{"hostfailurecooldown", &limits.HostFailureCooldown},
+	}
+	for _, f := range fields {
+		if req.FormValue(f.name) == "" {
+			continue
+		}
+		_, err := fmt.Sscan(req.FormValue(f.name), f.value)
+		if err != nil {
+			WriteError(w, Error{"unable to parse " + f.name + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := api.renter.SetLimits(limits)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// renterHostPreferenceHandlerGET handles the API call asking for the renter's
+// host preference.
+func (api *API) renterHostPreferenceHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterHostPreferenceGET{
+		HostPreference: api.renter.HostPreference(),
+	})
+}
+
+// renterHostPreferenceHandlerPOST handles the API call to set the renter's
+// host preference.
+func (api *API) renterHostPreferenceHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var p modules.HostPreference
+	_, err := fmt.Sscan(req.FormValue("hostpreference"), &p)
+	if err != nil {
+		WriteError(w, Error{"unable to parse hostpreference: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.SetHostPreference(p)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterSettingsToleranceHandlerGET handles the API call asking for the
+// renter's settings tolerance.
+func (api *API) renterSettingsToleranceHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterSettingsToleranceGET{
+		SettingsTolerance: api.renter.SettingsTolerance(),
+	})
+}
+
+// renterSettingsToleranceHandlerPOST handles the API call to set the renter's
+// settings tolerance.
+func (api *API) renterSettingsToleranceHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var tolerance float64
+	_, err := fmt.Sscan(req.FormValue("settingstolerance"), &tolerance)
+	if err != nil {
+		WriteError(w, Error{"unable to parse settingstolerance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.SetSettingsTolerance(tolerance)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterBandwidthHandlerGET handles the API call asking for the renter's
+// bandwidth limits and their current allocation.
+func (api *API) renterBandwidthHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterBandwidthGET{api.renter.Bandwidth()})
+}
+
+// renterBandwidthHandlerPOST handles the API call to set the renter's
+// bandwidth limits. Limits that are not supplied are left unchanged.
+func (api *API) renterBandwidthHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	current := api.renter.Bandwidth()
+	download, upload := current.DownloadLimit, current.UploadLimit
+	if req.FormValue("downloadlimit") != "" {
+		_, err := fmt.Sscan(req.FormValue("downloadlimit"), &download)
+		if err != nil {
+			WriteError(w, Error{"unable to parse downloadlimit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("uploadlimit") != "" {
+		_, err := fmt.Sscan(req.FormValue("uploadlimit"), &upload)
+		if err != nil {
+			WriteError(w, Error{"unable to parse uploadlimit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := api.renter.SetBandwidthLimits(download, upload)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterPricesHandler reports the expected costs of various actions given the
 // renter settings and the set of available hosts.
 func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -336,6 +589,37 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	})
 }
 
+// renterCostHandler estimates the cost of uploading a file of a given size and
+// storing it for a given duration.
+func (api *API) renterCostHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var size uint64
+	_, err := fmt.Sscan(req.FormValue("size"), &size)
+	if err != nil {
+		WriteError(w, Error{"unable to parse size: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var duration types.BlockHeight
+	_, err = fmt.Sscan(req.FormValue("duration"), &duration)
+	if err != nil {
+		WriteError(w, Error{"unable to parse duration: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	redundancy := float64(3)
+	if req.FormValue("redundancy") != "" {
+		_, err = fmt.Sscan(req.FormValue("redundancy"), &redundancy)
+		if err != nil {
+			WriteError(w, Error{"unable to parse redundancy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	cost, err := api.renter.EstimateUploadCost(size, duration, redundancy)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterCostGET{Cost: cost})
+}
+
 // renterDeleteHandler handles the API call to delete a file entry from the
 // renter.
 func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -348,6 +632,52 @@ func (api *API) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// renterDownloadByHashHandler handles the API call to download a file by the
+// hash of its contents.
+func (api *API) renterDownloadByHashHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	hash, err := scanHash(ps.ByName("hash"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse hash: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.DownloadByHash(hash, req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// renterVerifyHandler handles the API call to check that a file can be
+// recovered from the hosts storing it.
+func (api *API) renterVerifyHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	err := api.renter.VerifyFile(strings.TrimPrefix(ps.ByName("siapath"), "/"))
+	if err != nil {
+		WriteError(w, Error{"verification failed: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	WriteSuccess(w)
+}
+
+// renterHealthHandler handles the API call to report where the pieces of a
+// file are stored.
+func (api *API) renterHealthHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	health, err := api.renter.FileHealth(strings.TrimPrefix(ps.ByName("siapath"), "/"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterHealthGET{health})
+}
+
+// renterRepairsHandler handles the API call to list the chunks queued for
+// repair by the renter's health scan.
+func (api *API) renterRepairsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterRepairsGET{api.renter.RepairQueue()})
+}
+
 // renterDownloadHandler handles the API call to download a file.
 func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	params, err := parseDownloadParameters(w, req, ps)
@@ -412,6 +742,19 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	// If httprespparam is present, this parameter is ignored.
 	asyncparam := req.FormValue("async")
 
+	// The priority of the download relative to other downloads.
+	priorityparam := req.FormValue("priority")
+
+	// The cost-versus-speed preference of the download.
+	hostpreferenceparam := req.FormValue("hostpreference")
+
+	// Determines whether several hosts are raced for every piece.
+	fastestparam := req.FormValue("fastest")
+
+	// The directory that the download is written to before it is moved to
+	// the destination.
+	tempdir := req.FormValue("tempdir")
+
 	// Parse the offset and length parameters.
 	var offset, length uint64
 	if len(offsetparam) > 0 {
@@ -427,6 +770,25 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	// Parse the priority parameter.
+	var priority int
+	if len(priorityparam) > 0 {
+		_, err := fmt.Sscan(priorityparam, &priority)
+		if err != nil {
+			return modules.RenterDownloadParameters{}, build.ExtendErr("could not decode the priority as int: ", err)
+		}
+	}
+
+	// Parse the hostpreference parameter.
+	var hostPreference *modules.HostPreference
+	if len(hostpreferenceparam) > 0 {
+		hostPreference = new(modules.HostPreference)
+		_, err := fmt.Sscan(hostpreferenceparam, hostPreference)
+		if err != nil {
+			return modules.RenterDownloadParameters{}, build.ExtendErr("could not decode the hostpreference as float: ", err)
+		}
+	}
+
 	// Parse the httpresp parameter.
 	httpresp, err := scanBool(httprespparam)
 	if err != nil {
@@ -439,14 +801,25 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		return modules.RenterDownloadParameters{}, build.ExtendErr("async parameter could not be parsed", err)
 	}
 
+	// Parse the fastest parameter.
+	fastest, err := scanBool(fastestparam)
+	if err != nil {
+		return modules.RenterDownloadParameters{}, build.ExtendErr("fastest parameter could not be parsed", err)
+	}
+
 	siapath := strings.TrimPrefix(ps.ByName("siapath"), "/") // Sia file name.
 
 	dp := modules.RenterDownloadParameters{
 		Destination: destination,
+		TempDir:     tempdir,
 		Async:       async,
 		Length:      length,
 		Offset:      offset,
+		Priority:    priority,
 		Siapath:     siapath,
+
+		HostPreference: hostPreference,
+		Fastest:        fastest,
 	}
 	if httpresp {
 		dp.Httpwriter = w
@@ -2112,3 +2112,23 @@ func TestAdversarialPriceRenewal(t *testing.T) {
 		time.Sleep(time.Millisecond * 100)
 	}
 }
+
+// TestDownloadInfoProgress checks the progress reported by DownloadInfo.
+func TestDownloadInfoProgress(t *testing.T) {
+	di := DownloadInfo{Filesize: 1000, StartTime: time.Now().Add(-10 * time.Second)}
+	if _, ok := di.ETA(); ok {
+		t.Fatal("ETA should be unknown before anything is received")
+	}
+	di.Received = 250
+	if pct := di.PercentComplete(); pct != 25 {
+		t.Fatal("expected 25% complete, got", pct)
+	}
+	eta, ok := di.ETA()
+	if !ok || eta < 29*time.Second || eta > 31*time.Second {
+		t.Fatal("expected an ETA of about 30s, got", eta, ok)
+	}
+	di.Received = di.Filesize
+	if eta, ok := di.ETA(); !ok || eta != 0 || di.PercentComplete() != 100 {
+		t.Fatal("completed download should have a zero ETA, got", eta, ok)
+	}
+}
@@ -22,6 +22,8 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 	if api.cs != nil {
 		router.GET("/consensus", api.consensusHandler)
 		router.POST("/consensus/validate/transactionset", api.consensusValidateTransactionsetHandler)
+		router.GET("/consensus/dosblocks", api.consensusDoSBlocksHandlerGET)
+		router.POST("/consensus/dosblocks/clear/:id", RequirePassword(api.consensusDoSBlocksClearHandlerPOST, requiredPassword))
 	}
 
 	// Explorer API Calls
@@ -34,6 +36,8 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 	// Gateway API Calls
 	if api.gateway != nil {
 		router.GET("/gateway", api.gatewayHandler)
+		router.GET("/gateway/bandwidth", api.gatewayBandwidthHandlerGET)
+		router.POST("/gateway/bandwidth", RequirePassword(api.gatewayBandwidthHandlerPOST, requiredPassword))
 		router.POST("/gateway/connect/:netaddress", RequirePassword(api.gatewayConnectHandler, requiredPassword))
 		router.POST("/gateway/disconnect/:netaddress", RequirePassword(api.gatewayDisconnectHandler, requiredPassword))
 	}
@@ -44,7 +48,11 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/host", api.hostHandlerGET)                                                   // Get the host status.
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
+		router.GET("/host/earnings", api.hostEarningsHandlerGET)                                  // Get the revenue earned within a time window.
+		router.GET("/host/errors", api.hostErrorsHandlerGET)                                      // Get the most recent failed RPC calls.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.POST("/host/maintenance", RequirePassword(api.hostMaintenanceHandler, requiredPassword))   // Enable or disable maintenance mode.
+		router.POST("/host/decommission", RequirePassword(api.hostDecommissionHandler, requiredPassword)) // Permanently retire the host.
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
@@ -68,8 +76,20 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/renter", api.renterHandlerGET)
 		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/cost", api.renterCostHandler)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.GET("/renter/files", api.renterFilesHandler)
+		router.GET("/renter/files/expired", api.renterExpiredFilesHandlerGET)
+		router.POST("/renter/files/expired", RequirePassword(api.renterExpiredFilesHandlerPOST, requiredPassword))
+		router.GET("/renter/bandwidth", api.renterBandwidthHandlerGET)
+		router.POST("/renter/bandwidth", RequirePassword(api.renterBandwidthHandlerPOST, requiredPassword))
+		router.GET("/renter/hostpreference", api.renterHostPreferenceHandlerGET)
+		router.POST("/renter/hostpreference", RequirePassword(api.renterHostPreferenceHandlerPOST, requiredPassword))
+		router.GET("/renter/settingstolerance", api.renterSettingsToleranceHandlerGET)
+		router.POST("/renter/settingstolerance", RequirePassword(api.renterSettingsToleranceHandlerPOST, requiredPassword))
+		router.GET("/renter/limits", api.renterLimitsHandlerGET)
+		router.GET("/renter/repairs", api.renterRepairsHandler)
+		router.POST("/renter/limits", RequirePassword(api.renterLimitsHandlerPOST, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
 
 		// TODO: re-enable these routes once the new .sia format has been
@@ -82,8 +102,12 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
 		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
+		router.GET("/renter/downloadbyhash/:hash", RequirePassword(api.renterDownloadByHashHandler, requiredPassword))
+		router.GET("/renter/health/*siapath", api.renterHealthHandler)
+		router.GET("/renter/manifest/*siapath", api.renterManifestHandler)
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.GET("/renter/verify/*siapath", RequirePassword(api.renterVerifyHandler, requiredPassword))
 
 		// HostDB endpoints.
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
@@ -96,6 +120,7 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 		router.GET("/tpool/fee", api.tpoolFeeHandlerGET)
 		router.GET("/tpool/raw/:id", api.tpoolRawHandlerGET)
 		router.POST("/tpool/raw", api.tpoolRawHandlerPOST)
+		router.GET("/tpool/status/:id", api.tpoolStatusHandlerGET)
 
 		// TODO: re-enable this route once the transaction pool API has been finalized
 		//router.GET("/transactionpool/transactions", api.transactionpoolTransactionsHandler)
@@ -105,24 +130,46 @@ func (api *API) buildHttpRoutes(requiredUserAgent string, requiredPassword strin
 	if api.wallet != nil {
 		router.GET("/wallet", api.walletHandler)
 		router.POST("/wallet/033x", RequirePassword(api.wallet033xHandler, requiredPassword))
+		router.GET("/wallet/accounts", api.walletAccountsHandlerGET)
+		router.POST("/wallet/accounts", RequirePassword(api.walletAccountsHandlerPOST, requiredPassword))
 		router.GET("/wallet/address", RequirePassword(api.walletAddressHandler, requiredPassword))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
+		router.GET("/wallet/addresses/reused", api.walletAddressesReusedHandler)
 		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
 		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
 		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
 		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
+		router.POST("/wallet/outputs/lock/:id", RequirePassword(api.walletOutputsLockHandler, requiredPassword))
+		router.POST("/wallet/outputs/unlock/:id", RequirePassword(api.walletOutputsUnlockHandler, requiredPassword))
+		router.GET("/wallet/payments", api.walletPaymentsHandlerGET)
+		router.POST("/wallet/payments", RequirePassword(api.walletPaymentsHandlerPOST, requiredPassword))
+		router.POST("/wallet/payments/cancel/:id", RequirePassword(api.walletPaymentsCancelHandler, requiredPassword))
 		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
 		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
 		router.POST("/wallet/siacoins", RequirePassword(api.walletSiacoinsHandler, requiredPassword))
 		router.POST("/wallet/siafunds", RequirePassword(api.walletSiafundsHandler, requiredPassword))
 		router.POST("/wallet/siagkey", RequirePassword(api.walletSiagkeyHandler, requiredPassword))
+		router.POST("/wallet/sign", RequirePassword(api.walletSignHandler, requiredPassword))
 		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
+		router.POST("/wallet/sweep/all", RequirePassword(api.walletSweepAllHandler, requiredPassword))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
+		router.POST("/wallet/transaction/:id/note", RequirePassword(api.walletTransactionNoteHandler, requiredPassword))
+		router.POST("/wallet/transaction/:id/bumpfee", RequirePassword(api.walletTransactionBumpFeeHandler, requiredPassword))
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
 		router.POST("/wallet/unlock", RequirePassword(api.walletUnlockHandler, requiredPassword))
+		router.GET("/wallet/events", api.walletEventsHandler)
 		router.POST("/wallet/changepassword", RequirePassword(api.walletChangePasswordHandler, requiredPassword))
+		router.POST("/wallet/builder", RequirePassword(api.walletBuilderHandlerPOST, requiredPassword))
+		router.GET("/wallet/builder/:id", RequirePassword(api.walletBuilderHandlerGET, requiredPassword))
+		router.POST("/wallet/builder/:id/input", RequirePassword(api.walletBuilderInputHandler, requiredPassword))
+		router.POST("/wallet/builder/:id/output", RequirePassword(api.walletBuilderOutputHandler, requiredPassword))
+		router.POST("/wallet/builder/:id/fee", RequirePassword(api.walletBuilderFeeHandler, requiredPassword))
+		router.POST("/wallet/builder/:id/fund", RequirePassword(api.walletBuilderFundHandler, requiredPassword))
+		router.POST("/wallet/builder/:id/sign", RequirePassword(api.walletBuilderSignHandler, requiredPassword))
+		router.POST("/wallet/builder/:id/submit", RequirePassword(api.walletBuilderSubmitHandler, requiredPassword))
+		router.POST("/wallet/builder/:id/drop", RequirePassword(api.walletBuilderDropHandler, requiredPassword))
 	}
 
 	// Apply UserAgent middleware and return the Router
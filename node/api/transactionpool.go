@@ -25,6 +25,14 @@ type (
 		Parents     []byte              `json:"parents"`
 		Transaction []byte              `json:"transaction"`
 	}
+
+	// TpoolStatusGET reports whether a transaction is pending, confirmed, or
+	// was dropped from the transaction pool due to a conflict.
+	TpoolStatusGET struct {
+		ID                 types.TransactionID `json:"id"`
+		State              string              `json:"state"`
+		ConfirmationHeight types.BlockHeight   `json:"confirmationheight"`
+	}
 )
 
 // decodeTransactionID will decode a transaction id from a string.
@@ -108,3 +116,23 @@ func (api *API) tpoolRawHandlerPOST(w http.ResponseWriter, req *http.Request, _
 	}
 	WriteSuccess(w)
 }
+
+// tpoolStatusHandlerGET reports the status of the transaction that matches the
+// input id.
+func (api *API) tpoolStatusHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	txid, err := decodeTransactionID(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error decoding transaction id:" + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	status, err := api.tpool.TransactionStatus(txid)
+	if err != nil {
+		WriteError(w, Error{"error getting transaction status:" + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, TpoolStatusGET{
+		ID:                 txid,
+		State:              status.State.String(),
+		ConfirmationHeight: status.ConfirmationHeight,
+	})
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"path/filepath"
 	"strconv"
@@ -23,6 +25,7 @@ type (
 		Rescanning bool `json:"rescanning"`
 
 		ConfirmedSiacoinBalance     types.Currency `json:"confirmedsiacoinbalance"`
+		SpendableSiacoinBalance     types.Currency `json:"spendablesiacoinbalance"`
 		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingsiacoins"`
 		UnconfirmedIncomingSiacoins types.Currency `json:"unconfirmedincomingsiacoins"`
 
@@ -32,6 +35,18 @@ type (
 		DustThreshold types.Currency `json:"dustthreshold"`
 	}
 
+	// WalletAccountsGET contains the accounts returned by a GET call to
+	// /wallet/accounts.
+	WalletAccountsGET struct {
+		Accounts []modules.WalletAccount `json:"accounts"`
+	}
+
+	// WalletAccountsPOST contains the ID of the account created by a POST
+	// call to /wallet/accounts.
+	WalletAccountsPOST struct {
+		ID modules.WalletAccountID `json:"id"`
+	}
+
 	// WalletAddressGET contains an address returned by a GET call to
 	// /wallet/address.
 	WalletAddressGET struct {
@@ -69,6 +84,14 @@ type (
 		AllSeeds           []string `json:"allseeds"`
 	}
 
+	// WalletSignPOST contains the signature returned by a POST call to
+	// /wallet/sign, along with the unlock conditions of the address that are
+	// needed to verify the signature.
+	WalletSignPOST struct {
+		Signature        string                 `json:"signature"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
 	// WalletSweepPOST contains the coins and funds returned by a call to
 	// /wallet/sweep.
 	WalletSweepPOST struct {
@@ -76,6 +99,38 @@ type (
 		Funds types.Currency `json:"funds"`
 	}
 
+	// WalletSweepAllPOST contains the transaction created by a call to
+	// /wallet/sweep/all.
+	WalletSweepAllPOST struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		Value         types.Currency      `json:"value"`
+		Fee           types.Currency      `json:"fee"`
+	}
+
+	// WalletBumpFeePOST contains the replacement transaction created by a
+	// call to /wallet/transaction/:id/bumpfee.
+	WalletBumpFeePOST struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+	}
+
+	// WalletEventsGET contains the events returned by a call to
+	// /wallet/events.
+	WalletEventsGET struct {
+		Events []modules.WalletEvent `json:"events"`
+	}
+
+	// WalletPaymentsGET contains the scheduled payments returned by a call
+	// to /wallet/payments.
+	WalletPaymentsGET struct {
+		Payments []modules.ScheduledPayment `json:"payments"`
+	}
+
+	// WalletPaymentsPOST contains the ID of the payment scheduled by a call
+	// to /wallet/payments.
+	WalletPaymentsPOST struct {
+		ID string `json:"id"`
+	}
+
 	// WalletTransactionGETid contains the transaction returned by a call to
 	// /wallet/transaction/:id
 	WalletTransactionGETid struct {
@@ -123,6 +178,7 @@ func encryptionKeys(seedStr string) (validKeys []crypto.TwofishKey) {
 func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	siacoinBal, siafundBal, siaclaimBal := api.wallet.ConfirmedBalance()
 	siacoinsOut, siacoinsIn := api.wallet.UnconfirmedBalance()
+	spendableBal := api.wallet.SpendableBalance(api.cs.Height())
 	dustThreshold := api.wallet.DustThreshold()
 	WriteJSON(w, WalletGET{
 		Encrypted:  api.wallet.Encrypted(),
@@ -130,6 +186,7 @@ func (api *API) walletHandler(w http.ResponseWriter, req *http.Request, _ httpro
 		Rescanning: api.wallet.Rescanning(),
 
 		ConfirmedSiacoinBalance:     siacoinBal,
+		SpendableSiacoinBalance:     spendableBal,
 		UnconfirmedOutgoingSiacoins: siacoinsOut,
 		UnconfirmedIncomingSiacoins: siacoinsIn,
 
@@ -163,9 +220,36 @@ func (api *API) wallet033xHandler(w http.ResponseWriter, req *http.Request, _ ht
 	WriteError(w, Error{modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
 }
 
+// walletAccountsHandlerGET handles GET calls to /wallet/accounts.
+func (api *API) walletAccountsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	accounts, err := api.wallet.Accounts()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/accounts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountsGET{Accounts: accounts})
+}
+
+// walletAccountsHandlerPOST handles POST calls to /wallet/accounts.
+func (api *API) walletAccountsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id, err := api.wallet.CreateAccount(req.FormValue("name"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/accounts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountsPOST{ID: id})
+}
+
 // walletAddressHandler handles API calls to /wallet/address.
 func (api *API) walletAddressHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	unlockConditions, err := api.wallet.NextAddress()
+	account := modules.DefaultWalletAccount
+	if req.FormValue("account") != "" {
+		if _, err := fmt.Sscan(req.FormValue("account"), &account); err != nil {
+			WriteError(w, Error{"could not read account from call to /wallet/address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	unlockConditions, err := api.wallet.NextAccountAddress(account)
 	if err != nil {
 		WriteError(w, Error{"error when calling /wallet/addresses: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -182,6 +266,13 @@ func (api *API) walletAddressesHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// walletAddressesReusedHandler handles API calls to /wallet/addresses/reused.
+func (api *API) walletAddressesReusedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, WalletAddressesGET{
+		Addresses: api.wallet.ReusedAddresses(),
+	})
+}
+
 // walletBackupHandler handles API calls to /wallet/backup.
 func (api *API) walletBackupHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	destination := req.FormValue("destination")
@@ -380,6 +471,10 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			WriteError(w, Error{"cannot supply both 'outputs' and single amount+destination pair"}, http.StatusInternalServerError)
 			return
 		}
+		if req.FormValue("account") != "" {
+			WriteError(w, Error{"cannot supply 'account' with 'outputs'"}, http.StatusBadRequest)
+			return
+		}
 
 		var outputs []types.SiacoinOutput
 		err := json.Unmarshal([]byte(req.FormValue("outputs")), &outputs)
@@ -393,7 +488,7 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	} else {
-		// single amount + destination
+		// single amount + destination, optionally funded by an account
 		amount, ok := scanAmount(req.FormValue("amount"))
 		if !ok {
 			WriteError(w, Error{"could not read amount from POST call to /wallet/siacoins"}, http.StatusBadRequest)
@@ -405,7 +500,15 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 
-		txns, err = api.wallet.SendSiacoins(amount, dest)
+		account := modules.DefaultWalletAccount
+		if req.FormValue("account") != "" {
+			if _, err := fmt.Sscan(req.FormValue("account"), &account); err != nil {
+				WriteError(w, Error{"could not read account from POST call to /wallet/siacoins: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+
+		txns, err = api.wallet.SendSiacoinsFromAccount(account, amount, dest)
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -449,6 +552,30 @@ func (api *API) walletSiafundsHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// walletSignHandler handles API calls to /wallet/sign.
+func (api *API) walletSignHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	message := []byte(req.FormValue("message"))
+	sig, err := api.wallet.SignMessage(addr, message)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	uc, err := api.wallet.UnlockConditions(addr)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sign: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletSignPOST{
+		Signature:        hex.EncodeToString(sig[:]),
+		UnlockConditions: uc,
+	})
+}
+
 // walletSweepSeedHandler handles API calls to /wallet/sweep/seed.
 func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Get the seed using the ditionary + phrase
@@ -473,6 +600,108 @@ func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// walletSweepAllHandler handles API calls to /wallet/sweep/all.
+func (api *API) walletSweepAllHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sweep/all: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	txn, err := api.wallet.Sweep(dest)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/sweep/all: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletSweepAllPOST{
+		TransactionID: txn.ID(),
+		Value:         txn.SiacoinOutputs[0].Value,
+		Fee:           txn.MinerFees[0],
+	})
+}
+
+// walletEventsHandler handles GET calls to /wallet/events.
+func (api *API) walletEventsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, WalletEventsGET{Events: api.wallet.Events()})
+}
+
+// walletPaymentsHandlerGET handles GET calls to /wallet/payments.
+func (api *API) walletPaymentsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	payments, err := api.wallet.ScheduledPayments()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/payments: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, WalletPaymentsGET{Payments: payments})
+}
+
+// walletPaymentsHandlerPOST handles POST calls to /wallet/payments.
+func (api *API) walletPaymentsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/payments: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read amount from POST call to /wallet/payments"}, http.StatusBadRequest)
+		return
+	}
+	var interval types.BlockHeight
+	if _, err := fmt.Sscan(req.FormValue("interval"), &interval); err != nil {
+		WriteError(w, Error{"could not read interval from POST call to /wallet/payments: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	id, err := api.wallet.SchedulePayment(dest, amount, interval)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/payments: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPaymentsPOST{ID: id})
+}
+
+// walletPaymentsCancelHandler handles API calls to
+// /wallet/payments/cancel/:id.
+func (api *API) walletPaymentsCancelHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	err := api.wallet.CancelScheduledPayment(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/payments/cancel: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletOutputsLockHandler handles API calls to /wallet/outputs/lock/:id.
+func (api *API) walletOutputsLockHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/lock: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.wallet.LockOutput(types.OutputID(id))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/lock: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletOutputsUnlockHandler handles API calls to /wallet/outputs/unlock/:id.
+func (api *API) walletOutputsUnlockHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/unlock: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.wallet.UnlockOutput(types.OutputID(id))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/outputs/unlock: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletTransactionHandler handles API calls to /wallet/transaction/:id.
 func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse the id from the url.
@@ -494,6 +723,47 @@ func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Reques
 	})
 }
 
+// walletTransactionNoteHandler handles API calls to
+// /wallet/transaction/:id/note.
+func (api *API) walletTransactionNoteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	err := id.UnmarshalJSON([]byte(jsonID))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/note: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = api.wallet.SetTransactionNote(id, req.FormValue("note"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/note: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletTransactionBumpFeeHandler handles API calls to
+// /wallet/transaction/:id/bumpfee.
+func (api *API) walletTransactionBumpFeeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	err := id.UnmarshalJSON([]byte(jsonID))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/bumpfee: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	fee, ok := scanAmount(req.FormValue("fee"))
+	if !ok {
+		WriteError(w, Error{"could not read fee from POST call to /wallet/transaction/:id/bumpfee"}, http.StatusBadRequest)
+		return
+	}
+	txn, err := api.wallet.BumpFee(id, fee)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/bumpfee: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletBumpFeePOST{TransactionID: txn.ID()})
+}
+
 // walletTransactionsHandler handles API calls to /wallet/transactions.
 func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
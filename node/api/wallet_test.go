@@ -1635,3 +1635,107 @@ func TestWalletManyTransactions(t *testing.T) {
 		})
 	}
 }
+
+// TestWalletBuilder builds a transaction step by step through the
+// /wallet/builder endpoints and checks that it is accepted by the
+// transaction pool.
+func TestWalletBuilder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	var wbp WalletBuilderPOST
+	if err := st.postAPI("/wallet/builder", nil, &wbp); err != nil {
+		t.Fatal(err)
+	}
+	var wag WalletAddressGET
+	if err := st.getAPI("/wallet/address", &wag); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add an output, a fee, and fund the transaction.
+	var wbg WalletBuilderGET
+	output := types.SiacoinPrecision.Mul64(10)
+	fee := types.SiacoinPrecision
+	err = st.postAPI("/wallet/builder/"+wbp.ID+"/output", url.Values{
+		"amount":      {output.String()},
+		"destination": {wag.Address.String()},
+	}, &wbg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wbg.Transaction.SiacoinOutputs) != 1 || !wbg.Transaction.SiacoinOutputs[0].Value.Equals(output) {
+		t.Fatal("output was not added:", wbg.Transaction.SiacoinOutputs)
+	}
+	err = st.postAPI("/wallet/builder/"+wbp.ID+"/fee", url.Values{"amount": {fee.String()}}, &wbg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.postAPI("/wallet/builder/"+wbp.ID+"/fund", url.Values{"amount": {output.Add(fee).String()}}, &wbg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wbg.Transaction.SiacoinInputs) == 0 {
+		t.Fatal("transaction was not funded")
+	}
+
+	// Sign and submit the transaction.
+	err = st.postAPI("/wallet/builder/"+wbp.ID+"/sign", nil, &wbg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wbg.Signed || len(wbg.Transaction.TransactionSignatures) == 0 {
+		t.Fatal("transaction was not signed")
+	}
+	err = st.postAPI("/wallet/builder/"+wbp.ID+"/submit", nil, &wbg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := st.tpool.Transaction(wbg.Transaction.ID()); !ok {
+		t.Fatal("submitted transaction is not in the transaction pool")
+	}
+
+	// The session is closed after submitting.
+	if err := st.getAPI("/wallet/builder/"+wbp.ID, &wbg); err == nil {
+		t.Fatal("expected an error viewing a closed session")
+	}
+}
+
+// TestWalletOutputLocks checks that outputs can be locked and unlocked
+// through the /wallet/outputs endpoints.
+func TestWalletOutputLocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	id := types.OutputID{1}.String()
+	if err := st.stdPostAPI("/wallet/outputs/lock/"+id, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdPostAPI("/wallet/outputs/lock/"+id, nil); err == nil {
+		t.Fatal("expected an error locking an output twice")
+	}
+	if err := st.stdPostAPI("/wallet/outputs/unlock/"+id, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdPostAPI("/wallet/outputs/unlock/"+id, nil); err == nil {
+		t.Fatal("expected an error unlocking an output that is not locked")
+	}
+	if err := st.stdPostAPI("/wallet/outputs/lock/foo", nil); err == nil {
+		t.Fatal("expected an error locking an invalid output id")
+	}
+}
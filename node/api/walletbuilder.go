@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/fastrand"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// maxBuilderSessions is the maximum number of transaction builder
+	// sessions that can be open at once. Sessions hold wallet outputs until
+	// they are submitted or dropped, so they must not accumulate without
+	// bound.
+	maxBuilderSessions = 32
+)
+
+type (
+	// builderSessions holds the transaction builders that have been started
+	// through the API, so that a transaction can be constructed over several
+	// calls.
+	builderSessions struct {
+		sessions map[string]*builderSession
+		mu       sync.Mutex
+	}
+
+	// builderSession is a single transaction under construction. Transaction
+	// builders are not thread safe, so all access to a session must happen
+	// under the builderSessions lock.
+	builderSession struct {
+		builder modules.TransactionBuilder
+		signed  bool
+	}
+
+	// WalletBuilderPOST contains the id of a newly created transaction
+	// builder session.
+	WalletBuilderPOST struct {
+		ID string `json:"id"`
+	}
+
+	// WalletBuilderGET contains the transaction of a transaction builder
+	// session, along with its parents.
+	WalletBuilderGET struct {
+		ID          string              `json:"id"`
+		Signed      bool                `json:"signed"`
+		Transaction types.Transaction   `json:"transaction"`
+		Parents     []types.Transaction `json:"parents"`
+	}
+)
+
+// withBuilder calls fn with the builder session identified by the "id"
+// parameter, writing an error if there is no such session.
+func (api *API) withBuilder(w http.ResponseWriter, ps httprouter.Params, fn func(*builderSession) error) {
+	api.builders.mu.Lock()
+	defer api.builders.mu.Unlock()
+	id := ps.ByName("id")
+	s, ok := api.builders.sessions[id]
+	if !ok {
+		WriteError(w, Error{"no transaction builder session with id " + id}, http.StatusBadRequest)
+		return
+	}
+	if err := fn(s); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txn, parents := s.builder.View()
+	WriteJSON(w, WalletBuilderGET{
+		ID:          id,
+		Signed:      s.signed,
+		Transaction: txn,
+		Parents:     parents,
+	})
+}
+
+// walletBuilderHandlerPOST handles API calls to POST /wallet/builder,
+// starting a new transaction builder session.
+func (api *API) walletBuilderHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	api.builders.mu.Lock()
+	defer api.builders.mu.Unlock()
+	if len(api.builders.sessions) >= maxBuilderSessions {
+		WriteError(w, Error{"too many open transaction builder sessions, submit or drop an existing session first"}, http.StatusBadRequest)
+		return
+	}
+	id := hex.EncodeToString(fastrand.Bytes(16))
+	api.builders.sessions[id] = &builderSession{builder: api.wallet.StartTransaction()}
+	WriteJSON(w, WalletBuilderPOST{ID: id})
+}
+
+// walletBuilderHandlerGET handles API calls to GET /wallet/builder/:id.
+func (api *API) walletBuilderHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	api.withBuilder(w, ps, func(*builderSession) error { return nil })
+}
+
+// walletBuilderInputHandler handles API calls to /wallet/builder/:id/input,
+// adding a siacoin input to the transaction. The input is not signed by the
+// wallet.
+func (api *API) walletBuilderInputHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	parentID, err := scanHash(req.FormValue("parentid"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'parentid': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var uc types.UnlockConditions
+	if err = json.Unmarshal([]byte(req.FormValue("unlockconditions")), &uc); err != nil {
+		WriteError(w, Error{"could not read 'unlockconditions': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	api.withBuilder(w, ps, func(s *builderSession) error {
+		s.builder.AddSiacoinInput(types.SiacoinInput{ParentID: types.SiacoinOutputID(parentID), UnlockConditions: uc})
+		return nil
+	})
+}
+
+// walletBuilderOutputHandler handles API calls to /wallet/builder/:id/output,
+// adding a siacoin output to the transaction.
+func (api *API) walletBuilderOutputHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount'"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'destination': " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	api.withBuilder(w, ps, func(s *builderSession) error {
+		s.builder.AddSiacoinOutput(types.SiacoinOutput{Value: amount, UnlockHash: dest})
+		return nil
+	})
+}
+
+// walletBuilderFeeHandler handles API calls to /wallet/builder/:id/fee,
+// adding a miner fee to the transaction.
+func (api *API) walletBuilderFeeHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount'"}, http.StatusBadRequest)
+		return
+	}
+	api.withBuilder(w, ps, func(s *builderSession) error {
+		s.builder.AddMinerFee(amount)
+		return nil
+	})
+}
+
+// walletBuilderFundHandler handles API calls to /wallet/builder/:id/fund,
+// adding wallet inputs worth exactly 'amount' to the transaction.
+func (api *API) walletBuilderFundHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount'"}, http.StatusBadRequest)
+		return
+	}
+	api.withBuilder(w, ps, func(s *builderSession) error {
+		return s.builder.FundSiacoins(amount)
+	})
+}
+
+// walletBuilderSignHandler handles API calls to /wallet/builder/:id/sign,
+// signing the inputs that were added by the wallet.
+func (api *API) walletBuilderSignHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	wholeTransaction := true
+	if req.FormValue("wholetransaction") != "" {
+		var err error
+		wholeTransaction, err = scanBool(req.FormValue("wholetransaction"))
+		if err != nil {
+			WriteError(w, Error{"could not read 'wholetransaction': " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	api.withBuilder(w, ps, func(s *builderSession) error {
+		_, err := s.builder.Sign(wholeTransaction)
+		if err != nil {
+			return err
+		}
+		s.signed = true
+		return nil
+	})
+}
+
+// walletBuilderSubmitHandler handles API calls to /wallet/builder/:id/submit,
+// submitting the transaction and its parents to the transaction pool and
+// closing the session.
+func (api *API) walletBuilderSubmitHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	api.withBuilder(w, ps, func(s *builderSession) error {
+		if api.tpool == nil {
+			return errors.New("transaction pool is not loaded")
+		}
+		txnSet, err := s.builder.Export()
+		if err != nil {
+			return err
+		}
+		err = api.tpool.AcceptTransactionSet(txnSet)
+		if err != nil {
+			return err
+		}
+		delete(api.builders.sessions, ps.ByName("id"))
+		return nil
+	})
+}
+
+// walletBuilderDropHandler handles API calls to /wallet/builder/:id/drop,
+// releasing the outputs used by the transaction and closing the session.
+func (api *API) walletBuilderDropHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	api.builders.mu.Lock()
+	defer api.builders.mu.Unlock()
+	id := ps.ByName("id")
+	s, ok := api.builders.sessions[id]
+	if !ok {
+		WriteError(w, Error{"no transaction builder session with id " + id}, http.StatusBadRequest)
+		return
+	}
+	s.builder.Drop()
+	delete(api.builders.sessions, id)
+	WriteSuccess(w)
+}
@@ -0,0 +1,172 @@
+package sync
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// A RateLimit restricts the rate at which a resource is consumed, measured in
+// units per second.
+//
+// Units are consumed via Wait, which blocks until enough units have
+// accumulated to satisfy the request. Units accumulate continuously at the
+// configured rate, up to a burst of one second's worth of units. As with
+// Limiter, a request for more units than the burst size is permitted; the
+// caller simply waits proportionally longer. A limit of zero disables rate
+// limiting entirely.
+type RateLimit struct {
+	limit     int64
+	available float64
+	last      time.Time
+	mu        sync.Mutex
+}
+
+// Wait blocks until n units are available and consumes them. Wait returns
+// true if the request was canceled, and false otherwise. A canceled request
+// does not consume any units.
+func (rl *RateLimit) Wait(n int, cancel <-chan struct{}) bool {
+	for {
+		rl.mu.Lock()
+		if rl.limit <= 0 {
+			rl.mu.Unlock()
+			return false
+		}
+		rl.refill()
+		// A request larger than the burst size is fulfilled once the bucket is
+		// full, putting the bucket into debt. Subsequent callers must wait for
+		// the debt to be repaid.
+		need := float64(n)
+		if burst := float64(rl.limit); need > burst {
+			need = burst
+		}
+		if rl.available >= need {
+			rl.available -= float64(n)
+			rl.mu.Unlock()
+			return false
+		}
+		wait := time.Duration((need - rl.available) / float64(rl.limit) * float64(time.Second))
+		rl.mu.Unlock()
+
+		select {
+		case <-cancel:
+			return true
+		case <-time.After(wait):
+		}
+	}
+}
+
+// TryWait consumes n units if they are available, and returns whether they
+// were. Unlike Wait, TryWait never blocks, so that a caller with other work
+// can do it while the units accumulate. As with Wait, a request larger than
+// the burst size is fulfilled once the bucket is full.
+func (rl *RateLimit) TryWait(n int) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.limit <= 0 {
+		return true
+	}
+	rl.refill()
+	need := float64(n)
+	if burst := float64(rl.limit); need > burst {
+		need = burst
+	}
+	if rl.available < need {
+		return false
+	}
+	rl.available -= float64(n)
+	return true
+}
+
+// refill adds the units that have accumulated since the last refill. refill
+// must be called while holding the lock.
+func (rl *RateLimit) refill() {
+	now := time.Now()
+	rl.available += now.Sub(rl.last).Seconds() * float64(rl.limit)
+	if burst := float64(rl.limit); rl.available > burst {
+		rl.available = burst
+	}
+	rl.last = now
+}
+
+// Limit returns the current limit of rl in units per second.
+func (rl *RateLimit) Limit() int64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.limit
+}
+
+// SetLimit sets the limit of rl in units per second. A limit of zero disables
+// rate limiting. Callers blocked in Wait observe the new limit the next time
+// they wake.
+func (rl *RateLimit) SetLimit(limit int64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if limit < 0 {
+		limit = 0
+	}
+	rl.limit = limit
+	rl.available = float64(limit)
+	rl.last = time.Now()
+}
+
+// AdjustLimit changes the limit of rl without resetting it. Unlike SetLimit,
+// which makes a full burst available, the units that accumulated at the old
+// limit are kept, up to the burst size of the new limit, and so is any debt.
+// This allows the limit to be changed frequently without granting a new burst
+// each time. Adjusting a limit from or to zero behaves like SetLimit.
+func (rl *RateLimit) AdjustLimit(limit int64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if limit < 0 {
+		limit = 0
+	}
+	if rl.limit <= 0 || limit == 0 {
+		rl.limit = limit
+		rl.available = float64(limit)
+		rl.last = time.Now()
+		return
+	}
+	rl.refill()
+	rl.limit = limit
+	if burst := float64(limit); rl.available > burst {
+		rl.available = burst
+	}
+}
+
+// Debt returns the number of units that rl owes because a request larger
+// than the available units was fulfilled. A RateLimit that is not limiting
+// owes nothing.
+func (rl *RateLimit) Debt() int64 {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.limit <= 0 {
+		return 0
+	}
+	rl.refill()
+	if rl.available >= 0 {
+		return 0
+	}
+	return int64(math.Ceil(-rl.available))
+}
+
+// Charge consumes n units without waiting, putting rl into debt if fewer than
+// n units are available. Subsequent callers of Wait must wait for the debt to
+// be repaid. Charge has no effect if rate limiting is disabled.
+func (rl *RateLimit) Charge(n int64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.limit <= 0 {
+		return
+	}
+	rl.refill()
+	rl.available -= float64(n)
+}
+
+// NewRateLimit returns a RateLimit with the supplied limit in units per
+// second. A limit of zero disables rate limiting.
+func NewRateLimit(limit int64) *RateLimit {
+	rl := new(RateLimit)
+	rl.SetLimit(limit)
+	return rl
+}
@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	// a limit of zero should never block
+	rl := NewRateLimit(0)
+	if rl.Wait(1e9, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected unlimited Wait to succeed")
+	}
+
+	// the initial burst should be available immediately
+	rl.SetLimit(1000)
+	if rl.Limit() != 1000 {
+		t.Fatal("wrong limit:", rl.Limit())
+	}
+	if rl.Wait(1000, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected burst to succeed")
+	}
+
+	// once the burst has been spent, Wait should block
+	if !rl.Wait(500, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected Wait to be cancelled")
+	}
+
+	// after enough time has passed, Wait should succeed
+	if rl.Wait(100, cancelAfter(time.Second)) {
+		t.Fatal("expected Wait to succeed")
+	}
+
+	// requesting more than the limit should succeed once the bucket is full,
+	// and put the bucket into debt
+	rl.SetLimit(100)
+	if rl.Wait(300, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected oversized Wait to succeed")
+	}
+	if !rl.Wait(1, cancelAfter(100*time.Millisecond)) {
+		t.Fatal("expected Wait to be cancelled while in debt")
+	}
+
+	// removing the limit should unblock future callers
+	rl.SetLimit(0)
+	if rl.Wait(1, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected unlimited Wait to succeed")
+	}
+}
+
+// TestRateLimitAdjust checks that AdjustLimit does not grant a new burst.
+func TestRateLimitAdjust(t *testing.T) {
+	// adjusting an unlimited RateLimit makes the burst available
+	rl := NewRateLimit(0)
+	rl.AdjustLimit(1000)
+	if rl.Limit() != 1000 {
+		t.Fatal("wrong limit:", rl.Limit())
+	}
+	if rl.Wait(1000, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected burst to succeed")
+	}
+
+	// once the burst has been spent, adjusting the limit should not refill
+	// the bucket
+	rl.AdjustLimit(2000)
+	if !rl.Wait(500, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected Wait to be cancelled")
+	}
+
+	// lowering the limit caps the accumulated units at the new burst size
+	time.Sleep(100 * time.Millisecond)
+	rl.AdjustLimit(10)
+	if rl.Wait(10, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected Wait within the new burst to succeed")
+	}
+	if !rl.Wait(10, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected Wait beyond the new burst to be cancelled")
+	}
+
+	// adjusting the limit to zero disables rate limiting
+	rl.AdjustLimit(0)
+	if rl.Wait(1e9, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected unlimited Wait to succeed")
+	}
+}
+
+// TestRateLimitDebt checks that Debt reports the units owed after a large
+// request, and that Charge puts a RateLimit into debt.
+func TestRateLimitDebt(t *testing.T) {
+	// an unlimited RateLimit never owes anything
+	rl := NewRateLimit(0)
+	rl.Charge(1000)
+	if rl.Debt() != 0 {
+		t.Fatal("unlimited RateLimit should not be in debt:", rl.Debt())
+	}
+
+	// a request larger than the burst leaves the difference as debt
+	rl.SetLimit(100)
+	if rl.Wait(1000, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected request larger than the burst to succeed")
+	}
+	if debt := rl.Debt(); debt < 800 || debt > 900 {
+		t.Fatal("wrong debt:", debt)
+	}
+
+	// charging a RateLimit blocks subsequent callers
+	rl.SetLimit(1000)
+	rl.Charge(2000)
+	if debt := rl.Debt(); debt < 900 || debt > 1000 {
+		t.Fatal("wrong debt:", debt)
+	}
+	if !rl.Wait(1, cancelAfter(10*time.Millisecond)) {
+		t.Fatal("expected Wait to be cancelled while in debt")
+	}
+}
+
+// TestRateLimitTryWait checks that TryWait consumes units only when they are
+// available, without blocking.
+func TestRateLimitTryWait(t *testing.T) {
+	// an unlimited RateLimit always has units available
+	rl := NewRateLimit(0)
+	if !rl.TryWait(1000) {
+		t.Fatal("expected unlimited RateLimit to grant the request")
+	}
+
+	// a full bucket grants a request larger than the burst, and the debt
+	// refuses the next request
+	rl.SetLimit(100)
+	if !rl.TryWait(1000) {
+		t.Fatal("expected request larger than the burst to succeed")
+	}
+	if rl.TryWait(1) {
+		t.Fatal("expected request to be refused while in debt")
+	}
+	if debt := rl.Debt(); debt < 800 || debt > 900 {
+		t.Fatal("a refused request should not consume units:", debt)
+	}
+}
@@ -13,6 +13,13 @@ import (
 )
 
 var (
+	// ArbitraryDataSizeLimit is the maximum combined size of the
+	// ArbitraryData entries of a single transaction. It is enforced from the
+	// ArbitraryDataHardforkBlock onwards, so that blocks accepted before the
+	// limit was introduced remain valid.
+	ArbitraryDataHardforkBlock BlockHeight
+	ArbitraryDataSizeLimit     uint64
+
 	BlockFrequency         BlockHeight
 	BlockSizeLimit         = uint64(2e6)
 	ExtremeFutureThreshold Timestamp
@@ -43,12 +50,21 @@ var (
 	OakMaxDrop              *big.Rat
 	OakMaxRise              *big.Rat
 
+	// RepeatOutputHardforkBlock is the height from which a transaction may
+	// not create a siacoin output whose ID is already in the consensus set.
+	RepeatOutputHardforkBlock BlockHeight
+
 	RootDepth        = Target{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}
 	RootTarget       Target
 	SiacoinPrecision = NewCurrency(new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil))
 	SiafundCount     = NewCurrency64(10000)
 	SiafundPortion   = big.NewRat(39, 1000)
 	TargetWindow     BlockHeight
+
+	// TieBreakHardforkBlock is the height from which a block that is exactly
+	// as heavy as the current block replaces it if its ID is numerically
+	// smaller, so that all nodes settle on the same fork when two forks tie.
+	TieBreakHardforkBlock BlockHeight
 )
 
 // init checks which build constant is in place and initializes the variables
@@ -73,6 +89,9 @@ func init() {
 
 		MinimumCoinbase = 30e3
 
+		ArbitraryDataHardforkBlock = 200
+		ArbitraryDataSizeLimit = 16e3
+
 		OakHardforkBlock = 100
 		OakHardforkFixBlock = 105
 		OakDecayNum = 985
@@ -81,6 +100,9 @@ func init() {
 		OakMaxRise = big.NewRat(102, 100)
 		OakMaxDrop = big.NewRat(100, 102)
 
+		TieBreakHardforkBlock = 200
+		RepeatOutputHardforkBlock = 200
+
 		GenesisSiafundAllocation = []SiafundOutput{
 			{
 				Value:      NewCurrency64(2000),
@@ -115,6 +137,9 @@ func init() {
 
 		MinimumCoinbase = 299990 // Minimum coinbase is hit after 10 blocks to make testing minimum-coinbase code easier.
 
+		ArbitraryDataHardforkBlock = 20
+		ArbitraryDataSizeLimit = 16e3 // Leaves room for announcement-sized payloads and the transaction pool tests' filler.
+
 		// Do not let the difficulty change rapidly - blocks will be getting
 		// mined far faster than the difficulty can adjust to.
 		OakHardforkBlock = 20
@@ -125,6 +150,9 @@ func init() {
 		OakMaxRise = big.NewRat(10001, 10e3)
 		OakMaxDrop = big.NewRat(10e3, 10001)
 
+		TieBreakHardforkBlock = 10
+		RepeatOutputHardforkBlock = 10
+
 		GenesisSiafundAllocation = []SiafundOutput{
 			{
 				Value:      NewCurrency64(2000),
@@ -215,6 +243,16 @@ func init() {
 		OakHardforkBlock = 135e3
 		OakHardforkFixBlock = 139e3
 
+		// The arbitrary data of a transaction is limited to 16 KB from block
+		// 180,000. Host announcements need only a few hundred bytes, which
+		// leaves plenty of room for other legitimate uses, while a single
+		// transaction can no longer fill most of the 64 KB allowed by
+		// OakHardforkTxnSizeLimit with arbitrary data. Transactions already
+		// on the blockchain may carry more, so the limit cannot apply to
+		// earlier blocks.
+		ArbitraryDataHardforkBlock = 180e3
+		ArbitraryDataSizeLimit = 16e3
+
 		// The decay is kept at 995/1000, or a decay of about 0.5% each block.
 		// This puts the halflife of a block's relevance at about 1 day. This
 		// allows the difficulty to adjust rapidly if the hashrate is adjusting
@@ -239,6 +277,17 @@ func init() {
 		OakMaxRise = big.NewRat(1004, 1e3)
 		OakMaxDrop = big.NewRat(1e3, 1004)
 
+		// Ties between equally heavy forks are broken by block ID from block
+		// 180,000. Nodes that have not upgraded by then stay on whichever
+		// fork they saw first, so the height leaves several months for the
+		// network to adopt the rule, as was done for the Oak hardfork.
+		TieBreakHardforkBlock = 180e3
+
+		// Transactions may not recreate an existing siacoin output from block
+		// 180,000. Blocks that do so were valid before the hardfork, so the
+		// rule cannot be applied to the existing blockchain.
+		RepeatOutputHardforkBlock = 180e3
+
 		GenesisSiafundAllocation = []SiafundOutput{
 			{
 				Value:      NewCurrency64(2),
@@ -13,12 +13,13 @@ import (
 	"math/big"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
 )
 
 type (
 	// A Currency represents a number of siacoins or siafunds. Internally, a
 	// Currency value is unbounded; however, Currency values sent over the wire
-	// protocol are subject to a maximum size of 255 bytes (approximately 10^614).
+	// protocol are subject to a maximum size of maxCurrencyLen bytes.
 	// Unlike the math/big library, whose methods modify their receiver, all
 	// arithmetic Currency methods return a new value. Currency cannot be negative.
 	Currency struct {
@@ -26,7 +27,22 @@ type (
 	}
 )
 
+const (
+	// maxCurrencyLen is the maximum number of bytes that a Currency value may
+	// occupy when it is sent over the wire. A Currency is encoded as a
+	// length-prefixed byte slice holding its big-endian value, and
+	// UnmarshalSia reads it with decHelper.ReadPrefix, which rejects any
+	// byte slice longer than encoding.MaxSliceSize. A longer value could be
+	// encoded, but no peer could decode it.
+	maxCurrencyLen = encoding.MaxSliceSize
+)
+
 var (
+	// ErrCurrencyOverflow is the error that is returned if performing an
+	// operation results in a currency that is too large to be sent over the
+	// wire.
+	ErrCurrencyOverflow = errors.New("currency overflow: value is too large to be encoded")
+
 	// ErrNegativeCurrency is the error that is returned if performing an
 	// operation results in a negative currency.
 	ErrNegativeCurrency = errors.New("negative currency not allowed")
@@ -62,6 +78,16 @@ func (x Currency) Add(y Currency) (c Currency) {
 	return
 }
 
+// AddChecked returns a new Currency value c = x + y. Unlike Add, an error is
+// returned if the result is too large to be sent over the wire.
+func (x Currency) AddChecked(y Currency) (c Currency, err error) {
+	c.i.Add(&x.i, &y.i)
+	if c.i.BitLen() > maxCurrencyLen*8 {
+		return Currency{}, ErrCurrencyOverflow
+	}
+	return c, nil
+}
+
 // Big returns the value of c as a *big.Int. Importantly, it does not provide
 // access to the c's internal big.Int object, only a copy.
 func (c Currency) Big() *big.Int {
@@ -183,6 +209,17 @@ func (x Currency) Sub(y Currency) (c Currency) {
 	return
 }
 
+// SubChecked returns a new Currency value c = x - y. Unlike Sub, an error is
+// returned when x < y instead of triggering a build.Critical, so it is safe
+// to use on values that may be determined by users.
+func (x Currency) SubChecked(y Currency) (c Currency, err error) {
+	if x.Cmp(y) < 0 {
+		return Currency{}, ErrNegativeCurrency
+	}
+	c.i.Sub(&x.i, &y.i)
+	return c, nil
+}
+
 // Uint64 converts a Currency to a uint64. An error is returned because this
 // function is sometimes called on values that can be determined by users -
 // rather than have all user-facing points do input checking, the input
@@ -4,6 +4,8 @@ import (
 	"math"
 	"math/big"
 	"testing"
+
+	"github.com/NebulousLabs/Sia/encoding"
 )
 
 // TestNewCurrency initializes a standard new currency.
@@ -246,6 +248,63 @@ func TestCurrencySub(t *testing.T) {
 	}
 }
 
+// TestCurrencyAddChecked probes the AddChecked function of the currency type
+// at the largest value that can be encoded.
+func TestCurrencyAddChecked(t *testing.T) {
+	c, err := NewCurrency64(3).AddChecked(NewCurrency64(13))
+	if err != nil || !c.Equals64(16) {
+		t.Error("3 plus 13 should equal 16, got", c, err)
+	}
+
+	// The largest currency that can be encoded occupies maxCurrencyLen bytes,
+	// plus an 8 byte length prefix.
+	max := NewCurrency(new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), maxCurrencyLen*8), big.NewInt(1)))
+	if max.MarshalSiaSize() != maxCurrencyLen+8 {
+		t.Fatal("max currency has the wrong encoded size:", max.MarshalSiaSize())
+	}
+	if c, err := max.AddChecked(ZeroCurrency); err != nil || !c.Equals(max) {
+		t.Error("adding zero to the max currency should succeed:", err)
+	}
+	if _, err := max.AddChecked(NewCurrency64(1)); err != ErrCurrencyOverflow {
+		t.Error("expected ErrCurrencyOverflow, got", err)
+	}
+	if _, err := NewCurrency64(1).AddChecked(max); err != ErrCurrencyOverflow {
+		t.Error("expected ErrCurrencyOverflow, got", err)
+	}
+
+	// The max currency can be decoded, but a currency one byte longer cannot.
+	var decoded Currency
+	if err := encoding.Unmarshal(encoding.Marshal(max), &decoded); err != nil || !decoded.Equals(max) {
+		t.Error("max currency could not be decoded:", err)
+	}
+	if err := encoding.Unmarshal(encoding.Marshal(max.Add(NewCurrency64(1))), &decoded); err == nil {
+		t.Error("currency longer than maxCurrencyLen should not decode")
+	}
+}
+
+// TestCurrencySubChecked probes the SubChecked function of the currency type,
+// which should return an error rather than panic on underflow.
+func TestCurrencySubChecked(t *testing.T) {
+	c3 := NewCurrency64(3)
+	c16 := NewCurrency64(16)
+	if c, err := c16.SubChecked(c3); err != nil || !c.Equals64(13) {
+		t.Error("16 minus 3 should equal 13, got", c, err)
+	}
+	if c, err := c16.SubChecked(c16); err != nil || !c.IsZero() {
+		t.Error("16 minus 16 should equal 0, got", c, err)
+	}
+	if _, err := c3.SubChecked(c16); err != ErrNegativeCurrency {
+		t.Error("expected ErrNegativeCurrency, got", err)
+	}
+	if _, err := ZeroCurrency.SubChecked(NewCurrency64(1)); err != ErrNegativeCurrency {
+		t.Error("expected ErrNegativeCurrency, got", err)
+	}
+	// The operands should be unchanged.
+	if !c3.Equals64(3) || !c16.Equals64(16) {
+		t.Error("SubChecked modified its operands")
+	}
+}
+
 // TestNegativeCurrencyMulRat checks that negative numbers are rejected when
 // calling MulRat on the currency type.
 func TestNegativeCurrencyMulRat(t *testing.T) {
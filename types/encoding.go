@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -16,6 +17,12 @@ import (
 	"github.com/NebulousLabs/Sia/encoding"
 )
 
+var (
+	// ErrJSONIDMismatch is returned when a JSON-encoded block or transaction
+	// contains an id that does not match the decoded object.
+	ErrJSONIDMismatch = errors.New("id of json object does not match its contents")
+)
+
 // sanityCheckWriter checks that the bytes written to w exactly match the
 // bytes in buf.
 type sanityCheckWriter struct {
@@ -270,6 +277,33 @@ func (b *Block) UnmarshalSia(r io.Reader) error {
 	return d.Err()
 }
 
+// MarshalJSON marshals a block, including the id of the block as a hex string.
+func (b Block) MarshalJSON() ([]byte, error) {
+	type jsonBlock Block // prevents infinite recursion
+	return json.Marshal(struct {
+		ID BlockID `json:"id"`
+		jsonBlock
+	}{b.ID(), jsonBlock(b)})
+}
+
+// UnmarshalJSON decodes a json block. If the json contains an id, it must
+// match the id of the decoded block.
+func (b *Block) UnmarshalJSON(buf []byte) error {
+	type jsonBlock Block // prevents infinite recursion
+	var jb struct {
+		ID BlockID `json:"id"`
+		jsonBlock
+	}
+	if err := json.Unmarshal(buf, &jb); err != nil {
+		return err
+	}
+	*b = Block(jb.jsonBlock)
+	if jb.ID != (BlockID{}) && jb.ID != b.ID() {
+		return ErrJSONIDMismatch
+	}
+	return nil
+}
+
 // MarshalJSON marshales a block id as a hex string.
 func (bid BlockID) MarshalJSON() ([]byte, error) {
 	return json.Marshal(bid.String())
@@ -1027,6 +1061,34 @@ func (t *Transaction) UnmarshalSia(r io.Reader) error {
 	return d.Err()
 }
 
+// MarshalJSON marshals a transaction, including the id of the transaction as a
+// hex string.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type jsonTransaction Transaction // prevents infinite recursion
+	return json.Marshal(struct {
+		ID TransactionID `json:"id"`
+		jsonTransaction
+	}{t.ID(), jsonTransaction(t)})
+}
+
+// UnmarshalJSON decodes a json transaction. If the json contains an id, it
+// must match the id of the decoded transaction.
+func (t *Transaction) UnmarshalJSON(b []byte) error {
+	type jsonTransaction Transaction // prevents infinite recursion
+	var jt struct {
+		ID TransactionID `json:"id"`
+		jsonTransaction
+	}
+	if err := json.Unmarshal(b, &jt); err != nil {
+		return err
+	}
+	*t = Transaction(jt.jsonTransaction)
+	if jt.ID != (TransactionID{}) && jt.ID != t.ID() {
+		return ErrJSONIDMismatch
+	}
+	return nil
+}
+
 // MarshalJSON marshals an id as a hex string.
 func (tid TransactionID) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tid.String())
@@ -136,6 +136,54 @@ func TestBlockEncoding(t *testing.T) {
 	}
 }
 
+// TestBlockMarshalJSON checks that blocks and transactions survive a round
+// trip through JSON, and that the id included in the JSON is checked when
+// decoding.
+func TestBlockMarshalJSON(t *testing.T) {
+	b := heavyBlock
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890123456789", 10)
+	b.MinerPayouts = []SiacoinOutput{{Value: NewCurrency(huge)}}
+
+	js, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(js), `"id":"`+b.ID().String()+`"`) {
+		t.Fatal("block json does not contain the block id")
+	}
+	if !strings.Contains(string(js), `"id":"`+b.Transactions[0].ID().String()+`"`) {
+		t.Fatal("block json does not contain the transaction id")
+	}
+	if !strings.Contains(string(js), `"`+huge.String()+`"`) {
+		t.Fatal("currency was not encoded as a decimal string")
+	}
+	var decB Block
+	if err := json.Unmarshal(js, &decB); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoding.Marshal(b), encoding.Marshal(decB)) {
+		t.Fatal("block changed after json encode/decode")
+	}
+	if decB.MinerPayouts[0].Value.Big().Cmp(huge) != 0 {
+		t.Fatal("currency lost precision after json encode/decode")
+	}
+
+	// Decoding should fail if the id does not match the contents.
+	tampered := strings.Replace(string(js), b.ID().String(), BlockID{1}.String(), 1)
+	if err := json.Unmarshal([]byte(tampered), &decB); err != ErrJSONIDMismatch {
+		t.Fatal("expected ErrJSONIDMismatch, got", err)
+	}
+
+	// JSON without an id should still be accepted.
+	var txn Transaction
+	if err := json.Unmarshal([]byte(`{"minerfees":["10"]}`), &txn); err != nil {
+		t.Fatal(err)
+	}
+	if len(txn.MinerFees) != 1 || !txn.MinerFees[0].Equals64(10) {
+		t.Fatal("transaction was decoded incorrectly:", txn)
+	}
+}
+
 // TestBadBlock tests that a known invalid encoding is not successfully
 // decoded.
 func TestBadBlock(t *testing.T) {
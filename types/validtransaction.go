@@ -10,6 +10,7 @@ import (
 )
 
 var (
+	ErrArbitraryDataTooLarge            = errors.New("transaction arbitrary data exceeds the size limit")
 	ErrDoubleSpend                      = errors.New("transaction uses a parent object twice")
 	ErrFileContractOutputSumViolation   = errors.New("file contract has invalid output sums")
 	ErrFileContractWindowEndViolation   = errors.New("file contract window must end at least one block after it starts")
@@ -122,6 +123,23 @@ func (t Transaction) fitsInABlock(currentHeight BlockHeight) error {
 	return nil
 }
 
+// fitsArbitraryDataLimit checks that the combined size of the arbitrary data
+// of the transaction does not exceed ArbitraryDataSizeLimit. The limit only
+// applies from the ArbitraryDataHardforkBlock onwards.
+func (t Transaction) fitsArbitraryDataLimit(currentHeight BlockHeight) error {
+	if currentHeight < ArbitraryDataHardforkBlock {
+		return nil
+	}
+	var size uint64
+	for _, arb := range t.ArbitraryData {
+		size += uint64(len(arb))
+	}
+	if size > ArbitraryDataSizeLimit {
+		return ErrArbitraryDataTooLarge
+	}
+	return nil
+}
+
 // followsMinimumValues checks that all outputs adhere to the rules for the
 // minimum allowed value (generally 1).
 func (t Transaction) followsMinimumValues() error {
@@ -273,6 +291,10 @@ func (t Transaction) StandaloneValid(currentHeight BlockHeight) (err error) {
 	if err != nil {
 		return
 	}
+	err = t.fitsArbitraryDataLimit(currentHeight)
+	if err != nil {
+		return
+	}
 	err = t.followsStorageProofRules()
 	if err != nil {
 		return
@@ -166,6 +166,36 @@ func TestTransactionFitsInABlock(t *testing.T) {
 	}
 }
 
+// TestTransactionFitsArbitraryDataLimit probes the fitsArbitraryDataLimit
+// method of the Transaction type.
+func TestTransactionFitsArbitraryDataLimit(t *testing.T) {
+	// The limit must be stricter than the transaction size limit, or it would
+	// have no effect.
+	if ArbitraryDataSizeLimit >= OakHardforkTxnSizeLimit {
+		t.Error("arbitrary data limit is not below the transaction size limit")
+	}
+
+	// Arbitrary data up to the limit is allowed, even when split across
+	// several entries.
+	half := make([]byte, ArbitraryDataSizeLimit/2)
+	txn := Transaction{ArbitraryData: [][]byte{half, half}}
+	if err := txn.fitsArbitraryDataLimit(ArbitraryDataHardforkBlock); err != nil {
+		t.Error(err)
+	}
+
+	// One byte more is rejected after the hardfork height, but not before.
+	txn.ArbitraryData = append(txn.ArbitraryData, []byte{0})
+	if err := txn.fitsArbitraryDataLimit(ArbitraryDataHardforkBlock); err != ErrArbitraryDataTooLarge {
+		t.Error("expected ErrArbitraryDataTooLarge, got", err)
+	}
+	if err := txn.fitsArbitraryDataLimit(ArbitraryDataHardforkBlock - 1); err != nil {
+		t.Error(err)
+	}
+	if err := txn.StandaloneValid(ArbitraryDataHardforkBlock); err != ErrArbitraryDataTooLarge {
+		t.Error("StandaloneValid did not enforce the arbitrary data limit:", err)
+	}
+}
+
 // TestTransactionFollowsMinimumValues probes the followsMinimumValues method
 // of the Transaction type.
 func TestTransactionFollowsMinimumValues(t *testing.T) {